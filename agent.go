@@ -0,0 +1,216 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+const (
+	AgentOpenSSH = "openssh" // ssh-agent, as found via SSH_AUTH_SOCK
+	AgentPageant = "pageant" // PuTTY's agent, Windows only
+)
+
+var (
+	ErrUnknownAgent       = errors.New("Unknown agent type.")
+	ErrPageantUnsupported = errors.New("Pageant is only supported on Windows.")
+	ErrPageantFailed      = errors.New("Pageant request failed.")
+)
+
+// Returns the agents to add keys to, per config.Agents, defaulting to just ssh-agent.
+func configuredAgents(config *ClientAppConfiguration) []string {
+	var rv []string
+	for _, a := range strings.Split(config.Agents, ",") {
+		a = strings.TrimSpace(a)
+		if len(a) > 0 {
+			rv = append(rv, a)
+		}
+	}
+	if len(rv) == 0 {
+		rv = []string{AgentOpenSSH}
+	}
+	return rv
+}
+
+// Pageant is queried one whole message at a time via window messages,
+// so we buffer up each request and the response to present a stream to agent.NewClient.
+type pageantConn struct {
+	req  []byte
+	resp bytes.Buffer
+}
+
+func (c *pageantConn) Write(p []byte) (int, error) {
+	c.req = append(c.req, p...)
+	for len(c.req) >= 4 {
+		l := int(binary.BigEndian.Uint32(c.req)) + 4
+		if len(c.req) < l {
+			break
+		}
+		resp, err := pageantQuery(c.req[:l])
+		if err != nil {
+			return 0, err
+		}
+		c.req = c.req[l:]
+		c.resp.Write(resp)
+	}
+	return len(p), nil
+}
+
+func (c *pageantConn) Read(p []byte) (int, error) {
+	return c.resp.Read(p)
+}
+
+func (c *pageantConn) Close() error {
+	return nil
+}
+
+// Connect to the named agent. Returns nil if that agent is not running.
+func dialAgent(ctx context.Context, config *ClientAppConfiguration, name string) (io.ReadWriteCloser, error) {
+	switch name {
+	case AgentOpenSSH:
+		authSock := os.Getenv("SSH_AUTH_SOCK")
+		if len(authSock) == 0 && isWSL() {
+			conn, err := dialWSLAgent(ctx, config)
+			if errors.Is(err, ErrNoNPipeRelay) {
+				logInfo("Running under WSL with no SSH_AUTH_SOCK or npiperelay.exe, so the key and certificate have not been added to an agent. ssh will load them from the files written instead.")
+				return nil, nil
+			}
+			logVerbose("WSL detected, adding certificate to the Windows agent via npiperelay.exe.")
+			return conn, err
+		}
+		if len(authSock) == 0 {
+			return nil, nil
+		}
+		logVerbose("SSH_AUTH_SOCK detected, adding certificate to ssh-agent.")
+		conn, err := (&net.Dialer{}).DialContext(ctx, "unix", authSock)
+		if err != nil {
+			return nil, fmt.Errorf("%w (%w)", ErrAgentUnavailable, err)
+		}
+		return conn, nil
+	case AgentPageant:
+		if !pageantRunning() {
+			return nil, nil
+		}
+		logVerbose("Pageant detected, adding certificate to Pageant.")
+		return &pageantConn{}, nil
+	default:
+		return nil, ErrUnknownAgent
+	}
+}
+
+// Returns true if any of the configured agents is running.
+func agentRunning(ctx context.Context, config *ClientAppConfiguration) bool {
+	for _, name := range configuredAgents(config) {
+		conn, err := dialAgent(ctx, config, name)
+		if err == nil && conn != nil {
+			conn.Close()
+			return true
+		}
+	}
+	return false
+}
+
+// Add the key and certificate to each configured agent that is running, to expire with the certificate.
+func AddToAgents(ctx context.Context, config *ClientAppConfiguration, privateKey *rsa.PrivateKey, certificate string) error {
+	pk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(certificate))
+	if err != nil {
+		return err
+	}
+	cert, ok := pk.(*ssh.Certificate)
+	if !ok {
+		return ErrWrongCertType
+	}
+
+	for _, name := range configuredAgents(config) {
+		conn, err := dialAgent(ctx, config, name)
+		if err != nil {
+			return err
+		}
+		if conn == nil {
+			continue
+		}
+
+		ttl := int64(cert.ValidBefore) - time.Now().Unix()
+		logVerbose("Certificate will be added with TTL of %d seconds.", ttl)
+
+		client := agent.NewClient(conn)
+		err = client.Add(agent.AddedKey{
+			PrivateKey:   privateKey,
+			Certificate:  cert,
+			LifetimeSecs: uint32(ttl),
+		})
+		if err != nil && name == AgentOpenSSH && isGPGAgent(os.Getenv("SSH_AUTH_SOCK")) {
+			err = addToGPGAgent(config, client, privateKey, ttl)
+		}
+		if err != nil && name == AgentOpenSSH && isWSL() {
+			logWarning("The Windows agent rejected the key with a lifetime, adding without. The expired certificate will remain in the agent until removed with ssh-add -D.")
+			err = client.Add(agent.AddedKey{
+				PrivateKey:  privateKey,
+				Certificate: cert,
+			})
+		}
+		if err != nil && name == AgentOpenSSH && isAppleLaunchdAgent(os.Getenv("SSH_AUTH_SOCK")) {
+			logWarning("The macOS ssh-agent rejected the key with a lifetime, adding without. The expired certificate will remain in the agent until removed with ssh-add -D or you log out.")
+			err = client.Add(agent.AddedKey{
+				PrivateKey:  privateKey,
+				Certificate: cert,
+			})
+		}
+		conn.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Returns true if authSock is gpg-agent running with --enable-ssh-support.
+func isGPGAgent(authSock string) bool {
+	return filepath.Base(authSock) == "S.gpg-agent.ssh"
+}
+
+// gpg-agent rejects keys with certificates. Either add the plain key if configured to,
+// (ssh will still find the certificate file next to the key) or rely on the files alone.
+func addToGPGAgent(config *ClientAppConfiguration, client agent.Agent, privateKey *rsa.PrivateKey, ttl int64) error {
+	if config.GPGAgentAddPlainKey {
+		logInfo("gpg-agent does not accept certificates, adding key without certificate. gpg-agent may prompt for a passphrase to protect it.")
+		return client.Add(agent.AddedKey{
+			PrivateKey:   privateKey,
+			LifetimeSecs: uint32(ttl),
+		})
+	}
+
+	logInfo("gpg-agent does not accept certificates, so the key and certificate have not been added to it. ssh will load them from the files written instead.")
+	return nil
+}
@@ -0,0 +1,183 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	pb "github.com/continusec/geecert/sso"
+)
+
+const (
+	approvalPollInterval = 10 * time.Second
+	approvalWaitTimeout  = time.Hour
+)
+
+var (
+	ErrApprovalTimeout = errors.New("Timed out waiting for approval.")
+)
+
+// Polls the server until a request that needs approval is approved (returning the response
+// with the certificate) or denied.
+func waitForApproval(ctx context.Context, config *ClientAppConfiguration, client pb.GeeCertServerClient, req *pb.SSHCertsRequest, resp *pb.SSHCertsResponse) (*pb.SSHCertsResponse, error) {
+	logInfo("Certificate requires approval by another user. Ask an approver to run: approve %s", resp.ApprovalId)
+	logInfo("Waiting for approval...")
+
+	deadline := time.Now().Add(approvalWaitTimeout)
+	for resp.Status == pb.ResponseCode_APPROVAL_PENDING {
+		if time.Now().After(deadline) {
+			return nil, ErrApprovalTimeout
+		}
+		select {
+		case <-time.After(approvalPollInterval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		// Our ID token may expire while we wait
+		idToken, _, err := GetValidIDToken(ctx, config)
+		if err != nil {
+			return nil, err
+		}
+
+		retry := *req
+		retry.IdToken = idToken
+		retry.ApprovalId = resp.ApprovalId
+		resp, err = client.GetSSHCerts(ctx, &retry)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.Status != pb.ResponseCode_OK {
+		return nil, responseCodeError(resp.Status)
+	}
+
+	logInfo("Request approved.")
+	return resp, nil
+}
+
+// approvals
+func approvalsCommand(ctx context.Context, config *ClientAppConfiguration, args []string) error {
+	if len(args) != 0 {
+		return ErrUsage
+	}
+
+	approvals, err := ListApprovals(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	if len(approvals) == 0 {
+		fmt.Println("No requests awaiting approval.")
+		return nil
+	}
+
+	for _, a := range approvals {
+		fmt.Printf("ID:          %s\n", a.Id)
+		fmt.Printf("Email:       %s\n", a.Email)
+		fmt.Printf("Principals:  %s\n", strings.Join(a.Principals, ", "))
+		if len(a.OnBehalfOf) > 0 {
+			fmt.Printf("On behalf:   %s\n", a.OnBehalfOf)
+		}
+		fmt.Printf("Fingerprint: %s\n", a.Fingerprint)
+		fmt.Printf("Requested:   %s\n\n", time.Unix(a.RequestedAt, 0).Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// approve <id> or deny <id>
+func decideApprovalCommand(ctx context.Context, config *ClientAppConfiguration, args []string, approve bool) error {
+	if len(args) != 1 {
+		return ErrUsage
+	}
+
+	err := DecideApproval(ctx, config, args[0], approve)
+	if err != nil {
+		return err
+	}
+
+	if approve {
+		fmt.Println("Approved.")
+	} else {
+		fmt.Println("Denied.")
+	}
+	return nil
+}
+
+// Returns requests awaiting approval. Requires that our user is an approver on the server.
+func ListApprovals(ctx context.Context, config *ClientAppConfiguration) ([]*pb.ApprovalRecord, error) {
+	idToken, _, err := GetValidIDToken(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := DialServer(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	resp, err := NewClient(conn).ListApprovals(ctx, &pb.ListApprovalsRequest{
+		IdToken:        idToken,
+		CredentialType: credentialType(config),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Status != pb.ResponseCode_OK {
+		return nil, responseCodeError(resp.Status)
+	}
+	return resp.Approvals, nil
+}
+
+// Approves or denies another user's request. Requires that our user is an approver on the server.
+func DecideApproval(ctx context.Context, config *ClientAppConfiguration, approvalID string, approve bool) error {
+	idToken, _, err := GetValidIDToken(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	conn, err := DialServer(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	resp, err := NewClient(conn).DecideApproval(ctx, &pb.DecideApprovalRequest{
+		IdToken:        idToken,
+		CredentialType: credentialType(config),
+		ApprovalId:     approvalID,
+		Approve:        approve,
+	})
+	if err != nil {
+		return err
+	}
+
+	if resp.Status != pb.ResponseCode_OK {
+		return responseCodeError(resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// Checks a client config file, JSON with the field names of ClientAppConfiguration, and encodes
+// it to be baked in to a client binary at build time, e.g. by bakegeecert with
+// -ldflags "-X main.bakedConfig=...". Unknown fields are an error.
+func EncodeBakedConfig(configJSON []byte) (string, error) {
+	dec := json.NewDecoder(bytes.NewReader(configJSON))
+	dec.DisallowUnknownFields()
+	var config ClientAppConfiguration
+	err := dec.Decode(&config)
+	if err != nil {
+		return "", fmt.Errorf("Parsing client config: %w", err)
+	}
+	var compact bytes.Buffer
+	err = json.Compact(&compact, configJSON)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(compact.Bytes()), nil
+}
+
+// Sets the fields of config that are present in baked, as made by EncodeBakedConfig. Does
+// nothing if baked is empty, i.e. the binary was built without a baked in config.
+func ApplyBakedConfig(baked string, config *ClientAppConfiguration) error {
+	if len(baked) == 0 {
+		return nil
+	}
+	configJSON, err := base64.RawURLEncoding.DecodeString(baked)
+	if err != nil {
+		return fmt.Errorf("Decoding baked in config: %w", err)
+	}
+	err = json.Unmarshal(configJSON, config)
+	if err != nil {
+		return fmt.Errorf("Decoding baked in config: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	pb "github.com/continusec/geecert/sso"
+)
+
+// Bastion policies from the server are saved alongside the key, so that the bastion
+// command works without contacting the server while the certificate is still valid.
+func bastionPoliciesPath(paths *InstallPaths) string {
+	return paths.Key + "-bastions.json"
+}
+
+func saveBastionPolicies(ctx context.Context, paths *InstallPaths, policies []*pb.BastionPolicy) error {
+	data, err := json.Marshal(policies)
+	if err != nil {
+		return err
+	}
+	return SafeSave(ctx, bastionPoliciesPath(paths), data, 0644)
+}
+
+func loadBastionPolicies(paths *InstallPaths) ([]*pb.BastionPolicy, error) {
+	data, err := os.ReadFile(bastionPoliciesPath(paths))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var rv []*pb.BastionPolicy
+	err = json.Unmarshal(data, &rv)
+	if err != nil {
+		return nil, err
+	}
+	return rv, nil
+}
+
+// Matches host against ssh style patterns, where a match on any !negated pattern means no match.
+func matchHostPatterns(patterns []string, host string) bool {
+	matched := false
+	for _, p := range patterns {
+		negated := strings.HasPrefix(p, "!")
+		m, err := filepath.Match(strings.ToLower(strings.TrimPrefix(p, "!")), strings.ToLower(host))
+		if err != nil || !m {
+			continue
+		}
+		if negated {
+			return false
+		}
+		matched = true
+	}
+	return matched
+}
+
+// Returns the chain of jump hosts for the first policy matching host.
+func bastionJumpHosts(policies []*pb.BastionPolicy, host string) []string {
+	for _, p := range policies {
+		if matchHostPatterns(p.HostPattern, host) {
+			return p.JumpHost
+		}
+	}
+	return nil
+}
+
+// bastion [user@]host [ssh args...]
+// Makes sure we have a fresh certificate, then runs ssh via any bastions required for the host.
+func bastionCommand(ctx context.Context, config *ClientAppConfiguration, args []string) error {
+	if len(args) < 1 {
+		return ErrUsage
+	}
+
+	err := EnsureFreshCert(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	paths, err := DefaultInstallPaths(config)
+	if err != nil {
+		return err
+	}
+
+	policies, err := loadBastionPolicies(paths)
+	if err != nil {
+		return err
+	}
+
+	target := args[0]
+	host := target[strings.LastIndex(target, "@")+1:]
+
+	sshArgs := []string{
+		"-o", "IdentitiesOnly=yes",
+		"-i", paths.Key,
+		"-o", "CertificateFile=" + paths.Cert,
+	}
+	jumps := bastionJumpHosts(policies, host)
+	if len(jumps) > 0 {
+		sshArgs = append(sshArgs, "-J", strings.Join(jumps, ","))
+	}
+	sshArgs = append(sshArgs, args...)
+
+	logVerbose("Running: ssh %s", strings.Join(sshArgs, " "))
+	cmd := exec.Command("ssh", sshArgs...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,153 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	pb "github.com/continusec/geecert/sso"
+)
+
+// A key to be certified by IssueBatch, as read by the issue-batch command. Field names are stable.
+type BatchKey struct {
+	Label      string   `json:"label"`      // e.g. a hostname, names the certificate file
+	PublicKey  string   `json:"public_key"` // authorized_keys format
+	Principals []string `json:"principals"`
+}
+
+// A certificate issued by IssueBatch, as output by the issue-batch command. Field names are stable.
+type BatchResult struct {
+	Label       string `json:"label"`
+	Certificate string `json:"certificate"` // authorized_keys format
+	CertPath    string `json:"cert_path,omitempty"`
+	Serial      uint64 `json:"serial"`
+	Fingerprint string `json:"fingerprint"` // of the key, e.g. SHA256:...
+}
+
+// issue-batch <keys.json | -> [-dir <dir>]
+func issueBatchCommand(ctx context.Context, config *ClientAppConfiguration, args []string) error {
+	var dir string
+	switch {
+	case len(args) == 1:
+	case len(args) == 3 && (args[1] == "-dir" || args[1] == "--dir"):
+		dir = args[2]
+	default:
+		return ErrUsage
+	}
+
+	var in io.Reader = os.Stdin
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	var keys []*BatchKey
+	err := json.NewDecoder(in).Decode(&keys)
+	if err != nil {
+		return err
+	}
+
+	results, cas, err := IssueBatch(ctx, config, keys)
+	if err != nil {
+		return err
+	}
+	if len(dir) > 0 {
+		err = saveBatch(ctx, dir, results, cas)
+		if err != nil {
+			return err
+		}
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
+}
+
+// Requests certificates for each of keys in one call, returning them in the same order, along
+// with known_hosts lines for the CA. Requires that our identity is an automation account on
+// the server, see ServerConfig.automation_account.
+func IssueBatch(ctx context.Context, config *ClientAppConfiguration, keys []*BatchKey) ([]*BatchResult, []string, error) {
+	req := &pb.BatchCertsRequest{
+		CredentialType: credentialType(config),
+		Reason:         config.Reason,
+	}
+	for _, k := range keys {
+		req.Certs = append(req.Certs, &pb.BatchCertRequest{
+			Label:      k.Label,
+			PublicKey:  strings.TrimSpace(k.PublicKey),
+			Principals: k.Principals,
+		})
+	}
+
+	idToken, _, err := GetValidIDToken(ctx, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.IdToken = idToken
+
+	conn, err := DialServer(ctx, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer conn.Close()
+
+	resp, err := NewClient(conn).IssueBatch(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.Status != pb.ResponseCode_OK {
+		return nil, nil, responseCodeError(resp.Status)
+	}
+
+	var rv []*BatchResult
+	for _, c := range resp.Certs {
+		rv = append(rv, &BatchResult{
+			Label:       c.Label,
+			Certificate: strings.TrimSpace(c.Certificate),
+			Serial:      c.Serial,
+			Fingerprint: c.Fingerprint,
+		})
+	}
+	return rv, knownHostsLines(resp.HostCertificateAuthorities, resp.CertificateAuthorities), nil
+}
+
+// Writes each certificate to dir/<label>-cert.pub, and the CA to dir/known_hosts.
+func saveBatch(ctx context.Context, dir string, results []*BatchResult, cas []string) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	for _, r := range results {
+		// The server only allows labels that are safe as file names
+		r.CertPath = filepath.Join(dir, filepath.Base(r.Label)+"-cert.pub")
+		err = SafeSave(ctx, r.CertPath, []byte(r.Certificate+"\n"), 0644)
+		if err != nil {
+			return err
+		}
+	}
+	return SafeSave(ctx, filepath.Join(dir, "known_hosts"), []byte(strings.Join(cas, "\n")+"\n"), 0644)
+}
@@ -0,0 +1,115 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Served by the server under the bootstrap URL, see ServerConfig.bootstrap.
+const (
+	BootstrapConfigPath = "/bootstrap/config.json"
+	BootstrapCAKeysPath = "/bootstrap/ca.pub"
+	BootstrapKRLPath    = "/bootstrap/krl"
+)
+
+var ErrBootstrapNotHTTPS = errors.New("BootstrapURL must be an https:// URL.")
+
+// The organization's client configuration, as served at BootstrapConfigPath so that a generic
+// client binary can be configured by URL rather than at build time.
+type BootstrapConfig struct {
+	GRPCServer         string   `json:"grpc_server"`
+	GRPCPEMCertificate string   `json:"grpc_pem_certificate,omitempty"`
+	UseSystemCaForCert bool     `json:"use_system_ca_for_cert,omitempty"`
+	HostedDomain       string   `json:"hosted_domain"`
+	ClientID           string   `json:"client_id"`
+	ClientNotSoSecret  string   `json:"client_not_so_secret"`
+	CredentialFileName string   `json:"credential_file_name,omitempty"`
+	ShortlivedKeyName  string   `json:"shortlived_key_name,omitempty"`
+	SectionIdentifier  string   `json:"section_identifier"`
+	CAKeys             []string `json:"ca_keys"` // authorized_keys format, also served at BootstrapCAKeysPath
+}
+
+// Fetches the BootstrapConfig from config.BootstrapURL.
+func FetchBootstrapConfig(ctx context.Context, config *ClientAppConfiguration) (*BootstrapConfig, error) {
+	ctx = withDialer(ctx, config)
+	if !strings.HasPrefix(config.BootstrapURL, "https://") {
+		return nil, ErrBootstrapNotHTTPS
+	}
+	resp, err := httpGet(ctx, strings.TrimSuffix(config.BootstrapURL, "/")+BootstrapConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("Unexpected status fetching bootstrap config: %s", resp.Status))
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	var rv BootstrapConfig
+	err = json.Unmarshal(body, &rv)
+	if err != nil {
+		return nil, fmt.Errorf("Parsing bootstrap config: %w", err)
+	}
+	return &rv, nil
+}
+
+// If config.BootstrapURL is set, fetches the BootstrapConfig and uses it for the fields of config
+// that are not set, so that those baked in to the binary or set by flags take precedence.
+func ApplyBootstrapConfig(ctx context.Context, config *ClientAppConfiguration) error {
+	if len(config.BootstrapURL) == 0 {
+		return nil
+	}
+	bc, err := FetchBootstrapConfig(ctx, config)
+	if err != nil {
+		return err
+	}
+	logVerbose("Using organization config from %s.", config.BootstrapURL)
+
+	for _, f := range []struct {
+		field *string
+		value string
+	}{
+		{&config.GRPCServer, bc.GRPCServer},
+		{&config.HostedDomain, bc.HostedDomain},
+		{&config.ClientID, bc.ClientID},
+		{&config.ClientNotSoSecret, bc.ClientNotSoSecret},
+		{&config.CredentialFileName, bc.CredentialFileName},
+		{&config.ShortlivedKeyName, bc.ShortlivedKeyName},
+		{&config.SectionIdentifier, bc.SectionIdentifier},
+	} {
+		if len(*f.field) == 0 {
+			*f.field = f.value
+		}
+	}
+	if len(config.GRPCPEMCertificate) == 0 && len(config.GRPCPEMCertificatePath) == 0 && !config.UseSystemCaForCert {
+		config.GRPCPEMCertificate = bc.GRPCPEMCertificate
+		config.UseSystemCaForCert = bc.UseSystemCaForCert
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+
+	pb "github.com/continusec/geecert/sso"
+)
+
+var (
+	ErrNoBreakGlassKey       = errors.New("No break glass key configured.")
+	ErrBreakGlassKeyNotFound = errors.New("Break glass key not found in ssh-agent. Is the hardware token inserted?")
+)
+
+// The data signed by the break glass credential, binding the key to be certified,
+// the justification and the time together.
+func BreakGlassSignedData(req *pb.BreakGlassRequest) []byte {
+	return []byte(fmt.Sprintf("geecert-break-glass-v1\n%s\n%s\n%d", req.PublicKey, req.Justification, req.Timestamp))
+}
+
+// break-glass <justification...>
+func breakGlassCommand(ctx context.Context, config *ClientAppConfiguration, args []string) error {
+	justification := strings.TrimSpace(strings.Join(args, " "))
+	if len(justification) == 0 {
+		return ErrUsage
+	}
+
+	hd, err := homedir.Dir()
+	if err != nil {
+		return err
+	}
+
+	return BreakGlass(ctx, config, justification, filepath.Join(hd, ".ssh"), filepath.Join("~", ".ssh"))
+}
+
+// Finds the signer for the break glass key in ssh-agent, where the hardware token
+// (e.g. a PIV smart card via ssh-add -s, or a FIDO key) makes it available.
+func breakGlassSigner(ctx context.Context, config *ClientAppConfiguration) (ssh.Signer, func(), error) {
+	if len(config.BreakGlassKey) == 0 {
+		return nil, nil, ErrNoBreakGlassKey
+	}
+	want, _, _, _, err := ssh.ParseAuthorizedKey([]byte(config.BreakGlassKey))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	authSock := os.Getenv("SSH_AUTH_SOCK")
+	if len(authSock) == 0 {
+		return nil, nil, ErrBreakGlassKeyNotFound
+	}
+	conn, err := (&net.Dialer{}).DialContext(ctx, "unix", authSock)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w (%w)", ErrAgentUnavailable, err)
+	}
+
+	signers, err := agent.NewClient(conn).Signers()
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	for _, signer := range signers {
+		if bytes.Equal(signer.PublicKey().Marshal(), want.Marshal()) {
+			return signer, func() { conn.Close() }, nil
+		}
+	}
+
+	conn.Close()
+	return nil, nil, ErrBreakGlassKeyNotFound
+}
+
+// Requests a certificate without an ID token, for use when the IdP is unavailable. The server
+// must be running with -enable_break_glass. The justification is recorded by the server.
+// Arguments are otherwise as per FetchCerts.
+func BreakGlass(ctx context.Context, config *ClientAppConfiguration, justification string, sshDir string, homePathToSSHDir string) error {
+	signer, closeAgent, err := breakGlassSigner(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer closeAgent()
+
+	logVerbose("Generating new private key.")
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return err
+	}
+
+	ourPubKey, err := ssh.NewPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		return err
+	}
+	ourPubKeyString := base64.StdEncoding.EncodeToString(ourPubKey.Marshal())
+
+	req := &pb.BreakGlassRequest{
+		PublicKey:     ourPubKeyString,
+		CredentialKey: string(ssh.MarshalAuthorizedKey(signer.PublicKey())),
+		Justification: justification,
+		Timestamp:     time.Now().Unix(),
+	}
+
+	logInfo("Signing break glass request, your hardware token may require a touch or PIN.")
+	var sig *ssh.Signature
+	if as, ok := signer.(ssh.AlgorithmSigner); ok && signer.PublicKey().Type() == ssh.KeyAlgoRSA {
+		sig, err = as.SignWithAlgorithm(rand.Reader, BreakGlassSignedData(req), ssh.KeyAlgoRSASHA512)
+	} else {
+		sig, err = signer.Sign(rand.Reader, BreakGlassSignedData(req))
+	}
+	if err != nil {
+		return err
+	}
+	req.Signature = ssh.Marshal(sig)
+
+	conn, err := DialServer(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	logInfo("Requesting break glass certificate...")
+	resp, err := NewClient(conn).BreakGlassCerts(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if resp.Status != pb.ResponseCode_OK {
+		return responseCodeError(resp.Status)
+	}
+
+	logInfo("Received break glass certificate from server.")
+
+	return installCerts(ctx, config, privateKey, ourPubKeyString, resp, sshDir, homePathToSSHDir)
+}
@@ -0,0 +1,43 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/browser"
+)
+
+// Opens url in the user's browser. Under WSL there is usually no Linux browser, so open
+// the Windows one with wslview (from wslu) if installed, else via PowerShell. In Crostini,
+// ask ChromeOS to open it with garcon.
+func openBrowser(url string) error {
+	switch {
+	case isWSL():
+		if _, err := exec.LookPath("wslview"); err == nil {
+			return exec.Command("wslview", url).Run()
+		}
+		return exec.Command("powershell.exe", "-NoProfile", "-NonInteractive", "-Command", "Start-Process '"+strings.ReplaceAll(url, "'", "''")+"'").Run()
+	case isCrostini():
+		return exec.Command(crostiniGarconPath, "--client", "--url", url).Run()
+	default:
+		return browser.OpenURL(url)
+	}
+}
@@ -22,19 +22,19 @@ package geecert
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/binary"
 	"encoding/json"
 	"encoding/pem"
 	"errors"
 	"fmt"
-	"io/ioutil"
-	"log"
-	"net"
+	"io"
 	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
+	"os/user"
 	"path/filepath"
 	"runtime"
 	"strconv"
@@ -44,15 +44,11 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 
-	"github.com/hydrogen18/stoppableListener"
 	homedir "github.com/mitchellh/go-homedir"
-	"github.com/pkg/browser"
 
 	pb "github.com/continusec/geecert/sso"
 
 	"golang.org/x/crypto/ssh"
-	"golang.org/x/crypto/ssh/agent"
-	context "golang.org/x/net/context"
 
 	"crypto/rand"
 	"crypto/rsa"
@@ -67,6 +63,9 @@ const (
 
 	RedirectOOB       = "urn:ietf:wg:oauth:2.0:oob"
 	RedirectLocalhost = "http://localhost"
+	RedirectLoopback  = "http://127.0.0.1"
+
+	DefaultBrowserTimeout = 5 * time.Minute
 )
 
 type ClientAppConfiguration struct {
@@ -74,198 +73,379 @@ type ClientAppConfiguration struct {
 	ClientID           string // Client ID as configured with Google: https://console.developers.google.com/
 	ClientNotSoSecret  string // Client "Secret" corresponding to the Client ID. Note, despite the name, this is not really a secret nor intended to be.
 	GRPCPEMCertificate string // If set, Self-signed GRPC server certificate, else GRPCPEMCertificatePath is used
-	GRPCServer         string // server:host
+	GRPCServer         string // server:port, several separated by commas to fail over between, or srv: and a DNS SRV name, e.g. srv:_geecert._tcp.yourdomain.com
 	CredentialFileName string // e.g. .geecerttoken
 
+	BootstrapURL string // If set, e.g. https://sso.yourdomain.com, fields above and ShortlivedKeyName etc that are not set are fetched from the server's bootstrap config here, see ApplyBootstrapConfig
+
+	// Client IDs and secrets to use instead of ClientID and ClientNotSoSecret, by platform (runtime.GOOS, e.g. "darwin", or "chromeos" in a ChromeOS Linux container) or profile, e.g. "ci"
+	OAuthClients       map[string]OAuthClient
+	OAuthClientProfile string // Key in OAuthClients to use, default is the platform
+
+	BrokeredAuth string // If set, BrokeredAuthGcloud or BrokeredAuthADC to use the ID token of a user already signed in to the Cloud SDK, rather than signing in separately
+
+	SAMLBridgeURL string // If set, e.g. https://sso.yourdomain.com, sign in with the organization's SAML IdP via the server's SAML bridge, rather than with Google
+
+	KerberosSPN string // If set, e.g. HTTP/sso.yourdomain.com, authenticate with the user's Kerberos ticket (from kinit) for this service principal, rather than an ID token
+
+	KubernetesTokenPath string // If set, e.g. DefaultKubernetesTokenPath, authenticate with the Kubernetes service account token projected here, rather than an ID token
+
+	Scopes []string // Additional OAuth scopes to request, e.g. "openid" or "groups" for IdPs other than Google. "email" is always requested.
+
 	GRPCPEMCertificatePath string // If set, path to PEM for server certificate
 
 	OverrideMachinePolicy bool // If true, override machine policy such as requiring FDE
 	OverrideGrpcSecurity  bool // If true, allow insecure connection to gRPC server
 	UseSystemCaForCert    bool // If true, use a system CA instead of self-signed certificate
 
+	MaxMessageBytes int // Largest response accepted from the server, default DefaultMaxMessageBytes
+
+	ServerBalancing string // With several servers, ServerPickFirst (default) or ServerRoundRobin
+	ServerName      string // If set, the name expected in the server's TLS certificate and sent as SNI, e.g. when GRPCServer is an IP address
+	DNSOverHTTPSURL string // If set, e.g. https://1.1.1.1/dns-query, look up the server and Google's endpoints with this DNS over HTTPS resolver rather than the system's, e.g. on networks that interfere with DNS
+
+	ConnectTimeout    time.Duration // Longest to wait to connect to the server or Google, default DefaultConnectTimeout
+	FallbackDelay     time.Duration // How long to wait to connect over IPv6 (or IPv4, whichever is looked up first) before also trying the other, default DefaultFallbackDelay, negative to only try it after the first fails
+	KeepaliveInterval time.Duration // If set, how often to ping the server while waiting on it, e.g. during watch, to notice a dropped connection, at least 10s
+
 	ShortlivedKeyName string // e.g. id_orgname_shortlived_rsa
 	SectionIdentifier string // e.g. ORGNAME-CA
+
+	InstallPuTTY  bool // If true, also write the key in PuTTY format (.ppk) and trust the CA in PuTTY (Windows)
+	InstallVSCode bool // If true, also update the ssh config file used by VS Code Remote SSH if not the default
+
+	Agents string // Comma separated agents to add the key to if running, "openssh" and/or "pageant". Default is "openssh".
+
+	UseMacKeychain      bool // If true, on macOS add UseKeychain and AddKeysToAgent to the ssh config so ssh re-adds the key when used
+	GPGAgentAddPlainKey bool // If true, and ssh-agent is gpg-agent, add the key without its certificate as gpg-agent rejects certificates
+
+	WSLAgentPipe string // Under WSL with no SSH_AUTH_SOCK, the Windows agent's named pipe to add the key to via npiperelay.exe. Default is DefaultWSLAgentPipe.
+
+	// Paths to install to, if not the default of ~/.ssh/ShortlivedKeyName etc. Environment variables and ~ are expanded.
+	KeyPath        string // Private key, public key is written alongside with .pub suffix
+	CertPath       string // Certificate, default is KeyPath + "-cert.pub"
+	KnownHostsPath string // Default is ~/.ssh/known_hosts, or SystemKnownHostsPath if SystemWide
+	SSHConfigPath  string // Default is ~/.ssh/config, or SystemSSHConfigPath if SystemWide
+	SystemWide     bool   // If true, install the CAs and ssh config for all users of the machine, e.g. a shared build machine, rather than the user's own. Needs root.
+
+	AutoRenew bool // If true, add a Match exec line to the ssh config so that ssh renews the certificate when it is near expiry
+
+	BrowserTimeout time.Duration // How long to wait for authorization in the browser before asking the user to paste a code instead. Default is DefaultBrowserTimeout.
+
+	RedirectPorts string // If set, e.g. 8400-8410, the port on localhost for the browser's redirect is picked from this range, e.g. to suit local firewall rules. Default is any free port.
+
+	ShowQRCode bool // If true, when signing in with the device flow (e.g. over ssh), also show the URL to visit as a QR code, to sign in on a phone
+
+	// Pages shown in the browser after authorization. Each is either HTML, or an http(s):// URL to redirect to.
+	// If not set, a plain text message is shown.
+	LandingPageSuccess string
+	LandingPageDenied  string
+	LandingPageError   string
+
+	BreakGlassKey string // Public key (authorized_keys format) of a hardware token held key in ssh-agent, for the break-glass command
+
+	Reason string // If set, sent to the server with the request, e.g. a ticket number, to be recorded in the certificate
+
+	OnBehalfOf string // If set, email of the user to request a certificate on behalf of, for impersonators (see the server's impersonators)
+
+	// If Role is set, ask to be granted that jit_role for Ticket, for RoleDuration (default the role's maximum)
+	Role         string
+	Ticket       string
+	RoleDuration time.Duration
+
+	SourceAddress string // If set, comma separated CIDRs to ask the server to restrict the certificate to, if the server allows clients to choose
+
+	ClientName    string // Sent to the server with each request, e.g. getmycerts
+	ClientVersion string // Sent to the server with each request, default is Version
+
+	UpdateManifestURL   string // If set, URL of the signed release manifest used by the update command
+	UpdatePublicKey     string // Public key (authorized_keys format) that the release manifest must be signed by
+	AutoCheckForUpdates bool   // If true, check for a newer release at most daily after fetching certificates
+
+	NTPServer string // host:port used by the doctor command to check the clock, default is DefaultNTPServer
+
+	Prompter Prompter // If set, used to ask the user for input, else they are asked on the terminal
+
+	Notifications bool          // If true, show a desktop notification when a certificate is installed, and by the watch command when it is about to expire
+	NotifyBefore  time.Duration // How long before expiry to notify, default is DefaultNotifyBefore
+
+	MinRemaining time.Duration // If set, don't fetch a new certificate if the installed one is valid for at least this long, e.g. 30m
+
+	ReuseKey  bool // If true, request a certificate for the existing private key if there is one, rather than generating a new key each time
+	RotateKey bool // If true, generate a new private key even if ReuseKey is set
+
+	PreIssueHooks    []string // Shell commands to run before requesting a certificate. If any fails, no certificate is requested
+	PostInstallHooks []string // Shell commands to run after a certificate is installed, with its details in GEECERT_* environment variables
+
+	OfflineGrace time.Duration // If set, and the server or Google can't be reached, carry on with the installed certificate without error if it's valid for at least this long
+
+	Telemetry bool // If true, report the outcome of fetching certificates to the server, with our version and platform but nothing identifying
+
+	FollowSymlinks bool // If true, files that are symlinks (e.g. from a dotfile manager) are updated where they link to, else they are left alone and an error returned
+
+	NoFixPermissions bool // If true, modes of ~/.ssh and the files we install that ssh would refuse are only warned about, rather than fixed
+
+	IDTokenValidator IDTokenValidator // If set, used to check ID tokens instead of Google's keys, e.g. in tests
+
+	Flags []string // Command line flags the client was run with, to run it again the same way from git, ssh or a service
 }
 
 var (
-	ErrUserDenied       = errors.New("User clicked deny.")
-	ErrWrongKeyFileType = errors.New("Wrong key file type.")
-	ErrWrongCertType    = errors.New("Wrong cert file type.")
+	ErrUserDenied        = errors.New("User clicked deny.")
+	ErrBrowserTimeout    = errors.New("Timed out waiting for authorization in browser.")
+	ErrReauthRequired    = errors.New("Server requires that the user authenticates again.")
+	ErrFileVaultDisabled = &PolicyError{"FileVault must be enabled if you want SSH certificates. Please enable and then retry (or, re-run with --override_machine_policy)"}
+	ErrReasonRequired    = errors.New("Server requires a reason for the certificate, e.g. a ticket number. Please specify one with -reason.")
+	ErrWrongKeyFileType  = errors.New("Wrong key file type.")
+	ErrWrongCertType     = errors.New("Wrong cert file type.")
 )
 
+// Options for the authorization request.
+type AuthOptions struct {
+	LoginHint  string        // If set, email address of the account that Google should suggest
+	MaxAge     time.Duration // If ForceLogin, how recently the user must have authenticated
+	ForceLogin bool          // If true, ask that the user authenticates again, even if signed in
+}
+
+// Parameters for the authorization URL. If no login hint is set, the user is asked to choose
+// an account if signed in to several.
+func authURLParams(config *ClientAppConfiguration, redir string, opts *AuthOptions) url.Values {
+	rv := url.Values{
+		"scope":         {strings.Join(scopes(config), " ")},
+		"redirect_uri":  {redir},
+		"response_type": {"code"},
+		"client_id":     {oauthClient(config).ClientID},
+		"hd":            {config.HostedDomain},
+	}
+	if len(opts.LoginHint) > 0 {
+		rv.Set("login_hint", opts.LoginHint)
+	} else {
+		rv.Set("prompt", "select_account")
+	}
+	if opts.ForceLogin {
+		rv.Set("prompt", "login")
+		rv.Set("max_age", strconv.Itoa(int(opts.MaxAge/time.Second)))
+	}
+	return rv
+}
+
+func scopes(config *ClientAppConfiguration) []string {
+	rv := []string{"email"}
+	for _, s := range config.Scopes {
+		if s != "email" {
+			rv = append(rv, s)
+		}
+	}
+	return rv
+}
+
 // Try to launch a browser, redirect to local server etc etc
-// Return code, redirect URI, error
-func DoBrowserDance(config *ClientAppConfiguration) (string, string, error) {
-	// Find a free port number
-	addr, err := net.ResolveTCPAddr("tcp", "localhost:0")
+// Return code, redirect URI, error. The local server is stopped if ctx is done first.
+func DoBrowserDance(ctx context.Context, config *ClientAppConfiguration, opts *AuthOptions) (string, string, error) {
+	return browserDance(ctx, config, func(redir, state string) string {
+		params := authURLParams(config, redir, opts)
+		params.Set("state", state)
+		return AuthURI + "?" + params.Encode()
+	})
+}
+
+// As per DoBrowserDance, sending the user to the URL returned by authURL for our redirect URI and state.
+func browserDance(ctx context.Context, config *ClientAppConfiguration, authURL func(redir, state string) string) (string, string, error) {
+	// Don't send the user to the browser if we've already given up
+	err := ctx.Err()
 	if err != nil {
 		return "", "", err
 	}
 
-	// Bind a listener
-	listener, err := net.ListenTCP("tcp", addr)
+	// Random state, so that we only accept a code from the authorization we started
+	stateBytes := make([]byte, 16)
+	_, err = rand.Read(stateBytes)
 	if err != nil {
 		return "", "", err
 	}
+	state := base64.RawURLEncoding.EncodeToString(stateBytes)
 
-	// Make it stoppable
-	stoppable, err := stoppableListener.New(listener)
+	// Listen on a free port, on loopback only
+	listener, err := listenLoopback(config)
 	if err != nil {
 		return "", "", err
 	}
 
-	// Get the post out
-	port := listener.Addr().(*net.TCPAddr).Port
-
 	// Construct the redirect URL
-	redir := RedirectLocalhost + ":" + strconv.Itoa(port)
+	redir := listener.RedirectURI()
 
 	// Send the user there
-	urlToVisit := AuthURI + "?" + url.Values{
-		"scope":         {"email"},
-		"redirect_uri":  {redir},
-		"response_type": {"code"},
-		"client_id":     {config.ClientID},
-	}.Encode()
-
-	err = browser.OpenURL(urlToVisit)
+	err = openBrowser(authURL(redir, state))
 	if err != nil {
+		listener.Close()
 		return "", "", err
 	}
 
 	fmt.Println(`Please click the "Allow" button in your browser to authorize our SSO tool.`)
 
-	// Wait for the server to get the code
-	var code string
-	err = http.Serve(stoppable, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	// Wait for the server to get the code, or "" if denied
+	result := make(chan string, 1)
+	serveErr := listener.Serve(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("state") != state {
+			writeLandingPage(w, r, http.StatusBadRequest, config.LandingPageError, "Error - unexpected request.")
+			return
+		}
+
 		c := r.FormValue("code")
 		switch {
 		case len(c) > 0:
-			w.Write([]byte("Authorization code received. Please close this window and return to your terminal to complete the process."))
-			code = c
-			stoppable.Stop()
+			writeLandingPage(w, r, http.StatusOK, config.LandingPageSuccess, "Authorization code received. Please close this window and return to your terminal to complete the process.")
+			sendResult(result, c)
 		case r.FormValue("error") == "access_denied":
-			w.Write([]byte("We'll miss you. Please close this window and return to your terminal."))
-			stoppable.Stop()
+			writeLandingPage(w, r, http.StatusOK, config.LandingPageDenied, "We'll miss you. Please close this window and return to your terminal.")
+			sendResult(result, "")
 		default:
-			w.Write([]byte("Error - please try again."))
+			writeLandingPage(w, r, http.StatusOK, config.LandingPageError, "Error - please try again.")
 		}
 	}))
-	switch err {
-	case nil:
-		// pass
-	case stoppableListener.StoppedError:
-		// pass
-	default:
+
+	timeout := config.BrowserTimeout
+	if timeout == 0 {
+		timeout = DefaultBrowserTimeout
+	}
+
+	var code string
+	select {
+	case code = <-result:
+		listener.Stop()
+	case err = <-serveErr:
 		return "", "", err
+	case <-time.After(timeout):
+		listener.Stop()
+		logInfo("Timed out waiting for authorization in browser.")
+		return "", "", ErrBrowserTimeout
+	case <-ctx.Done():
+		listener.Stop()
+		return "", "", ctx.Err()
 	}
 
 	if len(code) < 1 {
 		return "", "", ErrUserDenied
 	}
 
-	log.Print("Authorization code received.")
+	logVerbose("Authorization code received.")
 
 	return code, redir, nil
 }
 
-func DoOOBDance(config *ClientAppConfiguration) (string, string, error) {
-	// Send the user there
-	urlToVisit := AuthURI + "?" + url.Values{
-		"scope":         {"email"},
-		"redirect_uri":  {RedirectOOB},
-		"response_type": {"code"},
-		"client_id":     {config.ClientID},
-	}.Encode()
+// Shows the configured page, which is either HTML or a URL to redirect to, else defaultText.
+func writeLandingPage(w http.ResponseWriter, r *http.Request, status int, page, defaultText string) {
+	switch {
+	case len(page) == 0:
+		w.WriteHeader(status)
+		w.Write([]byte(defaultText))
+	case strings.HasPrefix(page, "https://") || strings.HasPrefix(page, "http://"):
+		http.Redirect(w, r, page, http.StatusFound)
+	default:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(status)
+		w.Write([]byte(page))
+	}
+}
 
-	fmt.Printf("Please visit (in your browser):\n%s\n\nAnd then paste the code received here: ", urlToVisit)
+// Send without blocking, as only the first result is wanted.
+func sendResult(result chan string, s string) {
+	select {
+	case result <- s:
+	default:
+	}
+}
 
-	// If we don't have one, then prompt for it
-	var code string
-	for len(code) < 1 {
-		_, err := fmt.Scanln(&code)
-		if err != nil {
-			return "", "", err
-		}
+// Asks the user to visit the authorization URL and paste the code, with config.Prompter.
+// Returns ctx.Err() if ctx is done before the user enters it.
+func DoOOBDance(ctx context.Context, config *ClientAppConfiguration, opts *AuthOptions) (string, string, error) {
+	// Send the user there
+	urlToVisit := AuthURI + "?" + authURLParams(config, RedirectOOB, opts).Encode()
+
+	code, err := prompter(config).Prompt(ctx, fmt.Sprintf("Please visit (in your browser):\n%s\n\nAnd then paste the code received here: ", urlToVisit), validateCode)
+	if err != nil {
+		return "", "", err
 	}
 
 	return code, RedirectOOB, nil
 }
 
-func SwapCodeForTokens(config *ClientAppConfiguration, code, redir string) (*CachedCreds, error) {
-	log.Print("Exchanging authorization code for long-lived credentials.")
+func SwapCodeForTokens(ctx context.Context, config *ClientAppConfiguration, code, redir string) (*CachedCreds, error) {
+	ctx = withDialer(ctx, config)
+	logVerbose("Exchanging authorization code for long-lived credentials.")
 
 	// Now we have an authorization code, exchange this for the good stuff
-	resp, err := http.PostForm(TokenURI, url.Values{
+	resp, err := postForm(ctx, TokenURI, url.Values{
 		"code":          {code},
-		"client_id":     {config.ClientID},
-		"client_secret": {config.ClientNotSoSecret},
+		"client_id":     {oauthClient(config).ClientID},
+		"client_secret": {oauthClient(config).ClientNotSoSecret},
 		"redirect_uri":  {redir},
 		"grant_type":    {"authorization_code"},
 	})
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("Exchanging authorization code: %w", err)
 	}
 
 	// Always read body, even if not 200 as it can contain info about the err
 	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
+	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
 
 	// Fail if not OK
 	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New("Unexpected server response: " + resp.Status + " " + string(body))
+		return nil, tokenEndpointError(resp, body)
 	}
 
 	var creds CachedCreds
 	err = json.Unmarshal(body, &creds)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("Parsing response from token endpoint: %w", err)
 	}
 
-	log.Print("Received long-lived credentials.")
+	logVerbose("Received long-lived credentials.")
 
 	return &creds, nil
 }
 
-func SwapRefreshForTokens(config *ClientAppConfiguration, refreshToken string) (*CachedCreds, error) {
-	log.Print("Sending refresh token for short-lived credentials.")
+func SwapRefreshForTokens(ctx context.Context, config *ClientAppConfiguration, refreshToken string) (*CachedCreds, error) {
+	ctx = withDialer(ctx, config)
+	logVerbose("Sending refresh token for short-lived credentials.")
 
 	// Now we have an authorization code, exchange this for the good stuff
-	resp, err := http.PostForm(TokenURI, url.Values{
+	resp, err := postForm(ctx, TokenURI, url.Values{
 		"refresh_token": {refreshToken},
-		"client_id":     {config.ClientID},
-		"client_secret": {config.ClientNotSoSecret},
+		"client_id":     {oauthClient(config).ClientID},
+		"client_secret": {oauthClient(config).ClientNotSoSecret},
 		"grant_type":    {"refresh_token"},
 	})
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("Refreshing credentials: %w", err)
 	}
 
 	// Always read body, even if not 200 as it can contain info about the err
 	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
+	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
 
 	// Fail if not OK
 	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New("Unexpected server response: " + resp.Status + " " + string(body))
+		return nil, tokenEndpointError(resp, body)
 	}
 
 	var creds CachedCreds
 	err = json.Unmarshal(body, &creds)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("Parsing response from token endpoint: %w", err)
 	}
 
 	// Refresh token is not return to us
 	creds.RefreshToken = refreshToken
 
-	log.Print("Received new short-lived credentials.")
+	logVerbose("Received new short-lived credentials.")
 
 	return &creds, nil
 }
@@ -279,24 +459,58 @@ type CachedCreds struct {
 }
 
 // Prompt user to
-func Reauthorize(config *ClientAppConfiguration, path string) error {
+func Reauthorize(ctx context.Context, config *ClientAppConfiguration, path string) error {
+	return ReauthorizeWithOptions(ctx, config, path, &AuthOptions{})
+}
+
+// As per Reauthorize, with options such as requiring the user to log in again.
+func ReauthorizeWithOptions(ctx context.Context, config *ClientAppConfiguration, path string, opts *AuthOptions) error {
+	// Suggest the account we had last time, if any
+	oldCreds, err := LoadCreds(path)
+	if err == nil && len(opts.LoginHint) == 0 && checkAccountDomain(oldCreds.IDToken, config.HostedDomain) == nil {
+		opts.LoginHint = UnverifiedEmail(oldCreds.IDToken)
+	}
+
+	// With no browser, e.g. over ssh, use the device flow, unless a fresh sign in is needed as it
+	// can't ask for one. It needs a suitable OAuth client, so fall back to pasting a code.
+	if isHeadless() && !opts.ForceLogin {
+		logVerbose("No browser available in this session, signing in with the device flow.")
+		creds, err := DoDeviceDance(ctx, config)
+		switch {
+		case err == nil:
+			return SaveCreds(path, creds)
+		case err == ErrUserDenied, ctx.Err() != nil:
+			return err
+		}
+		logVerbose("Unable to use the device flow, asking for a code instead: %s", err)
+		code, redir, err := DoOOBDance(ctx, config, opts)
+		if err != nil {
+			return err
+		}
+		return swapAndSaveCreds(ctx, config, path, code, redir)
+	}
+
 	// First try the browser dance as it's easier for the user
-	code, redir, err := DoBrowserDance(config)
-	switch err {
-	case nil:
+	code, redir, err := DoBrowserDance(ctx, config, opts)
+	switch {
+	case err == nil:
 		// yay, pass!
-	case ErrUserDenied:
+	case err == ErrUserDenied, ctx.Err() != nil:
 		return err
 	default:
 		// Fall back to OOB dance
-		code, redir, err = DoOOBDance(config)
+		code, redir, err = DoOOBDance(ctx, config, opts)
 	}
 	if err != nil {
 		return err
 	}
 
+	return swapAndSaveCreds(ctx, config, path, code, redir)
+}
+
+func swapAndSaveCreds(ctx context.Context, config *ClientAppConfiguration, path, code, redir string) error {
 	// Swap authorization code for tokens
-	creds, err := SwapCodeForTokens(config, code, redir)
+	creds, err := SwapCodeForTokens(ctx, config, code, redir)
 	if err != nil {
 		return err
 	}
@@ -312,15 +526,15 @@ func Reauthorize(config *ClientAppConfiguration, path string) error {
 }
 
 func LoadCreds(path string) (*CachedCreds, error) {
-	body, err := ioutil.ReadFile(path)
+	body, err := os.ReadFile(path)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("Reading saved credentials: %w", err)
 	}
 
 	var creds CachedCreds
 	err = json.Unmarshal(body, &creds)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("Parsing saved credentials in %s: %w", path, err)
 	}
 
 	return &creds, nil
@@ -332,22 +546,124 @@ func SaveCreds(path string, creds *CachedCreds) error {
 		return err
 	}
 
-	err = ioutil.WriteFile(path, body, 0600)
+	err = os.WriteFile(path, body, 0600)
 	if err != nil {
-		return err
+		return fmt.Errorf("Saving credentials: %w", err)
 	}
 
-	log.Print("Saved credentials to ", path)
+	logVerbose("Saved credentials to %s", path)
 	return nil
 }
 
-// sshDir is the absolute path
+// Connect to the gRPC server, using the TLS settings in the config.
+func DialServer(ctx context.Context, config *ClientAppConfiguration) (*grpc.ClientConn, error) {
+	var dialOptions []grpc.DialOption
+	if config.OverrideGrpcSecurity {
+		// use system CA pool but disable cert validation
+		logWarning("Disabling TLS authentication when connecting to SSO gRPC server")
+		dialOptions = append(dialOptions, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{InsecureSkipVerify: true, ServerName: config.ServerName})))
+	} else if len(config.GRPCPEMCertificatePath) > 0 {
+		tc, err := credentials.NewClientTLSFromFile(config.GRPCPEMCertificatePath, config.ServerName)
+		if err != nil {
+			return nil, err
+		}
+		dialOptions = append(dialOptions, grpc.WithTransportCredentials(tc))
+	} else if config.UseSystemCaForCert {
+		dialOptions = append(dialOptions, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{ServerName: config.ServerName}))) // uses the system CA pool
+	} else {
+		// use baked in cert
+		cp := x509.NewCertPool()
+		if !cp.AppendCertsFromPEM([]byte(config.GRPCPEMCertificate)) {
+			return nil, errors.New("Unable to understand baked-in cert.")
+		}
+		dialOptions = append(dialOptions, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: cp, ServerName: config.ServerName})))
+	}
+	if len(config.ServerName) > 0 {
+		dialOptions = append(dialOptions, grpc.WithAuthority(config.ServerName))
+	}
+
+	if len(config.KerberosSPN) > 0 {
+		dialOptions = append(dialOptions, grpc.WithPerRPCCredentials(kerberosCredentials{config}))
+	}
+
+	dialOptions = append(dialOptions, messageDialOptions(config)...)
+	dialOptions = append(dialOptions, clientInterceptors(config)...)
+
+	target, targetOptions, err := serverTarget(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	dialOptions = append(dialOptions, targetOptions...)
+
+	logVerbose("Connecting to %s.", config.GRPCServer)
+	return grpc.DialContext(ctx, target, dialOptions...)
+}
+
+// Dial options that add our name and version to each request, and log them for debugging.
+func clientInterceptors(config *ClientAppConfiguration) []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithUnaryInterceptor(func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			err := invoker(withClientVersion(ctx, config), method, req, reply, cc, opts...)
+			logRPC(method, req, reply, err)
+			return messageSizeError(config, err)
+		}),
+		grpc.WithStreamInterceptor(func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			logDebug("gRPC %s: opening stream", method)
+			return streamer(withClientVersion(ctx, config), desc, cc, method, opts...)
+		}),
+	}
+}
+
+// Returns the private key to request a certificate for. If config.ReuseKey is set, this is the
+// existing key if there is one, unless config.RotateKey is set, else a newly generated key.
+func requestKey(config *ClientAppConfiguration, sshDir string, homePathToSSHDir string) (*rsa.PrivateKey, error) {
+	if config.ReuseKey && !config.RotateKey {
+		paths, err := ResolveInstallPaths(config, sshDir, homePathToSSHDir)
+		if err != nil {
+			return nil, err
+		}
+		pemBytes, err := os.ReadFile(paths.Key)
+		switch {
+		case err == nil:
+			key, err := ssh.ParseRawPrivateKey(pemBytes)
+			if err != nil {
+				return nil, fmt.Errorf("Reading existing private key %s: %w", paths.Key, err)
+			}
+			rsaKey, ok := key.(*rsa.PrivateKey)
+			if !ok {
+				return nil, fmt.Errorf("Existing private key %s is not an RSA key", paths.Key)
+			}
+			logVerbose("Reusing existing private key.")
+			return rsaKey, nil
+		case !os.IsNotExist(err):
+			return nil, err
+		}
+	}
+
+	logVerbose("Generating new private key.")
+	return rsa.GenerateKey(rand.Reader, 2048)
+}
+
+// sshDir is the absolute path, used unless paths are set in the config (see ResolveInstallPaths)
 // homePathToSSHDir is the path to use inside of a config file, this should contain a ~
 // rather than be absolute as it allows this .ssh dir to be mounted as a volume inside of Docker
 // and work well.
-func FetchCerts(config *ClientAppConfiguration, idToken string, sshDir string, homePathToSSHDir string) error {
-	log.Println("Generating new private key.")
-	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+func FetchCerts(ctx context.Context, config *ClientAppConfiguration, idToken string, sshDir string, homePathToSSHDir string) error {
+	paths, err := ResolveInstallPaths(config, sshDir, homePathToSSHDir)
+	if err != nil {
+		return err
+	}
+	if len(config.PreIssueHooks) > 0 {
+		err = runPreIssueHooks(ctx, config, paths.Key)
+		if err != nil {
+			return err
+		}
+	}
+
+	// So that the server doesn't count the certificate being replaced against our limit
+	replaces := certFingerprint(paths.Cert)
+
+	privateKey, err := requestKey(config, sshDir, homePathToSSHDir)
 	if err != nil {
 		return err
 	}
@@ -358,131 +674,211 @@ func FetchCerts(config *ClientAppConfiguration, idToken string, sshDir string, h
 	}
 	ourPubKeyString := base64.StdEncoding.EncodeToString(ourPubKey.Marshal())
 
-	// Get certs
-	var dialOptions []grpc.DialOption
-	if config.OverrideGrpcSecurity {
-		// use system CA pool but disable cert validation
-		log.Println("WARNING: Disabling TLS authentication when connecting to SSO gRPC server")
-		dialOptions = append(dialOptions, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{InsecureSkipVerify: true})))
-	} else if len(config.GRPCPEMCertificatePath) > 0 {
-		tc, err := credentials.NewClientTLSFromFile(config.GRPCPEMCertificatePath, "")
+	var deviceID, deviceSignature string
+	deviceKey, err := loadDeviceKey(paths)
+	if err != nil {
+		return err
+	}
+	if deviceKey != nil {
+		deviceID = DeviceID(deviceKey.PublicKey())
+		deviceSignature, err = DeviceSignature(deviceKey, ourPubKey)
 		if err != nil {
 			return err
 		}
-		dialOptions = append(dialOptions, grpc.WithTransportCredentials(tc))
-	} else if config.UseSystemCaForCert {
-		dialOptions = append(dialOptions, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{}))) // uses the system CA pool
-	} else {
-		// use baked in cert
-		cp := x509.NewCertPool()
-		if !cp.AppendCertsFromPEM([]byte(config.GRPCPEMCertificate)) {
-			return errors.New("Unable to understand baked-in cert.")
-		}
-		dialOptions = append(dialOptions, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: cp})))
 	}
 
-	conn, err := grpc.Dial(config.GRPCServer, dialOptions...)
+	conn, err := DialServer(ctx, config)
 	if err != nil {
-		return err
+		return fmt.Errorf("Unable to connect to %s: %w", config.GRPCServer, err)
 	}
 	defer conn.Close()
-	client := pb.NewGeeCertServerClient(conn)
+	client := NewClient(conn)
 
-	log.Println("Requesting fresh certificates...")
-	resp, err := client.GetSSHCerts(context.Background(), &pb.SSHCertsRequest{
-		IdToken:   idToken,
-		PublicKey: ourPubKeyString,
-	})
+	requestID, err := newRequestID()
 	if err != nil {
 		return err
 	}
 
-	if resp.Status != 0 {
-		return errors.New(fmt.Sprintf("Bad response form server: %#v", resp))
+	logInfo("Requesting fresh certificates...")
+	req := &pb.SSHCertsRequest{
+		RequestId:           requestID,
+		IdToken:             idToken,
+		CredentialType:      credentialType(config),
+		PublicKey:           ourPubKeyString,
+		Reason:              config.Reason,
+		SourceAddress:       config.SourceAddress,
+		ReplacesFingerprint: replaces,
+		DeviceId:            deviceID,
+		DeviceSignature:     deviceSignature,
+		ConfigHash:          installedConfigHash(config, paths, homePathToSSHDir),
+		OnBehalfOf:          config.OnBehalfOf,
 	}
-
-	log.Println("Received new certificates from server.")
-
-	// Create ssh dir if not exists
-	_, err = os.Stat(sshDir)
+	if len(config.Role) > 0 {
+		req.AccessRequest = &pb.AccessRequest{
+			Role:            config.Role,
+			DurationSeconds: int32(config.RoleDuration / time.Second),
+			Ticket:          config.Ticket,
+		}
+	}
+	resp, err := getSSHCertsWithRetry(ctx, client, req)
 	if err != nil {
-		if os.IsNotExist(err) {
-			log.Println("Creating SSH config directory.")
-			err = os.Mkdir(sshDir, 0700)
-			if err != nil {
-				return err
-			}
-		} else {
+		return fmt.Errorf("Requesting certificates: %w", err)
+	}
+
+	if resp.Status == pb.ResponseCode_APPROVAL_PENDING {
+		resp, err = waitForApproval(ctx, config, client, req, resp)
+		if err != nil {
 			return err
 		}
 	}
 
-	log.Println("Writing new private key.")
-	err = SafeSave(filepath.Join(sshDir, config.ShortlivedKeyName), pem.EncodeToMemory(
-		&pem.Block{
-			Type:  "RSA PRIVATE KEY",
-			Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
-		},
-	), 0600)
+	if resp.Status != pb.ResponseCode_OK {
+		return responseCodeError(resp.Status)
+	}
+
+	logInfo("Received new certificates from server.")
+
+	return installCerts(ctx, config, privateKey, ourPubKeyString, resp, sshDir, homePathToSSHDir)
+}
+
+// Writes the key and certificate received from the server, and updates known_hosts, ssh
+// config etc. to use them. Arguments are as per FetchCerts.
+func installCerts(ctx context.Context, config *ClientAppConfiguration, privateKey *rsa.PrivateKey, ourPubKeyString string, resp *pb.SSHCertsResponse, sshDir string, homePathToSSHDir string) error {
+	err := checkSystemWide(config)
+	if err != nil {
+		return err
+	}
+	paths, err := ResolveInstallPaths(config, sshDir, homePathToSSHDir)
 	if err != nil {
 		return err
 	}
 
-	// And public key too, not that it should be needed in theory, but SSH moans if it isn't there.
-	// Works in openssh 6.9. Broken in 7.2. Patch has been submitted to openssh team.
-	err = SafeSave(filepath.Join(sshDir, config.ShortlivedKeyName+".pub"), []byte("ssh-rsa "+ourPubKeyString+" ignorethiscomment\n"), 0644)
+	// Create ssh dir (and any others configured) if not exists
+	err = paths.makeDirs()
 	if err != nil {
 		return err
 	}
 
-	log.Println("Installing new certificate. For more info, run: ssh-keygen -Lf ~/.ssh/" + config.ShortlivedKeyName + "-cert.pub")
-	err = SafeSave(filepath.Join(sshDir, config.ShortlivedKeyName+"-cert.pub"), []byte(resp.Certificate), 0644)
+	agentOnly := resp.Directives.GetAgentOnly()
+	if agentOnly && !agentRunning(ctx, config) {
+		return fmt.Errorf("%w Your organization requires that keys are only kept in ssh-agent, so please start one.", ErrAgentUnavailable)
+	}
+
+	err = writeKeyAndCert(ctx, paths, privateKey, ourPubKeyString, resp.Certificate, agentOnly)
 	if err != nil {
 		return err
 	}
 
+	err = installConfig(ctx, config, privateKey, ourPubKeyString, resp, paths, homePathToSSHDir)
+	if err != nil {
+		return &PartialInstallError{Err: err}
+	}
+	err = saveConfigHash(ctx, config, paths, homePathToSSHDir, resp.ConfigHash)
+	if err != nil {
+		return &PartialInstallError{Err: err}
+	}
+	repairPermissions(config, paths, sshDir)
+
+	notifyInstalled(config, resp.Certificate)
+	runPostInstallHooks(ctx, config, paths, resp.Certificate)
+
+	return nil
+}
+
+// Updates known_hosts, ssh config etc. to use the key and certificate written by installCerts.
+func installConfig(ctx context.Context, config *ClientAppConfiguration, privateKey *rsa.PrivateKey, ourPubKeyString string, resp *pb.SSHCertsResponse, paths *InstallPaths, homePathToSSHDir string) error {
+	// If unchanged, the server didn't send the config again, so reinstall what we have
+	var cas, cnf []string
+	var err error
+	if resp.ConfigUnchanged {
+		logVerbose("Certificate authorities and ssh config are unchanged.")
+		cas, cnf, err = installedConfig(config, paths)
+		if err != nil {
+			return err
+		}
+	} else {
+		cas = knownHostsLines(resp.HostCertificateAuthorities, resp.CertificateAuthorities)
+
+		// Preferring the structured blocks if the server sent them
+		configLines := resp.Config
+		if len(resp.ConfigBlocks) > 0 {
+			configLines = RenderSSHConfigBlocks(resp.ConfigBlocks)
+		}
+		cnf, err = sshConfigLines(config, configLines, resp.ConfigVariables, paths, homePathToSSHDir)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Update known hosts
-	err = ReplaceSectionOfFile(config.SectionIdentifier, filepath.Join(sshDir, "known_hosts"), resp.CertificateAuthorities, 0644, "Updating known_hosts certificate authorities.")
+	err = ReplaceSectionOfFile(ctx, config.SectionIdentifier, paths.KnownHosts, cas, 0644, "Updating known_hosts certificate authorities.")
 	if err != nil {
 		return err
 	}
 
 	// Update SSH config
-	cnf := make([]string, len(resp.Config))
-	for i, line := range resp.Config {
-		cnf[i] = strings.Replace(line, "$CERTNAME", filepath.Join(homePathToSSHDir, config.ShortlivedKeyName), -1)
-	}
-	err = ReplaceSectionOfFile(config.SectionIdentifier, filepath.Join(sshDir, "config"), cnf, 0644, "Updating ssh config file to use certificates.")
+	err = ReplaceSectionOfSSHConfig(ctx, config.SectionIdentifier, paths.SSHConfig, cnf, 0644, "Updating ssh config file to use certificates.")
 	if err != nil {
 		return err
 	}
+	if config.SystemWide && len(config.SSHConfigPath) == 0 {
+		warnIfNotIncluded(paths.SSHConfig)
+	}
 
-	// Check if ssh-agent is running, and if so, add our cert
-	authSock := os.Getenv("SSH_AUTH_SOCK")
-	if len(authSock) != 0 {
-		log.Println("SSH_AUTH_SOCK detected, adding certificate to ssh-agent.")
-		// Try to add our cert
-		pk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(resp.Certificate))
+	if config.InstallVSCode {
+		err = UpdateVSCodeSSHConfig(ctx, config, paths.SSHConfig, cnf)
 		if err != nil {
 			return err
 		}
-		cert, ok := pk.(*ssh.Certificate)
-		if !ok {
-			return ErrWrongCertType
+	}
+
+	if config.InstallPuTTY {
+		if !resp.Directives.GetAgentOnly() {
+			logVerbose("Writing private key in PuTTY format.")
+			ppk, err := MarshalPPK(privateKey, config.ShortlivedKeyName)
+			if err != nil {
+				return err
+			}
+			err = SafeSave(ctx, paths.Key+".ppk", ppk, 0600)
+			if err != nil {
+				return err
+			}
 		}
-		ttl := int64(cert.ValidBefore) - time.Now().Unix()
-		log.Printf("Certificate will be added with TTL of %d seconds.\n", ttl)
 
-		agentSocket, err := net.Dial("unix", authSock)
+		err = InstallPuTTYHostCAs(ctx, config, cas)
 		if err != nil {
 			return err
 		}
-		sshAgent := agent.NewClient(agentSocket)
-		err = sshAgent.Add(agent.AddedKey{
-			PrivateKey:   privateKey,
-			Certificate:  cert,
-			LifetimeSecs: uint32(ttl),
-		})
+	}
+
+	// Save bastion policy for the bastion command
+	err = saveBastionPolicies(ctx, paths, resp.BastionPolicies)
+	if err != nil {
+		return err
+	}
+
+	err = saveAllowedSigners(ctx, paths, resp.AllowedSigners)
+	if err != nil {
+		return err
+	}
+
+	err = applyDirectives(ctx, config, paths, resp.Directives)
+	if err != nil {
+		return err
+	}
+
+	// Add our cert to any running agents
+	err = AddToAgents(ctx, config, privateKey, resp.Certificate)
+	if err != nil {
+		return err
+	}
+
+	err = removeStaleRealmSections(ctx, config, paths, resp.RealmCertificates)
+	if err != nil {
+		return err
+	}
+	for _, rc := range resp.RealmCertificates {
+		err = installRealmCert(ctx, config, privateKey, ourPubKeyString, rc, resp.ConfigVariables, paths, homePathToSSHDir, resp.Directives.GetAgentOnly())
 		if err != nil {
 			return err
 		}
@@ -491,20 +887,124 @@ func FetchCerts(config *ClientAppConfiguration, idToken string, sshDir string, h
 	return nil
 }
 
+// Writes the private key, public key and certificate to paths.
+func writeKeyAndCert(ctx context.Context, paths *InstallPaths, privateKey *rsa.PrivateKey, ourPubKeyString string, certificate string, agentOnly bool) error {
+	var err error
+	if agentOnly {
+		// ssh finds the key in the agent by the public key and certificate alongside
+		logVerbose("Not writing private key, as it is only to be kept in the agent.")
+		err = os.Remove(paths.Key)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	} else {
+		logVerbose("Writing new private key.")
+		err = SafeSave(ctx, paths.Key, pem.EncodeToMemory(
+			&pem.Block{
+				Type:  "RSA PRIVATE KEY",
+				Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+			},
+		), 0600)
+		if err != nil {
+			return err
+		}
+	}
+
+	// And public key too, not that it should be needed in theory, but SSH moans if it isn't there.
+	// Works in openssh 6.9. Broken in 7.2. Patch has been submitted to openssh team.
+	err = SafeSave(ctx, paths.Key+".pub", []byte("ssh-rsa "+ourPubKeyString+" ignorethiscomment\n"), 0644)
+	if err != nil {
+		return err
+	}
+
+	logInfo("Installing new certificate. For more info, run: ssh-keygen -Lf %s", paths.Cert)
+	return SafeSave(ctx, paths.Cert, []byte(certificate), 0644)
+}
+
+// Expands variables in config lines from the server, and adds any lines needed locally.
+func sshConfigLines(config *ClientAppConfiguration, configLines []string, serverVars map[string]string, paths *InstallPaths, homePathToSSHDir string) ([]string, error) {
+	if paths.SystemWide {
+		// Keys and certs are per user, so each user's own runs of the client configure them
+		return systemWideConfigLines(configLines), nil
+	}
+	vars, err := configVariables(serverVars, paths, homePathToSSHDir)
+	if err != nil {
+		return nil, err
+	}
+	var cnf []string
+	for _, line := range configLines {
+		cnf = append(cnf, ExpandConfigVariables(line, vars))
+
+		// If the cert isn't alongside the key, tell ssh where to find it
+		if strings.Contains(line, "$CERTNAME") && len(paths.CertInConfig) > 0 {
+			indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+			cnf = append(cnf, indent+"CertificateFile "+paths.CertInConfig)
+		}
+	}
+	if config.UseMacKeychain && runtime.GOOS == "darwin" {
+		cnf = addKeychainDirectives(cnf)
+	}
+	if config.AutoRenew {
+		cnf, err = addAutoRenewMatch(cnf, config.Flags)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return cnf, nil
+}
+
+// Variables that may be used in config lines from the server, e.g. $CERTNAME. Those sent by
+// the server are included, but can't override ours.
+func configVariables(serverVars map[string]string, paths *InstallPaths, homePathToSSHDir string) (map[string]string, error) {
+	hd, err := homedir.Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	rv := make(map[string]string)
+	for k, v := range serverVars {
+		rv[k] = v
+	}
+	rv["CERTNAME"] = paths.KeyInConfig
+	rv["HOME"] = hd
+	rv["SSHDIR"] = homePathToSSHDir
+	rv["USER"] = localUsername()
+
+	return rv, nil
+}
+
+// Returns the name of the local user, without any Windows domain.
+func localUsername() string {
+	u, err := user.Current()
+	if err != nil {
+		return os.Getenv("USER")
+	}
+	name := u.Username
+	idx := strings.LastIndex(name, `\`)
+	if idx >= 0 {
+		name = name[idx+1:]
+	}
+	return name
+}
+
 /* Deletes section with name:
 
 # AUTOGENERATED:BEGIN:name
 ...
 # AUTOGENERATED:END:name
 
-and adds new section at end with same.
+and adds new section at end with same. If the section was edited by hand since it was
+written, the previous file is first saved alongside as path + ".orig".
 */
-func ReplaceSectionOfFile(name string, path string, lines []string, perm os.FileMode, messageIfChanged string) error {
-	startMarker := "# AUTOGENERATED:BEGIN:" + name
-	endMarker := "# AUTOGENERATED:END:" + name
+func ReplaceSectionOfFile(ctx context.Context, name string, path string, lines []string, perm os.FileMode, messageIfChanged string) error {
+	return replaceSectionOfFile(ctx, name, path, lines, perm, messageIfChanged, nil)
+}
 
+// If check is set, it is called with the new contents and the line numbers of our section
+// before they are saved, and any error is returned instead of saving.
+func replaceSectionOfFile(ctx context.Context, name string, path string, lines []string, perm os.FileMode, messageIfChanged string, check func(ctx context.Context, path string, contents []byte, first, last int) error) error {
 	// Read contents of old file
-	contents, err := ioutil.ReadFile(path)
+	contents, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) { // it's OK if it doesn't exist
 			contents = nil
@@ -513,13 +1013,51 @@ func ReplaceSectionOfFile(name string, path string, lines []string, perm os.File
 		}
 	}
 
+	// Only log and write if we've changed.
+	newContents, first, last := replaceSection(name, contents, lines)
+	if !bytes.Equal(contents, newContents) {
+		if check != nil {
+			err = check(ctx, path, newContents, first, last)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Keep a copy of anything edited by hand before it is overwritten
+		if sectionEdited(name, contents) {
+			err = SafeSave(ctx, path+".orig", contents, perm)
+			if err != nil {
+				return err
+			}
+			logWarning("Section %s of %s was edited by hand, saved the previous file to %s before replacing it", name, path, path+".orig")
+		}
+
+		// Save it out
+		logInfo("%s", messageIfChanged)
+		err = SafeSave(ctx, path, newContents, perm)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// As per ReplaceSectionOfFile, for contents already read. Returns the new contents, and the
+// line numbers (from 1) of the first and last lines of our section. The contents keep their
+// line endings and byte order mark, see splitLines.
+func replaceSection(name string, contents []byte, lines []string) ([]byte, int, int) {
+	startMarker := "# AUTOGENERATED:BEGIN:" + name
+	endMarker := "# AUTOGENERATED:END:" + name
+
 	// Copy contents to buffer, skipping over our section
 	var output []string
 	include := true
-	for _, line := range strings.Split(string(contents), "\n") {
-		if strings.HasPrefix(line, startMarker) {
+	input, format := splitLines(contents)
+	for _, line := range input {
+		if isSectionMarker(line, startMarker) {
 			include = false
-		} else if strings.HasPrefix(line, endMarker) {
+		} else if isSectionMarker(line, endMarker) {
 			include = true
 		} else {
 			if include {
@@ -534,63 +1072,108 @@ func ReplaceSectionOfFile(name string, path string, lines []string, perm os.File
 	}
 
 	// If we have a section to append, append it
+	first := len(output) + 3
 	if len(lines) > 0 {
 		output = append(output, "")
-		output = append(output, startMarker+" - DO NOT EDIT BETWEEN MARKERS!")
+		output = append(output, startMarker+" - DO NOT EDIT BETWEEN MARKERS! "+sectionChecksum(lines))
 		output = append(output, lines...)
 		output = append(output, endMarker+" - DO NOT EDIT BETWEEN MARKERS!")
 	}
+	last := len(output) - 1
 
 	// Always finish with a new line
 	output = append(output, "")
 
-	// Only log and write if we've changed.
-	newContents := []byte(strings.Join(output, "\n"))
-	if !bytes.Equal(contents, newContents) {
-		// Save it out
-		log.Println(messageIfChanged)
-		err = SafeSave(path, newContents, perm)
-		if err != nil {
-			return err
+	return format.join(output), first, last
+}
+
+// Returns the lines of the section with name in contents, as written by ReplaceSectionOfFile.
+func sectionLines(name string, contents []byte) []string {
+	startMarker := "# AUTOGENERATED:BEGIN:" + name
+	endMarker := "# AUTOGENERATED:END:" + name
+
+	var rv []string
+	include := false
+	lines, _ := splitLines(contents)
+	for _, line := range lines {
+		if isSectionMarker(line, startMarker) {
+			include = true
+		} else if isSectionMarker(line, endMarker) {
+			include = false
+		} else if include {
+			rv = append(rv, line)
 		}
 	}
+	return rv
+}
 
-	return nil
+// Returns true if line is marker, as written by replaceSection, with nothing but the rest of
+// the marker after. Realm sections are named with the section identifier as a prefix, so they
+// must not be taken for the main section.
+func isSectionMarker(line, marker string) bool {
+	return strings.HasPrefix(line, marker) && (len(line) == len(marker) || line[len(marker)] == ' ')
 }
 
-func SafeSave(path string, contents []byte, perm os.FileMode) error {
+// Writes contents to a new file, then renames it over path, keeping the owner and extended
+// attributes (e.g. SELinux context) of any existing file. Symlinks and read-only files are
+// not replaced, see ManagedFileError.
+func SafeSave(ctx context.Context, path string, contents []byte, perm os.FileMode) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+	err = checkReplaceable(path)
+	if err != nil {
+		return err
+	}
 	pathToNew := path + ".tmpfornew"
-	err := ioutil.WriteFile(pathToNew, contents, perm)
+	err = os.WriteFile(pathToNew, contents, perm)
 	if err != nil {
 		return err
 	}
+	// Whatever the umask, or the mode of a file left over from before
+	err = os.Chmod(pathToNew, perm)
+	if err != nil {
+		return err
+	}
+	restorecon := copyFileAttributes(path, pathToNew)
 	err = os.Rename(pathToNew, path)
 	if err != nil {
 		return err
 	}
+	if restorecon {
+		restoreSELinuxContext(path)
+	}
 	return nil
 }
 
 // We can use this to soft-enforce only giving certificates out if reasonable precautions
 // are in place in the client device, e.g. enforce full disk encryption with machine passcode.
-func ValidateMachineIsSuitable(config *ClientAppConfiguration) error {
+func ValidateMachineIsSuitable(ctx context.Context, config *ClientAppConfiguration) error {
 	if config.OverrideMachinePolicy {
-		log.Println("WARNING: Overriding machine policy.")
+		logWarning("Overriding machine policy.")
 		return nil
 	}
 
 	switch runtime.GOOS {
 	case "darwin":
 		// on Mac, require full disk encryption be enabled
-		out, err := exec.Command("fdesetup", "status").Output()
+		out, err := exec.CommandContext(ctx, "fdesetup", "status").Output()
 		if err != nil {
 			return err
 		}
 
 		if strings.Index(string(out), "FileVault is On") < 0 {
-			log.Fatal("FileVault must be enabled if you want SSH certificates. Please enable and then retry (or, re-run with --override_machine_policy)")
+			return ErrFileVaultDisabled
 		}
 
+		return nil
+	case "linux":
+		// A ChromeOS Linux container can't see how the host is set up, but ChromeOS always
+		// encrypts user data and checks the OS with verified boot, which serves the same purpose
+		if isCrostini() {
+			logVerbose("Running on ChromeOS, which encrypts user data and uses verified boot.")
+		}
 		return nil
 	default:
 		// for now, allow
@@ -599,14 +1182,12 @@ func ValidateMachineIsSuitable(config *ClientAppConfiguration) error {
 }
 
 func loadSigningKey(config *ClientAppConfiguration) (ssh.Signer, *ssh.Certificate, error) {
-	hd, err := homedir.Dir()
+	paths, err := DefaultInstallPaths(config)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	sshDir := filepath.Join(hd, ".ssh")
-
-	data, err := ioutil.ReadFile(filepath.Join(sshDir, config.ShortlivedKeyName))
+	data, err := os.ReadFile(paths.Key)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -616,7 +1197,7 @@ func loadSigningKey(config *ClientAppConfiguration) (ssh.Signer, *ssh.Certificat
 		return nil, nil, err
 	}
 
-	certData, err := ioutil.ReadFile(filepath.Join(sshDir, config.ShortlivedKeyName+"-cert.pub"))
+	certData, err := os.ReadFile(paths.Cert)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -638,30 +1219,41 @@ func loadSigningKey(config *ClientAppConfiguration) (ssh.Signer, *ssh.Certificat
 	return cs, actCert, nil
 }
 
-// Get a current set of certs, then use them to sign a payload (experimental)
+// Get a current set of certs, then use them to sign a payload (experimental, see SignEnvelope for version 1)
 // Format is:
-// uint8 - format version. Version 0 is defined as:
+// uint8 - format version. Version 1 is defined as:
 // uint64 - big endian cert length
 // certificate
 // uint64 - big endian sig length
-// signature
+// signature, in SSH wire format including its algorithm (version 0 had only the blob)
 func signData(config *ClientAppConfiguration, msg []byte) ([]byte, error) {
 	signer, cert, err := loadSigningKey(config)
 	if err != nil {
 		return nil, err
 	}
+	return signDataWith(signer, cert, msg)
+}
 
-	sig, err := signer.Sign(rand.Reader, msg)
+// Signs msg with signer, as signData does, including cert.
+func signDataWith(signer ssh.Signer, cert *ssh.Certificate, msg []byte) ([]byte, error) {
+	// RSA keys sign with SHA-2 rather than their default of SHA-1
+	var sig *ssh.Signature
+	var err error
+	if as, ok := signer.(ssh.AlgorithmSigner); ok && cert.Key.Type() == ssh.KeyAlgoRSA {
+		sig, err = as.SignWithAlgorithm(rand.Reader, msg, ssh.KeyAlgoRSASHA512)
+	} else {
+		sig, err = signer.Sign(rand.Reader, msg)
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	certData := cert.Marshal()
-	sigData := sig.Blob
+	sigData := ssh.Marshal(sig)
 
 	var rv []byte
 
-	rv = append(rv, 0x00)
+	rv = append(rv, signedDataVersion1)
 
 	bb := make([]byte, 8)
 
@@ -678,53 +1270,186 @@ func signData(config *ClientAppConfiguration, msg []byte) ([]byte, error) {
 	return rv, nil
 }
 
-func ProcessClient(config *ClientAppConfiguration) error {
-	err := ValidateMachineIsSuitable(config)
+func credentialsPath(config *ClientAppConfiguration) (string, error) {
+	hd, err := homedir.Dir()
 	if err != nil {
-		return err
+		return "", err
 	}
+	return filepath.Join(hd, config.CredentialFileName), nil
+}
 
-	hd, err := homedir.Dir()
+// Returns a valid ID token, authorizing or refreshing our saved credentials as needed, or
+// from the Cloud SDK if BrokeredAuth is set, or the SAML bridge if SAMLBridgeURL is set.
+// If KerberosSPN is set, the ID token is empty, as the user's Kerberos ticket is sent instead.
+// If KubernetesTokenPath is set, it is the service account token there.
+func GetValidIDToken(ctx context.Context, config *ClientAppConfiguration) (string, *IDTokenClaims, error) {
+	ctx = withDialer(ctx, config)
+	if len(config.KerberosSPN) > 0 {
+		return getKerberosIdentity(config)
+	}
+	if len(config.KubernetesTokenPath) > 0 {
+		return getKubernetesToken(config)
+	}
+	if len(config.BrokeredAuth) > 0 {
+		return getBrokeredIDToken(ctx, config)
+	}
+	if len(config.SAMLBridgeURL) > 0 {
+		return getSAMLBridgeIDToken(ctx, config)
+	}
+
+	path, err := credentialsPath(config)
 	if err != nil {
-		return err
+		return "", nil, err
 	}
-	path := filepath.Join(hd, config.CredentialFileName)
 
 	// First, try to load creds, and if we have none, go ahead and authorize us
 	creds, err := LoadCreds(path)
 	if err != nil {
-		err = Reauthorize(config, path)
+		err = Reauthorize(ctx, config, path)
 		if err != nil {
-			return err
+			return "", nil, err
 		}
 		creds, err = LoadCreds(path)
 		if err != nil {
-			return err
+			return "", nil, err
 		}
 	}
 
 	// Now that we have creds, try to get a valid ID token refreshing if needed
-	idTokenClaims, err := ValidateTokenWithRetryForClock(creds.IDToken, config.ClientID, config.HostedDomain, 5)
+	idTokenClaims, err := ValidateTokenWithRetryForClockUsing(ctx, idTokenValidator(config), creds.IDToken, oauthClient(config).ClientID, config.HostedDomain, 5)
 	if err != nil {
-		creds, err = SwapRefreshForTokens(config, creds.RefreshToken)
+		creds, err = SwapRefreshForTokens(ctx, config, creds.RefreshToken)
 		if err != nil {
-			return err
+			// Refresh token may have been revoked or expired, so authorize again
+			logInfo("Unable to refresh credentials, authorizing again: %s", err)
+			err = Reauthorize(ctx, config, path)
+			if err != nil {
+				return "", nil, err
+			}
+			creds, err = LoadCreds(path)
+			if err != nil {
+				return "", nil, err
+			}
 		}
 		err = SaveCreds(path, creds)
 		if err != nil {
-			return err
+			return "", nil, err
 		}
-		idTokenClaims, err = ValidateTokenWithRetryForClock(creds.IDToken, config.ClientID, config.HostedDomain, 5)
+		idTokenClaims, err = ValidateTokenWithRetryForClockUsing(ctx, idTokenValidator(config), creds.IDToken, oauthClient(config).ClientID, config.HostedDomain, 5)
 		if err != nil {
-			return err
+			// If the user picked the wrong account, say so and forget it so that they are asked again
+			wrongAccountErr := checkAccountDomain(creds.IDToken, config.HostedDomain)
+			if wrongAccountErr != nil {
+				os.Remove(path)
+				return "", nil, wrongAccountErr
+			}
+			return "", nil, err
+		}
+	}
+
+	return creds.IDToken, idTokenClaims, nil
+}
+
+// Has the user log in again, even if they have valid credentials, and returns the new ID token.
+// Used when the server requires a recent authentication.
+func GetFreshlyAuthenticatedIDToken(ctx context.Context, config *ClientAppConfiguration) (string, *IDTokenClaims, error) {
+	ctx = withDialer(ctx, config)
+	if len(config.KerberosSPN) > 0 {
+		return "", nil, ErrKerberosReauth
+	}
+	if len(config.KubernetesTokenPath) > 0 {
+		return "", nil, ErrKubernetesReauth
+	}
+
+	path, err := credentialsPath(config)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(config.SAMLBridgeURL) > 0 {
+		return samlBridgeSignIn(ctx, config, path, true)
+	}
+
+	err = ReauthorizeWithOptions(ctx, config, path, &AuthOptions{ForceLogin: true})
+	if err != nil {
+		return "", nil, err
+	}
+
+	creds, err := LoadCreds(path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	idTokenClaims, err := ValidateTokenWithRetryForClockUsing(ctx, idTokenValidator(config), creds.IDToken, oauthClient(config).ClientID, config.HostedDomain, 5)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return creds.IDToken, idTokenClaims, nil
+}
+
+// Fetches and installs new certificates, signing in if needed, unless the installed certificate
+// is still valid for at least config.MinRemaining. If the server or Google can't be reached,
+// returns an *OfflineError, or nil if the installed certificate is still valid for at least
+// config.OfflineGrace.
+func ProcessClient(ctx context.Context, config *ClientAppConfiguration) error {
+	// Before signing in, which would be wasted
+	err := checkSystemWide(config)
+	if err != nil {
+		return err
+	}
+	err = processClient(ctx, config)
+	if !isOffline(err) {
+		return err
+	}
+
+	remaining := installedCertRemaining(config)
+	if config.OfflineGrace > 0 && remaining >= config.OfflineGrace {
+		logWarning("Unable to reach the server or Google (%s), continuing with the current certificate, which is valid for %s.", err, remaining.Round(time.Minute))
+		return nil
+	}
+	return &OfflineError{Err: err, Remaining: remaining}
+}
+
+func processClient(ctx context.Context, config *ClientAppConfiguration) error {
+	err := ValidateMachineIsSuitable(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	if config.MinRemaining > 0 {
+		remaining := installedCertRemaining(config)
+		if remaining >= config.MinRemaining {
+			logInfo("Current certificate is valid for another %s, not fetching a new one.", remaining.Round(time.Minute))
+			return nil
 		}
 	}
 
-	log.Print("Have valid ID token for: ", idTokenClaims.EmailAddress)
-	err = FetchCerts(config, creds.IDToken, filepath.Join(hd, ".ssh"), filepath.Join("~", ".ssh"))
+	hd, err := homedir.Dir()
+	if err != nil {
+		return err
+	}
+
+	idToken, idTokenClaims, err := GetValidIDToken(ctx, config)
 	if err != nil {
 		return err
 	}
 
+	logInfo("Have valid ID token for: %s", idTokenClaims.EmailAddress)
+	err = FetchCerts(ctx, config, idToken, filepath.Join(hd, ".ssh"), filepath.Join("~", ".ssh"))
+	if errors.Is(err, ErrReauthRequired) {
+		logInfo("Server requires that you authenticate again.")
+		idToken, _, err = GetFreshlyAuthenticatedIDToken(ctx, config)
+		if err != nil {
+			return err
+		}
+		err = FetchCerts(ctx, config, idToken, filepath.Join(hd, ".ssh"), filepath.Join("~", ".ssh"))
+	}
+	if err != nil {
+		return err
+	}
+
+	autoCheckForUpdates(ctx, config)
+
 	return nil
 }
@@ -0,0 +1,353 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/continusec/geecert/sso"
+)
+
+// An error returned by the server, with what the user can do about it if known.
+type ServerError struct {
+	Status      pb.ResponseCode
+	Reason      pb.ErrorReason
+	Message     string
+	Remediation string
+}
+
+func (e *ServerError) Error() string {
+	if len(e.Remediation) == 0 || strings.Contains(e.Message, e.Remediation) {
+		return e.Message
+	}
+	return e.Message + ": " + e.Remediation
+}
+
+// Remediations for servers that only support the v1 API.
+var responseCodeRemediation = map[pb.ResponseCode]string{
+	pb.ResponseCode_INVALID_ID_TOKEN:     "Sign in to Google again.",
+	pb.ResponseCode_NO_CERTS_ALLOWED:     "Your account (or key) is not allowed certificates. Ask an administrator to add you.",
+	pb.ResponseCode_RATE_LIMITED:         "Too many certificates have been issued to you in the past day. Try again later.",
+	pb.ResponseCode_NOT_AUTHORIZED:       "You are not authorized for this request. Ask an administrator if you should be.",
+	pb.ResponseCode_TOO_MANY_CERTS:       "You already have as many certificates as allowed. Use one of them, or wait for one to expire.",
+	pb.ResponseCode_DEVICE_NOT_ENROLLED:  "Certificates are only issued to enrolled devices. Run enroll-device, then try again.",
+	pb.ResponseCode_DEVICE_NOT_COMPLIANT: "Your device is not managed, or does not meet your organization's device policy. Check it in Self Service or Company Portal, then try again.",
+	pb.ResponseCode_TICKET_REJECTED:      "Give an open ticket that covers this access, with -ticket.",
+	pb.ResponseCode_NETWORK_NOT_ALLOWED:  "Certificates can't be issued to where you are connecting from. Connect from an allowed network, e.g. the VPN, then try again.",
+}
+
+// The error for a response from the v1 API with a status other than OK.
+func responseCodeError(st pb.ResponseCode) error {
+	switch st {
+	case pb.ResponseCode_NOT_AUTHORIZED:
+		return ErrNotAuthorized
+	case pb.ResponseCode_REAUTH_REQUIRED:
+		return ErrReauthRequired
+	case pb.ResponseCode_REASON_REQUIRED:
+		return ErrReasonRequired
+	}
+	return &ServerError{
+		Status:      st,
+		Message:     fmt.Sprintf("Server responded %s", st),
+		Remediation: responseCodeRemediation[st],
+	}
+}
+
+// Returns the ErrorDetail from an error returned by the v2 API, or nil if there isn't one.
+func errorDetail(err error) *pb.ErrorDetail {
+	s, ok := status.FromError(err)
+	if !ok {
+		return nil
+	}
+	for _, d := range s.Details() {
+		ed, ok := d.(*pb.ErrorDetail)
+		if ok {
+			return ed
+		}
+	}
+	return nil
+}
+
+// Converts an error from the server into a ServerError, if it has details. Errors from
+// the v1 API may also have details, e.g. if the client must be upgraded.
+func serverError(err error) error {
+	ed := errorDetail(err)
+	if ed == nil {
+		return err
+	}
+	return &ServerError{
+		Status:      ed.Status,
+		Reason:      ed.Reason,
+		Message:     status.Convert(err).Message(),
+		Remediation: ed.Remediation,
+	}
+}
+
+// Converts an error from the v2 API for a request still in progress (e.g. awaiting approval)
+// back into a response, as returned by the v1 API. Other errors are converted as per serverError.
+func certsResponseFromError(err error) (*pb.SSHCertsResponse, error) {
+	ed := errorDetail(err)
+	if ed == nil {
+		return nil, err
+	}
+	switch ed.Status {
+	case pb.ResponseCode_APPROVAL_PENDING, pb.ResponseCode_REAUTH_REQUIRED, pb.ResponseCode_REASON_REQUIRED:
+		return &pb.SSHCertsResponse{
+			Status:            ed.Status,
+			MaxAuthAgeSeconds: ed.MaxAuthAgeSeconds,
+			ApprovalId:        ed.ApprovalId,
+		}, nil
+	}
+	return nil, serverError(err)
+}
+
+// A client that uses the v2 API, falling back to v1 for older servers. Errors are returned
+// as ServerErrors where possible.
+type fallbackClient struct {
+	v2    pb.GeeCertServerV2Client
+	v1    pb.GeeCertServerClient
+	useV1 bool
+}
+
+// Returns a client for conn, as returned by DialServer.
+func NewClient(conn *grpc.ClientConn) pb.GeeCertServerClient {
+	return &fallbackClient{
+		v2: pb.NewGeeCertServerV2Client(conn),
+		v1: pb.NewGeeCertServerClient(conn),
+	}
+}
+
+// Returns true if err means the server doesn't support the v2 API, and remembers as much.
+func (c *fallbackClient) fallBack(err error) bool {
+	if status.Code(err) == codes.Unimplemented {
+		c.useV1 = true
+	}
+	return c.useV1
+}
+
+func (c *fallbackClient) GetSSHCerts(ctx context.Context, in *pb.SSHCertsRequest, opts ...grpc.CallOption) (*pb.SSHCertsResponse, error) {
+	if !c.useV1 {
+		resp, err := c.v2.GetSSHCerts(ctx, in, opts...)
+		if !c.fallBack(err) {
+			if err != nil {
+				return certsResponseFromError(err)
+			}
+			return resp, nil
+		}
+	}
+	resp, err := c.v1.GetSSHCerts(ctx, in, opts...)
+	return resp, serverError(err)
+}
+
+func (c *fallbackClient) LookupCert(ctx context.Context, in *pb.LookupCertRequest, opts ...grpc.CallOption) (*pb.LookupCertResponse, error) {
+	if !c.useV1 {
+		resp, err := c.v2.LookupCert(ctx, in, opts...)
+		if !c.fallBack(err) {
+			return resp, serverError(err)
+		}
+	}
+	resp, err := c.v1.LookupCert(ctx, in, opts...)
+	return resp, serverError(err)
+}
+
+func (c *fallbackClient) ListApprovals(ctx context.Context, in *pb.ListApprovalsRequest, opts ...grpc.CallOption) (*pb.ListApprovalsResponse, error) {
+	if !c.useV1 {
+		resp, err := c.v2.ListApprovals(ctx, in, opts...)
+		if !c.fallBack(err) {
+			return resp, serverError(err)
+		}
+	}
+	resp, err := c.v1.ListApprovals(ctx, in, opts...)
+	return resp, serverError(err)
+}
+
+func (c *fallbackClient) DecideApproval(ctx context.Context, in *pb.DecideApprovalRequest, opts ...grpc.CallOption) (*pb.DecideApprovalResponse, error) {
+	if !c.useV1 {
+		resp, err := c.v2.DecideApproval(ctx, in, opts...)
+		if !c.fallBack(err) {
+			return resp, serverError(err)
+		}
+	}
+	resp, err := c.v1.DecideApproval(ctx, in, opts...)
+	return resp, serverError(err)
+}
+
+func (c *fallbackClient) BreakGlassCerts(ctx context.Context, in *pb.BreakGlassRequest, opts ...grpc.CallOption) (*pb.SSHCertsResponse, error) {
+	if !c.useV1 {
+		resp, err := c.v2.BreakGlassCerts(ctx, in, opts...)
+		if !c.fallBack(err) {
+			return resp, serverError(err)
+		}
+	}
+	resp, err := c.v1.BreakGlassCerts(ctx, in, opts...)
+	return resp, serverError(err)
+}
+
+func (c *fallbackClient) ReportTelemetry(ctx context.Context, in *pb.TelemetryReport, opts ...grpc.CallOption) (*pb.TelemetryResponse, error) {
+	if !c.useV1 {
+		resp, err := c.v2.ReportTelemetry(ctx, in, opts...)
+		if !c.fallBack(err) {
+			return resp, serverError(err)
+		}
+	}
+	resp, err := c.v1.ReportTelemetry(ctx, in, opts...)
+	return resp, serverError(err)
+}
+
+func (c *fallbackClient) TelemetrySummary(ctx context.Context, in *pb.TelemetrySummaryRequest, opts ...grpc.CallOption) (*pb.TelemetrySummaryResponse, error) {
+	if !c.useV1 {
+		resp, err := c.v2.TelemetrySummary(ctx, in, opts...)
+		if !c.fallBack(err) {
+			return resp, serverError(err)
+		}
+	}
+	resp, err := c.v1.TelemetrySummary(ctx, in, opts...)
+	return resp, serverError(err)
+}
+
+func (c *fallbackClient) RedeemPickupCode(ctx context.Context, in *pb.PickupCodeRequest, opts ...grpc.CallOption) (*pb.PickupCodeResponse, error) {
+	if !c.useV1 {
+		resp, err := c.v2.RedeemPickupCode(ctx, in, opts...)
+		if !c.fallBack(err) {
+			return resp, serverError(err)
+		}
+	}
+	resp, err := c.v1.RedeemPickupCode(ctx, in, opts...)
+	return resp, serverError(err)
+}
+
+func (c *fallbackClient) EnrollDevice(ctx context.Context, in *pb.EnrollDeviceRequest, opts ...grpc.CallOption) (*pb.EnrollDeviceResponse, error) {
+	if !c.useV1 {
+		resp, err := c.v2.EnrollDevice(ctx, in, opts...)
+		if !c.fallBack(err) {
+			return resp, serverError(err)
+		}
+	}
+	resp, err := c.v1.EnrollDevice(ctx, in, opts...)
+	return resp, serverError(err)
+}
+
+func (c *fallbackClient) RevokeDevice(ctx context.Context, in *pb.RevokeDeviceRequest, opts ...grpc.CallOption) (*pb.RevokeDeviceResponse, error) {
+	if !c.useV1 {
+		resp, err := c.v2.RevokeDevice(ctx, in, opts...)
+		if !c.fallBack(err) {
+			return resp, serverError(err)
+		}
+	}
+	resp, err := c.v1.RevokeDevice(ctx, in, opts...)
+	return resp, serverError(err)
+}
+
+func (c *fallbackClient) IssueBatch(ctx context.Context, in *pb.BatchCertsRequest, opts ...grpc.CallOption) (*pb.BatchCertsResponse, error) {
+	if !c.useV1 {
+		resp, err := c.v2.IssueBatch(ctx, in, opts...)
+		if !c.fallBack(err) {
+			return resp, serverError(err)
+		}
+	}
+	resp, err := c.v1.IssueBatch(ctx, in, opts...)
+	return resp, serverError(err)
+}
+
+func (c *fallbackClient) ExplainPolicy(ctx context.Context, in *pb.ExplainPolicyRequest, opts ...grpc.CallOption) (*pb.ExplainPolicyResponse, error) {
+	if !c.useV1 {
+		resp, err := c.v2.ExplainPolicy(ctx, in, opts...)
+		if !c.fallBack(err) {
+			return resp, serverError(err)
+		}
+	}
+	resp, err := c.v1.ExplainPolicy(ctx, in, opts...)
+	return resp, serverError(err)
+}
+
+// A stream for WatchUpdates that falls back to v1 if the server doesn't support v2.
+// Only the first request is resent to the v1 stream, as the server won't respond to
+// the v2 stream before then.
+type fallbackWatchClient struct {
+	pb.GeeCertServer_WatchUpdatesClient
+
+	mu       sync.Mutex
+	c        *fallbackClient
+	ctx      context.Context
+	opts     []grpc.CallOption
+	first    *pb.WatchRequest
+	received bool
+}
+
+func (w *fallbackWatchClient) stream() pb.GeeCertServer_WatchUpdatesClient {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.GeeCertServer_WatchUpdatesClient
+}
+
+func (w *fallbackWatchClient) Send(m *pb.WatchRequest) error {
+	w.mu.Lock()
+	if w.first == nil {
+		w.first = m
+	}
+	w.mu.Unlock()
+	return w.stream().Send(m)
+}
+
+func (w *fallbackWatchClient) Recv() (*pb.WatchUpdate, error) {
+	u, err := w.stream().Recv()
+	if err != nil && !w.received && !w.c.useV1 && w.c.fallBack(err) {
+		w.mu.Lock()
+		stream, err := w.c.v1.WatchUpdates(w.ctx, w.opts...)
+		if err == nil {
+			w.GeeCertServer_WatchUpdatesClient = stream
+			if w.first != nil {
+				err = stream.Send(w.first)
+			}
+		}
+		w.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		return w.Recv()
+	}
+	if err != nil {
+		return nil, serverError(err)
+	}
+	w.received = true
+	return u, nil
+}
+
+func (c *fallbackClient) WatchUpdates(ctx context.Context, opts ...grpc.CallOption) (pb.GeeCertServer_WatchUpdatesClient, error) {
+	if c.useV1 {
+		return c.v1.WatchUpdates(ctx, opts...)
+	}
+	stream, err := c.v2.WatchUpdates(ctx, opts...)
+	if err != nil {
+		return nil, serverError(err)
+	}
+	return &fallbackWatchClient{
+		GeeCertServer_WatchUpdatesClient: stream,
+		c:                                c,
+		ctx:                              ctx,
+		opts:                             opts,
+	}, nil
+}
@@ -0,0 +1,100 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/continusec/geecert"
+)
+
+// Builds client binaries for several platforms with a config baked in, optionally signing them:
+// bakegeecert -out dist config.json
+func main() {
+	pkg := flag.String("pkg", "github.com/continusec/geecert/cmd/geecertsample", "Client package to build, whose main package declares: var bakedConfig string")
+	name := flag.String("name", "geecertsample", "Name of the binaries, which are written as name-GOOS-GOARCH")
+	platforms := flag.String("platforms", "darwin/amd64,darwin/arm64,linux/amd64,linux/arm64,windows/amd64", "Comma separated GOOS/GOARCH to build for")
+	outDir := flag.String("out", "dist", "Directory to write the binaries to")
+	tags := flag.String("tags", "", "Build tags, e.g. tray")
+	signDarwin := flag.String("sign_darwin", "", "Shell command run for each macOS binary with its path as $1, e.g. to codesign and notarize it")
+	signWindows := flag.String("sign_windows", "", "Shell command run for each Windows binary with its path as $1, e.g. to sign it with osslsigncode")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	configJSON, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	baked, err := geecert.EncodeBakedConfig(configJSON)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = os.MkdirAll(*outDir, 0755)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, p := range strings.Split(*platforms, ",") {
+		goos, goarch, ok := strings.Cut(strings.TrimSpace(p), "/")
+		if !ok {
+			log.Fatalf("Bad platform %q, should be GOOS/GOARCH.", p)
+		}
+		out := filepath.Join(*outDir, fmt.Sprintf("%s-%s-%s", *name, goos, goarch))
+		if goos == "windows" {
+			out += ".exe"
+		}
+
+		args := []string{"build", "-trimpath", "-ldflags", "-X main.bakedConfig=" + baked, "-o", out}
+		if len(*tags) > 0 {
+			args = append(args, "-tags", *tags)
+		}
+		cmd := exec.Command("go", append(args, *pkg)...)
+		cmd.Env = append(os.Environ(), "GOOS="+goos, "GOARCH="+goarch)
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = os.Stderr
+		err = cmd.Run()
+		if err != nil {
+			log.Fatalf("Building for %s/%s: %s", goos, goarch, err)
+		}
+
+		hook := map[string]string{"darwin": *signDarwin, "windows": *signWindows}[goos]
+		if len(hook) > 0 {
+			cmd = exec.Command("sh", "-c", hook, "sh", out)
+			cmd.Stdout = os.Stderr
+			cmd.Stderr = os.Stderr
+			err = cmd.Run()
+			if err != nil {
+				log.Fatalf("Signing %s: %s", out, err)
+			}
+		}
+
+		log.Printf("Built %s.\n", out)
+	}
+}
@@ -0,0 +1,68 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/continusec/geecert"
+)
+
+// Sets up sshd on a host to trust certificates from the CA, run once or periodically (e.g. from cron):
+// geecert-hostsetup -bootstrap_url https://sso.yourdomain.com -principals_map /etc/ssh/geecert_principals -reload_command "systemctl reload sshd"
+func main() {
+	opts := &geecert.HostSetupOptions{}
+	flag.StringVar(&opts.BootstrapURL, "bootstrap_url", "", "Fetch the CA public keys from /bootstrap/ca.pub under this https:// URL")
+	flag.StringVar(&opts.CAKeysPath, "ca_keys", "", "Else read the CA public keys from this file")
+	flag.StringVar(&opts.TrustedUserCAKeysPath, "trusted_user_ca_keys", geecert.DefaultTrustedUserCAKeysPath, "Where to write the CA public keys, for TrustedUserCAKeys")
+	flag.StringVar(&opts.SSHDConfigPath, "sshd_config", "/etc/ssh/sshd_config", "sshd config file to update")
+	flag.StringVar(&opts.PrincipalsMapPath, "principals_map", "", "File of lines of a username followed by the principals they may log in as, for AuthorizedPrincipalsFile")
+	flag.StringVar(&opts.AuthorizedPrincipalsDir, "authorized_principals_dir", "/etc/ssh/auth_principals", "Where to write a file of principals for each user in the map. Files for other users are removed")
+	flag.StringVar(&opts.SSHDPath, "sshd", "sshd", "sshd binary, run with -t to check the new config before installing it")
+	flag.StringVar(&opts.ReloadCommand, "reload_command", "", "Command to run after anything changes, e.g. systemctl reload sshd")
+	interval := flag.Duration("interval", 0, "If set, keep running, checking for changes such as CA rotations this often")
+	flag.Parse()
+
+	if flag.NArg() != 0 || (len(opts.BootstrapURL) == 0 && len(opts.CAKeysPath) == 0) {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	geecert.SetLogger(geecert.NewConsoleLogger(os.Stderr, geecert.LogInfo))
+	ctx := context.Background()
+	for {
+		changed, err := geecert.SetupHost(ctx, opts)
+		if err != nil {
+			if *interval == 0 {
+				log.Fatal(err)
+			}
+			log.Println(err)
+		} else if !changed && *interval == 0 {
+			log.Println("Already up to date.")
+		}
+		if *interval == 0 {
+			return
+		}
+		time.Sleep(*interval)
+	}
+}
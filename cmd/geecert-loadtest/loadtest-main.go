@@ -0,0 +1,90 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/continusec/geecert"
+	"github.com/continusec/geecert/geecerttest"
+)
+
+// Fires concurrent certificate requests at a server started with -insecure_fake_idp_key, as
+// synthetic users, and reports latency percentiles:
+// geecert-loadtest -server sso.test:10000 -idp_key fake-idp.pem -users 1000 -requests 10000
+func main() {
+	config := &geecert.ClientAppConfiguration{}
+	flag.StringVar(&config.GRPCServer, "server", "localhost:10000", "Address:port of the server to test")
+	flag.StringVar(&config.GRPCPEMCertificatePath, "server_cert", "", "Certificate expected from the server for TLS")
+	flag.BoolVar(&config.UseSystemCaForCert, "server_cert_from_real_ca", false, "Use system CA for server cert")
+	flag.BoolVar(&config.OverrideGrpcSecurity, "allow_insecure_connect_to_sso_server", false, "Don't check the server's certificate")
+	idpKey := flag.String("idp_key", "fake-idp.pem", "Key to sign ID tokens with, created if it doesn't exist. Pass the same file to servegeecerts -insecure_fake_idp_key")
+	clientID := flag.String("client_id", "", "allowed_client_id_for_id_token from the server config")
+	domain := flag.String("domain", "", "allowed_domain_for_id_token from the server config")
+	emailFormat := flag.String("email_format", "loadtest-%d@%s", "Format of synthetic email addresses, given the user number and domain")
+	users := flag.Int("users", 100, "Number of synthetic users")
+	requests := flag.Int("requests", 1000, "Total number of requests")
+	concurrency := flag.Int("concurrency", 50, "Number of requests in flight at once")
+	printUsers := flag.Bool("print_users", false, "Print allowed_users entries for the synthetic users, to add to the server config, then exit")
+	flag.Parse()
+
+	if len(*clientID) == 0 || len(*domain) == 0 || *users < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	emails := make([]string, *users)
+	for i := range emails {
+		emails[i] = fmt.Sprintf(*emailFormat, i, *domain)
+	}
+
+	if *printUsers {
+		for _, email := range emails {
+			fmt.Printf("allowed_users: <\n    key: %q\n    value: <\n        username: \"loadtest\"\n    >\n>\n", email)
+		}
+		return
+	}
+
+	idp, err := geecerttest.LoadOrCreateFakeIdP(*idpKey, *clientID, *domain)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Ctrl-C stops early and prints the results so far
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	geecert.SetLogger(geecert.NewConsoleLogger(os.Stderr, geecert.LogWarning))
+	result, err := geecerttest.RunLoadTest(ctx, &geecerttest.LoadTestOptions{
+		Config:      config,
+		IdP:         idp,
+		Emails:      emails,
+		Requests:    *requests,
+		Concurrency: *concurrency,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(result)
+}
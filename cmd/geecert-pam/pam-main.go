@@ -0,0 +1,76 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/continusec/geecert"
+)
+
+// Authenticates PAM_USER with a token from getmycerts pam-token, given as the password by pam_exec:
+// auth sufficient pam_exec.so expose_authtok quiet /usr/local/bin/geecert-pam -principal admin
+func main() {
+	v := &geecert.PAMVerifier{}
+	caKeys := flag.String("ca_keys", geecert.DefaultTrustedUserCAKeysPath, "File of CA public keys to trust, as written by geecert-hostsetup")
+	flag.Func("principal", "Principal the certificate must have, may be repeated. Defaults to the user being authenticated", func(s string) error {
+		v.Principals = append(v.Principals, s)
+		return nil
+	})
+	flag.StringVar(&v.Hostname, "hostname", "", "Name of this host in tokens, if not its hostname")
+	flag.DurationVar(&v.MaxAge, "max_age", geecert.DefaultPAMTokenMaxAge, "How old tokens may be")
+	flag.StringVar(&v.UsedDir, "used_dir", geecert.DefaultPAMUsedTokensDir, "Directory to record accepted tokens in until they expire, so that each is only accepted once")
+	flag.Parse()
+
+	log.SetPrefix("geecert-pam: ")
+	username := os.Getenv("PAM_USER")
+	if flag.NArg() != 0 || len(username) == 0 || os.Getenv("PAM_TYPE") != "auth" {
+		log.Fatal("Must be run by pam_exec with expose_authtok, for auth.")
+	}
+
+	var err error
+	if len(v.Hostname) == 0 {
+		v.Hostname, err = os.Hostname()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	v.CAKeys, err = geecert.LoadAuthorizedKeys(*caKeys)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// pam_exec writes the password followed by a NUL
+	token, err := io.ReadAll(io.LimitReader(os.Stdin, 1<<16))
+	if err != nil {
+		log.Fatal(err)
+	}
+	token = bytes.TrimRight(token, "\x00")
+
+	cert, err := v.Verify(username, string(token), time.Now())
+	if err != nil {
+		log.Fatalf("Refusing %s: %s", username, err)
+	}
+	log.Printf("Authenticated %s with certificate %d (%s).", username, cert.Serial, cert.KeyId)
+}
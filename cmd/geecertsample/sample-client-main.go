@@ -19,8 +19,11 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
+	"os"
+	"os/signal"
 
 	"github.com/continusec/geecert"
 )
@@ -44,20 +47,110 @@ MIIF...RI=
 	CredentialFileName: ".orgnamesso",
 	ShortlivedKeyName:  "id_orgname_shortlived_rsa",
 	SectionIdentifier:  "ORGNAME-CA",
+	ClientName:         "geecertsample",
+
+	// Releases for the update command, signed with signgeecertupdate
+	// UpdateManifestURL:   "https://downloads.orgname.com/geecertsample/manifest.signed",
+	// UpdatePublicKey:     "ssh-ed25519 AAAA... orgname-release",
+	// AutoCheckForUpdates: true,
 
 	// Other fields are specified via defaults in flags below
 }
 
+// Set at build time by bakegeecert to override LocalConfiguration and the defaults of flags below.
+var bakedConfig string
+
 func main() {
-	flag.StringVar(&LocalConfiguration.GRPCServer, "server", "sso.orgname.com:10000", "Address:port of the server to connect to")
+	flag.StringVar(&LocalConfiguration.GRPCServer, "server", "sso.orgname.com:10000", "Address:port of the server to connect to, several separated by commas to fail over between, or srv: and a DNS SRV name")
+	flag.StringVar(&LocalConfiguration.BootstrapURL, "bootstrap_url", "", "Fetch organization config not set in the binary from the server at this URL, e.g. https://sso.orgname.com.")
 	flag.StringVar(&LocalConfiguration.GRPCPEMCertificatePath, "server_cert", "", "Certificate expected from the server for TLS, overrides default in binary")
 	flag.BoolVar(&LocalConfiguration.OverrideMachinePolicy, "override_machine_policy", false, "Please don't use this.")
 	flag.BoolVar(&LocalConfiguration.OverrideGrpcSecurity, "allow_insecure_connect_to_sso_server", false, "Please don't use this.")
 	flag.BoolVar(&LocalConfiguration.UseSystemCaForCert, "server_cert_from_real_ca", false, "Use system CA for server cert.")
+	flag.IntVar(&LocalConfiguration.MaxMessageBytes, "max_message_bytes", geecert.DefaultMaxMessageBytes, "Largest response to accept from the server, e.g. for many certificate authorities or much config.")
+	flag.StringVar(&LocalConfiguration.ServerBalancing, "server_balancing", geecert.ServerPickFirst, "With several servers, pick_first to use the first that can be connected to, or round_robin to spread requests over healthy servers.")
+	flag.StringVar(&LocalConfiguration.ServerName, "server_name", "", "Name to expect in the server's TLS certificate, e.g. if -server is an IP address.")
+	flag.StringVar(&LocalConfiguration.DNSOverHTTPSURL, "dns_over_https", "", "Look up the server and Google with this DNS over HTTPS resolver, e.g. https://1.1.1.1/dns-query, if the network interferes with DNS.")
+	flag.DurationVar(&LocalConfiguration.ConnectTimeout, "connect_timeout", geecert.DefaultConnectTimeout, "Longest to wait to connect to the server or Google.")
+	flag.DurationVar(&LocalConfiguration.FallbackDelay, "fallback_delay", geecert.DefaultFallbackDelay, "How long to wait to connect over IPv6 before also trying IPv4, negative to only try IPv4 once IPv6 fails.")
+	flag.DurationVar(&LocalConfiguration.KeepaliveInterval, "keepalive", 0, "If set, how often to ping the server while waiting on it, e.g. 30s, to notice a dropped connection.")
+	flag.BoolVar(&LocalConfiguration.InstallPuTTY, "putty", false, "Also write key in PuTTY format and trust CA in PuTTY.")
+	flag.BoolVar(&LocalConfiguration.InstallVSCode, "vscode", false, "Also update ssh config file used by VS Code Remote SSH.")
+	flag.StringVar(&LocalConfiguration.Agents, "agents", "openssh", "Comma separated agents to add key to if running: openssh, pageant")
+	flag.BoolVar(&LocalConfiguration.UseMacKeychain, "use_keychain", false, "On macOS, configure ssh to re-add the key to the agent and keychain when used.")
+	flag.BoolVar(&LocalConfiguration.GPGAgentAddPlainKey, "gpg_agent_add_key", false, "If ssh-agent is gpg-agent, add the key to it without the certificate.")
+	flag.StringVar(&LocalConfiguration.WSLAgentPipe, "wsl_agent_pipe", geecert.DefaultWSLAgentPipe, "Under WSL with no SSH_AUTH_SOCK, the Windows agent pipe to add the key to via npiperelay.exe.")
+	flag.StringVar(&LocalConfiguration.KeyPath, "key_path", "", "Path to write private key to, default is in ~/.ssh")
+	flag.StringVar(&LocalConfiguration.CertPath, "cert_path", "", "Path to write certificate to, default is alongside private key")
+	flag.StringVar(&LocalConfiguration.KnownHostsPath, "known_hosts_path", "", "Path of known_hosts file to update, default is ~/.ssh/known_hosts")
+	flag.StringVar(&LocalConfiguration.SSHConfigPath, "ssh_config_path", "", "Path of ssh config file to update, default is ~/.ssh/config")
+	flag.BoolVar(&LocalConfiguration.SystemWide, "system", false, "Install the certificate authorities and ssh config for all users, in /etc/ssh, e.g. on shared build machines. Needs root.")
+	flag.BoolVar(&LocalConfiguration.AutoRenew, "auto_renew", false, "Configure ssh to renew the certificate when near expiry.")
+	flag.DurationVar(&LocalConfiguration.BrowserTimeout, "browser_timeout", geecert.DefaultBrowserTimeout, "How long to wait for authorization in the browser.")
+	flag.StringVar(&LocalConfiguration.RedirectPorts, "redirect_ports", "", "Ports to listen on for the browser's redirect, e.g. 8400-8410, default is any free port.")
+	flag.BoolVar(&LocalConfiguration.ShowQRCode, "qr", false, "When signing in without a browser, also show the URL to visit as a QR code.")
+	flag.StringVar(&LocalConfiguration.BreakGlassKey, "break_glass_key", "", "Public key of hardware token in ssh-agent, for the break-glass command.")
+	flag.StringVar(&LocalConfiguration.Reason, "reason", "", "Reason for the certificate, e.g. a ticket number, recorded in the certificate if the server is so configured.")
+	flag.StringVar(&LocalConfiguration.OnBehalfOf, "on_behalf_of", "", "Email of the user to request a certificate on behalf of, if the server allows you to.")
+	flag.StringVar(&LocalConfiguration.Role, "role", "", "Role to be granted just in time, e.g. for a group of hosts, with -ticket.")
+	flag.StringVar(&LocalConfiguration.Ticket, "ticket", "", "With -role, the ticket the access is for, e.g. INC-1234.")
+	flag.DurationVar(&LocalConfiguration.RoleDuration, "role_duration", 0, "With -role, how long the certificate should be valid, default the longest the role allows.")
+	flag.StringVar(&LocalConfiguration.SourceAddress, "source_address", "", "Comma separated CIDRs to restrict the certificate to, if the server allows.")
+	flag.BoolVar(&LocalConfiguration.Notifications, "notify", false, "Show desktop notifications when a certificate is installed or about to expire.")
+	flag.DurationVar(&LocalConfiguration.NotifyBefore, "notify_before", geecert.DefaultNotifyBefore, "With -notify, how long before expiry to notify.")
+	flag.DurationVar(&LocalConfiguration.MinRemaining, "min_remaining", 0, "Don't fetch a new certificate if the current one is valid for at least this long, e.g. 30m.")
+	flag.BoolVar(&LocalConfiguration.ReuseKey, "reuse_key", false, "Keep the existing private key and only fetch a new certificate for it.")
+	flag.BoolVar(&LocalConfiguration.RotateKey, "rotate_key", false, "Generate a new private key, even with -reuse_key.")
+	flag.Func("pre_issue_hook", "Shell command to run before requesting a certificate, which can refuse by failing. May be repeated.", func(s string) error {
+		LocalConfiguration.PreIssueHooks = append(LocalConfiguration.PreIssueHooks, s)
+		return nil
+	})
+	flag.Func("post_install_hook", "Shell command to run after a certificate is installed, with its details in GEECERT_* environment variables. May be repeated.", func(s string) error {
+		LocalConfiguration.PostInstallHooks = append(LocalConfiguration.PostInstallHooks, s)
+		return nil
+	})
+	flag.DurationVar(&LocalConfiguration.OfflineGrace, "offline_grace", 0, "If the server can't be reached, carry on without error if the current certificate is valid for at least this long, e.g. 1h.")
+	flag.BoolVar(&LocalConfiguration.Telemetry, "telemetry", false, "Report outcomes, client version and platform (but not who you are) to the server.")
+	flag.BoolVar(&LocalConfiguration.FollowSymlinks, "follow_symlinks", false, "If files to update are symlinks, e.g. from a dotfile manager, update the files they link to.")
+	flag.BoolVar(&LocalConfiguration.NoFixPermissions, "no_fix_perms", false, "Only warn about permissions on ~/.ssh and the files installed that ssh would refuse, rather than fixing them.")
+	flag.StringVar(&LocalConfiguration.BrokeredAuth, "brokered_auth", "", "Use the ID token of the user signed in to the Cloud SDK instead of signing in: gcloud or adc.")
+	flag.StringVar(&LocalConfiguration.OAuthClientProfile, "client_profile", "", "Which OAuth client ID to use, e.g. ci, default is the one for this platform.")
+	flag.StringVar(&LocalConfiguration.SAMLBridgeURL, "saml_bridge", "", "Sign in with your organization's SAML IdP via the SAML bridge at this URL, e.g. https://sso.yourdomain.com.")
+	flag.StringVar(&LocalConfiguration.KerberosSPN, "kerberos_spn", "", "Authenticate with your Kerberos ticket for this service principal, e.g. HTTP/sso.yourdomain.com, instead of signing in.")
+	flag.StringVar(&LocalConfiguration.KubernetesTokenPath, "kubernetes_token_path", "", "Authenticate with the Kubernetes service account token at this path, e.g. "+geecert.DefaultKubernetesTokenPath+", instead of signing in.")
+	verbose := flag.Bool("verbose", false, "Show more detail of what is being done.")
+	debug := flag.Bool("debug", false, "Also show HTTP and gRPC requests and responses, with secrets removed.")
+
+	err := geecert.ApplyBakedConfig(bakedConfig, &LocalConfiguration)
+	if err != nil {
+		log.Fatal(err)
+	}
 	flag.Parse()
+	// The flags, without the command, so that we can be run again in the same way, e.g. by git
+	LocalConfiguration.Flags = os.Args[1 : len(os.Args)-flag.NArg()]
+
+	switch {
+	case *debug:
+		geecert.SetLogger(geecert.NewConsoleLogger(os.Stderr, geecert.LogDebug))
+	case *verbose:
+		geecert.SetLogger(geecert.NewConsoleLogger(os.Stderr, geecert.LogVerbose))
+	}
 
-	err := geecert.ProcessClient(&LocalConfiguration)
+	// Stop waiting for the browser, server etc. on Ctrl-C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err = geecert.ApplyBootstrapConfig(ctx, &LocalConfiguration)
+	switch {
+	case err != nil:
+	case flag.Arg(0) == "tray":
+		err = runTray(&LocalConfiguration)
+	default:
+		err = geecert.RunCommand(ctx, &LocalConfiguration, flag.Args())
+	}
 	if err != nil {
-		log.Fatal(err)
+		log.Print(err)
+		stop()
+		os.Exit(geecert.ExitCode(err))
 	}
 }
@@ -0,0 +1,200 @@
+//go:build tray
+// +build tray
+
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"sync"
+	"time"
+
+	"github.com/continusec/geecert"
+	"github.com/getlantern/systray"
+)
+
+const trayRefreshInterval = 30 * time.Second
+
+var (
+	trayColorValid    = color.RGBA{0x2e, 0xa0, 0x43, 0xff}
+	trayColorExpiring = color.RGBA{0xe0, 0x9b, 0x00, 0xff}
+	trayColorExpired  = color.RGBA{0xc6, 0x28, 0x28, 0xff}
+)
+
+// Runs in the macOS menu bar, Windows notification area or Linux app indicator area until
+// quit, showing how long the certificate is valid for, with a menu item to renew it.
+func runTray(config *geecert.ClientAppConfiguration) error {
+	var err error
+	systray.Run(func() {
+		err = trayReady(config)
+		if err != nil {
+			systray.Quit()
+		}
+	}, func() {})
+	return err
+}
+
+type tray struct {
+	config *geecert.ClientAppConfiguration
+
+	status *systray.MenuItem
+	renew  *systray.MenuItem
+	quit   *systray.MenuItem
+
+	lock       sync.Mutex
+	renewing   bool
+	warnedCert uint64 // serial of the certificate we last warned about, so we only warn once
+}
+
+func trayReady(config *geecert.ClientAppConfiguration) error {
+	t := &tray{config: config}
+	systray.SetTooltip("SSH certificate")
+	t.status = systray.AddMenuItem("", "")
+	t.status.Disable()
+	t.renew = systray.AddMenuItem("Renew now", "Fetch a new SSH certificate")
+	systray.AddSeparator()
+	t.quit = systray.AddMenuItem("Quit", "")
+
+	t.refresh()
+	go t.loop()
+	return nil
+}
+
+func (t *tray) loop() {
+	ticker := time.NewTicker(trayRefreshInterval)
+	defer ticker.Stop()
+	wake := geecert.Wakeups(context.Background())
+	for {
+		select {
+		case <-ticker.C:
+			t.refresh()
+		case <-wake:
+			t.refresh()
+		case <-t.renew.ClickedCh:
+			go t.doRenew()
+		case <-t.quit.ClickedCh:
+			systray.Quit()
+			return
+		}
+	}
+}
+
+func (t *tray) doRenew() {
+	t.lock.Lock()
+	if t.renewing {
+		t.lock.Unlock()
+		return
+	}
+	t.renewing = true
+	t.lock.Unlock()
+
+	t.renew.SetTitle("Renewing...")
+	t.renew.Disable()
+	err := geecert.ProcessClient(context.Background(), t.config)
+	t.renew.SetTitle("Renew now")
+	t.renew.Enable()
+
+	t.lock.Lock()
+	t.renewing = false
+	t.lock.Unlock()
+
+	if err != nil {
+		geecert.Notify("SSH certificate renewal failed", err.Error())
+	}
+	t.refresh()
+}
+
+// Updates the title, icon and status from the installed certificate, and warns if it expires soon.
+func (t *tray) refresh() {
+	cert, err := geecert.LoadInstalledCert(t.config)
+	if err != nil {
+		t.show("No certificate", "No SSH certificate installed", trayColorExpired)
+		return
+	}
+
+	warnBefore := t.config.NotifyBefore
+	if warnBefore == 0 {
+		warnBefore = geecert.DefaultNotifyBefore
+	}
+
+	remaining := time.Until(time.Unix(int64(cert.ValidBefore), 0))
+	switch {
+	case remaining <= 0:
+		t.show("Expired", "SSH certificate has expired", trayColorExpired)
+	case remaining < warnBefore:
+		t.show(formatRemaining(remaining), "SSH certificate expires in "+formatRemaining(remaining), trayColorExpiring)
+		t.lock.Lock()
+		warn := t.warnedCert != cert.Serial
+		t.warnedCert = cert.Serial
+		t.lock.Unlock()
+		if warn {
+			geecert.Notify("SSH certificate expiring", fmt.Sprintf("Your SSH certificate expires in %s. Choose Renew now to fetch a new one.", formatRemaining(remaining)))
+		}
+	default:
+		t.show(formatRemaining(remaining), "SSH certificate expires in "+formatRemaining(remaining), trayColorValid)
+	}
+}
+
+func (t *tray) show(title, status string, c color.RGBA) {
+	systray.SetTitle(title)
+	systray.SetTooltip(status)
+	systray.SetIcon(trayIcon(c))
+	t.status.SetTitle(status)
+}
+
+// e.g. 3h12m, 8m
+func formatRemaining(d time.Duration) string {
+	d = d.Truncate(time.Minute)
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// A filled circle of the given color, as an ICO containing a PNG, which Windows requires
+// and the other platforms accept.
+func trayIcon(c color.RGBA) []byte {
+	const size = 16
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			dx, dy := 2*x-size+1, 2*y-size+1
+			if dx*dx+dy*dy <= (size-2)*(size-2) {
+				img.Set(x, y, c)
+			}
+		}
+	}
+	var p bytes.Buffer
+	png.Encode(&p, img)
+
+	var ico bytes.Buffer
+	binary.Write(&ico, binary.LittleEndian, []uint16{0, 1, 1})                 // reserved, type icon, 1 image
+	ico.Write([]byte{size, size, 0, 0})                                        // width, height, no palette, reserved
+	binary.Write(&ico, binary.LittleEndian, []uint16{1, 32})                   // planes, bits per pixel
+	binary.Write(&ico, binary.LittleEndian, []uint32{uint32(p.Len()), 6 + 16}) // size, offset of image
+	ico.Write(p.Bytes())
+	return ico.Bytes()
+}
@@ -0,0 +1,34 @@
+//go:build !tray
+// +build !tray
+
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package main
+
+import (
+	"errors"
+
+	"github.com/continusec/geecert"
+)
+
+var ErrNoTray = errors.New("Built without tray support, rebuild with: go build -tags tray")
+
+func runTray(config *geecert.ClientAppConfiguration) error {
+	return ErrNoTray
+}
@@ -19,260 +19,91 @@ limitations under the License.
 package main
 
 import (
-	"encoding/base64"
-	"encoding/pem"
-	"errors"
+	"context"
+	"flag"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"net"
 	"os"
-	"path/filepath"
-	"strings"
 
-	"github.com/golang/protobuf/proto"
-
-	"golang.org/x/net/context"
-
-	"github.com/continusec/geecert"
-	pb "github.com/continusec/geecert/sso"
+	"github.com/continusec/geecert/geecerttest"
+	"github.com/continusec/geecert/server"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 
-	"time"
-
-	"crypto/rand"
-	"crypto/rsa"
-	"crypto/x509"
-
-	"net/http"
-
 	"github.com/mholt/caddy"
-	"golang.org/x/crypto/ssh"
 
 	_ "github.com/mholt/caddy/caddyhttp"
 )
 
-type SSOServer struct {
-	Config *pb.ServerConfig
-}
-
-// Generate a host cert for whatever we see
-func (s *SSOServer) makeHostCert(w http.ResponseWriter, h string) {
-	var certToReturn []byte
-	var kt string
-
-	ssh.Dial("tcp", fmt.Sprintf("%s:%d", h, 22), &ssh.ClientConfig{
-		User: "ca",
-		Auth: []ssh.AuthMethod{
-			ssh.Password("wrongpassignoreme"),
-		},
-		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
-			if key == nil {
-				return errors.New("no host key")
-			}
-			caKey, err := LoadPrivateKeyFromPEM(s.Config.CaKeyPath)
-			if err != nil {
-				return err
-			}
-
-			cert, nva, err := CreateHostCertificate(h, key, caKey, time.Duration(s.Config.GenerateCertDurationSeconds)*time.Second)
-			if err != nil {
-				return err
-			}
-			kt = key.Type()
-
-			log.Printf("Issued host certificate for %s valid until %s.\n", h, nva.Format(time.RFC3339))
-
-			certToReturn = cert
-			return errors.New("fail now please")
-		},
-	})
-
-	// Ignore error code for above, as we'll definitely fail due to no creds
-	if len(certToReturn) == 0 {
-		w.WriteHeader(http.StatusBadRequest)
-		return
-	}
-
-	fmt.Fprintf(w, "%s-cert-v01@openssh.com %s %s\n", kt, base64.StdEncoding.EncodeToString(certToReturn), h)
-}
-
-func (s *SSOServer) issueHostCertificate(w http.ResponseWriter, r *http.Request) {
-	h := r.FormValue("host")
-	for _, m := range s.Config.AllowedHosts {
-		matched, err := filepath.Match(m, h)
+func main() {
+	printDNSRecords := flag.Bool("print_dns_records", false, "Print DNS records publishing the CA public key, then exit.")
+	updateDNS := flag.Bool("update_dns", false, "Update DNS records publishing the CA public key using dns_update_server, then exit.")
+	enableBreakGlass := flag.Bool("enable_break_glass", false, "Accept break glass requests from users in break_glass_user. Only set this while the IdP is unavailable.")
+	checkConfig := flag.Bool("check_config", false, "Check the config file, including that the keys and other files it refers to can be loaded, then exit. Each problem found is printed on its own line.")
+	fakeIdPKey := flag.String("insecure_fake_idp_key", "", "Accept ID tokens signed by this key (as created by geecert-loadtest) instead of Google's. For load testing only.")
+	caKeyType := flag.String("ca_key_type", server.CAKeyTypeEd25519, "With init-ca, the type of CA key to generate: rsa, ecdsa or ed25519.")
+	caComment := flag.String("ca_comment", "geecert-ca", "With init-ca, the comment for the CA public key.")
+	flag.Parse()
+
+	// init-ca /path/to/ssh-ca
+	if flag.NArg() == 2 && flag.Arg(0) == "init-ca" {
+		err := server.InitCA(os.Stdout, flag.Arg(1), *caKeyType, *caComment)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			return
-		}
-		if matched {
-			s.makeHostCert(w, h)
-			return
+			log.Fatal(err)
 		}
+		return
 	}
-	w.WriteHeader(http.StatusBadRequest)
-	return
-}
-
-func (s *SSOServer) StartHTTP() {
-	http.HandleFunc("/hostCertificate", s.issueHostCertificate)
-	http.ListenAndServe(fmt.Sprintf("localhost:%d", s.Config.HttpListenPort), nil)
-}
-
-func (s *SSOServer) GetSSHCerts(ctx context.Context, in *pb.SSHCertsRequest) (*pb.SSHCertsResponse, error) {
-	idTokenClaims, err := geecert.ValidateIDToken(in.IdToken, s.Config.AllowedClientIdForIdToken, s.Config.AllowedDomainForIdToken)
-	if err != nil {
-		return nil, err
-	}
-
-	userConf, ok := s.Config.AllowedUsers[idTokenClaims.EmailAddress]
-	if !ok {
-		return &pb.SSHCertsResponse{
-			Status: pb.ResponseCode_NO_CERTS_ALLOWED,
-		}, nil
-	}
-
-	rpk, err := base64.StdEncoding.DecodeString(in.PublicKey)
-	if err != nil {
-		return nil, err
-	}
-
-	keyToSign, err := ssh.ParsePublicKey(rpk)
-	if err != nil {
-		return nil, err
-	}
-
-	caKey, err := LoadPrivateKeyFromPEM(s.Config.CaKeyPath)
-	if err != nil {
-		return nil, err
-	}
-
-	ourCAPubKey, err := ssh.NewPublicKey(&caKey.PublicKey)
-	if err != nil {
-		return nil, err
-	}
-
-	cert, nva, err := CreateUserCertificate(append([]string{userConf.Username}, userConf.ExtraPrincipals...), idTokenClaims.EmailAddress, keyToSign, caKey, time.Duration(s.Config.GenerateCertDurationSeconds)*time.Second, userConf.CertPermissions)
-	if err != nil {
-		return nil, err
-	}
-
-	log.Printf("Issued certificate to %s valid until %s.\n", idTokenClaims.EmailAddress, nva.Format(time.RFC3339))
-
-	return &pb.SSHCertsResponse{
-		Status:      pb.ResponseCode_OK,
-		Certificate: fmt.Sprintf("ssh-rsa-cert-v01@openssh.com %s %s\n", base64.StdEncoding.EncodeToString(cert), idTokenClaims.EmailAddress),
-		CertificateAuthorities: []string{
-			fmt.Sprintf("@cert-authority %s ssh-rsa %s %s", s.Config.ClientConfigScope, base64.StdEncoding.EncodeToString(ourCAPubKey.Marshal()), s.Config.CaComment),
-		},
-		Config: augmentWithIndented([]string{
-			"Host " + s.Config.ClientConfigScope,
-			"    User " + userConf.Username,
-			"    IdentityFile $CERTNAME", // client to replace
-			"    IdentitiesOnly yes",
-			"    PasswordAuthentication no",
-		}, s.Config.AdditionalSshConfigurationLine, "    "),
-	}, nil
-}
-
-func augmentWithIndented(base []string, additional []string, indent string) []string {
-	for _, line := range additional {
-		base = append(base, indent+line)
-	}
-	return base
-}
 
-func LoadPrivateKeyFromPEM(path string) (*rsa.PrivateKey, error) {
-	data, err := ioutil.ReadFile(path)
-	if err != nil {
-		return nil, err
+	if flag.NArg() != 1 {
+		log.Fatal("Please specify a config file for the server to use.")
 	}
 
-	// Decode PEM
-	block, _ := pem.Decode(data)
-	if block == nil {
-		return nil, errors.New("Bad PEM")
-	}
-	if block.Type != "RSA PRIVATE KEY" {
-		return nil, errors.New("Unexpected block")
-	}
+	ctx := context.Background()
 
-	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	conf, err := server.LoadConfig(flag.Arg(0))
 	if err != nil {
-		return nil, err
+		log.Fatal(err)
 	}
 
-	return key, nil
-}
-
-func CreateHostCertificate(hostname string, keyToSign ssh.PublicKey, signingKey *rsa.PrivateKey, duration time.Duration) ([]byte, *time.Time, error) {
-	signer, err := ssh.NewSignerFromKey(signingKey)
-	if err != nil {
-		return nil, nil, err
-	}
-	now := time.Now()
-	end := now.Add(duration)
-	cert := ssh.Certificate{
-		Key:             keyToSign,
-		CertType:        ssh.HostCert,
-		KeyId:           hostname,
-		ValidPrincipals: []string{hostname},
-		ValidAfter:      uint64(now.Unix()),
-		ValidBefore:     uint64(end.Unix()),
-	}
-	err = cert.SignCert(rand.Reader, signer)
-	if err != nil {
-		return nil, nil, err
+	if *checkConfig {
+		err = server.CheckConfig(conf)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Println("Config OK.")
+		return
 	}
-	return cert.Marshal(), &end, nil
-}
 
-func CreateUserCertificate(usernames []string, emailAddress string, keyToSign ssh.PublicKey, signingKey *rsa.PrivateKey, duration time.Duration, perms map[string]string) ([]byte, *time.Time, error) {
-	signer, err := ssh.NewSignerFromKey(signingKey)
-	if err != nil {
-		return nil, nil, err
-	}
-	now := time.Now()
-	end := now.Add(duration)
-	cert := ssh.Certificate{
-		Key:             keyToSign,
-		CertType:        ssh.UserCert,
-		KeyId:           strings.Join(usernames, "/") + " (for " + emailAddress + ")",
-		ValidPrincipals: usernames,
-		ValidAfter:      uint64(now.Unix()),
-		ValidBefore:     uint64(end.Unix()),
-		Permissions: ssh.Permissions{
-			Extensions: perms,
-		},
-	}
-	err = cert.SignCert(rand.Reader, signer)
+	err = server.ValidateConfig(conf)
 	if err != nil {
-		return nil, nil, err
-	}
-	return cert.Marshal(), &end, nil
-}
-
-func main() {
-	if len(os.Args) != 2 {
-		log.Fatal("Please specify a config file for the server to use.")
+		log.Fatal(err)
 	}
 
-	confData, err := ioutil.ReadFile(os.Args[1])
-	if err != nil {
-		log.Fatal(err)
+	if *printDNSRecords {
+		rrs, err := server.CADNSRecords(conf)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, rr := range rrs {
+			fmt.Println(rr.String())
+		}
+		return
 	}
 
-	conf := &pb.ServerConfig{}
-	err = proto.UnmarshalText(string(confData), conf)
-	if err != nil {
-		log.Fatal(err)
+	if *updateDNS {
+		err = server.UpdateCADNSRecords(ctx, conf)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
 
 	var input caddy.Input
 	if conf.CaddyFilePath != "" {
 		caddy.SetDefaultCaddyfileLoader("default", caddy.LoaderFunc(func(serverType string) (caddy.Input, error) {
-			contents, err := ioutil.ReadFile(conf.CaddyFilePath)
+			contents, err := os.ReadFile(conf.CaddyFilePath)
 			if err != nil {
 				return nil, err
 			}
@@ -296,18 +127,94 @@ func main() {
 		log.Fatal(err)
 	}
 
-	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", conf.ListenPort))
+	listeners, err := server.OpenListeners(conf)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	grpcServer := grpc.NewServer(grpc.Creds(tc))
-	sso := &SSOServer{Config: conf}
-	pb.RegisterGeeCertServerServer(grpcServer, sso)
+	store, err := server.OpenStore(ctx, conf)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer store.Close()
+
+	sso := &server.SSOServer{Config: conf, Store: store, BreakGlassEnabled: *enableBreakGlass}
+	if conf.AcceptTelemetry {
+		sso.Telemetry = server.NewTelemetryCounts()
+	}
+	if conf.SamlBridge != nil {
+		sso.SAMLBridge, err = server.NewSAMLBridge(conf)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if conf.Kerberos != nil {
+		sso.Kerberos, err = server.NewKerberosAuthenticator(conf)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if len(conf.Kubernetes) > 0 {
+		sso.Kubernetes, err = server.NewKubernetesAuthenticator(conf)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if conf.Mdm != nil {
+		sso.MDM, err = server.NewMDMClient(conf)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if conf.Slack != nil {
+		sso.Slack, err = server.NewSlackClient(conf)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if conf.TicketWebhook != nil {
+		sso.Tickets, err = server.NewTicketWebhookClient(conf)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if len(conf.GeoipPath) > 0 {
+		sso.GeoIP, err = server.LoadGeoIP(conf.GeoipPath)
+		if err != nil {
+			log.Fatalf("geoip_path: %s", err)
+		}
+	}
+	if conf.ClockCheck != nil {
+		err = sso.CheckClock(ctx)
+		if err != nil {
+			log.Printf("WARNING: %s\n", err)
+		}
+		go sso.WatchClock(ctx)
+	}
+	if sso.BreakGlassEnabled {
+		log.Println("WARNING: Break glass issuance is enabled.")
+	}
+	if *fakeIdPKey != "" {
+		idp, err := geecerttest.LoadOrCreateFakeIdP(*fakeIdPKey, conf.AllowedClientIdForIdToken, conf.AllowedDomainForIdToken)
+		if err != nil {
+			log.Fatal(err)
+		}
+		sso.IDTokenValidator = idp
+		log.Println("WARNING: Accepting ID tokens from a fake IdP instead of Google. Never do this in production.")
+	}
+	grpcServer := grpc.NewServer(append([]grpc.ServerOption{grpc.Creds(tc)}, sso.ServerOptions()...)...)
+	sso.Register(grpcServer)
+	var plaintextServer *grpc.Server
+	for _, l := range listeners {
+		if l.Plaintext && plaintextServer == nil {
+			plaintextServer = grpc.NewServer(sso.ServerOptions()...)
+			sso.Register(plaintextServer)
+		}
+	}
 
 	log.Println("Serving...")
 	if conf.HttpListenPort != 0 {
-		go sso.StartHTTP()
+		go sso.StartHTTP(ctx)
 
 		if input != nil {
 			_, err := caddy.Start(input)
@@ -317,5 +224,16 @@ func main() {
 		}
 	}
 
-	grpcServer.Serve(lis)
+	errs := make(chan error)
+	for _, l := range listeners {
+		log.Printf("Serving gRPC on %s %s.\n", l.Addr().Network(), l.Addr())
+		gs := grpcServer
+		if l.Plaintext {
+			gs = plaintextServer
+		}
+		go func(l net.Listener) {
+			errs <- gs.Serve(l)
+		}(l)
+	}
+	log.Fatal(<-errs)
 }
@@ -0,0 +1,64 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/continusec/geecert"
+	"golang.org/x/crypto/ssh"
+)
+
+// Signs a release manifest for the client update command:
+// signgeecertupdate -key /path/to/release-key manifest.json > manifest.signed
+func main() {
+	keyPath := flag.String("key", "", "Path to the (unencrypted) private key to sign with")
+	flag.Parse()
+
+	if len(*keyPath) == 0 || flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	keyBytes, err := os.ReadFile(*keyPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	signer, err := ssh.ParsePrivateKey(keyBytes)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	manifest, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	signed, err := geecert.SignUpdateManifest(signer, manifest)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = os.Stdout.Write(append(signed, '\n'))
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,177 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/continusec/geecert/sso"
+)
+
+var (
+	ErrUnknownCommand = errors.New("Unknown command.")
+	ErrUsage          = errors.New("Incorrect arguments for command.")
+	ErrNotAuthorized  = errors.New("Not authorized by server for this request.")
+)
+
+// Run the sub-command named by the first argument, typically flag.Args().
+// With no arguments, fetches fresh certificates as per ProcessClient.
+func RunCommand(ctx context.Context, config *ClientAppConfiguration, args []string) error {
+	command := "fetch"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	err := runCommand(ctx, config, args)
+	sendTelemetry(ctx, config, command, err)
+	return err
+}
+
+func runCommand(ctx context.Context, config *ClientAppConfiguration, args []string) error {
+	if len(args) == 0 {
+		return ProcessClient(ctx, config)
+	}
+
+	switch args[0] {
+	case "lookup-cert":
+		return lookupCertCommand(ctx, config, args[1:])
+	case "enroll-device":
+		return enrollDeviceCommand(ctx, config, args[1:])
+	case "revoke-device":
+		return revokeDeviceCommand(ctx, config, args[1:])
+	case "bastion":
+		return bastionCommand(ctx, config, args[1:])
+	case renewIfNeededCommandName:
+		return renewIfNeededCommand(ctx, config, args[1:])
+	case "approvals":
+		return approvalsCommand(ctx, config, args[1:])
+	case "approve":
+		return decideApprovalCommand(ctx, config, args[1:], true)
+	case "deny":
+		return decideApprovalCommand(ctx, config, args[1:], false)
+	case "version":
+		return versionCommand(ctx, config, args[1:])
+	case "watch":
+		return watchCommand(ctx, config, args[1:])
+	case "inspect":
+		return inspectCommand(ctx, config, args[1:])
+	case "explain":
+		return explainCommand(ctx, config, args[1:])
+	case "break-glass":
+		return breakGlassCommand(ctx, config, args[1:])
+	case "doctor":
+		return doctorCommand(ctx, config, args[1:])
+	case "update":
+		return updateCommand(ctx, config, args[1:])
+	case "telemetry":
+		return telemetryCommand(ctx, config, args[1:])
+	case "install":
+		return installCommand(ctx, config, args[1:])
+	case "issue":
+		return issueCommand(ctx, config, args[1:])
+	case "issue-batch":
+		return issueBatchCommand(ctx, config, args[1:])
+	case "terraform":
+		return terraformCommand(ctx, config, args[1:])
+	case "install-service":
+		return installServiceCommand(ctx, config, args[1:])
+	case "pam-token":
+		return pamTokenCommand(ctx, config, args[1:])
+	case "sign":
+		return signCommand(ctx, config, args[1:])
+	case "verify":
+		return verifyCommand(ctx, config, args[1:])
+	case "git-sign":
+		return gitSignCommand(ctx, config, args[1:])
+	default:
+		return ErrUnknownCommand
+	}
+}
+
+// lookup-cert <serial | SHA256:fingerprint>
+func lookupCertCommand(ctx context.Context, config *ClientAppConfiguration, args []string) error {
+	if len(args) != 1 {
+		return ErrUsage
+	}
+
+	req := &pb.LookupCertRequest{}
+	serial, err := strconv.ParseUint(args[0], 10, 64)
+	if err == nil {
+		req.Serial = serial
+	} else {
+		req.Fingerprint = args[0]
+	}
+
+	recs, err := LookupCert(ctx, config, req)
+	if err != nil {
+		return err
+	}
+
+	if len(recs) == 0 {
+		fmt.Println("No matching certificates found.")
+		return nil
+	}
+
+	for _, rec := range recs {
+		fmt.Printf("Serial:      %d\n", rec.Serial)
+		fmt.Printf("Email:       %s\n", rec.Email)
+		fmt.Printf("Key ID:      %s\n", rec.KeyId)
+		fmt.Printf("Principals:  %s\n", strings.Join(rec.Principals, ", "))
+		fmt.Printf("Fingerprint: %s\n", rec.Fingerprint)
+		if len(rec.DeviceId) > 0 {
+			fmt.Printf("Device:      %s\n", rec.DeviceId)
+		}
+		if len(rec.OnBehalfOf) > 0 {
+			fmt.Printf("On behalf:   %s\n", rec.OnBehalfOf)
+		}
+		fmt.Printf("Valid:       %s to %s\n\n", time.Unix(rec.ValidAfter, 0).Format(time.RFC3339), time.Unix(rec.ValidBefore, 0).Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// Ask the server who a certificate was issued to. Requires that our user is an admin on the server.
+func LookupCert(ctx context.Context, config *ClientAppConfiguration, req *pb.LookupCertRequest) ([]*pb.IssuedCertRecord, error) {
+	idToken, _, err := GetValidIDToken(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	req.IdToken = idToken
+	req.CredentialType = credentialType(config)
+
+	conn, err := DialServer(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	resp, err := NewClient(conn).LookupCert(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Status != pb.ResponseCode_OK {
+		return nil, responseCodeError(resp.Status)
+	}
+	return resp.Certs, nil
+}
@@ -0,0 +1,115 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+// The config_hash of the response whose config was last installed is saved alongside the key,
+// with a digest of what was installed, so that the server can leave it out of the next
+// response if it hasn't changed, see SSHCertsResponse.config_unchanged.
+func configHashPath(paths *InstallPaths) string {
+	return paths.Key + "-config.hash"
+}
+
+// Digest of the known_hosts and ssh config sections as installed, and of what else they were
+// rendered from locally, so that a new client, changed settings or edited files are noticed.
+func installedConfigDigest(config *ClientAppConfiguration, paths *InstallPaths, homePathToSSHDir string) (string, error) {
+	hd, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	p, err := json.Marshal(paths)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00%s\x00%s\x00%t,%t,%t,%t\x00", Version, config.SectionIdentifier, p, homePathToSSHDir, hd, localUsername(),
+		config.UseMacKeychain, config.AutoRenew, config.InstallVSCode, config.InstallPuTTY)
+	for _, path := range []string{paths.KnownHosts, paths.SSHConfig} {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		for _, line := range sectionLines(config.SectionIdentifier, contents) {
+			fmt.Fprintf(h, "%s\n", line)
+		}
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Returns the config_hash to send with a request, or "" if there is none, or what was
+// installed has changed since.
+func installedConfigHash(config *ClientAppConfiguration, paths *InstallPaths, homePathToSSHDir string) string {
+	b, err := os.ReadFile(configHashPath(paths))
+	if err != nil {
+		return ""
+	}
+	f := strings.Fields(string(b))
+	if len(f) != 2 {
+		return ""
+	}
+	digest, err := installedConfigDigest(config, paths, homePathToSSHDir)
+	if err != nil || digest != f[1] {
+		logVerbose("Installed config has changed, so asking the server for all of it.")
+		return ""
+	}
+	return f[0]
+}
+
+// Saves the config_hash of the response just installed, or removes any saved if there is none.
+func saveConfigHash(ctx context.Context, config *ClientAppConfiguration, paths *InstallPaths, homePathToSSHDir string, hash string) error {
+	if len(hash) == 0 {
+		err := os.Remove(configHashPath(paths))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	digest, err := installedConfigDigest(config, paths, homePathToSSHDir)
+	if err != nil {
+		return err
+	}
+	return SafeSave(ctx, configHashPath(paths), []byte(hash+" "+digest+"\n"), 0644)
+}
+
+// Returns the lines of the known_hosts and ssh config sections as installed, to install again
+// when the server says they are unchanged.
+func installedConfig(config *ClientAppConfiguration, paths *InstallPaths) ([]string, []string, error) {
+	var rv [2][]string
+	for i, path := range []string{paths.KnownHosts, paths.SSHConfig} {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		rv[i] = sectionLines(config.SectionIdentifier, contents)
+	}
+	return rv[0], rv[1], nil
+}
@@ -0,0 +1,49 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"os"
+	"runtime"
+)
+
+const (
+	// Platform for a ChromeOS Linux (Crostini) container, for OAuthClients and telemetry.
+	PlatformChromeOS = "chromeos"
+
+	// Asks ChromeOS to open URLs in its browser, from within the container.
+	crostiniGarconPath = "/opt/google/cros-containers/bin/garcon"
+)
+
+// Returns true if running in a ChromeOS Linux (Crostini) container.
+func isCrostini() bool {
+	if runtime.GOOS != "linux" {
+		return false
+	}
+	_, err := os.Stat("/dev/.cros_milestone")
+	return err == nil
+}
+
+// Returns the platform we're running on, i.e. runtime.GOOS, or PlatformChromeOS in Crostini.
+func platform() string {
+	if isCrostini() {
+		return PlatformChromeOS
+	}
+	return runtime.GOOS
+}
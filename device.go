@@ -0,0 +1,204 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"rsc.io/qr"
+)
+
+const (
+	DeviceCodeURI   = "https://oauth2.googleapis.com/device/code"
+	DeviceTokenURI  = "https://oauth2.googleapis.com/token"
+	DeviceGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+	defaultDevicePollInterval = 5 * time.Second
+)
+
+var ErrDeviceCodeExpired = errors.New("The code expired before sign in was completed. Please try again.")
+
+// Returns true if a browser can't be opened for the user, i.e. logged in with ssh, or on
+// Linux etc with no display (including tmux and screen started from such a session).
+func isHeadless() bool {
+	for _, v := range []string{"SSH_CONNECTION", "SSH_CLIENT", "SSH_TTY"} {
+		if len(os.Getenv(v)) > 0 {
+			return true
+		}
+	}
+	switch runtime.GOOS {
+	case "windows", "darwin":
+		return false
+	}
+	return !isWSL() && len(os.Getenv("DISPLAY")) == 0 && len(os.Getenv("WAYLAND_DISPLAY")) == 0
+}
+
+type deviceCodeResponse struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURL string `json:"verification_url"` // Google's name
+	VerificationURI string `json:"verification_uri"` // RFC 8628's name
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+// Signs in with the device flow, where the user enters a code at a URL on any device, e.g.
+// their phone. Google only allows this for OAuth clients of the "TVs and Limited Input
+// devices" type. Returns ctx.Err() if ctx is done before the user has signed in.
+func DoDeviceDance(ctx context.Context, config *ClientAppConfiguration) (*CachedCreds, error) {
+	ctx = withDialer(ctx, config)
+	resp, err := postForm(ctx, DeviceCodeURI, url.Values{
+		"client_id": {oauthClient(config).ClientID},
+		"scope":     {strings.Join(scopes(config), " ")},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Requesting device code: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, tokenEndpointError(resp, body)
+	}
+
+	var dc deviceCodeResponse
+	err = json.Unmarshal(body, &dc)
+	if err != nil {
+		return nil, fmt.Errorf("Parsing response from device code endpoint: %w", err)
+	}
+	verificationURL := dc.VerificationURL
+	if len(verificationURL) == 0 {
+		verificationURL = dc.VerificationURI
+	}
+
+	fmt.Printf("To sign in, visit %s on any device and enter the code: %s\n", verificationURL, dc.UserCode)
+	if config.ShowQRCode {
+		err = writeQRCode(os.Stdout, verificationURL)
+		if err != nil {
+			logVerbose("Unable to show QR code: %s", err)
+		}
+	}
+
+	interval := time.Duration(dc.Interval) * time.Second
+	if interval <= 0 {
+		interval = defaultDevicePollInterval
+	}
+	expired := time.After(time.Duration(dc.ExpiresIn) * time.Second)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-expired:
+			return nil, ErrDeviceCodeExpired
+		case <-time.After(interval):
+		}
+
+		creds, err := pollDeviceToken(ctx, config, dc.DeviceCode)
+		if err == nil {
+			logVerbose("Received long-lived credentials.")
+			return creds, nil
+		}
+		var te *TokenEndpointError
+		if !errors.As(err, &te) {
+			return nil, err
+		}
+		switch te.Code {
+		case "authorization_pending":
+		case "slow_down":
+			interval += defaultDevicePollInterval
+		case "access_denied":
+			return nil, ErrUserDenied
+		case "expired_token":
+			return nil, ErrDeviceCodeExpired
+		default:
+			return nil, err
+		}
+	}
+}
+
+func pollDeviceToken(ctx context.Context, config *ClientAppConfiguration, deviceCode string) (*CachedCreds, error) {
+	resp, err := postForm(ctx, DeviceTokenURI, url.Values{
+		"device_code":   {deviceCode},
+		"client_id":     {oauthClient(config).ClientID},
+		"client_secret": {oauthClient(config).ClientNotSoSecret},
+		"grant_type":    {DeviceGrantType},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Polling for device authorization: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, tokenEndpointError(resp, body)
+	}
+
+	var creds CachedCreds
+	err = json.Unmarshal(body, &creds)
+	if err != nil {
+		return nil, fmt.Errorf("Parsing response from token endpoint: %w", err)
+	}
+	return &creds, nil
+}
+
+// Draws text as a QR code with half block characters, two rows of modules per line, light
+// modules drawn so that it scans on a terminal with a dark background.
+func writeQRCode(w io.Writer, text string) error {
+	code, err := qr.Encode(text, qr.L)
+	if err != nil {
+		return err
+	}
+	const quiet = 2
+	var sb strings.Builder
+	for y := -quiet; y < code.Size+quiet; y += 2 {
+		for x := -quiet; x < code.Size+quiet; x++ {
+			top, bottom := !code.Black(x, y), !code.Black(x, y+1)
+			if y+1 >= code.Size+quiet {
+				bottom = false
+			}
+			switch {
+			case top && bottom:
+				sb.WriteString("█")
+			case top:
+				sb.WriteString("▀")
+			case bottom:
+				sb.WriteString("▄")
+			default:
+				sb.WriteString(" ")
+			}
+		}
+		sb.WriteString("\n")
+	}
+	_, err = io.WriteString(w, sb.String())
+	return err
+}
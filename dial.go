@@ -0,0 +1,184 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/keepalive"
+)
+
+const (
+	DefaultConnectTimeout = 10 * time.Second
+	DefaultFallbackDelay  = 300 * time.Millisecond // as per net.Dialer
+)
+
+func connectTimeout(config *ClientAppConfiguration) time.Duration {
+	if config != nil && config.ConnectTimeout > 0 {
+		return config.ConnectTimeout
+	}
+	return DefaultConnectTimeout
+}
+
+func fallbackDelay(config *ClientAppConfiguration) time.Duration {
+	if config != nil && config.FallbackDelay != 0 {
+		return config.FallbackDelay
+	}
+	return DefaultFallbackDelay
+}
+
+type dialerKey struct{}
+
+// Returns ctx with config's connection settings and resolver, which HTTP requests made by the
+// library with it use.
+func withDialer(ctx context.Context, config *ClientAppConfiguration) context.Context {
+	return context.WithValue(ctx, dialerKey{}, config)
+}
+
+// As http.DefaultTransport, connecting as per the config in the request's context, if any.
+func dialingTransport() http.RoundTripper {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		config, _ := ctx.Value(dialerKey{}).(*ClientAppConfiguration)
+		return dialAddr(ctx, config, network, addr)
+	}
+	return t
+}
+
+// Connects to addr within config's connect timeout, trying IPv6 and IPv4 addresses in
+// parallel if the first doesn't connect quickly (happy eyeballs, RFC 6555), so that a
+// broken network for one doesn't hold up the other. config may be nil for the defaults.
+func dialAddr(ctx context.Context, config *ClientAppConfiguration, network, addr string) (net.Conn, error) {
+	d := &net.Dialer{Timeout: connectTimeout(config), FallbackDelay: fallbackDelay(config)}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil || config == nil || len(config.DNSOverHTTPSURL) == 0 {
+		// net.Dialer does happy eyeballs itself for names it looks up
+		return d.DialContext(ctx, network, addr)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout)
+	defer cancel()
+	ips, err := configResolver(config).LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	var primaries, fallbacks []string
+	for _, ip := range ips {
+		if (ip.IP.To4() == nil) == (ips[0].IP.To4() == nil) {
+			primaries = append(primaries, net.JoinHostPort(ip.String(), port))
+		} else {
+			fallbacks = append(fallbacks, net.JoinHostPort(ip.String(), port))
+		}
+	}
+	if d.FallbackDelay < 0 {
+		primaries, fallbacks = append(primaries, fallbacks...), nil
+	}
+
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	results := make(chan result)
+	race := func(addrs []string) {
+		var err error
+		for _, a := range addrs {
+			var conn net.Conn
+			conn, err = d.DialContext(ctx, network, a)
+			if err == nil {
+				select {
+				case results <- result{conn: conn}:
+				case <-ctx.Done():
+					conn.Close()
+				}
+				return
+			}
+		}
+		select {
+		case results <- result{err: err}:
+		case <-ctx.Done():
+		}
+	}
+
+	go race(primaries)
+	pending := 1
+	var fallbackTimer <-chan time.Time
+	if len(fallbacks) > 0 {
+		t := time.NewTimer(d.FallbackDelay)
+		defer t.Stop()
+		fallbackTimer = t.C
+	}
+	var firstErr error
+	for {
+		select {
+		case <-fallbackTimer:
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				return r.conn, nil
+			}
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			if fallbackTimer == nil {
+				if pending == 0 {
+					return nil, firstErr
+				}
+				continue
+			}
+			// Don't wait for the delay if the first family has already failed
+		}
+		go race(fallbacks)
+		pending++
+		fallbackTimer = nil
+	}
+}
+
+// Whether gRPC connects to addr via an HTTPS proxy, per the environment, in which case it
+// must dial itself, so that our dialer isn't used.
+func grpcProxied(addr string) bool {
+	u, err := http.ProxyFromEnvironment(&http.Request{URL: &url.URL{Scheme: "https", Host: addr}})
+	return err == nil && u != nil
+}
+
+// Dial options to connect to the server, as per config, at any of endpoints.
+func connectDialOptions(config *ClientAppConfiguration, endpoints []string) []grpc.DialOption {
+	// gRPC tries the servers in turn within this, so allow each the connect timeout
+	rv := []grpc.DialOption{
+		grpc.WithConnectParams(grpc.ConnectParams{Backoff: backoff.DefaultConfig, MinConnectTimeout: connectTimeout(config) * time.Duration(len(endpoints))}),
+	}
+	proxied := false
+	for _, ep := range endpoints {
+		proxied = proxied || grpcProxied(ep)
+	}
+	if !proxied {
+		rv = append(rv, grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return dialAddr(ctx, config, "tcp", addr)
+		}))
+	}
+	if config.KeepaliveInterval > 0 {
+		rv = append(rv, grpc.WithKeepaliveParams(keepalive.ClientParameters{Time: config.KeepaliveInterval, Timeout: connectTimeout(config)}))
+	}
+	return rv
+}
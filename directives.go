@@ -0,0 +1,84 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+
+	pb "github.com/continusec/geecert/sso"
+)
+
+// The directives last sent by the server are kept alongside the key, so that they apply to
+// renew-if-needed and notifications without contacting the server.
+func directivesPath(paths *InstallPaths) string {
+	return paths.Key + "-directives.json"
+}
+
+func saveDirectives(ctx context.Context, paths *InstallPaths, d *pb.ClientDirectives) error {
+	if d == nil {
+		err := os.Remove(directivesPath(paths))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	return SafeSave(ctx, directivesPath(paths), data, 0644)
+}
+
+// Returns the directives last sent by the server, or nil if none.
+func loadDirectives(config *ClientAppConfiguration) *pb.ClientDirectives {
+	paths, err := DefaultInstallPaths(config)
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(directivesPath(paths))
+	if err != nil {
+		return nil
+	}
+	var rv pb.ClientDirectives
+	err = json.Unmarshal(data, &rv)
+	if err != nil {
+		logVerbose("Ignoring unreadable %s: %s", directivesPath(paths), err)
+		return nil
+	}
+	return &rv
+}
+
+// How long before expiry to renew the certificate, RenewBefore unless directed otherwise.
+func renewBefore(config *ClientAppConfiguration) time.Duration {
+	if d := loadDirectives(config); d.GetRenewBeforeSeconds() > 0 {
+		return time.Duration(d.RenewBeforeSeconds) * time.Second
+	}
+	return RenewBefore
+}
+
+// Applies directives that take effect straight away, and saves them for later.
+func applyDirectives(ctx context.Context, config *ClientAppConfiguration, paths *InstallPaths, d *pb.ClientDirectives) error {
+	if v := d.GetMinClientVersion(); len(v) > 0 && CompareVersions(clientVersion(config), v) < 0 {
+		logWarning("This client is version %s, but your organization requires at least %s. Please update it, e.g. with the update command.", clientVersion(config), v)
+	}
+	return saveDirectives(ctx, paths, d)
+}
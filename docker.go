@@ -0,0 +1,167 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Prefix of install targets naming a running Docker container, e.g. docker:devcontainer.
+const DockerTargetPrefix = "docker:"
+
+// install --target docker:<container>
+// Fetches a certificate if needed, then copies it into a running container.
+func installCommand(ctx context.Context, config *ClientAppConfiguration, args []string) error {
+	var target string
+	switch {
+	case len(args) == 2 && (args[0] == "--target" || args[0] == "-target"):
+		target = args[1]
+	case len(args) == 1 && strings.HasPrefix(args[0], "--target="):
+		target = strings.TrimPrefix(args[0], "--target=")
+	default:
+		return ErrUsage
+	}
+
+	container := strings.TrimPrefix(target, DockerTargetPrefix)
+	if len(container) == 0 || container == target {
+		return fmt.Errorf("%w Install targets are of the form %s<container>.", ErrUsage, DockerTargetPrefix)
+	}
+
+	err := EnsureFreshCert(ctx, config)
+	if err != nil {
+		return err
+	}
+	return InstallInContainer(ctx, config, container)
+}
+
+// Copies the installed key and certificate into ~/.ssh of the default user of a running
+// Docker container, and adds the known_hosts certificate authorities and ssh config section
+// to the files there, as ProcessClient does locally.
+func InstallInContainer(ctx context.Context, config *ClientAppConfiguration, container string) error {
+	paths, err := DefaultInstallPaths(config)
+	if err != nil {
+		return err
+	}
+	key, err := os.ReadFile(paths.Key)
+	if err != nil {
+		return err
+	}
+	pub, err := os.ReadFile(paths.Key + ".pub")
+	if err != nil {
+		return err
+	}
+	cert, err := os.ReadFile(paths.Cert)
+	if err != nil {
+		return err
+	}
+	knownHosts, err := os.ReadFile(paths.KnownHosts)
+	if err != nil {
+		return err
+	}
+	sshConfig, err := os.ReadFile(paths.SSHConfig)
+	if err != nil {
+		return err
+	}
+
+	logInfo("Installing certificate in container %s.", container)
+	for _, f := range []struct {
+		name     string
+		contents []byte
+		mode     string
+	}{
+		{config.ShortlivedKeyName, key, "600"},
+		{config.ShortlivedKeyName + ".pub", pub, "644"},
+		{config.ShortlivedKeyName + "-cert.pub", cert, "644"},
+	} {
+		err = writeContainerFile(ctx, container, f.name, f.contents, f.mode)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = replaceContainerSection(ctx, config, container, "known_hosts", sectionLines(config.SectionIdentifier, knownHosts))
+	if err != nil {
+		return err
+	}
+	return replaceContainerSection(ctx, config, container, "config", containerSSHConfigLines(config, paths, sectionLines(config.SectionIdentifier, sshConfig)))
+}
+
+// Rewrites our local ssh config section for a container, where the key and certificate are
+// in ~/.ssh, and we are not installed to renew them.
+func containerSSHConfigLines(config *ClientAppConfiguration, paths *InstallPaths, lines []string) []string {
+	keyInContainer := filepath.ToSlash(filepath.Join("~", ".ssh", config.ShortlivedKeyName))
+	var rv []string
+	skip := false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "Host ") || strings.HasPrefix(trimmed, "Match ") {
+			skip = strings.Contains(trimmed, renewIfNeededCommandName)
+		}
+		if skip || (len(paths.CertInConfig) > 0 && trimmed == "CertificateFile "+paths.CertInConfig) {
+			continue
+		}
+		rv = append(rv, strings.ReplaceAll(line, paths.KeyInConfig, keyInContainer))
+	}
+	return rv
+}
+
+// Runs a shell script in the container, as its default user.
+func dockerExec(ctx context.Context, container string, stdin []byte, script string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "docker", append([]string{"exec", "-i", container, "sh", "-c", script, "sh"}, args...)...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("Running in container %s: %w %s", container, err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// Writes a file in ~/.ssh in the container, replacing any existing file.
+func writeContainerFile(ctx context.Context, container, name string, contents []byte, mode string) error {
+	_, err := dockerExec(ctx, container, contents, `umask 077 && mkdir -p ~/.ssh && cat > ~/.ssh/"$1.tmpfornew" && chmod "$2" ~/.ssh/"$1.tmpfornew" && mv ~/.ssh/"$1.tmpfornew" ~/.ssh/"$1"`, name, mode)
+	return err
+}
+
+// As per ReplaceSectionOfFile, for a file in ~/.ssh in the container.
+func replaceContainerSection(ctx context.Context, config *ClientAppConfiguration, container, name string, lines []string) error {
+	contents, err := dockerExec(ctx, container, nil, `cat ~/.ssh/"$1" 2>/dev/null || true`, name)
+	if err != nil {
+		return err
+	}
+	newContents, _, _ := replaceSection(config.SectionIdentifier, contents, lines)
+	if bytes.Equal(contents, newContents) {
+		return nil
+	}
+	if sectionEdited(config.SectionIdentifier, contents) {
+		err = writeContainerFile(ctx, container, name+".orig", contents, "644")
+		if err != nil {
+			return err
+		}
+		logWarning("Section %s of ~/.ssh/%s in container %s was edited by hand, saved the previous file to ~/.ssh/%s.orig before replacing it", config.SectionIdentifier, name, container, name)
+	}
+	return writeContainerFile(ctx, container, name, newContents, "644")
+}
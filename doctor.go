@@ -0,0 +1,416 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+const (
+	DefaultNTPServer = "time.google.com:123"
+
+	doctorTimeout  = 10 * time.Second
+	maxClockSkew   = 30 * time.Second // ID tokens are checked against the local clock
+	ntpEpochOffset = 2208988800       // seconds between 1900 and 1970
+)
+
+var (
+	ErrDoctorFailed = errors.New("One or more checks failed.")
+	ErrBadNTPReply  = errors.New("Bad reply from NTP server.")
+)
+
+// The outcome of one check made by the doctor command.
+type DoctorResult struct {
+	Name        string
+	OK          bool
+	Detail      string
+	Remediation string // if not OK, what the user might do about it
+}
+
+func doctorPass(name, detail string) *DoctorResult {
+	return &DoctorResult{Name: name, OK: true, Detail: detail}
+}
+
+func doctorFail(name, detail, remediation string) *DoctorResult {
+	return &DoctorResult{Name: name, Detail: detail, Remediation: remediation}
+}
+
+// doctor
+// Checks for common problems with the local machine and our configuration.
+func doctorCommand(ctx context.Context, config *ClientAppConfiguration, args []string) error {
+	if len(args) != 0 {
+		return ErrUsage
+	}
+
+	failed := false
+	for _, r := range Diagnose(ctx, config) {
+		if r.OK {
+			fmt.Printf("[PASS] %s: %s\n", r.Name, r.Detail)
+		} else {
+			failed = true
+			fmt.Printf("[FAIL] %s: %s\n", r.Name, r.Detail)
+			if len(r.Remediation) > 0 {
+				fmt.Printf("       %s\n", r.Remediation)
+			}
+		}
+	}
+
+	if failed {
+		return ErrDoctorFailed
+	}
+	return nil
+}
+
+// Runs all checks, returning their results. Nothing is changed on the machine.
+func Diagnose(ctx context.Context, config *ClientAppConfiguration) []*DoctorResult {
+	var rv []*DoctorResult
+	rv = append(rv, checkClock(ctx, config))
+	rv = append(rv, checkAgents(ctx, config)...)
+	rv = append(rv, checkOpenSSH(ctx))
+	rv = append(rv, checkPermissions(config)...)
+	rv = append(rv, checkReplaceableFiles(config)...)
+	if config.SystemWide && len(config.SSHConfigPath) == 0 {
+		rv = append(rv, checkSystemSSHConfig())
+	}
+	rv = append(rv, checkTokenEndpoint(ctx, config))
+	rv = append(rv, checkServerReachable(ctx, config))
+	rv = append(rv, checkCredentials(ctx, config))
+	rv = append(rv, checkInstalledCert(config))
+	return rv
+}
+
+func checkClock(ctx context.Context, config *ClientAppConfiguration) *DoctorResult {
+	const name = "Clock"
+	server := config.NTPServer
+	if len(server) == 0 {
+		server = DefaultNTPServer
+	}
+	skew, err := clockSkew(ctx, server)
+	if err != nil {
+		return doctorFail(name, fmt.Sprintf("unable to query %s: %s", server, err), "Check that UDP port 123 is not blocked, or set a reachable NTP server.")
+	}
+	if skew > maxClockSkew || skew < -maxClockSkew {
+		return doctorFail(name, fmt.Sprintf("local clock is %s out compared to %s", skew, server), "Enable automatic time synchronization in your system settings.")
+	}
+	return doctorPass(name, fmt.Sprintf("within %s of %s", skew, server))
+}
+
+// Returns how far ahead the local clock is of the given NTP server, using a single SNTP (RFC 4330) query.
+func clockSkew(ctx context.Context, server string) (time.Duration, error) {
+	conn, err := (&net.Dialer{Timeout: doctorTimeout}).DialContext(ctx, "udp", server)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(doctorTimeout))
+
+	req := make([]byte, 48)
+	req[0] = 0x1b // version 3, client mode
+	sent := time.Now()
+	_, err = conn.Write(req)
+	if err != nil {
+		return 0, err
+	}
+
+	resp := make([]byte, 48)
+	n, err := io.ReadFull(conn, resp)
+	if err != nil {
+		return 0, err
+	}
+	received := time.Now()
+	if n != 48 || resp[0]&0x07 != 4 {
+		return 0, ErrBadNTPReply
+	}
+
+	serverReceived := ntpTime(resp[32:40])
+	serverSent := ntpTime(resp[40:48])
+	return (sent.Sub(serverReceived) + received.Sub(serverSent)) / 2, nil
+}
+
+func ntpTime(b []byte) time.Time {
+	secs := int64(binary.BigEndian.Uint32(b[:4])) - ntpEpochOffset
+	frac := int64(binary.BigEndian.Uint32(b[4:]))
+	return time.Unix(secs, (frac*1e9)>>32)
+}
+
+func checkAgents(ctx context.Context, config *ClientAppConfiguration) []*DoctorResult {
+	var rv []*DoctorResult
+	for _, name := range configuredAgents(config) {
+		title := "Agent " + name
+		var conn io.ReadWriteCloser
+		switch name {
+		case AgentOpenSSH:
+			authSock := os.Getenv("SSH_AUTH_SOCK")
+			if len(authSock) == 0 && isWSL() {
+				c, err := dialWSLAgent(ctx, config)
+				if err != nil {
+					rv = append(rv, doctorFail(title, fmt.Sprintf("SSH_AUTH_SOCK is not set, and unable to reach the Windows agent: %s", err), "Put npiperelay.exe in your PATH and start the Windows OpenSSH agent, or set SSH_AUTH_SOCK to an agent bridge."))
+					continue
+				}
+				conn = c
+				break
+			}
+			if len(authSock) == 0 {
+				rv = append(rv, doctorFail(title, "SSH_AUTH_SOCK is not set", "Start ssh-agent, e.g. eval $(ssh-agent), or add it to your login scripts."))
+				continue
+			}
+			c, err := (&net.Dialer{Timeout: doctorTimeout}).DialContext(ctx, "unix", authSock)
+			if err != nil {
+				rv = append(rv, doctorFail(title, fmt.Sprintf("unable to connect to %s: %s", authSock, err), "Restart ssh-agent and update SSH_AUTH_SOCK."))
+				continue
+			}
+			conn = c
+		case AgentPageant:
+			if !pageantRunning() {
+				rv = append(rv, doctorFail(title, "Pageant is not running", "Start Pageant, e.g. add it to your startup programs."))
+				continue
+			}
+			conn = &pageantConn{}
+		default:
+			rv = append(rv, doctorFail(title, ErrUnknownAgent.Error(), "Check the agents configured."))
+			continue
+		}
+
+		keys, err := agent.NewClient(conn).List()
+		conn.Close()
+		if err != nil {
+			rv = append(rv, doctorFail(title, fmt.Sprintf("unable to list keys: %s", err), "Restart the agent."))
+			continue
+		}
+		rv = append(rv, doctorPass(title, fmt.Sprintf("running, holding %d keys", len(keys))))
+	}
+	return rv
+}
+
+var openSSHVersionRE = regexp.MustCompile(`OpenSSH_for_Windows_(\d+)\.(\d+)|OpenSSH_(\d+)\.(\d+)`)
+
+func checkOpenSSH(ctx context.Context) *DoctorResult {
+	const name = "OpenSSH client"
+	out, err := exec.CommandContext(ctx, "ssh", "-V").CombinedOutput()
+	if err != nil {
+		return doctorFail(name, fmt.Sprintf("unable to run ssh -V: %s", err), "Install the OpenSSH client and make sure ssh is in your PATH.")
+	}
+	m := openSSHVersionRE.FindStringSubmatch(string(out))
+	if m == nil {
+		return doctorFail(name, fmt.Sprintf("not OpenSSH: %s", out), "Certificates have only been tested with the OpenSSH client.")
+	}
+	version := m[1] + "." + m[2]
+	if len(m[1]) == 0 {
+		version = m[3] + "." + m[4]
+	}
+	switch {
+	case CompareVersions(version, "5.6") < 0:
+		return doctorFail(name, "version "+version+" does not support certificates", "Upgrade to a newer OpenSSH.")
+	case CompareVersions(version, "7.8") == 0:
+		return doctorFail(name, "version 7.8 can fail to authenticate with certificates held in ssh-agent", "Upgrade to OpenSSH 7.9 or later.")
+	}
+	return doctorPass(name, "version "+version)
+}
+
+// The ssh directory and files in it should not be writable by others, and keys should not
+// be readable by others, else ssh refuses to use them, see permissionChecks.
+func checkPermissions(config *ClientAppConfiguration) []*DoctorResult {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	paths, err := DefaultInstallPaths(config)
+	if err != nil {
+		return []*DoctorResult{doctorFail("Permissions", err.Error(), "")}
+	}
+	hd, err := homedir.Dir()
+	if err != nil {
+		return []*DoctorResult{doctorFail("Permissions", err.Error(), "")}
+	}
+	checks, err := permissionChecks(config, paths, filepath.Join(hd, ".ssh"))
+	if err != nil {
+		return []*DoctorResult{doctorFail("Permissions", err.Error(), "")}
+	}
+
+	var rv []*DoctorResult
+	for _, c := range checks {
+		title := "Permissions " + c.path
+		fi, err := os.Stat(c.path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			rv = append(rv, doctorFail(title, err.Error(), ""))
+			continue
+		}
+		if !c.ok(fi.Mode()) {
+			rv = append(rv, doctorFail(title, fmt.Sprintf("mode is %s", fi.Mode().Perm()), fmt.Sprintf("Run: chmod %o %s", c.want(fi.Mode()), c.path)))
+			continue
+		}
+		rv = append(rv, doctorPass(title, fmt.Sprintf("mode is %s", fi.Mode().Perm())))
+	}
+	return rv
+}
+
+// Only reports files that we would refuse to replace.
+func checkReplaceableFiles(config *ClientAppConfiguration) []*DoctorResult {
+	paths, err := DefaultInstallPaths(config)
+	if err != nil {
+		return []*DoctorResult{doctorFail("Managed files", err.Error(), "")}
+	}
+
+	var rv []*DoctorResult
+	for _, path := range []string{paths.Key, paths.Cert, paths.KnownHosts, paths.SSHConfig} {
+		err = checkReplaceable(path)
+		if err != nil {
+			rv = append(rv, doctorFail("Managed file "+path, err.Error(), "Set FollowSymlinks (-follow_symlinks) to update the file linked to, or make the file writable."))
+		}
+	}
+	return rv
+}
+
+func checkSystemSSHConfig() *DoctorResult {
+	name := "System ssh config " + SystemSSHConfigPath
+	if !systemSSHConfigIncludes(SystemSSHConfigPath) {
+		return doctorFail(name, "not included by "+systemSSHConfig, includeRemediation(SystemSSHConfigPath))
+	}
+	return doctorPass(name, "included by "+systemSSHConfig)
+}
+
+func checkTokenEndpoint(ctx context.Context, config *ClientAppConfiguration) *DoctorResult {
+	const name = "Google token endpoint"
+	client := &http.Client{Timeout: doctorTimeout, Transport: dialingTransport()}
+	req, err := http.NewRequestWithContext(withDialer(ctx, config), http.MethodGet, TokenURI, nil)
+	if err != nil {
+		return doctorFail(name, err.Error(), "")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return doctorFail(name, err.Error(), "Check your internet connection and any proxy settings.")
+	}
+	resp.Body.Close()
+	return doctorPass(name, "reachable")
+}
+
+func checkServerReachable(ctx context.Context, config *ClientAppConfiguration) *DoctorResult {
+	name := "Server " + config.GRPCServer
+	endpoints, err := serverEndpoints(ctx, config)
+	if err != nil {
+		return doctorFail(name, err.Error(), "Check the -server flag.")
+	}
+	// One server being down is fine, as long as another is reachable
+	var reachable, unreachable []string
+	for _, ep := range endpoints {
+		dialCtx, cancel := context.WithTimeout(ctx, doctorTimeout)
+		conn, err := dialAddr(dialCtx, config, "tcp", ep)
+		cancel()
+		if err != nil {
+			unreachable = append(unreachable, err.Error())
+			continue
+		}
+		conn.Close()
+		reachable = append(reachable, ep)
+	}
+	if len(reachable) == 0 {
+		return doctorFail(name, strings.Join(unreachable, "; "), "Check your network connection, VPN and the -server flag.")
+	}
+	if len(unreachable) > 0 {
+		return doctorPass(name, "reachable via "+strings.Join(reachable, ", ")+", but "+strings.Join(unreachable, "; "))
+	}
+	return doctorPass(name, "reachable")
+}
+
+func checkCredentials(ctx context.Context, config *ClientAppConfiguration) *DoctorResult {
+	const name = "Credentials"
+	if len(config.KerberosSPN) > 0 {
+		_, claims, err := getKerberosIdentity(config)
+		if err != nil {
+			return doctorFail(name, err.Error(), "Run kinit to get a Kerberos ticket.")
+		}
+		return doctorPass(name, "Kerberos ticket for "+claims.EmailAddress+" valid until "+claims.Expiry.Format(time.RFC3339))
+	}
+	if len(config.KubernetesTokenPath) > 0 {
+		_, claims, err := getKubernetesToken(config)
+		if err != nil {
+			return doctorFail(name, err.Error(), "Check that a service account token is projected into the pod at "+config.KubernetesTokenPath+".")
+		}
+		return doctorPass(name, "service account token for "+claims.EmailAddress+" valid until "+claims.Expiry.Format(time.RFC3339))
+	}
+	if len(config.BrokeredAuth) > 0 {
+		_, claims, err := getBrokeredIDToken(ctx, config)
+		if err != nil {
+			return doctorFail(name, err.Error(), "Sign in to the Cloud SDK again, or check that it is installed.")
+		}
+		return doctorPass(name, "valid ID token from "+config.BrokeredAuth+" for "+claims.EmailAddress)
+	}
+	path, err := credentialsPath(config)
+	if err != nil {
+		return doctorFail(name, err.Error(), "")
+	}
+	creds, err := LoadCreds(path)
+	if err != nil {
+		return doctorFail(name, fmt.Sprintf("none saved in %s", path), "Run without arguments to sign in.")
+	}
+
+	if len(config.SAMLBridgeURL) > 0 {
+		claims, err := samlBridgeClaims(creds.IDToken, config.HostedDomain)
+		if err != nil {
+			return doctorFail(name, err.Error(), "Run without arguments to sign in again.")
+		}
+		return doctorPass(name, "valid ID token from the SAML bridge for "+claims.EmailAddress)
+	}
+
+	claims, err := idTokenValidator(config).ValidateIDToken(ctx, creds.IDToken, oauthClient(config).ClientID, config.HostedDomain)
+	switch {
+	case err == nil:
+		return doctorPass(name, "valid ID token for "+claims.EmailAddress)
+	case IDTokenExpired(err) && len(creds.RefreshToken) > 0:
+		return doctorPass(name, "ID token has expired, and will be refreshed when next needed")
+	default:
+		return doctorFail(name, err.Error(), "Run without arguments to sign in again.")
+	}
+}
+
+func checkInstalledCert(config *ClientAppConfiguration) *DoctorResult {
+	const name = "Certificate"
+	cert, err := LoadInstalledCert(config)
+	if err != nil {
+		return doctorFail(name, err.Error(), "Run without arguments to fetch a certificate.")
+	}
+	if cert.Signature != nil && cert.Signature.Format == ssh.KeyAlgoRSA {
+		return doctorFail(name, "signed with SHA-1 (ssh-rsa), which OpenSSH 8.2 and later servers reject by default", "Ask your administrator to upgrade the server, or to unset ca_signature_algorithm.")
+	}
+	validBefore := time.Unix(int64(cert.ValidBefore), 0)
+	if validBefore.Before(time.Now()) {
+		return doctorFail(name, "expired at "+validBefore.Format(time.RFC3339), "Run without arguments to fetch a new certificate.")
+	}
+	return doctorPass(name, "valid until "+validBefore.Format(time.RFC3339))
+}
@@ -0,0 +1,170 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const dohTimeout = 10 * time.Second
+
+// Looks up names for connecting to the server and Google, see ClientAppConfiguration.DNSOverHTTPSURL.
+type dnsResolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+func configResolver(config *ClientAppConfiguration) dnsResolver {
+	if config != nil && len(config.DNSOverHTTPSURL) > 0 {
+		// The resolver's own name is looked up with the system resolver
+		direct := *config
+		direct.DNSOverHTTPSURL = ""
+		return &dohResolver{url: config.DNSOverHTTPSURL, config: &direct}
+	}
+	return net.DefaultResolver
+}
+
+// A DNS over HTTPS (RFC 8484) resolver. Best given with an IP address, e.g.
+// https://1.1.1.1/dns-query, as otherwise its own name must be looked up.
+type dohResolver struct {
+	url    string
+	config *ClientAppConfiguration // for connecting to the resolver
+}
+
+func (r *dohResolver) query(ctx context.Context, name string, qtype dnsmessage.Type) ([]dnsmessage.Resource, error) {
+	qname, err := dnsmessage.NewName(strings.TrimSuffix(name, ".") + ".")
+	if err != nil {
+		return nil, err
+	}
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{RecursionDesired: true})
+	b.EnableCompression()
+	err = b.StartQuestions()
+	if err != nil {
+		return nil, err
+	}
+	err = b.Question(dnsmessage.Question{Name: qname, Type: qtype, Class: dnsmessage.ClassINET})
+	if err != nil {
+		return nil, err
+	}
+	msg, err := b.Finish()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(withDialer(ctx, r.config), dohTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(msg))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Querying DNS over HTTPS resolver: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("DNS over HTTPS resolver returned %s", resp.Status)
+	}
+
+	var m dnsmessage.Message
+	err = m.Unpack(body)
+	if err != nil {
+		return nil, fmt.Errorf("Parsing response from DNS over HTTPS resolver: %w", err)
+	}
+	if m.RCode != dnsmessage.RCodeSuccess {
+		return nil, &net.DNSError{Err: m.RCode.String(), Name: name, Server: r.url, IsNotFound: m.RCode == dnsmessage.RCodeNameError}
+	}
+	var rv []dnsmessage.Resource
+	for _, a := range m.Answers {
+		if a.Header.Type == qtype {
+			rv = append(rv, a)
+		}
+	}
+	return rv, nil
+}
+
+func (r *dohResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	var rv []net.IPAddr
+	var firstErr error
+	for _, qtype := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		answers, err := r.query(ctx, host, qtype)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		for _, a := range answers {
+			switch body := a.Body.(type) {
+			case *dnsmessage.AResource:
+				rv = append(rv, net.IPAddr{IP: net.IP(body.A[:])})
+			case *dnsmessage.AAAAResource:
+				rv = append(rv, net.IPAddr{IP: net.IP(body.AAAA[:])})
+			}
+		}
+	}
+	if len(rv) == 0 {
+		if firstErr != nil {
+			return nil, firstErr
+		}
+		return nil, &net.DNSError{Err: "no answer", Name: host, Server: r.url, IsNotFound: true}
+	}
+	return rv, nil
+}
+
+// Only looks up name, as service and proto are always empty here. Records are sorted by
+// priority and then weight, heaviest first.
+func (r *dohResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	answers, err := r.query(ctx, name, dnsmessage.TypeSRV)
+	if err != nil {
+		return "", nil, err
+	}
+	var rv []*net.SRV
+	for _, a := range answers {
+		if body, ok := a.Body.(*dnsmessage.SRVResource); ok {
+			rv = append(rv, &net.SRV{Target: body.Target.String(), Port: body.Port, Priority: body.Priority, Weight: body.Weight})
+		}
+	}
+	if len(rv) == 0 {
+		return "", nil, &net.DNSError{Err: "no answer", Name: name, Server: r.url, IsNotFound: true}
+	}
+	sort.SliceStable(rv, func(i, j int) bool {
+		if rv[i].Priority != rv[j].Priority {
+			return rv[i].Priority < rv[j].Priority
+		}
+		return rv[i].Weight > rv[j].Weight
+	})
+	return name, rv, nil
+}
@@ -0,0 +1,120 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc"
+	_ "google.golang.org/grpc/health" // health checks for ServerRoundRobin
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+)
+
+// How a client with several servers (see ClientAppConfiguration.GRPCServer) uses them.
+const (
+	ServerPickFirst  = "pick_first"  // the first that can be connected to, in order, the default
+	ServerRoundRobin = "round_robin" // each request to the next server that is connected and healthy
+)
+
+// Prefix for GRPCServer to look up servers in DNS SRV records, e.g. srv:_geecert._tcp.example.com
+const srvPrefix = "srv:"
+
+// Returns the host:port of each server in config.GRPCServer, in order of preference.
+func serverEndpoints(ctx context.Context, config *ClientAppConfiguration) ([]string, error) {
+	if strings.HasPrefix(config.GRPCServer, srvPrefix) {
+		return lookupSRVEndpoints(ctx, configResolver(config), strings.TrimPrefix(config.GRPCServer, srvPrefix))
+	}
+	var rv []string
+	for _, ep := range strings.Split(config.GRPCServer, ",") {
+		ep = strings.TrimSpace(ep)
+		if len(ep) == 0 {
+			continue
+		}
+		_, _, err := net.SplitHostPort(ep)
+		if err != nil {
+			return nil, fmt.Errorf("Bad server address %q, expecting host:port with IPv6 addresses in brackets, e.g. [2001:db8::1]:10000: %w", ep, err)
+		}
+		rv = append(rv, ep)
+	}
+	if len(rv) == 0 {
+		return nil, fmt.Errorf("No server address set")
+	}
+	return rv, nil
+}
+
+// SRV records are returned sorted by priority and randomized by weight, so pick first
+// follows them.
+func lookupSRVEndpoints(ctx context.Context, r dnsResolver, name string) ([]string, error) {
+	_, srvs, err := r.LookupSRV(ctx, "", "", name)
+	if err != nil {
+		return nil, fmt.Errorf("Looking up servers in SRV records for %s: %w", name, err)
+	}
+	var rv []string
+	for _, srv := range srvs {
+		rv = append(rv, net.JoinHostPort(strings.TrimSuffix(srv.Target, "."), strconv.Itoa(int(srv.Port))))
+	}
+	if len(rv) == 0 {
+		return nil, fmt.Errorf("No SRV records for %s", name)
+	}
+	return rv, nil
+}
+
+func serverServiceConfig(config *ClientAppConfiguration) (string, error) {
+	switch config.ServerBalancing {
+	case "", ServerPickFirst:
+		return `{"loadBalancingConfig": [{"pick_first": {}}]}`, nil
+	case ServerRoundRobin:
+		// Servers without the health service are treated as healthy
+		return `{"loadBalancingConfig": [{"round_robin": {}}], "healthCheckConfig": {"serviceName": ""}}`, nil
+	default:
+		return "", fmt.Errorf("Unknown server balancing %q, expecting %s or %s", config.ServerBalancing, ServerPickFirst, ServerRoundRobin)
+	}
+}
+
+// Returns the target to dial for config.GRPCServer, and options to connect to it and resolve
+// it to each of several servers, each of which has its TLS certificate checked for its own name, unless
+// ServerName is set.
+func serverTarget(ctx context.Context, config *ClientAppConfiguration) (string, []grpc.DialOption, error) {
+	endpoints, err := serverEndpoints(ctx, config)
+	if err != nil {
+		return "", nil, err
+	}
+	sc, err := serverServiceConfig(config)
+	if err != nil {
+		return "", nil, err
+	}
+	opts := connectDialOptions(config, endpoints)
+	if len(endpoints) == 1 {
+		return endpoints[0], opts, nil
+	}
+
+	var addrs []resolver.Address
+	for _, ep := range endpoints {
+		host, _, _ := net.SplitHostPort(ep)
+		addrs = append(addrs, resolver.Address{Addr: ep, ServerName: host})
+	}
+	r := manual.NewBuilderWithScheme("geecert")
+	r.InitialState(resolver.State{Addresses: addrs})
+	return r.Scheme() + ":///" + endpoints[0], append(opts, grpc.WithResolvers(r), grpc.WithDefaultServiceConfig(sc)), nil
+}
@@ -0,0 +1,279 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+
+	pb "github.com/continusec/geecert/sso"
+)
+
+var (
+	ErrBadDeviceSignature = errors.New("Bad device signature.")
+)
+
+// Signed by the device key with each certificate request, followed by the public key to be
+// certified in SSH wire format.
+const DeviceSignedData = "geecert-device-v1\x00"
+
+// The device key is kept next to the short lived key, but unlike it, is never replaced.
+func deviceKeyPath(paths *InstallPaths) string {
+	return paths.Key + "-device"
+}
+
+// Returns the device key, or nil if this device has not been enrolled.
+func loadDeviceKey(paths *InstallPaths) (ssh.Signer, error) {
+	b, err := os.ReadFile(deviceKeyPath(paths))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return ssh.ParsePrivateKey(b)
+}
+
+// Returns the device key, generating it if there isn't one yet.
+func loadOrCreateDeviceKey(paths *InstallPaths) (ssh.Signer, error) {
+	signer, err := loadDeviceKey(paths)
+	if err != nil || signer != nil {
+		return signer, err
+	}
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	block, err := ssh.MarshalPrivateKey(key, "geecert-device")
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(deviceKeyPath(paths), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, err
+	}
+	_, err = f.Write(pem.EncodeToMemory(block))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ssh.NewSignerFromKey(key)
+}
+
+// The device ID is the fingerprint of the device key.
+func DeviceID(deviceKey ssh.PublicKey) string {
+	return ssh.FingerprintSHA256(deviceKey)
+}
+
+// Signs pub, the key to be certified, with the device key, for SSHCertsRequest.device_signature.
+func DeviceSignature(deviceKey ssh.Signer, pub ssh.PublicKey) (string, error) {
+	sig, err := deviceKey.Sign(rand.Reader, append([]byte(DeviceSignedData), pub.Marshal()...))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(ssh.Marshal(sig)), nil
+}
+
+// Checks signature is by deviceKey over pub, as per DeviceSignature.
+func VerifyDeviceSignature(deviceKey ssh.PublicKey, pub ssh.PublicKey, signature string) error {
+	b, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return ErrBadDeviceSignature
+	}
+	var sig ssh.Signature
+	err = ssh.Unmarshal(b, &sig)
+	if err != nil {
+		return ErrBadDeviceSignature
+	}
+	err = deviceKey.Verify(append([]byte(DeviceSignedData), pub.Marshal()...), &sig)
+	if err != nil {
+		return ErrBadDeviceSignature
+	}
+	return nil
+}
+
+var (
+	ioregUUID   = regexp.MustCompile(`"IOPlatformUUID" = "([^"]+)"`)
+	ioregSerial = regexp.MustCompile(`"IOPlatformSerialNumber" = "([^"]+)"`)
+)
+
+// Returns the machine's identifier and serial number as reported by the OS, each "" if it can't
+// be found. These are only reported to the server for its records and MDM lookups, as anything
+// running as the user could lie about them.
+func deviceIdentifiers(ctx context.Context) (string, string) {
+	var hardwareID, serialNumber string
+	switch runtime.GOOS {
+	case "linux":
+		b, err := os.ReadFile("/etc/machine-id")
+		if err == nil {
+			hardwareID = strings.TrimSpace(string(b))
+		}
+		// Usually only readable by root
+		b, err = os.ReadFile("/sys/class/dmi/id/product_serial")
+		if err == nil {
+			serialNumber = strings.TrimSpace(string(b))
+		}
+	case "darwin":
+		out, err := exec.CommandContext(ctx, "ioreg", "-rd1", "-c", "IOPlatformExpertDevice").Output()
+		if err == nil {
+			if m := ioregUUID.FindSubmatch(out); m != nil {
+				hardwareID = string(m[1])
+			}
+			if m := ioregSerial.FindSubmatch(out); m != nil {
+				serialNumber = string(m[1])
+			}
+		}
+	case "windows":
+		out, err := exec.CommandContext(ctx, "reg", "query", `HKLM\SOFTWARE\Microsoft\Cryptography`, "/v", "MachineGuid").Output()
+		if err == nil {
+			fields := strings.Fields(string(out))
+			if len(fields) > 0 {
+				hardwareID = fields[len(fields)-1]
+			}
+		}
+		out, err = exec.CommandContext(ctx, "powershell", "-NoProfile", "-Command", "(Get-CimInstance Win32_BIOS).SerialNumber").Output()
+		if err == nil {
+			serialNumber = strings.TrimSpace(string(out))
+		}
+	}
+	return hardwareID, serialNumber
+}
+
+// enroll-device
+func enrollDeviceCommand(ctx context.Context, config *ClientAppConfiguration, args []string) error {
+	if len(args) != 0 {
+		return ErrUsage
+	}
+	id, err := EnrollDevice(ctx, config)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Enrolled this device as %s.\n", id)
+	return nil
+}
+
+// Generates a device key if there isn't one yet, and registers it with the server. Certificates
+// requested from this device are then bound to it. Returns the device ID.
+func EnrollDevice(ctx context.Context, config *ClientAppConfiguration) (string, error) {
+	paths, err := DefaultInstallPaths(config)
+	if err != nil {
+		return "", err
+	}
+	deviceKey, err := loadOrCreateDeviceKey(paths)
+	if err != nil {
+		return "", err
+	}
+
+	idToken, _, err := GetValidIDToken(ctx, config)
+	if err != nil {
+		return "", err
+	}
+
+	conn, err := DialServer(ctx, config)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	hostname, _ := os.Hostname()
+	hardwareID, serialNumber := deviceIdentifiers(ctx)
+	resp, err := NewClient(conn).EnrollDevice(ctx, &pb.EnrollDeviceRequest{
+		IdToken:        idToken,
+		CredentialType: credentialType(config),
+		DeviceKey:      strings.TrimSpace(string(ssh.MarshalAuthorizedKey(deviceKey.PublicKey()))),
+		Hostname:       hostname,
+		Platform:       runtime.GOOS + "/" + runtime.GOARCH,
+		HardwareId:     hardwareID,
+		SerialNumber:   serialNumber,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if resp.Status != pb.ResponseCode_OK {
+		return "", responseCodeError(resp.Status)
+	}
+	return resp.DeviceId, nil
+}
+
+// revoke-device <device ID> [reason]
+func revokeDeviceCommand(ctx context.Context, config *ClientAppConfiguration, args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return ErrUsage
+	}
+	reason := ""
+	if len(args) == 2 {
+		reason = args[1]
+	}
+
+	revoked, err := RevokeDevice(ctx, config, args[0], reason)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Revoked device %s and %d keys certified for it.\n", args[0], len(revoked))
+	for _, fp := range revoked {
+		fmt.Println(fp)
+	}
+	return nil
+}
+
+// Revokes a device and every key certified for it, returning the fingerprints of those keys.
+// Requires that our user is an admin on the server.
+func RevokeDevice(ctx context.Context, config *ClientAppConfiguration, deviceID string, reason string) ([]string, error) {
+	idToken, _, err := GetValidIDToken(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := DialServer(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	resp, err := NewClient(conn).RevokeDevice(ctx, &pb.RevokeDeviceRequest{
+		IdToken:        idToken,
+		CredentialType: credentialType(config),
+		DeviceId:       deviceID,
+		Reason:         reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Status != pb.ResponseCode_OK {
+		return nil, responseCodeError(resp.Status)
+	}
+	return resp.RevokedFingerprint, nil
+}
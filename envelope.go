@@ -0,0 +1,423 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Signed envelope format version 1. An envelope is the version byte, 0x01, followed by
+// envelopeV1 in SSH wire format (RFC 4251). The signature, by the key in the certificate, is over
+// envelopeV1SignedData, which includes the version and hash algorithm, and a digest of the payload
+// rather than the payload itself, so that large payloads can be signed as a stream. Detached
+// envelopes have an empty payload, which the verifier supplies.
+const envelopeVersion1 = 0x01
+
+// Identifies what the envelope signature is over, so it can't be mistaken for another use of the key.
+const envelopeV1Magic = "geecert-envelope-v1"
+
+// Hash algorithm identifiers for envelopes.
+const (
+	EnvelopeHashSHA256 = "sha256"
+	EnvelopeHashSHA512 = "sha512"
+)
+
+var (
+	ErrUnknownEnvelopeHash   = errors.New("Unknown envelope hash algorithm.")
+	ErrBadEnvelope           = errors.New("Signed envelope is not in a format we understand.")
+	ErrEnvelopeNeedsPayload  = errors.New("Signed envelope is detached, so the payload must be given.")
+	ErrEnvelopeWeakSignature = errors.New("Signed envelope uses a SHA-1 signature.")
+	ErrEnvelopeHasPayload    = errors.New("Signed envelope already contains its payload.")
+)
+
+type envelopeV1 struct {
+	HashAlgorithm string
+	SignedAt      uint64 // unix seconds
+	Certificate   []byte // SSH wire format
+	Signature     []byte // SSH wire format signature, including its algorithm
+	Payload       []byte // empty if detached
+	Detached      bool
+}
+
+type envelopeV1SignedData struct {
+	Magic         string
+	Version       uint8
+	HashAlgorithm string
+	SignedAt      uint64
+	Detached      bool
+	Digest        []byte
+}
+
+// Options for SignEnvelope.
+type EnvelopeOptions struct {
+	Hash     string // EnvelopeHashSHA256 or EnvelopeHashSHA512 (the default)
+	Detached bool   // if set, the payload is not included in the envelope
+}
+
+// A verified envelope, as returned by VerifyEnvelope.
+type Envelope struct {
+	HashAlgorithm string
+	SignedAt      time.Time        // as claimed by the signer
+	Certificate   *ssh.Certificate // of the signer
+	Payload       []byte           // nil if detached
+}
+
+func envelopeHash(name string) (crypto.Hash, error) {
+	switch name {
+	case EnvelopeHashSHA256:
+		return crypto.SHA256, nil
+	case EnvelopeHashSHA512:
+		return crypto.SHA512, nil
+	default:
+		return 0, ErrUnknownEnvelopeHash
+	}
+}
+
+// Returns a digest of the contents of r, also copied to keep if not nil.
+func envelopeDigest(h crypto.Hash, r io.Reader, keep *bytes.Buffer) ([]byte, error) {
+	hasher := h.New()
+	w := io.Writer(hasher)
+	if keep != nil {
+		w = io.MultiWriter(hasher, keep)
+	}
+	_, err := io.Copy(w, r)
+	if err != nil {
+		return nil, err
+	}
+	return hasher.Sum(nil), nil
+}
+
+// Signs the contents of r, read as a stream, with signer, whose certificate is cert, and returns a
+// version 1 envelope. Unless opts.Detached, the envelope includes the contents.
+func SignEnvelope(signer ssh.Signer, cert *ssh.Certificate, r io.Reader, opts *EnvelopeOptions) ([]byte, error) {
+	hashName := opts.Hash
+	if len(hashName) == 0 {
+		hashName = EnvelopeHashSHA512
+	}
+	h, err := envelopeHash(hashName)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload *bytes.Buffer
+	if !opts.Detached {
+		payload = &bytes.Buffer{}
+	}
+	digest, err := envelopeDigest(h, r, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	signedAt := uint64(time.Now().Unix())
+	toSign := ssh.Marshal(&envelopeV1SignedData{
+		Magic:         envelopeV1Magic,
+		Version:       envelopeVersion1,
+		HashAlgorithm: hashName,
+		SignedAt:      signedAt,
+		Detached:      opts.Detached,
+		Digest:        digest,
+	})
+	var sig *ssh.Signature
+	if as, ok := signer.(ssh.AlgorithmSigner); ok && cert.Key.Type() == ssh.KeyAlgoRSA {
+		sig, err = as.SignWithAlgorithm(rand.Reader, toSign, ssh.KeyAlgoRSASHA512)
+	} else {
+		sig, err = signer.Sign(rand.Reader, toSign)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	env := &envelopeV1{
+		HashAlgorithm: hashName,
+		SignedAt:      signedAt,
+		Certificate:   cert.Marshal(),
+		Signature:     ssh.Marshal(sig),
+		Detached:      opts.Detached,
+	}
+	if payload != nil {
+		env.Payload = payload.Bytes()
+	}
+	return append([]byte{envelopeVersion1}, ssh.Marshal(env)...), nil
+}
+
+// Checks a version 1 envelope, and that its certificate is signed by one of caKeys and valid
+// at t, usually now, or a time the verifier trusts the payload existed at, such as from a
+// timestamping service. The time signed is only the signer's word, so isn't used. For a
+// detached envelope, detached supplies the payload (read as a stream), otherwise it must be nil.
+func VerifyEnvelope(data []byte, detached io.Reader, caKeys []ssh.PublicKey, t time.Time) (*Envelope, error) {
+	if len(data) < 1 || data[0] != envelopeVersion1 {
+		return nil, ErrBadEnvelope
+	}
+	var env envelopeV1
+	err := ssh.Unmarshal(data[1:], &env)
+	if err != nil {
+		return nil, ErrBadEnvelope
+	}
+	h, err := envelopeHash(env.HashAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload io.Reader
+	switch {
+	case env.Detached && detached == nil:
+		return nil, ErrEnvelopeNeedsPayload
+	case env.Detached:
+		payload = detached
+	case detached != nil:
+		return nil, ErrEnvelopeHasPayload
+	default:
+		payload = bytes.NewReader(env.Payload)
+	}
+	digest, err := envelopeDigest(h, payload, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	pk, err := ssh.ParsePublicKey(env.Certificate)
+	if err != nil {
+		return nil, err
+	}
+	cert, ok := pk.(*ssh.Certificate)
+	if !ok || cert.CertType != ssh.UserCert {
+		return nil, ErrWrongCertType
+	}
+	var sig ssh.Signature
+	err = ssh.Unmarshal(env.Signature, &sig)
+	if err != nil {
+		return nil, ErrBadEnvelope
+	}
+	if sig.Format == ssh.KeyAlgoRSA {
+		return nil, ErrEnvelopeWeakSignature
+	}
+	err = cert.Key.Verify(ssh.Marshal(&envelopeV1SignedData{
+		Magic:         envelopeV1Magic,
+		Version:       envelopeVersion1,
+		HashAlgorithm: env.HashAlgorithm,
+		SignedAt:      env.SignedAt,
+		Detached:      env.Detached,
+		Digest:        digest,
+	}), &sig)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkSigningCert(cert, caKeys, t)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := &Envelope{
+		HashAlgorithm: env.HashAlgorithm,
+		SignedAt:      time.Unix(int64(env.SignedAt), 0),
+		Certificate:   cert,
+	}
+	if !env.Detached {
+		rv.Payload = env.Payload
+	}
+	return rv, nil
+}
+
+// Checks cert is signed by one of caKeys, and valid at t. Critical options restrict ssh
+// logins, not signing, so are all accepted.
+func checkSigningCert(cert *ssh.Certificate, caKeys []ssh.PublicKey, t time.Time) error {
+	trusted := false
+	for _, k := range caKeys {
+		if bytes.Equal(k.Marshal(), cert.SignatureKey.Marshal()) {
+			trusted = true
+		}
+	}
+	if !trusted {
+		return ErrUntrustedCA
+	}
+	checker := &ssh.CertChecker{Clock: func() time.Time { return t }}
+	for opt := range cert.CriticalOptions {
+		checker.SupportedCriticalOptions = append(checker.SupportedCriticalOptions, opt)
+	}
+	principal := ""
+	if len(cert.ValidPrincipals) > 0 {
+		principal = cert.ValidPrincipals[0]
+	}
+	return checker.CheckCert(principal, cert)
+}
+
+// Namespace for SSH signatures of files, as commonly used with ssh-keygen -Y sign.
+const defaultSSHSIGNamespace = "file"
+
+// sign [-detached] [-hash sha256|sha512] [-format envelope|sshsig] [-n namespace] [file]
+// Signs the file (or stdin) with the installed key and certificate, writing a version 1 envelope,
+// or with -format sshsig a (detached) SSH signature in namespace, to stdout.
+func signCommand(ctx context.Context, config *ClientAppConfiguration, args []string) error {
+	opts := &EnvelopeOptions{}
+	sshsig := false
+	namespace := defaultSSHSIGNamespace
+	var files []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-detached":
+			opts.Detached = true
+		case "-hash", "-format", "-n":
+			if i+1 == len(args) {
+				return ErrUsage
+			}
+			i++
+			switch args[i-1] {
+			case "-hash":
+				opts.Hash = args[i]
+			case "-n":
+				namespace = args[i]
+			case "-format":
+				if args[i] != "envelope" && args[i] != "sshsig" {
+					return ErrUsage
+				}
+				sshsig = args[i] == "sshsig"
+			}
+		default:
+			files = append(files, args[i])
+		}
+	}
+	if len(files) > 1 {
+		return ErrUsage
+	}
+
+	in := io.Reader(os.Stdin)
+	if len(files) == 1 && files[0] != "-" {
+		f, err := os.Open(files[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	err := EnsureFreshCert(ctx, config)
+	if err != nil {
+		return err
+	}
+	signer, cert, err := loadSigningKey(config)
+	if err != nil {
+		return err
+	}
+	var out []byte
+	if sshsig {
+		out, err = SignSSHSIG(signer, cert, namespace, in, opts.Hash)
+	} else {
+		out, err = SignEnvelope(signer, cert, in, opts)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(out)
+	return err
+}
+
+// verify [-ca_keys path] [-n namespace] [-at time] signature [file]
+// Checks an envelope, with the payload from file (or stdin, if "-") if detached, or an SSH
+// signature (e.g. from ssh-keygen -Y sign) in namespace over file, by a certificate valid now,
+// or at the RFC 3339 time given. The signer is described on stderr, and an included payload
+// written to stdout.
+func verifyCommand(ctx context.Context, config *ClientAppConfiguration, args []string) error {
+	caKeysPath := DefaultTrustedUserCAKeysPath
+	namespace := defaultSSHSIGNamespace
+	at := time.Now()
+	var files []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-ca_keys", "-n", "-at":
+			if i+1 == len(args) {
+				return ErrUsage
+			}
+			i++
+			switch args[i-1] {
+			case "-n":
+				namespace = args[i]
+			case "-at":
+				var err error
+				at, err = time.Parse(time.RFC3339, args[i])
+				if err != nil {
+					return ErrUsage
+				}
+			default:
+				caKeysPath = args[i]
+			}
+		default:
+			files = append(files, args[i])
+		}
+	}
+	if len(files) < 1 || len(files) > 2 {
+		return ErrUsage
+	}
+
+	caKeys, err := LoadAuthorizedKeys(caKeysPath)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		return err
+	}
+	var detached io.Reader
+	if len(files) == 2 {
+		if files[1] == "-" {
+			detached = os.Stdin
+		} else {
+			f, err := os.Open(files[1])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			detached = f
+		}
+	}
+
+	if isSSHSIG(data) {
+		if detached == nil {
+			return ErrEnvelopeNeedsPayload
+		}
+		cert, err := VerifySSHSIG(data, detached, namespace, caKeys, at)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Good %q signature from %s (certificate %d, principals %s).\n", namespace, cert.KeyId, cert.Serial, strings.Join(cert.ValidPrincipals, ", "))
+		return nil
+	}
+
+	env, err := VerifyEnvelope(data, detached, caKeys, at)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Good signature from %s (certificate %d, principals %s), claiming to be signed at %s.\n", env.Certificate.KeyId, env.Certificate.Serial, strings.Join(env.Certificate.ValidPrincipals, ", "), env.SignedAt.Format(time.RFC3339))
+	if env.Payload != nil {
+		_, err = os.Stdout.Write(env.Payload)
+	}
+	return err
+}
@@ -0,0 +1,138 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestEnvelopeV1(t *testing.T) {
+	ca := testCA(t)
+	rk, _ := rsa.GenerateKey(rand.Reader, 2048)
+	_, ek, _ := ed25519.GenerateKey(rand.Reader)
+	payload := bytes.Repeat([]byte("data"), 100000)
+	for _, key := range []interface{}{rk, ek} {
+		signer, cert := testCertSigner(t, ca, key, []string{"alice"}, time.Now().Add(time.Hour))
+		for _, opts := range []*EnvelopeOptions{{}, {Hash: EnvelopeHashSHA256}, {Detached: true}} {
+			env, err := SignEnvelope(signer, cert, bytes.NewReader(payload), opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var detached *bytes.Reader
+			if opts.Detached {
+				if len(env) > 10000 {
+					t.Fatal("payload included")
+				}
+				detached = bytes.NewReader(payload)
+			}
+			var r interface{ Read([]byte) (int, error) }
+			if detached != nil {
+				r = detached
+			}
+			got, err := VerifyEnvelope(env, r, []ssh.PublicKey{ca.PublicKey()}, time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !opts.Detached && !bytes.Equal(got.Payload, payload) {
+				t.Fatal("payload")
+			}
+			if got.Certificate.KeyId != "alice" {
+				t.Fatal(got)
+			}
+			if detached != nil {
+				detached.Seek(0, 0)
+			}
+			if _, err := VerifyEnvelope(env, r, nil, time.Now()); err != ErrUntrustedCA {
+				t.Fatal(err)
+			}
+			// tampering with the payload or hash algorithm fails
+			if opts.Detached {
+				if _, err := VerifyEnvelope(env, strings.NewReader("other"), []ssh.PublicKey{ca.PublicKey()}, time.Now()); err == nil {
+					t.Fatal("expected error")
+				}
+				if _, err := VerifyEnvelope(env, nil, []ssh.PublicKey{ca.PublicKey()}, time.Now()); err != ErrEnvelopeNeedsPayload {
+					t.Fatal(err)
+				}
+			} else {
+				bad := append([]byte{}, env...)
+				bad[len(bad)-10] ^= 1
+				if _, err := VerifyEnvelope(bad, nil, []ssh.PublicKey{ca.PublicKey()}, time.Now()); err == nil {
+					t.Fatal("expected error")
+				}
+			}
+		}
+	}
+	if _, err := SignEnvelope(nil, nil, nil, &EnvelopeOptions{Hash: "md5"}); err != ErrUnknownEnvelopeHash {
+		t.Fatal(err)
+	}
+	if _, err := VerifyEnvelope([]byte{0}, nil, nil, time.Now()); err != ErrBadEnvelope {
+		t.Fatal(err)
+	}
+	// a backdated envelope by an expired certificate is refused
+	signer, cert := testCertSigner(t, ca, ek, []string{"alice"}, time.Now().Add(-time.Second))
+	signedAt := uint64(time.Now().Add(-30 * time.Second).Unix())
+	digest, _ := envelopeDigest(crypto.SHA512, strings.NewReader("x"), nil)
+	sig, _ := signer.Sign(rand.Reader, ssh.Marshal(&envelopeV1SignedData{Magic: envelopeV1Magic, Version: envelopeVersion1, HashAlgorithm: EnvelopeHashSHA512, SignedAt: signedAt, Digest: digest}))
+	env := append([]byte{envelopeVersion1}, ssh.Marshal(&envelopeV1{HashAlgorithm: EnvelopeHashSHA512, SignedAt: signedAt, Certificate: cert.Marshal(), Signature: ssh.Marshal(sig), Payload: []byte("x")})...)
+	if _, err := VerifyEnvelope(env, nil, []ssh.PublicKey{ca.PublicKey()}, time.Now()); err == nil {
+		t.Fatal("expected error for backdated envelope")
+	}
+	// unless the verifier trusts the time
+	if _, err := VerifyEnvelope(env, nil, []ssh.PublicKey{ca.PublicKey()}, time.Unix(int64(signedAt), 0)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEnvelopeRefused(t *testing.T) {
+	ca := testCA(t)
+	rk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, cert := testCertSigner(t, ca, rk, []string{"alice"}, time.Now().Add(time.Hour))
+	expiredSigner, expiredCert := testCertSigner(t, ca, rk, []string{"alice"}, time.Now().Add(-time.Minute))
+	for _, c := range []struct {
+		name   string
+		signer ssh.Signer
+		cert   *ssh.Certificate
+		caKeys []ssh.PublicKey
+		want   error
+	}{
+		{"ok", signer, cert, []ssh.PublicKey{ca.PublicKey()}, nil},
+		{"SHA-1", sha1Signer{signer}, cert, []ssh.PublicKey{ca.PublicKey()}, ErrEnvelopeWeakSignature},
+		{"untrusted CA", signer, cert, []ssh.PublicKey{testCA(t).PublicKey()}, ErrUntrustedCA},
+		{"expired certificate", expiredSigner, expiredCert, []ssh.PublicKey{ca.PublicKey()}, errAny},
+	} {
+		env, err := SignEnvelope(c.signer, c.cert, strings.NewReader("x"), &EnvelopeOptions{})
+		if err != nil {
+			t.Fatal(c.name, err)
+		}
+		_, err = VerifyEnvelope(env, nil, c.caKeys, time.Now())
+		checkTestError(t, c.name, err, c.want)
+	}
+}
@@ -0,0 +1,189 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/continusec/geecert/sso"
+)
+
+// Errors that callers may check for with errors.Is, whether they arise locally or are
+// returned by the server.
+var (
+	ErrTokenExpired     = errors.New("ID token or refresh token has expired.")
+	ErrDomainMismatch   = errors.New("ID token is not for an account in the allowed domain.")
+	ErrServerRejected   = errors.New("Server rejected the request.") // matches any *ServerError, use errors.As for the status
+	ErrAgentUnavailable = errors.New("Unable to connect to agent.")
+	ErrPolicyFailed     = errors.New("Machine does not meet policy.")
+	ErrPartialInstall   = errors.New("Certificate was only partially installed.")
+	ErrOffline          = errors.New("Unable to reach the server or Google.")        // matches any *OfflineError
+	ErrMessageTooLarge  = errors.New("Message to or from the server was too large.") // matches any *MessageSizeError
+)
+
+// Is reports whether the server error means the same as one of the errors above, or ErrInvalidIDToken.
+func (e *ServerError) Is(target error) bool {
+	switch target {
+	case ErrServerRejected:
+		return true
+	case ErrTokenExpired:
+		return e.Reason == pb.ErrorReason_TOKEN_EXPIRED
+	case ErrDomainMismatch:
+		return e.Reason == pb.ErrorReason_DOMAIN_MISMATCH
+	case ErrPolicyFailed:
+		return e.Reason == pb.ErrorReason_POLICY_VIOLATION
+	case ErrMessageTooLarge:
+		return e.Reason == pb.ErrorReason_MESSAGE_TOO_LARGE
+	case ErrInvalidIDToken:
+		return e.Reason == pb.ErrorReason_TOKEN_INVALID || e.Status == pb.ResponseCode_INVALID_ID_TOKEN
+	}
+	return false
+}
+
+// Signed in to Google with an account outside of the hosted domain. Matches ErrDomainMismatch.
+type DomainMismatchError struct {
+	Email        string
+	HostedDomain string
+}
+
+func (e *DomainMismatchError) Error() string {
+	return fmt.Sprintf("Signed in to Google as %s, but an account in %s is required. Please run again and choose an account in %s.", e.Email, e.HostedDomain, e.HostedDomain)
+}
+
+func (e *DomainMismatchError) Is(target error) bool {
+	return target == ErrDomainMismatch
+}
+
+// A local machine policy is not met. Matches ErrPolicyFailed.
+type PolicyError struct {
+	Message string
+}
+
+func (e *PolicyError) Error() string {
+	return e.Message
+}
+
+func (e *PolicyError) Is(target error) bool {
+	return target == ErrPolicyFailed
+}
+
+// An error response from Google's token endpoint. If the refresh token has expired or been
+// revoked (invalid_grant), matches ErrTokenExpired.
+type TokenEndpointError struct {
+	StatusCode  int
+	Code        string // e.g. invalid_grant
+	Description string
+}
+
+func (e *TokenEndpointError) Error() string {
+	if len(e.Code) == 0 {
+		return fmt.Sprintf("Unexpected response from token endpoint: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	if len(e.Description) == 0 {
+		return fmt.Sprintf("Token endpoint returned %s", e.Code)
+	}
+	return fmt.Sprintf("Token endpoint returned %s: %s", e.Code, e.Description)
+}
+
+func (e *TokenEndpointError) Is(target error) bool {
+	return target == ErrTokenExpired && e.Code == "invalid_grant"
+}
+
+func tokenEndpointError(resp *http.Response, body []byte) error {
+	var oe struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	json.Unmarshal(body, &oe)
+	return &TokenEndpointError{
+		StatusCode:  resp.StatusCode,
+		Code:        oe.Error,
+		Description: oe.ErrorDescription,
+	}
+}
+
+// The key and certificate were written, but a later step (e.g. updating known_hosts or the ssh
+// config, or adding to an agent) failed. Matches ErrPartialInstall.
+type PartialInstallError struct {
+	Err error
+}
+
+func (e *PartialInstallError) Error() string {
+	return "Certificate written, but installation did not complete: " + e.Err.Error()
+}
+
+func (e *PartialInstallError) Unwrap() error {
+	return e.Err
+}
+
+func (e *PartialInstallError) Is(target error) bool {
+	return target == ErrPartialInstall
+}
+
+// The server or Google could not be reached, e.g. no network or VPN. Remaining is how long the
+// installed certificate is still valid for, 0 if there is none or it has expired. Matches ErrOffline.
+type OfflineError struct {
+	Err       error
+	Remaining time.Duration
+}
+
+func (e *OfflineError) Error() string {
+	if e.Remaining <= 0 {
+		return fmt.Sprintf("Unable to reach the server or Google (%s), and you have no valid certificate. Check your network connection and VPN.", e.Err)
+	}
+	return fmt.Sprintf("Unable to reach the server or Google (%s). Your current certificate is still valid for %s.", e.Err, e.Remaining.Round(time.Minute))
+}
+
+func (e *OfflineError) Unwrap() error {
+	return e.Err
+}
+
+func (e *OfflineError) Is(target error) bool {
+	return target == ErrOffline
+}
+
+// Returns true if err means that the server or Google could not be reached, rather than that
+// either rejected the request.
+func isOffline(err error) bool {
+	var oe *net.OpError
+	var de *net.DNSError
+	var ne net.Error
+	switch {
+	case err == nil:
+		return false
+	case errors.As(err, &oe), errors.As(err, &de), errors.As(err, &ne) && ne.Timeout():
+		return true
+	}
+	var se interface {
+		GRPCStatus() *status.Status
+	}
+	if errors.As(err, &se) {
+		c := se.GRPCStatus().Code()
+		return c == codes.Unavailable || c == codes.DeadlineExceeded
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"errors"
+	"net"
+
+	"google.golang.org/grpc/status"
+
+	pb "github.com/continusec/geecert/sso"
+)
+
+// Exit codes for client programs, so that scripts and MDM tooling can tell outcomes apart.
+// Returned by ExitCode.
+const (
+	ExitOK             = 0
+	ExitError          = 1 // any other error
+	ExitUsage          = 2 // unknown command or bad arguments, as for the flag package
+	ExitUserDenied     = 3 // the user denied authorization in the browser
+	ExitPolicyFailed   = 4 // the machine does not meet policy, e.g. FileVault is off
+	ExitServerError    = 5 // the server or Google could not be reached, or rejected the request
+	ExitTokenExpired   = 6 // credentials have expired or been revoked, run interactively to sign in again
+	ExitPartialInstall = 7 // the certificate was written, but e.g. the ssh config could not be updated
+)
+
+// Returns the exit code that a client program should exit with after err, e.g.
+// os.Exit(geecert.ExitCode(geecert.RunCommand(ctx, config, flag.Args()))).
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, ErrPartialInstall):
+		return ExitPartialInstall
+	case errors.Is(err, ErrUserDenied):
+		return ExitUserDenied
+	case errors.Is(err, ErrPolicyFailed), isDevicePolicyError(err):
+		return ExitPolicyFailed
+	case errors.Is(err, ErrTokenExpired):
+		return ExitTokenExpired
+	case errors.Is(err, ErrUsage), errors.Is(err, ErrUnknownCommand):
+		return ExitUsage
+	case isServerError(err):
+		return ExitServerError
+	}
+	return ExitError
+}
+
+// The server refused the request as the device isn't enrolled, managed or compliant.
+func isDevicePolicyError(err error) bool {
+	var se *ServerError
+	if !errors.As(err, &se) {
+		return false
+	}
+	return se.Status == pb.ResponseCode_DEVICE_NOT_ENROLLED || se.Status == pb.ResponseCode_DEVICE_NOT_COMPLIANT
+}
+
+func isServerError(err error) bool {
+	switch {
+	case errors.Is(err, ErrServerRejected), errors.Is(err, ErrNotAuthorized), errors.Is(err, ErrReauthRequired), errors.Is(err, ErrReasonRequired):
+		return true
+	}
+	var te *TokenEndpointError
+	if errors.As(err, &te) {
+		return true
+	}
+	var ne net.Error
+	if errors.As(err, &ne) {
+		return true
+	}
+	var se interface {
+		GRPCStatus() *status.Status
+	}
+	return errors.As(err, &se)
+}
@@ -0,0 +1,186 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	pb "github.com/continusec/geecert/sso"
+	"golang.org/x/crypto/ssh"
+)
+
+// What outcomes that the server only gives along with more detail mean for a request.
+var explainRemediation = map[pb.ResponseCode]string{
+	pb.ResponseCode_APPROVAL_PENDING: "Requests must be approved by an approver.",
+	pb.ResponseCode_REASON_REQUIRED:  "Requests must give a reason, with -reason.",
+	pb.ResponseCode_REAUTH_REQUIRED:  "Sign in again before requesting a certificate.",
+}
+
+// explain
+// Shows what the server would issue us (or OnBehalfOf, with Role), the policy that decided it,
+// and how that differs from the installed certificate.
+func explainCommand(ctx context.Context, config *ClientAppConfiguration, args []string) error {
+	if len(args) != 0 {
+		return ErrUsage
+	}
+
+	resp, err := ExplainPolicy(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Email:            %s\n", resp.Email)
+	if len(resp.Groups) > 0 {
+		fmt.Printf("Groups:           %s\n", strings.Join(resp.Groups, ", "))
+	}
+	remediation := responseCodeRemediation[resp.Outcome]
+	if len(remediation) == 0 {
+		remediation = explainRemediation[resp.Outcome]
+	}
+	if len(remediation) == 0 {
+		fmt.Printf("Outcome:          %s\n", resp.Outcome)
+	} else {
+		fmt.Printf("Outcome:          %s. %s\n", resp.Outcome, remediation)
+	}
+	fmt.Printf("Principals:       %s\n", strings.Join(resp.Principals, ", "))
+	fmt.Printf("Valid for:        %s\n", time.Duration(resp.ValiditySeconds)*time.Second)
+	printCertOptions("Critical options:", resp.CriticalOptions)
+	printCertOptions("Extensions:", resp.Extensions)
+	fmt.Println("Rules:")
+	for _, r := range resp.Rules {
+		fmt.Printf("    %s\n", r)
+	}
+
+	cert, err := LoadInstalledCert(config)
+	if err != nil {
+		fmt.Println("\nNo certificate is installed.")
+		return nil
+	}
+	fmt.Printf("\nCompared with the installed certificate (serial %d):\n", cert.Serial)
+	diffs := explainDiffs(resp, cert)
+	if len(diffs) == 0 {
+		fmt.Println("    (no differences)")
+	}
+	for _, d := range diffs {
+		fmt.Printf("    %s\n", d)
+	}
+	return nil
+}
+
+// Returns what would be different about a certificate issued as resp explains from cert,
+// "+" for what would be added, "-" for what would be removed and "~" for what would change.
+func explainDiffs(resp *pb.ExplainPolicyResponse, cert *ssh.Certificate) []string {
+	var rv []string
+	rv = append(rv, diffStrings("principal", resp.Principals, cert.ValidPrincipals)...)
+	rv = append(rv, diffOptions("critical option", resp.CriticalOptions, cert.CriticalOptions)...)
+	rv = append(rv, diffOptions("extension", resp.Extensions, cert.Extensions)...)
+
+	// The installed certificate was valid from valid_after_skew_seconds before it was issued
+	want := time.Duration(resp.ValiditySeconds) * time.Second
+	have := time.Duration(int64(cert.ValidBefore-cert.ValidAfter)-resp.ValidAfterSkewSeconds) * time.Second
+	if want != have {
+		rv = append(rv, fmt.Sprintf("~ valid for %s, was %s", want, have))
+	}
+	return rv
+}
+
+func diffStrings(what string, want, have []string) []string {
+	var rv []string
+	in := func(list []string, s string) bool {
+		for _, v := range list {
+			if v == s {
+				return true
+			}
+		}
+		return false
+	}
+	for _, s := range want {
+		if !in(have, s) {
+			rv = append(rv, fmt.Sprintf("+ %s %s", what, s))
+		}
+	}
+	for _, s := range have {
+		if !in(want, s) {
+			rv = append(rv, fmt.Sprintf("- %s %s", what, s))
+		}
+	}
+	return rv
+}
+
+// Options only in have are expected where they depend on the request, e.g. the reason.
+func diffOptions(what string, want, have map[string]string) []string {
+	var names []string
+	for k := range want {
+		names = append(names, k)
+	}
+	for k := range have {
+		if _, ok := want[k]; !ok {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+
+	var rv []string
+	for _, k := range names {
+		w, inWant := want[k]
+		h, inHave := have[k]
+		switch {
+		case !inHave:
+			rv = append(rv, fmt.Sprintf("+ %s %s", what, k))
+		case !inWant:
+			rv = append(rv, fmt.Sprintf("- %s %s", what, k))
+		case w != h:
+			rv = append(rv, fmt.Sprintf("~ %s %s: %q, was %q", what, k, w, h))
+		}
+	}
+	return rv
+}
+
+// Ask the server what it would issue us, and why, for OnBehalfOf and Role if set.
+func ExplainPolicy(ctx context.Context, config *ClientAppConfiguration) (*pb.ExplainPolicyResponse, error) {
+	idToken, _, err := GetValidIDToken(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := DialServer(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	resp, err := NewClient(conn).ExplainPolicy(ctx, &pb.ExplainPolicyRequest{
+		IdToken:        idToken,
+		CredentialType: credentialType(config),
+		OnBehalfOf:     config.OnBehalfOf,
+		Role:           config.Role,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Status != pb.ResponseCode_OK {
+		return nil, responseCodeError(resp.Status)
+	}
+	return resp, nil
+}
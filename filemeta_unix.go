@@ -0,0 +1,110 @@
+//go:build linux || darwin
+// +build linux darwin
+
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+const selinuxXattr = "security.selinux"
+
+// Copies the owner and extended attributes (including SELinux context and ACLs) of the file
+// at from, if it exists, to the file at to, which is about to replace it. Failures are logged
+// and ignored, but if the SELinux context can't be copied, restorecon is tried after the
+// file is replaced.
+func copyFileAttributes(from, to string) (restorecon bool) {
+	fi, err := os.Stat(from)
+	if err != nil {
+		return false
+	}
+
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok && (int(st.Uid) != os.Getuid() || int(st.Gid) != os.Getgid()) {
+		err = os.Chown(to, int(st.Uid), int(st.Gid))
+		if err != nil {
+			logVerbose("Unable to keep owner of %s: %s", from, err)
+		}
+	}
+
+	names, err := xattrNames(from)
+	if err != nil {
+		logVerbose("Unable to read extended attributes of %s: %s", from, err)
+		return false
+	}
+	for _, name := range names {
+		value, err := xattr(from, name)
+		if err == nil {
+			err = unix.Setxattr(to, name, value, 0)
+		}
+		if err != nil {
+			logVerbose("Unable to keep extended attribute %s of %s: %s", name, from, err)
+			if name == selinuxXattr {
+				restorecon = true
+			}
+		}
+	}
+	return restorecon
+}
+
+// Resets the SELinux context of path to the policy default, e.g. ssh_home_t in ~/.ssh.
+func restoreSELinuxContext(path string) {
+	out, err := exec.Command("restorecon", path).CombinedOutput()
+	if err != nil {
+		logVerbose("Unable to restore SELinux context of %s: %s %s", path, err, bytes.TrimSpace(out))
+	}
+}
+
+func xattrNames(path string) ([]string, error) {
+	size, err := unix.Listxattr(path, nil)
+	if err != nil || size == 0 {
+		return nil, err
+	}
+	buf := make([]byte, size)
+	size, err = unix.Listxattr(path, buf)
+	if err != nil {
+		return nil, err
+	}
+	var rv []string
+	for _, name := range bytes.Split(buf[:size], []byte{0}) {
+		if len(name) > 0 {
+			rv = append(rv, string(name))
+		}
+	}
+	return rv, nil
+}
+
+func xattr(path, name string) ([]byte, error) {
+	size, err := unix.Getxattr(path, name, nil)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, size)
+	size, err = unix.Getxattr(path, name, buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:size], nil
+}
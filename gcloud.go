@@ -0,0 +1,124 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+// Values for ClientAppConfiguration.BrokeredAuth.
+const (
+	BrokeredAuthGcloud = "gcloud" // gcloud auth print-identity-token, for the user logged in with gcloud auth login
+	BrokeredAuthADC    = "adc"    // application default credentials, from gcloud auth application-default login
+
+	// Client ID of the Cloud SDK, that ID tokens from gcloud auth print-identity-token are issued to
+	GcloudClientID = "32555940559.apps.googleusercontent.com"
+)
+
+var (
+	ErrUnknownBrokeredAuth = errors.New("Unknown brokered auth, must be gcloud or adc.")
+	ErrNoADCUser           = errors.New("No application default credentials for a user found. Run: gcloud auth application-default login")
+)
+
+// Application default credentials file, as written by gcloud auth application-default login.
+type adcFile struct {
+	Type         string `json:"type"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Returns an ID token from the Cloud SDK, as per BrokeredAuth, and the client ID that it is issued to.
+func brokeredIDToken(ctx context.Context, config *ClientAppConfiguration) (string, string, error) {
+	switch config.BrokeredAuth {
+	case BrokeredAuthGcloud:
+		logVerbose("Getting ID token from gcloud.")
+		out, err := exec.CommandContext(ctx, "gcloud", "auth", "print-identity-token").Output()
+		if err != nil {
+			return "", "", fmt.Errorf("Running gcloud auth print-identity-token: %w", err)
+		}
+		return strings.TrimSpace(string(out)), GcloudClientID, nil
+	case BrokeredAuthADC:
+		path, err := adcPath()
+		if err != nil {
+			return "", "", err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return "", "", ErrNoADCUser
+			}
+			return "", "", err
+		}
+		var adc adcFile
+		err = json.Unmarshal(data, &adc)
+		if err != nil {
+			return "", "", fmt.Errorf("Parsing %s: %w", path, err)
+		}
+		if adc.Type != "authorized_user" || len(adc.RefreshToken) == 0 {
+			return "", "", ErrNoADCUser
+		}
+		logVerbose("Getting ID token for application default credentials in %s.", path)
+		creds, err := SwapRefreshForTokens(ctx, &ClientAppConfiguration{ClientID: adc.ClientID, ClientNotSoSecret: adc.ClientSecret}, adc.RefreshToken)
+		if err != nil {
+			return "", "", err
+		}
+		return creds.IDToken, adc.ClientID, nil
+	default:
+		return "", "", ErrUnknownBrokeredAuth
+	}
+}
+
+// Where the Cloud SDK looks for application default credentials.
+func adcPath() (string, error) {
+	if p := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); len(p) > 0 {
+		return p, nil
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("APPDATA"), "gcloud", "application_default_credentials.json"), nil
+	}
+	hd, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(hd, ".config", "gcloud", "application_default_credentials.json"), nil
+}
+
+// As per GetValidIDToken, with the ID token from the Cloud SDK.
+func getBrokeredIDToken(ctx context.Context, config *ClientAppConfiguration) (string, *IDTokenClaims, error) {
+	idToken, clientID, err := brokeredIDToken(ctx, config)
+	if err != nil {
+		return "", nil, err
+	}
+	idTokenClaims, err := ValidateTokenWithRetryForClockUsing(ctx, idTokenValidator(config), idToken, clientID, config.HostedDomain, 5)
+	if err != nil {
+		return "", nil, err
+	}
+	return idToken, idTokenClaims, nil
+}
@@ -0,0 +1,76 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecerttest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// A throwaway CA, with its key written where a server can load it from ca_key_path.
+type CA struct {
+	Key       *rsa.PrivateKey
+	Signer    ssh.Signer
+	PublicKey ssh.PublicKey
+	Path      string // of the PEM encoded private key
+}
+
+// Creates a CA with a new key, saved as "ca" in dir.
+func NewCA(dir string) (*CA, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(dir, "ca")
+	err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CA{
+		Key:       key,
+		Signer:    signer,
+		PublicKey: signer.PublicKey(),
+		Path:      path,
+	}, nil
+}
+
+// Returns true if cert (in authorized_keys format, as returned by the server) was signed by this CA.
+func (ca *CA) Signed(cert string) bool {
+	pk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(cert))
+	if err != nil {
+		return false
+	}
+	c, ok := pk.(*ssh.Certificate)
+	if !ok {
+		return false
+	}
+	return string(c.SignatureKey.Marshal()) == string(ca.PublicKey.Marshal())
+}
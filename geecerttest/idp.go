@@ -0,0 +1,124 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+// Package geecerttest provides fakes for testing geecert clients and servers without
+// Google, and for load testing servers with synthetic users.
+package geecerttest
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+
+	"github.com/continusec/geecert"
+)
+
+const fakeIdPKeyID = "geecerttest"
+
+var ErrBadPEM = errors.New("Unable to find RSA private key in PEM file.")
+
+// Issues and validates ID tokens in the same form as Google, for any email address, signed
+// with its own key. Never trust a FakeIdP outside of tests.
+type FakeIdP struct {
+	Key          *rsa.PrivateKey
+	ClientID     string
+	HostedDomain string
+	Lifetime     time.Duration // how long tokens are valid for, default is an hour
+	ClockOffset  time.Duration // added to the time tokens are issued at, e.g. to test clock skew
+}
+
+// Creates a FakeIdP with a new key.
+func NewFakeIdP(clientID, hostedDomain string) (*FakeIdP, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	return &FakeIdP{Key: key, ClientID: clientID, HostedDomain: hostedDomain}, nil
+}
+
+// Loads the key for a FakeIdP from a PEM file, first creating it if it doesn't exist, so that
+// a load testing tool and a server can share it.
+func LoadOrCreateFakeIdP(path, clientID, hostedDomain string) (*FakeIdP, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		idp, err := NewFakeIdP(clientID, hostedDomain)
+		if err != nil {
+			return nil, err
+		}
+		err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(idp.Key)}), 0600)
+		if err != nil {
+			return nil, err
+		}
+		return idp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "RSA PRIVATE KEY" {
+		return nil, ErrBadPEM
+	}
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	return &FakeIdP{Key: key, ClientID: clientID, HostedDomain: hostedDomain}, nil
+}
+
+// Returns a signed ID token for email, as if they had just authenticated.
+func (idp *FakeIdP) IDToken(email string) (string, error) {
+	lifetime := idp.Lifetime
+	if lifetime == 0 {
+		lifetime = time.Hour
+	}
+	now := time.Now().Add(idp.ClockOffset)
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            "accounts.google.com",
+		"aud":            idp.ClientID,
+		"sub":            email,
+		"hd":             idp.HostedDomain,
+		"email":          email,
+		"email_verified": true,
+		"iat":            now.Unix(),
+		"auth_time":      now.Unix(),
+		"exp":            now.Add(lifetime).Unix(),
+	})
+	token.Header["kid"] = fakeIdPKeyID
+	return token.SignedString(idp.Key)
+}
+
+// As per geecert.ValidateIDToken, but for tokens issued by this FakeIdP, so that a FakeIdP
+// can be used as a geecert.IDTokenValidator.
+func (idp *FakeIdP) ValidateIDToken(ctx context.Context, idToken, clientID, hostedDomain string) (*geecert.IDTokenClaims, error) {
+	return idp.Validator().ValidateIDToken(ctx, idToken, clientID, hostedDomain)
+}
+
+// Returns a validator that accepts tokens from this FakeIdP, without needing its private key.
+func (idp *FakeIdP) Validator() *geecert.StaticKeyIDTokenValidator {
+	return &geecert.StaticKeyIDTokenValidator{
+		Keys: map[string]*rsa.PublicKey{fakeIdPKeyID: &idp.Key.PublicKey},
+	}
+}
@@ -0,0 +1,196 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecerttest
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"google.golang.org/grpc/status"
+
+	"github.com/continusec/geecert"
+	pb "github.com/continusec/geecert/sso"
+)
+
+var ErrNoIdentities = errors.New("At least one email address is needed for a load test.")
+
+// What to send in a load test. The server must accept ID tokens from IdP, and allow each
+// of the email addresses in Emails.
+type LoadTestOptions struct {
+	Config      *geecert.ClientAppConfiguration // server address and TLS settings
+	IdP         *FakeIdP
+	Emails      []string // synthetic users, used in turn
+	Requests    int      // total GetSSHCerts calls to make
+	Concurrency int      // calls in flight at once, each worker has its own connection
+}
+
+type LoadTestResult struct {
+	Requests  int
+	Failures  map[string]int // count of failed calls by status or error code
+	Duration  time.Duration
+	Latencies []time.Duration // of each call, in increasing order
+}
+
+// Returns the latency that p percent of calls completed within.
+func (r *LoadTestResult) Percentile(p float64) time.Duration {
+	if len(r.Latencies) == 0 {
+		return 0
+	}
+	i := int(float64(len(r.Latencies))*p/100+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(r.Latencies) {
+		i = len(r.Latencies) - 1
+	}
+	return r.Latencies[i]
+}
+
+func (r *LoadTestResult) String() string {
+	failed := 0
+	for _, n := range r.Failures {
+		failed += n
+	}
+	rv := fmt.Sprintf("%d requests in %s (%.1f/s), %d failed\n", r.Requests, r.Duration, float64(r.Requests)/r.Duration.Seconds(), failed)
+	rv += fmt.Sprintf("p50 %s  p90 %s  p99 %s  max %s\n", r.Percentile(50), r.Percentile(90), r.Percentile(99), r.Percentile(100))
+	for code, n := range r.Failures {
+		rv += fmt.Sprintf("  %6d %s\n", n, code)
+	}
+	return rv
+}
+
+// Makes concurrent GetSSHCerts calls to a server, as different users, timing each.
+// Stops early, returning the results so far, if ctx is done.
+func RunLoadTest(ctx context.Context, opts *LoadTestOptions) (*LoadTestResult, error) {
+	if len(opts.Emails) == 0 {
+		return nil, ErrNoIdentities
+	}
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	// Mint tokens up front so that we only time the server
+	tokens := make([]string, len(opts.Emails))
+	for i, email := range opts.Emails {
+		t, err := opts.IdP.IDToken(email)
+		if err != nil {
+			return nil, err
+		}
+		tokens[i] = t
+	}
+
+	rv := &LoadTestResult{Failures: make(map[string]int)}
+	var lock sync.Mutex
+	next := 0
+	take := func() int {
+		lock.Lock()
+		defer lock.Unlock()
+		if next >= opts.Requests {
+			return -1
+		}
+		next++
+		return next - 1
+	}
+	record := func(latency time.Duration, failure string) {
+		lock.Lock()
+		defer lock.Unlock()
+		rv.Requests++
+		rv.Latencies = append(rv.Latencies, latency)
+		if len(failure) > 0 {
+			rv.Failures[failure]++
+		}
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, concurrency)
+	start := time.Now()
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := loadTestWorker(ctx, opts.Config, tokens, take, record)
+			if err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	rv.Duration = time.Since(start)
+	close(errs)
+	if err, ok := <-errs; ok {
+		return nil, err
+	}
+
+	sort.Slice(rv.Latencies, func(i, j int) bool {
+		return rv.Latencies[i] < rv.Latencies[j]
+	})
+	return rv, nil
+}
+
+func loadTestWorker(ctx context.Context, config *geecert.ClientAppConfiguration, tokens []string, take func() int, record func(time.Duration, string)) error {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	pk, err := ssh.NewPublicKey(key.Public())
+	if err != nil {
+		return err
+	}
+	pubKey := base64.StdEncoding.EncodeToString(pk.Marshal())
+
+	conn, err := geecert.DialServer(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	client := geecert.NewClient(conn)
+
+	for i := take(); i >= 0 && ctx.Err() == nil; i = take() {
+		start := time.Now()
+		resp, err := client.GetSSHCerts(ctx, &pb.SSHCertsRequest{
+			IdToken:   tokens[i%len(tokens)],
+			PublicKey: pubKey,
+		})
+		record(time.Since(start), loadTestFailure(resp, err))
+	}
+	return nil
+}
+
+// Returns "" if the call succeeded, else what went wrong, e.g. RATE_LIMITED.
+func loadTestFailure(resp *pb.SSHCertsResponse, err error) string {
+	var se *geecert.ServerError
+	switch {
+	case errors.As(err, &se):
+		return se.Status.String()
+	case err != nil:
+		return status.Code(err).String()
+	case resp.Status != pb.ResponseCode_OK:
+		return resp.Status.String()
+	}
+	return ""
+}
@@ -0,0 +1,198 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecerttest
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/continusec/geecert"
+	"github.com/continusec/geecert/server"
+	pb "github.com/continusec/geecert/sso"
+)
+
+const (
+	DefaultTestClientID     = "geecerttest.apps.googleusercontent.com"
+	DefaultTestHostedDomain = "example.com"
+)
+
+// A complete server, listening on a random port on localhost, with a throwaway CA and TLS
+// certificate, which accepts ID tokens from its FakeIdP. For end-to-end tests of clients.
+type TestServer struct {
+	Addr           string // host:port to connect to
+	CertificatePEM string // the server's self-signed TLS certificate
+	Config         *pb.ServerConfig
+	IdP            *FakeIdP
+	CA             *CA
+	Server         *server.SSOServer // set Server.IDTokenValidator to plug in another validator
+
+	dir        string
+	grpcServer *grpc.Server
+}
+
+// Starts a server with conf, which needn't set the CA key or TLS settings. If conf doesn't set
+// the client ID and domain for ID tokens, DefaultTestClientID and DefaultTestHostedDomain are
+// used. Call Close when done.
+func NewTestServer(conf *pb.ServerConfig) (*TestServer, error) {
+	if conf.AllowedClientIdForIdToken == "" {
+		conf.AllowedClientIdForIdToken = DefaultTestClientID
+	}
+	if conf.AllowedDomainForIdToken == "" {
+		conf.AllowedDomainForIdToken = DefaultTestHostedDomain
+	}
+	if conf.ClientConfigScope == "" {
+		conf.ClientConfigScope = "*." + conf.AllowedDomainForIdToken
+	}
+	if conf.GenerateCertDurationSeconds == 0 {
+		conf.GenerateCertDurationSeconds = 3600
+	}
+
+	dir, err := os.MkdirTemp("", "geecerttest")
+	if err != nil {
+		return nil, err
+	}
+	ts := &TestServer{Config: conf, dir: dir}
+	err = ts.start()
+	if err != nil {
+		ts.Close()
+		return nil, err
+	}
+	return ts, nil
+}
+
+func (ts *TestServer) start() error {
+	var err error
+	ts.CA, err = NewCA(ts.dir)
+	if err != nil {
+		return err
+	}
+	ts.Config.CaKeyPath = ts.CA.Path
+
+	ts.IdP, err = NewFakeIdP(ts.Config.AllowedClientIdForIdToken, ts.Config.AllowedDomainForIdToken)
+	if err != nil {
+		return err
+	}
+
+	err = server.ValidateConfig(ts.Config)
+	if err != nil {
+		return err
+	}
+	store, err := server.OpenStore(context.Background(), ts.Config)
+	if err != nil {
+		return err
+	}
+	ts.Server = &server.SSOServer{
+		Config:           ts.Config,
+		Store:            store,
+		IDTokenValidator: ts.IdP,
+	}
+	if ts.Config.AcceptTelemetry {
+		ts.Server.Telemetry = server.NewTelemetryCounts()
+	}
+
+	cert, certPEM, err := localhostCertificate()
+	if err != nil {
+		return err
+	}
+	ts.CertificatePEM = certPEM
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	ts.Addr = lis.Addr().String()
+
+	ts.grpcServer = grpc.NewServer(append([]grpc.ServerOption{grpc.Creds(credentials.NewServerTLSFromCert(cert))}, ts.Server.ServerOptions()...)...)
+	ts.Server.Register(ts.grpcServer)
+	go ts.grpcServer.Serve(lis)
+	return nil
+}
+
+// Stops the server and removes its files.
+func (ts *TestServer) Close() {
+	if ts.grpcServer != nil {
+		ts.grpcServer.Stop()
+	}
+	if ts.Server != nil {
+		ts.Server.Store.Close()
+	}
+	os.RemoveAll(ts.dir)
+}
+
+// Returns a client config for connecting to this server, with files written under home. The
+// key is added to any running ssh-agent, so unset SSH_AUTH_SOCK if that's not wanted.
+func (ts *TestServer) ClientConfig(home string) *geecert.ClientAppConfiguration {
+	return &geecert.ClientAppConfiguration{
+		ClientID:           ts.Config.AllowedClientIdForIdToken,
+		HostedDomain:       ts.Config.AllowedDomainForIdToken,
+		GRPCServer:         ts.Addr,
+		GRPCPEMCertificate: ts.CertificatePEM,
+		SectionIdentifier:  "geecerttest",
+		ShortlivedKeyName:  "id_geecerttest",
+		KeyPath:            filepath.Join(home, "id_geecerttest"),
+		KnownHostsPath:     filepath.Join(home, "known_hosts"),
+		SSHConfigPath:      filepath.Join(home, "config"),
+		CredentialFileName: ".geecerttesttoken",
+		IDTokenValidator:   ts.IdP,
+	}
+}
+
+// Returns an ID token for email, for use in requests to this server.
+func (ts *TestServer) IDToken(email string) (string, error) {
+	return ts.IdP.IDToken(email)
+}
+
+// A self-signed TLS certificate for 127.0.0.1 and localhost.
+func localhostCertificate() (*tls.Certificate, string, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, "", err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, "", err
+	}
+	return &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})), nil
+}
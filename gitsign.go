@@ -0,0 +1,269 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// git-sign setup [-global]
+// git-sign allowed-signers
+// git-sign -Y sign -n namespace -f key [file...]
+// With setup, configures git (in this repository, or for the user with -global) to sign commits
+// and tags with the installed key and certificate, by running us as gpg.ssh.program, as if we were
+// ssh-keygen. We sign with -Y sign, and run ssh-keygen for other operations such as -Y verify.
+func gitSignCommand(ctx context.Context, config *ClientAppConfiguration, args []string) error {
+	if len(args) == 0 {
+		return ErrUsage
+	}
+	switch args[0] {
+	case "setup":
+		global := false
+		switch {
+		case len(args) == 1:
+		case len(args) == 2 && args[1] == "-global":
+			global = true
+		default:
+			return ErrUsage
+		}
+		return SetupGitSigning(ctx, config, config.Flags, global)
+	case "allowed-signers":
+		if len(args) != 1 {
+			return ErrUsage
+		}
+		lines, err := allowedSigners(config)
+		if err != nil {
+			return err
+		}
+		fmt.Println(strings.Join(lines, "\n"))
+		return nil
+	case "-Y":
+		if len(args) > 1 && args[1] == "sign" {
+			return gitSign(ctx, config, args[2:])
+		}
+		cmd := exec.CommandContext(ctx, "ssh-keygen", args...)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		return cmd.Run()
+	default:
+		return ErrUsage
+	}
+}
+
+// Signs each file to file.sig, or stdin to stdout, as ssh-keygen -Y sign does. The key given
+// with -f is ignored, as we always sign with the installed key and certificate.
+func gitSign(ctx context.Context, config *ClientAppConfiguration, args []string) error {
+	var namespace string
+	var files []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-n", "-f", "-O":
+			if i+1 == len(args) {
+				return ErrUsage
+			}
+			i++
+			if args[i-1] == "-n" {
+				namespace = args[i]
+			}
+		case "-U":
+		default:
+			files = append(files, args[i])
+		}
+	}
+	if len(namespace) == 0 {
+		return ErrUsage
+	}
+
+	err := EnsureFreshCert(ctx, config)
+	if err != nil {
+		return err
+	}
+	signer, cert, err := loadSigningKey(config)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		sig, err := SignSSHSIG(signer, cert, namespace, os.Stdin, "")
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(sig)
+		return err
+	}
+	for _, file := range files {
+		err = signFileSSHSIG(ctx, signer, cert, namespace, file)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Signs file to file.sig, as ssh-keygen -Y sign does.
+func signFileSSHSIG(ctx context.Context, signer ssh.Signer, cert *ssh.Certificate, namespace string, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	sig, err := SignSSHSIG(signer, cert, namespace, f, "")
+	if err != nil {
+		return err
+	}
+	return SafeSave(ctx, file+".sig", sig, 0644)
+}
+
+var ErrWindowsUnquotable = errors.New("A path or flag containing \" or % can't be passed to the client on Windows.")
+
+var ErrNoAllowedSigners = errors.New("The server hasn't sent which users may sign, so signatures can't be verified. Fetch a new certificate, or upgrade the server.")
+
+// Lines for git's allowed signers file, as last sent by the server, trusting certificates from
+// the CAs that sign user certificates for each user's username.
+func allowedSigners(config *ClientAppConfiguration) ([]string, error) {
+	path, err := allowedSignersPath(config)
+	if err != nil {
+		return nil, err
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNoAllowedSigners
+		}
+		return nil, err
+	}
+	lines, _ := splitLines(contents)
+	for len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	return lines, nil
+}
+
+// Where the allowed signers file is kept, next to the key.
+func allowedSignersPath(config *ClientAppConfiguration) (string, error) {
+	paths, err := DefaultInstallPaths(config)
+	if err != nil {
+		return "", err
+	}
+	return allowedSignersFile(paths), nil
+}
+
+func allowedSignersFile(paths *InstallPaths) string {
+	return paths.Key + "-allowed_signers"
+}
+
+// Writes the allowed signers sent by the server. If there are none, e.g. from an older server,
+// any file is removed, as older clients wrote one that also trusted the host CAs.
+func saveAllowedSigners(ctx context.Context, paths *InstallPaths, lines []string) error {
+	path := allowedSignersFile(paths)
+	if len(lines) == 0 {
+		err := os.Remove(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	_, err := saveIfChanged(ctx, path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+	return err
+}
+
+// Configures git to sign with us, run as the client binary with flags, and to verify signatures
+// against the allowed signers sent by the server. If global, the user's git config is changed, else that of the current repository.
+func SetupGitSigning(ctx context.Context, config *ClientAppConfiguration, flags []string, global bool) error {
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	paths, err := DefaultInstallPaths(config)
+	if err != nil {
+		return err
+	}
+	_, err = allowedSigners(config)
+	if err != nil {
+		logWarning("Commits can be signed, but not yet verified: %s", err)
+	}
+	signersPath := allowedSignersFile(paths)
+
+	// git runs gpg.ssh.program without a shell, so give it a script that adds our flags
+	program := paths.Key + "-git-sign"
+	var script string
+	if runtime.GOOS == "windows" {
+		program += ".cmd"
+		quoted, err := windowsQuote(append(append([]string{self}, flags...), "git-sign"))
+		if err != nil {
+			return err
+		}
+		script = "@" + quoted + " %*\r\n"
+	} else {
+		script = "#!/bin/sh\nexec " + shellQuote(append(append([]string{self}, flags...), "git-sign")) + " \"$@\"\n"
+	}
+	_, err = saveIfChanged(ctx, program, []byte(script), 0755)
+	if err != nil {
+		return err
+	}
+
+	settings := [][2]string{
+		{"gpg.format", "ssh"},
+		{"gpg.ssh.program", program},
+		{"gpg.ssh.allowedSignersFile", signersPath},
+		{"user.signingkey", paths.Cert},
+		{"commit.gpgsign", "true"},
+		{"tag.gpgsign", "true"},
+	}
+	for _, kv := range settings {
+		args := []string{"config"}
+		if global {
+			args = append(args, "--global")
+		}
+		out, err := exec.CommandContext(ctx, "git", append(args, kv[0], kv[1])...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("git config %s: %w: %s", kv[0], err, strings.TrimSpace(string(out)))
+		}
+	}
+	logInfo("Configured git to sign commits and tags with %s.", paths.Cert)
+	return nil
+}
+
+// Quotes args for sh.
+func shellQuote(args []string) string {
+	var quoted []string
+	for _, a := range args {
+		quoted = append(quoted, "'"+strings.ReplaceAll(a, "'", `'\''`)+"'")
+	}
+	return strings.Join(quoted, " ")
+}
+
+// Quotes args for cmd.exe, which has no way to escape a double quote within quotes, and
+// expands %VAR% even within them, so those can't be passed.
+func windowsQuote(args []string) (string, error) {
+	var quoted []string
+	for _, a := range args {
+		if strings.ContainsAny(a, `"%`) {
+			return "", fmt.Errorf("%w: %s", ErrWindowsUnquotable, a)
+		}
+		quoted = append(quoted, `"`+a+`"`)
+	}
+	return strings.Join(quoted, " "), nil
+}
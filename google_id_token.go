@@ -19,12 +19,13 @@ limitations under the License.
 package geecert
 
 import (
+	"context"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/json"
 	"encoding/pem"
 	"errors"
-	"io/ioutil"
+	"io"
 	"net/http"
 	"sync"
 	"time"
@@ -44,7 +45,15 @@ var (
 	ErrCertificateNotValid      = errors.New("ErrCertificateNotValid")
 )
 
-func GoogleKeyFunc(t *jwt.Token) (interface{}, error) {
+// Returns a jwt.Keyfunc that checks tokens are signed by Google, fetching Google's
+// certificates with ctx if needed.
+func GoogleKeyFunc(ctx context.Context) jwt.Keyfunc {
+	return func(t *jwt.Token) (interface{}, error) {
+		return googleKey(ctx, t)
+	}
+}
+
+func googleKey(ctx context.Context, t *jwt.Token) (interface{}, error) {
 	// Ensure that RS256 is used. This might seem overkill to care,
 	// but since the JWT spec actually allows a None algorithm which
 	// we definitely don't want, so instead we whitelist what we will allow.
@@ -64,7 +73,7 @@ func GoogleKeyFunc(t *jwt.Token) (interface{}, error) {
 	}
 
 	// Get Cert
-	cert, err := GoogleCache.Get(kidS)
+	cert, err := GoogleCache.Get(ctx, kidS)
 	if err != nil {
 		return nil, err
 	}
@@ -109,7 +118,7 @@ var GoogleCache = &CertificateCache{
 
 // Looks for the certificate with given ID. If not found, and not recently
 // updated, then update the cache
-func (cc *CertificateCache) Get(kid string) (*x509.Certificate, error) {
+func (cc *CertificateCache) Get(ctx context.Context, kid string) (*x509.Certificate, error) {
 	cc.readLock.Lock()
 	rv, ok := cc.certs[kid]
 	cc.readLock.Unlock()
@@ -117,7 +126,7 @@ func (cc *CertificateCache) Get(kid string) (*x509.Certificate, error) {
 		return rv, nil
 	}
 
-	err := cc.Update()
+	err := cc.Update(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -133,7 +142,7 @@ func (cc *CertificateCache) Get(kid string) (*x509.Certificate, error) {
 }
 
 // Updates the cache if past interval.
-func (cc *CertificateCache) Update() error {
+func (cc *CertificateCache) Update(ctx context.Context) error {
 	cc.updateLock.Lock()
 	defer cc.updateLock.Unlock()
 
@@ -142,14 +151,14 @@ func (cc *CertificateCache) Update() error {
 		return nil
 	}
 
-	resp, err := http.Get(cc.URL)
+	resp, err := httpGet(ctx, cc.URL)
 	if err != nil {
 		return err
 	}
 
 	// Always read body, even if not 200 as it can contain info about the err
 	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
+	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,105 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Runs a hook command with the shell, with extra environment variables, returning its output.
+func runHook(ctx context.Context, hook string, env []string) ([]byte, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", hook)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", hook)
+	}
+	cmd.Env = append(os.Environ(), env...)
+	return cmd.CombinedOutput()
+}
+
+// Runs each of config.PreIssueHooks before a certificate is requested. If any exits
+// unsuccessfully, returns a *PolicyError with its output, and no certificate is requested.
+func runPreIssueHooks(ctx context.Context, config *ClientAppConfiguration, keyPath string) error {
+	env := []string{
+		"GEECERT_SERVER=" + config.GRPCServer,
+		"GEECERT_KEY=" + keyPath,
+		"GEECERT_REASON=" + config.Reason,
+		"GEECERT_ON_BEHALF_OF=" + config.OnBehalfOf,
+		"GEECERT_ROLE=" + config.Role,
+		"GEECERT_TICKET=" + config.Ticket,
+	}
+	for _, hook := range config.PreIssueHooks {
+		logVerbose("Running pre-issue hook: %s", hook)
+		out, err := runHook(ctx, hook, env)
+		if err != nil {
+			msg := strings.TrimSpace(string(out))
+			if len(msg) == 0 {
+				msg = err.Error()
+			}
+			return &PolicyError{fmt.Sprintf("Pre-issue hook %q refused to allow a certificate to be requested: %s", hook, msg)}
+		}
+	}
+	return nil
+}
+
+// Runs each of config.PostInstallHooks after a certificate is installed, with details of the
+// certificate in GEECERT_* environment variables. Failures are logged, as the certificate
+// is already installed.
+func runPostInstallHooks(ctx context.Context, config *ClientAppConfiguration, paths *InstallPaths, certificate string) {
+	if len(config.PostInstallHooks) == 0 {
+		return
+	}
+	env := []string{
+		"GEECERT_SERVER=" + config.GRPCServer,
+		"GEECERT_KEY=" + paths.Key,
+		"GEECERT_CERT=" + paths.Cert,
+	}
+	pk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(certificate))
+	if err == nil {
+		if cert, ok := pk.(*ssh.Certificate); ok {
+			env = append(env,
+				"GEECERT_KEY_ID="+cert.KeyId,
+				"GEECERT_SERIAL="+strconv.FormatUint(cert.Serial, 10),
+				"GEECERT_PRINCIPALS="+strings.Join(cert.ValidPrincipals, ","),
+				"GEECERT_VALID_AFTER="+time.Unix(int64(cert.ValidAfter), 0).UTC().Format(time.RFC3339),
+				"GEECERT_VALID_BEFORE="+time.Unix(int64(cert.ValidBefore), 0).UTC().Format(time.RFC3339),
+				"GEECERT_FINGERPRINT="+ssh.FingerprintSHA256(cert.Key),
+			)
+		}
+	}
+	for _, hook := range config.PostInstallHooks {
+		logVerbose("Running post-install hook: %s", hook)
+		out, err := runHook(ctx, hook, env)
+		if err != nil {
+			logWarning("Post-install hook %q failed: %s %s", hook, err, strings.TrimSpace(string(out)))
+		} else if len(out) > 0 {
+			logVerbose("%s", strings.TrimSpace(string(out)))
+		}
+	}
+}
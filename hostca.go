@@ -0,0 +1,120 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+
+	pb "github.com/continusec/geecert/sso"
+)
+
+var (
+	ErrBadCAHostPattern      = errors.New("CA host patterns must be non-empty and contain no whitespace, commas or quotes, and at least one must not be negated.")
+	ErrCAHostPatternTooBroad = errors.New("CA host pattern would trust the CA for every host, list the domains it certifies instead, e.g. *.yourdomain.com.")
+	ErrBadCAPublicKey        = errors.New("CA public key must be in authorized_keys format.")
+	ErrBadCAComment          = errors.New("CA comment must be on a single line.")
+)
+
+// Splits client_config_scope, an ssh config Host line, into host patterns.
+func HostPatterns(scope string) []string {
+	return strings.FieldsFunc(scope, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+}
+
+// Checks patterns for a @cert-authority line, so that a mistake can't leave the CA trusted for
+// every host, or known_hosts unreadable. Used by both the server (on startup) and the client.
+func ValidateCAHostPatterns(patterns []string) error {
+	positive := false
+	for _, p := range patterns {
+		if len(p) == 0 || strings.ContainsAny(p, " \t\r\n\",") {
+			return ErrBadCAHostPattern
+		}
+		if strings.HasPrefix(p, "!") {
+			continue
+		}
+		positive = true
+
+		// [host]:port matches host on that port
+		host := p
+		if strings.HasPrefix(host, "[") {
+			if i := strings.Index(host, "]"); i > 0 {
+				host = host[1:i]
+			}
+		}
+		if len(strings.Trim(host, "*?.")) == 0 {
+			return ErrCAHostPatternTooBroad
+		}
+	}
+	if !positive {
+		return ErrBadCAHostPattern
+	}
+	return nil
+}
+
+func ValidateHostCertificateAuthority(ca *pb.HostCertificateAuthority) error {
+	err := ValidateCAHostPatterns(ca.HostPattern)
+	if err != nil {
+		return err
+	}
+	_, _, options, _, err := ssh.ParseAuthorizedKey([]byte(ca.PublicKey))
+	if err != nil || len(options) > 0 {
+		return ErrBadCAPublicKey
+	}
+	if strings.ContainsAny(ca.Comment, "\r\n") {
+		return ErrBadCAComment
+	}
+	return nil
+}
+
+// Renders a valid CA as a known_hosts line.
+func RenderHostCertificateAuthority(ca *pb.HostCertificateAuthority) string {
+	pk, _, _, _, _ := ssh.ParseAuthorizedKey([]byte(ca.PublicKey))
+	line := "@cert-authority " + strings.Join(ca.HostPattern, ",") + " " + pk.Type() + " " + base64.StdEncoding.EncodeToString(pk.Marshal())
+	if len(ca.Comment) > 0 {
+		line += " " + ca.Comment
+	}
+	return line
+}
+
+// Renders CAs into known_hosts lines, skipping (with a warning) any that are invalid.
+func RenderHostCertificateAuthorities(cas []*pb.HostCertificateAuthority) []string {
+	var rv []string
+	for _, ca := range cas {
+		err := ValidateHostCertificateAuthority(ca)
+		if err != nil {
+			logWarning("Skipping invalid certificate authority (%s): %s", ca.String(), err)
+			continue
+		}
+		rv = append(rv, RenderHostCertificateAuthority(ca))
+	}
+	return rv
+}
+
+// Known_hosts lines for a response, preferring the structured CAs if the server sent them.
+func knownHostsLines(cas []*pb.HostCertificateAuthority, lines []string) []string {
+	if len(cas) > 0 {
+		return RenderHostCertificateAuthorities(cas)
+	}
+	return lines
+}
@@ -0,0 +1,284 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Where hosts keep the CA public keys, for sshd's TrustedUserCAKeys.
+const DefaultTrustedUserCAKeysPath = "/etc/ssh/geecert_ca.pub"
+
+// Name of the section of sshd_config managed by SetupHost.
+const hostSetupSection = "geecert-hostsetup"
+
+var (
+	ErrNoCAKeys         = errors.New("No CA public keys found.")
+	ErrNoCAKeysSource   = errors.New("Either the bootstrap URL or a CA keys file must be set.")
+	ErrBadPrincipalsMap = errors.New("Each line of the principals map must be a username followed by one or more principals.")
+)
+
+// Options for SetupHost, as set by the flags of geecert-hostsetup.
+type HostSetupOptions struct {
+	BootstrapURL            string // if set, the CA keys are fetched from BootstrapCAKeysPath under this
+	CAKeysPath              string // else they are read from this file
+	TrustedUserCAKeysPath   string // where the CA keys are written for TrustedUserCAKeys
+	SSHDConfigPath          string // e.g. /etc/ssh/sshd_config
+	PrincipalsMapPath       string // if set, lines of a username followed by the principals they may log in as
+	AuthorizedPrincipalsDir string // where a file per user in the principals map is written, other files are removed
+	SSHDPath                string // run with -t to check sshd_config before it is replaced
+	ReloadCommand           string // if set, run with the shell after anything changes, e.g. systemctl reload sshd
+}
+
+// Installs the CA public keys for sshd to trust certificates signed by them, writes an
+// AuthorizedPrincipalsFile for each user in the principals map, and points sshd_config at them
+// in a section at the top of the file, after checking the result with sshd -t. Run periodically,
+// it picks up CA rotations and changes to the map. Returns true if anything changed.
+func SetupHost(ctx context.Context, opts *HostSetupOptions) (bool, error) {
+	keys, err := hostCAKeys(ctx, opts)
+	if err != nil {
+		return false, err
+	}
+	changed, err := saveIfChanged(ctx, opts.TrustedUserCAKeysPath, []byte(strings.Join(keys, "\n")+"\n"), 0644)
+	if err != nil {
+		return false, err
+	}
+
+	lines := []string{"TrustedUserCAKeys " + opts.TrustedUserCAKeysPath}
+	if len(opts.PrincipalsMapPath) > 0 {
+		principals, err := loadPrincipalsMap(opts.PrincipalsMapPath)
+		if err != nil {
+			return changed, err
+		}
+		c, err := writeAuthorizedPrincipals(ctx, opts.AuthorizedPrincipalsDir, principals)
+		if err != nil {
+			return changed, err
+		}
+		changed = changed || c
+		lines = append(lines, "AuthorizedPrincipalsFile "+filepath.Join(opts.AuthorizedPrincipalsDir, "%u"))
+	}
+
+	c, err := updateSSHDConfig(ctx, opts, lines)
+	if err != nil {
+		return changed, err
+	}
+	changed = changed || c
+
+	if changed && len(opts.ReloadCommand) > 0 {
+		out, err := runHook(ctx, opts.ReloadCommand, nil)
+		if err != nil {
+			return changed, fmt.Errorf("%s: %w: %s", opts.ReloadCommand, err, strings.TrimSpace(string(out)))
+		}
+	}
+	return changed, nil
+}
+
+// Returns the CA public keys, in authorized_keys format, from the bootstrap URL or file.
+func hostCAKeys(ctx context.Context, opts *HostSetupOptions) ([]string, error) {
+	var data []byte
+	switch {
+	case len(opts.BootstrapURL) > 0:
+		if !strings.HasPrefix(opts.BootstrapURL, "https://") {
+			return nil, ErrBootstrapNotHTTPS
+		}
+		resp, err := httpGet(ctx, strings.TrimSuffix(opts.BootstrapURL, "/")+BootstrapCAKeysPath)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, errors.New(fmt.Sprintf("Unexpected status fetching CA keys: %s", resp.Status))
+		}
+		data, err = io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		if err != nil {
+			return nil, err
+		}
+	case len(opts.CAKeysPath) > 0:
+		var err error
+		data, err = os.ReadFile(opts.CAKeysPath)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrNoCAKeysSource
+	}
+
+	var rv []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		_, _, _, _, err := ssh.ParseAuthorizedKey([]byte(line))
+		if err != nil {
+			return nil, fmt.Errorf("CA key %q: %w", line, err)
+		}
+		rv = append(rv, line)
+	}
+	if len(rv) == 0 {
+		return nil, ErrNoCAKeys
+	}
+	return rv, nil
+}
+
+// Parses lines of a username followed by principals, ignoring blank lines and # comments.
+func loadPrincipalsMap(path string) (map[string][]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	rv := make(map[string][]string)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.ContainsAny(fields[0], `/\`) || fields[0] == "." || fields[0] == ".." {
+			return nil, fmt.Errorf("%s:%d: %w", path, n, ErrBadPrincipalsMap)
+		}
+		rv[fields[0]] = append(rv[fields[0]], fields[1:]...)
+	}
+	return rv, scanner.Err()
+}
+
+// Writes a file of principals for each user to dir, and removes the files of users no longer listed.
+func writeAuthorizedPrincipals(ctx context.Context, dir string, principals map[string][]string) (bool, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return false, err
+	}
+
+	var users []string
+	for user := range principals {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+
+	changed := false
+	for _, user := range users {
+		c, err := saveIfChanged(ctx, filepath.Join(dir, user), []byte(strings.Join(principals[user], "\n")+"\n"), 0644)
+		if err != nil {
+			return changed, err
+		}
+		changed = changed || c
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return changed, err
+	}
+	for _, e := range entries {
+		if _, ok := principals[e.Name()]; ok || !e.Type().IsRegular() {
+			continue
+		}
+		err = os.Remove(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return changed, err
+		}
+		logInfo("Removed %s.", filepath.Join(dir, e.Name()))
+		changed = true
+	}
+	return changed, nil
+}
+
+// Puts lines in our section at the top of sshd_config, if they are not there already, after
+// checking the new config with sshd -t.
+func updateSSHDConfig(ctx context.Context, opts *HostSetupOptions, lines []string) (bool, error) {
+	contents, err := os.ReadFile(opts.SSHDConfigPath)
+	if err != nil {
+		return false, err
+	}
+	newContents := prependSection(hostSetupSection, contents, lines)
+	if bytes.Equal(contents, newContents) {
+		return false, nil
+	}
+
+	// Check the new config in the same directory, so that relative Include paths resolve the same
+	tmp, err := os.CreateTemp(filepath.Dir(opts.SSHDConfigPath), ".sshd_config-")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(newContents)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return false, err
+	}
+	out, err := exec.CommandContext(ctx, opts.SSHDPath, "-t", "-f", tmp.Name()).CombinedOutput()
+	if err != nil {
+		return false, fmt.Errorf("%s -t rejected the new sshd_config: %w: %s", opts.SSHDPath, err, strings.TrimSpace(string(out)))
+	}
+
+	err = SafeSave(ctx, opts.SSHDConfigPath, newContents, 0644)
+	if err != nil {
+		return false, err
+	}
+	logInfo("Updated %s.", opts.SSHDConfigPath)
+	return true, nil
+}
+
+// Returns contents with the section name holding lines at the top, as sshd uses the first value
+// it finds for most keywords, and lines after a Match line only apply to matching connections.
+func prependSection(name string, contents []byte, lines []string) []byte {
+	rest, _, _ := replaceSection(name, contents, nil)
+	restLines, format := splitLines(rest)
+	section, _, _ := replaceSection(name, nil, lines)
+	sectionLines, _ := splitLines(section)
+	return format.join(append(trimLeadingEmpty(sectionLines), trimLeadingEmpty(restLines)...))
+}
+
+func trimLeadingEmpty(lines []string) []string {
+	for len(lines) > 0 && len(lines[0]) == 0 {
+		lines = lines[1:]
+	}
+	return lines
+}
+
+// Saves contents to path with SafeSave, unless it already holds them. Returns true if saved.
+func saveIfChanged(ctx context.Context, path string, contents []byte, perm os.FileMode) (bool, error) {
+	existing, err := os.ReadFile(path)
+	if err == nil && bytes.Equal(existing, contents) {
+		return false, nil
+	}
+	err = SafeSave(ctx, path, contents, perm)
+	if err != nil {
+		return false, err
+	}
+	logInfo("Updated %s.", path)
+	return true, nil
+}
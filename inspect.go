@@ -0,0 +1,101 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// inspect [path to certificate]
+// Shows the installed certificate (or that given), including any extensions added by the server.
+func inspectCommand(ctx context.Context, config *ClientAppConfiguration, args []string) error {
+	var cert *ssh.Certificate
+	var err error
+	switch len(args) {
+	case 0:
+		cert, err = LoadInstalledCert(config)
+	case 1:
+		cert, err = loadCertFile(args[0])
+	default:
+		return ErrUsage
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Type:             %s\n", cert.Type())
+	fmt.Printf("Serial:           %d\n", cert.Serial)
+	fmt.Printf("Key ID:           %s\n", cert.KeyId)
+	fmt.Printf("Key:              %s\n", ssh.FingerprintSHA256(cert.Key))
+	fmt.Printf("Signing CA:       %s\n", ssh.FingerprintSHA256(cert.SignatureKey))
+	if cert.Signature != nil {
+		fmt.Printf("Signature:        %s\n", cert.Signature.Format)
+	}
+	fmt.Printf("Principals:       %s\n", strings.Join(cert.ValidPrincipals, ", "))
+	fmt.Printf("Valid:            %s to %s\n", time.Unix(int64(cert.ValidAfter), 0).Format(time.RFC3339), time.Unix(int64(cert.ValidBefore), 0).Format(time.RFC3339))
+	printCertOptions("Critical options:", cert.CriticalOptions)
+	printCertOptions("Extensions:", cert.Extensions)
+
+	return nil
+}
+
+func printCertOptions(title string, opts map[string]string) {
+	fmt.Println(title)
+	if len(opts) == 0 {
+		fmt.Println("    (none)")
+		return
+	}
+	var names []string
+	for k := range opts {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		if len(opts[k]) == 0 {
+			fmt.Printf("    %s\n", k)
+		} else {
+			fmt.Printf("    %s: %s\n", k, opts[k])
+		}
+	}
+}
+
+func loadCertFile(path string) (*ssh.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pk, _, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err != nil {
+		return nil, err
+	}
+	cert, ok := pk.(*ssh.Certificate)
+	if !ok {
+		return nil, ErrWrongCertType
+	}
+
+	return cert, nil
+}
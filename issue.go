@@ -0,0 +1,157 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Describes an installed certificate and where its files are, for programs that run the
+// client, e.g. as output by the issue command. Field names are stable.
+type IssueResult struct {
+	KeyPath        string    `json:"key_path"`
+	PublicKeyPath  string    `json:"public_key_path"`
+	CertPath       string    `json:"cert_path"`
+	KnownHostsPath string    `json:"known_hosts_path"`
+	SSHConfigPath  string    `json:"ssh_config_path"`
+	Serial         uint64    `json:"serial"`
+	KeyID          string    `json:"key_id"`
+	Principals     []string  `json:"principals"`
+	ValidAfter     time.Time `json:"valid_after"`
+	ValidBefore    time.Time `json:"valid_before"`
+	Fingerprint    string    `json:"fingerprint"` // of the key, e.g. SHA256:...
+}
+
+// Fetches a certificate as per ProcessClient and describes it. If dir is set, the key,
+// certificate, known_hosts and ssh config are written there instead, as dir/ShortlivedKeyName,
+// dir/ShortlivedKeyName-cert.pub, dir/known_hosts and dir/config, for use with ssh -F.
+func IssueCert(ctx context.Context, config *ClientAppConfiguration, dir string) (*IssueResult, error) {
+	if len(dir) > 0 {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		c := *config
+		c.KeyPath = filepath.Join(abs, config.ShortlivedKeyName)
+		c.CertPath = ""
+		c.KnownHostsPath = filepath.Join(abs, "known_hosts")
+		c.SSHConfigPath = filepath.Join(abs, "config")
+		c.AutoRenew = false
+		c.InstallVSCode = false
+		config = &c
+	}
+
+	err := ProcessClient(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := DefaultInstallPaths(config)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := loadCertFile(paths.Cert)
+	if err != nil {
+		return nil, err
+	}
+	return &IssueResult{
+		KeyPath:        paths.Key,
+		PublicKeyPath:  paths.Key + ".pub",
+		CertPath:       paths.Cert,
+		KnownHostsPath: paths.KnownHosts,
+		SSHConfigPath:  paths.SSHConfig,
+		Serial:         cert.Serial,
+		KeyID:          cert.KeyId,
+		Principals:     cert.ValidPrincipals,
+		ValidAfter:     time.Unix(int64(cert.ValidAfter), 0).UTC(),
+		ValidBefore:    time.Unix(int64(cert.ValidBefore), 0).UTC(),
+		Fingerprint:    ssh.FingerprintSHA256(cert.Key),
+	}, nil
+}
+
+// issue [-dir DIR]
+// Fetches a certificate and writes an IssueResult as JSON to stdout. Messages go to stderr
+// as usual, so that stdout can be parsed.
+func issueCommand(ctx context.Context, config *ClientAppConfiguration, args []string) error {
+	var dir string
+	switch {
+	case len(args) == 0:
+	case len(args) == 2 && (args[0] == "-dir" || args[0] == "--dir"):
+		dir = args[1]
+	default:
+		return ErrUsage
+	}
+
+	result, err := IssueCert(ctx, config, dir)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
+
+// terraform
+// Run by Terraform's external data source, which sends a JSON object of strings on stdin
+// (here optionally "dir", as per the issue command) and expects one on stdout.
+func terraformCommand(ctx context.Context, config *ClientAppConfiguration, args []string) error {
+	if len(args) != 0 {
+		return ErrUsage
+	}
+
+	var query map[string]string
+	b, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return err
+	}
+	if len(strings.TrimSpace(string(b))) > 0 {
+		err = json.Unmarshal(b, &query)
+		if err != nil {
+			return fmt.Errorf("Parsing query from Terraform: %w", err)
+		}
+	}
+
+	result, err := IssueCert(ctx, config, query["dir"])
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(os.Stdout).Encode(map[string]string{
+		"key_path":         result.KeyPath,
+		"public_key_path":  result.PublicKeyPath,
+		"cert_path":        result.CertPath,
+		"known_hosts_path": result.KnownHostsPath,
+		"ssh_config_path":  result.SSHConfigPath,
+		"serial":           strconv.FormatUint(result.Serial, 10),
+		"key_id":           result.KeyID,
+		"principals":       strings.Join(result.Principals, ","),
+		"valid_after":      result.ValidAfter.Format(time.RFC3339),
+		"valid_before":     result.ValidBefore.Format(time.RFC3339),
+		"fingerprint":      result.Fingerprint,
+	})
+}
@@ -19,36 +19,103 @@ limitations under the License.
 package geecert
 
 import (
+	"context"
+	"crypto/rsa"
 	"errors"
-	"log"
+	"fmt"
 	"time"
 
 	jwt "github.com/dgrijalva/jwt-go"
 )
 
 var (
-	ErrInvalidIDToken = errors.New("ErrInvalidIDToken")
+	ErrInvalidIDToken    = errors.New("ErrInvalidIDToken")
+	ErrWrongAudience     = errors.New("ID token is for another client ID.")
+	ErrWrongHostedDomain = ErrDomainMismatch // kept for compatibility
 )
 
 type IDTokenClaims struct {
 	EmailAddress string
 	FirstName    string
 	LastName     string
+	AuthTime     time.Time // When the user last interactively authenticated, zero if not in the token
+	Expiry       time.Time // When the token expires
+
+	Groups []string               // Groups the user is in, set by the server from its groups_claim
+	Claims map[string]interface{} // All claims in the token, e.g. for mapping others to the identity or groups
+}
+
+// Returns true if err is from ValidateIDToken because the token has expired.
+func IDTokenExpired(err error) bool {
+	return errors.Is(err, ErrTokenExpired)
+}
+
+// Checks that ID tokens are signed by a trusted key, and are for the given client ID and
+// hosted domain, as per ValidateIDToken.
+type IDTokenValidator interface {
+	ValidateIDToken(ctx context.Context, idToken, clientID, hostedDomain string) (*IDTokenClaims, error)
+}
+
+// Validates ID tokens signed by Google. Used unless configured otherwise.
+type GoogleIDTokenValidator struct{}
+
+func (GoogleIDTokenValidator) ValidateIDToken(ctx context.Context, idToken, clientID, hostedDomain string) (*IDTokenClaims, error) {
+	return ValidateIDTokenWithKeyFunc(idToken, clientID, hostedDomain, GoogleKeyFunc(ctx))
+}
+
+// Validates ID tokens signed by fixed keys, selected by the kid header of the token,
+// e.g. tokens signed locally in tests. Never use this with real users.
+type StaticKeyIDTokenValidator struct {
+	Keys map[string]*rsa.PublicKey
+}
+
+func (v *StaticKeyIDTokenValidator) ValidateIDToken(ctx context.Context, idToken, clientID, hostedDomain string) (*IDTokenClaims, error) {
+	return ValidateIDTokenWithKeyFunc(idToken, clientID, hostedDomain, func(t *jwt.Token) (interface{}, error) {
+		if t.Method.Alg() != "RS256" {
+			return nil, ErrUnexpectedAlgorithm
+		}
+		kid, _ := t.Header["kid"].(string)
+		key, ok := v.Keys[kid]
+		if !ok {
+			return nil, ErrMissingKeyID
+		}
+		return key, nil
+	})
+}
+
+func idTokenValidator(config *ClientAppConfiguration) IDTokenValidator {
+	if config.IDTokenValidator != nil {
+		return config.IDTokenValidator
+	}
+	return GoogleIDTokenValidator{}
 }
 
+// How long to wait before trying again with a token from the future.
+var clockRetryInterval = time.Second
+
 func errIsClock(err error) bool {
-	return err != nil && err.Error() == "Token used before issued"
+	var ve *jwt.ValidationError
+	return errors.As(err, &ve) && ve.Errors&jwt.ValidationErrorIssuedAt != 0
 }
 
-func ValidateTokenWithRetryForClock(idToken, clientID, hostedDomain string, retries int) (*IDTokenClaims, error) {
+func ValidateTokenWithRetryForClock(ctx context.Context, idToken, clientID, hostedDomain string, retries int) (*IDTokenClaims, error) {
+	return ValidateTokenWithRetryForClockUsing(ctx, GoogleIDTokenValidator{}, idToken, clientID, hostedDomain, retries)
+}
+
+// As per ValidateTokenWithRetryForClock, with tokens checked by v.
+func ValidateTokenWithRetryForClockUsing(ctx context.Context, v IDTokenValidator, idToken, clientID, hostedDomain string, retries int) (*IDTokenClaims, error) {
 	var rv *IDTokenClaims
 	var err error
 	for done, attempts := false, 0; !done; attempts++ {
-		rv, err = ValidateIDToken(idToken, clientID, hostedDomain)
+		rv, err = v.ValidateIDToken(ctx, idToken, clientID, hostedDomain)
 		if errIsClock(err) {
 			if attempts < retries {
-				log.Print("Token appears to have come from the future - retrying in 1 second.")
-				time.Sleep(time.Second)
+				logVerbose("Token appears to have come from the future - retrying in %s.", clockRetryInterval)
+				select {
+				case <-time.After(clockRetryInterval):
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
 			} else {
 				done = true
 			}
@@ -61,10 +128,24 @@ func ValidateTokenWithRetryForClock(idToken, clientID, hostedDomain string, retr
 
 // Validates a token, including that it matchines the client ID and hosted domain
 // Returns the email address and nil upon success
-func ValidateIDToken(idToken, clientID, hostedDomain string) (*IDTokenClaims, error) {
-	token, err := jwt.Parse(idToken, GoogleKeyFunc)
+func ValidateIDToken(ctx context.Context, idToken, clientID, hostedDomain string) (*IDTokenClaims, error) {
+	return GoogleIDTokenValidator{}.ValidateIDToken(ctx, idToken, clientID, hostedDomain)
+}
+
+// As per ValidateIDToken, but checking the signature with keys from keyFunc rather than
+// Google's, e.g. for tokens from a fake IdP in tests.
+func ValidateIDTokenWithKeyFunc(idToken, clientID, hostedDomain string, keyFunc jwt.Keyfunc) (*IDTokenClaims, error) {
+	return ValidateIDTokenFromIssuer(idToken, "accounts.google.com", clientID, hostedDomain, keyFunc)
+}
+
+// As per ValidateIDTokenWithKeyFunc, for tokens from issuer rather than Google, e.g. the SAML bridge.
+func ValidateIDTokenFromIssuer(idToken, issuer, clientID, hostedDomain string, keyFunc jwt.Keyfunc) (*IDTokenClaims, error) {
+	token, err := jwt.Parse(idToken, keyFunc)
 	if err != nil {
-		return nil, err
+		if ve, ok := err.(*jwt.ValidationError); ok && ve.Errors&jwt.ValidationErrorExpired != 0 {
+			return nil, fmt.Errorf("%w (%w)", ErrTokenExpired, err)
+		}
+		return nil, fmt.Errorf("%w (%w)", ErrInvalidIDToken, err)
 	}
 
 	if !token.Valid {
@@ -75,11 +156,11 @@ func ValidateIDToken(idToken, clientID, hostedDomain string) (*IDTokenClaims, er
 	if !ok {
 		return nil, ErrInvalidIDToken
 	}
-	if !mapClaims.VerifyIssuer("accounts.google.com", true) {
+	if !mapClaims.VerifyIssuer(issuer, true) {
 		return nil, ErrInvalidIDToken
 	}
 	if !mapClaims.VerifyAudience(clientID, true) {
-		return nil, ErrInvalidIDToken
+		return nil, fmt.Errorf("%w (%w)", ErrInvalidIDToken, ErrWrongAudience)
 	}
 
 	// Check hosted domain
@@ -92,7 +173,7 @@ func ValidateIDToken(idToken, clientID, hostedDomain string) (*IDTokenClaims, er
 		return nil, ErrInvalidIDToken
 	}
 	if hds != hostedDomain {
-		return nil, ErrInvalidIDToken
+		return nil, ErrWrongHostedDomain
 	}
 
 	// Check email verified
@@ -122,6 +203,7 @@ func ValidateIDToken(idToken, clientID, hostedDomain string) (*IDTokenClaims, er
 	// Start setting up return value
 	rv := &IDTokenClaims{
 		EmailAddress: emails,
+		Claims:       mapClaims,
 	}
 
 	// Try to get first name, it's OK if it fails
@@ -142,5 +224,54 @@ func ValidateIDToken(idToken, clientID, hostedDomain string) (*IDTokenClaims, er
 		}
 	}
 
+	// Already checked by jwt.Parse
+	exp, ok := mapClaims["exp"]
+	if ok {
+		expAsNumber, ok := exp.(float64)
+		if ok {
+			rv.Expiry = time.Unix(int64(expAsNumber), 0)
+		}
+	}
+
+	// Only present if requested, e.g. with max_age
+	authTime, ok := mapClaims["auth_time"]
+	if ok {
+		authTimeAsNumber, ok := authTime.(float64)
+		if ok {
+			rv.AuthTime = time.Unix(int64(authTimeAsNumber), 0)
+		}
+	}
+
 	return rv, nil
 }
+
+// Returns the claims from an ID token without checking its signature or expiry.
+// Only for use in hints and error messages.
+func unverifiedClaims(idToken string) jwt.MapClaims {
+	claims := jwt.MapClaims{}
+	_, _, err := new(jwt.Parser).ParseUnverified(idToken, claims)
+	if err != nil {
+		return nil
+	}
+	return claims
+}
+
+// Returns the email address in an ID token, without verifying it, or "" if none.
+func UnverifiedEmail(idToken string) string {
+	email, _ := unverifiedClaims(idToken)["email"].(string)
+	return email
+}
+
+// Returns an error naming the account if the ID token is for an account outside of hostedDomain.
+func checkAccountDomain(idToken, hostedDomain string) error {
+	claims := unverifiedClaims(idToken)
+	if claims == nil {
+		return nil
+	}
+	hd, _ := claims["hd"].(string)
+	if hd == hostedDomain {
+		return nil
+	}
+	email, _ := claims["email"].(string)
+	return &DomainMismatchError{Email: email, HostedDomain: hostedDomain}
+}
@@ -0,0 +1,154 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jcmturner/gokrb5/v8/client"
+	krb5config "github.com/jcmturner/gokrb5/v8/config"
+	krb5credentials "github.com/jcmturner/gokrb5/v8/credentials"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+
+	pb "github.com/continusec/geecert/sso"
+)
+
+// Metadata that a Kerberos ticket is sent in with each request, as per HTTP Negotiate.
+const (
+	NegotiateMetadata = "authorization"
+	NegotiatePrefix   = "Negotiate "
+)
+
+var (
+	ErrNoKerberosTicket = errors.New("No Kerberos ticket found. Run: kinit")
+	ErrKerberosReauth   = errors.New("The server requires a recent sign in. Run kinit, then try again.")
+)
+
+// Where MIT Kerberos keeps the user's tickets. Only file caches can be read.
+func kerberosCCachePath() string {
+	if p := os.Getenv("KRB5CCNAME"); len(p) > 0 {
+		return strings.TrimPrefix(p, "FILE:")
+	}
+	return "/tmp/krb5cc_" + strconv.Itoa(os.Getuid())
+}
+
+func kerberosConfigPath() string {
+	if p := os.Getenv("KRB5_CONFIG"); len(p) > 0 {
+		return p
+	}
+	return "/etc/krb5.conf"
+}
+
+func loadKerberosCCache() (*krb5credentials.CCache, error) {
+	path := kerberosCCachePath()
+	cc, err := krb5credentials.LoadCCache(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNoKerberosTicket
+		}
+		return nil, fmt.Errorf("Reading Kerberos tickets from %s: %w", path, err)
+	}
+	return cc, nil
+}
+
+// Returns a base64 SPNEGO token for KerberosSPN, made with the user's ticket from kinit.
+func kerberosToken(config *ClientAppConfiguration) (string, error) {
+	cc, err := loadKerberosCCache()
+	if err != nil {
+		return "", err
+	}
+	kc, err := krb5config.Load(kerberosConfigPath())
+	if err != nil {
+		return "", fmt.Errorf("Reading Kerberos config: %w", err)
+	}
+	cl, err := client.NewFromCCache(cc, kc, client.DisablePAFXFAST(true))
+	if err != nil {
+		return "", fmt.Errorf("%w (%w)", ErrNoKerberosTicket, err)
+	}
+	defer cl.Destroy()
+
+	ct, err := spnego.SPNEGOClient(cl, config.KerberosSPN).InitSecContext()
+	if err != nil {
+		return "", fmt.Errorf("Getting Kerberos ticket for %s: %w", config.KerberosSPN, err)
+	}
+	b, err := ct.Marshal()
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// The credential_type for requests, KERBEROS if KerberosSPN is set, else ID_TOKEN.
+func credentialType(config *ClientAppConfiguration) pb.CredentialType {
+	if len(config.KerberosSPN) > 0 {
+		return pb.CredentialType_KERBEROS
+	}
+	if len(config.KubernetesTokenPath) > 0 {
+		return pb.CredentialType_KUBERNETES
+	}
+	return pb.CredentialType_ID_TOKEN
+}
+
+// Sends a fresh Kerberos token with each request, as the server refuses tokens it has seen.
+type kerberosCredentials struct {
+	config *ClientAppConfiguration
+}
+
+func (k kerberosCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	tok, err := kerberosToken(k.config)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{NegotiateMetadata: NegotiatePrefix + tok}, nil
+}
+
+func (k kerberosCredentials) RequireTransportSecurity() bool {
+	return true
+}
+
+// As per GetValidIDToken, for KerberosSPN. There is no ID token, as the ticket is sent with
+// each request instead, so only the claims are returned, with the principal as the email address.
+func getKerberosIdentity(config *ClientAppConfiguration) (string, *IDTokenClaims, error) {
+	cc, err := loadKerberosCCache()
+	if err != nil {
+		return "", nil, err
+	}
+	principal := cc.GetClientPrincipalName().PrincipalNameString() + "@" + cc.GetClientRealm()
+	claims := &IDTokenClaims{EmailAddress: principal}
+	for _, c := range cc.GetEntries() {
+		if strings.HasPrefix(c.Server.PrincipalName.PrincipalNameString(), "krbtgt/") {
+			claims.AuthTime = c.AuthTime
+			claims.Expiry = c.EndTime
+		}
+	}
+	if claims.Expiry.IsZero() {
+		return "", nil, ErrNoKerberosTicket
+	}
+	if claims.Expiry.Before(time.Now()) {
+		return "", nil, fmt.Errorf("%w (ticket for %s has expired)", ErrNoKerberosTicket, principal)
+	}
+	return "", claims, nil
+}
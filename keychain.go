@@ -0,0 +1,72 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"runtime"
+	"strings"
+)
+
+// The ssh-agent started by launchd on macOS listens on a socket under here.
+const appleLaunchdSocketPrefix = "/private/tmp/com.apple.launchd."
+
+// Returns true if authSock is the macOS launchd started ssh-agent, which has
+// been known to reject keys added with lifetime constraints.
+func isAppleLaunchdAgent(authSock string) bool {
+	return runtime.GOOS == "darwin" && strings.HasPrefix(authSock, appleLaunchdSocketPrefix)
+}
+
+// Adds the Apple specific directives to each Host or Match block so that ssh
+// re-adds our key to the agent (and keychain) when it is used, for example after a reboot.
+// IgnoreUnknown is needed so that non-Apple builds of ssh don't reject the config file.
+func addKeychainDirectives(lines []string) []string {
+	var rv []string
+	for i, line := range lines {
+		rv = append(rv, line)
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "Host ") || strings.HasPrefix(trimmed, "Match ") {
+			rv = append(rv,
+				"    IgnoreUnknown UseKeychain",
+				"    UseKeychain yes",
+			)
+			if !blockSetsOption(lines[i+1:], "AddKeysToAgent") {
+				rv = append(rv, "    AddKeysToAgent yes")
+			}
+		}
+	}
+	return rv
+}
+
+// Reports whether the block starting at lines, up to the next Host or Match line, sets keyword,
+// such as an AddKeysToAgent set by the server's agent forwarding policy.
+func blockSetsOption(lines []string, keyword string) bool {
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "Host" || fields[0] == "Match" {
+			return false
+		}
+		if strings.EqualFold(fields[0], keyword) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// Where a service account token for the server is typically projected into a pod, with
+// audience as per the server's kubernetes config.
+const DefaultKubernetesTokenPath = "/var/run/secrets/geecert/token"
+
+var ErrKubernetesReauth = errors.New("The server requires a recent sign in, which a Kubernetes service account token can't provide.")
+
+// Returns the service account token at KubernetesTokenPath, and its claims. The kubelet
+// replaces the token before it expires, so it is read again each time. The signature is
+// checked by the server, not us.
+func getKubernetesToken(config *ClientAppConfiguration) (string, *IDTokenClaims, error) {
+	b, err := os.ReadFile(config.KubernetesTokenPath)
+	if err != nil {
+		return "", nil, err
+	}
+	token := strings.TrimSpace(string(b))
+	parsed, _, err := new(jwt.Parser).ParseUnverified(token, jwt.MapClaims{})
+	if err != nil {
+		return "", nil, fmt.Errorf("%w (%s: %w)", ErrInvalidIDToken, config.KubernetesTokenPath, err)
+	}
+	mapClaims := parsed.Claims.(jwt.MapClaims)
+	claims := &IDTokenClaims{Claims: mapClaims}
+	claims.EmailAddress, _ = mapClaims["sub"].(string)
+	if exp, ok := mapClaims["exp"].(float64); ok {
+		claims.Expiry = time.Unix(int64(exp), 0)
+	}
+	if claims.Expiry.Before(time.Now()) {
+		return "", nil, fmt.Errorf("%w (service account token in %s)", ErrTokenExpired, config.KubernetesTokenPath)
+	}
+	return token, claims, nil
+}
@@ -0,0 +1,81 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"unicode/utf16"
+)
+
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+// How a file we edit was saved, which may be by an editor on Windows, so that it can be
+// parsed as UTF-8 lines and written back the same way. Files saved as UTF-16 are written back
+// as UTF-8, as ssh can't read them otherwise.
+type lineFormat struct {
+	crlf bool
+	bom  bool
+}
+
+// Splits contents into lines without line endings or byte order mark, and returns how they
+// were saved. The line endings are taken from the first line.
+func splitLines(contents []byte) ([]string, lineFormat) {
+	var format lineFormat
+	switch {
+	case bytes.HasPrefix(contents, utf8BOM):
+		contents = contents[len(utf8BOM):]
+		format.bom = true
+	case bytes.HasPrefix(contents, []byte{0xff, 0xfe}):
+		contents = decodeUTF16(contents[2:], binary.LittleEndian)
+	case bytes.HasPrefix(contents, []byte{0xfe, 0xff}):
+		contents = decodeUTF16(contents[2:], binary.BigEndian)
+	}
+	if i := bytes.IndexByte(contents, '\n'); i > 0 && contents[i-1] == '\r' {
+		format.crlf = true
+	}
+
+	lines := strings.Split(string(contents), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines, format
+}
+
+// Joins lines as they were split from a file saved in format.
+func (format lineFormat) join(lines []string) []byte {
+	eol := "\n"
+	if format.crlf {
+		eol = "\r\n"
+	}
+	rv := []byte(strings.Join(lines, eol))
+	if format.bom {
+		rv = append(append([]byte{}, utf8BOM...), rv...)
+	}
+	return rv
+}
+
+func decodeUTF16(b []byte, order binary.ByteOrder) []byte {
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = order.Uint16(b[2*i:])
+	}
+	return []byte(string(utf16.Decode(u)))
+}
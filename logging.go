@@ -0,0 +1,198 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+type LogLevel int
+
+const (
+	LogWarning LogLevel = iota // only warnings
+	LogInfo                    // progress messages, the default
+	LogVerbose                 // also details of what is being done
+	LogDebug                   // also HTTP and gRPC requests and responses, with secrets redacted
+)
+
+// Receives all log output from this library. Replace the default with SetLogger, e.g. to
+// show messages in a GUI or to change the level.
+type Logger interface {
+	Log(level LogLevel, msg string)
+	Enabled(level LogLevel) bool // if false, messages at level are not logged
+}
+
+var (
+	loggerLock sync.Mutex
+	logger     Logger = NewConsoleLogger(os.Stderr, LogInfo)
+)
+
+func SetLogger(l Logger) {
+	loggerLock.Lock()
+	defer loggerLock.Unlock()
+	logger = l
+}
+
+func currentLogger() Logger {
+	loggerLock.Lock()
+	defer loggerLock.Unlock()
+	return logger
+}
+
+// Writes messages at or below level to w, one per line. Warnings are prefixed with WARNING,
+// and at LogDebug each line is timestamped. If w is stderr and that is connected to the
+// systemd journal, each line is instead prefixed with its priority, as per sd-daemon.
+func NewConsoleLogger(w io.Writer, level LogLevel) Logger {
+	return &consoleLogger{w: w, level: level, journal: w == os.Stderr && len(os.Getenv("JOURNAL_STREAM")) > 0}
+}
+
+type consoleLogger struct {
+	lock    sync.Mutex
+	w       io.Writer
+	level   LogLevel
+	journal bool
+}
+
+// Syslog priorities of our levels, for the journal.
+var journalPriorities = map[LogLevel]string{
+	LogWarning: "<4>",
+	LogInfo:    "<6>",
+	LogVerbose: "<7>",
+	LogDebug:   "<7>",
+}
+
+func (cl *consoleLogger) Enabled(level LogLevel) bool {
+	return level <= cl.level
+}
+
+func (cl *consoleLogger) Log(level LogLevel, msg string) {
+	if !cl.Enabled(level) {
+		return
+	}
+
+	var b bytes.Buffer
+	if cl.journal {
+		b.WriteString(journalPriorities[level])
+	} else if cl.level >= LogDebug {
+		b.WriteString(time.Now().Format("15:04:05.000 "))
+	}
+	if level == LogWarning {
+		b.WriteString("WARNING: ")
+	}
+	b.WriteString(strings.TrimRight(msg, "\n"))
+	b.WriteString("\n")
+
+	cl.lock.Lock()
+	defer cl.lock.Unlock()
+	cl.w.Write(b.Bytes())
+}
+
+func logf(level LogLevel, format string, args ...interface{}) {
+	l := currentLogger()
+	if l.Enabled(level) {
+		l.Log(level, fmt.Sprintf(format, args...))
+	}
+}
+
+func logWarning(format string, args ...interface{}) { logf(LogWarning, format, args...) }
+func logInfo(format string, args ...interface{})    { logf(LogInfo, format, args...) }
+func logVerbose(format string, args ...interface{}) { logf(LogVerbose, format, args...) }
+func logDebug(format string, args ...interface{})   { logf(LogDebug, format, args...) }
+
+// Matches credentials in form bodies, JSON and proto text format, so they can be removed from debug output.
+var secretsRE = regexp.MustCompile(`(\b(?:client_secret|code|refresh_token|access_token|id_token|signature)(?:=|"?\s*:\s*"?))[^&"\s]+`)
+
+func redactSecrets(s string) string {
+	return secretsRE.ReplaceAllString(s, "${1}REDACTED")
+}
+
+// Logs each request and response at LogDebug.
+type debugTransport struct {
+	next http.RoundTripper
+}
+
+func (dt *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !currentLogger().Enabled(LogDebug) {
+		return dt.next.RoundTrip(req)
+	}
+
+	dump, err := httputil.DumpRequestOut(req, true)
+	if err == nil {
+		logDebug("HTTP request:\n%s", redactSecrets(string(dump)))
+	}
+
+	resp, err := dt.next.RoundTrip(req)
+	if err != nil {
+		logDebug("HTTP error: %s", err)
+		return nil, err
+	}
+
+	// Don't dump binaries, e.g. when downloading an update
+	ct := resp.Header.Get("Content-Type")
+	dump, err = httputil.DumpResponse(resp, strings.Contains(ct, "json") || strings.HasPrefix(ct, "text/"))
+	if err == nil {
+		logDebug("HTTP response:\n%s", redactSecrets(string(dump)))
+	}
+	return resp, nil
+}
+
+// Used for all HTTP requests made by the library, with the resolver from withResolver.
+var httpClient = &http.Client{Transport: &debugTransport{next: dialingTransport()}}
+
+// As per http.Get, using httpClient and ctx.
+func httpGet(ctx context.Context, uri string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	return httpClient.Do(req)
+}
+
+// As per http.PostForm, using httpClient and ctx.
+func postForm(ctx context.Context, uri string, data url.Values) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uri, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return httpClient.Do(req)
+}
+
+// Logs each RPC at LogDebug.
+func logRPC(method string, req, reply interface{}, err error) {
+	if !currentLogger().Enabled(LogDebug) {
+		return
+	}
+	if err != nil {
+		logDebug("gRPC %s:\nrequest: %s\nerror: %s", method, redactSecrets(fmt.Sprint(req)), err)
+		return
+	}
+	logDebug("gRPC %s:\nrequest: %s\nresponse: %s", method, redactSecrets(fmt.Sprint(req)), redactSecrets(fmt.Sprint(reply)))
+}
@@ -0,0 +1,166 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/hydrogen18/stoppableListener"
+)
+
+// Attempts at finding a port free on both 127.0.0.1 and ::1, if no RedirectPorts are set.
+const loopbackListenAttempts = 10
+
+var (
+	ErrBadRedirectPorts = errors.New("RedirectPorts must be a port, or a range such as 8400-8410.")
+	ErrNoRedirectPort   = errors.New("No free port for the browser's redirect in RedirectPorts.")
+)
+
+// Listens for the browser's redirect on loopback only. If IPv6 is available, listens on both
+// 127.0.0.1 and ::1, so that a redirect to localhost arrives whichever the browser resolves.
+type loopbackListener struct {
+	raw       []net.Listener
+	listeners []*stoppableListener.StoppableListener
+	Port      int
+}
+
+// Returns the first and last port in RedirectPorts, or 0 and 0 if any port may be used.
+func parseRedirectPorts(s string) (int, int, error) {
+	if len(s) == 0 {
+		return 0, 0, nil
+	}
+	lo, hi, isRange := strings.Cut(s, "-")
+	first, err := strconv.Atoi(strings.TrimSpace(lo))
+	if err != nil {
+		return 0, 0, ErrBadRedirectPorts
+	}
+	last := first
+	if isRange {
+		last, err = strconv.Atoi(strings.TrimSpace(hi))
+		if err != nil {
+			return 0, 0, ErrBadRedirectPorts
+		}
+	}
+	if first < 1 || last > 65535 || first > last {
+		return 0, 0, ErrBadRedirectPorts
+	}
+	return first, last, nil
+}
+
+// Returns true if we can listen on ::1. Many machines have IPv6 disabled.
+func ipv6LoopbackAvailable() bool {
+	l, err := net.Listen("tcp6", "[::1]:0")
+	if err != nil {
+		return false
+	}
+	l.Close()
+	return true
+}
+
+// Listens on a port from RedirectPorts, or any free port, on the loopback addresses available.
+func listenLoopback(config *ClientAppConfiguration) (*loopbackListener, error) {
+	first, last, err := parseRedirectPorts(config.RedirectPorts)
+	if err != nil {
+		return nil, err
+	}
+	hosts := []string{"127.0.0.1"}
+	if ipv6LoopbackAvailable() {
+		hosts = append(hosts, "::1")
+	}
+
+	if first == 0 {
+		// The port picked for 127.0.0.1 may be in use on ::1, so try a few
+		for i := 0; i < loopbackListenAttempts; i++ {
+			l, err := listenLoopbackPort(hosts, 0)
+			if err == nil || i == loopbackListenAttempts-1 {
+				return l, err
+			}
+		}
+	}
+	for port := first; port <= last; port++ {
+		l, err := listenLoopbackPort(hosts, port)
+		if err == nil {
+			return l, nil
+		}
+		logVerbose("Unable to listen for redirect on port %d: %s", port, err)
+	}
+	return nil, fmt.Errorf("%w (%s)", ErrNoRedirectPort, config.RedirectPorts)
+}
+
+// Listens on port on each of hosts. If port is 0, that picked for the first host is used for the rest.
+func listenLoopbackPort(hosts []string, port int) (*loopbackListener, error) {
+	rv := &loopbackListener{}
+	for _, h := range hosts {
+		l, err := net.Listen("tcp", net.JoinHostPort(h, strconv.Itoa(port)))
+		if err != nil {
+			rv.Close()
+			return nil, err
+		}
+		rv.raw = append(rv.raw, l)
+		if port == 0 {
+			port = l.Addr().(*net.TCPAddr).Port
+		}
+		sl, err := stoppableListener.New(l)
+		if err != nil {
+			rv.Close()
+			return nil, err
+		}
+		rv.listeners = append(rv.listeners, sl)
+	}
+	rv.Port = port
+	return rv, nil
+}
+
+// Redirect URI for the listeners: localhost if listening on both IPv4 and IPv6, else 127.0.0.1.
+func (l *loopbackListener) RedirectURI() string {
+	if len(l.listeners) > 1 {
+		return RedirectLocalhost + ":" + strconv.Itoa(l.Port)
+	}
+	return RedirectLoopback + ":" + strconv.Itoa(l.Port)
+}
+
+// Serves h on each listener, sending the error when each stops to the returned channel.
+func (l *loopbackListener) Serve(h http.Handler) <-chan error {
+	errs := make(chan error, len(l.listeners))
+	for _, sl := range l.listeners {
+		go func(sl *stoppableListener.StoppableListener) {
+			errs <- http.Serve(sl, h)
+		}(sl)
+	}
+	return errs
+}
+
+// Stops serving.
+func (l *loopbackListener) Stop() {
+	for _, sl := range l.listeners {
+		sl.Stop()
+	}
+}
+
+// Closes the listeners, if not yet served.
+func (l *loopbackListener) Close() {
+	for _, r := range l.raw {
+		r.Close()
+	}
+}
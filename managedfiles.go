@@ -0,0 +1,90 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+var ErrManagedFile = errors.New("File appears to be managed by something else, so is not replaced.")
+
+// Returned by SafeSave rather than replacing a symlink (e.g. from a dotfile manager such as
+// stow or chezmoi) or a read-only file.
+type ManagedFileError struct {
+	Path   string
+	Reason string
+}
+
+func (e *ManagedFileError) Error() string {
+	return fmt.Sprintf("Not replacing %s as it %s. Set FollowSymlinks to update the file it links to, or edit it by hand.", e.Path, e.Reason)
+}
+
+func (e *ManagedFileError) Is(target error) bool {
+	return target == ErrManagedFile
+}
+
+// Returns an error if the file at path shouldn't be replaced, because it is a symlink or is
+// read-only. It's fine for it not to exist.
+func checkReplaceable(path string) error {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if fi.Mode()&os.ModeSymlink != 0 {
+		target, _ := os.Readlink(path)
+		return &ManagedFileError{Path: path, Reason: "is a symlink to " + target}
+	}
+	if fi.Mode().Perm()&0200 == 0 {
+		return &ManagedFileError{Path: path, Reason: "is read-only"}
+	}
+	return nil
+}
+
+// Returns the file that path finally links to, or path if it isn't a symlink. The file
+// linked to needn't exist.
+func followSymlinks(path string) (string, error) {
+	for i := 0; i < 255; i++ {
+		fi, err := os.Lstat(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return path, nil
+			}
+			return "", err
+		}
+		if fi.Mode()&os.ModeSymlink == 0 {
+			return path, nil
+		}
+		target, err := os.Readlink(path)
+		if err != nil {
+			return "", err
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		logVerbose("Following symlink from %s to %s.", path, target)
+		path = target
+	}
+	return "", &ManagedFileError{Path: path, Reason: "is part of a symlink loop"}
+}
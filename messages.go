@@ -0,0 +1,76 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	_ "google.golang.org/grpc/encoding/gzip" // accept responses compressed by servers with compress_responses set
+	"google.golang.org/grpc/status"
+)
+
+// As for gRPC's own default.
+const DefaultMaxMessageBytes = 4 << 20
+
+func maxMessageBytes(config *ClientAppConfiguration) int {
+	if config.MaxMessageBytes > 0 {
+		return config.MaxMessageBytes
+	}
+	return DefaultMaxMessageBytes
+}
+
+func messageDialOptions(config *ClientAppConfiguration) []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(maxMessageBytes(config))),
+	}
+}
+
+// A request or response was larger than gRPC allows, on our side or the server's. Matches
+// ErrMessageTooLarge.
+type MessageSizeError struct {
+	Err   error // from gRPC
+	Limit int   // ours, see ClientAppConfiguration.MaxMessageBytes
+}
+
+func (e *MessageSizeError) Error() string {
+	return fmt.Sprintf("Message was too large (%s). Run again with -max_message_bytes larger than %d, or ask an administrator to raise max_message_bytes on the server.", status.Convert(e.Err).Message(), e.Limit)
+}
+
+func (e *MessageSizeError) Is(target error) bool {
+	return target == ErrMessageTooLarge
+}
+
+func (e *MessageSizeError) Unwrap() error {
+	return e.Err
+}
+
+// Returns a MessageSizeError for gRPC's own errors about message sizes, which say neither which
+// side's limit it was nor what to do about it. Other errors are returned as is.
+func messageSizeError(config *ClientAppConfiguration, err error) error {
+	if status.Code(err) != codes.ResourceExhausted || errorDetail(err) != nil {
+		return err
+	}
+	if !strings.Contains(status.Convert(err).Message(), "larger than max") {
+		return err
+	}
+	return &MessageSizeError{Err: err, Limit: maxMessageBytes(config)}
+}
@@ -0,0 +1,134 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// If ClientAppConfiguration.Notifications is set, the user is notified when the certificate
+// expires within this time, unless NotifyBefore is set.
+const DefaultNotifyBefore = 15 * time.Minute
+
+var (
+	expiryNotifiedLock   sync.Mutex
+	expiryNotifiedSerial uint64 // the certificate we last warned about, so we only warn once
+)
+
+// PowerShell to show a toast, reading the title and message from the environment so
+// that they needn't be escaped.
+const windowsToastScript = `
+[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
+$xml = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $xml.GetElementsByTagName("text")
+$text.Item(0).AppendChild($xml.CreateTextNode($env:GEECERT_NOTIFY_TITLE)) | Out-Null
+$text.Item(1).AppendChild($xml.CreateTextNode($env:GEECERT_NOTIFY_MESSAGE)) | Out-Null
+$toast = [Windows.UI.Notifications.ToastNotification]::new($xml)
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier("{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe").Show($toast)
+`
+
+// Shows a desktop notification, using osascript on macOS, a toast on Windows, or notify-send elsewhere.
+func Notify(title, message string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("osascript",
+			"-e", "on run argv",
+			"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+			"-e", "end run",
+			title, message)
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", windowsToastScript)
+		cmd.Env = append(os.Environ(), "GEECERT_NOTIFY_TITLE="+title, "GEECERT_NOTIFY_MESSAGE="+message)
+	default:
+		cmd = exec.Command("notify-send", "--app-name=geecert", title, message)
+	}
+	return cmd.Run()
+}
+
+// Notifies if configured to, logging rather than returning any error, as notifications are only a nicety.
+func notifyIfEnabled(config *ClientAppConfiguration, title, message string) {
+	if !config.Notifications {
+		return
+	}
+	err := Notify(title, message)
+	if err != nil {
+		logWarning("Unable to show notification: %s", err)
+	}
+}
+
+func notifyInstalled(config *ClientAppConfiguration, certificate string) {
+	if !config.Notifications {
+		return
+	}
+	pk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(certificate))
+	if err != nil {
+		return
+	}
+	cert, ok := pk.(*ssh.Certificate)
+	if !ok {
+		return
+	}
+	validBefore := time.Unix(int64(cert.ValidBefore), 0)
+	notifyIfEnabled(config, "SSH certificate installed", fmt.Sprintf("Your new SSH certificate is valid until %s.", validBefore.Format("Mon 15:04")))
+}
+
+// Notifies, once per certificate, if the installed certificate expires within config.NotifyBefore.
+func notifyIfExpiring(config *ClientAppConfiguration) {
+	if !config.Notifications {
+		return
+	}
+	cert, err := LoadInstalledCert(config)
+	if err != nil {
+		return
+	}
+
+	notifyBefore := config.NotifyBefore
+	if d := loadDirectives(config); d.GetNotifyBeforeSeconds() > 0 {
+		notifyBefore = time.Duration(d.NotifyBeforeSeconds) * time.Second
+	}
+	if notifyBefore == 0 {
+		notifyBefore = DefaultNotifyBefore
+	}
+	remaining := time.Unix(int64(cert.ValidBefore), 0).Sub(time.Now())
+	if remaining > notifyBefore {
+		return
+	}
+
+	expiryNotifiedLock.Lock()
+	notified := expiryNotifiedSerial == cert.Serial
+	expiryNotifiedSerial = cert.Serial
+	expiryNotifiedLock.Unlock()
+	if notified {
+		return
+	}
+
+	if remaining <= 0 {
+		notifyIfEnabled(config, "SSH certificate expired", "Your SSH certificate has expired. Run again to fetch a new one.")
+	} else {
+		notifyIfEnabled(config, "SSH certificate expiring", fmt.Sprintf("Your SSH certificate expires in %d minutes.", int(remaining.Minutes())+1))
+	}
+}
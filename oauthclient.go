@@ -0,0 +1,38 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+// An OAuth client ID and its secret, as configured with Google.
+type OAuthClient struct {
+	ClientID          string
+	ClientNotSoSecret string
+}
+
+// Returns the client ID and secret for OAuthClientProfile (default platform()) if listed in
+// OAuthClients, else ClientID and ClientNotSoSecret.
+func oauthClient(config *ClientAppConfiguration) OAuthClient {
+	profile := config.OAuthClientProfile
+	if len(profile) == 0 {
+		profile = platform()
+	}
+	if c, ok := config.OAuthClients[profile]; ok {
+		return c
+	}
+	return OAuthClient{ClientID: config.ClientID, ClientNotSoSecret: config.ClientNotSoSecret}
+}
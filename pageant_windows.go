@@ -0,0 +1,122 @@
+//go:build windows
+// +build windows
+
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"encoding/binary"
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// See windows/winpgntc.c in the PuTTY source.
+const (
+	pageantCopyDataID = 0x804e50ba
+	pageantMaxMsgLen  = 8192
+
+	wmCopyData = 0x004A
+)
+
+var (
+	user32   = syscall.NewLazyDLL("user32.dll")
+	kernel32 = syscall.NewLazyDLL("kernel32.dll")
+
+	procFindWindowW        = user32.NewProc("FindWindowW")
+	procSendMessageW       = user32.NewProc("SendMessageW")
+	procGetCurrentThreadId = kernel32.NewProc("GetCurrentThreadId")
+)
+
+type copyDataStruct struct {
+	dwData uintptr
+	cbData uint32
+	lpData uintptr
+}
+
+func findPageantWindow() uintptr {
+	name, err := syscall.UTF16PtrFromString("Pageant")
+	if err != nil {
+		return 0
+	}
+	hwnd, _, _ := procFindWindowW.Call(uintptr(unsafe.Pointer(name)), uintptr(unsafe.Pointer(name)))
+	return hwnd
+}
+
+func pageantRunning() bool {
+	return findPageantWindow() != 0
+}
+
+// Send a single agent message (including length prefix) to Pageant and return the response.
+// The request is passed in a named shared memory mapping, which Pageant overwrites with the response.
+func pageantQuery(req []byte) ([]byte, error) {
+	if len(req) > pageantMaxMsgLen {
+		return nil, ErrPageantFailed
+	}
+
+	hwnd := findPageantWindow()
+	if hwnd == 0 {
+		return nil, ErrPageantFailed
+	}
+
+	tid, _, _ := procGetCurrentThreadId.Call()
+	mapName := fmt.Sprintf("PageantRequest%08x\x00", tid)
+	mapNamePtr, err := syscall.UTF16PtrFromString(mapName[:len(mapName)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	fileMap, err := syscall.CreateFileMapping(syscall.InvalidHandle, nil, syscall.PAGE_READWRITE, 0, pageantMaxMsgLen, mapNamePtr)
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.CloseHandle(fileMap)
+
+	addr, err := syscall.MapViewOfFile(fileMap, syscall.FILE_MAP_WRITE, 0, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.UnmapViewOfFile(addr)
+
+	// addr is memory mapped outside of the Go heap, so it is safe to convert
+	shared := (*[pageantMaxMsgLen]byte)(*(*unsafe.Pointer)(unsafe.Pointer(&addr)))[:]
+	copy(shared, req)
+
+	// Pageant expects the mapping name as a NUL terminated ANSI string
+	nameBytes := []byte(mapName)
+	cds := copyDataStruct{
+		dwData: pageantCopyDataID,
+		cbData: uint32(len(nameBytes)),
+		lpData: uintptr(unsafe.Pointer(&nameBytes[0])),
+	}
+	rv, _, _ := procSendMessageW.Call(hwnd, wmCopyData, 0, uintptr(unsafe.Pointer(&cds)))
+	if rv == 0 {
+		return nil, ErrPageantFailed
+	}
+
+	l := int(binary.BigEndian.Uint32(shared)) + 4
+	if l > pageantMaxMsgLen {
+		return nil, ErrPageantFailed
+	}
+
+	resp := make([]byte, l)
+	copy(resp, shared)
+	return resp, nil
+}
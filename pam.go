@@ -0,0 +1,312 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// First line of the message signed for a PAM token.
+const pamTokenContext = "geecert-pam-v1"
+
+// How old a PAM token can be, by default, when verified.
+const DefaultPAMTokenMaxAge = time.Minute
+
+// Where geecert-pam records the tokens it has accepted, by default.
+const DefaultPAMUsedTokensDir = "/var/lib/geecert-pam"
+
+// Version of the format written by signData.
+const signedDataVersion1 = 0x01
+
+var (
+	ErrBadSignedData    = errors.New("Signed data is not in a format we understand.")
+	ErrWeakSignedData   = errors.New("Signed data uses a SHA-1 signature.")
+	ErrBadPAMToken      = errors.New("PAM token is not in a format we understand.")
+	ErrPAMTokenExpired  = errors.New("PAM token is too old, or from the future.")
+	ErrPAMTokenWrong    = errors.New("PAM token is for another user or host.")
+	ErrPAMTokenReplayed = errors.New("PAM token has already been used.")
+	ErrUntrustedCA      = errors.New("Certificate is not signed by a trusted CA.")
+)
+
+// Parses data as written by signData (version 1), and checks its signature over msg by the key
+// in its certificate, which is returned. The certificate itself is not checked.
+func verifySignedData(data []byte, msg []byte) (*ssh.Certificate, error) {
+	if len(data) < 1 || data[0] != signedDataVersion1 {
+		return nil, ErrBadSignedData
+	}
+	data = data[1:]
+
+	next := func() ([]byte, error) {
+		if len(data) < 8 {
+			return nil, ErrBadSignedData
+		}
+		n := binary.BigEndian.Uint64(data)
+		data = data[8:]
+		if n > uint64(len(data)) {
+			return nil, ErrBadSignedData
+		}
+		rv := data[:n]
+		data = data[n:]
+		return rv, nil
+	}
+	certData, err := next()
+	if err != nil {
+		return nil, err
+	}
+	sigData, err := next()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != 0 {
+		return nil, ErrBadSignedData
+	}
+
+	pk, err := ssh.ParsePublicKey(certData)
+	if err != nil {
+		return nil, err
+	}
+	cert, ok := pk.(*ssh.Certificate)
+	if !ok {
+		return nil, ErrWrongCertType
+	}
+	var sig ssh.Signature
+	err = ssh.Unmarshal(sigData, &sig)
+	if err != nil {
+		return nil, ErrBadSignedData
+	}
+	if sig.Format == ssh.KeyAlgoRSA {
+		return nil, ErrWeakSignedData
+	}
+	err = cert.Key.Verify(msg, &sig)
+	if err != nil {
+		return nil, err
+	}
+	return cert, nil
+}
+
+// Loads public keys in authorized_keys format, such as the CA keys written by SetupHost.
+func LoadAuthorizedKeys(path string) ([]ssh.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rv []ssh.PublicKey
+	for len(bytes.TrimSpace(data)) > 0 {
+		pk, _, _, rest, err := ssh.ParseAuthorizedKey(data)
+		if err != nil {
+			return nil, err
+		}
+		rv = append(rv, pk)
+		data = rest
+	}
+	return rv, nil
+}
+
+// The message signed for a PAM token for username on host at t. The nonce makes each token
+// different, so that each can only be used once.
+func pamTokenMessage(username string, host string, t time.Time, nonce string) []byte {
+	return []byte(fmt.Sprintf("%s\n%s\n%s\n%d\n%s", pamTokenContext, username, host, t.Unix(), nonce))
+}
+
+// Returns a token to authenticate as username on host, e.g. to sudo -S, made by signing with the
+// installed key and certificate, for geecert-pam to check with a PAMVerifier.
+func PAMToken(config *ClientAppConfiguration, username string, host string) (string, error) {
+	nonce := make([]byte, 16)
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+	msg := pamTokenMessage(username, host, time.Now(), base64.RawURLEncoding.EncodeToString(nonce))
+	signed, err := signData(config, msg)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(msg) + "." + base64.RawURLEncoding.EncodeToString(signed), nil
+}
+
+// Checks PAM tokens, as used by geecert-pam.
+type PAMVerifier struct {
+	CAKeys     []ssh.PublicKey // the certificate must be signed by one of these
+	Principals []string        // if set, the certificate must have one of these, else the user being authenticated
+	Hostname   string          // the token must be for this host
+	MaxAge     time.Duration   // how long ago the token may have been made, or DefaultPAMTokenMaxAge
+	UsedDir    string          // if set, tokens are recorded here until they expire, and each is only accepted once
+}
+
+// Checks token authenticates username on this host at now, and returns its certificate.
+func (v *PAMVerifier) Verify(username string, token string, now time.Time) (*ssh.Certificate, error) {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 2 {
+		return nil, ErrBadPAMToken
+	}
+	msg, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrBadPAMToken
+	}
+	signed, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrBadPAMToken
+	}
+
+	fields := strings.Split(string(msg), "\n")
+	if len(fields) != 5 || fields[0] != pamTokenContext || len(fields[4]) == 0 {
+		return nil, ErrBadPAMToken
+	}
+	if fields[1] != username || fields[2] != v.Hostname {
+		return nil, ErrPAMTokenWrong
+	}
+	made, err := strconv.ParseInt(fields[3], 10, 64)
+	if err != nil {
+		return nil, ErrBadPAMToken
+	}
+	maxAge := v.MaxAge
+	if maxAge == 0 {
+		maxAge = DefaultPAMTokenMaxAge
+	}
+	age := now.Sub(time.Unix(made, 0))
+	if age > maxAge || age < -maxAge {
+		return nil, ErrPAMTokenExpired
+	}
+
+	cert, err := verifySignedData(signed, msg)
+	if err != nil {
+		return nil, err
+	}
+	if cert.CertType != ssh.UserCert {
+		return nil, ErrWrongCertType
+	}
+
+	// CheckCert checks the CA's signature, but not that it is one we trust
+	trusted := false
+	for _, k := range v.CAKeys {
+		if bytes.Equal(k.Marshal(), cert.SignatureKey.Marshal()) {
+			trusted = true
+		}
+	}
+	if !trusted {
+		return nil, ErrUntrustedCA
+	}
+	checker := &ssh.CertChecker{Clock: func() time.Time { return now }}
+	principals := v.Principals
+	if len(principals) == 0 {
+		principals = []string{username}
+	}
+	for _, p := range principals {
+		err = checker.CheckCert(p, cert)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = v.recordUsed(msg, maxAge, now)
+	if err != nil {
+		return nil, err
+	}
+	return cert, nil
+}
+
+// Records a token's message in UsedDir, failing if it is already there, and removes those
+// that have expired. Tokens made up to maxAge in the future are accepted until maxAge after.
+func (v *PAMVerifier) recordUsed(msg []byte, maxAge time.Duration, now time.Time) error {
+	if len(v.UsedDir) == 0 {
+		return nil
+	}
+	err := os.MkdirAll(v.UsedDir, 0700)
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(v.UsedDir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		fi, err := e.Info()
+		if err == nil && now.Sub(fi.ModTime()) > 2*maxAge {
+			os.Remove(filepath.Join(v.UsedDir, e.Name()))
+		}
+	}
+
+	// By the message, as a signature may be altered and remain valid
+	sum := sha256.Sum256(msg)
+	f, err := os.OpenFile(filepath.Join(v.UsedDir, hex.EncodeToString(sum[:])), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if os.IsExist(err) {
+		return ErrPAMTokenReplayed
+	}
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// pam-token [username [host]]
+// Prints a token for geecert-pam to authenticate as username (default the current user) on host
+// (default this one), e.g. getmycerts pam-token | sudo -S -p "" true
+func pamTokenCommand(ctx context.Context, config *ClientAppConfiguration, args []string) error {
+	if len(args) > 2 {
+		return ErrUsage
+	}
+	var username, host string
+	var err error
+	if len(args) > 0 {
+		username = args[0]
+	} else {
+		u, err := user.Current()
+		if err != nil {
+			return err
+		}
+		username = u.Username
+	}
+	if len(args) > 1 {
+		host = args[1]
+	} else {
+		host, err = os.Hostname()
+		if err != nil {
+			return err
+		}
+	}
+
+	err = EnsureFreshCert(ctx, config)
+	if err != nil {
+		return err
+	}
+	token, err := PAMToken(config, username, host)
+	if err != nil {
+		return err
+	}
+	fmt.Println(token)
+	return nil
+}
@@ -0,0 +1,144 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Returns a signer for a certificate for principals, signed by ca, valid from an hour ago to validBefore.
+func testCertSigner(t *testing.T, ca ssh.Signer, key interface{}, principals []string, validBefore time.Time) (ssh.Signer, *ssh.Certificate) {
+	s, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := &ssh.Certificate{
+		Key:             s.PublicKey(),
+		CertType:        ssh.UserCert,
+		KeyId:           "alice",
+		ValidPrincipals: principals,
+		ValidAfter:      uint64(time.Now().Add(-time.Hour).Unix()),
+		ValidBefore:     uint64(validBefore.Unix()),
+	}
+	err = cert.SignCert(rand.Reader, ca)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs, err := ssh.NewCertSigner(cert, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cs, cert
+}
+
+func testCA(t *testing.T) ssh.Signer {
+	_, k, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := ssh.NewSignerFromKey(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ca
+}
+
+// Stands for any error in tables of expected errors.
+var errAny = errors.New("any error")
+
+// Reports err if it isn't want.
+func checkTestError(t *testing.T, name string, err error, want error) {
+	t.Helper()
+	switch {
+	case want == nil && err != nil:
+		t.Errorf("%s: %v", name, err)
+	case want == errAny && err == nil:
+		t.Errorf("%s: expected an error", name)
+	case want != nil && want != errAny && err != want:
+		t.Errorf("%s: got %v, want %v", name, err, want)
+	}
+}
+
+// Hides SignWithAlgorithm, so that RSA keys sign with SHA-1.
+type sha1Signer struct {
+	ssh.Signer
+}
+
+func TestPAMVerifier(t *testing.T) {
+	now := time.Now()
+	ca := testCA(t)
+	rk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, cert := testCertSigner(t, ca, rk, []string{"alice"}, now.Add(time.Hour))
+	_, ek, _ := ed25519.GenerateKey(rand.Reader)
+	expiredSigner, expiredCert := testCertSigner(t, ca, ek, []string{"alice"}, now.Add(-time.Minute))
+	otherSigner, otherCert := testCertSigner(t, testCA(t), ek, []string{"alice"}, now.Add(time.Hour))
+
+	nonce := 0
+	token := func(s ssh.Signer, c *ssh.Certificate, username, host string, made time.Time) string {
+		nonce++
+		msg := pamTokenMessage(username, host, made, base64.RawURLEncoding.EncodeToString([]byte{byte(nonce)}))
+		signed, err := signDataWith(s, c, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(msg) + "." + base64.RawURLEncoding.EncodeToString(signed)
+	}
+	good := token(signer, cert, "alice", "host1", now)
+
+	v := &PAMVerifier{CAKeys: []ssh.PublicKey{ca.PublicKey()}, Hostname: "host1", UsedDir: t.TempDir()}
+	for _, c := range []struct {
+		name     string
+		username string
+		token    string
+		want     error
+	}{
+		{"ok", "alice", good, nil},
+		{"replayed", "alice", good, ErrPAMTokenReplayed},
+		{"another from the same second", "alice", token(signer, cert, "alice", "host1", now), nil},
+		{"wrong user", "bob", token(signer, cert, "alice", "host1", now), ErrPAMTokenWrong},
+		{"wrong host", "alice", token(signer, cert, "alice", "host2", now), ErrPAMTokenWrong},
+		{"not a principal", "bob", token(signer, cert, "bob", "host1", now), errAny},
+		{"too old", "alice", token(signer, cert, "alice", "host1", now.Add(-2*DefaultPAMTokenMaxAge)), ErrPAMTokenExpired},
+		{"from the future", "alice", token(signer, cert, "alice", "host1", now.Add(2*DefaultPAMTokenMaxAge)), ErrPAMTokenExpired},
+		{"expired certificate", "alice", token(expiredSigner, expiredCert, "alice", "host1", now), errAny},
+		{"untrusted CA", "alice", token(otherSigner, otherCert, "alice", "host1", now), ErrUntrustedCA},
+		{"SHA-1", "alice", token(sha1Signer{signer}, cert, "alice", "host1", now), ErrWeakSignedData},
+		{"malformed", "alice", "x", ErrBadPAMToken},
+		{"bad signed data", "alice", good[:len(good)-20], ErrBadSignedData},
+		{"another message", "alice", base64.RawURLEncoding.EncodeToString(pamTokenMessage("alice", "host1", now, "other")) + good[strings.Index(good, "."):], errAny},
+	} {
+		got, err := v.Verify(c.username, c.token, now)
+		checkTestError(t, c.name, err, c.want)
+		if err == nil && got.KeyId != "alice" {
+			t.Errorf("%s: got %v", c.name, got)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"os"
+	"path/filepath"
+
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+// Where we install files. The *InConfig paths are as written into the ssh config file,
+// and may contain a ~ rather than be absolute.
+type InstallPaths struct {
+	Key        string
+	Cert       string
+	KnownHosts string
+	SSHConfig  string
+
+	KeyInConfig  string
+	CertInConfig string // "" if ssh will find the cert next to the key
+
+	SystemWide bool // KnownHosts and SSHConfig are read by every user
+}
+
+// Expands environment variables and a leading ~ in a configured path.
+func expandPath(path string) (string, error) {
+	return homedir.Expand(os.ExpandEnv(path))
+}
+
+// Works out where to install files, using the paths in the config if set, else defaulting
+// to files in sshDir. homePathToSSHDir is as per FetchCerts.
+func ResolveInstallPaths(config *ClientAppConfiguration, sshDir string, homePathToSSHDir string) (*InstallPaths, error) {
+	rv := &InstallPaths{
+		Key:         filepath.Join(sshDir, config.ShortlivedKeyName),
+		KnownHosts:  filepath.Join(sshDir, "known_hosts"),
+		SSHConfig:   filepath.Join(sshDir, "config"),
+		KeyInConfig: filepath.Join(homePathToSSHDir, config.ShortlivedKeyName),
+	}
+
+	if config.SystemWide {
+		rv.KnownHosts = SystemKnownHostsPath
+		rv.SSHConfig = SystemSSHConfigPath
+		rv.SystemWide = true
+	}
+
+	var err error
+	if len(config.KeyPath) > 0 {
+		rv.KeyInConfig = os.ExpandEnv(config.KeyPath)
+		rv.Key, err = expandPath(config.KeyPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rv.Cert = rv.Key + "-cert.pub"
+	if len(config.CertPath) > 0 {
+		rv.CertInConfig = os.ExpandEnv(config.CertPath)
+		rv.Cert, err = expandPath(config.CertPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(config.KnownHostsPath) > 0 {
+		rv.KnownHosts, err = expandPath(config.KnownHostsPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(config.SSHConfigPath) > 0 {
+		rv.SSHConfig, err = expandPath(config.SSHConfigPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if config.FollowSymlinks {
+		for _, p := range []*string{&rv.Key, &rv.Cert, &rv.KnownHosts, &rv.SSHConfig} {
+			*p, err = followSymlinks(*p)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return rv, nil
+}
+
+// As per ResolveInstallPaths, for the ~/.ssh directory used by ProcessClient.
+func DefaultInstallPaths(config *ClientAppConfiguration) (*InstallPaths, error) {
+	hd, err := homedir.Dir()
+	if err != nil {
+		return nil, err
+	}
+	return ResolveInstallPaths(config, filepath.Join(hd, ".ssh"), filepath.Join("~", ".ssh"))
+}
+
+// Create the directories that our files live in, if needed.
+func (p *InstallPaths) makeDirs() error {
+	for _, path := range []string{p.Key, p.Cert, p.KnownHosts, p.SSHConfig} {
+		perm := os.FileMode(0700)
+		if p.SystemWide && (path == p.KnownHosts || path == p.SSHConfig) {
+			perm = 0755
+		}
+		err := os.MkdirAll(filepath.Dir(path), perm)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// A file or directory that ssh reads, and the permission bits it must not have, else ssh
+// refuses to use it, and those it must have, for files that every user reads.
+type permissionCheck struct {
+	path string
+	deny os.FileMode
+	need os.FileMode
+}
+
+func (c permissionCheck) ok(mode os.FileMode) bool {
+	return mode.Perm()&c.deny == 0 && mode.Perm()&c.need == c.need
+}
+
+func (c permissionCheck) want(mode os.FileMode) os.FileMode {
+	return mode.Perm()&^c.deny | c.need
+}
+
+// The checks for the files in paths, directories first. The ssh directory is kept private to
+// the user, while other directories that files are installed to must not be writable by others.
+func permissionChecks(config *ClientAppConfiguration, paths *InstallPaths, sshDir string) ([]permissionCheck, error) {
+	credPath, err := credentialsPath(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var dirs, files []permissionCheck
+	shared := func(path string) bool {
+		return paths.SystemWide && (path == paths.KnownHosts || path == paths.SSHConfig)
+	}
+	for _, path := range []string{paths.Key, paths.Cert, paths.KnownHosts, paths.SSHConfig} {
+		c := permissionCheck{path: filepath.Dir(path), deny: 0022}
+		if c.path == filepath.Clean(sshDir) {
+			c.deny = 0077
+		}
+		if shared(path) {
+			c.need = 0555
+		}
+		dirs = append(dirs, c)
+	}
+	for _, path := range []string{paths.Cert, paths.Key + ".pub", paths.KnownHosts, paths.SSHConfig} {
+		c := permissionCheck{path: path, deny: 0022}
+		if shared(path) {
+			c.need = 0444
+		}
+		files = append(files, c)
+	}
+	for _, path := range []string{paths.Key, paths.Key + ".ppk", credPath} {
+		files = append(files, permissionCheck{path: path, deny: 0077})
+	}
+
+	var rv []permissionCheck
+	seen := make(map[string]bool)
+	for _, c := range append(dirs, files...) {
+		if !seen[c.path] {
+			seen[c.path] = true
+			rv = append(rv, c)
+		}
+	}
+	return rv, nil
+}
+
+// Fixes the modes of the files in paths that ssh would refuse to use, or only reports them if
+// NoFixPermissions is set. New files are written with the right modes whatever the umask, but
+// existing ones may have been copied from elsewhere, or changed by other tools.
+func repairPermissions(config *ClientAppConfiguration, paths *InstallPaths, sshDir string) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+	checks, err := permissionChecks(config, paths, sshDir)
+	if err != nil {
+		logWarning("Unable to check permissions: %s", err)
+		return
+	}
+	for _, c := range checks {
+		fi, err := os.Stat(c.path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				logWarning("Unable to check permissions of %s: %s", c.path, err)
+			}
+			continue
+		}
+		if c.ok(fi.Mode()) {
+			continue
+		}
+		if config.NoFixPermissions {
+			logWarning("%s has mode %s, which ssh may refuse. Run: chmod %o %s", c.path, fi.Mode().Perm(), c.want(fi.Mode()), c.path)
+			continue
+		}
+		err = os.Chmod(c.path, c.want(fi.Mode()))
+		if err != nil {
+			logWarning("Unable to fix mode %s of %s, which ssh may refuse: %s", fi.Mode().Perm(), c.path, err)
+			continue
+		}
+		logInfo("Changed mode of %s from %s to %s, as ssh may refuse it otherwise.", c.path, fi.Mode().Perm(), c.want(fi.Mode()))
+	}
+}
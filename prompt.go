@@ -0,0 +1,127 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+var (
+	ErrEmptyInput = errors.New("Nothing was entered.")
+	ErrBadCode    = errors.New("That doesn't look like an authorization code, it should be a single word.")
+)
+
+// Asks the user for input, e.g. the authorization code when the browser can't be used.
+// Programs with a GUI can set ClientAppConfiguration.Prompter to ask in their own way.
+type Prompter interface {
+	// Shows message, and returns the user's answer with surrounding whitespace removed.
+	// If validate is not nil, the answer is only returned once it passes. Returns ctx.Err()
+	// if ctx is done before the user answers.
+	Prompt(ctx context.Context, message string, validate func(string) error) (string, error)
+}
+
+// Prompts on a terminal. The default Prompter uses stdin and stdout.
+type TerminalPrompter struct {
+	In  io.Reader
+	Out io.Writer
+
+	once  sync.Once
+	lines chan string
+	err   error // set before lines is closed
+}
+
+var defaultPrompter = &TerminalPrompter{In: os.Stdin, Out: os.Stdout}
+
+func prompter(config *ClientAppConfiguration) Prompter {
+	if config.Prompter != nil {
+		return config.Prompter
+	}
+	return defaultPrompter
+}
+
+// Lines are read by a single goroutine for the life of the prompter, so that a line
+// typed after a prompt was cancelled is given to the next prompt rather than lost.
+func (tp *TerminalPrompter) readLines() {
+	tp.lines = make(chan string)
+	go func() {
+		r := bufio.NewReader(tp.In)
+		for {
+			line, err := r.ReadString('\n')
+			if len(line) > 0 {
+				tp.lines <- line
+			}
+			if err != nil {
+				tp.err = err
+				close(tp.lines)
+				return
+			}
+		}
+	}()
+}
+
+func (tp *TerminalPrompter) Prompt(ctx context.Context, message string, validate func(string) error) (string, error) {
+	tp.once.Do(tp.readLines)
+
+	fmt.Fprint(tp.Out, message)
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Fprintln(tp.Out)
+			return "", ctx.Err()
+		case line, ok := <-tp.lines:
+			if !ok {
+				return "", tp.err
+			}
+			answer := strings.TrimSpace(line)
+			err := validateAnswer(answer, validate)
+			if err == nil {
+				return answer, nil
+			}
+			fmt.Fprintf(tp.Out, "%s Please try again: ", err)
+		}
+	}
+}
+
+func validateAnswer(answer string, validate func(string) error) error {
+	if len(answer) == 0 {
+		return ErrEmptyInput
+	}
+	if validate != nil {
+		return validate(answer)
+	}
+	return nil
+}
+
+// Authorization codes are printable and contain no spaces, e.g. 4/AX4XfWj...
+func validateCode(code string) error {
+	for _, r := range code {
+		if unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return ErrBadCode
+		}
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Registry key under which PuTTY 0.78 and later look for trusted host CAs.
+const puttyHostCAsKey = `HKCU\Software\SimonTatham\PuTTY\SshHostCAs\`
+
+// Append an SSH wire format string.
+func appendSSHString(buf []byte, s []byte) []byte {
+	l := make([]byte, 4)
+	binary.BigEndian.PutUint32(l, uint32(len(s)))
+	return append(append(buf, l...), s...)
+}
+
+// Append an SSH wire format mpint (positive values only).
+func appendSSHMPInt(buf []byte, n *big.Int) []byte {
+	b := n.Bytes()
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		b = append([]byte{0}, b...)
+	}
+	return appendSSHString(buf, b)
+}
+
+// Base64 encode into 64 character lines, as used by PPK files.
+func ppkLines(data []byte) []string {
+	s := base64.StdEncoding.EncodeToString(data)
+	var rv []string
+	for len(s) > 64 {
+		rv = append(rv, s[:64])
+		s = s[64:]
+	}
+	return append(rv, s)
+}
+
+// Returns an unencrypted PuTTY version 2 private key file for the key.
+// This format is understood by all versions of PuTTY and WinSCP.
+func MarshalPPK(privateKey *rsa.PrivateKey, comment string) ([]byte, error) {
+	pub, err := ssh.NewPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	pubBlob := pub.Marshal()
+
+	privateKey.Precompute()
+	var privBlob []byte
+	privBlob = appendSSHMPInt(privBlob, privateKey.D)
+	privBlob = appendSSHMPInt(privBlob, privateKey.Primes[0])
+	privBlob = appendSSHMPInt(privBlob, privateKey.Primes[1])
+	privBlob = appendSSHMPInt(privBlob, privateKey.Precomputed.Qinv)
+
+	const algorithm, encryption = "ssh-rsa", "none"
+
+	var macData []byte
+	macData = appendSSHString(macData, []byte(algorithm))
+	macData = appendSSHString(macData, []byte(encryption))
+	macData = appendSSHString(macData, []byte(comment))
+	macData = appendSSHString(macData, pubBlob)
+	macData = appendSSHString(macData, privBlob)
+
+	// MAC key is derived from an empty passphrase as the file is not encrypted
+	macKey := sha1.Sum([]byte("putty-private-key-file-mac-key"))
+	mac := hmac.New(sha1.New, macKey[:])
+	mac.Write(macData)
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "PuTTY-User-Key-File-2: %s\n", algorithm)
+	fmt.Fprintf(&out, "Encryption: %s\n", encryption)
+	fmt.Fprintf(&out, "Comment: %s\n", comment)
+	lines := ppkLines(pubBlob)
+	fmt.Fprintf(&out, "Public-Lines: %d\n%s\n", len(lines), strings.Join(lines, "\n"))
+	lines = ppkLines(privBlob)
+	fmt.Fprintf(&out, "Private-Lines: %d\n%s\n", len(lines), strings.Join(lines, "\n"))
+	fmt.Fprintf(&out, "Private-MAC: %s\n", hex.EncodeToString(mac.Sum(nil)))
+
+	return out.Bytes(), nil
+}
+
+// Parses a known_hosts line of the form:
+// @cert-authority <host patterns> <key type> <base64 key> [comment]
+// returning the host patterns and key.
+func parseCertAuthorityLine(line string) ([]string, ssh.PublicKey, string, error) {
+	marker, hosts, pub, comment, _, err := ssh.ParseKnownHosts([]byte(line))
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if marker != "cert-authority" {
+		return nil, nil, "", ErrWrongCertType
+	}
+	return hosts, pub, comment, nil
+}
+
+// Converts OpenSSH host patterns into a PuTTY validity expression.
+func puttyValidityExpression(hosts []string) string {
+	var positive, negative []string
+	for _, h := range hosts {
+		if strings.HasPrefix(h, "!") {
+			negative = append(negative, h[1:])
+		} else {
+			positive = append(positive, h)
+		}
+	}
+	rv := strings.Join(positive, " || ")
+	if len(negative) > 0 {
+		if len(positive) > 1 {
+			rv = "(" + rv + ")"
+		}
+		for _, n := range negative {
+			rv += " && !" + n
+		}
+	}
+	return rv
+}
+
+// Registers each CA with PuTTY so that host certificates it has signed are trusted.
+// Requires PuTTY 0.78 or later, and is a no-op except on Windows.
+func InstallPuTTYHostCAs(ctx context.Context, config *ClientAppConfiguration, caLines []string) error {
+	if runtime.GOOS != "windows" {
+		return nil
+	}
+
+	for i, line := range caLines {
+		hosts, pub, _, err := parseCertAuthorityLine(line)
+		if err != nil {
+			return err
+		}
+
+		name := config.SectionIdentifier
+		if i > 0 {
+			name = fmt.Sprintf("%s-%d", name, i)
+		}
+
+		values := [][]string{
+			{"PublicKey", "REG_SZ", base64.StdEncoding.EncodeToString(pub.Marshal())},
+			{"Validity", "REG_SZ", puttyValidityExpression(hosts)},
+			{"PermitRSASHA1", "REG_DWORD", "0"},
+			{"PermitRSASHA256", "REG_DWORD", "1"},
+			{"PermitRSASHA512", "REG_DWORD", "1"},
+		}
+		for _, v := range values {
+			err = exec.CommandContext(ctx, "reg", "add", puttyHostCAsKey+name, "/v", v[0], "/t", v[1], "/d", v[2], "/f").Run()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	logInfo("Registered certificate authorities with PuTTY.")
+	return nil
+}
@@ -0,0 +1,152 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"os"
+	"regexp"
+	"strings"
+
+	pb "github.com/continusec/geecert/sso"
+)
+
+var ErrBadRealmName = errors.New("Realm names must be letters, digits, - and _ only.")
+
+var realmName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Realm names are used in file names on the client, so are checked by both the server and client.
+func ValidRealmName(name string) bool {
+	return realmName.MatchString(name)
+}
+
+// Where a realm's key and certificate are installed: alongside the main key, with the
+// name suffixed by -realm. known_hosts and the ssh config file are shared.
+func (p *InstallPaths) forRealm(name string) *InstallPaths {
+	return &InstallPaths{
+		Key:         p.Key + "-" + name,
+		Cert:        p.Key + "-" + name + "-cert.pub",
+		KnownHosts:  p.KnownHosts,
+		SSHConfig:   p.SSHConfig,
+		KeyInConfig: p.KeyInConfig + "-" + name,
+	}
+}
+
+// Section of known_hosts and the ssh config file for a realm.
+func realmSectionIdentifier(config *ClientAppConfiguration, name string) string {
+	return config.SectionIdentifier + "-" + name
+}
+
+// Installs a certificate for a realm, as per installCerts for the main certificate.
+func installRealmCert(ctx context.Context, config *ClientAppConfiguration, privateKey *rsa.PrivateKey, ourPubKeyString string, rc *pb.RealmCertificate, serverVars map[string]string, mainPaths *InstallPaths, homePathToSSHDir string, agentOnly bool) error {
+	if !ValidRealmName(rc.Name) {
+		return ErrBadRealmName
+	}
+
+	logInfo("Installing certificate for realm %s.", rc.Name)
+
+	paths := mainPaths.forRealm(rc.Name)
+	err := writeKeyAndCert(ctx, paths, privateKey, ourPubKeyString, rc.Certificate, agentOnly)
+	if err != nil {
+		return err
+	}
+
+	err = installRealmConfig(ctx, config, rc, serverVars, mainPaths, homePathToSSHDir)
+	if err != nil {
+		return err
+	}
+
+	return AddToAgents(ctx, config, privateKey, rc.Certificate)
+}
+
+// Updates the sections of known_hosts and the ssh config file for a realm.
+func installRealmConfig(ctx context.Context, config *ClientAppConfiguration, rc *pb.RealmCertificate, serverVars map[string]string, mainPaths *InstallPaths, homePathToSSHDir string) error {
+	if !ValidRealmName(rc.Name) {
+		return ErrBadRealmName
+	}
+
+	paths := mainPaths.forRealm(rc.Name)
+	section := realmSectionIdentifier(config, rc.Name)
+	err := ReplaceSectionOfFile(ctx, section, paths.KnownHosts, knownHostsLines(rc.HostCertificateAuthorities, rc.CertificateAuthorities), 0644, "Updating known_hosts certificate authorities for realm "+rc.Name+".")
+	if err != nil {
+		return err
+	}
+
+	cnf, err := sshConfigLines(config, RenderSSHConfigBlocks(rc.ConfigBlocks), serverVars, paths, homePathToSSHDir)
+	if err != nil {
+		return err
+	}
+	return ReplaceSectionOfSSHConfig(ctx, section, paths.SSHConfig, cnf, 0644, "Updating ssh config file to use certificates for realm "+rc.Name+".")
+}
+
+// Removes the sections of realms that the server no longer certifies us for, being those
+// with a certificate installed alongside the main one that aren't in current.
+func removeStaleRealmSections(ctx context.Context, config *ClientAppConfiguration, paths *InstallPaths, current []*pb.RealmCertificate) error {
+	keep := make(map[string]bool)
+	for _, rc := range current {
+		keep[rc.Name] = true
+	}
+	for _, name := range installedRealms(config, paths) {
+		if keep[name] {
+			continue
+		}
+		section := realmSectionIdentifier(config, name)
+		err := ReplaceSectionOfFile(ctx, section, paths.KnownHosts, nil, 0644, "Removing known_hosts certificate authorities for realm "+name+".")
+		if err != nil {
+			return err
+		}
+		err = ReplaceSectionOfFile(ctx, section, paths.SSHConfig, nil, 0644, "Removing ssh config for realm "+name+".")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Names of the realms with sections in known_hosts or the ssh config file. Only sections with
+// a realm certificate installed are included, so that others whose names happen to start with
+// the section identifier are left alone.
+func installedRealms(config *ClientAppConfiguration, paths *InstallPaths) []string {
+	prefix := "# AUTOGENERATED:BEGIN:" + realmSectionIdentifier(config, "")
+	var rv []string
+	seen := make(map[string]bool)
+	for _, path := range []string{paths.KnownHosts, paths.SSHConfig} {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		lines, _ := splitLines(contents)
+		for _, line := range lines {
+			if !strings.HasPrefix(line, prefix) {
+				continue
+			}
+			name, _, _ := strings.Cut(strings.TrimPrefix(line, prefix), " ")
+			if seen[name] || !ValidRealmName(name) {
+				continue
+			}
+			seen[name] = true
+			if _, err := os.Stat(paths.forRealm(name).Cert); err == nil {
+				rv = append(rv, name)
+			}
+		}
+	}
+	return rv
+}
@@ -0,0 +1,188 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Certificates are renewed by EnsureFreshCert if they expire within this time.
+const RenewBefore = 5 * time.Minute
+
+// Returns the certificate currently installed.
+func LoadInstalledCert(config *ClientAppConfiguration) (*ssh.Certificate, error) {
+	paths, err := DefaultInstallPaths(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return loadCertFile(paths.Cert)
+}
+
+// Returns the fingerprint of the key of the certificate at path, or "" if there isn't one.
+func certFingerprint(path string) string {
+	cert, err := loadCertFile(path)
+	if err != nil {
+		return ""
+	}
+	return ssh.FingerprintSHA256(cert.Key)
+}
+
+// Returns how long the installed certificate is valid for, or 0 if it is missing or has expired.
+func installedCertRemaining(config *ClientAppConfiguration) time.Duration {
+	cert, err := LoadInstalledCert(config)
+	if err != nil {
+		return 0
+	}
+	remaining := time.Until(time.Unix(int64(cert.ValidBefore), 0))
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// Returns true if the installed certificate is missing, or expires within RenewBefore, or as
+// directed by the server.
+func CertNeedsRenewal(config *ClientAppConfiguration) bool {
+	cert, err := LoadInstalledCert(config)
+	if err != nil {
+		return true
+	}
+	return time.Unix(int64(cert.ValidBefore), 0).Before(time.Now().Add(renewBefore(config)))
+}
+
+// Fetches new certificates as per ProcessClient, unless those installed are still fresh.
+func EnsureFreshCert(ctx context.Context, config *ClientAppConfiguration) error {
+	if !CertNeedsRenewal(config) {
+		return nil
+	}
+	logInfo("Certificate is missing or about to expire, fetching a new one.")
+	return ProcessClient(ctx, config)
+}
+
+// Subcommand run by ssh via the Match exec line added by addAutoRenewMatch.
+const renewIfNeededCommandName = "renew-if-needed"
+
+// A renewal started by another ssh within this time is assumed to still be running.
+const renewLockTimeout = 2 * time.Minute
+
+// Adds a Match exec line so that ssh runs us, with flags, to renew the certificate before
+// connecting to any host that uses it. No options are set in the Match block, it is only
+// there for the side effect of the exec.
+func addAutoRenewMatch(lines []string, flags []string) ([]string, error) {
+	var hosts []string
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "Host ") {
+			hosts = append(hosts, strings.Fields(trimmed)[1:]...)
+		}
+	}
+	if len(hosts) == 0 {
+		return lines, nil
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	// ssh expands % tokens in the command, then runs it with the user's shell. Versions of ssh
+	// differ in whether they allow escaped quotes within it, so we can't pass those on.
+	command := append(append([]string{self}, flags...), renewIfNeededCommandName)
+	for _, arg := range command {
+		if strings.ContainsAny(arg, "'\"\r\n") {
+			logWarning("Not configuring ssh to renew the certificate, as it can't be run with a quote in the command line: %s", arg)
+			return lines, nil
+		}
+	}
+	var exec string
+	if runtime.GOOS == "windows" {
+		exec, err = windowsQuote(command)
+		if err != nil {
+			logWarning("Not configuring ssh to renew the certificate: %s", err)
+			return lines, nil
+		}
+	} else {
+		exec = shellQuote(command)
+	}
+	exec = strings.ReplaceAll(exec, "%", "%%")
+	match := "Match host " + strings.Join(hosts, ",") + " exec \"" + exec + "\""
+	return append([]string{match}, lines...), nil
+}
+
+// renew-if-needed
+// Quietly renews the certificate if needed. Output is only shown if we fail, as this is run by ssh.
+func renewIfNeededCommand(ctx context.Context, config *ClientAppConfiguration, args []string) error {
+	if len(args) != 0 {
+		return ErrUsage
+	}
+
+	if !CertNeedsRenewal(config) {
+		return nil
+	}
+
+	paths, err := DefaultInstallPaths(config)
+	if err != nil {
+		return err
+	}
+
+	// Avoid many parallel ssh invocations all renewing at once
+	lockPath := paths.Key + ".renewing"
+	st, err := os.Stat(lockPath)
+	if err == nil && time.Since(st.ModTime()) < renewLockTimeout {
+		return nil
+	}
+	lock, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		if !os.IsExist(err) {
+			return err
+		}
+		// Stale lock from a renewal that didn't finish
+		err = os.Remove(lockPath)
+		if err != nil {
+			return err
+		}
+		lock, err = os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+	}
+	lock.Close()
+	defer os.Remove(lockPath)
+
+	// Keep detail, as it is only shown if renewal fails
+	var output bytes.Buffer
+	prev := currentLogger()
+	SetLogger(NewConsoleLogger(&output, LogVerbose))
+	err = ProcessClient(ctx, config)
+	SetLogger(prev)
+	if err != nil {
+		os.Stderr.WriteString(output.String())
+		return err
+	}
+
+	return nil
+}
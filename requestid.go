@@ -0,0 +1,73 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	pb "github.com/continusec/geecert/sso"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	certRequestAttempts      = 3
+	certRequestRetryInterval = time.Second // doubled after each attempt
+)
+
+// Returns a new ID for a certificate request, sent again with any retries of it.
+func newRequestID() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Sends req, retrying if the server couldn't be reached or didn't respond in time. As the
+// request ID is the same, if the certificate was issued but the response lost, the server
+// returns it again rather than issuing another.
+func getSSHCertsWithRetry(ctx context.Context, client pb.GeeCertServerClient, req *pb.SSHCertsRequest) (*pb.SSHCertsResponse, error) {
+	wait := certRequestRetryInterval
+	for attempt := 1; ; attempt++ {
+		resp, err := client.GetSSHCerts(ctx, req)
+		if attempt == certRequestAttempts || !retryableError(err) {
+			return resp, err
+		}
+		logVerbose("Requesting certificates failed (%s), retrying in %s.", err, wait)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		wait *= 2
+	}
+}
+
+func retryableError(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	}
+	return false
+}
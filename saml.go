@@ -0,0 +1,119 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	pb "github.com/continusec/geecert/sso"
+)
+
+// As per GetValidIDToken, signing in with the SAML bridge at SAMLBridgeURL if the saved ID
+// token has expired. Tokens from the bridge can't be refreshed.
+func getSAMLBridgeIDToken(ctx context.Context, config *ClientAppConfiguration) (string, *IDTokenClaims, error) {
+	path, err := credentialsPath(config)
+	if err != nil {
+		return "", nil, err
+	}
+
+	creds, err := LoadCreds(path)
+	if err == nil {
+		claims, err := samlBridgeClaims(creds.IDToken, config.HostedDomain)
+		if err == nil {
+			return creds.IDToken, claims, nil
+		}
+		logVerbose("Signing in again as saved ID token is not usable: %s", err)
+	}
+
+	return samlBridgeSignIn(ctx, config, path, false)
+}
+
+// Sends the user to the SAML bridge to sign in (again if force), and exchanges the pickup
+// code it sends us for an ID token, which is saved to path.
+func samlBridgeSignIn(ctx context.Context, config *ClientAppConfiguration, path string, force bool) (string, *IDTokenClaims, error) {
+	code, _, err := browserDance(ctx, config, func(redir, state string) string {
+		params := url.Values{
+			"redirect_uri": {redir},
+			"state":        {state},
+		}
+		if force {
+			params.Set("force", "1")
+		}
+		return strings.TrimSuffix(config.SAMLBridgeURL, "/") + "/saml/login?" + params.Encode()
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	conn, err := DialServer(ctx, config)
+	if err != nil {
+		return "", nil, err
+	}
+	defer conn.Close()
+
+	resp, err := NewClient(conn).RedeemPickupCode(ctx, &pb.PickupCodeRequest{Code: code})
+	if err != nil {
+		return "", nil, err
+	}
+	if resp.Status != pb.ResponseCode_OK {
+		return "", nil, responseCodeError(resp.Status)
+	}
+
+	claims, err := samlBridgeClaims(resp.IdToken, config.HostedDomain)
+	if err != nil {
+		return "", nil, err
+	}
+	err = SaveCreds(path, &CachedCreds{IDToken: resp.IdToken})
+	if err != nil {
+		return "", nil, err
+	}
+	return resp.IdToken, claims, nil
+}
+
+// Returns the claims of an ID token from the SAML bridge. Only the server has the key to
+// check its signature, so we only check that it is current and for our domain.
+func samlBridgeClaims(idToken, hostedDomain string) (*IDTokenClaims, error) {
+	claims := unverifiedClaims(idToken)
+	if claims == nil {
+		return nil, ErrInvalidIDToken
+	}
+	exp, _ := claims["exp"].(float64)
+	expiry := time.Unix(int64(exp), 0)
+	if time.Now().After(expiry) {
+		return nil, ErrTokenExpired
+	}
+	err := checkAccountDomain(idToken, hostedDomain)
+	if err != nil {
+		return nil, err
+	}
+
+	email, _ := claims["email"].(string)
+	rv := &IDTokenClaims{
+		EmailAddress: email,
+		Expiry:       expiry,
+		Claims:       claims,
+	}
+	if authTime, ok := claims["auth_time"].(float64); ok {
+		rv.AuthTime = time.Unix(int64(authTime), 0)
+	}
+	return rv, nil
+}
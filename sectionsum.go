@@ -0,0 +1,67 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// The begin marker of each section records a checksum of the lines written, so that edits
+// made between the markers by hand are noticed before they are overwritten.
+const sectionChecksumPrefix = "sha256:"
+
+func sectionChecksum(lines []string) string {
+	h := sha256.New()
+	for _, line := range lines {
+		fmt.Fprintf(h, "%s\n", line)
+	}
+	return sectionChecksumPrefix + hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// Returns true if the lines of the section with name in contents no longer match the checksum
+// in its begin marker. Sections written before checksums were recorded can't be checked, and
+// are assumed to be unedited.
+func sectionEdited(name string, contents []byte) bool {
+	startMarker := "# AUTOGENERATED:BEGIN:" + name
+	endMarker := "# AUTOGENERATED:END:" + name
+
+	var sum string
+	var lines []string
+	include := false
+	input, _ := splitLines(contents)
+	for _, line := range input {
+		if isSectionMarker(line, startMarker) {
+			sum, lines, include = "", nil, true
+			if i := strings.LastIndex(line, " "+sectionChecksumPrefix); i >= 0 {
+				sum = line[i+1:]
+			}
+		} else if isSectionMarker(line, endMarker) {
+			if include && sum != "" && sum != sectionChecksum(lines) {
+				return true
+			}
+			include = false
+		} else if include {
+			lines = append(lines, line)
+		}
+	}
+	return false
+}
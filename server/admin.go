@@ -0,0 +1,90 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"context"
+	"log"
+
+	"github.com/continusec/geecert"
+	pb "github.com/continusec/geecert/sso"
+)
+
+// Authenticates the caller, and returns the claims only if the caller is listed in admin_users.
+func (s *SSOServer) validateAdmin(ctx context.Context, req credentialRequest) (*geecert.IDTokenClaims, error) {
+	idTokenClaims, err := s.authenticate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if listed(s.Config.AdminUsers, idTokenClaims) {
+		return idTokenClaims, nil
+	}
+
+	log.Printf("Refusing admin request from %s.\n", idTokenClaims.EmailAddress)
+	return nil, nil
+}
+
+func (s *SSOServer) LookupCert(ctx context.Context, in *pb.LookupCertRequest) (*pb.LookupCertResponse, error) {
+	admin, err := s.validateAdmin(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	if admin == nil {
+		return &pb.LookupCertResponse{
+			Status: pb.ResponseCode_NOT_AUTHORIZED,
+		}, nil
+	}
+
+	var recs []*IssuedCert
+	if in.Serial != 0 {
+		rec, err := s.Store.IssuedCertBySerial(ctx, in.Serial)
+		if err != nil {
+			return nil, err
+		}
+		if rec != nil {
+			recs = append(recs, rec)
+		}
+	} else {
+		recs, err = s.Store.IssuedCertsByFingerprint(ctx, in.Fingerprint)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	log.Printf("%s looked up serial %d fingerprint %q, found %d certificates.\n", admin.EmailAddress, in.Serial, in.Fingerprint, len(recs))
+
+	rv := &pb.LookupCertResponse{
+		Status: pb.ResponseCode_OK,
+	}
+	for _, rec := range recs {
+		rv.Certs = append(rv.Certs, &pb.IssuedCertRecord{
+			Serial:      rec.Serial,
+			Email:       rec.Email,
+			KeyId:       rec.KeyID,
+			Principals:  rec.Principals,
+			Fingerprint: rec.Fingerprint,
+			ValidAfter:  rec.ValidAfter.Unix(),
+			ValidBefore: rec.ValidBefore.Unix(),
+			DeviceId:    rec.DeviceID,
+			OnBehalfOf:  rec.OnBehalfOf,
+		})
+	}
+	return rv, nil
+}
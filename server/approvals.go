@@ -0,0 +1,269 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/continusec/geecert"
+	pb "github.com/continusec/geecert/sso"
+)
+
+const defaultApprovalTimeout = time.Hour
+
+// Returns true if any of the principals requires approval.
+func (s *SSOServer) needsApproval(principals []string) bool {
+	for _, p := range principals {
+		for _, pp := range s.Config.PrivilegedPrincipal {
+			if p == pp {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *SSOServer) approvalTimeout() time.Duration {
+	if s.Config.ApprovalTimeoutSeconds > 0 {
+		return time.Duration(s.Config.ApprovalTimeoutSeconds) * time.Second
+	}
+	return defaultApprovalTimeout
+}
+
+// Checks whether a request for privileged principals, or on behalf of another user, may go
+// ahead. If no approvalID is given a new request for approval is created. Returns OK, and the
+// approval for useApproval, only if it matches this request and has been approved.
+func (s *SSOServer) checkApproval(ctx context.Context, email, onBehalfOf, fingerprint string, principals []string, approvalID string) (pb.ResponseCode, string, *Approval, error) {
+	if len(approvalID) == 0 {
+		idBytes := make([]byte, 8)
+		_, err := rand.Read(idBytes)
+		if err != nil {
+			return 0, "", nil, err
+		}
+		a := &Approval{
+			ID:          hex.EncodeToString(idBytes),
+			Email:       email,
+			Fingerprint: fingerprint,
+			Principals:  principals,
+			State:       ApprovalPending,
+			RequestedAt: time.Now(),
+			OnBehalfOf:  onBehalfOf,
+		}
+		err = s.Store.CreateApproval(ctx, a)
+		if err != nil {
+			return 0, "", nil, err
+		}
+		log.Printf("Approval %s requested by %s.\n", a.ID, a.summary())
+		s.notifyApprovers(a)
+		if s.Slack != nil {
+			go s.Slack.postApproval(a)
+		}
+		return pb.ResponseCode_APPROVAL_PENDING, a.ID, nil, nil
+	}
+
+	a, err := s.Store.GetApproval(ctx, approvalID)
+	if err != nil {
+		return 0, "", nil, err
+	}
+	if a == nil || a.Email != email || a.OnBehalfOf != onBehalfOf || a.Fingerprint != fingerprint || strings.Join(a.Principals, ",") != strings.Join(principals, ",") {
+		log.Printf("Approval %s does not match request from %s.\n", approvalID, email)
+		return pb.ResponseCode_NOT_AUTHORIZED, "", nil, nil
+	}
+	if time.Since(a.RequestedAt) > s.approvalTimeout() {
+		log.Printf("Approval %s for %s has expired.\n", a.ID, email)
+		return pb.ResponseCode_NOT_AUTHORIZED, "", nil, nil
+	}
+
+	switch a.State {
+	case ApprovalPending:
+		return pb.ResponseCode_APPROVAL_PENDING, a.ID, nil, nil
+	case ApprovalApproved:
+		return pb.ResponseCode_OK, "", a, nil
+	}
+
+	return pb.ResponseCode_NOT_AUTHORIZED, "", nil, nil
+}
+
+// Marks an approval returned by checkApproval as used, once any other checks have passed, so
+// that the request can be refused for other reasons without spending it. Returns false if it
+// has already been used, e.g. by a request to another server.
+func (s *SSOServer) useApproval(ctx context.Context, a *Approval, email string) (bool, error) {
+	ok, err := s.Store.UpdateApprovalState(ctx, a.ID, ApprovalApproved, ApprovalUsed, a.DecidedBy)
+	if err != nil {
+		return false, err
+	}
+	if ok {
+		log.Printf("Using approval %s by %s for %s.\n", a.ID, a.DecidedBy, email)
+	}
+	return ok, nil
+}
+
+// Who requested what, for logs and notifications.
+func (a *Approval) summary() string {
+	rv := fmt.Sprintf("%s for principals %s", a.Email, strings.Join(a.Principals, ", "))
+	if len(a.OnBehalfOf) > 0 {
+		rv += " on behalf of " + a.OnBehalfOf
+	}
+	return rv
+}
+
+// Posts a message about a new request to the approval webhook, if configured.
+// The JSON is as expected by Slack incoming webhooks.
+func (s *SSOServer) notifyApprovers(a *Approval) {
+	if len(s.Config.ApprovalWebhookUrl) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"text": fmt.Sprintf("%s requests an SSH certificate. To approve, run: approve %s", a.summary(), a.ID),
+	})
+	if err != nil {
+		log.Println("Error notifying approvers:", err)
+		return
+	}
+
+	resp, err := http.Post(s.Config.ApprovalWebhookUrl, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Println("Error notifying approvers:", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		log.Println("Error notifying approvers:", resp.Status)
+	}
+}
+
+// Authenticates the caller, and returns the claims only if the caller is an approver.
+func (s *SSOServer) validateApprover(ctx context.Context, req credentialRequest) (*geecert.IDTokenClaims, error) {
+	if len(s.Config.Approvers) == 0 {
+		return s.validateAdmin(ctx, req)
+	}
+
+	idTokenClaims, err := s.authenticate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if listed(s.Config.Approvers, idTokenClaims) {
+		return idTokenClaims, nil
+	}
+
+	log.Printf("Refusing approval request from %s.\n", idTokenClaims.EmailAddress)
+	return nil, nil
+}
+
+// Returns true if claims are of an approver (or admin, if there are no approvers).
+func (s *SSOServer) isApprover(claims *geecert.IDTokenClaims) bool {
+	if len(s.Config.Approvers) == 0 {
+		return listed(s.Config.AdminUsers, claims)
+	}
+	return listed(s.Config.Approvers, claims)
+}
+
+func (s *SSOServer) ListApprovals(ctx context.Context, in *pb.ListApprovalsRequest) (*pb.ListApprovalsResponse, error) {
+	approver, err := s.validateApprover(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	if approver == nil {
+		return &pb.ListApprovalsResponse{
+			Status: pb.ResponseCode_NOT_AUTHORIZED,
+		}, nil
+	}
+
+	as, err := s.Store.PendingApprovals(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := &pb.ListApprovalsResponse{
+		Status: pb.ResponseCode_OK,
+	}
+	for _, a := range as {
+		if time.Since(a.RequestedAt) > s.approvalTimeout() {
+			continue
+		}
+		rv.Approvals = append(rv.Approvals, &pb.ApprovalRecord{
+			Id:          a.ID,
+			Email:       a.Email,
+			Principals:  a.Principals,
+			Fingerprint: a.Fingerprint,
+			RequestedAt: a.RequestedAt.Unix(),
+			OnBehalfOf:  a.OnBehalfOf,
+		})
+	}
+	return rv, nil
+}
+
+func (s *SSOServer) DecideApproval(ctx context.Context, in *pb.DecideApprovalRequest) (*pb.DecideApprovalResponse, error) {
+	approver, err := s.validateApprover(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	if approver == nil {
+		return &pb.DecideApprovalResponse{
+			Status: pb.ResponseCode_NOT_AUTHORIZED,
+		}, nil
+	}
+
+	status, err := s.decideApproval(ctx, in.ApprovalId, approver.EmailAddress, in.Approve)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.DecideApprovalResponse{
+		Status: status,
+	}, nil
+}
+
+// Approves or denies a pending approval on behalf of approver, who must already be known to be
+// an approver. Returns NOT_AUTHORIZED if it isn't pending, or is approver's own request.
+func (s *SSOServer) decideApproval(ctx context.Context, id string, approver string, approve bool) (pb.ResponseCode, error) {
+	a, err := s.Store.GetApproval(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	if a == nil || a.Email == approver {
+		log.Printf("Refusing decision on approval %q by %s.\n", id, approver)
+		return pb.ResponseCode_NOT_AUTHORIZED, nil
+	}
+
+	to := ApprovalDenied
+	if approve {
+		to = ApprovalApproved
+	}
+	ok, err := s.Store.UpdateApprovalState(ctx, a.ID, ApprovalPending, to, approver)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return pb.ResponseCode_NOT_AUTHORIZED, nil
+	}
+
+	log.Printf("Approval %s for %s %s by %s.\n", a.ID, a.summary(), to, approver)
+	return pb.ResponseCode_OK, nil
+}
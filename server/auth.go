@@ -0,0 +1,135 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/continusec/geecert"
+	pb "github.com/continusec/geecert/sso"
+)
+
+var ErrNotHandled = errors.New("Credential is not handled by this authenticator.")
+
+// A credential sent with a request, as selected by its credential_type.
+type Credential struct {
+	Type  pb.CredentialType
+	Token string // the ID token, empty for credentials sent in the request metadata
+}
+
+// Requests that carry a credential, i.e. all of those from users.
+type credentialRequest interface {
+	GetCredentialType() pb.CredentialType
+	GetIdToken() string
+}
+
+// Authenticates the caller of an RPC. Returns ErrNotHandled for credentials of a type (or
+// issuer) that it doesn't handle, so that the next authenticator in the chain is tried.
+type Authenticator interface {
+	Authenticate(ctx context.Context, cred *Credential) (*geecert.IDTokenClaims, error)
+}
+
+// Authenticators tried in order, the first to handle the credential deciding the result.
+type AuthenticatorChain []Authenticator
+
+func (c AuthenticatorChain) Authenticate(ctx context.Context, cred *Credential) (*geecert.IDTokenClaims, error) {
+	for _, a := range c {
+		claims, err := a.Authenticate(ctx, cred)
+		if err != ErrNotHandled {
+			return claims, err
+		}
+	}
+	return nil, fmt.Errorf("%w (no authenticator for %s credentials)", geecert.ErrInvalidIDToken, cred.Type)
+}
+
+// Validates ID tokens with Validator, for any of ClientIDs and the hosted domain.
+type IDTokenAuthenticator struct {
+	Validator    geecert.IDTokenValidator
+	ClientIDs    []string
+	HostedDomain string
+}
+
+func (a *IDTokenAuthenticator) Authenticate(ctx context.Context, cred *Credential) (*geecert.IDTokenClaims, error) {
+	if cred.Type != pb.CredentialType_ID_TOKEN {
+		return nil, ErrNotHandled
+	}
+	// Try each client ID in turn, as we can't tell which was used until the token is validated
+	var claims *geecert.IDTokenClaims
+	var err error
+	for _, clientID := range a.ClientIDs {
+		claims, err = a.Validator.ValidateIDToken(ctx, cred.Token, clientID, a.HostedDomain)
+		if !errors.Is(err, geecert.ErrWrongAudience) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// The chain built from the config: the SAML bridge and Kerberos if set, then ID tokens from
+// Google, or IDTokenValidator if set.
+func (s *SSOServer) DefaultAuthenticators() AuthenticatorChain {
+	var rv AuthenticatorChain
+	if s.SAMLBridge != nil {
+		rv = append(rv, s.SAMLBridge)
+	}
+	if s.Kerberos != nil {
+		rv = append(rv, s.Kerberos)
+	}
+	if s.Kubernetes != nil {
+		rv = append(rv, s.Kubernetes)
+	}
+	var v geecert.IDTokenValidator = geecert.GoogleIDTokenValidator{}
+	if s.IDTokenValidator != nil {
+		v = s.IDTokenValidator
+	}
+	return append(rv, &IDTokenAuthenticator{
+		Validator:    v,
+		ClientIDs:    append([]string{s.Config.AllowedClientIdForIdToken}, s.Config.AdditionalClientIdForIdToken...),
+		HostedDomain: s.Config.AllowedDomainForIdToken,
+	})
+}
+
+// Authenticates the caller with Authenticators, or if not set, DefaultAuthenticators. The
+// claims of ID tokens are then mapped as per identity_claim and groups_claim.
+func (s *SSOServer) authenticate(ctx context.Context, req credentialRequest) (*geecert.IDTokenClaims, error) {
+	chain := s.Authenticators
+	if chain == nil {
+		chain = s.DefaultAuthenticators()
+	}
+	cred := &Credential{
+		Type:  req.GetCredentialType(),
+		Token: req.GetIdToken(),
+	}
+	claims, err := chain.Authenticate(ctx, cred)
+	if err != nil {
+		return nil, err
+	}
+	if cred.Type == pb.CredentialType_ID_TOKEN {
+		err = s.mapClaims(claims)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return claims, nil
+}
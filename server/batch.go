@@ -0,0 +1,204 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/continusec/geecert"
+	pb "github.com/continusec/geecert/sso"
+)
+
+const (
+	defaultMaxBatchSize    = 100
+	defaultMaxCertsPerHour = 1000
+)
+
+var (
+	ErrBadBatch = errors.New("Batch requests must have a unique label, a valid public key and at least one principal for each key.")
+)
+
+var batchLabel = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// Issues certificates for each key in the batch, or none, if the caller is an automation
+// account and every key and principal is allowed. See ServerConfig.automation_account.
+func (s *SSOServer) IssueBatch(ctx context.Context, in *pb.BatchCertsRequest) (*pb.BatchCertsResponse, error) {
+	idTokenClaims, err := s.authenticate(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	identity := idTokenClaims.EmailAddress
+	acct, ok := s.Config.AutomationAccount[identity]
+	if !ok {
+		log.Printf("Refusing batch request from %s, which is not an automation account.\n", identity)
+		return &pb.BatchCertsResponse{
+			Status: pb.ResponseCode_NOT_AUTHORIZED,
+		}, nil
+	}
+
+	st, err := s.checkNetwork(ctx, idTokenClaims, nil)
+	if err != nil {
+		return nil, err
+	}
+	if st != pb.ResponseCode_OK {
+		return &pb.BatchCertsResponse{
+			Status: st,
+		}, nil
+	}
+
+	maxBatch := acct.MaxBatchSize
+	if maxBatch == 0 {
+		maxBatch = defaultMaxBatchSize
+	}
+	if len(in.Certs) == 0 || len(in.Certs) > int(maxBatch) {
+		log.Printf("Refusing batch of %d from %s, as at most %d are allowed.\n", len(in.Certs), identity, maxBatch)
+		return &pb.BatchCertsResponse{
+			Status: pb.ResponseCode_NOT_AUTHORIZED,
+		}, nil
+	}
+
+	maxPerHour := acct.MaxCertsPerHour
+	if maxPerHour == 0 {
+		maxPerHour = defaultMaxCertsPerHour
+	}
+	issued, err := s.Store.CountIssuedSince(ctx, identity, time.Now().Add(-time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	if issued+len(in.Certs) > int(maxPerHour) {
+		log.Printf("Refusing batch of %d from %s as %d have been issued in the past hour.\n", len(in.Certs), identity, issued)
+		return &pb.BatchCertsResponse{
+			Status: pb.ResponseCode_RATE_LIMITED,
+		}, nil
+	}
+
+	reason := strings.TrimSpace(in.Reason)
+	if len(reason) > maxReasonLength || strings.ContainsAny(reason, "\r\n") {
+		return nil, ErrBadReason
+	}
+
+	// Check the whole batch before signing any of it
+	keys := make([]ssh.PublicKey, len(in.Certs))
+	labels := make(map[string]bool)
+	for i, c := range in.Certs {
+		if !batchLabel.MatchString(c.Label) || labels[c.Label] || len(c.Principals) == 0 {
+			return nil, ErrBadBatch
+		}
+		labels[c.Label] = true
+		keys[i], _, _, _, err = ssh.ParseAuthorizedKey([]byte(c.PublicKey))
+		if err != nil {
+			return nil, ErrBadBatch
+		}
+		for _, p := range c.Principals {
+			if !principalAllowed(acct.AllowedPrincipal, p) {
+				log.Printf("Refusing batch from %s, as %s may not be issued certificates for %s.\n", identity, c.Label, p)
+				return &pb.BatchCertsResponse{
+					Status: pb.ResponseCode_NOT_AUTHORIZED,
+				}, nil
+			}
+		}
+		revoked, err := s.Store.IsRevoked(ctx, ssh.FingerprintSHA256(keys[i]))
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			log.Printf("Refusing batch from %s, as the key for %s is revoked.\n", identity, c.Label)
+			return &pb.BatchCertsResponse{
+				Status: pb.ResponseCode_NO_CERTS_ALLOWED,
+			}, nil
+		}
+	}
+
+	duration := time.Duration(s.Config.GenerateCertDurationSeconds) * time.Second
+	if acct.CertDurationSeconds > 0 {
+		duration = time.Duration(acct.CertDurationSeconds) * time.Second
+	}
+	userConf := &pb.ServerConfig_UserConfig{CertPermissions: acct.CertPermissions}
+	vars := configVariables(s.Config, userConf, identity)
+	exts := certExtensions(s.Config, userConf, vars, reason)
+
+	// One request ID for the batch, so that its certificates can be found together in the logs
+	base, err := newCertRequest(identity, nil, "", "", false, vars)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Issuing batch of %d certificates to %s (request %s).\n", len(in.Certs), identity, base.RequestID)
+
+	rv := &pb.BatchCertsResponse{
+		Status: pb.ResponseCode_OK,
+	}
+	var caPubKey ssh.PublicKey
+	for i, c := range in.Certs {
+		req := *base
+		req.Principals = c.Principals
+		req.Fingerprint = ssh.FingerprintSHA256(keys[i])
+		req.Label = c.Label
+		var cert string
+		cert, caPubKey, err = s.signUserCert(ctx, s.Config.CaKeyPath, "", &req, keys[i], duration, nil, exts, reason)
+		if err != nil {
+			return nil, err
+		}
+		pk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(cert))
+		if err != nil {
+			return nil, err
+		}
+		rv.Certs = append(rv.Certs, &pb.BatchCert{
+			Label:       c.Label,
+			Certificate: cert,
+			Serial:      pk.(*ssh.Certificate).Serial,
+			Fingerprint: req.Fingerprint,
+		})
+	}
+	rv.HostCertificateAuthorities = s.hostCertificateAuthorities(caPubKey)
+	rv.CertificateAuthorities = geecert.RenderHostCertificateAuthorities(rv.HostCertificateAuthorities)
+	return rv, nil
+}
+
+// Returns true if principal matches one of the patterns.
+func principalAllowed(patterns []string, principal string) bool {
+	for _, p := range patterns {
+		matched, err := filepath.Match(p, principal)
+		if err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+func validateAutomationAccount(acct *pb.ServerConfig_AutomationAccount) error {
+	if len(acct.AllowedPrincipal) == 0 {
+		return errors.New("allowed_principal must be set")
+	}
+	for _, p := range acct.AllowedPrincipal {
+		_, err := filepath.Match(p, "")
+		if err != nil {
+			return errors.New(fmt.Sprintf("allowed_principal %q: %s", p, err))
+		}
+	}
+	return nil
+}
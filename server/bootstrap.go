@@ -0,0 +1,120 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/continusec/geecert"
+)
+
+// Serves the client configuration, CA public keys and KRL under /bootstrap/, see ServerConfig.bootstrap.
+func (s *SSOServer) registerBootstrap(mux *http.ServeMux) {
+	mux.HandleFunc(geecert.BootstrapConfigPath, s.serveBootstrapConfig)
+	mux.HandleFunc(geecert.BootstrapCAKeysPath, s.serveBootstrapCAKeys)
+	mux.HandleFunc(geecert.BootstrapKRLPath, s.serveBootstrapKRL)
+}
+
+// authorized_keys lines for our CA, any parallel CAs and each realm's CA, e.g. for sshd's TrustedUserCAKeys.
+func (s *SSOServer) caAuthorizedKeys() ([]string, error) {
+	var keys [][2]string
+	for _, path := range s.caKeyPaths() {
+		keys = append(keys, [2]string{path, s.Config.CaComment})
+	}
+	for _, r := range s.Config.Realm {
+		keys = append(keys, [2]string{r.CaKeyPath, r.CaComment})
+	}
+	var rv []string
+	for _, k := range keys {
+		pk, err := caPublicKey(k[0])
+		if err != nil {
+			return nil, err
+		}
+		line := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pk)))
+		if len(k[1]) > 0 {
+			line += " " + k[1]
+		}
+		rv = append(rv, line)
+	}
+	return rv, nil
+}
+
+func (s *SSOServer) bootstrapConfig() (*geecert.BootstrapConfig, error) {
+	bc := s.Config.Bootstrap
+	caKeys, err := s.caAuthorizedKeys()
+	if err != nil {
+		return nil, err
+	}
+	rv := &geecert.BootstrapConfig{
+		GRPCServer:         bc.GrpcServer,
+		UseSystemCaForCert: len(bc.GrpcPemCertificatePath) == 0,
+		HostedDomain:       s.Config.AllowedDomainForIdToken,
+		ClientID:           s.Config.AllowedClientIdForIdToken,
+		ClientNotSoSecret:  bc.ClientNotSoSecret,
+		CredentialFileName: bc.CredentialFileName,
+		ShortlivedKeyName:  bc.ShortlivedKeyName,
+		SectionIdentifier:  bc.SectionIdentifier,
+		CAKeys:             caKeys,
+	}
+	if len(bc.GrpcPemCertificatePath) > 0 {
+		pem, err := os.ReadFile(bc.GrpcPemCertificatePath)
+		if err != nil {
+			return nil, err
+		}
+		rv.GRPCPEMCertificate = string(pem)
+	}
+	return rv, nil
+}
+
+func (s *SSOServer) serveBootstrapConfig(w http.ResponseWriter, r *http.Request) {
+	bc, err := s.bootstrapConfig()
+	if err != nil {
+		log.Println("Error serving bootstrap config:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(bc)
+}
+
+func (s *SSOServer) serveBootstrapCAKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := s.caAuthorizedKeys()
+	if err != nil {
+		log.Println("Error serving CA keys:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(strings.Join(keys, "\n") + "\n"))
+}
+
+func (s *SSOServer) serveBootstrapKRL(w http.ResponseWriter, r *http.Request) {
+	if len(s.Config.Bootstrap.KrlPath) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeFile(w, r, s.Config.Bootstrap.KrlPath)
+}
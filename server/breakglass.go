@@ -0,0 +1,217 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/continusec/geecert"
+	pb "github.com/continusec/geecert/sso"
+)
+
+const (
+	defaultBreakGlassCertDuration = time.Hour
+	breakGlassMaxClockSkew        = 5 * time.Minute
+)
+
+// An entry in break_glass_log_path.
+type breakGlassLogEntry struct {
+	Time          time.Time `json:"time"`
+	Email         string    `json:"email"`
+	Credential    string    `json:"credential"`
+	Justification string    `json:"justification"`
+	Serial        uint64    `json:"serial"`
+	Fingerprint   string    `json:"fingerprint"`
+	Principals    []string  `json:"principals"`
+}
+
+// Returns the break glass user whose credential key is credentialKey, or nil.
+func (s *SSOServer) findBreakGlassUser(credentialKey ssh.PublicKey) *pb.ServerConfig_BreakGlassUser {
+	for _, u := range s.Config.BreakGlassUser {
+		pk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(u.CredentialKey))
+		if err != nil {
+			log.Printf("Ignoring bad credential_key for break glass user %s: %s\n", u.Email, err)
+			continue
+		}
+		if bytes.Equal(pk.Marshal(), credentialKey.Marshal()) {
+			return u
+		}
+	}
+	return nil
+}
+
+// Appends the issuance to break_glass_log_path, if set.
+func (s *SSOServer) logBreakGlass(entry *breakGlassLogEntry) error {
+	if len(s.Config.BreakGlassLogPath) == 0 {
+		return nil
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.Config.BreakGlassLogPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Issues a certificate without an ID token, for use when the IdP is unavailable.
+func (s *SSOServer) BreakGlassCerts(ctx context.Context, in *pb.BreakGlassRequest) (*pb.SSHCertsResponse, error) {
+	if !s.BreakGlassEnabled {
+		log.Println("Refusing break glass request as -enable_break_glass is not set.")
+		return &pb.SSHCertsResponse{
+			Status: pb.ResponseCode_NOT_AUTHORIZED,
+		}, nil
+	}
+
+	credentialKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(in.CredentialKey))
+	if err != nil {
+		return nil, err
+	}
+	user := s.findBreakGlassUser(credentialKey)
+	if user == nil {
+		log.Printf("BREAK GLASS: Refusing request from unknown credential %s.\n", ssh.FingerprintSHA256(credentialKey))
+		return &pb.SSHCertsResponse{
+			Status: pb.ResponseCode_NOT_AUTHORIZED,
+		}, nil
+	}
+
+	skew := time.Since(time.Unix(in.Timestamp, 0))
+	if skew > breakGlassMaxClockSkew || skew < -breakGlassMaxClockSkew {
+		log.Printf("BREAK GLASS: Refusing request from %s with stale timestamp.\n", user.Email)
+		return &pb.SSHCertsResponse{
+			Status: pb.ResponseCode_NOT_AUTHORIZED,
+		}, nil
+	}
+
+	sig := &ssh.Signature{}
+	err = ssh.Unmarshal(in.Signature, sig)
+	if err != nil {
+		return nil, err
+	}
+	if sig.Format == ssh.KeyAlgoRSA {
+		log.Printf("BREAK GLASS: Refusing request from %s with SHA-1 signature.\n", user.Email)
+		return &pb.SSHCertsResponse{
+			Status: pb.ResponseCode_NOT_AUTHORIZED,
+		}, nil
+	}
+	err = credentialKey.Verify(geecert.BreakGlassSignedData(in), sig)
+	if err != nil {
+		log.Printf("BREAK GLASS: Refusing request from %s with bad signature.\n", user.Email)
+		return &pb.SSHCertsResponse{
+			Status: pb.ResponseCode_NOT_AUTHORIZED,
+		}, nil
+	}
+
+	if len(strings.TrimSpace(in.Justification)) == 0 {
+		log.Printf("BREAK GLASS: Refusing request from %s without justification.\n", user.Email)
+		return &pb.SSHCertsResponse{
+			Status: pb.ResponseCode_NOT_AUTHORIZED,
+		}, nil
+	}
+	if len(in.Justification) > maxReasonLength || strings.ContainsAny(in.Justification, "\r\n") {
+		return nil, ErrBadReason
+	}
+
+	rpk, err := base64.StdEncoding.DecodeString(in.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	keyToSign, err := ssh.ParsePublicKey(rpk)
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint := ssh.FingerprintSHA256(keyToSign)
+	for _, fp := range []string{fingerprint, ssh.FingerprintSHA256(credentialKey)} {
+		revoked, err := s.Store.IsRevoked(ctx, fp)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			log.Printf("BREAK GLASS: Refusing to sign for %s as key %s is revoked.\n", user.Email, fp)
+			return &pb.SSHCertsResponse{
+				Status: pb.ResponseCode_NO_CERTS_ALLOWED,
+			}, nil
+		}
+	}
+
+	critOpts, err := criticalOptions(ctx, sourceAddressPolicy(s.Config, nil), "")
+	if err != nil {
+		return nil, err
+	}
+
+	duration := defaultBreakGlassCertDuration
+	if s.Config.BreakGlassCertDurationSeconds > 0 {
+		duration = time.Duration(s.Config.BreakGlassCertDurationSeconds) * time.Second
+	}
+
+	userConf := &pb.ServerConfig_UserConfig{
+		Username:        user.Username,
+		ExtraPrincipals: user.ExtraPrincipals,
+	}
+	principals := append([]string{user.Username}, user.ExtraPrincipals...)
+	resp, err := s.issueUserCert(ctx, user.Email, userConf, principals, keyToSign, fingerprint, duration, critOpts, in.Justification, "", "", nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	pk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(resp.Certificate))
+	if err != nil {
+		return nil, err
+	}
+	cert, ok := pk.(*ssh.Certificate)
+	if !ok {
+		return nil, geecert.ErrWrongCertType
+	}
+
+	log.Printf("BREAK GLASS: Issued certificate %d to %s for %s. Justification: %q\n", cert.Serial, user.Email, strings.Join(principals, ","), in.Justification)
+	err = s.logBreakGlass(&breakGlassLogEntry{
+		Time:          time.Now(),
+		Email:         user.Email,
+		Credential:    ssh.FingerprintSHA256(credentialKey),
+		Justification: in.Justification,
+		Serial:        cert.Serial,
+		Fingerprint:   fingerprint,
+		Principals:    principals,
+	})
+	if err != nil {
+		// The certificate has been issued, so it must not go unrecorded
+		log.Printf("BREAK GLASS: Error writing to break_glass_log_path, withholding certificate %d: %s\n", cert.Serial, err)
+		return nil, err
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,173 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/continusec/geecert"
+)
+
+var (
+	ErrUnsupportedCAKey = errors.New("CA key must be RSA, ECDSA or Ed25519.")
+	ErrUnknownCAKeyType = errors.New("CA key type must be rsa, ecdsa or ed25519.")
+	ErrNoCAKeyOfType    = errors.New("No CA key of the requested type.")
+)
+
+// Types of key GenerateCAKey can create.
+const (
+	CAKeyTypeRSA     = "rsa"
+	CAKeyTypeECDSA   = "ecdsa"
+	CAKeyTypeEd25519 = "ed25519"
+)
+
+// Loads a CA private key, either PEM encoded (PKCS#1, SEC 1 or PKCS#8) or in the OpenSSH
+// format written by ssh-keygen.
+func LoadCAKey(path string) (ssh.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.ParsePrivateKey(data)
+	if err != nil {
+		return nil, err
+	}
+	switch signer.PublicKey().Type() {
+	case ssh.KeyAlgoRSA, ssh.KeyAlgoECDSA256, ssh.KeyAlgoECDSA384, ssh.KeyAlgoECDSA521, ssh.KeyAlgoED25519:
+		return signer, nil
+	default:
+		return nil, ErrUnsupportedCAKey
+	}
+}
+
+// Generates a CA key of keyType (one of the CAKeyType constants) and writes it to path, which
+// must not already exist, in OpenSSH format. Returns the public key.
+func GenerateCAKey(path string, keyType string, comment string) (ssh.PublicKey, error) {
+	var key crypto.Signer
+	var err error
+	switch keyType {
+	case CAKeyTypeRSA:
+		key, err = rsa.GenerateKey(rand.Reader, 4096)
+	case CAKeyTypeECDSA:
+		key, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case CAKeyTypeEd25519:
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, ErrUnknownCAKeyType
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := ssh.MarshalPrivateKey(key, comment)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, err
+	}
+	_, err = f.Write(pem.EncodeToMemory(block))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ssh.NewPublicKey(key.Public())
+}
+
+// Where InitCA suggests hosts keep the CA public key.
+const DefaultTrustedUserCAKeysPath = geecert.DefaultTrustedUserCAKeysPath
+
+// Generates a CA key of keyType at path, unless one is already there, then writes its public key,
+// fingerprints and the sshd_config lines for hosts to trust it to w.
+func InitCA(w io.Writer, path string, keyType string, comment string) error {
+	var pk ssh.PublicKey
+	signer, err := LoadCAKey(path)
+	switch {
+	case err == nil:
+		pk = signer.PublicKey()
+		fmt.Fprintf(w, "Using the existing CA key at %s.\n", path)
+	case errors.Is(err, fs.ErrNotExist):
+		pk, err = GenerateCAKey(path, keyType, comment)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Generated a new %s CA key at %s. Set ca_key_path to it in the server config, and back it up.\n", keyType, path)
+	default:
+		return err
+	}
+
+	authorizedKey := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pk)))
+	if len(comment) > 0 {
+		authorizedKey += " " + comment
+	}
+	fmt.Fprintf(w, "\nPublic key:\n%s\n", authorizedKey)
+	fmt.Fprintf(w, "\nFingerprints:\n%s\nMD5:%s\n", ssh.FingerprintSHA256(pk), ssh.FingerprintLegacyMD5(pk))
+	fmt.Fprintf(w, "\nOn each host, save the public key as %s:\n", DefaultTrustedUserCAKeysPath)
+	fmt.Fprintf(w, "echo '%s' | sudo tee %s\n", authorizedKey, DefaultTrustedUserCAKeysPath)
+	fmt.Fprintf(w, "\nthen add to /etc/ssh/sshd_config, and reload sshd:\n")
+	fmt.Fprintf(w, "TrustedUserCAKeys %s\n", DefaultTrustedUserCAKeysPath)
+	return nil
+}
+
+// Loads the public key of the CA key at path.
+func caPublicKey(path string) (ssh.PublicKey, error) {
+	signer, err := LoadCAKey(path)
+	if err != nil {
+		return nil, err
+	}
+	return signer.PublicKey(), nil
+}
+
+// Paths of ca_key_path followed by each parallel_ca_key_path.
+func (s *SSOServer) caKeyPaths() []string {
+	return append([]string{s.Config.CaKeyPath}, s.Config.ParallelCaKeyPath...)
+}
+
+// Loads the CA key for host certificates with public key type keyType (e.g. ssh-ed25519),
+// from ca_key_path or parallel_ca_key_path. If keyType is empty, ca_key_path is used.
+func (s *SSOServer) hostCAKey(keyType string) (ssh.Signer, error) {
+	for _, path := range s.caKeyPaths() {
+		signer, err := LoadCAKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if len(keyType) == 0 || signer.PublicKey().Type() == keyType {
+			return signer, nil
+		}
+	}
+	return nil, ErrNoCAKeyOfType
+}
@@ -0,0 +1,126 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	pb "github.com/continusec/geecert/sso"
+)
+
+// Revocation reason for keys revoked by checkUnexpiredCerts, so that watching clients remove
+// their certificates rather than fetch new ones, which would revoke another key in turn.
+const replacedKeyReason = "Replaced by another key, as max_unexpired_certs_per_user was reached."
+
+// Checks the user may have a certificate for the key with given fingerprint, as per
+// max_unexpired_certs_per_user. The key the client says it is replacing is revoked first
+// to make room, and then if revoke_oldest_unexpired_cert is set, the user's oldest keys.
+func (s *SSOServer) checkUnexpiredCerts(ctx context.Context, email string, fingerprint string, replaces string) (pb.ResponseCode, error) {
+	max := int(s.Config.MaxUnexpiredCertsPerUser)
+	if max == 0 {
+		return pb.ResponseCode_OK, nil
+	}
+	keys, err := s.unexpiredKeys(ctx, email, fingerprint)
+	if err != nil {
+		return pb.ResponseCode_OK, err
+	}
+	if len(keys) < max {
+		return pb.ResponseCode_OK, nil
+	}
+
+	var revoke []string
+	for i, fp := range keys {
+		if fp == replaces {
+			revoke = append(revoke, fp)
+			keys = append(keys[:i:i], keys[i+1:]...)
+			break
+		}
+	}
+	if len(keys) >= max {
+		if !s.Config.RevokeOldestUnexpiredCert {
+			log.Printf("Refusing to sign key %s for %s as they have unexpired certificates for %d keys.\n", fingerprint, email, len(keys))
+			return pb.ResponseCode_TOO_MANY_CERTS, nil
+		}
+		revoke = append(revoke, keys[:len(keys)-max+1]...)
+	}
+
+	for _, fp := range revoke {
+		err = s.Store.Revoke(ctx, fp, replacedKeyReason)
+		if err != nil {
+			return pb.ResponseCode_OK, err
+		}
+		log.Printf("Revoked key %s for %s to make room for %s.\n", fp, email, fingerprint)
+	}
+	return pb.ResponseCode_OK, nil
+}
+
+// Returns the fingerprints of the user's unrevoked keys, other than except, with unexpired
+// certificates, in the order their latest certificates were issued.
+func (s *SSOServer) unexpiredKeys(ctx context.Context, email string, except string) ([]string, error) {
+	certs, err := s.Store.IssuedCertsForUser(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	latest := make(map[string]uint64) // serial of the latest certificate for each key
+	for _, c := range certs {
+		if c.Fingerprint == except || now.After(c.ValidBefore) {
+			continue
+		}
+		if c.Serial > latest[c.Fingerprint] {
+			latest[c.Fingerprint] = c.Serial
+		}
+	}
+	var rv []string
+	for fp := range latest {
+		revoked, err := s.Store.IsRevoked(ctx, fp)
+		if err != nil {
+			return nil, err
+		}
+		if !revoked {
+			rv = append(rv, fp)
+		}
+	}
+	sort.Slice(rv, func(i, j int) bool {
+		return latest[rv[i]] < latest[rv[j]]
+	})
+	return rv, nil
+}
+
+// Returns those of the revoked fingerprints that were revoked by checkUnexpiredCerts.
+func (s *SSOServer) replacedKeys(ctx context.Context, revoked []string) ([]string, error) {
+	revs, err := s.Store.Revocations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reasons := make(map[string]string)
+	for _, r := range revs {
+		reasons[r.Fingerprint] = r.Reason
+	}
+	var rv []string
+	for _, fp := range revoked {
+		if reasons[fp] == replacedKeyReason {
+			rv = append(rv, fp)
+		}
+	}
+	return rv, nil
+}
@@ -0,0 +1,76 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/continusec/geecert"
+)
+
+// Sets the identity (EmailAddress) and Groups of the claims from identity_claim and groups_claim.
+func (s *SSOServer) mapClaims(claims *geecert.IDTokenClaims) error {
+	if len(s.Config.IdentityClaim) > 0 {
+		id, _ := claims.Claims[s.Config.IdentityClaim].(string)
+		if len(id) == 0 {
+			return fmt.Errorf("%w (no %s claim)", geecert.ErrInvalidIDToken, s.Config.IdentityClaim)
+		}
+		claims.EmailAddress = id
+	}
+	if len(s.Config.GroupsClaim) > 0 {
+		claims.Groups = claimStrings(claims.Claims[s.Config.GroupsClaim])
+	}
+	return nil
+}
+
+// Groups are usually a list, but some IdPs send a single space separated string.
+func claimStrings(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return strings.Fields(v)
+	case []interface{}:
+		var rv []string
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				rv = append(rv, s)
+			}
+		}
+		return rv
+	default:
+		return nil
+	}
+}
+
+// Returns true if the user is in list by identity, or by a group listed as "group:name".
+func listed(list []string, claims *geecert.IDTokenClaims) bool {
+	for _, entry := range list {
+		if entry == claims.EmailAddress {
+			return true
+		}
+		if name := strings.TrimPrefix(entry, "group:"); name != entry {
+			for _, g := range claims.Groups {
+				if g == name {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
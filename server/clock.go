@@ -0,0 +1,160 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+var (
+	ErrClockOffset  = errors.New("The server's clock is too far out to sign certificates.")
+	ErrBadNTPPacket = errors.New("Bad NTP response.")
+)
+
+const (
+	defaultMaxClockOffset     = time.Minute
+	defaultClockCheckInterval = time.Hour
+
+	// Seconds from 1900, when NTP time starts, to 1970
+	ntpEpochOffset = 2208988800
+)
+
+// Whether the last clock check passed, see clock_check.
+type clockState struct {
+	mu  sync.Mutex
+	err error
+}
+
+func (c *clockState) set(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = err
+}
+
+func (c *clockState) check() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Returns how far the local clock is behind that of the SNTP server at addr (host or host:port).
+func NTPOffset(ctx context.Context, addr string) (time.Duration, error) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "123")
+	}
+	conn, err := (&net.Dialer{}).DialContext(ctx, "udp", addr)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(5 * time.Second)
+	}
+	conn.SetDeadline(deadline)
+
+	req := make([]byte, 48)
+	req[0] = 0x23 // version 4, client
+	sent := time.Now()
+	binary.BigEndian.PutUint64(req[40:], ntpTimestamp(sent))
+	_, err = conn.Write(req)
+	if err != nil {
+		return 0, err
+	}
+	resp := make([]byte, 48)
+	n, err := conn.Read(resp)
+	if err != nil {
+		return 0, err
+	}
+	received := time.Now()
+
+	if n < 48 || resp[0]&0x7 != 4 || resp[1] == 0 || binary.BigEndian.Uint64(resp[24:]) != ntpTimestamp(sent) {
+		return 0, ErrBadNTPPacket
+	}
+	serverReceived := ntpTime(binary.BigEndian.Uint64(resp[32:]))
+	serverSent := ntpTime(binary.BigEndian.Uint64(resp[40:]))
+	return (serverReceived.Sub(sent) + serverSent.Sub(received)) / 2, nil
+}
+
+func ntpTimestamp(t time.Time) uint64 {
+	secs := uint64(t.Unix() + ntpEpochOffset)
+	frac := uint64(t.Nanosecond()) << 32 / 1e9
+	return secs<<32 | frac
+}
+
+func ntpTime(ts uint64) time.Time {
+	secs := int64(ts>>32) - ntpEpochOffset
+	nanos := int64((ts & 0xffffffff) * 1e9 >> 32)
+	return time.Unix(secs, nanos)
+}
+
+// Checks the server's clock against clock_check's NTP server. If it is too far out, certificates
+// are refused until a later check passes. If the NTP server can't be reached, the previous result stands.
+func (s *SSOServer) CheckClock(ctx context.Context) error {
+	cc := s.Config.ClockCheck
+	if cc == nil {
+		return nil
+	}
+	offset, err := NTPOffset(ctx, cc.NtpServer)
+	if err != nil {
+		return fmt.Errorf("checking clock against %s: %w", cc.NtpServer, err)
+	}
+	max := defaultMaxClockOffset
+	if cc.MaxOffsetSeconds != 0 {
+		max = time.Duration(cc.MaxOffsetSeconds) * time.Second
+	}
+	if offset > max || offset < -max {
+		err = fmt.Errorf("%w (%s from %s)", ErrClockOffset, offset.Round(time.Millisecond), cc.NtpServer)
+		s.clock.set(err)
+		return err
+	}
+	s.clock.set(nil)
+	return nil
+}
+
+// Runs CheckClock every clock_check interval until ctx is done, logging any problem. Call
+// CheckClock first, so that certificates aren't signed before the first check.
+func (s *SSOServer) WatchClock(ctx context.Context) {
+	cc := s.Config.ClockCheck
+	if cc == nil {
+		return
+	}
+	interval := defaultClockCheckInterval
+	if cc.IntervalSeconds != 0 {
+		interval = time.Duration(cc.IntervalSeconds) * time.Second
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+		err := s.CheckClock(ctx)
+		if err != nil {
+			log.Printf("WARNING: %s\n", err)
+		}
+	}
+}
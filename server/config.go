@@ -0,0 +1,468 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/continusec/geecert"
+	pb "github.com/continusec/geecert/sso"
+)
+
+// The newest config_version this server understands.
+const ConfigVersion = 1
+
+// Reads the server config, in protobuf text format, from path. Unknown fields are an error.
+func LoadConfig(path string) (*pb.ServerConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	conf := &pb.ServerConfig{}
+	err = proto.UnmarshalText(string(data), conf)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return conf, nil
+}
+
+// The ssh config blocks sent to a user, starting with the one for client_config_scope.
+func sshConfigBlocks(conf *pb.ServerConfig, username string) []*pb.SSHConfigBlock {
+	return scopeConfigBlocks(conf.ClientConfigScope, conf.AdditionalSshConfigurationLine, conf.SshConfigBlock, username, conf.AgentForwarding)
+}
+
+// A block for hosts in scope using the certificate, with lines added, followed by blocks.
+// If af is set, the scope block sets its agent options, preceded by a block for the hosts
+// that the agent is forwarded to anyway.
+func scopeConfigBlocks(clientConfigScope string, lines []string, blocks []*pb.SSHConfigBlock, username string, af *pb.ServerConfig_AgentForwarding) []*pb.SSHConfigBlock {
+	scope := &pb.SSHConfigBlock{
+		HostPattern:    strings.Fields(clientConfigScope),
+		User:           username,
+		UseCertificate: true,
+		Option: []*pb.SSHConfigBlock_Option{
+			{Keyword: "PasswordAuthentication", Value: "no"},
+		},
+	}
+	var rv []*pb.SSHConfigBlock
+	if af != nil {
+		if len(af.ForwardAgentHost) > 0 && !af.ForwardAgent {
+			rv = append(rv, &pb.SSHConfigBlock{
+				HostPattern: af.ForwardAgentHost,
+				Option:      []*pb.SSHConfigBlock_Option{{Keyword: "ForwardAgent", Value: "yes"}},
+			})
+		}
+		forward := "no"
+		if af.ForwardAgent {
+			forward = "yes"
+		}
+		scope.Option = append(scope.Option, &pb.SSHConfigBlock_Option{Keyword: "ForwardAgent", Value: forward})
+		if len(af.AddKeysToAgent) > 0 {
+			scope.Option = append(scope.Option, &pb.SSHConfigBlock_Option{Keyword: "AddKeysToAgent", Value: af.AddKeysToAgent})
+		}
+	}
+	for _, line := range lines {
+		scope.Option = append(scope.Option, geecert.ParseSSHConfigOption(line))
+	}
+	rv = append(rv, scope)
+	return append(rv, blocks...)
+}
+
+// Variables sent to the client to substitute in config. User specific variables take
+// precedence over those in the server config, but neither can override EMAIL or EMAIL_LOCALPART.
+func configVariables(conf *pb.ServerConfig, userConf *pb.ServerConfig_UserConfig, email string) map[string]string {
+	rv := make(map[string]string)
+	for k, v := range conf.ConfigVariables {
+		rv[k] = v
+	}
+	for k, v := range userConf.ConfigVariables {
+		rv[k] = v
+	}
+	rv["EMAIL"] = email
+	rv["EMAIL_LOCALPART"] = strings.SplitN(email, "@", 2)[0]
+	return rv
+}
+
+// Extensions for a user's certificate: cert_permissions, then cert_extensions from the server
+// and user config with config variables expanded, then the reason if reason_extension is set.
+func certExtensions(conf *pb.ServerConfig, userConf *pb.ServerConfig_UserConfig, vars map[string]string, reason string) map[string]string {
+	rv := make(map[string]string)
+	for k, v := range userConf.CertPermissions {
+		rv[k] = v
+	}
+	for k, v := range conf.CertExtensions {
+		rv[k] = geecert.ExpandConfigVariables(v, vars)
+	}
+	for k, v := range userConf.CertExtensions {
+		rv[k] = geecert.ExpandConfigVariables(v, vars)
+	}
+	if len(conf.ReasonExtension) > 0 && len(reason) > 0 {
+		rv[conf.ReasonExtension] = reason
+	}
+	return rv
+}
+
+// Custom extensions must be named name@domain so as not to clash with those defined by OpenSSH.
+func validateCertExtensions(exts map[string]string) error {
+	for k, v := range exts {
+		if !strings.Contains(k, "@") || strings.ContainsAny(k, " \t\r\n") {
+			return errors.New(fmt.Sprintf("extension name %q must be of the form name@domain", k))
+		}
+		if strings.ContainsAny(v, "\r\n") {
+			return errors.New(fmt.Sprintf("extension %q must be on a single line", k))
+		}
+	}
+	return nil
+}
+
+// Checks add_keys_to_agent is a value ssh understands: yes, no, ask, confirm, a time
+// interval such as 1h30m, or confirm followed by one.
+func validateAgentForwarding(af *pb.ServerConfig_AgentForwarding) error {
+	if af == nil || len(af.AddKeysToAgent) == 0 {
+		return nil
+	}
+	fields := strings.Fields(af.AddKeysToAgent)
+	switch {
+	case len(fields) == 1 && (fields[0] == "yes" || fields[0] == "no" || fields[0] == "ask" || fields[0] == "confirm"):
+		return nil
+	case len(fields) == 1 && sshTimeInterval.MatchString(fields[0]):
+		return nil
+	case len(fields) == 2 && fields[0] == "confirm" && sshTimeInterval.MatchString(fields[1]):
+		return nil
+	}
+	return errors.New(fmt.Sprintf("add_keys_to_agent %q must be yes, no, ask, confirm or a time interval", af.AddKeysToAgent))
+}
+
+// An ssh_config(5) time format such as 300, 10m or 1h30m.
+var sshTimeInterval = regexp.MustCompile(`^([0-9]+[sSmMhHdDwW]?)+$`)
+
+// Checks the server config for mistakes that would otherwise only show up on clients.
+func ValidateConfig(conf *pb.ServerConfig) error {
+	if conf.ConfigVersion > ConfigVersion {
+		return errors.New(fmt.Sprintf("config_version: %d is newer than this server understands (%d)", conf.ConfigVersion, ConfigVersion))
+	}
+	switch conf.CaSignatureAlgorithm {
+	case "", ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA:
+	default:
+		return errors.New(fmt.Sprintf("ca_signature_algorithm: %q must be rsa-sha2-512, rsa-sha2-256 or ssh-rsa", conf.CaSignatureAlgorithm))
+	}
+	err := validateKeyIDTemplate(conf.KeyIdTemplate)
+	if err != nil {
+		return errors.New(fmt.Sprintf("key_id_template: %s", err))
+	}
+	if conf.ClockCheck != nil && len(conf.ClockCheck.NtpServer) == 0 {
+		return errors.New("clock_check: ntp_server must be set")
+	}
+	// client_config_scope is only required by CheckConfig
+	if patterns := caHostPatterns(conf.CaHostPattern, conf.ClientConfigScope); len(patterns) > 0 {
+		err = geecert.ValidateCAHostPatterns(patterns)
+		if err != nil {
+			return errors.New(fmt.Sprintf("ca_host_pattern / client_config_scope: %s", err))
+		}
+	}
+	for i, ca := range conf.AdditionalHostCa {
+		err := geecert.ValidateHostCertificateAuthority(ca)
+		if err != nil {
+			return errors.New(fmt.Sprintf("additional_host_ca %d: %s", i, err))
+		}
+	}
+	err = validateAgentForwarding(conf.AgentForwarding)
+	if err != nil {
+		return errors.New(fmt.Sprintf("agent_forwarding: %s", err))
+	}
+	blocks := sshConfigBlocks(conf, "")
+	scopeIndex := len(blocks) - len(conf.SshConfigBlock) - 1
+	for i, b := range blocks {
+		err := geecert.ValidateSSHConfigBlock(b)
+		if err != nil {
+			if i < scopeIndex {
+				return errors.New(fmt.Sprintf("agent_forwarding: %s", err))
+			}
+			if i == scopeIndex {
+				return errors.New(fmt.Sprintf("client_config_scope / additional_ssh_configuration_line: %s", err))
+			}
+			return errors.New(fmt.Sprintf("ssh_config_block %d: %s", i-scopeIndex-1, err))
+		}
+	}
+	err = geecert.ValidateConfigVariables(conf.ConfigVariables)
+	if err != nil {
+		return errors.New(fmt.Sprintf("config_variables: %s", err))
+	}
+	for i, bp := range conf.BastionPolicy {
+		if len(bp.HostPattern) == 0 || len(bp.JumpHost) == 0 {
+			return errors.New(fmt.Sprintf("bastion_policy %d: host_pattern and jump_host must be set", i))
+		}
+	}
+	err = validateCertExtensions(conf.CertExtensions)
+	if err != nil {
+		return errors.New(fmt.Sprintf("cert_extensions: %s", err))
+	}
+	if len(conf.ReasonExtension) > 0 {
+		err = validateCertExtensions(map[string]string{conf.ReasonExtension: ""})
+		if err != nil {
+			return errors.New(fmt.Sprintf("reason_extension: %s", err))
+		}
+	}
+	if conf.Mdm != nil {
+		err = validateMDM(conf.Mdm)
+		if err != nil {
+			return errors.New(fmt.Sprintf("mdm: %s", err))
+		}
+	}
+	if conf.Slack != nil {
+		err = validateSlack(conf.Slack)
+		if err != nil {
+			return errors.New(fmt.Sprintf("slack: %s", err))
+		}
+		if len(conf.Slack.ApprovalChannel) > 0 && conf.HttpListenPort == 0 {
+			return errors.New("slack: http_listen_port must be set for approval_channel, as Slack sends button clicks over HTTP")
+		}
+	}
+	if len(conf.DeviceExtension) > 0 {
+		err = validateCertExtensions(map[string]string{conf.DeviceExtension: ""})
+		if err != nil {
+			return errors.New(fmt.Sprintf("device_extension: %s", err))
+		}
+	}
+	if len(conf.Impersonators) > 0 {
+		if len(conf.OnBehalfOfExtension) == 0 {
+			return errors.New("impersonators: on_behalf_of_extension must be set, so that such certificates can be told apart")
+		}
+		err = validateCertExtensions(map[string]string{conf.OnBehalfOfExtension: ""})
+		if err != nil {
+			return errors.New(fmt.Sprintf("on_behalf_of_extension: %s", err))
+		}
+	}
+	for i, k := range conf.AdditionalHostCaKey {
+		_, _, _, _, err = ssh.ParseAuthorizedKey([]byte(k))
+		if err != nil {
+			return errors.New(fmt.Sprintf("additional_host_ca_key %d: %s", i, err))
+		}
+	}
+	err = validateSourceAddressPolicy(conf.SourceAddress)
+	if err != nil {
+		return errors.New(fmt.Sprintf("source_address: %s", err))
+	}
+	err = validateRealms(conf)
+	if err != nil {
+		return err
+	}
+	if bc := conf.SamlBridge; bc != nil {
+		if len(bc.RootUrl) == 0 || len(bc.IdpMetadataPath) == 0 || len(bc.KeyPath) == 0 || len(bc.CertPath) == 0 {
+			return errors.New("saml_bridge: root_url, idp_metadata_path, key_path and cert_path must be set")
+		}
+		if conf.HttpListenPort == 0 {
+			return errors.New("saml_bridge: http_listen_port must be set, as the bridge is served over HTTP")
+		}
+	}
+	if kc := conf.Kerberos; kc != nil {
+		if len(kc.KeytabPath) == 0 || len(kc.Realm) == 0 {
+			return errors.New("kerberos: keytab_path and realm must be set")
+		}
+	}
+	if d := conf.ClientDirectives; d != nil && (d.RenewBeforeSeconds < 0 || d.NotifyBeforeSeconds < 0) {
+		return errors.New("client_directives: renew_before_seconds and notify_before_seconds must not be negative")
+	}
+	for i, kc := range conf.Kubernetes {
+		if len(kc.Issuer) == 0 || len(kc.Audience) == 0 {
+			return errors.New(fmt.Sprintf("kubernetes %d: issuer and audience must be set", i))
+		}
+	}
+	if bc := conf.Bootstrap; bc != nil {
+		if len(bc.GrpcServer) == 0 {
+			return errors.New("bootstrap: grpc_server must be set")
+		}
+		if conf.HttpListenPort == 0 {
+			return errors.New("bootstrap: http_listen_port must be set, as the bootstrap config is served over HTTP")
+		}
+	}
+	for id, acct := range conf.AutomationAccount {
+		err = validateAutomationAccount(acct)
+		if err != nil {
+			return errors.New(fmt.Sprintf("automation_account %s: %s", id, err))
+		}
+	}
+	for name, r := range conf.JitRole {
+		err = validateJITRole(r)
+		if err != nil {
+			return errors.New(fmt.Sprintf("jit_role %s: %s", name, err))
+		}
+	}
+	if tw := conf.TicketWebhook; tw != nil && len(tw.Url) == 0 {
+		return errors.New("ticket_webhook: url must be set")
+	}
+	for i, l := range conf.Listener {
+		err = validateListener(l)
+		if err != nil {
+			return errors.New(fmt.Sprintf("listener %d: %s", i, err))
+		}
+	}
+	err = validateSystemdListeners(conf.Listener)
+	if err != nil {
+		return errors.New(fmt.Sprintf("listener: %s", err))
+	}
+	err = validateProxyConfig(conf)
+	if err != nil {
+		return err
+	}
+	if conf.MaxMessageBytes > 0 && conf.MaxMessageBytes < minMaxMessageBytes {
+		return errors.New(fmt.Sprintf("max_message_bytes must be at least %d", minMaxMessageBytes))
+	}
+	for i, wh := range conf.WorkingHours {
+		err = validateWorkingHours(wh)
+		if err != nil {
+			return errors.New(fmt.Sprintf("working_hours %d: %s", i, err))
+		}
+	}
+	if conf.NetworkPolicy != nil {
+		err = validateNetworkPolicy(conf.NetworkPolicy, conf.GeoipPath)
+		if err != nil {
+			return errors.New(fmt.Sprintf("network_policy: %s", err))
+		}
+	}
+	if conf.StatusPage && conf.HttpListenPort == 0 {
+		return errors.New("status_page: http_listen_port must be set, as the status page is served over HTTP")
+	}
+	for i, u := range conf.BreakGlassUser {
+		if len(u.Email) == 0 || len(u.Username) == 0 {
+			return errors.New(fmt.Sprintf("break_glass_user %d: email and username must be set", i))
+		}
+		_, _, _, _, err = ssh.ParseAuthorizedKey([]byte(u.CredentialKey))
+		if err != nil {
+			return errors.New(fmt.Sprintf("break_glass_user %d: credential_key: %s", i, err))
+		}
+	}
+	for email, uc := range conf.AllowedUsers {
+		err = geecert.ValidateConfigVariables(uc.ConfigVariables)
+		if err != nil {
+			return errors.New(fmt.Sprintf("config_variables for %s: %s", email, err))
+		}
+		err = validateCertExtensions(uc.CertExtensions)
+		if err != nil {
+			return errors.New(fmt.Sprintf("cert_extensions for %s: %s", email, err))
+		}
+		err = validateSourceAddressPolicy(uc.SourceAddress)
+		if err != nil {
+			return errors.New(fmt.Sprintf("source_address for %s: %s", email, err))
+		}
+		if uc.NetworkPolicy != nil {
+			err = validateNetworkPolicy(uc.NetworkPolicy, conf.GeoipPath)
+			if err != nil {
+				return errors.New(fmt.Sprintf("network_policy for %s: %s", email, err))
+			}
+		}
+	}
+	return nil
+}
+
+// As per ValidateConfig, and also checks that required settings are present and that the files
+// the config refers to can be loaded, so that a config can be checked before deploying it.
+// Returns all the problems found, one per line.
+func CheckConfig(conf *pb.ServerConfig) error {
+	var errs []error
+	err := ValidateConfig(conf)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	check := func(field string, err error) {
+		if err != nil {
+			errs = append(errs, errors.New(fmt.Sprintf("%s: %s", field, err)))
+		}
+	}
+	required := func(field string, set bool) {
+		if !set {
+			errs = append(errs, errors.New(fmt.Sprintf("%s: must be set", field)))
+		}
+	}
+
+	required("ca_key_path", len(conf.CaKeyPath) > 0)
+	required("listen_port or listener", conf.ListenPort != 0 || len(conf.Listener) > 0)
+	required("server_cert_path", len(conf.ServerCertPath) > 0)
+	required("server_key_path", len(conf.ServerKeyPath) > 0)
+	required("allowed_client_id_for_id_token", len(conf.AllowedClientIdForIdToken) > 0)
+	required("client_config_scope", len(conf.ClientConfigScope) > 0)
+
+	if len(conf.CaKeyPath) > 0 {
+		_, err = LoadCAKey(conf.CaKeyPath)
+		check("ca_key_path", err)
+	}
+	for _, path := range conf.ParallelCaKeyPath {
+		_, err = LoadCAKey(path)
+		check("parallel_ca_key_path", err)
+	}
+	for _, r := range conf.Realm {
+		_, err = LoadCAKey(r.CaKeyPath)
+		check(fmt.Sprintf("realm %s: ca_key_path", r.Name), err)
+	}
+	if len(conf.ServerCertPath) > 0 && len(conf.ServerKeyPath) > 0 {
+		_, err = tls.LoadX509KeyPair(conf.ServerCertPath, conf.ServerKeyPath)
+		check("server_cert_path / server_key_path", err)
+	}
+	if len(conf.CaddyFilePath) > 0 {
+		_, err = os.Stat(conf.CaddyFilePath)
+		check("caddy_file_path", err)
+	}
+	if conf.SamlBridge != nil {
+		_, err = NewSAMLBridge(conf)
+		check("saml_bridge", err)
+	}
+	if conf.Kerberos != nil {
+		_, err = NewKerberosAuthenticator(conf)
+		check("kerberos", err)
+	}
+	if len(conf.Kubernetes) > 0 {
+		_, err = NewKubernetesAuthenticator(conf)
+		check("kubernetes", err)
+	}
+	if conf.Mdm != nil {
+		_, err = NewMDMClient(conf)
+		check("mdm", err)
+	}
+	if conf.Slack != nil {
+		_, err = NewSlackClient(conf)
+		check("slack", err)
+	}
+	if conf.TicketWebhook != nil {
+		_, err = NewTicketWebhookClient(conf)
+		check("ticket_webhook", err)
+	}
+	if len(conf.GeoipPath) > 0 {
+		_, err = LoadGeoIP(conf.GeoipPath)
+		check("geoip_path", err)
+	}
+	if bc := conf.Bootstrap; bc != nil {
+		if len(bc.GrpcPemCertificatePath) > 0 {
+			_, err = os.Stat(bc.GrpcPemCertificatePath)
+			check("bootstrap: grpc_pem_certificate_path", err)
+		}
+		if len(bc.KrlPath) > 0 {
+			_, err = os.Stat(bc.KrlPath)
+			check("bootstrap: krl_path", err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,192 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/continusec/geecert"
+	pb "github.com/continusec/geecert/sso"
+)
+
+var (
+	ErrBadDeviceKey  = errors.New("Bad device key.")
+	ErrUnknownDevice = errors.New("Unknown device.")
+)
+
+func (s *SSOServer) EnrollDevice(ctx context.Context, in *pb.EnrollDeviceRequest) (*pb.EnrollDeviceResponse, error) {
+	idTokenClaims, err := s.authenticate(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	email := idTokenClaims.EmailAddress
+
+	userConf, err := s.Store.LookupUser(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if userConf == nil {
+		return &pb.EnrollDeviceResponse{
+			Status: pb.ResponseCode_NO_CERTS_ALLOWED,
+		}, nil
+	}
+
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(in.DeviceKey))
+	if err != nil {
+		return nil, ErrBadDeviceKey
+	}
+	id := geecert.DeviceID(pub)
+
+	d, err := s.Store.GetDevice(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		d = &Device{
+			ID:         id,
+			Email:      email,
+			PublicKey:  strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pub))),
+			EnrolledAt: time.Now(),
+		}
+	} else if d.Email != email || !d.RevokedAt.IsZero() {
+		log.Printf("Refusing to enroll device %s for %s, as it is enrolled for %s or revoked.\n", id, email, d.Email)
+		return &pb.EnrollDeviceResponse{
+			Status: pb.ResponseCode_NOT_AUTHORIZED,
+		}, nil
+	}
+	d.Hostname = in.Hostname
+	d.Platform = in.Platform
+	d.HardwareID = in.HardwareId
+	d.SerialNumber = in.SerialNumber
+	err = s.Store.SaveDevice(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Enrolled device %s (%s, %s, hardware ID %q, serial number %q) for %s.\n", id, d.Hostname, d.Platform, d.HardwareID, d.SerialNumber, email)
+	return &pb.EnrollDeviceResponse{
+		Status:   pb.ResponseCode_OK,
+		DeviceId: id,
+	}, nil
+}
+
+func (s *SSOServer) RevokeDevice(ctx context.Context, in *pb.RevokeDeviceRequest) (*pb.RevokeDeviceResponse, error) {
+	admin, err := s.validateAdmin(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	if admin == nil {
+		return &pb.RevokeDeviceResponse{
+			Status: pb.ResponseCode_NOT_AUTHORIZED,
+		}, nil
+	}
+
+	d, err := s.Store.GetDevice(ctx, in.DeviceId)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, ErrUnknownDevice
+	}
+	if d.RevokedAt.IsZero() {
+		d.RevokedAt = time.Now()
+		d.RevokedReason = in.Reason
+		err = s.Store.SaveDevice(ctx, d)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	certs, err := s.Store.IssuedCertsForDevice(ctx, d.ID)
+	if err != nil {
+		return nil, err
+	}
+	rv := &pb.RevokeDeviceResponse{
+		Status: pb.ResponseCode_OK,
+	}
+	seen := make(map[string]bool)
+	for _, c := range certs {
+		if seen[c.Fingerprint] {
+			continue
+		}
+		seen[c.Fingerprint] = true
+		err = s.Store.Revoke(ctx, c.Fingerprint, fmt.Sprintf("Device %s revoked by %s: %s", d.ID, admin.EmailAddress, in.Reason))
+		if err != nil {
+			return nil, err
+		}
+		rv.RevokedFingerprint = append(rv.RevokedFingerprint, c.Fingerprint)
+	}
+
+	log.Printf("%s revoked device %s of %s and %d keys. Reason: %q\n", admin.EmailAddress, d.ID, d.Email, len(rv.RevokedFingerprint), in.Reason)
+	return rv, nil
+}
+
+// Checks that a request with a device ID is from that enrolled device of the user's, as proven
+// by the signature over the key to be certified, and that there is one if require_device or mdm
+// is set. With mdm, also checks that the MDM trusts the device. Returns the device ID, if any.
+func (s *SSOServer) checkDevice(ctx context.Context, email string, in *pb.SSHCertsRequest, keyToSign ssh.PublicKey) (string, pb.ResponseCode, error) {
+	if len(in.DeviceId) == 0 {
+		if s.Config.RequireDevice || s.MDM != nil {
+			log.Printf("Refusing to issue certificate to %s from an unenrolled device.\n", email)
+			return "", pb.ResponseCode_DEVICE_NOT_ENROLLED, nil
+		}
+		return "", pb.ResponseCode_OK, nil
+	}
+
+	d, err := s.Store.GetDevice(ctx, in.DeviceId)
+	if err != nil {
+		return "", pb.ResponseCode_OK, err
+	}
+	if d == nil || d.Email != email {
+		log.Printf("Refusing to issue certificate to %s from device %s, not enrolled for them.\n", email, in.DeviceId)
+		return "", pb.ResponseCode_DEVICE_NOT_ENROLLED, nil
+	}
+	if !d.RevokedAt.IsZero() {
+		log.Printf("Refusing to issue certificate to %s from revoked device %s.\n", email, d.ID)
+		return "", pb.ResponseCode_NO_CERTS_ALLOWED, nil
+	}
+	deviceKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(d.PublicKey))
+	if err != nil {
+		return "", pb.ResponseCode_OK, err
+	}
+	err = geecert.VerifyDeviceSignature(deviceKey, keyToSign, in.DeviceSignature)
+	if err != nil {
+		log.Printf("Refusing to issue certificate to %s from device %s: %s\n", email, d.ID, err)
+		return "", pb.ResponseCode_NOT_AUTHORIZED, nil
+	}
+
+	if s.MDM != nil {
+		trusted, detail, err := s.MDM.CheckDevice(ctx, d.SerialNumber)
+		if err != nil {
+			return "", pb.ResponseCode_OK, fmt.Errorf("checking device %s with MDM: %w", d.ID, err)
+		}
+		if !trusted {
+			log.Printf("Refusing to issue certificate to %s from device %s (serial number %q): %s.\n", email, d.ID, d.SerialNumber, detail)
+			return "", pb.ResponseCode_DEVICE_NOT_COMPLIANT, nil
+		}
+	}
+	return d.ID, pb.ResponseCode_OK, nil
+}
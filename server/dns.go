@@ -0,0 +1,131 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/miekg/dns"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/continusec/geecert"
+	pb "github.com/continusec/geecert/sso"
+)
+
+const dnsRecordTTL = 3600
+
+var (
+	ErrNoDNSRecordName = errors.New("dns_record_name must be set in the server config.")
+	ErrNoDNSUpdateConf = errors.New("dns_zone and dns_update_server must be set in the server config.")
+)
+
+// Algorithm numbers from RFC 4255, RFC 6594 and RFC 7479
+func sshfpAlgorithm(pub ssh.PublicKey) uint8 {
+	switch pub.Type() {
+	case ssh.KeyAlgoRSA:
+		return 1
+	case ssh.KeyAlgoDSA:
+		return 2
+	case ssh.KeyAlgoECDSA256, ssh.KeyAlgoECDSA384, ssh.KeyAlgoECDSA521:
+		return 3
+	case ssh.KeyAlgoED25519:
+		return 4
+	default:
+		return 0
+	}
+}
+
+// TXT records are made of strings of at most 255 bytes, readers concatenate them.
+func splitTXT(s string) []string {
+	var rv []string
+	for len(s) > 255 {
+		rv = append(rv, s[:255])
+		s = s[255:]
+	}
+	return append(rv, s)
+}
+
+// Returns an SSHFP record with the SHA256 fingerprint of the CA key, and a TXT record
+// containing the line that clients should add to their known_hosts file.
+func CADNSRecords(conf *pb.ServerConfig) ([]dns.RR, error) {
+	if conf.DnsRecordName == "" {
+		return nil, ErrNoDNSRecordName
+	}
+
+	caPubKey, err := caPublicKey(conf.CaKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	name := dns.Fqdn(conf.DnsRecordName)
+	sum := sha256.Sum256(caPubKey.Marshal())
+
+	return []dns.RR{
+		&dns.SSHFP{
+			Hdr:         dns.RR_Header{Name: name, Rrtype: dns.TypeSSHFP, Class: dns.ClassINET, Ttl: dnsRecordTTL},
+			Algorithm:   sshfpAlgorithm(caPubKey),
+			Type:        2, // SHA-256
+			FingerPrint: hex.EncodeToString(sum[:]),
+		},
+		&dns.TXT{
+			Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: dnsRecordTTL},
+			Txt: splitTXT(geecert.RenderHostCertificateAuthority(hostCA(caHostPatterns(conf.CaHostPattern, conf.ClientConfigScope), caPubKey, conf.CaComment))),
+		},
+	}, nil
+}
+
+// Replaces the CA records in DNS using an RFC 2136 dynamic update.
+func UpdateCADNSRecords(ctx context.Context, conf *pb.ServerConfig) error {
+	if conf.DnsZone == "" || conf.DnsUpdateServer == "" {
+		return ErrNoDNSUpdateConf
+	}
+
+	rrs, err := CADNSRecords(conf)
+	if err != nil {
+		return err
+	}
+
+	m := new(dns.Msg)
+	m.SetUpdate(dns.Fqdn(conf.DnsZone))
+	m.RemoveRRset(rrs)
+	m.Insert(rrs)
+
+	c := new(dns.Client)
+	if conf.DnsTsigKeyName != "" {
+		keyName := dns.Fqdn(conf.DnsTsigKeyName)
+		c.TsigSecret = map[string]string{keyName: conf.DnsTsigSecret}
+		m.SetTsig(keyName, dns.HmacSHA256, 300, time.Now().Unix())
+	}
+
+	r, _, err := c.ExchangeContext(ctx, m, conf.DnsUpdateServer)
+	if err != nil {
+		return err
+	}
+	if r.Rcode != dns.RcodeSuccess {
+		return errors.New("DNS update failed: " + dns.RcodeToString[r.Rcode])
+	}
+
+	log.Printf("Updated DNS records for %s.\n", dns.Fqdn(conf.DnsRecordName))
+	return nil
+}
@@ -0,0 +1,186 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	pb "github.com/continusec/geecert/sso"
+)
+
+// Explains what GetSSHCerts would issue to the caller, following the same checks in the same
+// order, but stopping short of anything that depends on the key or device.
+func (s *SSOServer) ExplainPolicy(ctx context.Context, in *pb.ExplainPolicyRequest) (*pb.ExplainPolicyResponse, error) {
+	idTokenClaims, err := s.authenticate(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := &pb.ExplainPolicyResponse{
+		Status:  pb.ResponseCode_OK,
+		Outcome: pb.ResponseCode_OK,
+		Email:   idTokenClaims.EmailAddress,
+		Groups:  idTokenClaims.Groups,
+	}
+	rule := func(format string, a ...interface{}) {
+		rv.Rules = append(rv.Rules, fmt.Sprintf(format, a...))
+	}
+	refuse := func(st pb.ResponseCode, format string, a ...interface{}) {
+		if rv.Outcome == pb.ResponseCode_OK {
+			rv.Outcome = st
+		}
+		rule(format, a...)
+	}
+
+	onBehalfOf := strings.TrimSpace(in.OnBehalfOf)
+	if len(onBehalfOf) > 0 {
+		if !listed(s.Config.Impersonators, idTokenClaims) || onBehalfOf == idTokenClaims.EmailAddress {
+			log.Printf("Refusing to explain policy for %s on behalf of %s.\n", idTokenClaims.EmailAddress, onBehalfOf)
+			return &pb.ExplainPolicyResponse{
+				Status: pb.ResponseCode_NOT_AUTHORIZED,
+			}, nil
+		}
+		rv.Email = onBehalfOf
+		rule("impersonators: %s may request certificates on behalf of %s, with a reason and approval", idTokenClaims.EmailAddress, onBehalfOf)
+	}
+
+	userConf, err := s.Store.LookupUser(ctx, rv.Email)
+	if err != nil {
+		return nil, err
+	}
+	if userConf == nil {
+		refuse(pb.ResponseCode_NO_CERTS_ALLOWED, "user: %s is not configured", rv.Email)
+		return rv, nil
+	}
+	rule("user: %s has username %s", rv.Email, userConf.Username)
+	if len(userConf.ExtraPrincipals) > 0 {
+		rule("user: extra_principals %s", strings.Join(userConf.ExtraPrincipals, ", "))
+	}
+
+	if np := s.networkPolicy(idTokenClaims, userConf); np != nil {
+		ip, err := peerIP(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var country string
+		if s.GeoIP != nil {
+			country = s.GeoIP.Country(ip)
+		}
+		if why := networkRefusal(np, ip, country); len(why) > 0 {
+			refuse(pb.ResponseCode_NETWORK_NOT_ALLOWED, "network_policy: refuses %s (country %q): %s", ip, country, why)
+		} else {
+			rule("network_policy: allows %s (country %q)", ip, country)
+		}
+	} else if listed(s.Config.NetworkPolicyExempt, idTokenClaims) {
+		rule("network_policy_exempt: %s is exempt from network_policy", idTokenClaims.EmailAddress)
+	}
+
+	maxAuthAge := s.Config.MaxAuthAgeSeconds
+	if userConf.MaxAuthAgeSeconds > 0 {
+		maxAuthAge = userConf.MaxAuthAgeSeconds
+	}
+	if maxAuthAge > 0 {
+		if time.Since(idTokenClaims.AuthTime) > time.Duration(maxAuthAge)*time.Second {
+			refuse(pb.ResponseCode_REAUTH_REQUIRED, "max_auth_age_seconds: %d, and you must authenticate again", maxAuthAge)
+		} else {
+			rule("max_auth_age_seconds: %d, and you authenticated at %s", maxAuthAge, idTokenClaims.AuthTime.Format(time.RFC3339))
+		}
+	}
+
+	if s.Config.RequireReason || len(onBehalfOf) > 0 {
+		refuse(pb.ResponseCode_REASON_REQUIRED, "require_reason: requests must give a reason")
+	}
+
+	policy := sourceAddressPolicy(s.Config, userConf)
+	rv.CriticalOptions, err = criticalOptions(ctx, policy, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(rv.CriticalOptions) > 0 {
+		rule("source_address: %q restricts the certificate to %s", policy, rv.CriticalOptions["source-address"])
+	}
+
+	rv.Principals = append([]string{userConf.Username}, userConf.ExtraPrincipals...)
+	duration := time.Duration(s.Config.GenerateCertDurationSeconds) * time.Second
+	rule("generate_cert_duration_seconds: %d", s.Config.GenerateCertDurationSeconds)
+	roleApproval := false
+	if len(in.Role) > 0 {
+		role := s.Config.JitRole[in.Role]
+		if role == nil || (len(role.Allowed) > 0 && !listed(role.Allowed, idTokenClaims)) {
+			refuse(pb.ResponseCode_NOT_AUTHORIZED, "jit_role: %s doesn't exist, or you may not request it", in.Role)
+		} else {
+			rv.Principals = addPrincipals(rv.Principals, role.Principals)
+			duration = time.Duration(role.MaxDurationSeconds) * time.Second
+			roleApproval = role.RequireApproval
+			rule("jit_role: %s adds %s, for up to %d seconds, with a ticket", in.Role, strings.Join(role.Principals, ", "), role.MaxDurationSeconds)
+		}
+	}
+	rv.ValiditySeconds = int64(duration / time.Second)
+	rv.ValidAfterSkewSeconds = int64(s.Config.ValidAfterSkewSeconds)
+
+	for _, p := range rv.Principals {
+		for _, pp := range s.Config.PrivilegedPrincipal {
+			if p == pp {
+				refuse(pb.ResponseCode_APPROVAL_PENDING, "privileged_principal: %s requires approval", p)
+			}
+		}
+	}
+	if len(onBehalfOf) > 0 {
+		refuse(pb.ResponseCode_APPROVAL_PENDING, "impersonators: requests on behalf of another user require approval")
+	}
+	if roleApproval {
+		refuse(pb.ResponseCode_APPROVAL_PENDING, "jit_role: %s requires approval", in.Role)
+	}
+	if s.outsideWorkingHours(idTokenClaims, rv.Principals, time.Now()) {
+		refuse(pb.ResponseCode_APPROVAL_PENDING, "working_hours: it is outside working hours, so approval is required")
+	}
+
+	if s.Config.RequireDevice || s.MDM != nil {
+		rule("require_device: certificates are only issued to enrolled devices")
+	}
+	if s.Config.MaxUnexpiredCertsPerUser > 0 {
+		rule("max_unexpired_certs_per_user: %d", s.Config.MaxUnexpiredCertsPerUser)
+	}
+
+	vars := configVariables(s.Config, userConf, rv.Email)
+	rv.Extensions = certExtensions(s.Config, userConf, vars, "")
+	if len(onBehalfOf) > 0 {
+		rv.Extensions[s.Config.OnBehalfOfExtension] = onBehalfOf
+	}
+	var names []string
+	for k := range rv.Extensions {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	if len(names) > 0 {
+		rule("cert_permissions and cert_extensions: %s", strings.Join(names, ", "))
+	}
+
+	for _, name := range userConf.Realm {
+		if findRealm(s.Config, name) != nil {
+			rule("realm: %s also issues a certificate", name)
+		}
+	}
+	return rv, nil
+}
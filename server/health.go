@@ -0,0 +1,95 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// How long a health result is reused for, and how often watchers are checked, so that many
+// clients watching don't each ping the store.
+const healthInterval = 10 * time.Second
+
+// The gRPC health checking protocol, serving when /status is healthy, so that clients with
+// several servers and load balancers can skip a server that can't issue.
+type healthServer struct {
+	healthpb.UnimplementedHealthServer
+	s *SSOServer
+
+	mu      sync.Mutex
+	checked time.Time
+	serving bool
+}
+
+func (h *healthServer) servingStatus(ctx context.Context) healthpb.HealthCheckResponse_ServingStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if time.Since(h.checked) >= healthInterval {
+		h.serving = h.s.status(ctx).Healthy
+		h.checked = time.Now()
+	}
+	if h.serving {
+		return healthpb.HealthCheckResponse_SERVING
+	}
+	return healthpb.HealthCheckResponse_NOT_SERVING
+}
+
+// The overall server, or either version of the service, which are always served together.
+func knownHealthService(name string) bool {
+	switch name {
+	case "", "GeeCertServer", "GeeCertServerV2":
+		return true
+	}
+	return false
+}
+
+func (h *healthServer) Check(ctx context.Context, in *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if !knownHealthService(in.Service) {
+		return nil, status.Error(codes.NotFound, "Unknown service.")
+	}
+	return &healthpb.HealthCheckResponse{Status: h.servingStatus(ctx)}, nil
+}
+
+func (h *healthServer) Watch(in *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	if !knownHealthService(in.Service) {
+		return stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVICE_UNKNOWN})
+	}
+	var last healthpb.HealthCheckResponse_ServingStatus
+	for {
+		st := h.servingStatus(stream.Context())
+		if st != last {
+			err := stream.Send(&healthpb.HealthCheckResponse{Status: st})
+			if err != nil {
+				return err
+			}
+			last = st
+		}
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-time.After(healthInterval):
+		}
+	}
+}
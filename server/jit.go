@@ -0,0 +1,215 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/continusec/geecert"
+	pb "github.com/continusec/geecert/sso"
+)
+
+const (
+	maxTicketLength      = 128
+	defaultTicketTimeout = 10 * time.Second
+)
+
+// A role granted for an AccessRequest, once checked.
+type jitGrant struct {
+	Role       string
+	Ticket     string
+	Principals []string // of the role, to add to the user's
+	Duration   time.Duration
+	Approval   bool // if the role requires approval
+}
+
+// Checks tickets for access requests with a webhook, see ServerConfig.TicketWebhook.
+type TicketWebhookClient struct {
+	conf  *pb.ServerConfig_TicketWebhook
+	token string
+}
+
+func NewTicketWebhookClient(conf *pb.ServerConfig) (*TicketWebhookClient, error) {
+	tc := conf.TicketWebhook
+	rv := &TicketWebhookClient{conf: tc}
+	if len(tc.AuthTokenPath) > 0 {
+		b, err := os.ReadFile(tc.AuthTokenPath)
+		if err != nil {
+			return nil, fmt.Errorf("ticket_webhook auth_token_path: %w", err)
+		}
+		rv.token = strings.TrimSpace(string(b))
+	}
+	return rv, nil
+}
+
+func validateJITRole(r *pb.ServerConfig_JITRole) error {
+	if len(r.Principals) == 0 {
+		return errors.New("principals must be set")
+	}
+	if r.MaxDurationSeconds == 0 {
+		return errors.New("max_duration_seconds must be set")
+	}
+	if len(r.TicketPattern) > 0 {
+		_, err := regexp.Compile(r.TicketPattern)
+		if err != nil {
+			return errors.New(fmt.Sprintf("ticket_pattern: %s", err))
+		}
+	}
+	return nil
+}
+
+// Checks an access request from claims against jit_role and the ticket webhook, if any.
+// Returns the grant only if status is OK. justification defaults to the request's reason.
+func (s *SSOServer) checkAccessRequest(ctx context.Context, claims *geecert.IDTokenClaims, ar *pb.AccessRequest, reason string) (*jitGrant, pb.ResponseCode, error) {
+	email := claims.EmailAddress
+	role := s.Config.JitRole[ar.Role]
+	if role == nil || (len(role.Allowed) > 0 && !listed(role.Allowed, claims)) {
+		log.Printf("Refusing access request from %s for role %q.\n", email, ar.Role)
+		return nil, pb.ResponseCode_NOT_AUTHORIZED, nil
+	}
+
+	duration := time.Duration(role.MaxDurationSeconds) * time.Second
+	if ar.DurationSeconds < 0 || ar.DurationSeconds > int32(role.MaxDurationSeconds) {
+		log.Printf("Refusing access request from %s for role %s for %d seconds.\n", email, ar.Role, ar.DurationSeconds)
+		return nil, pb.ResponseCode_NOT_AUTHORIZED, nil
+	}
+	if ar.DurationSeconds > 0 {
+		duration = time.Duration(ar.DurationSeconds) * time.Second
+	}
+
+	ticket := strings.TrimSpace(ar.Ticket)
+	if len(ticket) == 0 || len(ticket) > maxTicketLength || strings.IndexFunc(ticket, unicode.IsControl) != -1 {
+		log.Printf("Refusing access request from %s for role %s without a valid ticket.\n", email, ar.Role)
+		return nil, pb.ResponseCode_TICKET_REJECTED, nil
+	}
+	if len(role.TicketPattern) > 0 && !regexp.MustCompile(role.TicketPattern).MatchString(ticket) {
+		log.Printf("Refusing access request from %s for role %s as ticket %q doesn't match ticket_pattern.\n", email, ar.Role, ticket)
+		return nil, pb.ResponseCode_TICKET_REJECTED, nil
+	}
+
+	justification := strings.TrimSpace(ar.Justification)
+	if len(justification) == 0 {
+		justification = reason
+	}
+	if len(justification) > maxReasonLength || strings.ContainsAny(justification, "\r\n") {
+		return nil, 0, ErrBadReason
+	}
+
+	grant := &jitGrant{
+		Role:       ar.Role,
+		Ticket:     ticket,
+		Principals: role.Principals,
+		Duration:   duration,
+		Approval:   role.RequireApproval,
+	}
+	if s.Tickets != nil {
+		allow, msg, err := s.Tickets.check(ctx, email, grant, justification)
+		if err != nil {
+			return nil, 0, fmt.Errorf("checking ticket %s: %w", ticket, err)
+		}
+		if !allow {
+			log.Printf("Ticket webhook refused access request from %s for role %s with ticket %s: %s\n", email, ar.Role, ticket, msg)
+			return nil, pb.ResponseCode_TICKET_REJECTED, nil
+		}
+	}
+	return grant, pb.ResponseCode_OK, nil
+}
+
+// Returns principals with any of extra not already in it appended.
+func addPrincipals(principals []string, extra []string) []string {
+	rv := append([]string(nil), principals...)
+	for _, p := range extra {
+		found := false
+		for _, q := range rv {
+			if p == q {
+				found = true
+				break
+			}
+		}
+		if !found {
+			rv = append(rv, p)
+		}
+	}
+	return rv
+}
+
+// Asks the webhook whether the grant may be made, and if not, why not.
+func (c *TicketWebhookClient) check(ctx context.Context, email string, grant *jitGrant, justification string) (bool, string, error) {
+	timeout := defaultTicketTimeout
+	if c.conf.TimeoutSeconds > 0 {
+		timeout = time.Duration(c.conf.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	body, err := json.Marshal(map[string]interface{}{
+		"email":            email,
+		"role":             grant.Role,
+		"principals":       grant.Principals,
+		"ticket":           grant.Ticket,
+		"justification":    justification,
+		"duration_seconds": int64(grant.Duration / time.Second),
+	})
+	if err != nil {
+		return false, "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.conf.Url, bytes.NewReader(body))
+	if err != nil {
+		return false, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(c.token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, "", err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return false, "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, "", fmt.Errorf("ticket webhook: %s", resp.Status)
+	}
+
+	var rv struct {
+		Allow   bool   `json:"allow"`
+		Message string `json:"message"`
+	}
+	err = json.Unmarshal(b, &rv)
+	if err != nil {
+		return false, "", err
+	}
+	return rv.Allow, rv.Message, nil
+}
@@ -0,0 +1,137 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jcmturner/gokrb5/v8/keytab"
+	"github.com/jcmturner/gokrb5/v8/service"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/continusec/geecert"
+	pb "github.com/continusec/geecert/sso"
+)
+
+var (
+	ErrBadKerberosToken = errors.New("Kerberos token is not valid.")
+	ErrKerberosRealm    = errors.New("Kerberos principal is not a user in the configured realm.")
+)
+
+// Authenticates users by the Kerberos ticket sent with a request, see ServerConfig.Kerberos.
+type KerberosAuthenticator struct {
+	conf     *pb.ServerConfig_Kerberos
+	settings *service.Settings
+}
+
+// Loads the keytab for the kerberos config in conf.
+func NewKerberosAuthenticator(conf *pb.ServerConfig) (*KerberosAuthenticator, error) {
+	kc := conf.Kerberos
+	kt, err := keytab.Load(kc.KeytabPath)
+	if err != nil {
+		return nil, fmt.Errorf("kerberos keytab_path: %w", err)
+	}
+	opts := []func(*service.Settings){service.DecodePAC(false)}
+	if len(kc.ServicePrincipal) > 0 {
+		opts = append(opts, service.KeytabPrincipal(kc.ServicePrincipal))
+	}
+	return &KerberosAuthenticator{
+		conf:     kc,
+		settings: service.NewSettings(kt, opts...),
+	}, nil
+}
+
+// Returns the SPNEGO token sent with the request, or nil if none.
+func negotiateToken(ctx context.Context) ([]byte, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, nil
+	}
+	for _, v := range md.Get(geecert.NegotiateMetadata) {
+		if tok := strings.TrimPrefix(v, geecert.NegotiatePrefix); tok != v {
+			return base64.StdEncoding.DecodeString(tok)
+		}
+	}
+	return nil, nil
+}
+
+// Authenticates KERBEROS credentials, by the ticket sent in the request metadata.
+func (k *KerberosAuthenticator) Authenticate(ctx context.Context, cred *Credential) (*geecert.IDTokenClaims, error) {
+	if cred.Type != pb.CredentialType_KERBEROS {
+		return nil, ErrNotHandled
+	}
+	b, err := negotiateToken(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w (%w)", ErrBadKerberosToken, err)
+	}
+	if b == nil {
+		return nil, fmt.Errorf("%w (none sent)", ErrBadKerberosToken)
+	}
+
+	var st spnego.SPNEGOToken
+	err = st.Unmarshal(b)
+	if err != nil {
+		return nil, fmt.Errorf("%w (%w)", ErrBadKerberosToken, err)
+	}
+	if !st.Init {
+		return nil, ErrBadKerberosToken
+	}
+	var kt spnego.KRB5Token
+	err = kt.Unmarshal(st.NegTokenInit.MechTokenBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w (%w)", ErrBadKerberosToken, err)
+	}
+	ok, creds, err := service.VerifyAPREQ(&kt.APReq, k.settings)
+	if err != nil {
+		return nil, fmt.Errorf("%w (%w)", ErrBadKerberosToken, err)
+	}
+	if !ok {
+		return nil, ErrBadKerberosToken
+	}
+
+	identity, err := k.identity(creds.UserName(), creds.Realm())
+	if err != nil {
+		return nil, err
+	}
+	return &geecert.IDTokenClaims{
+		EmailAddress: identity,
+		AuthTime:     kt.APReq.Ticket.DecryptedEncPart.AuthTime,
+		Expiry:       creds.ValidUntil(),
+		Claims: map[string]interface{}{
+			"principal": creds.UserName() + "@" + creds.Realm(),
+		},
+	}, nil
+}
+
+// Maps a principal to the identity used in allowed_users etc. Only users (with a single
+// component name, e.g. not host/name) in the configured realm are accepted.
+func (k *KerberosAuthenticator) identity(user, realm string) (string, error) {
+	if realm != k.conf.Realm || len(user) == 0 || strings.ContainsAny(user, "/@") {
+		return "", fmt.Errorf("%w (%s@%s)", ErrKerberosRealm, user, realm)
+	}
+	if len(k.conf.IdentityDomain) > 0 {
+		return user + "@" + k.conf.IdentityDomain, nil
+	}
+	return user + "@" + realm, nil
+}
@@ -0,0 +1,139 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/continusec/geecert"
+)
+
+// Key ID profiles, see key_id_template.
+const (
+	KeyIDProfileStandard   = "standard"
+	KeyIDProfileBreakGlass = "break-glass"
+	KeyIDProfileBatch      = "batch"
+	KeyIDProfileOnBehalfOf = "on-behalf-of"
+	KeyIDProfileJIT        = "jit"
+)
+
+// A request for user certificates, with what is needed for their key IDs.
+type certRequest struct {
+	Email       string
+	Principals  []string
+	Fingerprint string
+	DeviceID    string
+	BreakGlass  bool
+	Label       string // of the key, for batch certificates, see IssueBatch
+	OnBehalfOf  string // user Email is acting as, see impersonators
+	Role        string // jit_role granted, if any
+	Ticket      string // for Role
+	RequestID   string
+	Vars        map[string]string
+}
+
+func newCertRequest(email string, principals []string, fingerprint string, deviceID string, breakGlass bool, vars map[string]string) (*certRequest, error) {
+	b := make([]byte, 8)
+	_, err := rand.Read(b)
+	if err != nil {
+		return nil, err
+	}
+	return &certRequest{
+		Email:       email,
+		Principals:  principals,
+		Fingerprint: fingerprint,
+		DeviceID:    deviceID,
+		BreakGlass:  breakGlass,
+		RequestID:   hex.EncodeToString(b),
+		Vars:        vars,
+	}, nil
+}
+
+// Who the certificate is for, in logs and the default key ID.
+func (r *certRequest) label() string {
+	if r.BreakGlass {
+		return "break-glass: " + r.Email
+	}
+	if len(r.Label) > 0 {
+		return "batch: " + r.Email + "/" + r.Label
+	}
+	rv := r.Email
+	if len(r.OnBehalfOf) > 0 {
+		rv += " on behalf of " + r.OnBehalfOf
+	}
+	if len(r.Role) > 0 {
+		rv += " as " + r.Role + " for " + r.Ticket
+	}
+	return rv
+}
+
+func (r *certRequest) profile() string {
+	if r.BreakGlass {
+		return KeyIDProfileBreakGlass
+	}
+	if len(r.Label) > 0 {
+		return KeyIDProfileBatch
+	}
+	if len(r.OnBehalfOf) > 0 {
+		return KeyIDProfileOnBehalfOf
+	}
+	if len(r.Role) > 0 {
+		return KeyIDProfileJIT
+	}
+	return KeyIDProfileStandard
+}
+
+// Key ID for a certificate issued for r by realm (empty for ca_key_path) with serial.
+func certKeyID(template string, r *certRequest, realm string, serial uint64) string {
+	if len(template) == 0 {
+		return userCertKeyID(r.Principals, r.label())
+	}
+	vars := make(map[string]string)
+	for k, v := range r.Vars {
+		vars[k] = v
+	}
+	vars["PRINCIPALS"] = strings.Join(r.Principals, ",")
+	vars["SERIAL"] = strconv.FormatUint(serial, 10)
+	vars["PROFILE"] = r.profile()
+	vars["REALM"] = realm
+	vars["FINGERPRINT"] = r.Fingerprint
+	vars["REQUEST_ID"] = r.RequestID
+	vars["DEVICE_ID"] = r.DeviceID
+	vars["LABEL"] = r.Label
+	vars["ON_BEHALF_OF"] = r.OnBehalfOf
+	vars["ROLE"] = r.Role
+	vars["TICKET"] = r.Ticket
+	return geecert.ExpandConfigVariables(template, vars)
+}
+
+// Key IDs end up in sshd's logs, one line per entry.
+func validateKeyIDTemplate(template string) error {
+	if len(template) == 0 {
+		return nil
+	}
+	if strings.IndexFunc(template, unicode.IsControl) != -1 {
+		return errors.New("must not contain control characters")
+	}
+	return nil
+}
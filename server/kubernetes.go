@@ -0,0 +1,287 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+
+	"github.com/continusec/geecert"
+	pb "github.com/continusec/geecert/sso"
+)
+
+// Keys are fetched again for an unknown key ID at most this often.
+const kubernetesKeysInterval = 5 * time.Minute
+
+// Prefix of the subject of service account tokens.
+const serviceAccountPrefix = "system:serviceaccount:"
+
+var ErrBadKubernetesToken = errors.New("Kubernetes service account token is not valid.")
+
+// Authenticates workloads by Kubernetes service account tokens, see ServerConfig.Kubernetes.
+type KubernetesAuthenticator struct {
+	clusters []*kubernetesCluster
+}
+
+type kubernetesCluster struct {
+	conf *pb.ServerConfig_Kubernetes
+
+	lock    sync.Mutex
+	keys    map[string]interface{}
+	fetched time.Time
+}
+
+// Loads the signing keys of the clusters in conf that have jwks_path set. Those of the
+// others are fetched when first needed.
+func NewKubernetesAuthenticator(conf *pb.ServerConfig) (*KubernetesAuthenticator, error) {
+	rv := &KubernetesAuthenticator{}
+	for _, kc := range conf.Kubernetes {
+		c := &kubernetesCluster{conf: kc}
+		if len(kc.JwksPath) > 0 {
+			b, err := os.ReadFile(kc.JwksPath)
+			if err != nil {
+				return nil, fmt.Errorf("kubernetes %s: jwks_path: %w", kc.Issuer, err)
+			}
+			c.keys, err = parseJWKS(b)
+			if err != nil {
+				return nil, fmt.Errorf("kubernetes %s: jwks_path: %w", kc.Issuer, err)
+			}
+		}
+		rv.clusters = append(rv.clusters, c)
+	}
+	return rv, nil
+}
+
+// Authenticates KUBERNETES credentials, by the service account token sent as the ID token.
+func (k *KubernetesAuthenticator) Authenticate(ctx context.Context, cred *Credential) (*geecert.IDTokenClaims, error) {
+	if cred.Type != pb.CredentialType_KUBERNETES {
+		return nil, ErrNotHandled
+	}
+
+	// Find the cluster by the issuer, before we can check the signature with its keys
+	unverified, _, err := new(jwt.Parser).ParseUnverified(cred.Token, jwt.MapClaims{})
+	if err != nil {
+		return nil, fmt.Errorf("%w (%w)", ErrBadKubernetesToken, err)
+	}
+	iss, _ := unverified.Claims.(jwt.MapClaims)["iss"].(string)
+	var c *kubernetesCluster
+	for _, cc := range k.clusters {
+		if cc.conf.Issuer == iss {
+			c = cc
+		}
+	}
+	if c == nil {
+		return nil, fmt.Errorf("%w (unknown issuer %q)", ErrBadKubernetesToken, iss)
+	}
+
+	token, err := jwt.Parse(cred.Token, func(t *jwt.Token) (interface{}, error) {
+		switch t.Method.Alg() {
+		case "RS256", "ES256":
+		default:
+			return nil, geecert.ErrUnexpectedAlgorithm
+		}
+		kid, _ := t.Header["kid"].(string)
+		return c.key(ctx, kid)
+	})
+	if err != nil {
+		if ve, ok := err.(*jwt.ValidationError); ok && ve.Errors&jwt.ValidationErrorExpired != 0 {
+			return nil, fmt.Errorf("%w (%w)", geecert.ErrTokenExpired, err)
+		}
+		return nil, fmt.Errorf("%w (%w)", ErrBadKubernetesToken, err)
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, ErrBadKubernetesToken
+	}
+	if !hasAudience(claims, c.conf.Audience) {
+		return nil, fmt.Errorf("%w (audience is not %s)", ErrBadKubernetesToken, c.conf.Audience)
+	}
+	sub, _ := claims["sub"].(string)
+	if !strings.HasPrefix(sub, serviceAccountPrefix) {
+		return nil, fmt.Errorf("%w (%q is not a service account)", ErrBadKubernetesToken, sub)
+	}
+
+	identity := sub
+	if len(c.conf.ClusterName) > 0 {
+		identity += "@" + c.conf.ClusterName
+	}
+	rv := &geecert.IDTokenClaims{
+		EmailAddress: identity,
+		Claims:       claims,
+	}
+	if exp, ok := claims["exp"].(float64); ok {
+		rv.Expiry = time.Unix(int64(exp), 0)
+	}
+	if iat, ok := claims["iat"].(float64); ok {
+		rv.AuthTime = time.Unix(int64(iat), 0)
+	}
+	return rv, nil
+}
+
+// The aud claim of service account tokens is a list.
+func hasAudience(claims jwt.MapClaims, audience string) bool {
+	switch aud := claims["aud"].(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, a := range aud {
+			if a == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Returns the cluster's signing key with kid, fetching the keys again if it isn't known and
+// they weren't fetched recently.
+func (c *kubernetesCluster) key(ctx context.Context, kid string) (interface{}, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	key, ok := c.keys[kid]
+	if ok {
+		return key, nil
+	}
+	if len(c.conf.JwksPath) > 0 || time.Since(c.fetched) < kubernetesKeysInterval {
+		return nil, fmt.Errorf("no signing key with ID %q", kid)
+	}
+
+	c.fetched = time.Now()
+	keys, err := fetchJWKS(ctx, c.conf)
+	if err != nil {
+		return nil, err
+	}
+	c.keys = keys
+	key, ok = c.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("no signing key with ID %q", kid)
+	}
+	return key, nil
+}
+
+// Fetches the cluster's signing keys from jwks_uri, or the jwks_uri in the issuer's OIDC
+// discovery document.
+func fetchJWKS(ctx context.Context, kc *pb.ServerConfig_Kubernetes) (map[string]interface{}, error) {
+	uri := kc.JwksUri
+	if len(uri) == 0 {
+		b, err := httpGet(ctx, strings.TrimSuffix(kc.Issuer, "/")+"/.well-known/openid-configuration")
+		if err != nil {
+			return nil, err
+		}
+		var disco struct {
+			JWKSURI string `json:"jwks_uri"`
+		}
+		err = json.Unmarshal(b, &disco)
+		if err != nil {
+			return nil, err
+		}
+		if len(disco.JWKSURI) == 0 {
+			return nil, fmt.Errorf("no jwks_uri in OIDC discovery document for %s", kc.Issuer)
+		}
+		uri = disco.JWKSURI
+	}
+	b, err := httpGet(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	return parseJWKS(b)
+}
+
+func httpGet(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// Parses a JSON Web Key Set of RSA and EC keys, by key ID.
+func parseJWKS(b []byte) (map[string]interface{}, error) {
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	err := json.Unmarshal(b, &set)
+	if err != nil {
+		return nil, err
+	}
+	rv := make(map[string]interface{})
+	for _, k := range set.Keys {
+		switch k.Kty {
+		case "RSA":
+			n, err := base64.RawURLEncoding.DecodeString(k.N)
+			if err != nil {
+				return nil, fmt.Errorf("key %s: %w", k.Kid, err)
+			}
+			e, err := base64.RawURLEncoding.DecodeString(k.E)
+			if err != nil {
+				return nil, fmt.Errorf("key %s: %w", k.Kid, err)
+			}
+			rv[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case "EC":
+			if k.Crv != "P-256" {
+				continue
+			}
+			x, err := base64.RawURLEncoding.DecodeString(k.X)
+			if err != nil {
+				return nil, fmt.Errorf("key %s: %w", k.Kid, err)
+			}
+			y, err := base64.RawURLEncoding.DecodeString(k.Y)
+			if err != nil {
+				return nil, fmt.Errorf("key %s: %w", k.Kid, err)
+			}
+			rv[k.Kid] = &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		}
+	}
+	if len(rv) == 0 {
+		return nil, errors.New("no RSA or P-256 keys found")
+	}
+	return rv, nil
+}
@@ -0,0 +1,227 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	pb "github.com/continusec/geecert/sso"
+)
+
+const (
+	ListenerTCP     = "tcp"
+	ListenerUnix    = "unix"
+	ListenerSystemd = "systemd"
+
+	systemdFirstFD = 3 // SD_LISTEN_FDS_START
+)
+
+var (
+	ErrNoSystemdSockets     = errors.New("No sockets were passed by systemd.")
+	ErrPlaintextNeedsUnix   = errors.New("Plaintext is only allowed for unix sockets.")
+	ErrSystemdSocketsReused = errors.New("Systemd sockets are used by more than one listener.")
+)
+
+// A listener to serve gRPC on, from listen_port or listener.
+type Listener struct {
+	net.Listener
+	Plaintext bool // serve without TLS
+}
+
+func validateListener(l *pb.ServerConfig_Listener) error {
+	switch l.Network {
+	case ListenerTCP:
+		if l.Plaintext {
+			return errors.New("plaintext is only allowed for unix and systemd")
+		}
+		_, _, err := net.SplitHostPort(l.Address)
+		if err != nil {
+			return errors.New(fmt.Sprintf("address: %s", err))
+		}
+	case ListenerUnix:
+		if len(l.Address) == 0 {
+			return errors.New("address must be set")
+		}
+	case ListenerSystemd:
+	default:
+		return errors.New(fmt.Sprintf("network %q must be tcp, unix or systemd", l.Network))
+	}
+	if l.TrustForwarded && l.Network == ListenerTCP {
+		return errors.New("trust_forwarded is only allowed for unix and systemd, use trusted_proxy for tcp")
+	}
+	return nil
+}
+
+// Returns an error if more than one systemd listener would use the same sockets, i.e. they
+// have the same name, or one has no name and so uses them all.
+func validateSystemdListeners(listeners []*pb.ServerConfig_Listener) error {
+	names := make(map[string]bool)
+	for _, l := range listeners {
+		if l.Network != ListenerSystemd {
+			continue
+		}
+		if names[l.Address] || names[""] || (len(l.Address) == 0 && len(names) > 0) {
+			return ErrSystemdSocketsReused
+		}
+		names[l.Address] = true
+	}
+	return nil
+}
+
+// Opens listen_port (if set, or if there are no listeners) and each listener.
+func OpenListeners(conf *pb.ServerConfig) ([]*Listener, error) {
+	trusted, err := parseTrustedProxies(conf.TrustedProxy)
+	if err != nil {
+		return nil, err
+	}
+	var rv []*Listener
+	closeAll := func() {
+		for _, l := range rv {
+			l.Close()
+		}
+	}
+	if conf.ListenPort != 0 || len(conf.Listener) == 0 {
+		l, err := net.Listen("tcp", fmt.Sprintf(":%d", conf.ListenPort))
+		if err != nil {
+			return nil, err
+		}
+		if conf.ProxyProtocol {
+			l = &proxyListener{Listener: l, trusted: trusted}
+		}
+		rv = append(rv, &Listener{Listener: l})
+	}
+
+	var activated map[string][]net.Listener
+	for _, lc := range conf.Listener {
+		var ls []net.Listener
+		var err error
+		switch lc.Network {
+		case ListenerTCP:
+			var l net.Listener
+			l, err = net.Listen("tcp", lc.Address)
+			ls = []net.Listener{l}
+		case ListenerUnix:
+			var l net.Listener
+			l, err = listenUnix(lc.Address)
+			ls = []net.Listener{l}
+		case ListenerSystemd:
+			if activated == nil {
+				activated, err = systemdListeners()
+				if err != nil {
+					break
+				}
+			}
+			for name, fls := range activated {
+				if len(lc.Address) == 0 || name == lc.Address {
+					ls = append(ls, fls...)
+				}
+			}
+			if len(ls) == 0 {
+				err = ErrNoSystemdSockets
+			}
+		}
+		if lc.Plaintext && err == nil {
+			// systemd may pass TCP sockets, which mustn't be served without TLS
+			for _, l := range ls {
+				if _, ok := l.Addr().(*net.UnixAddr); !ok {
+					err = ErrPlaintextNeedsUnix
+				}
+			}
+		}
+		if err != nil {
+			for _, l := range ls {
+				if l != nil {
+					l.Close()
+				}
+			}
+			closeAll()
+			return nil, fmt.Errorf("listener %s %s: %w", lc.Network, lc.Address, err)
+		}
+		for _, l := range ls {
+			if _, ok := l.Addr().(*net.UnixAddr); ok && lc.TrustForwarded {
+				l = &trustedListener{Listener: l}
+			}
+			if lc.ProxyProtocol {
+				l = &proxyListener{Listener: l, trusted: trusted}
+			}
+			rv = append(rv, &Listener{Listener: l, Plaintext: lc.Plaintext})
+		}
+	}
+	return rv, nil
+}
+
+// Listens on a unix socket at path, replacing any stale socket, usable by the group.
+func listenUnix(path string) (net.Listener, error) {
+	fi, err := os.Lstat(path)
+	if err == nil && fi.Mode()&os.ModeSocket != 0 {
+		err = os.Remove(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	err = os.Chmod(path, 0660)
+	if err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// Listeners passed by systemd socket activation (see sd_listen_fds), by FileDescriptorName=.
+func systemdListeners() (map[string][]net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, ErrNoSystemdSockets
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n < 1 {
+		return nil, ErrNoSystemdSockets
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+
+	// Not for any child processes
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	rv := make(map[string][]net.Listener)
+	for i := 0; i < n; i++ {
+		name := "unknown"
+		if i < len(names) && len(names[i]) > 0 {
+			name = names[i]
+		}
+		f := os.NewFile(uintptr(systemdFirstFD+i), name)
+		l, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("socket %d (%s): %w", systemdFirstFD+i, name, err)
+		}
+		rv[name] = append(rv[name], l)
+	}
+	return rv, nil
+}
@@ -0,0 +1,282 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/continusec/geecert/sso"
+)
+
+const (
+	MDMProviderJamf   = "jamf"
+	MDMProviderIntune = "intune"
+
+	defaultIntuneURL = "https://graph.microsoft.com"
+	defaultMDMCache  = 5 * time.Minute
+)
+
+var (
+	ErrUnknownMDMProvider = errors.New("Unknown MDM provider.")
+)
+
+// Serial numbers are only ever letters, digits and a little punctuation, and are quoted in filters
+var mdmSerialNumber = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// Looks up devices in Jamf Pro or Intune, see ServerConfig.MDM.
+type MDMClient struct {
+	conf   *pb.ServerConfig_MDM
+	secret string
+
+	lock        sync.Mutex
+	token       string
+	tokenExpiry time.Time
+	cache       map[string]*mdmDeviceState
+}
+
+type mdmDeviceState struct {
+	trusted bool
+	detail  string // why not, if not trusted
+	expires time.Time
+}
+
+func NewMDMClient(conf *pb.ServerConfig) (*MDMClient, error) {
+	mc := conf.Mdm
+	switch mc.Provider {
+	case MDMProviderJamf, MDMProviderIntune:
+	default:
+		return nil, ErrUnknownMDMProvider
+	}
+	b, err := os.ReadFile(mc.ClientSecretPath)
+	if err != nil {
+		return nil, fmt.Errorf("mdm client_secret_path: %w", err)
+	}
+	return &MDMClient{
+		conf:   mc,
+		secret: strings.TrimSpace(string(b)),
+		cache:  make(map[string]*mdmDeviceState),
+	}, nil
+}
+
+func validateMDM(mc *pb.ServerConfig_MDM) error {
+	switch mc.Provider {
+	case MDMProviderJamf:
+		if len(mc.Url) == 0 {
+			return errors.New("url must be set for jamf")
+		}
+	case MDMProviderIntune:
+		if len(mc.TenantId) == 0 && len(mc.TokenUrl) == 0 {
+			return errors.New("tenant_id must be set for intune")
+		}
+	default:
+		return errors.New(fmt.Sprintf("provider %q must be jamf or intune", mc.Provider))
+	}
+	if len(mc.ClientId) == 0 || len(mc.ClientSecretPath) == 0 {
+		return errors.New("client_id and client_secret_path must be set")
+	}
+	return nil
+}
+
+// Returns true if the MDM says the device with given serial number is managed (Jamf Pro) or
+// compliant (Intune), and if not, why not. Results are cached for cache_seconds.
+func (m *MDMClient) CheckDevice(ctx context.Context, serialNumber string) (bool, string, error) {
+	if !mdmSerialNumber.MatchString(serialNumber) {
+		return false, "no serial number was reported when the device was enrolled", nil
+	}
+
+	m.lock.Lock()
+	st, ok := m.cache[serialNumber]
+	m.lock.Unlock()
+	if ok && time.Now().Before(st.expires) {
+		return st.trusted, st.detail, nil
+	}
+
+	token, err := m.accessToken(ctx)
+	if err != nil {
+		return false, "", err
+	}
+	st = &mdmDeviceState{}
+	if m.conf.Provider == MDMProviderJamf {
+		st.trusted, st.detail, err = m.lookupJamf(ctx, token, serialNumber)
+	} else {
+		st.trusted, st.detail, err = m.lookupIntune(ctx, token, serialNumber)
+	}
+	if err != nil {
+		return false, "", err
+	}
+
+	cache := defaultMDMCache
+	if m.conf.CacheSeconds != 0 {
+		cache = time.Duration(m.conf.CacheSeconds) * time.Second
+	}
+	st.expires = time.Now().Add(cache)
+	m.lock.Lock()
+	m.cache[serialNumber] = st
+	m.lock.Unlock()
+	return st.trusted, st.detail, nil
+}
+
+// Returns an access token for the MDM's API, from the client credentials grant.
+func (m *MDMClient) accessToken(ctx context.Context) (string, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if len(m.token) > 0 && time.Now().Before(m.tokenExpiry) {
+		return m.token, nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {m.conf.ClientId},
+		"client_secret": {m.secret},
+	}
+	tokenURL := m.conf.TokenUrl
+	if m.conf.Provider == MDMProviderJamf {
+		if len(tokenURL) == 0 {
+			tokenURL = strings.TrimSuffix(m.conf.Url, "/") + "/api/oauth/token"
+		}
+	} else {
+		if len(tokenURL) == 0 {
+			tokenURL = "https://login.microsoftonline.com/" + url.PathEscape(m.conf.TenantId) + "/oauth2/v2.0/token"
+		}
+		form.Set("scope", m.intuneURL()+"/.default")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	err = mdmDo(req, &tok)
+	if err != nil {
+		return "", err
+	}
+	if len(tok.AccessToken) == 0 {
+		return "", fmt.Errorf("no access token from %s", tokenURL)
+	}
+
+	// Renew a minute early, so that a token doesn't expire during a lookup
+	m.token = tok.AccessToken
+	m.tokenExpiry = time.Now().Add(time.Duration(tok.ExpiresIn)*time.Second - time.Minute)
+	return m.token, nil
+}
+
+func (m *MDMClient) intuneURL() string {
+	if len(m.conf.Url) > 0 {
+		return strings.TrimSuffix(m.conf.Url, "/")
+	}
+	return defaultIntuneURL
+}
+
+func (m *MDMClient) lookupJamf(ctx context.Context, token string, serialNumber string) (bool, string, error) {
+	q := url.Values{
+		"section": {"GENERAL", "HARDWARE"},
+		"filter":  {`hardware.serialNumber=="` + serialNumber + `"`},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(m.conf.Url, "/")+"/api/v1/computers-inventory?"+q.Encode(), nil)
+	if err != nil {
+		return false, "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	var inv struct {
+		Results []struct {
+			General struct {
+				RemoteManagement struct {
+					Managed bool `json:"managed"`
+				} `json:"remoteManagement"`
+			} `json:"general"`
+		} `json:"results"`
+	}
+	err = mdmDo(req, &inv)
+	if err != nil {
+		return false, "", err
+	}
+	if len(inv.Results) == 0 {
+		return false, "not found in Jamf Pro", nil
+	}
+	for _, r := range inv.Results {
+		if r.General.RemoteManagement.Managed {
+			return true, "", nil
+		}
+	}
+	return false, "not managed by Jamf Pro", nil
+}
+
+func (m *MDMClient) lookupIntune(ctx context.Context, token string, serialNumber string) (bool, string, error) {
+	q := url.Values{
+		"$filter": {"serialNumber eq '" + serialNumber + "'"},
+		"$select": {"complianceState,managementState"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.intuneURL()+"/v1.0/deviceManagement/managedDevices?"+q.Encode(), nil)
+	if err != nil {
+		return false, "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	var devs struct {
+		Value []struct {
+			ComplianceState string `json:"complianceState"`
+			ManagementState string `json:"managementState"`
+		} `json:"value"`
+	}
+	err = mdmDo(req, &devs)
+	if err != nil {
+		return false, "", err
+	}
+	if len(devs.Value) == 0 {
+		return false, "not found in Intune", nil
+	}
+	for _, d := range devs.Value {
+		if d.ComplianceState == "compliant" && d.ManagementState == "managed" {
+			return true, "", nil
+		}
+	}
+	d := devs.Value[0]
+	return false, fmt.Sprintf("Intune compliance state %s, management state %s", d.ComplianceState, d.ManagementState), nil
+}
+
+func mdmDo(req *http.Request, v interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s: %s", req.Method, req.URL.Path, resp.Status)
+	}
+	return json.Unmarshal(b, v)
+}
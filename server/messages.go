@@ -0,0 +1,97 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	pb "github.com/continusec/geecert/sso"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding/gzip"
+)
+
+const (
+	defaultMaxMessageBytes = 4 << 20 // as for gRPC's own default for requests received
+	minMaxMessageBytes     = 64 << 10
+)
+
+func (s *SSOServer) maxMessageBytes() int {
+	if s.Config.MaxMessageBytes > 0 {
+		return int(s.Config.MaxMessageBytes)
+	}
+	return defaultMaxMessageBytes
+}
+
+// Returns an error saying what to do about it if resp is too large to send, rather than leave
+// gRPC to fail the request with a bare RESOURCE_EXHAUSTED.
+func (s *SSOServer) checkResponseSize(method string, resp interface{}) error {
+	m, ok := resp.(proto.Message)
+	if !ok {
+		return nil
+	}
+	size := proto.Size(m)
+	if size <= s.maxMessageBytes() {
+		return nil
+	}
+	log.Printf("Response to %s of %d bytes is larger than max_message_bytes %d.\n", method, size, s.maxMessageBytes())
+	return withDetail(codes.ResourceExhausted, fmt.Sprintf("Response of %d bytes is larger than the server allows.", size), &pb.ErrorDetail{
+		Reason:      pb.ErrorReason_MESSAGE_TOO_LARGE,
+		Remediation: "Ask an administrator to raise max_message_bytes on the server.",
+	})
+}
+
+// Compresses responses to the request with ctx, if compress_responses is set and the client
+// accepts gzip.
+func (s *SSOServer) compressResponse(ctx context.Context) {
+	if !s.Config.CompressResponses {
+		return
+	}
+	accepted, err := grpc.ClientSupportedCompressors(ctx)
+	if err != nil {
+		return
+	}
+	for _, name := range accepted {
+		if name == gzip.Name {
+			err = grpc.SetSendCompressor(ctx, gzip.Name)
+			if err != nil {
+				log.Println("Error compressing response:", err)
+			}
+			return
+		}
+	}
+}
+
+// Checks the size of each message sent on a stream, as for checkResponseSize.
+type sizeCheckedStream struct {
+	grpc.ServerStream
+	s      *SSOServer
+	method string
+}
+
+func (ss *sizeCheckedStream) SendMsg(m interface{}) error {
+	err := ss.s.checkResponseSize(ss.method, m)
+	if err != nil {
+		return err
+	}
+	return ss.ServerStream.SendMsg(m)
+}
@@ -0,0 +1,205 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/continusec/geecert"
+	pb "github.com/continusec/geecert/sso"
+)
+
+var countryCode = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// Countries of address ranges, read from geoip_path.
+type GeoIP struct {
+	ranges []geoRange // sorted by first
+}
+
+type geoRange struct {
+	first, last net.IP // 16 byte form
+	country     string
+}
+
+// Reads a CSV file of CIDR,country or first,last,country lines, ignoring any others.
+func LoadGeoIP(path string) (*GeoIP, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.ReuseRecord = true
+	g := &GeoIP{}
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		gr, ok := parseGeoRange(rec)
+		if ok {
+			g.ranges = append(g.ranges, gr)
+		}
+	}
+	if len(g.ranges) == 0 {
+		return nil, errors.New("no address ranges found")
+	}
+	sort.Slice(g.ranges, func(i, j int) bool {
+		return bytes.Compare(g.ranges[i].first, g.ranges[j].first) < 0
+	})
+	return g, nil
+}
+
+func parseGeoRange(rec []string) (geoRange, bool) {
+	if len(rec) >= 2 && strings.Contains(rec[0], "/") {
+		_, n, err := net.ParseCIDR(strings.TrimSpace(rec[0]))
+		country := strings.TrimSpace(rec[1])
+		if err != nil || !countryCode.MatchString(country) {
+			return geoRange{}, false
+		}
+		last := make(net.IP, len(n.IP))
+		for i := range n.IP {
+			last[i] = n.IP[i] | ^n.Mask[i]
+		}
+		return geoRange{first: n.IP.To16(), last: last.To16(), country: country}, true
+	}
+	if len(rec) >= 3 {
+		first := net.ParseIP(strings.TrimSpace(rec[0]))
+		last := net.ParseIP(strings.TrimSpace(rec[1]))
+		country := strings.TrimSpace(rec[2])
+		if first == nil || last == nil || !countryCode.MatchString(country) {
+			return geoRange{}, false
+		}
+		return geoRange{first: first.To16(), last: last.To16(), country: country}, true
+	}
+	return geoRange{}, false
+}
+
+// Returns the country of ip, or "" if not known.
+func (g *GeoIP) Country(ip net.IP) string {
+	ip = ip.To16()
+	i := sort.Search(len(g.ranges), func(i int) bool {
+		return bytes.Compare(g.ranges[i].first, ip) > 0
+	})
+	if i > 0 && bytes.Compare(ip, g.ranges[i-1].last) <= 0 {
+		return g.ranges[i-1].country
+	}
+	return ""
+}
+
+func validateNetworkPolicy(np *pb.ServerConfig_NetworkPolicy, geoIPPath string) error {
+	for _, c := range np.AllowedCidr {
+		_, _, err := net.ParseCIDR(c)
+		if err != nil {
+			return errors.New(fmt.Sprintf("allowed_cidr: %s", err))
+		}
+	}
+	for _, c := range append(append([]string(nil), np.AllowedCountry...), np.DeniedCountry...) {
+		if !countryCode.MatchString(c) {
+			return errors.New(fmt.Sprintf("country %q must be an ISO 3166 code, e.g. DE", c))
+		}
+		if len(geoIPPath) == 0 {
+			return errors.New("geoip_path must be set to check countries")
+		}
+	}
+	return nil
+}
+
+// Why policy refuses requests from ip in country, or "" if it doesn't.
+func networkRefusal(np *pb.ServerConfig_NetworkPolicy, ip net.IP, country string) string {
+	if len(np.AllowedCidr) > 0 {
+		found := false
+		for _, c := range np.AllowedCidr {
+			_, n, err := net.ParseCIDR(c)
+			if err == nil && n.Contains(ip) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "address not in allowed_cidr"
+		}
+	}
+	for _, c := range np.DeniedCountry {
+		if c == country {
+			return "country in denied_country"
+		}
+	}
+	if len(np.AllowedCountry) > 0 {
+		for _, c := range np.AllowedCountry {
+			if c == country {
+				return ""
+			}
+		}
+		return "country not in allowed_country"
+	}
+	return ""
+}
+
+// Returns the network_policy that applies to claims, nil if none does.
+func (s *SSOServer) networkPolicy(claims *geecert.IDTokenClaims, userConf *pb.ServerConfig_UserConfig) *pb.ServerConfig_NetworkPolicy {
+	np := s.Config.NetworkPolicy
+	if userConf != nil && userConf.NetworkPolicy != nil {
+		np = userConf.NetworkPolicy
+	}
+	if listed(s.Config.NetworkPolicyExempt, claims) {
+		return nil
+	}
+	return np
+}
+
+// Checks the address the request came from against the network_policy of userConf, if set, else
+// the server's. Refusals are logged with the address and country, to audit where they came from.
+func (s *SSOServer) checkNetwork(ctx context.Context, claims *geecert.IDTokenClaims, userConf *pb.ServerConfig_UserConfig) (pb.ResponseCode, error) {
+	np := s.networkPolicy(claims, userConf)
+	if np == nil {
+		return pb.ResponseCode_OK, nil
+	}
+
+	ip, err := peerIP(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var country string
+	if s.GeoIP != nil {
+		country = s.GeoIP.Country(ip)
+	}
+	why := networkRefusal(np, ip, country)
+	if len(why) > 0 {
+		log.Printf("Network policy refused request from %s at %s (country %q): %s.\n", claims.EmailAddress, ip, country, why)
+		return pb.ResponseCode_NETWORK_NOT_ALLOWED, nil
+	}
+	return pb.ResponseCode_OK, nil
+}
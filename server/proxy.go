@@ -0,0 +1,353 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	pb "github.com/continusec/geecert/sso"
+)
+
+const (
+	proxyHeaderTimeout = 10 * time.Second
+	maxProxyV1Length   = 107
+)
+
+var (
+	ErrBadProxyHeader = errors.New("Bad PROXY protocol header.")
+
+	proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+)
+
+// Parses trusted_proxy, CIDRs or bare addresses.
+func parseTrustedProxies(list []string) ([]*net.IPNet, error) {
+	var rv []*net.IPNet
+	for _, s := range list {
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, fmt.Errorf("%q is not an address or CIDR", s)
+			}
+			s = hostCIDR(ip)
+		}
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, err
+		}
+		rv = append(rv, n)
+	}
+	return rv, nil
+}
+
+// Returns true if connections from addr are from a proxy that can be trusted to say who the
+// client is. Connections over unix sockets are only trusted on listeners with trust_forwarded,
+// as any process on this host that can reach the socket could otherwise claim any address.
+func isTrustedProxy(trusted []*net.IPNet, addr net.Addr) bool {
+	switch a := addr.(type) {
+	case trustedAddr:
+		return true
+	case *net.TCPAddr:
+		return ipTrusted(trusted, a.IP)
+	}
+	return false
+}
+
+func ipTrusted(trusted []*net.IPNet, ip net.IP) bool {
+	for _, n := range trusted {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// The client's address from an X-Forwarded-For header added to by trusted proxies: the last
+// address not of a trusted proxy, or nil if there isn't one.
+func forwardedFor(trusted []*net.IPNet, header string) net.IP {
+	addrs := strings.Split(header, ",")
+	for i := len(addrs) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(addrs[i]))
+		if ip == nil {
+			return nil
+		}
+		if !ipTrusted(trusted, ip) {
+			return ip
+		}
+	}
+	return nil
+}
+
+// Replaces the peer of a request from a trusted proxy with the client given by the proxy in
+// x-forwarded-for metadata, if any.
+func (s *SSOServer) forwardedPeer(ctx context.Context) context.Context {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ctx
+	}
+	trusted, err := parseTrustedProxies(s.Config.TrustedProxy)
+	if err != nil || !isTrustedProxy(trusted, p.Addr) {
+		return ctx
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	xff := md.Get("x-forwarded-for")
+	if len(xff) == 0 {
+		return ctx
+	}
+	ip := forwardedFor(trusted, strings.Join(xff, ","))
+	if ip == nil {
+		return ctx
+	}
+	fp := *p
+	fp.Addr = &net.TCPAddr{IP: ip}
+	return peer.NewContext(ctx, &fp)
+}
+
+// A stream with the peer replaced by forwardedPeer.
+type forwardedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *forwardedStream) Context() context.Context {
+	return s.ctx
+}
+
+// Sets the RemoteAddr of HTTP requests from trusted proxies to the client given in their
+// X-Forwarded-For header, if any.
+func (s *SSOServer) forwardedHTTP(h http.Handler) http.Handler {
+	trusted, _ := parseTrustedProxies(s.Config.TrustedProxy)
+	if len(trusted) == 0 {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		xff := r.Header.Values("X-Forwarded-For")
+		if ip := net.ParseIP(host); err == nil && ip != nil && ipTrusted(trusted, ip) && len(xff) > 0 {
+			if fip := forwardedFor(trusted, strings.Join(xff, ",")); fip != nil {
+				r2 := r.Clone(r.Context())
+				r2.RemoteAddr = net.JoinHostPort(fip.String(), "0")
+				r = r2
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// The address of a peer on a unix socket listener with trust_forwarded.
+type trustedAddr struct {
+	net.Addr
+}
+
+// Marks connections as from trusted proxies, see trustedAddr.
+type trustedListener struct {
+	net.Listener
+}
+
+func (l *trustedListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &trustedConn{Conn: c}, nil
+}
+
+type trustedConn struct {
+	net.Conn
+}
+
+func (c *trustedConn) RemoteAddr() net.Addr {
+	return trustedAddr{c.Conn.RemoteAddr()}
+}
+
+// Accepts connections that start with a PROXY protocol header if from a trusted proxy.
+type proxyListener struct {
+	net.Listener
+	trusted []*net.IPNet
+}
+
+func (l *proxyListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &proxyConn{Conn: c, trusted: l.trusted}, nil
+}
+
+// The header is read on first use, rather than in Accept, so as not to hold up other connections.
+type proxyConn struct {
+	net.Conn
+	trusted []*net.IPNet
+
+	once   sync.Once
+	r      *bufio.Reader
+	remote net.Addr
+	err    error
+}
+
+func (c *proxyConn) init() {
+	c.once.Do(func() {
+		c.r = bufio.NewReader(c.Conn)
+		c.remote = c.Conn.RemoteAddr()
+		if !isTrustedProxy(c.trusted, c.remote) {
+			return
+		}
+		c.Conn.SetReadDeadline(time.Now().Add(proxyHeaderTimeout))
+		addr, err := readProxyHeader(c.r)
+		c.Conn.SetReadDeadline(time.Time{})
+		if err != nil {
+			c.err = fmt.Errorf("from %s: %w", c.remote, err)
+			return
+		}
+		if addr != nil {
+			c.remote = addr
+		}
+	})
+}
+
+func (c *proxyConn) Read(b []byte) (int, error) {
+	c.init()
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.r.Read(b)
+}
+
+func (c *proxyConn) RemoteAddr() net.Addr {
+	c.init()
+	return c.remote
+}
+
+// Reads a PROXY protocol v1 or v2 header, returning the client's address, or nil if the proxy
+// doesn't say (e.g. for its own health checks).
+func readProxyHeader(r *bufio.Reader) (net.Addr, error) {
+	b, err := r.Peek(len(proxyV2Signature))
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(b, proxyV2Signature) {
+		return readProxyV2(r)
+	}
+	if bytes.HasPrefix(b, []byte("PROXY ")) {
+		return readProxyV1(r)
+	}
+	return nil, ErrBadProxyHeader
+}
+
+// e.g. "PROXY TCP4 192.0.2.1 198.51.100.1 56324 443\r\n"
+func readProxyV1(r *bufio.Reader) (net.Addr, error) {
+	var line []byte
+	for len(line) <= maxProxyV1Length {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+	}
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, ErrBadProxyHeader
+	}
+	f := strings.Fields(string(line))
+	if len(f) >= 2 && f[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(f) != 6 || (f[1] != "TCP4" && f[1] != "TCP6") {
+		return nil, ErrBadProxyHeader
+	}
+	ip := net.ParseIP(f[2])
+	port, err := strconv.ParseUint(f[4], 10, 16)
+	if ip == nil || err != nil {
+		return nil, ErrBadProxyHeader
+	}
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+func readProxyV2(r *bufio.Reader) (net.Addr, error) {
+	hdr := make([]byte, 16)
+	_, err := io.ReadFull(r, hdr)
+	if err != nil {
+		return nil, err
+	}
+	if hdr[12]>>4 != 2 {
+		return nil, ErrBadProxyHeader
+	}
+	body := make([]byte, binary.BigEndian.Uint16(hdr[14:]))
+	_, err = io.ReadFull(r, body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch hdr[12] & 0xf {
+	case 0: // LOCAL
+		return nil, nil
+	case 1: // PROXY
+	default:
+		return nil, ErrBadProxyHeader
+	}
+	switch hdr[13] >> 4 {
+	case 1: // IPv4
+		if len(body) < 12 {
+			return nil, ErrBadProxyHeader
+		}
+		return &net.TCPAddr{IP: net.IP(body[0:4]), Port: int(binary.BigEndian.Uint16(body[8:10]))}, nil
+	case 2: // IPv6
+		if len(body) < 36 {
+			return nil, ErrBadProxyHeader
+		}
+		return &net.TCPAddr{IP: net.IP(body[0:16]), Port: int(binary.BigEndian.Uint16(body[32:34]))}, nil
+	}
+	return nil, nil
+}
+
+func validateProxyConfig(conf *pb.ServerConfig) error {
+	_, err := parseTrustedProxies(conf.TrustedProxy)
+	if err != nil {
+		return errors.New(fmt.Sprintf("trusted_proxy: %s", err))
+	}
+	if len(conf.TrustedProxy) > 0 {
+		return nil
+	}
+	if conf.ProxyProtocol {
+		return errors.New("proxy_protocol: trusted_proxy must be set")
+	}
+	for i, l := range conf.Listener {
+		if l.ProxyProtocol && !l.TrustForwarded {
+			return errors.New(fmt.Sprintf("listener %d: proxy_protocol: trusted_proxy or trust_forwarded must be set", i))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"bufio"
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestReadProxyHeader(t *testing.T) {
+	v2 := func(cmd, fam byte, body []byte) string {
+		h := append([]byte(nil), proxyV2Signature...)
+		h = append(h, 0x20|cmd, fam, 0, 0)
+		binary.BigEndian.PutUint16(h[14:], uint16(len(body)))
+		return string(append(h, body...))
+	}
+	v4body := []byte{192, 0, 2, 1, 198, 51, 100, 1, 0xdc, 0x04, 1, 187}
+	v6body := make([]byte, 36)
+	copy(v6body, net.ParseIP("2001:db8::5"))
+	binary.BigEndian.PutUint16(v6body[32:], 4000)
+	v1Version := []byte(v2(1, 0x11, v4body))
+	v1Version[12] = 0x11
+	for _, c := range []struct {
+		in   string
+		want string // "" for no address, "err" for an error
+	}{
+		{"PROXY TCP4 192.0.2.1 198.51.100.1 56324 443\r\nhello", "192.0.2.1:56324"},
+		{"PROXY TCP6 2001:db8::1 2001:db8::2 1234 443\r\nhello", "[2001:db8::1]:1234"},
+		{"PROXY UNKNOWN\r\nhello", ""},
+		{"PROXY TCP4 192.0.2.1 198.51.100.1 56324\r\nhello", "err"},
+		{"PROXY TCP4 nope 198.51.100.1 56324 443\r\nhello", "err"},
+		{"PROXY TCP4 192.0.2.1 198.51.100.1 56324 443\nhello", "err"},
+		{"PROXY " + strings.Repeat("x", 200) + "\r\n", "err"},
+		{"GET / HTTP/1.1\r\n\r\n", "err"},
+		{v2(1, 0x11, v4body) + "hello", "192.0.2.1:56324"},
+		{v2(1, 0x21, v6body) + "hello", "[2001:db8::5]:4000"},
+		{v2(0, 0x00, nil) + "hello", ""},
+		{v2(1, 0x11, v4body[:8]) + "hello", "err"},
+		{"PROXY TCP4 192.0.2.1 198.51.100.1 99999 443\r\nhello", "err"},
+		{"PROXY UDP4 192.0.2.1 198.51.100.1 56324 443\r\nhello", "err"},
+		{"PROXY TCP4 192.0.2.1", "err"},
+		{string(proxyV2Signature[:6]), "err"},
+		{string(v1Version) + "hello", "err"},
+		{v2(2, 0x11, v4body) + "hello", "err"},
+		{v2(1, 0x21, v6body[:20]) + "hello", "err"},
+		{v2(1, 0x11, v4body)[:20], "err"},
+	} {
+		r := bufio.NewReader(strings.NewReader(c.in))
+		addr, err := readProxyHeader(r)
+		got := ""
+		if err != nil {
+			got = "err"
+		} else if addr != nil {
+			got = addr.String()
+		}
+		if got != c.want {
+			t.Errorf("%q: got %s, %v", c.in, got, err)
+			continue
+		}
+		if err == nil {
+			rest, _ := r.ReadString(0)
+			if rest != "hello" {
+				t.Errorf("%q: rest %q", c.in, rest)
+			}
+		}
+	}
+}
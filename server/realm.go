@@ -0,0 +1,123 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/continusec/geecert"
+	pb "github.com/continusec/geecert/sso"
+)
+
+func findRealm(conf *pb.ServerConfig, name string) *pb.ServerConfig_Realm {
+	for _, r := range conf.Realm {
+		if r.Name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// Signs keyToSign with the CA of each realm the user may use, as per issueUserCert.
+func (s *SSOServer) issueRealmCerts(ctx context.Context, req *certRequest, userConf *pb.ServerConfig_UserConfig, keyToSign ssh.PublicKey, duration time.Duration, critOpts map[string]string, exts map[string]string, reason string) ([]*pb.RealmCertificate, error) {
+	var rv []*pb.RealmCertificate
+	for _, name := range userConf.Realm {
+		r := findRealm(s.Config, name)
+		if r == nil {
+			log.Printf("WARNING: Skipping unknown realm %s for %s.\n", name, req.Email)
+			continue
+		}
+
+		cert, caPubKey, err := s.signUserCert(ctx, r.CaKeyPath, r.Name, req, keyToSign, duration, critOpts, exts, reason)
+		if err != nil {
+			return nil, err
+		}
+
+		rc := s.realmConfig(r, caPubKey, userConf.Username)
+		rc.Certificate = cert
+		rv = append(rv, rc)
+	}
+	return rv, nil
+}
+
+// The CAs and config of a realm, as sent with its certificate, and to clients watching for updates.
+func (s *SSOServer) realmConfig(r *pb.ServerConfig_Realm, caPubKey ssh.PublicKey, username string) *pb.RealmCertificate {
+	ca := hostCA(caHostPatterns(r.CaHostPattern, r.ClientConfigScope), caPubKey, r.CaComment)
+	return &pb.RealmCertificate{
+		Name:                       r.Name,
+		CertificateAuthorities:     []string{geecert.RenderHostCertificateAuthority(ca)},
+		HostCertificateAuthorities: []*pb.HostCertificateAuthority{ca},
+		ConfigBlocks:               scopeConfigBlocks(r.ClientConfigScope, r.AdditionalSshConfigurationLine, r.SshConfigBlock, username, s.Config.AgentForwarding),
+	}
+}
+
+// The CAs and config of each realm the user may use, as per realmConfig.
+func (s *SSOServer) watchRealms(userConf *pb.ServerConfig_UserConfig) ([]*pb.RealmCertificate, error) {
+	var rv []*pb.RealmCertificate
+	for _, name := range userConf.Realm {
+		r := findRealm(s.Config, name)
+		if r == nil {
+			continue
+		}
+		caPubKey, err := caPublicKey(r.CaKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		rv = append(rv, s.realmConfig(r, caPubKey, userConf.Username))
+	}
+	return rv, nil
+}
+
+// Checks realms are complete, and that users only reference realms that exist.
+func validateRealms(conf *pb.ServerConfig) error {
+	seen := make(map[string]bool)
+	for i, r := range conf.Realm {
+		if !geecert.ValidRealmName(r.Name) || seen[r.Name] {
+			return errors.New(fmt.Sprintf("realm %d: name must be unique, and only letters, digits, - and _", i))
+		}
+		seen[r.Name] = true
+		if len(r.CaKeyPath) == 0 || len(r.ClientConfigScope) == 0 {
+			return errors.New(fmt.Sprintf("realm %s: ca_key_path and client_config_scope must be set", r.Name))
+		}
+		for j, b := range scopeConfigBlocks(r.ClientConfigScope, r.AdditionalSshConfigurationLine, r.SshConfigBlock, "", conf.AgentForwarding) {
+			err := geecert.ValidateSSHConfigBlock(b)
+			if err != nil {
+				return errors.New(fmt.Sprintf("realm %s: ssh config block %d: %s", r.Name, j, err))
+			}
+		}
+		err := geecert.ValidateCAHostPatterns(caHostPatterns(r.CaHostPattern, r.ClientConfigScope))
+		if err != nil {
+			return errors.New(fmt.Sprintf("realm %s: ca_host_pattern / client_config_scope: %s", r.Name, err))
+		}
+	}
+	for email, uc := range conf.AllowedUsers {
+		for _, name := range uc.Realm {
+			if !seen[name] {
+				return errors.New(fmt.Sprintf("realm for %s: unknown realm %s", email, name))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	pb "github.com/continusec/geecert/sso"
+	"github.com/golang/protobuf/proto"
+)
+
+const (
+	maxRequestIDLength = 64
+
+	// How long a certificate is returned again for retries of the request it was issued for.
+	requestIDWindow = 5 * time.Minute
+)
+
+var (
+	ErrBadRequestID    = errors.New("Request ID must be a single line of at most 64 characters.")
+	ErrRequestIDReused = errors.New("Request ID was already used for a different request.")
+)
+
+// Recent requests with request IDs, so that a retry gets the certificate issued for the
+// first attempt rather than another one. Kept in memory, so only for retries to the same server.
+type recentRequests struct {
+	mu      sync.Mutex
+	entries map[string]*recentRequest
+}
+
+type recentRequest struct {
+	req     *pb.SSHCertsRequest // as compared with retries, see retryKey
+	done    chan struct{}       // closed once resp is set
+	resp    *pb.SSHCertsResponse
+	expires time.Time
+}
+
+// Returns the parts of in that a retry must repeat, i.e. without credentials that may have
+// been refreshed, the approval ID (learned by the first attempt) or the config hash.
+func retryKey(in *pb.SSHCertsRequest) *pb.SSHCertsRequest {
+	rv := proto.Clone(in).(*pb.SSHCertsRequest)
+	rv.IdToken = ""
+	rv.CredentialType = 0
+	rv.ApprovalId = ""
+	rv.ConfigHash = ""
+	return rv
+}
+
+// Returns the response to an earlier attempt of in by email, waiting for it if in progress,
+// or if there is none (or it wasn't OK) calls issue and, if OK, keeps its response for retries.
+func (r *recentRequests) do(ctx context.Context, email string, in *pb.SSHCertsRequest, issue func() (*pb.SSHCertsResponse, error)) (*pb.SSHCertsResponse, error) {
+	if len(in.RequestId) > maxRequestIDLength || strings.IndexFunc(in.RequestId, unicode.IsControl) != -1 {
+		return nil, ErrBadRequestID
+	}
+	key := email + "\x00" + in.RequestId
+	req := retryKey(in)
+
+	for {
+		r.mu.Lock()
+		if r.entries == nil {
+			r.entries = make(map[string]*recentRequest)
+		}
+		now := time.Now()
+		for k, e := range r.entries {
+			if e.resp != nil && now.After(e.expires) {
+				delete(r.entries, k)
+			}
+		}
+		e, ok := r.entries[key]
+		if !ok {
+			e = &recentRequest{req: req, done: make(chan struct{})}
+			r.entries[key] = e
+			r.mu.Unlock()
+			return r.issue(key, e, issue)
+		}
+		r.mu.Unlock()
+
+		if !proto.Equal(e.req, req) {
+			return nil, ErrRequestIDReused
+		}
+		select {
+		case <-e.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if e.resp != nil {
+			log.Printf("Returning the certificate already issued to %s for retried request %q.\n", email, in.RequestId)
+			return proto.Clone(e.resp).(*pb.SSHCertsResponse), nil
+		}
+		// The earlier attempt failed and was forgotten, so try again
+	}
+}
+
+func (r *recentRequests) issue(key string, e *recentRequest, issue func() (*pb.SSHCertsResponse, error)) (*pb.SSHCertsResponse, error) {
+	resp, err := issue()
+
+	r.mu.Lock()
+	if err == nil && resp.Status == pb.ResponseCode_OK {
+		e.resp = proto.Clone(resp).(*pb.SSHCertsResponse)
+		e.expires = time.Now().Add(requestIDWindow)
+	} else {
+		delete(r.entries, key)
+	}
+	r.mu.Unlock()
+	close(e.done)
+	return resp, err
+}
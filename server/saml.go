@@ -0,0 +1,443 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/crewjam/saml"
+	"github.com/crewjam/saml/samlsp"
+	jwt "github.com/dgrijalva/jwt-go"
+
+	"github.com/continusec/geecert"
+	pb "github.com/continusec/geecert/sso"
+)
+
+const (
+	samlLoginTimeout         = 10 * time.Minute // how long the user has to sign in with the IdP
+	samlPickupTimeout        = 2 * time.Minute  // how long the client has to redeem a pickup code
+	maxPendingSAMLLogins     = 10000            // sign ins are started unauthenticated, so limit how many we hold
+	defaultSAMLTokenLifetime = time.Hour
+
+	samlBridgeKeyID = "geecert-saml-bridge"
+)
+
+var (
+	ErrBadSAMLRedirect = errors.New("redirect_uri must be an http URL on localhost or 127.0.0.1.")
+	ErrNoSAMLEmail     = errors.New("SAML assertion has no email address for the user.")
+)
+
+// A sign in started at /saml/login, keyed by the relay state sent to the IdP.
+type samlLogin struct {
+	RequestID   string
+	RedirectURI string
+	State       string
+	Expiry      time.Time
+}
+
+// A completed sign in, waiting for the client to redeem its pickup code.
+type samlPickup struct {
+	Email    string
+	Groups   []string
+	AuthTime time.Time
+	Expiry   time.Time
+}
+
+// Signs users in with a SAML IdP and issues them ID tokens, see ServerConfig.SAMLBridge.
+// Sign ins in progress are held in memory, so must complete on the server they started on.
+type SAMLBridge struct {
+	conf         *pb.ServerConfig_SAMLBridge
+	clientID     string
+	hostedDomain string
+	issuer       string
+	sp           *saml.ServiceProvider
+
+	lock    sync.Mutex
+	logins  map[string]*samlLogin
+	pickups map[string]*samlPickup
+}
+
+// Loads the keys and IdP metadata for the saml_bridge in conf.
+func NewSAMLBridge(conf *pb.ServerConfig) (*SAMLBridge, error) {
+	bc := conf.SamlBridge
+	key, err := LoadPrivateKeyFromPEM(bc.KeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("saml_bridge key_path: %w", err)
+	}
+
+	certData, err := os.ReadFile(bc.CertPath)
+	if err != nil {
+		return nil, fmt.Errorf("saml_bridge cert_path: %w", err)
+	}
+	block, _ := pem.Decode(certData)
+	if block == nil {
+		return nil, errors.New("saml_bridge cert_path: Bad PEM")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("saml_bridge cert_path: %w", err)
+	}
+
+	mdData, err := os.ReadFile(bc.IdpMetadataPath)
+	if err != nil {
+		return nil, fmt.Errorf("saml_bridge idp_metadata_path: %w", err)
+	}
+	md, err := samlsp.ParseMetadata(mdData)
+	if err != nil {
+		return nil, fmt.Errorf("saml_bridge idp_metadata_path: %w", err)
+	}
+
+	root := strings.TrimSuffix(bc.RootUrl, "/")
+	metadataURL, err := url.Parse(root + "/saml/metadata")
+	if err != nil {
+		return nil, fmt.Errorf("saml_bridge root_url: %w", err)
+	}
+	acsURL, err := url.Parse(root + "/saml/acs")
+	if err != nil {
+		return nil, fmt.Errorf("saml_bridge root_url: %w", err)
+	}
+
+	sp := &saml.ServiceProvider{
+		Key:         key,
+		Certificate: cert,
+		MetadataURL: *metadataURL,
+		AcsURL:      *acsURL,
+		IDPMetadata: md,
+	}
+	if len(sp.GetSSOBindingLocation(saml.HTTPRedirectBinding)) == 0 {
+		return nil, errors.New("saml_bridge idp_metadata_path: IdP has no HTTP-Redirect SingleSignOnService")
+	}
+
+	return &SAMLBridge{
+		conf:         bc,
+		clientID:     conf.AllowedClientIdForIdToken,
+		hostedDomain: conf.AllowedDomainForIdToken,
+		issuer:       root,
+		sp:           sp,
+		logins:       make(map[string]*samlLogin),
+		pickups:      make(map[string]*samlPickup),
+	}, nil
+}
+
+// Adds the handlers for the IdP and clients, all under /saml/.
+func (b *SAMLBridge) register(mux *http.ServeMux) {
+	mux.HandleFunc("/saml/metadata", b.serveMetadata)
+	mux.HandleFunc("/saml/login", b.login)
+	mux.HandleFunc("/saml/acs", b.acs)
+}
+
+func (b *SAMLBridge) serveMetadata(w http.ResponseWriter, r *http.Request) {
+	buf, err := xml.MarshalIndent(b.sp.Metadata(), "", "  ")
+	if err != nil {
+		log.Println("Error marshalling SAML metadata:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/samlmetadata+xml")
+	w.Write(buf)
+}
+
+// Only clients on the user's machine may be sent pickup codes.
+func loopbackRedirect(redir string) bool {
+	u, err := url.Parse(redir)
+	if err != nil || u.Scheme != "http" {
+		return false
+	}
+	if u.Hostname() == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(u.Hostname())
+	return ip != nil && ip.IsLoopback()
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Drops sign ins and pickup codes that have expired. Must hold lock.
+func (b *SAMLBridge) expire() {
+	now := time.Now()
+	for k, l := range b.logins {
+		if now.After(l.Expiry) {
+			delete(b.logins, k)
+		}
+	}
+	for k, p := range b.pickups {
+		if now.After(p.Expiry) {
+			delete(b.pickups, k)
+		}
+	}
+}
+
+// /saml/login?redirect_uri=...&state=...[&force=1]
+// Sends the user to the IdP, remembering where to send the pickup code once they have signed in.
+func (b *SAMLBridge) login(w http.ResponseWriter, r *http.Request) {
+	redir := r.FormValue("redirect_uri")
+	if !loopbackRedirect(redir) {
+		http.Error(w, ErrBadSAMLRedirect.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req, err := b.sp.MakeAuthenticationRequest(b.sp.GetSSOBindingLocation(saml.HTTPRedirectBinding), saml.HTTPRedirectBinding, saml.HTTPPostBinding)
+	if err != nil {
+		log.Println("Error making SAML request:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if len(r.FormValue("force")) > 0 {
+		force := true
+		req.ForceAuthn = &force
+	}
+
+	relayState, err := randomToken()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	b.lock.Lock()
+	b.expire()
+	full := len(b.logins) >= maxPendingSAMLLogins
+	if !full {
+		b.logins[relayState] = &samlLogin{
+			RequestID:   req.ID,
+			RedirectURI: redir,
+			State:       r.FormValue("state"),
+			Expiry:      time.Now().Add(samlLoginTimeout),
+		}
+	}
+	b.lock.Unlock()
+	if full {
+		http.Error(w, "Too many sign ins in progress, try again later.", http.StatusServiceUnavailable)
+		return
+	}
+
+	u, err := req.Redirect(relayState, b.sp)
+	if err != nil {
+		log.Println("Error making SAML redirect:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+// Values of the attribute with name (or friendly name) name.
+func samlAttribute(a *saml.Assertion, name string) []string {
+	var rv []string
+	for _, st := range a.AttributeStatements {
+		for _, attr := range st.Attributes {
+			if attr.Name == name || attr.FriendlyName == name {
+				for _, v := range attr.Values {
+					rv = append(rv, v.Value)
+				}
+			}
+		}
+	}
+	return rv
+}
+
+// Returns the user's email address and groups from the assertion, as per the config.
+func (b *SAMLBridge) identity(a *saml.Assertion) (string, []string, error) {
+	var email string
+	if len(b.conf.EmailAttribute) > 0 {
+		if vals := samlAttribute(a, b.conf.EmailAttribute); len(vals) > 0 {
+			email = vals[0]
+		}
+	} else if a.Subject != nil && a.Subject.NameID != nil {
+		email = a.Subject.NameID.Value
+	}
+	email = strings.TrimSpace(email)
+	if !strings.Contains(email, "@") {
+		return "", nil, ErrNoSAMLEmail
+	}
+
+	var groups []string
+	if len(b.conf.GroupsAttribute) > 0 {
+		groups = samlAttribute(a, b.conf.GroupsAttribute)
+	}
+	return email, groups, nil
+}
+
+// Receives the IdP's response, and sends the client a pickup code for it.
+func (b *SAMLBridge) acs(w http.ResponseWriter, r *http.Request) {
+	relayState := r.FormValue("RelayState")
+	b.lock.Lock()
+	login, ok := b.logins[relayState]
+	delete(b.logins, relayState)
+	b.lock.Unlock()
+	if !ok || time.Now().After(login.Expiry) {
+		http.Error(w, "Unknown or expired sign in, please try again.", http.StatusBadRequest)
+		return
+	}
+
+	assertion, err := b.sp.ParseResponse(r, []string{login.RequestID})
+	if err != nil {
+		var ire *saml.InvalidResponseError
+		if errors.As(err, &ire) {
+			err = ire.PrivateErr
+		}
+		log.Println("Rejected SAML response:", err)
+		http.Error(w, "Sign in failed.", http.StatusForbidden)
+		return
+	}
+
+	email, groups, err := b.identity(assertion)
+	if err != nil {
+		log.Println("Rejected SAML response:", err)
+		http.Error(w, "Sign in failed.", http.StatusForbidden)
+		return
+	}
+	authTime := time.Now()
+	if len(assertion.AuthnStatements) > 0 && !assertion.AuthnStatements[0].AuthnInstant.IsZero() {
+		authTime = assertion.AuthnStatements[0].AuthnInstant
+	}
+
+	code, err := randomToken()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	b.lock.Lock()
+	b.pickups[code] = &samlPickup{
+		Email:    email,
+		Groups:   groups,
+		AuthTime: authTime,
+		Expiry:   time.Now().Add(samlPickupTimeout),
+	}
+	b.lock.Unlock()
+
+	log.Printf("SAML sign in for %s, issued pickup code.\n", email)
+	http.Redirect(w, r, login.RedirectURI+"?"+url.Values{"code": {code}, "state": {login.State}}.Encode(), http.StatusFound)
+}
+
+// Returns the sign in for code, or nil if unknown or expired. Each code may only be redeemed once.
+func (b *SAMLBridge) redeem(code string) *samlPickup {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.expire()
+	p, ok := b.pickups[code]
+	if !ok {
+		return nil
+	}
+	delete(b.pickups, code)
+	return p
+}
+
+// Issues an ID token for the sign in, in the same form as Google's so that it is accepted
+// wherever they are.
+func (b *SAMLBridge) idToken(p *samlPickup) (string, error) {
+	lifetime := defaultSAMLTokenLifetime
+	if b.conf.TokenLifetimeSeconds > 0 {
+		lifetime = time.Duration(b.conf.TokenLifetimeSeconds) * time.Second
+	}
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            b.issuer,
+		"aud":            b.clientID,
+		"sub":            p.Email,
+		"hd":             p.Email[strings.LastIndex(p.Email, "@")+1:],
+		"email":          p.Email,
+		"email_verified": true,
+		"iat":            now.Unix(),
+		"auth_time":      p.AuthTime.Unix(),
+		"exp":            now.Add(lifetime).Unix(),
+	}
+	if len(p.Groups) > 0 {
+		claims["groups"] = p.Groups
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = samlBridgeKeyID
+	return token.SignedString(b.sp.Key)
+}
+
+// Returns true if idToken says that it was issued by the bridge. Only ValidateIDToken shows that it was.
+func (b *SAMLBridge) issued(idToken string) bool {
+	claims := jwt.MapClaims{}
+	_, _, err := new(jwt.Parser).ParseUnverified(idToken, claims)
+	if err != nil {
+		return false
+	}
+	iss, _ := claims["iss"].(string)
+	return iss == b.issuer
+}
+
+// Validates ID tokens issued by the bridge, as per geecert.ValidateIDToken.
+func (b *SAMLBridge) ValidateIDToken(ctx context.Context, idToken, clientID, hostedDomain string) (*geecert.IDTokenClaims, error) {
+	return geecert.ValidateIDTokenFromIssuer(idToken, b.issuer, clientID, hostedDomain, func(t *jwt.Token) (interface{}, error) {
+		if t.Method.Alg() != "RS256" {
+			return nil, geecert.ErrUnexpectedAlgorithm
+		}
+		return &b.sp.Key.PublicKey, nil
+	})
+}
+
+// Authenticates ID tokens issued by the bridge, leaving others to the next authenticator.
+func (b *SAMLBridge) Authenticate(ctx context.Context, cred *Credential) (*geecert.IDTokenClaims, error) {
+	if cred.Type != pb.CredentialType_ID_TOKEN || !b.issued(cred.Token) {
+		return nil, ErrNotHandled
+	}
+	return b.ValidateIDToken(ctx, cred.Token, b.clientID, b.hostedDomain)
+}
+
+func (s *SSOServer) RedeemPickupCode(ctx context.Context, in *pb.PickupCodeRequest) (*pb.PickupCodeResponse, error) {
+	if s.SAMLBridge == nil {
+		return &pb.PickupCodeResponse{
+			Status: pb.ResponseCode_NOT_AUTHORIZED,
+		}, nil
+	}
+
+	p := s.SAMLBridge.redeem(in.Code)
+	if p == nil {
+		log.Println("Unknown or expired SAML pickup code.")
+		return &pb.PickupCodeResponse{
+			Status: pb.ResponseCode_NOT_AUTHORIZED,
+		}, nil
+	}
+
+	idToken, err := s.SAMLBridge.idToken(p)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Issued SAML bridge ID token for %s.\n", p.Email)
+	return &pb.PickupCodeResponse{
+		Status:  pb.ResponseCode_OK,
+		IdToken: idToken,
+	}, nil
+}
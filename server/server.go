@@ -0,0 +1,721 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+// Package server implements the geecert gRPC services, as run by servegeecerts.
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/continusec/geecert"
+	pb "github.com/continusec/geecert/sso"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+
+	"time"
+
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+
+	"net/http"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Longest reason accepted from a client, to keep certificates a sensible size.
+const maxReasonLength = 256
+
+var ErrBadReason = errors.New("Reason must be a single line of at most 256 characters.")
+
+type SSOServer struct {
+	Config            *pb.ServerConfig
+	Store             Store
+	BreakGlassEnabled bool
+	Telemetry         *TelemetryCounts         // nil if telemetry is not accepted
+	SAMLBridge        *SAMLBridge              // nil if saml_bridge is not set
+	Kerberos          *KerberosAuthenticator   // nil if kerberos is not set
+	Kubernetes        *KubernetesAuthenticator // nil if kubernetes is not set
+	MDM               *MDMClient               // nil if mdm is not set
+	Slack             *SlackClient             // nil if slack is not set
+	Tickets           *TicketWebhookClient     // nil if ticket_webhook is not set
+	GeoIP             *GeoIP                   // nil if geoip_path is not set
+
+	clock  clockState
+	recent recentRequests
+
+	// If set, used to check ID tokens instead of Google's keys, e.g. to accept tokens from a fake IdP in tests
+	IDTokenValidator geecert.IDTokenValidator
+
+	// If set, used to authenticate callers instead of DefaultAuthenticators, e.g. to add other identity sources
+	Authenticators AuthenticatorChain
+}
+
+// Registers both versions of the service with grpcServer, which should be created with ServerOptions,
+// the gRPC health service, and server reflection if grpc_reflection is set.
+func (s *SSOServer) Register(grpcServer *grpc.Server) {
+	pb.RegisterGeeCertServerServer(grpcServer, s)
+	pb.RegisterGeeCertServerV2Server(grpcServer, &SSOServerV2{s})
+	healthpb.RegisterHealthServer(grpcServer, &healthServer{s: s})
+	if s.Config.GrpcReflection {
+		reflection.Register(grpcServer)
+	}
+}
+
+// Generate a host cert for whatever we see
+func (s *SSOServer) makeHostCert(ctx context.Context, w http.ResponseWriter, h string, caType string, from string) {
+	var certToReturn []byte
+	var kt string
+
+	addr := fmt.Sprintf("%s:%d", h, 22)
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	defer conn.Close()
+
+	ssh.NewClientConn(conn, addr, &ssh.ClientConfig{
+		User: "ca",
+		Auth: []ssh.AuthMethod{
+			ssh.Password("wrongpassignoreme"),
+		},
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			if key == nil {
+				return errors.New("no host key")
+			}
+			err := s.clock.check()
+			if err != nil {
+				return err
+			}
+			caKey, err := s.hostCAKey(caType)
+			if err != nil {
+				return err
+			}
+
+			serial, err := s.Store.NextSerial(ctx)
+			if err != nil {
+				return err
+			}
+
+			cert, nva, err := CreateHostCertificate(h, serial, key, caKey, s.Config.CaSignatureAlgorithm, time.Duration(s.Config.GenerateCertDurationSeconds)*time.Second, s.validAfterSkew())
+			if err != nil {
+				return err
+			}
+			kt = key.Type()
+
+			log.Printf("Issued host certificate %d for %s valid until %s (requested from %s).\n", serial, h, nva.Format(time.RFC3339), from)
+
+			certToReturn = cert
+			return errors.New("fail now please")
+		},
+	})
+
+	// Ignore error code for above, as we'll definitely fail due to no creds
+	if len(certToReturn) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	fmt.Fprintf(w, "%s-cert-v01@openssh.com %s %s\n", kt, base64.StdEncoding.EncodeToString(certToReturn), h)
+}
+
+func (s *SSOServer) issueHostCertificate(w http.ResponseWriter, r *http.Request) {
+	h := r.FormValue("host")
+	for _, m := range s.Config.AllowedHosts {
+		matched, err := filepath.Match(m, h)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if matched {
+			s.makeHostCert(r.Context(), w, h, r.FormValue("ca_type"), r.RemoteAddr)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusBadRequest)
+	return
+}
+
+// Serves host certificate requests, and the SAML bridge if set, until ctx is done.
+func (s *SSOServer) StartHTTP(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hostCertificate", s.issueHostCertificate)
+	if s.SAMLBridge != nil {
+		s.SAMLBridge.register(mux)
+	}
+	if s.Config.Bootstrap != nil {
+		s.registerBootstrap(mux)
+	}
+	if s.Config.StatusPage {
+		s.registerStatus(mux)
+	}
+	if s.Slack != nil && len(s.Config.Slack.ApprovalChannel) > 0 {
+		mux.HandleFunc("/slack/interactions", s.serveSlackInteraction)
+	}
+	hs := &http.Server{
+		Addr:        fmt.Sprintf("localhost:%d", s.Config.HttpListenPort),
+		Handler:     s.forwardedHTTP(mux),
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		hs.Close()
+	}()
+	err := hs.ListenAndServe()
+	if err == http.ErrServerClosed {
+		return ctx.Err()
+	}
+	return err
+}
+
+func (s *SSOServer) GetSSHCerts(ctx context.Context, in *pb.SSHCertsRequest) (*pb.SSHCertsResponse, error) {
+	idTokenClaims, err := s.authenticate(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp *pb.SSHCertsResponse
+	if len(in.RequestId) > 0 {
+		resp, err = s.recent.do(ctx, idTokenClaims.EmailAddress, in, func() (*pb.SSHCertsResponse, error) {
+			return s.getSSHCerts(ctx, in, idTokenClaims)
+		})
+	} else {
+		resp, err = s.getSSHCerts(ctx, in, idTokenClaims)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The client already has the same config installed, so needn't be sent it again
+	if len(in.ConfigHash) > 0 && in.ConfigHash == resp.ConfigHash {
+		resp.CertificateAuthorities = nil
+		resp.HostCertificateAuthorities = nil
+		resp.Config = nil
+		resp.ConfigBlocks = nil
+		resp.ConfigUnchanged = true
+	}
+	return resp, nil
+}
+
+func (s *SSOServer) getSSHCerts(ctx context.Context, in *pb.SSHCertsRequest, idTokenClaims *geecert.IDTokenClaims) (*pb.SSHCertsResponse, error) {
+
+	// Impersonators are issued certificates with the principals and config of the other user
+	onBehalfOf := strings.TrimSpace(in.OnBehalfOf)
+	userEmail := idTokenClaims.EmailAddress
+	if len(onBehalfOf) > 0 {
+		if !listed(s.Config.Impersonators, idTokenClaims) || onBehalfOf == idTokenClaims.EmailAddress {
+			log.Printf("Refusing request from %s on behalf of %s.\n", idTokenClaims.EmailAddress, onBehalfOf)
+			return &pb.SSHCertsResponse{
+				Status: pb.ResponseCode_NOT_AUTHORIZED,
+			}, nil
+		}
+		userEmail = onBehalfOf
+	}
+
+	userConf, err := s.Store.LookupUser(ctx, userEmail)
+	if err != nil {
+		return nil, err
+	}
+	if userConf == nil {
+		return &pb.SSHCertsResponse{
+			Status: pb.ResponseCode_NO_CERTS_ALLOWED,
+		}, nil
+	}
+
+	st, err := s.checkNetwork(ctx, idTokenClaims, userConf)
+	if err != nil {
+		return nil, err
+	}
+	if st != pb.ResponseCode_OK {
+		return &pb.SSHCertsResponse{
+			Status: st,
+		}, nil
+	}
+
+	maxAuthAge := s.Config.MaxAuthAgeSeconds
+	if userConf.MaxAuthAgeSeconds > 0 {
+		maxAuthAge = userConf.MaxAuthAgeSeconds
+	}
+	if maxAuthAge > 0 && time.Since(idTokenClaims.AuthTime) > time.Duration(maxAuthAge)*time.Second {
+		log.Printf("Requiring %s to authenticate again before issuing certificate.\n", idTokenClaims.EmailAddress)
+		return &pb.SSHCertsResponse{
+			Status:            pb.ResponseCode_REAUTH_REQUIRED,
+			MaxAuthAgeSeconds: maxAuthAge,
+		}, nil
+	}
+
+	rpk, err := base64.StdEncoding.DecodeString(in.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	keyToSign, err := ssh.ParsePublicKey(rpk)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Config.MaxCertsPerUserPerDay > 0 {
+		issued, err := s.Store.CountIssuedSince(ctx, idTokenClaims.EmailAddress, time.Now().Add(-24*time.Hour))
+		if err != nil {
+			return nil, err
+		}
+		if issued >= int(s.Config.MaxCertsPerUserPerDay) {
+			log.Printf("Refusing to issue certificate to %s as %d have been issued in the past day.\n", idTokenClaims.EmailAddress, issued)
+			return &pb.SSHCertsResponse{
+				Status: pb.ResponseCode_RATE_LIMITED,
+			}, nil
+		}
+	}
+
+	fingerprint := ssh.FingerprintSHA256(keyToSign)
+	revoked, err := s.Store.IsRevoked(ctx, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		log.Printf("Refusing to sign revoked key %s for %s.\n", fingerprint, idTokenClaims.EmailAddress)
+		return &pb.SSHCertsResponse{
+			Status: pb.ResponseCode_NO_CERTS_ALLOWED,
+		}, nil
+	}
+
+	// The ticket will do as the reason for an access request
+	reason := strings.TrimSpace(in.Reason)
+	if len(reason) == 0 && in.AccessRequest != nil {
+		reason = strings.TrimSpace(in.AccessRequest.Ticket)
+	}
+	if len(reason) == 0 && (s.Config.RequireReason || len(onBehalfOf) > 0) {
+		log.Printf("Refusing to issue certificate to %s without a reason.\n", idTokenClaims.EmailAddress)
+		return &pb.SSHCertsResponse{
+			Status: pb.ResponseCode_REASON_REQUIRED,
+		}, nil
+	}
+	if len(reason) > maxReasonLength || strings.ContainsAny(reason, "\r\n") {
+		return nil, ErrBadReason
+	}
+
+	critOpts, err := criticalOptions(ctx, sourceAddressPolicy(s.Config, userConf), in.SourceAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	principals := append([]string{userConf.Username}, userConf.ExtraPrincipals...)
+	duration := time.Duration(s.Config.GenerateCertDurationSeconds) * time.Second
+	var grant *jitGrant
+	if in.AccessRequest != nil {
+		grant, st, err = s.checkAccessRequest(ctx, idTokenClaims, in.AccessRequest, reason)
+		if err != nil {
+			return nil, err
+		}
+		if st != pb.ResponseCode_OK {
+			return &pb.SSHCertsResponse{
+				Status: st,
+			}, nil
+		}
+		principals = addPrincipals(principals, grant.Principals)
+		duration = grant.Duration
+	}
+
+	offHours := s.outsideWorkingHours(idTokenClaims, principals, time.Now())
+	if offHours && len(in.ApprovalId) == 0 {
+		log.Printf("Requiring approval for %s outside working hours.\n", idTokenClaims.EmailAddress)
+	}
+	var approval *Approval
+	if s.needsApproval(principals) || len(onBehalfOf) > 0 || (grant != nil && grant.Approval) || offHours {
+		var status pb.ResponseCode
+		var approvalID string
+		status, approvalID, approval, err = s.checkApproval(ctx, idTokenClaims.EmailAddress, onBehalfOf, fingerprint, principals, in.ApprovalId)
+		if err != nil {
+			return nil, err
+		}
+		if status != pb.ResponseCode_OK {
+			return &pb.SSHCertsResponse{
+				Status:     status,
+				ApprovalId: approvalID,
+			}, nil
+		}
+	}
+
+	deviceID, st, err := s.checkDevice(ctx, idTokenClaims.EmailAddress, in, keyToSign)
+	if err != nil {
+		return nil, err
+	}
+	if st != pb.ResponseCode_OK {
+		return &pb.SSHCertsResponse{
+			Status: st,
+		}, nil
+	}
+
+	st, err = s.checkUnexpiredCerts(ctx, idTokenClaims.EmailAddress, fingerprint, in.ReplacesFingerprint)
+	if err != nil {
+		return nil, err
+	}
+	if st != pb.ResponseCode_OK {
+		return &pb.SSHCertsResponse{
+			Status: st,
+		}, nil
+	}
+
+	if approval != nil {
+		ok, err := s.useApproval(ctx, approval, idTokenClaims.EmailAddress)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return &pb.SSHCertsResponse{
+				Status: pb.ResponseCode_NOT_AUTHORIZED,
+			}, nil
+		}
+	}
+
+	return s.issueUserCert(ctx, idTokenClaims.EmailAddress, userConf, principals, keyToSign, fingerprint, duration, critOpts, reason, deviceID, onBehalfOf, grant, false)
+}
+
+// Signs keyToSign, records the issuance, and returns the response for the client.
+// Break glass certificates are marked as such in their key ID. If onBehalfOf is set, userConf
+// is that user's and email is the impersonator's. grant, if any, is recorded in the key ID.
+func (s *SSOServer) issueUserCert(ctx context.Context, email string, userConf *pb.ServerConfig_UserConfig, principals []string, keyToSign ssh.PublicKey, fingerprint string, duration time.Duration, critOpts map[string]string, reason string, deviceID string, onBehalfOf string, grant *jitGrant, breakGlass bool) (*pb.SSHCertsResponse, error) {
+	configEmail := email
+	if len(onBehalfOf) > 0 {
+		configEmail = onBehalfOf
+	}
+	configVars := configVariables(s.Config, userConf, configEmail)
+	exts := certExtensions(s.Config, userConf, configVars, reason)
+	if len(s.Config.DeviceExtension) > 0 && len(deviceID) > 0 {
+		exts[s.Config.DeviceExtension] = deviceID
+	}
+	if len(onBehalfOf) > 0 {
+		exts[s.Config.OnBehalfOfExtension] = onBehalfOf
+	}
+
+	req, err := newCertRequest(email, principals, fingerprint, deviceID, breakGlass, configVars)
+	if err != nil {
+		return nil, err
+	}
+	req.OnBehalfOf = onBehalfOf
+	if grant != nil {
+		req.Role = grant.Role
+		req.Ticket = grant.Ticket
+	}
+
+	cert, ourCAPubKey, err := s.signUserCert(ctx, s.Config.CaKeyPath, "", req, keyToSign, duration, critOpts, exts, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	realmCerts, err := s.issueRealmCerts(ctx, req, userConf, keyToSign, duration, critOpts, exts, reason)
+	if err != nil {
+		return nil, err
+	}
+	if s.Slack != nil {
+		go s.Slack.notifyIssued(req, time.Now().Add(duration))
+	}
+
+	configBlocks := sshConfigBlocks(s.Config, userConf.Username)
+
+	// Older clients only understand $CERTNAME, so substitute what we can for them
+	var configLines []string
+	for _, line := range geecert.RenderSSHConfigBlocks(configBlocks) {
+		configLines = append(configLines, geecert.ExpandConfigVariables(line, configVars))
+	}
+
+	cas := s.hostCertificateAuthorities(ourCAPubKey)
+	rv := &pb.SSHCertsResponse{
+		Status:                     pb.ResponseCode_OK,
+		Certificate:                cert,
+		CertificateAuthorities:     geecert.RenderHostCertificateAuthorities(cas),
+		HostCertificateAuthorities: cas,
+		Config:                     configLines,
+		ConfigBlocks:               configBlocks,
+		ConfigVariables:            configVars,
+		BastionPolicies:            s.Config.BastionPolicy,
+		RealmCertificates:          realmCerts,
+		Directives:                 s.Config.ClientDirectives,
+		AllowedSigners:             s.allowedSigners(ourCAPubKey),
+	}
+	rv.ConfigHash, err = configHash(rv)
+	if err != nil {
+		return nil, err
+	}
+	return rv, nil
+}
+
+// Hash of the parts of the response that the client installs in known_hosts and the ssh config.
+func configHash(resp *pb.SSHCertsResponse) (string, error) {
+	var buf proto.Buffer
+	buf.SetDeterministic(true)
+	err := buf.Marshal(&pb.SSHCertsResponse{
+		CertificateAuthorities:     resp.CertificateAuthorities,
+		HostCertificateAuthorities: resp.HostCertificateAuthorities,
+		Config:                     resp.Config,
+		ConfigBlocks:               resp.ConfigBlocks,
+		ConfigVariables:            resp.ConfigVariables,
+	})
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256(buf.Bytes())
+	return hex.EncodeToString(h[:]), nil
+}
+
+// Signs keyToSign with the CA key at caKeyPath (of realm, if any) and records the issuance.
+// Returns the certificate in authorized_keys format, and the CA public key.
+func (s *SSOServer) signUserCert(ctx context.Context, caKeyPath string, realm string, req *certRequest, keyToSign ssh.PublicKey, duration time.Duration, critOpts map[string]string, exts map[string]string, reason string) (string, ssh.PublicKey, error) {
+	err := s.clock.check()
+	if err != nil {
+		return "", nil, err
+	}
+	caKey, err := LoadCAKey(caKeyPath)
+	if err != nil {
+		return "", nil, err
+	}
+	ourCAPubKey := caKey.PublicKey()
+
+	serial, err := s.Store.NextSerial(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	keyID := certKeyID(s.Config.KeyIdTemplate, req, realm, serial)
+	skew := s.validAfterSkew()
+	now := time.Now()
+	cert, nva, err := CreateUserCertificate(req.Principals, keyID, serial, keyToSign, caKey, s.Config.CaSignatureAlgorithm, duration, skew, critOpts, exts)
+	if err != nil {
+		return "", nil, err
+	}
+
+	err = s.Store.RecordIssuedCert(ctx, &IssuedCert{
+		Serial:      serial,
+		Email:       req.Email,
+		KeyID:       keyID,
+		Principals:  req.Principals,
+		Fingerprint: req.Fingerprint,
+		DeviceID:    req.DeviceID,
+		OnBehalfOf:  req.OnBehalfOf,
+		ValidAfter:  now.Add(-skew),
+		ValidBefore: *nva,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	from := "unknown"
+	if ip, err := peerIP(ctx); err == nil {
+		from = ip.String()
+	}
+	if len(reason) > 0 {
+		log.Printf("Issued certificate %d to %s valid until %s (request %s from %s). Reason: %q\n", serial, req.label(), nva.Format(time.RFC3339), req.RequestID, from, reason)
+	} else {
+		log.Printf("Issued certificate %d to %s valid until %s (request %s from %s).\n", serial, req.label(), nva.Format(time.RFC3339), req.RequestID, from)
+	}
+
+	return fmt.Sprintf("%s-cert-v01@openssh.com %s %s\n", keyToSign.Type(), base64.StdEncoding.EncodeToString(cert), req.Email), ourCAPubKey, nil
+}
+
+// CAs for a known_hosts file: our CA, any parallel CAs and any additional_host_ca_key, for
+// hosts in ca_host_pattern, and each additional_host_ca.
+func (s *SSOServer) hostCertificateAuthorities(caPubKey ssh.PublicKey) []*pb.HostCertificateAuthority {
+	patterns := caHostPatterns(s.Config.CaHostPattern, s.Config.ClientConfigScope)
+	rv := []*pb.HostCertificateAuthority{hostCA(patterns, caPubKey, s.Config.CaComment)}
+	for _, path := range s.Config.ParallelCaKeyPath {
+		pk, err := caPublicKey(path)
+		if err != nil {
+			log.Println("Ignoring bad parallel_ca_key_path:", err)
+			continue
+		}
+		rv = append(rv, hostCA(patterns, pk, s.Config.CaComment))
+	}
+	for _, k := range s.Config.AdditionalHostCaKey {
+		pk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(k))
+		if err != nil {
+			log.Println("Ignoring bad additional_host_ca_key:", err)
+			continue
+		}
+		rv = append(rv, hostCA(patterns, pk, s.Config.CaComment))
+	}
+	return append(rv, s.Config.AdditionalHostCa...)
+}
+
+// Lines for an allowed signers file, trusting certificates from caPubKey and each
+// parallel_ca_key_path for the usernames in allowed_users, so that signatures, e.g. of git
+// commits, verify as the user who made them. Host CAs, which may be run by others, aren't trusted.
+func (s *SSOServer) allowedSigners(caPubKey ssh.PublicKey) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, uc := range s.Config.AllowedUsers {
+		// Usernames are principals in the file, so must not be taken as a list or patterns
+		if len(uc.Username) == 0 || seen[uc.Username] || strings.ContainsAny(uc.Username, ",*?! \t\"") {
+			continue
+		}
+		seen[uc.Username] = true
+		names = append(names, uc.Username)
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+
+	keys := []ssh.PublicKey{caPubKey}
+	for _, path := range s.Config.ParallelCaKeyPath {
+		pk, err := caPublicKey(path)
+		if err != nil {
+			log.Println("Ignoring bad parallel_ca_key_path:", err)
+			continue
+		}
+		keys = append(keys, pk)
+	}
+	var rv []string
+	for _, k := range keys {
+		rv = append(rv, strings.Join(names, ",")+" cert-authority "+strings.TrimSpace(string(ssh.MarshalAuthorizedKey(k))))
+	}
+	return rv
+}
+
+// ca_host_pattern if set, else the patterns in client_config_scope.
+func caHostPatterns(caHostPattern []string, clientConfigScope string) []string {
+	if len(caHostPattern) > 0 {
+		return caHostPattern
+	}
+	return geecert.HostPatterns(clientConfigScope)
+}
+
+// Trusts caPubKey for hosts matching patterns.
+func hostCA(patterns []string, caPubKey ssh.PublicKey, comment string) *pb.HostCertificateAuthority {
+	return &pb.HostCertificateAuthority{
+		HostPattern: patterns,
+		PublicKey:   strings.TrimSpace(string(ssh.MarshalAuthorizedKey(caPubKey))),
+		Comment:     comment,
+	}
+}
+
+func LoadPrivateKeyFromPEM(path string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decode PEM
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("Bad PEM")
+	}
+	if block.Type != "RSA PRIVATE KEY" {
+		return nil, errors.New("Unexpected block")
+	}
+
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// Used when ca_signature_algorithm is not set, as OpenSSH 8.8 and later reject ssh-rsa (SHA-1) CA signatures.
+const DefaultCASignatureAlgorithm = ssh.KeyAlgoRSASHA512
+
+// Returns a signer for an RSA CA key that only signs with algorithm, or DefaultCASignatureAlgorithm
+// if empty. Other types of key only have one algorithm, so are returned as is.
+func caSigner(signer ssh.Signer, algorithm string) (ssh.Signer, error) {
+	if signer.PublicKey().Type() != ssh.KeyAlgoRSA {
+		return signer, nil
+	}
+	if len(algorithm) == 0 {
+		algorithm = DefaultCASignatureAlgorithm
+	}
+	as, ok := signer.(ssh.AlgorithmSigner)
+	if !ok {
+		return nil, errors.New("CA key does not support choosing a signature algorithm")
+	}
+	return ssh.NewSignerWithAlgorithms(as, []string{algorithm})
+}
+
+// Creates a certificate valid for duration from now, and from skew before now.
+func CreateHostCertificate(hostname string, serial uint64, keyToSign ssh.PublicKey, signingKey ssh.Signer, sigAlgorithm string, duration time.Duration, skew time.Duration) ([]byte, *time.Time, error) {
+	signer, err := caSigner(signingKey, sigAlgorithm)
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	end := now.Add(duration)
+	cert := ssh.Certificate{
+		Key:             keyToSign,
+		Serial:          serial,
+		CertType:        ssh.HostCert,
+		KeyId:           hostname,
+		ValidPrincipals: []string{hostname},
+		ValidAfter:      uint64(now.Add(-skew).Unix()),
+		ValidBefore:     uint64(end.Unix()),
+	}
+	err = cert.SignCert(rand.Reader, signer)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert.Marshal(), &end, nil
+}
+
+func (s *SSOServer) validAfterSkew() time.Duration {
+	return time.Duration(s.Config.ValidAfterSkewSeconds) * time.Second
+}
+
+func userCertKeyID(usernames []string, emailAddress string) string {
+	return strings.Join(usernames, "/") + " (for " + emailAddress + ")"
+}
+
+// Creates a certificate valid for duration from now, and from skew before now.
+func CreateUserCertificate(usernames []string, keyID string, serial uint64, keyToSign ssh.PublicKey, signingKey ssh.Signer, sigAlgorithm string, duration time.Duration, skew time.Duration, critOpts map[string]string, perms map[string]string) ([]byte, *time.Time, error) {
+	signer, err := caSigner(signingKey, sigAlgorithm)
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	end := now.Add(duration)
+	cert := ssh.Certificate{
+		Key:             keyToSign,
+		Serial:          serial,
+		CertType:        ssh.UserCert,
+		KeyId:           keyID,
+		ValidPrincipals: usernames,
+		ValidAfter:      uint64(now.Add(-skew).Unix()),
+		ValidBefore:     uint64(end.Unix()),
+		Permissions: ssh.Permissions{
+			CriticalOptions: critOpts,
+			Extensions:      perms,
+		},
+	}
+	err = cert.SignCert(rand.Reader, signer)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert.Marshal(), &end, nil
+}
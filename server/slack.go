@@ -0,0 +1,437 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/continusec/geecert"
+	pb "github.com/continusec/geecert/sso"
+)
+
+const (
+	defaultSlackAPIURL = "https://slack.com/api"
+	slackRequestMaxAge = 5 * time.Minute
+
+	slackActionApprove = "approve"
+	slackActionDeny    = "deny"
+)
+
+var (
+	ErrBadSlackSignature = errors.New("Bad Slack request signature.")
+)
+
+// Posts to Slack with a bot token, see ServerConfig.Slack.
+type SlackClient struct {
+	conf          *pb.ServerConfig_Slack
+	token         string
+	signingSecret []byte
+
+	lock    sync.Mutex
+	userIDs map[string]string // by email
+}
+
+func NewSlackClient(conf *pb.ServerConfig) (*SlackClient, error) {
+	sc := conf.Slack
+	b, err := os.ReadFile(sc.BotTokenPath)
+	if err != nil {
+		return nil, fmt.Errorf("slack bot_token_path: %w", err)
+	}
+	rv := &SlackClient{
+		conf:    sc,
+		token:   strings.TrimSpace(string(b)),
+		userIDs: make(map[string]string),
+	}
+	if len(sc.SigningSecretPath) > 0 {
+		b, err = os.ReadFile(sc.SigningSecretPath)
+		if err != nil {
+			return nil, fmt.Errorf("slack signing_secret_path: %w", err)
+		}
+		rv.signingSecret = bytes.TrimSpace(b)
+	}
+	return rv, nil
+}
+
+func validateSlack(sc *pb.ServerConfig_Slack) error {
+	if len(sc.BotTokenPath) == 0 {
+		return errors.New("bot_token_path must be set")
+	}
+	if len(sc.ApprovalChannel) > 0 && len(sc.SigningSecretPath) == 0 {
+		return errors.New("signing_secret_path must be set for approval_channel")
+	}
+	if !sc.NotifyIssued && len(sc.ApprovalChannel) == 0 {
+		return errors.New("notify_issued or approval_channel must be set")
+	}
+	return nil
+}
+
+func (c *SlackClient) apiURL(method string) string {
+	if len(c.conf.ApiUrl) > 0 {
+		return strings.TrimSuffix(c.conf.ApiUrl, "/") + "/" + method
+	}
+	return defaultSlackAPIURL + "/" + method
+}
+
+// Calls a Slack Web API method, with a JSON body if in is not nil, else as a GET with params.
+func (c *SlackClient) call(ctx context.Context, method string, params url.Values, in interface{}, out interface{}) error {
+	var req *http.Request
+	var err error
+	if in != nil {
+		body, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL(method), bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	} else {
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, c.apiURL(method)+"?"+params.Encode(), nil)
+		if err != nil {
+			return err
+		}
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack %s: %s", method, resp.Status)
+	}
+
+	// Slack reports errors in the body, with a 200
+	var status struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	err = json.Unmarshal(b, &status)
+	if err != nil {
+		return err
+	}
+	if !status.OK {
+		return fmt.Errorf("slack %s: %s", method, status.Error)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(b, out)
+}
+
+func (c *SlackClient) postMessage(ctx context.Context, channel, text string, blocks []interface{}) error {
+	msg := map[string]interface{}{
+		"channel": channel,
+		"text":    text,
+	}
+	if len(blocks) > 0 {
+		msg["blocks"] = blocks
+	}
+	return c.call(ctx, "chat.postMessage", nil, msg, nil)
+}
+
+// Returns the Slack user ID of the user with this email address.
+func (c *SlackClient) userID(ctx context.Context, email string) (string, error) {
+	c.lock.Lock()
+	id, ok := c.userIDs[email]
+	c.lock.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	var resp struct {
+		User struct {
+			ID string `json:"id"`
+		} `json:"user"`
+	}
+	err := c.call(ctx, "users.lookupByEmail", url.Values{"email": {email}}, nil, &resp)
+	if err != nil {
+		return "", err
+	}
+
+	c.lock.Lock()
+	c.userIDs[email] = resp.User.ID
+	c.lock.Unlock()
+	return resp.User.ID, nil
+}
+
+// Returns the email address of the Slack user with this ID.
+func (c *SlackClient) userEmail(ctx context.Context, id string) (string, error) {
+	var resp struct {
+		User struct {
+			Profile struct {
+				Email string `json:"email"`
+			} `json:"profile"`
+		} `json:"user"`
+	}
+	err := c.call(ctx, "users.info", url.Values{"user": {id}}, nil, &resp)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.User.Profile.Email) == 0 {
+		return "", fmt.Errorf("no email address for Slack user %s", id)
+	}
+	return resp.User.Profile.Email, nil
+}
+
+// DMs the user about a certificate issued for them, if notify_issued is set.
+func (c *SlackClient) notifyIssued(req *certRequest, validBefore time.Time) {
+	if !c.conf.NotifyIssued {
+		return
+	}
+	ctx := context.Background()
+	id, err := c.userID(ctx, req.Email)
+	if err != nil {
+		log.Printf("Error notifying %s on Slack: %s\n", req.Email, err)
+		return
+	}
+
+	what := "An SSH certificate"
+	if req.BreakGlass {
+		what = "A break glass SSH certificate"
+	}
+	text := fmt.Sprintf("%s was just issued for you, for %s, valid until %s.\nKey: %s", what, strings.Join(req.Principals, ", "), validBefore.Format(time.RFC1123), req.Fingerprint)
+	if len(req.OnBehalfOf) > 0 {
+		text += "\nOn behalf of: " + req.OnBehalfOf
+	}
+	if len(req.DeviceID) > 0 {
+		text += "\nDevice: " + req.DeviceID
+	}
+	text += fmt.Sprintf("\nRequest: %s\nIf this wasn't you, tell your security team now.", req.RequestID)
+
+	err = c.postMessage(ctx, id, text, nil)
+	if err != nil {
+		log.Printf("Error notifying %s on Slack: %s\n", req.Email, err)
+	}
+
+	// The user being impersonated should know too
+	if len(req.OnBehalfOf) > 0 {
+		id, err = c.userID(ctx, req.OnBehalfOf)
+		if err == nil {
+			err = c.postMessage(ctx, id, fmt.Sprintf("%s was just issued an SSH certificate with your access, for %s, valid until %s.\nRequest: %s", req.Email, strings.Join(req.Principals, ", "), validBefore.Format(time.RFC1123), req.RequestID), nil)
+		}
+		if err != nil {
+			log.Printf("Error notifying %s on Slack: %s\n", req.OnBehalfOf, err)
+		}
+	}
+}
+
+// Posts a new request for approval to approval_channel, with buttons to approve or deny it.
+func (c *SlackClient) postApproval(a *Approval) {
+	if len(c.conf.ApprovalChannel) == 0 {
+		return
+	}
+	text := fmt.Sprintf("%s requests an SSH certificate.", a.summary())
+	blocks := []interface{}{
+		map[string]interface{}{
+			"type": "section",
+			"text": map[string]string{"type": "mrkdwn", "text": text + fmt.Sprintf("\nKey: `%s`\nApproval: `%s`", a.Fingerprint, a.ID)},
+		},
+		map[string]interface{}{
+			"type": "actions",
+			"elements": []interface{}{
+				map[string]interface{}{
+					"type":      "button",
+					"text":      map[string]string{"type": "plain_text", "text": "Approve"},
+					"style":     "primary",
+					"action_id": slackActionApprove,
+					"value":     a.ID,
+				},
+				map[string]interface{}{
+					"type":      "button",
+					"text":      map[string]string{"type": "plain_text", "text": "Deny"},
+					"style":     "danger",
+					"action_id": slackActionDeny,
+					"value":     a.ID,
+				},
+			},
+		},
+	}
+	err := c.postMessage(context.Background(), c.conf.ApprovalChannel, text, blocks)
+	if err != nil {
+		log.Println("Error posting approval to Slack:", err)
+	}
+}
+
+// Checks the signature Slack makes over each request with the app's signing secret.
+func (c *SlackClient) verifyRequest(r *http.Request, body []byte) error {
+	ts := r.Header.Get("X-Slack-Request-Timestamp")
+	secs, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrBadSlackSignature
+	}
+	age := time.Since(time.Unix(secs, 0))
+	if age > slackRequestMaxAge || age < -slackRequestMaxAge {
+		return ErrBadSlackSignature
+	}
+
+	mac := hmac.New(sha256.New, c.signingSecret)
+	fmt.Fprintf(mac, "v0:%s:", ts)
+	mac.Write(body)
+	want := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(want), []byte(r.Header.Get("X-Slack-Signature"))) {
+		return ErrBadSlackSignature
+	}
+	return nil
+}
+
+// Replaces the message with the buttons with text via the response URL of an interaction, or if
+// not replace, shows text to the user who clicked.
+func (c *SlackClient) respond(ctx context.Context, responseURL, text string, replace bool) error {
+	msg := map[string]interface{}{
+		"replace_original": replace,
+		"text":             text,
+	}
+	if !replace {
+		msg["response_type"] = "ephemeral"
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, responseURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack response_url: %s", resp.Status)
+	}
+	return nil
+}
+
+// Handles clicks on the approve and deny buttons. The Slack user must be an approver,
+// identified by the email address in their Slack profile.
+func (s *SSOServer) serveSlackInteraction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	err = s.Slack.verifyRequest(r, body)
+	if err != nil {
+		log.Println("Refusing Slack interaction:", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var payload struct {
+		Type string `json:"type"`
+		User struct {
+			ID string `json:"id"`
+		} `json:"user"`
+		Actions []struct {
+			ActionID string `json:"action_id"`
+			Value    string `json:"value"`
+		} `json:"actions"`
+		ResponseURL string `json:"response_url"`
+	}
+	err = json.Unmarshal([]byte(form.Get("payload")), &payload)
+	if err != nil || payload.Type != "block_actions" || len(payload.Actions) != 1 {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	action := payload.Actions[0]
+	if action.ActionID != slackActionApprove && action.ActionID != slackActionDeny {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// Slack wants an answer within 3 seconds, so decide and update the message afterwards
+	w.WriteHeader(http.StatusOK)
+	go func() {
+		ctx := context.Background()
+		text, decided, err := s.slackDecision(ctx, payload.User.ID, action.Value, action.ActionID == slackActionApprove)
+		if err != nil {
+			log.Println("Error handling Slack interaction:", err)
+			return
+		}
+		err = s.Slack.respond(ctx, payload.ResponseURL, text, decided)
+		if err != nil {
+			log.Println("Error handling Slack interaction:", err)
+		}
+	}()
+}
+
+// Decides the approval on behalf of the Slack user. Returns the text to replace the message
+// with, and true, or if the user may not decide it, text saying so, and false.
+func (s *SSOServer) slackDecision(ctx context.Context, slackUser, approvalID string, approve bool) (string, bool, error) {
+	email, err := s.Slack.userEmail(ctx, slackUser)
+	if err != nil {
+		return "", false, err
+	}
+	if !s.isApprover(&geecert.IDTokenClaims{EmailAddress: email}) {
+		log.Printf("Refusing approval request from %s on Slack.\n", email)
+		return "You are not an approver.", false, nil
+	}
+
+	status, err := s.decideApproval(ctx, approvalID, email, approve)
+	if err != nil {
+		return "", false, err
+	}
+	if status != pb.ResponseCode_OK {
+		return "This request can't be decided, either because it's your own or because it has already been decided.", false, nil
+	}
+	a, err := s.Store.GetApproval(ctx, approvalID)
+	if err != nil {
+		return "", false, err
+	}
+
+	decision := "Denied"
+	if approve {
+		decision = "Approved"
+	}
+	return fmt.Sprintf("%s by %s: SSH certificate for %s, for %s.", decision, email, a.Email, strings.Join(a.Principals, ", ")), true, nil
+}
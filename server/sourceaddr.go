@@ -0,0 +1,140 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc/peer"
+
+	pb "github.com/continusec/geecert/sso"
+)
+
+const (
+	SourceAddressObserved = "observed"
+	SourceAddressClient   = "client"
+	SourceAddressNone     = "none"
+)
+
+var (
+	ErrNoPeerAddress    = errors.New("Unable to determine address of client.")
+	ErrBadSourceAddress = errors.New("Source address must be a comma separated list of CIDRs.")
+)
+
+// The source_address policy for a user, which may be nil for break glass users.
+func sourceAddressPolicy(conf *pb.ServerConfig, userConf *pb.ServerConfig_UserConfig) string {
+	if userConf != nil && len(userConf.SourceAddress) > 0 {
+		return userConf.SourceAddress
+	}
+	return conf.SourceAddress
+}
+
+// Parses a comma separated list of CIDRs (or bare addresses), returning them normalized.
+func parseCIDRList(s string) (string, error) {
+	var rv []string
+	for _, c := range strings.Split(s, ",") {
+		c = strings.TrimSpace(c)
+		if !strings.Contains(c, "/") {
+			ip := net.ParseIP(c)
+			if ip == nil {
+				return "", ErrBadSourceAddress
+			}
+			rv = append(rv, hostCIDR(ip))
+			continue
+		}
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			return "", ErrBadSourceAddress
+		}
+		rv = append(rv, n.String())
+	}
+	return strings.Join(rv, ","), nil
+}
+
+// A CIDR matching only ip.
+func hostCIDR(ip net.IP) string {
+	if ip.To4() != nil {
+		return fmt.Sprintf("%s/32", ip)
+	}
+	return fmt.Sprintf("%s/128", ip)
+}
+
+// The address the request came from.
+func peerIP(ctx context.Context) (net.IP, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return nil, ErrNoPeerAddress
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return nil, err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil, ErrNoPeerAddress
+	}
+	return ip, nil
+}
+
+// The address the request came from, as a CIDR.
+func observedAddress(ctx context.Context) (string, error) {
+	ip, err := peerIP(ctx)
+	if err != nil {
+		return "", err
+	}
+	return hostCIDR(ip), nil
+}
+
+// Critical options for a certificate, per the policy. requested is as sent by the client.
+func criticalOptions(ctx context.Context, policy string, requested string) (map[string]string, error) {
+	var addr string
+	var err error
+	switch policy {
+	case "", SourceAddressNone:
+		return nil, nil
+	case SourceAddressObserved:
+		addr, err = observedAddress(ctx)
+	case SourceAddressClient:
+		if len(strings.TrimSpace(requested)) > 0 {
+			addr, err = parseCIDRList(requested)
+		} else {
+			addr, err = observedAddress(ctx)
+		}
+	default:
+		addr, err = parseCIDRList(policy)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"source-address": addr}, nil
+}
+
+// Checks a source_address policy from the server config.
+func validateSourceAddressPolicy(policy string) error {
+	switch policy {
+	case "", SourceAddressNone, SourceAddressObserved, SourceAddressClient:
+		return nil
+	}
+	_, err := parseCIDRList(policy)
+	return err
+}
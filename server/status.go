@@ -0,0 +1,189 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/continusec/geecert"
+)
+
+const statusCheckTimeout = 5 * time.Second
+
+// What /status shows, see ServerConfig.status_page.
+type Status struct {
+	Healthy                bool              `json:"healthy"`
+	Checks                 []StatusCheck     `json:"checks"`
+	ServerVersion          string            `json:"server_version"`
+	ConfigVersion          uint32            `json:"config_version"`
+	ConfigDigest           string            `json:"config_digest"` // changes whenever the policy does
+	MinClientVersion       string            `json:"min_client_version,omitempty"`
+	MinClientVersionByName map[string]string `json:"min_client_version_by_name,omitempty"`
+	CertificateAuthorities []StatusCA        `json:"certificate_authorities"`
+	Time                   time.Time         `json:"time"`
+}
+
+type StatusCheck struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+type StatusCA struct {
+	Source      string `json:"source"` // config field it is from, e.g. ca_key_path
+	Signs       string `json:"signs"`
+	Type        string `json:"type"`
+	Fingerprint string `json:"fingerprint"`
+	Comment     string `json:"comment,omitempty"`
+}
+
+var statusTemplate = template.Must(template.New("status").Parse(`<!DOCTYPE html>
+<html>
+<head><title>SSH certificate authority status</title></head>
+<body>
+<h1>SSH certificate authority {{if .Healthy}}OK{{else}}UNHEALTHY{{end}}</h1>
+<table>
+{{range .Checks}}<tr><td>{{.Name}}</td><td>{{if .OK}}OK{{else}}{{.Error}}{{end}}</td></tr>
+{{end}}</table>
+<h2>Certificate authorities</h2>
+<table>
+<tr><th>Fingerprint</th><th>Type</th><th>Signs</th><th>Source</th><th>Comment</th></tr>
+{{range .CertificateAuthorities}}<tr><td><code>{{.Fingerprint}}</code></td><td>{{.Type}}</td><td>{{.Signs}}</td><td>{{.Source}}</td><td>{{.Comment}}</td></tr>
+{{end}}</table>
+<h2>Versions</h2>
+<table>
+<tr><td>Server</td><td>{{.ServerVersion}}</td></tr>
+<tr><td>Config</td><td>{{.ConfigVersion}} ({{.ConfigDigest}})</td></tr>
+<tr><td>Minimum client</td><td>{{if .MinClientVersion}}{{.MinClientVersion}}{{else}}none{{end}}</td></tr>
+{{range $name, $v := .MinClientVersionByName}}<tr><td>Minimum {{$name}}</td><td>{{$v}}</td></tr>
+{{end}}</table>
+<p>As of {{.Time.Format "2006-01-02 15:04:05 MST"}}</p>
+</body>
+</html>
+`))
+
+// Serves the status page at /status and /status.json.
+func (s *SSOServer) registerStatus(mux *http.ServeMux) {
+	mux.HandleFunc("/status", s.serveStatus)
+	mux.HandleFunc("/status.json", s.serveStatus)
+}
+
+func (s *SSOServer) status(ctx context.Context) *Status {
+	rv := &Status{
+		Healthy:                true,
+		ServerVersion:          geecert.Version,
+		ConfigVersion:          s.Config.ConfigVersion,
+		MinClientVersion:       s.Config.MinClientVersion,
+		MinClientVersionByName: s.Config.MinClientVersionByName,
+		Time:                   time.Now(),
+	}
+	check := func(name string, err error) {
+		c := StatusCheck{Name: name, OK: err == nil}
+		if err != nil {
+			c.Error = err.Error()
+			rv.Healthy = false
+		}
+		rv.Checks = append(rv.Checks, c)
+	}
+	addCA := func(source, signs string, pk ssh.PublicKey, comment string) {
+		rv.CertificateAuthorities = append(rv.CertificateAuthorities, StatusCA{
+			Source:      source,
+			Signs:       signs,
+			Type:        pk.Type(),
+			Fingerprint: ssh.FingerprintSHA256(pk),
+			Comment:     comment,
+		})
+	}
+
+	var caErr error
+	for i, path := range s.caKeyPaths() {
+		source := "ca_key_path"
+		if i > 0 {
+			source = "parallel_ca_key_path"
+		}
+		pk, err := caPublicKey(path)
+		if err != nil {
+			caErr = err
+			continue
+		}
+		addCA(source, "users and hosts", pk, s.Config.CaComment)
+	}
+	for _, r := range s.Config.Realm {
+		pk, err := caPublicKey(r.CaKeyPath)
+		if err != nil {
+			caErr = err
+			continue
+		}
+		addCA("realm "+r.Name, "users", pk, r.CaComment)
+	}
+	for _, k := range s.Config.AdditionalHostCaKey {
+		pk, comment, _, _, err := ssh.ParseAuthorizedKey([]byte(k))
+		if err != nil {
+			continue
+		}
+		addCA("additional_host_ca_key", "hosts, not by this server", pk, comment)
+	}
+	check("CA keys", caErr)
+
+	ctx, cancel := context.WithTimeout(ctx, statusCheckTimeout)
+	defer cancel()
+	check("Store", s.Store.Ping(ctx))
+	check("Clock", s.clock.check())
+
+	// Deterministic, so that servers with the same config show the same digest
+	var buf proto.Buffer
+	buf.SetDeterministic(true)
+	if buf.Marshal(s.Config) == nil {
+		h := sha256.Sum256(buf.Bytes())
+		rv.ConfigDigest = hex.EncodeToString(h[:6])
+	}
+	return rv
+}
+
+// Unhealthy is served with 503, so that the page can also be used by load balancers.
+func (s *SSOServer) serveStatus(w http.ResponseWriter, r *http.Request) {
+	st := s.status(r.Context())
+	code := http.StatusOK
+	if !st.Healthy {
+		code = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	if r.URL.Path == "/status.json" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(st)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(code)
+	err := statusTemplate.Execute(w, st)
+	if err != nil {
+		log.Println("Error serving status page:", err)
+	}
+}
@@ -0,0 +1,401 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	pb "github.com/continusec/geecert/sso"
+)
+
+var (
+	ErrUnknownStoreDriver = errors.New("Unknown store driver.")
+)
+
+// Store holds server state that should survive a restart, and that must be
+// shared between servers if more than one is run.
+type Store interface {
+	// Returns the config for the user, or nil if the user is not known.
+	LookupUser(ctx context.Context, email string) (*pb.ServerConfig_UserConfig, error)
+
+	// Creates or replaces the config for the user.
+	SaveUser(ctx context.Context, email string, uc *pb.ServerConfig_UserConfig) error
+
+	// Saves the users from the config file, and removes any that were saved by an earlier
+	// call but are no longer given. Users saved by SaveUser are left alone.
+	SyncConfigUsers(ctx context.Context, users map[string]*pb.ServerConfig_UserConfig) error
+
+	// Records that a certificate has been issued.
+	RecordIssuedCert(ctx context.Context, rec *IssuedCert) error
+
+	// Returns all certificates issued to a user, oldest first.
+	IssuedCertsForUser(ctx context.Context, email string) ([]*IssuedCert, error)
+
+	// Returns the certificate with given serial number, or nil if not found.
+	IssuedCertBySerial(ctx context.Context, serial uint64) (*IssuedCert, error)
+
+	// Returns all certificates issued for the public key with given fingerprint.
+	IssuedCertsByFingerprint(ctx context.Context, fingerprint string) ([]*IssuedCert, error)
+
+	// Returns all certificates issued to the device with given ID, oldest first.
+	IssuedCertsForDevice(ctx context.Context, deviceID string) ([]*IssuedCert, error)
+
+	// Returns the number of certificates issued to a user since the given time.
+	CountIssuedSince(ctx context.Context, email string, since time.Time) (int, error)
+
+	// Allocates a certificate serial number, unique amongst all servers sharing the store.
+	NextSerial(ctx context.Context) (uint64, error)
+
+	// Marks the public key with given fingerprint as revoked.
+	Revoke(ctx context.Context, fingerprint, reason string) error
+
+	// Returns true if the public key with given fingerprint has been revoked.
+	IsRevoked(ctx context.Context, fingerprint string) (bool, error)
+
+	// Returns all revocations, oldest first.
+	Revocations(ctx context.Context) ([]*Revocation, error)
+
+	// Records a new request awaiting approval.
+	CreateApproval(ctx context.Context, a *Approval) error
+
+	// Returns the approval with given ID, or nil if not found.
+	GetApproval(ctx context.Context, id string) (*Approval, error)
+
+	// Returns all approvals in the pending state, oldest first.
+	PendingApprovals(ctx context.Context) ([]*Approval, error)
+
+	// Moves the approval from state from to state to, recording who by. Returns false if
+	// the approval was not in state from, e.g. as another server has already changed it.
+	UpdateApprovalState(ctx context.Context, id, from, to, by string) (bool, error)
+
+	// Creates or replaces the record of an enrolled device.
+	SaveDevice(ctx context.Context, d *Device) error
+
+	// Returns the device with given ID, or nil if not found.
+	GetDevice(ctx context.Context, id string) (*Device, error)
+
+	// Returns an error if the store can't be reached.
+	Ping(ctx context.Context) error
+
+	Close() error
+}
+
+type IssuedCert struct {
+	Serial      uint64
+	Email       string
+	KeyID       string
+	Principals  []string
+	Fingerprint string // SHA256 fingerprint of the public key that was signed
+	ValidAfter  time.Time
+	ValidBefore time.Time
+	DeviceID    string // if requested from an enrolled device
+	OnBehalfOf  string // user the certificate was requested for by Email, see impersonators
+}
+
+type Revocation struct {
+	Fingerprint string
+	Reason      string
+	RevokedAt   time.Time
+}
+
+const (
+	ApprovalPending  = "pending"
+	ApprovalApproved = "approved"
+	ApprovalDenied   = "denied"
+	ApprovalUsed     = "used" // approved, and the certificate has been issued
+)
+
+type Device struct {
+	ID            string // SHA256 fingerprint of the device key
+	Email         string
+	PublicKey     string // authorized_keys format
+	Hostname      string
+	Platform      string
+	HardwareID    string // as reported by the client
+	SerialNumber  string // as reported by the client
+	EnrolledAt    time.Time
+	RevokedAt     time.Time // zero unless revoked
+	RevokedReason string
+}
+
+type Approval struct {
+	ID          string
+	Email       string
+	Fingerprint string // SHA256 fingerprint of the public key to be signed
+	Principals  []string
+	State       string
+	RequestedAt time.Time
+	DecidedBy   string
+	DecidedAt   time.Time
+	OnBehalfOf  string
+}
+
+// Opens the store specified by the config. Users in the config file are always
+// written to the store, and those removed from it are removed from the store, so that
+// the config file remains authoritative for them.
+func OpenStore(ctx context.Context, conf *pb.ServerConfig) (Store, error) {
+	var store Store
+	switch conf.StoreDriver {
+	case "":
+		store = NewMemoryStore()
+	case "sqlite3", "postgres":
+		var err error
+		store, err = OpenSQLStore(ctx, conf.StoreDriver, conf.StoreDsn)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrUnknownStoreDriver
+	}
+
+	err := store.SyncConfigUsers(ctx, conf.AllowedUsers)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// MemoryStore keeps all state in memory, and is used if no store is configured.
+type MemoryStore struct {
+	lock        sync.Mutex
+	users       map[string]*pb.ServerConfig_UserConfig
+	configUsers map[string]bool // those in users from SyncConfigUsers
+	issued      []*IssuedCert
+	revocations []*Revocation
+	approvals   []*Approval
+	devices     map[string]*Device
+	lastSerial  uint64
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		users:       make(map[string]*pb.ServerConfig_UserConfig),
+		configUsers: make(map[string]bool),
+		devices:     make(map[string]*Device),
+	}
+}
+
+func (ms *MemoryStore) LookupUser(ctx context.Context, email string) (*pb.ServerConfig_UserConfig, error) {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	return ms.users[email], nil
+}
+
+func (ms *MemoryStore) SaveUser(ctx context.Context, email string, uc *pb.ServerConfig_UserConfig) error {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	ms.users[email] = uc
+	delete(ms.configUsers, email)
+	return nil
+}
+
+func (ms *MemoryStore) SyncConfigUsers(ctx context.Context, users map[string]*pb.ServerConfig_UserConfig) error {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	for email := range ms.configUsers {
+		if _, ok := users[email]; !ok {
+			delete(ms.users, email)
+			delete(ms.configUsers, email)
+		}
+	}
+	for email, uc := range users {
+		ms.users[email] = uc
+		ms.configUsers[email] = true
+	}
+	return nil
+}
+
+func (ms *MemoryStore) RecordIssuedCert(ctx context.Context, rec *IssuedCert) error {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	ms.issued = append(ms.issued, rec)
+	return nil
+}
+
+func (ms *MemoryStore) IssuedCertsForUser(ctx context.Context, email string) ([]*IssuedCert, error) {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	var rv []*IssuedCert
+	for _, rec := range ms.issued {
+		if rec.Email == email {
+			rv = append(rv, rec)
+		}
+	}
+	return rv, nil
+}
+
+func (ms *MemoryStore) IssuedCertBySerial(ctx context.Context, serial uint64) (*IssuedCert, error) {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	for _, rec := range ms.issued {
+		if rec.Serial == serial {
+			return rec, nil
+		}
+	}
+	return nil, nil
+}
+
+func (ms *MemoryStore) IssuedCertsByFingerprint(ctx context.Context, fingerprint string) ([]*IssuedCert, error) {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	var rv []*IssuedCert
+	for _, rec := range ms.issued {
+		if rec.Fingerprint == fingerprint {
+			rv = append(rv, rec)
+		}
+	}
+	return rv, nil
+}
+
+func (ms *MemoryStore) IssuedCertsForDevice(ctx context.Context, deviceID string) ([]*IssuedCert, error) {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	var rv []*IssuedCert
+	for _, rec := range ms.issued {
+		if rec.DeviceID == deviceID {
+			rv = append(rv, rec)
+		}
+	}
+	return rv, nil
+}
+
+func (ms *MemoryStore) CountIssuedSince(ctx context.Context, email string, since time.Time) (int, error) {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	n := 0
+	for _, rec := range ms.issued {
+		if rec.Email == email && !rec.ValidAfter.Before(since) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (ms *MemoryStore) NextSerial(ctx context.Context) (uint64, error) {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	ms.lastSerial++
+	return ms.lastSerial, nil
+}
+
+func (ms *MemoryStore) Revoke(ctx context.Context, fingerprint, reason string) error {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	ms.revocations = append(ms.revocations, &Revocation{
+		Fingerprint: fingerprint,
+		Reason:      reason,
+		RevokedAt:   time.Now(),
+	})
+	return nil
+}
+
+func (ms *MemoryStore) IsRevoked(ctx context.Context, fingerprint string) (bool, error) {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	for _, r := range ms.revocations {
+		if r.Fingerprint == fingerprint {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (ms *MemoryStore) Revocations(ctx context.Context) ([]*Revocation, error) {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	return append([]*Revocation(nil), ms.revocations...), nil
+}
+
+func (ms *MemoryStore) CreateApproval(ctx context.Context, a *Approval) error {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	ms.approvals = append(ms.approvals, a)
+	return nil
+}
+
+func (ms *MemoryStore) GetApproval(ctx context.Context, id string) (*Approval, error) {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	for _, a := range ms.approvals {
+		if a.ID == id {
+			rv := *a
+			return &rv, nil
+		}
+	}
+	return nil, nil
+}
+
+func (ms *MemoryStore) PendingApprovals(ctx context.Context) ([]*Approval, error) {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	var rv []*Approval
+	for _, a := range ms.approvals {
+		if a.State == ApprovalPending {
+			c := *a
+			rv = append(rv, &c)
+		}
+	}
+	return rv, nil
+}
+
+func (ms *MemoryStore) UpdateApprovalState(ctx context.Context, id, from, to, by string) (bool, error) {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	for _, a := range ms.approvals {
+		if a.ID == id && a.State == from {
+			a.State = to
+			a.DecidedBy = by
+			a.DecidedAt = time.Now()
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (ms *MemoryStore) SaveDevice(ctx context.Context, d *Device) error {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	c := *d
+	ms.devices[d.ID] = &c
+	return nil
+}
+
+func (ms *MemoryStore) GetDevice(ctx context.Context, id string) (*Device, error) {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	d, ok := ms.devices[id]
+	if !ok {
+		return nil, nil
+	}
+	rv := *d
+	return &rv, nil
+}
+
+func (ms *MemoryStore) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (ms *MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,511 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+
+	pb "github.com/continusec/geecert/sso"
+
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Statements are written to work on both SQLite and PostgreSQL. Each is applied
+// once, in order, and recorded in schema_migrations. Only ever append to this list.
+// The first four may find their tables already made by servers from before
+// schema_migrations, so must not fail if they exist.
+var sqlMigrations = []string{
+	`CREATE TABLE IF NOT EXISTS users (
+		email TEXT PRIMARY KEY,
+		config TEXT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS issued_certs (
+		email TEXT NOT NULL,
+		key_id TEXT NOT NULL,
+		principals TEXT NOT NULL,
+		fingerprint TEXT NOT NULL,
+		valid_after BIGINT NOT NULL,
+		valid_before BIGINT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS issued_certs_email ON issued_certs (email)`,
+	`CREATE TABLE IF NOT EXISTS revocations (
+		fingerprint TEXT PRIMARY KEY,
+		reason TEXT NOT NULL,
+		revoked_at BIGINT NOT NULL
+	)`,
+	`ALTER TABLE issued_certs ADD COLUMN serial BIGINT NOT NULL DEFAULT 0`,
+	`CREATE TABLE serials (
+		name TEXT PRIMARY KEY,
+		value BIGINT NOT NULL
+	)`,
+	`INSERT INTO serials (name, value) VALUES ('certificate', 0)`,
+	`CREATE INDEX issued_certs_serial ON issued_certs (serial)`,
+	`CREATE INDEX issued_certs_fingerprint ON issued_certs (fingerprint)`,
+	`CREATE TABLE approvals (
+		id TEXT PRIMARY KEY,
+		email TEXT NOT NULL,
+		fingerprint TEXT NOT NULL,
+		principals TEXT NOT NULL,
+		state TEXT NOT NULL,
+		requested_at BIGINT NOT NULL,
+		decided_by TEXT NOT NULL,
+		decided_at BIGINT NOT NULL
+	)`,
+	`CREATE INDEX approvals_state ON approvals (state)`,
+	`ALTER TABLE issued_certs ADD COLUMN device_id TEXT NOT NULL DEFAULT ''`,
+	`CREATE INDEX issued_certs_device_id ON issued_certs (device_id)`,
+	`CREATE TABLE devices (
+		id TEXT PRIMARY KEY,
+		email TEXT NOT NULL,
+		public_key TEXT NOT NULL,
+		hostname TEXT NOT NULL,
+		platform TEXT NOT NULL,
+		hardware_id TEXT NOT NULL,
+		enrolled_at BIGINT NOT NULL,
+		revoked_at BIGINT NOT NULL,
+		revoked_reason TEXT NOT NULL
+	)`,
+	`ALTER TABLE devices ADD COLUMN serial_number TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE issued_certs ADD COLUMN on_behalf_of TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE approvals ADD COLUMN on_behalf_of TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE users ADD COLUMN source TEXT NOT NULL DEFAULT 'config'`,
+}
+
+// Values of users.source: whether the user came from the config file or SaveUser.
+const (
+	userSourceConfig = "config"
+	userSourceAPI    = "api"
+)
+
+// SQLStore keeps state in a SQLite or PostgreSQL database.
+type SQLStore struct {
+	db     *sql.DB
+	driver string
+}
+
+func OpenSQLStore(ctx context.Context, driver, dsn string) (*SQLStore, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite does not cope well with concurrent writers
+	if driver == "sqlite3" {
+		db.SetMaxOpenConns(1)
+	}
+
+	s := &SQLStore{db: db, driver: driver}
+	err = s.migrate(ctx)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Key of the PostgreSQL advisory lock held while migrating.
+const sqlMigrationLock = 0x67656563
+
+// Applies any statements in sqlMigrations not yet applied to the database, in one
+// transaction. Servers starting at once take turns, and those after the first find
+// nothing left to do.
+func (s *SQLStore) migrate(ctx context.Context) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var tx interface {
+		ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+		QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	}
+	var commit, rollback func() error
+	if s.driver == "postgres" {
+		t, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		tx, commit, rollback = t, t.Commit, t.Rollback
+		_, err = t.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", sqlMigrationLock)
+		if err != nil {
+			t.Rollback()
+			return err
+		}
+	} else {
+		// Takes the write lock now, rather than on the first write, which SQLite may refuse
+		_, err = conn.ExecContext(ctx, "BEGIN IMMEDIATE")
+		if err != nil {
+			return err
+		}
+		tx = conn
+		commit = func() error {
+			_, err := conn.ExecContext(ctx, "COMMIT")
+			return err
+		}
+		rollback = func() error {
+			_, err := conn.ExecContext(ctx, "ROLLBACK")
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT PRIMARY KEY)")
+	if err != nil {
+		rollback()
+		return err
+	}
+	var applied int
+	err = tx.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&applied)
+	if err != nil {
+		rollback()
+		return err
+	}
+	for i := applied; i < len(sqlMigrations); i++ {
+		_, err = tx.ExecContext(ctx, sqlMigrations[i])
+		if err == nil {
+			_, err = tx.ExecContext(ctx, s.rebind("INSERT INTO schema_migrations (version) VALUES (?)"), i+1)
+		}
+		if err != nil {
+			rollback()
+			return err
+		}
+	}
+	return commit()
+}
+
+// Rewrites ? placeholders to $1, $2 etc for PostgreSQL.
+func (s *SQLStore) rebind(query string) string {
+	if s.driver != "postgres" {
+		return query
+	}
+	var rv []byte
+	n := 0
+	for i := 0; i < len(query); i++ {
+		if query[i] == '?' {
+			n++
+			rv = append(rv, '$')
+			rv = strconv.AppendInt(rv, int64(n), 10)
+		} else {
+			rv = append(rv, query[i])
+		}
+	}
+	return string(rv)
+}
+
+func (s *SQLStore) exec(ctx context.Context, query string, args ...interface{}) error {
+	_, err := s.db.ExecContext(ctx, s.rebind(query), args...)
+	return err
+}
+
+func (s *SQLStore) LookupUser(ctx context.Context, email string) (*pb.ServerConfig_UserConfig, error) {
+	var config string
+	err := s.db.QueryRowContext(ctx, s.rebind("SELECT config FROM users WHERE email = ?"), email).Scan(&config)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	uc := &pb.ServerConfig_UserConfig{}
+	err = proto.UnmarshalText(config, uc)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc, nil
+}
+
+func (s *SQLStore) SaveUser(ctx context.Context, email string, uc *pb.ServerConfig_UserConfig) error {
+	return s.exec(ctx, "INSERT INTO users (email, config, source) VALUES (?, ?, ?) ON CONFLICT (email) DO UPDATE SET config = excluded.config, source = excluded.source", email, proto.MarshalTextString(uc), userSourceAPI)
+}
+
+func (s *SQLStore) SyncConfigUsers(ctx context.Context, users map[string]*pb.ServerConfig_UserConfig) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, s.rebind("SELECT email FROM users WHERE source = ?"), userSourceConfig)
+	if err != nil {
+		return err
+	}
+	var removed []string
+	for rows.Next() {
+		var email string
+		err = rows.Scan(&email)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		if _, ok := users[email]; !ok {
+			removed = append(removed, email)
+		}
+	}
+	rows.Close()
+	err = rows.Err()
+	if err != nil {
+		return err
+	}
+
+	for _, email := range removed {
+		_, err = tx.ExecContext(ctx, s.rebind("DELETE FROM users WHERE email = ? AND source = ?"), email, userSourceConfig)
+		if err != nil {
+			return err
+		}
+	}
+	for email, uc := range users {
+		_, err = tx.ExecContext(ctx, s.rebind("INSERT INTO users (email, config, source) VALUES (?, ?, ?) ON CONFLICT (email) DO UPDATE SET config = excluded.config, source = excluded.source"), email, proto.MarshalTextString(uc), userSourceConfig)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) RecordIssuedCert(ctx context.Context, rec *IssuedCert) error {
+	return s.exec(ctx, "INSERT INTO issued_certs (serial, email, key_id, principals, fingerprint, valid_after, valid_before, device_id, on_behalf_of) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		int64(rec.Serial), rec.Email, rec.KeyID, strings.Join(rec.Principals, ","), rec.Fingerprint, rec.ValidAfter.Unix(), rec.ValidBefore.Unix(), rec.DeviceID, rec.OnBehalfOf)
+}
+
+func (s *SQLStore) IssuedCertsForUser(ctx context.Context, email string) ([]*IssuedCert, error) {
+	return s.queryIssuedCerts(ctx, "WHERE email = ? ORDER BY valid_after", email)
+}
+
+func (s *SQLStore) IssuedCertBySerial(ctx context.Context, serial uint64) (*IssuedCert, error) {
+	recs, err := s.queryIssuedCerts(ctx, "WHERE serial = ?", int64(serial))
+	if err != nil {
+		return nil, err
+	}
+	if len(recs) == 0 {
+		return nil, nil
+	}
+	return recs[0], nil
+}
+
+func (s *SQLStore) IssuedCertsByFingerprint(ctx context.Context, fingerprint string) ([]*IssuedCert, error) {
+	return s.queryIssuedCerts(ctx, "WHERE fingerprint = ? ORDER BY valid_after", fingerprint)
+}
+
+func (s *SQLStore) IssuedCertsForDevice(ctx context.Context, deviceID string) ([]*IssuedCert, error) {
+	return s.queryIssuedCerts(ctx, "WHERE device_id = ? ORDER BY valid_after", deviceID)
+}
+
+func (s *SQLStore) queryIssuedCerts(ctx context.Context, where string, args ...interface{}) ([]*IssuedCert, error) {
+	rows, err := s.db.QueryContext(ctx, s.rebind("SELECT serial, email, key_id, principals, fingerprint, valid_after, valid_before, device_id, on_behalf_of FROM issued_certs "+where), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rv []*IssuedCert
+	for rows.Next() {
+		var principals string
+		var serial, va, vb int64
+		rec := &IssuedCert{}
+		err = rows.Scan(&serial, &rec.Email, &rec.KeyID, &principals, &rec.Fingerprint, &va, &vb, &rec.DeviceID, &rec.OnBehalfOf)
+		if err != nil {
+			return nil, err
+		}
+		if len(principals) > 0 {
+			rec.Principals = strings.Split(principals, ",")
+		}
+		rec.Serial = uint64(serial)
+		rec.ValidAfter = time.Unix(va, 0)
+		rec.ValidBefore = time.Unix(vb, 0)
+		rv = append(rv, rec)
+	}
+
+	return rv, rows.Err()
+}
+
+func (s *SQLStore) CountIssuedSince(ctx context.Context, email string, since time.Time) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, s.rebind("SELECT COUNT(*) FROM issued_certs WHERE email = ? AND valid_after >= ?"), email, since.Unix()).Scan(&n)
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// The UPDATE takes a row lock, so concurrent callers on other servers block
+// until we commit, and each sees a distinct value.
+func (s *SQLStore) NextSerial(ctx context.Context) (uint64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "UPDATE serials SET value = value + 1 WHERE name = 'certificate'")
+	if err != nil {
+		return 0, err
+	}
+
+	var serial int64
+	err = tx.QueryRowContext(ctx, "SELECT value FROM serials WHERE name = 'certificate'").Scan(&serial)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(serial), nil
+}
+
+func (s *SQLStore) Revoke(ctx context.Context, fingerprint, reason string) error {
+	return s.exec(ctx, "INSERT INTO revocations (fingerprint, reason, revoked_at) VALUES (?, ?, ?) ON CONFLICT (fingerprint) DO NOTHING", fingerprint, reason, time.Now().Unix())
+}
+
+func (s *SQLStore) IsRevoked(ctx context.Context, fingerprint string) (bool, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, s.rebind("SELECT COUNT(*) FROM revocations WHERE fingerprint = ?"), fingerprint).Scan(&n)
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (s *SQLStore) Revocations(ctx context.Context) ([]*Revocation, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT fingerprint, reason, revoked_at FROM revocations ORDER BY revoked_at")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rv []*Revocation
+	for rows.Next() {
+		var at int64
+		r := &Revocation{}
+		err = rows.Scan(&r.Fingerprint, &r.Reason, &at)
+		if err != nil {
+			return nil, err
+		}
+		r.RevokedAt = time.Unix(at, 0)
+		rv = append(rv, r)
+	}
+
+	return rv, rows.Err()
+}
+
+func (s *SQLStore) CreateApproval(ctx context.Context, a *Approval) error {
+	return s.exec(ctx, "INSERT INTO approvals (id, email, fingerprint, principals, state, requested_at, decided_by, decided_at, on_behalf_of) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		a.ID, a.Email, a.Fingerprint, strings.Join(a.Principals, ","), a.State, a.RequestedAt.Unix(), a.DecidedBy, a.DecidedAt.Unix(), a.OnBehalfOf)
+}
+
+func (s *SQLStore) GetApproval(ctx context.Context, id string) (*Approval, error) {
+	as, err := s.queryApprovals(ctx, "WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(as) == 0 {
+		return nil, nil
+	}
+	return as[0], nil
+}
+
+func (s *SQLStore) PendingApprovals(ctx context.Context) ([]*Approval, error) {
+	return s.queryApprovals(ctx, "WHERE state = ? ORDER BY requested_at", ApprovalPending)
+}
+
+func (s *SQLStore) queryApprovals(ctx context.Context, where string, args ...interface{}) ([]*Approval, error) {
+	rows, err := s.db.QueryContext(ctx, s.rebind("SELECT id, email, fingerprint, principals, state, requested_at, decided_by, decided_at, on_behalf_of FROM approvals "+where), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rv []*Approval
+	for rows.Next() {
+		var principals string
+		var ra, da int64
+		a := &Approval{}
+		err = rows.Scan(&a.ID, &a.Email, &a.Fingerprint, &principals, &a.State, &ra, &a.DecidedBy, &da, &a.OnBehalfOf)
+		if err != nil {
+			return nil, err
+		}
+		if len(principals) > 0 {
+			a.Principals = strings.Split(principals, ",")
+		}
+		a.RequestedAt = time.Unix(ra, 0)
+		a.DecidedAt = time.Unix(da, 0)
+		rv = append(rv, a)
+	}
+
+	return rv, rows.Err()
+}
+
+func (s *SQLStore) UpdateApprovalState(ctx context.Context, id, from, to, by string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, s.rebind("UPDATE approvals SET state = ?, decided_by = ?, decided_at = ? WHERE id = ? AND state = ?"), to, by, time.Now().Unix(), id, from)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (s *SQLStore) SaveDevice(ctx context.Context, d *Device) error {
+	var revokedAt int64
+	if !d.RevokedAt.IsZero() {
+		revokedAt = d.RevokedAt.Unix()
+	}
+	return s.exec(ctx, "INSERT INTO devices (id, email, public_key, hostname, platform, hardware_id, serial_number, enrolled_at, revoked_at, revoked_reason) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT (id) DO UPDATE SET email = excluded.email, public_key = excluded.public_key, hostname = excluded.hostname, platform = excluded.platform, hardware_id = excluded.hardware_id, serial_number = excluded.serial_number, enrolled_at = excluded.enrolled_at, revoked_at = excluded.revoked_at, revoked_reason = excluded.revoked_reason",
+		d.ID, d.Email, d.PublicKey, d.Hostname, d.Platform, d.HardwareID, d.SerialNumber, d.EnrolledAt.Unix(), revokedAt, d.RevokedReason)
+}
+
+func (s *SQLStore) GetDevice(ctx context.Context, id string) (*Device, error) {
+	var enrolledAt, revokedAt int64
+	d := &Device{}
+	err := s.db.QueryRowContext(ctx, s.rebind("SELECT id, email, public_key, hostname, platform, hardware_id, serial_number, enrolled_at, revoked_at, revoked_reason FROM devices WHERE id = ?"), id).Scan(
+		&d.ID, &d.Email, &d.PublicKey, &d.Hostname, &d.Platform, &d.HardwareID, &d.SerialNumber, &enrolledAt, &revokedAt, &d.RevokedReason)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	d.EnrolledAt = time.Unix(enrolledAt, 0)
+	if revokedAt != 0 {
+		d.RevokedAt = time.Unix(revokedAt, 0)
+	}
+	return d, nil
+}
+
+func (s *SQLStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,155 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	pb "github.com/continusec/geecert/sso"
+)
+
+const (
+	maxTelemetryKeys        = 10000 // distinct combinations counted, further ones are dropped
+	maxTelemetryFieldLength = 64
+)
+
+type telemetryKey struct {
+	ClientName    string
+	ClientVersion string
+	OS            string
+	Arch          string
+	Command       string
+	Outcome       string
+}
+
+type telemetryCount struct {
+	Count    int64
+	LastSeen time.Time
+}
+
+// TelemetryCounts aggregates telemetry reports in memory. Each server counts only the reports
+// sent to it, and counts are lost on restart.
+type TelemetryCounts struct {
+	lock   sync.Mutex
+	since  time.Time
+	counts map[telemetryKey]*telemetryCount
+}
+
+func NewTelemetryCounts() *TelemetryCounts {
+	return &TelemetryCounts{
+		since:  time.Now(),
+		counts: make(map[telemetryKey]*telemetryCount),
+	}
+}
+
+func (tc *TelemetryCounts) Add(k telemetryKey) {
+	tc.lock.Lock()
+	defer tc.lock.Unlock()
+	c, ok := tc.counts[k]
+	if !ok {
+		if len(tc.counts) >= maxTelemetryKeys {
+			return
+		}
+		c = &telemetryCount{}
+		tc.counts[k] = c
+	}
+	c.Count++
+	c.LastSeen = time.Now()
+}
+
+// Returns the counts, most frequent first.
+func (tc *TelemetryCounts) Summary() *pb.TelemetrySummaryResponse {
+	tc.lock.Lock()
+	defer tc.lock.Unlock()
+	rv := &pb.TelemetrySummaryResponse{
+		Status: pb.ResponseCode_OK,
+		Since:  tc.since.Unix(),
+	}
+	for k, c := range tc.counts {
+		rv.Counts = append(rv.Counts, &pb.TelemetryCount{
+			ClientName:    k.ClientName,
+			ClientVersion: k.ClientVersion,
+			Os:            k.OS,
+			Arch:          k.Arch,
+			Command:       k.Command,
+			Outcome:       k.Outcome,
+			Count:         c.Count,
+			LastSeen:      c.LastSeen.Unix(),
+		})
+	}
+	sort.Slice(rv.Counts, func(i, j int) bool {
+		return rv.Counts[i].Count > rv.Counts[j].Count
+	})
+	return rv
+}
+
+// Reports are unauthenticated, so limit what we keep to short printable strings.
+func telemetryField(s string) string {
+	if len(s) > maxTelemetryFieldLength {
+		s = s[:maxTelemetryFieldLength]
+	}
+	b := []byte(s)
+	for i, c := range b {
+		if c < 0x20 || c > 0x7e {
+			b[i] = '?'
+		}
+	}
+	return string(b)
+}
+
+func (s *SSOServer) ReportTelemetry(ctx context.Context, in *pb.TelemetryReport) (*pb.TelemetryResponse, error) {
+	if !s.Config.AcceptTelemetry || s.Telemetry == nil {
+		return &pb.TelemetryResponse{
+			Status: pb.ResponseCode_NOT_AUTHORIZED,
+		}, nil
+	}
+
+	name, version := clientNameAndVersion(ctx)
+	s.Telemetry.Add(telemetryKey{
+		ClientName:    telemetryField(name),
+		ClientVersion: telemetryField(version),
+		OS:            telemetryField(in.Os),
+		Arch:          telemetryField(in.Arch),
+		Command:       telemetryField(in.Command),
+		Outcome:       telemetryField(in.Outcome),
+	})
+
+	return &pb.TelemetryResponse{
+		Status: pb.ResponseCode_OK,
+	}, nil
+}
+
+func (s *SSOServer) TelemetrySummary(ctx context.Context, in *pb.TelemetrySummaryRequest) (*pb.TelemetrySummaryResponse, error) {
+	admin, err := s.validateAdmin(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	if admin == nil || s.Telemetry == nil {
+		return &pb.TelemetrySummaryResponse{
+			Status: pb.ResponseCode_NOT_AUTHORIZED,
+		}, nil
+	}
+
+	log.Printf("%s requested telemetry summary.\n", admin.EmailAddress)
+	return s.Telemetry.Summary(), nil
+}
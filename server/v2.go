@@ -0,0 +1,263 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/continusec/geecert"
+	pb "github.com/continusec/geecert/sso"
+)
+
+// Serves GeeCertServerV2 by calling the GeeCertServer methods, and converting any status
+// other than OK, and errors validating ID tokens, into errors with an ErrorDetail.
+type SSOServerV2 struct {
+	*SSOServer
+}
+
+// The error returned for a status other than OK, with a remediation for the user.
+func statusError(st pb.ResponseCode, maxAuthAge int32, approvalID string) error {
+	var code codes.Code
+	detail := &pb.ErrorDetail{
+		Reason:            pb.ErrorReason_POLICY_VIOLATION,
+		Status:            st,
+		MaxAuthAgeSeconds: maxAuthAge,
+		ApprovalId:        approvalID,
+	}
+	switch st {
+	case pb.ResponseCode_INVALID_ID_TOKEN:
+		code = codes.Unauthenticated
+		detail.Reason = pb.ErrorReason_TOKEN_INVALID
+		detail.Remediation = "Sign in to Google again."
+	case pb.ResponseCode_NO_CERTS_ALLOWED:
+		code = codes.PermissionDenied
+		detail.Remediation = "Your account (or key) is not allowed certificates. Ask an administrator to add you."
+	case pb.ResponseCode_RATE_LIMITED:
+		code = codes.ResourceExhausted
+		detail.Remediation = "Too many certificates have been issued to you in the past day. Try again later."
+	case pb.ResponseCode_NOT_AUTHORIZED:
+		code = codes.PermissionDenied
+		detail.Remediation = "You are not authorized for this request. Ask an administrator if you should be."
+	case pb.ResponseCode_TOO_MANY_CERTS:
+		code = codes.ResourceExhausted
+		detail.Remediation = "You already have as many certificates as allowed. Use one of them, or wait for one to expire."
+	case pb.ResponseCode_DEVICE_NOT_ENROLLED:
+		code = codes.PermissionDenied
+		detail.Remediation = "Certificates are only issued to enrolled devices. Run enroll-device, then try again."
+	case pb.ResponseCode_DEVICE_NOT_COMPLIANT:
+		code = codes.PermissionDenied
+		detail.Remediation = "Your device is not managed, or does not meet your organization's device policy. Check it in Self Service or Company Portal, then try again."
+	case pb.ResponseCode_REAUTH_REQUIRED:
+		code = codes.Unauthenticated
+		detail.Reason = pb.ErrorReason_TOKEN_EXPIRED
+		detail.Remediation = fmt.Sprintf("Sign in to Google again, you must have signed in within the last %d seconds.", maxAuthAge)
+	case pb.ResponseCode_APPROVAL_PENDING:
+		code = codes.FailedPrecondition
+		detail.Remediation = fmt.Sprintf("Ask an approver to run: approve %s", approvalID)
+	case pb.ResponseCode_REASON_REQUIRED:
+		code = codes.FailedPrecondition
+		detail.Remediation = "Give a reason for the certificate, e.g. a ticket number, with -reason."
+	case pb.ResponseCode_TICKET_REJECTED:
+		code = codes.PermissionDenied
+		detail.Remediation = "Give an open ticket that covers this access, with -ticket."
+	case pb.ResponseCode_NETWORK_NOT_ALLOWED:
+		code = codes.PermissionDenied
+		detail.Remediation = "Certificates can't be issued to where you are connecting from. Connect from an allowed network, e.g. the VPN, then try again."
+	default:
+		code = codes.Unknown
+		detail.Reason = pb.ErrorReason_UNKNOWN_ERROR
+	}
+	return withDetail(code, st.String(), detail)
+}
+
+func withDetail(code codes.Code, msg string, detail *pb.ErrorDetail) error {
+	s, err := status.New(code, msg).WithDetails(detail)
+	if err != nil {
+		log.Println("Error adding error detail:", err)
+		return status.Error(code, msg)
+	}
+	return s.Err()
+}
+
+// Converts errors from validating an ID token. Other errors are returned as is.
+func v2Error(err error) error {
+	switch {
+	case errors.Is(err, geecert.ErrDomainMismatch):
+		return withDetail(codes.Unauthenticated, err.Error(), &pb.ErrorDetail{
+			Reason:      pb.ErrorReason_DOMAIN_MISMATCH,
+			Remediation: "Sign in to Google with your account in the organization's domain.",
+		})
+	case geecert.IDTokenExpired(err):
+		return withDetail(codes.Unauthenticated, err.Error(), &pb.ErrorDetail{
+			Reason:      pb.ErrorReason_TOKEN_EXPIRED,
+			Remediation: "Your ID token has expired. Run again to refresh it, and check your clock is correct.",
+		})
+	case errors.Is(err, ErrBadKerberosToken), errors.Is(err, ErrKerberosRealm):
+		return withDetail(codes.Unauthenticated, err.Error(), &pb.ErrorDetail{
+			Reason:      pb.ErrorReason_TOKEN_INVALID,
+			Remediation: "Run kinit to get a new Kerberos ticket, then try again.",
+		})
+	case errors.Is(err, geecert.ErrInvalidIDToken):
+		return statusError(pb.ResponseCode_INVALID_ID_TOKEN, 0, "")
+	}
+	var ve *jwt.ValidationError
+	if errors.As(err, &ve) {
+		return statusError(pb.ResponseCode_INVALID_ID_TOKEN, 0, "")
+	}
+	return err
+}
+
+func (s *SSOServerV2) GetSSHCerts(ctx context.Context, in *pb.SSHCertsRequest) (*pb.SSHCertsResponse, error) {
+	resp, err := s.SSOServer.GetSSHCerts(ctx, in)
+	if err != nil {
+		return nil, v2Error(err)
+	}
+	if resp.Status != pb.ResponseCode_OK {
+		return nil, statusError(resp.Status, resp.MaxAuthAgeSeconds, resp.ApprovalId)
+	}
+	return resp, nil
+}
+
+func (s *SSOServerV2) LookupCert(ctx context.Context, in *pb.LookupCertRequest) (*pb.LookupCertResponse, error) {
+	resp, err := s.SSOServer.LookupCert(ctx, in)
+	if err != nil {
+		return nil, v2Error(err)
+	}
+	if resp.Status != pb.ResponseCode_OK {
+		return nil, statusError(resp.Status, 0, "")
+	}
+	return resp, nil
+}
+
+func (s *SSOServerV2) ListApprovals(ctx context.Context, in *pb.ListApprovalsRequest) (*pb.ListApprovalsResponse, error) {
+	resp, err := s.SSOServer.ListApprovals(ctx, in)
+	if err != nil {
+		return nil, v2Error(err)
+	}
+	if resp.Status != pb.ResponseCode_OK {
+		return nil, statusError(resp.Status, 0, "")
+	}
+	return resp, nil
+}
+
+func (s *SSOServerV2) DecideApproval(ctx context.Context, in *pb.DecideApprovalRequest) (*pb.DecideApprovalResponse, error) {
+	resp, err := s.SSOServer.DecideApproval(ctx, in)
+	if err != nil {
+		return nil, v2Error(err)
+	}
+	if resp.Status != pb.ResponseCode_OK {
+		return nil, statusError(resp.Status, 0, "")
+	}
+	return resp, nil
+}
+
+func (s *SSOServerV2) BreakGlassCerts(ctx context.Context, in *pb.BreakGlassRequest) (*pb.SSHCertsResponse, error) {
+	resp, err := s.SSOServer.BreakGlassCerts(ctx, in)
+	if err != nil {
+		return nil, v2Error(err)
+	}
+	if resp.Status != pb.ResponseCode_OK {
+		return nil, statusError(resp.Status, 0, "")
+	}
+	return resp, nil
+}
+
+func (s *SSOServerV2) ReportTelemetry(ctx context.Context, in *pb.TelemetryReport) (*pb.TelemetryResponse, error) {
+	resp, err := s.SSOServer.ReportTelemetry(ctx, in)
+	if err != nil {
+		return nil, v2Error(err)
+	}
+	if resp.Status != pb.ResponseCode_OK {
+		return nil, statusError(resp.Status, 0, "")
+	}
+	return resp, nil
+}
+
+func (s *SSOServerV2) TelemetrySummary(ctx context.Context, in *pb.TelemetrySummaryRequest) (*pb.TelemetrySummaryResponse, error) {
+	resp, err := s.SSOServer.TelemetrySummary(ctx, in)
+	if err != nil {
+		return nil, v2Error(err)
+	}
+	if resp.Status != pb.ResponseCode_OK {
+		return nil, statusError(resp.Status, 0, "")
+	}
+	return resp, nil
+}
+
+func (s *SSOServerV2) EnrollDevice(ctx context.Context, in *pb.EnrollDeviceRequest) (*pb.EnrollDeviceResponse, error) {
+	resp, err := s.SSOServer.EnrollDevice(ctx, in)
+	if err != nil {
+		return nil, v2Error(err)
+	}
+	if resp.Status != pb.ResponseCode_OK {
+		return nil, statusError(resp.Status, 0, "")
+	}
+	return resp, nil
+}
+
+func (s *SSOServerV2) RevokeDevice(ctx context.Context, in *pb.RevokeDeviceRequest) (*pb.RevokeDeviceResponse, error) {
+	resp, err := s.SSOServer.RevokeDevice(ctx, in)
+	if err != nil {
+		return nil, v2Error(err)
+	}
+	if resp.Status != pb.ResponseCode_OK {
+		return nil, statusError(resp.Status, 0, "")
+	}
+	return resp, nil
+}
+
+func (s *SSOServerV2) IssueBatch(ctx context.Context, in *pb.BatchCertsRequest) (*pb.BatchCertsResponse, error) {
+	resp, err := s.SSOServer.IssueBatch(ctx, in)
+	if err != nil {
+		return nil, v2Error(err)
+	}
+	if resp.Status != pb.ResponseCode_OK {
+		return nil, statusError(resp.Status, 0, "")
+	}
+	return resp, nil
+}
+
+func (s *SSOServerV2) RedeemPickupCode(ctx context.Context, in *pb.PickupCodeRequest) (*pb.PickupCodeResponse, error) {
+	resp, err := s.SSOServer.RedeemPickupCode(ctx, in)
+	if err != nil {
+		return nil, v2Error(err)
+	}
+	if resp.Status != pb.ResponseCode_OK {
+		return nil, statusError(resp.Status, 0, "")
+	}
+	return resp, nil
+}
+
+func (s *SSOServerV2) ExplainPolicy(ctx context.Context, in *pb.ExplainPolicyRequest) (*pb.ExplainPolicyResponse, error) {
+	resp, err := s.SSOServer.ExplainPolicy(ctx, in)
+	if err != nil {
+		return nil, v2Error(err)
+	}
+	if resp.Status != pb.ResponseCode_OK {
+		return nil, statusError(resp.Status, 0, "")
+	}
+	return resp, nil
+}
@@ -0,0 +1,123 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/continusec/geecert"
+	pb "github.com/continusec/geecert/sso"
+)
+
+// Returns the name and version sent by the client, empty if not sent.
+func clientNameAndVersion(ctx context.Context) (string, string) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", ""
+	}
+	var name, version string
+	if v := md.Get(geecert.ClientNameMetadata); len(v) > 0 {
+		name = v[0]
+	}
+	if v := md.Get(geecert.ClientVersionMetadata); len(v) > 0 {
+		version = v[0]
+	}
+	return name, version
+}
+
+// Returns an error if the client is older than the minimum version for its name.
+func (s *SSOServer) checkClientVersion(ctx context.Context) error {
+	name, version := clientNameAndVersion(ctx)
+	min, ok := s.Config.MinClientVersionByName[name]
+	if !ok {
+		min = s.Config.MinClientVersion
+	}
+	if len(min) == 0 {
+		return nil
+	}
+	if len(version) > 0 && geecert.CompareVersions(version, min) >= 0 {
+		return nil
+	}
+
+	log.Printf("Refusing request from client %q version %q, older than %s.\n", name, version, min)
+	remediation := fmt.Sprintf("Please upgrade to version %s or later.", min)
+	if len(s.Config.UpgradeUrl) > 0 {
+		remediation = fmt.Sprintf("Please upgrade to version %s or later from %s", min, s.Config.UpgradeUrl)
+	}
+	// Sent as an error with details to both API versions, as older clients print the message
+	return withDetail(codes.FailedPrecondition, "Client version too old. "+remediation, &pb.ErrorDetail{
+		Reason:      pb.ErrorReason_CLIENT_TOO_OLD,
+		Status:      pb.ResponseCode_UPGRADE_REQUIRED,
+		Remediation: remediation,
+		UpgradeUrl:  s.Config.UpgradeUrl,
+	})
+}
+
+// Server options that check the client version before each request, limit and compress
+// messages as configured, and accept keepalive pings from clients. Telemetry is accepted
+// from all versions, so that operators can see old clients that are failing, and reflection
+// and health checks from any tool, as the client's health checks don't carry its version.
+func (s *SSOServer) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(s.maxMessageBytes()),
+		grpc.MaxSendMsgSize(s.maxMessageBytes()),
+		// Allow clients to ping as often as gRPC lets them, rather than only every 5 minutes
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{MinTime: 10 * time.Second}),
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			ctx = s.forwardedPeer(ctx)
+			s.compressResponse(ctx)
+			if !strings.HasSuffix(info.FullMethod, "/ReportTelemetry") && !strings.HasPrefix(info.FullMethod, "/grpc.health.") {
+				err := s.checkClientVersion(ctx)
+				if err != nil {
+					return nil, err
+				}
+			}
+			resp, err := handler(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+			err = s.checkResponseSize(info.FullMethod, resp)
+			if err != nil {
+				return nil, err
+			}
+			return resp, nil
+		}),
+		grpc.StreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			ss = &forwardedStream{ServerStream: ss, ctx: s.forwardedPeer(ss.Context())}
+			s.compressResponse(ss.Context())
+			ss = &sizeCheckedStream{ServerStream: ss, s: s, method: info.FullMethod}
+			if strings.HasPrefix(info.FullMethod, "/grpc.reflection.") || strings.HasPrefix(info.FullMethod, "/grpc.health.") {
+				return handler(srv, ss)
+			}
+			err := s.checkClientVersion(ss.Context())
+			if err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	}
+}
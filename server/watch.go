@@ -0,0 +1,209 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"context"
+	"io"
+	"log"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/continusec/geecert"
+	pb "github.com/continusec/geecert/sso"
+)
+
+const defaultWatchPollInterval = 30 * time.Second
+
+// Common to the streams of both API versions.
+type watchStream interface {
+	Send(*pb.WatchUpdate) error
+	Recv() (*pb.WatchRequest, error)
+	Context() context.Context
+}
+
+func (s *SSOServer) watchPollInterval() time.Duration {
+	if s.Config.WatchPollIntervalSeconds > 0 {
+		return time.Duration(s.Config.WatchPollIntervalSeconds) * time.Second
+	}
+	return defaultWatchPollInterval
+}
+
+// The current state for a user, as sent to clients watching for updates.
+func (s *SSOServer) watchState(ctx context.Context, email string, userConf *pb.ServerConfig_UserConfig) (*pb.WatchUpdate, error) {
+	// Read the CA key each time, so that a rotated key is noticed
+	caPubKey, err := caPublicKey(s.Config.CaKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	certs, err := s.Store.IssuedCertsForUser(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	var revoked, replaced []string
+	seen := make(map[string]bool)
+	for _, c := range certs {
+		if seen[c.Fingerprint] || time.Now().After(c.ValidBefore) {
+			continue
+		}
+		seen[c.Fingerprint] = true
+		r, err := s.Store.IsRevoked(ctx, c.Fingerprint)
+		if err != nil {
+			return nil, err
+		}
+		if r {
+			revoked = append(revoked, c.Fingerprint)
+		}
+	}
+	if len(revoked) > 0 && s.Config.MaxUnexpiredCertsPerUser > 0 {
+		replaced, err = s.replacedKeys(ctx, revoked)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	realms, err := s.watchRealms(userConf)
+	if err != nil {
+		return nil, err
+	}
+
+	cas := s.hostCertificateAuthorities(caPubKey)
+	return &pb.WatchUpdate{
+		Status:                     pb.ResponseCode_OK,
+		CertificateAuthorities:     geecert.RenderHostCertificateAuthorities(cas),
+		HostCertificateAuthorities: cas,
+		ConfigBlocks:               sshConfigBlocks(s.Config, userConf.Username),
+		ConfigVariables:            configVariables(s.Config, userConf, email),
+		BastionPolicies:            s.Config.BastionPolicy,
+		RevokedFingerprint:         revoked,
+		ReplacedFingerprint:        replaced,
+		Directives:                 s.Config.ClientDirectives,
+		Realms:                     realms,
+	}, nil
+}
+
+// Runs a session for WatchUpdates, until the client goes away or its ID token expires.
+// Returns a status other than OK if the session can't be started.
+func (s *SSOServer) watchUpdates(stream watchStream) (pb.ResponseCode, error) {
+	ctx := stream.Context()
+	req, err := stream.Recv()
+	if err != nil {
+		return pb.ResponseCode_OK, err
+	}
+	claims, err := s.authenticate(ctx, req)
+	if err != nil {
+		return pb.ResponseCode_OK, err
+	}
+	email := claims.EmailAddress
+
+	// Receive fresh ID tokens for as long as the client sends them
+	tokens := make(chan *geecert.IDTokenClaims)
+	go func() {
+		defer close(tokens)
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				if err != io.EOF {
+					log.Printf("Watch session for %s ended: %s\n", email, err)
+				}
+				return
+			}
+			c, err := s.authenticate(ctx, req)
+			if err != nil || c.EmailAddress != email {
+				log.Printf("Ending watch session for %s on bad ID token.\n", email)
+				return
+			}
+			select {
+			case tokens <- c:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	log.Printf("Starting watch session for %s.\n", email)
+	ticker := time.NewTicker(s.watchPollInterval())
+	defer ticker.Stop()
+	var last *pb.WatchUpdate
+	for {
+		// Look up the user each time, as they may have been removed from the store
+		userConf, err := s.Store.LookupUser(ctx, email)
+		if err != nil {
+			return pb.ResponseCode_OK, err
+		}
+		if userConf == nil {
+			return pb.ResponseCode_NO_CERTS_ALLOWED, nil
+		}
+
+		update, err := s.watchState(ctx, email, userConf)
+		if err != nil {
+			return pb.ResponseCode_OK, err
+		}
+		if last == nil || !proto.Equal(update, last) {
+			err = stream.Send(update)
+			if err != nil {
+				return pb.ResponseCode_OK, err
+			}
+			last = update
+		}
+
+		var expired <-chan time.Time
+		if !claims.Expiry.IsZero() {
+			expired = time.After(claims.Expiry.Sub(time.Now()))
+		}
+
+		select {
+		case c, ok := <-tokens:
+			if !ok {
+				return pb.ResponseCode_OK, nil
+			}
+			claims = c
+		case <-expired:
+			log.Printf("Ending watch session for %s as ID token has expired.\n", email)
+			return pb.ResponseCode_INVALID_ID_TOKEN, nil
+		case <-ticker.C:
+		case <-ctx.Done():
+			return pb.ResponseCode_OK, nil
+		}
+	}
+}
+
+func (s *SSOServer) WatchUpdates(stream pb.GeeCertServer_WatchUpdatesServer) error {
+	st, err := s.watchUpdates(stream)
+	if err != nil {
+		return err
+	}
+	if st != pb.ResponseCode_OK {
+		return stream.Send(&pb.WatchUpdate{Status: st})
+	}
+	return nil
+}
+
+func (s *SSOServerV2) WatchUpdates(stream pb.GeeCertServerV2_WatchUpdatesServer) error {
+	st, err := s.watchUpdates(stream)
+	if err != nil {
+		return v2Error(err)
+	}
+	if st != pb.ResponseCode_OK {
+		return statusError(st, 0, "")
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/continusec/geecert"
+	pb "github.com/continusec/geecert/sso"
+)
+
+// A parsed working_hours window: minute hour day-of-month month day-of-week, as for cron.
+type cronWindow struct {
+	fields [5]map[int]bool // nil for *
+}
+
+var cronFieldRanges = [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+func parseCronWindow(s string) (*cronWindow, error) {
+	parts := strings.Fields(s)
+	if len(parts) != 5 {
+		return nil, errors.New("must have 5 fields: minute hour day-of-month month day-of-week")
+	}
+	w := &cronWindow{}
+	for i, p := range parts {
+		f, err := parseCronField(p, cronFieldRanges[i][0], cronFieldRanges[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("field %d %q: %w", i+1, p, err)
+		}
+		w.fields[i] = f
+	}
+	// Sunday is 0 or 7
+	if dow := w.fields[4]; dow != nil && dow[7] {
+		dow[0] = true
+	}
+	return w, nil
+}
+
+// Parses a comma separated list of *, n, a-b, */step or a-b/step, returning nil for *.
+func parseCronField(s string, min, max int) (map[int]bool, error) {
+	if s == "*" {
+		return nil, nil
+	}
+	rv := make(map[int]bool)
+	for _, item := range strings.Split(s, ",") {
+		step := 1
+		if i := strings.Index(item, "/"); i != -1 {
+			n, err := strconv.Atoi(item[i+1:])
+			if err != nil || n < 1 {
+				return nil, errors.New("bad step")
+			}
+			step = n
+			item = item[:i]
+		}
+		lo, hi := min, max
+		if item != "*" {
+			a, b, isRange := strings.Cut(item, "-")
+			var err error
+			lo, err = strconv.Atoi(a)
+			if err != nil {
+				return nil, errors.New("bad number")
+			}
+			hi = lo
+			if isRange {
+				hi, err = strconv.Atoi(b)
+				if err != nil {
+					return nil, errors.New("bad number")
+				}
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("out of range %d-%d", min, max)
+		}
+		for n := lo; n <= hi; n += step {
+			rv[n] = true
+		}
+	}
+	return rv, nil
+}
+
+// Returns true if the minute t is in falls in the window. As for cron, if both the day of
+// month and day of week are restricted, either may match.
+func (w *cronWindow) matches(t time.Time) bool {
+	in := func(f map[int]bool, n int) bool {
+		return f == nil || f[n]
+	}
+	if !in(w.fields[0], t.Minute()) || !in(w.fields[1], t.Hour()) || !in(w.fields[3], int(t.Month())) {
+		return false
+	}
+	dom, dow := w.fields[2], w.fields[4]
+	if dom != nil && dow != nil {
+		return dom[t.Day()] || dow[int(t.Weekday())]
+	}
+	return in(dom, t.Day()) && in(dow, int(t.Weekday()))
+}
+
+func validateWorkingHours(wh *pb.ServerConfig_WorkingHours) error {
+	if len(wh.Window) == 0 {
+		return errors.New("window must be set")
+	}
+	for _, s := range wh.Window {
+		_, err := parseCronWindow(s)
+		if err != nil {
+			return errors.New(fmt.Sprintf("window %q: %s", s, err))
+		}
+	}
+	_, err := time.LoadLocation(wh.TimeZone)
+	if err != nil {
+		return errors.New(fmt.Sprintf("time_zone: %s", err))
+	}
+	return nil
+}
+
+// Returns true if any working_hours for claims and principals doesn't have a window including
+// now, so the request must be approved.
+func (s *SSOServer) outsideWorkingHours(claims *geecert.IDTokenClaims, principals []string, now time.Time) bool {
+	for _, wh := range s.Config.WorkingHours {
+		if len(wh.AppliesTo) > 0 && !listed(wh.AppliesTo, claims) {
+			continue
+		}
+		if len(wh.Principals) > 0 && !anyPrincipal(principals, wh.Principals) {
+			continue
+		}
+		loc, err := time.LoadLocation(wh.TimeZone)
+		if err != nil {
+			log.Println("Ignoring working_hours with bad time_zone:", err)
+			continue
+		}
+		t := now.In(loc)
+		inside := false
+		for _, s := range wh.Window {
+			w, err := parseCronWindow(s)
+			if err == nil && w.matches(t) {
+				inside = true
+				break
+			}
+		}
+		if !inside {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if any of principals is in list.
+func anyPrincipal(principals []string, list []string) bool {
+	for _, p := range principals {
+		for _, q := range list {
+			if p == q {
+				return true
+			}
+		}
+	}
+	return false
+}
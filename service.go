@@ -0,0 +1,315 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+// Kinds of service written by install-service.
+const (
+	ServiceTimer = "timer" // runs renew-if-needed every few minutes
+	ServiceWatch = "watch" // runs the watch command, keeping a session with the server
+)
+
+var ErrServiceUnsupported = errors.New("Installing a service is not supported on this platform.")
+
+// How often the timer kind of service checks the certificate.
+const serviceTimerMinutes = 5
+
+// install-service [timer | watch | remove]
+// Installs a per-user service to keep the certificate fresh: a systemd user unit on Linux,
+// a launchd agent on macOS, or a scheduled task on Windows. The service is run with the
+// same flags as this command.
+func installServiceCommand(ctx context.Context, config *ClientAppConfiguration, args []string) error {
+	kind := ServiceTimer
+	switch {
+	case len(args) == 0:
+	case len(args) == 1 && (args[0] == ServiceTimer || args[0] == ServiceWatch || args[0] == "remove"):
+		kind = args[0]
+	default:
+		return ErrUsage
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	name := strings.TrimSuffix(filepath.Base(self), ".exe")
+
+	if kind == "remove" {
+		return RemoveService(ctx, name)
+	}
+	return InstallService(ctx, name, kind, append([]string{self}, config.Flags...))
+}
+
+// Installs a per-user service called name, of kind ServiceTimer or ServiceWatch, that runs
+// command (the client binary and its flags) with renew-if-needed or watch.
+func InstallService(ctx context.Context, name, kind string, command []string) error {
+	switch runtime.GOOS {
+	case "linux":
+		return installSystemdService(ctx, name, kind, command)
+	case "darwin":
+		return installLaunchdAgent(ctx, name, kind, command)
+	case "windows":
+		return installScheduledTask(ctx, name, kind, command)
+	}
+	return ErrServiceUnsupported
+}
+
+// Stops and removes the service installed by InstallService.
+func RemoveService(ctx context.Context, name string) error {
+	switch runtime.GOOS {
+	case "linux":
+		dir, err := systemdUserDir()
+		if err != nil {
+			return err
+		}
+		runService(ctx, "systemctl", "--user", "disable", "--now", name+".timer", name+".service")
+		for _, f := range []string{name + ".timer", name + ".service"} {
+			err = os.Remove(filepath.Join(dir, f))
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		return runService(ctx, "systemctl", "--user", "daemon-reload")
+	case "darwin":
+		path, err := launchdAgentPath(name)
+		if err != nil {
+			return err
+		}
+		runService(ctx, "launchctl", "unload", path)
+		err = os.Remove(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	case "windows":
+		return runService(ctx, "schtasks", "/Delete", "/TN", name, "/F")
+	}
+	return ErrServiceUnsupported
+}
+
+func runService(ctx context.Context, name string, args ...string) error {
+	logVerbose("Running: %s %s", name, strings.Join(args, " "))
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("Running %s: %w %s", name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func systemdUserDir() (string, error) {
+	if d := os.Getenv("XDG_CONFIG_HOME"); len(d) > 0 {
+		return filepath.Join(d, "systemd", "user"), nil
+	}
+	hd, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(hd, ".config", "systemd", "user"), nil
+}
+
+// Quotes args for ExecStart in a systemd unit.
+func systemdQuote(args []string) string {
+	var rv []string
+	for _, a := range args {
+		a = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%", "$", "$$").Replace(a)
+		rv = append(rv, `"`+a+`"`)
+	}
+	return strings.Join(rv, " ")
+}
+
+// Returns the units to write for a systemd service, by file name.
+func systemdUnits(name, kind string, command []string) map[string]string {
+	if kind == ServiceWatch {
+		return map[string]string{
+			name + ".service": fmt.Sprintf(`[Unit]
+Description=Keep SSH certificate fresh (%s)
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+Type=notify
+ExecStart=%s watch
+Restart=on-failure
+RestartSec=30
+
+[Install]
+WantedBy=default.target
+`, name, systemdQuote(command)),
+		}
+	}
+	return map[string]string{
+		name + ".service": fmt.Sprintf(`[Unit]
+Description=Renew SSH certificate if needed (%s)
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+Type=oneshot
+ExecStart=%s %s
+`, name, systemdQuote(command), renewIfNeededCommandName),
+		name + ".timer": fmt.Sprintf(`[Unit]
+Description=Renew SSH certificate if needed (%s)
+
+[Timer]
+OnBootSec=1min
+OnUnitActiveSec=%dmin
+
+[Install]
+WantedBy=timers.target
+`, name, serviceTimerMinutes),
+	}
+}
+
+func installSystemdService(ctx context.Context, name, kind string, command []string) error {
+	dir, err := systemdUserDir()
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	units := systemdUnits(name, kind, command)
+	for f, contents := range units {
+		err = SafeSave(ctx, filepath.Join(dir, f), []byte(contents), 0644)
+		if err != nil {
+			return err
+		}
+	}
+	err = runService(ctx, "systemctl", "--user", "daemon-reload")
+	if err != nil {
+		return err
+	}
+	unit := name + ".service"
+	if kind == ServiceTimer {
+		unit = name + ".timer"
+	}
+	logInfo("Installed %s in %s, see: journalctl --user -u %s.service", unit, dir, name)
+	return runService(ctx, "systemctl", "--user", "enable", "--now", unit)
+}
+
+func launchdAgentPath(name string) (string, error) {
+	hd, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(hd, "Library", "LaunchAgents", "geecert."+name+".plist"), nil
+}
+
+// Returns a launchd property list for an agent.
+func launchdPlist(name, kind string, command []string) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>geecert.` + html.EscapeString(name) + `</string>
+	<key>ProgramArguments</key>
+	<array>
+`)
+	sub := renewIfNeededCommandName
+	if kind == ServiceWatch {
+		sub = "watch"
+	}
+	for _, a := range append(command, sub) {
+		b.WriteString("\t\t<string>" + html.EscapeString(a) + "</string>\n")
+	}
+	b.WriteString("\t</array>\n\t<key>RunAtLoad</key>\n\t<true/>\n")
+	if kind == ServiceWatch {
+		b.WriteString("\t<key>KeepAlive</key>\n\t<true/>\n")
+	} else {
+		fmt.Fprintf(&b, "\t<key>StartInterval</key>\n\t<integer>%d</integer>\n", serviceTimerMinutes*60)
+	}
+	b.WriteString("</dict>\n</plist>\n")
+	return b.String()
+}
+
+func installLaunchdAgent(ctx context.Context, name, kind string, command []string) error {
+	path, err := launchdAgentPath(name)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	runService(ctx, "launchctl", "unload", path)
+	err = SafeSave(ctx, path, []byte(launchdPlist(name, kind, command)), 0644)
+	if err != nil {
+		return err
+	}
+	logInfo("Installed launchd agent %s.", path)
+	return runService(ctx, "launchctl", "load", path)
+}
+
+func installScheduledTask(ctx context.Context, name, kind string, command []string) error {
+	var tr []string
+	for _, a := range command {
+		if strings.ContainsAny(a, " \t") {
+			a = `"` + a + `"`
+		}
+		tr = append(tr, a)
+	}
+	args := []string{"/Create", "/F", "/TN", name}
+	if kind == ServiceWatch {
+		args = append(args, "/SC", "ONLOGON", "/TR", strings.Join(tr, " ")+" watch")
+	} else {
+		args = append(args, "/SC", "MINUTE", "/MO", fmt.Sprint(serviceTimerMinutes), "/TR", strings.Join(tr, " ")+" "+renewIfNeededCommandName)
+	}
+	err := runService(ctx, "schtasks", args...)
+	if err != nil {
+		return err
+	}
+	logInfo("Installed scheduled task %s.", name)
+	return nil
+}
+
+// Sends state (e.g. READY=1) to systemd as per sd_notify, if we were started by a unit with
+// Type=notify. Errors are ignored, as with sd_notify.
+func sdNotify(state string) {
+	sock := os.Getenv("NOTIFY_SOCKET")
+	if len(sock) == 0 {
+		return
+	}
+	if sock[0] == '@' {
+		sock = "\x00" + sock[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: sock, Net: "unixgram"})
+	if err != nil {
+		logVerbose("Unable to notify systemd: %s", err)
+		return
+	}
+	defer conn.Close()
+	conn.Write([]byte(state))
+}
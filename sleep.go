@@ -0,0 +1,60 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	wakeCheckInterval = 5 * time.Second
+	wakeMinSleep      = 30 * time.Second // shorter gaps are treated as scheduling delays
+)
+
+// Sends how long the machine slept each time it wakes, until ctx is done. Timers and tickers
+// run on a monotonic clock which stops on many platforms while asleep, so one due during
+// sleep fires up to its full interval late, when certificates have often expired with the lid
+// closed. Instead we check often, and compare the wall clock, which includes the time asleep.
+func Wakeups(ctx context.Context) <-chan time.Duration {
+	rv := make(chan time.Duration, 1)
+	go func() {
+		ticker := time.NewTicker(wakeCheckInterval)
+		defer ticker.Stop()
+		last := time.Now().Round(0) // strip the monotonic reading
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			now := time.Now().Round(0)
+			gap := now.Sub(last) - wakeCheckInterval
+			last = now
+			if gap < wakeMinSleep {
+				continue
+			}
+			select {
+			case rv <- gap:
+			default:
+			}
+		}
+	}()
+	return rv
+}
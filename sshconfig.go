@@ -0,0 +1,171 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	pb "github.com/continusec/geecert/sso"
+)
+
+var (
+	ErrBadConfigBlockHeader = errors.New("Exactly one of host_pattern or match must be set.")
+	ErrBadConfigHostPattern = errors.New("Host patterns must be non-empty and contain no whitespace or quotes.")
+	ErrBadConfigValue       = errors.New("Config values must be non-empty and on a single line.")
+	ErrBadConfigUser        = errors.New("User must contain no whitespace or quotes.")
+	ErrBadConfigKeyword     = errors.New("Unknown or disallowed ssh config keyword.")
+	ErrBadConfigVariable    = errors.New("Config variable names must be letters, digits and underscores, and values on a single line.")
+)
+
+// Matches $NAME or ${NAME}
+var configVariableRef = regexp.MustCompile(`\$(\{[A-Za-z_][A-Za-z0-9_]*\}|[A-Za-z_][A-Za-z0-9_]*)`)
+var configVariableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Keywords that may be used in SSHConfigBlock options, lower case as ssh matches them case-insensitively.
+// Host, Match and Include are deliberately absent as they would change the structure of the file.
+var allowedSSHConfigKeywords = map[string]bool{
+	"addkeystoagent": true, "addressfamily": true, "batchmode": true, "bindaddress": true,
+	"bindinterface": true, "canonicaldomains": true, "canonicalizefallbacklocal": true,
+	"canonicalizehostname": true, "canonicalizemaxdots": true, "canonicalizepermittedcnames": true,
+	"casignaturealgorithms": true, "certificatefile": true, "checkhostip": true, "ciphers": true,
+	"clearallforwardings": true, "compression": true, "connectionattempts": true,
+	"connecttimeout": true, "controlmaster": true, "controlpath": true, "controlpersist": true,
+	"dynamicforward": true, "escapechar": true, "exitonforwardfailure": true,
+	"fingerprinthash": true, "forwardagent": true, "forwardx11": true, "forwardx11timeout": true,
+	"forwardx11trusted": true, "gatewayports": true, "globalknownhostsfile": true,
+	"gssapiauthentication": true, "gssapidelegatecredentials": true, "hashknownhosts": true,
+	"hostbasedauthentication": true, "hostbasedkeytypes": true, "hostkeyalgorithms": true,
+	"hostkeyalias": true, "hostname": true, "identitiesonly": true, "identityagent": true,
+	"identityfile": true, "ipqos": true, "kbdinteractiveauthentication": true,
+	"kexalgorithms": true, "localcommand": true, "localforward": true, "loglevel": true,
+	"macs": true, "nohostauthenticationforlocalhost": true, "numberofpasswordprompts": true,
+	"passwordauthentication": true, "permitlocalcommand": true, "pkcs11provider": true,
+	"port": true, "preferredauthentications": true, "proxycommand": true, "proxyjump": true,
+	"proxyusefdpass": true, "pubkeyacceptedkeytypes": true, "pubkeyauthentication": true,
+	"rekeylimit": true, "remotecommand": true, "remoteforward": true, "requesttty": true,
+	"sendenv": true, "serveralivecountmax": true, "serveraliveinterval": true,
+	"streamlocalbindmask": true, "streamlocalbindunlink": true, "stricthostkeychecking": true,
+	"tcpkeepalive": true, "tunnel": true, "tunneldevice": true, "updatehostkeys": true,
+	"userknownhostsfile": true, "verifyhostkeydns": true, "visualhostkey": true,
+	"xauthlocation": true,
+}
+
+// Validates a block, so that a mistake in the server config can't produce an ssh config
+// file that ssh refuses to read. Used by both the server (on startup) and the client.
+func ValidateSSHConfigBlock(b *pb.SSHConfigBlock) error {
+	if (len(b.HostPattern) == 0) == (len(b.Match) == 0) {
+		return ErrBadConfigBlockHeader
+	}
+	for _, h := range b.HostPattern {
+		if len(h) == 0 || strings.ContainsAny(h, " \t\r\n\"") {
+			return ErrBadConfigHostPattern
+		}
+	}
+	if len(b.Match) > 0 && !singleLine(b.Match) {
+		return ErrBadConfigValue
+	}
+	if strings.ContainsAny(b.User, " \t\r\n\"") {
+		return ErrBadConfigUser
+	}
+	for _, o := range b.Option {
+		if !allowedSSHConfigKeywords[strings.ToLower(o.Keyword)] {
+			return ErrBadConfigKeyword
+		}
+		if !singleLine(o.Value) {
+			return ErrBadConfigValue
+		}
+	}
+	return nil
+}
+
+func singleLine(s string) bool {
+	return len(strings.TrimSpace(s)) > 0 && !strings.ContainsAny(s, "\r\n")
+}
+
+// Parses a line such as "Port 10011" into an option.
+func ParseSSHConfigOption(line string) *pb.SSHConfigBlock_Option {
+	line = strings.TrimSpace(line)
+	idx := strings.IndexAny(line, " \t=")
+	if idx < 0 {
+		return &pb.SSHConfigBlock_Option{Keyword: line}
+	}
+	return &pb.SSHConfigBlock_Option{
+		Keyword: line[:idx],
+		Value:   strings.TrimLeft(line[idx:], " \t="),
+	}
+}
+
+// Renders a single block. Where the certificate is used, the key is referenced as $CERTNAME
+// for the client to replace.
+func RenderSSHConfigBlock(b *pb.SSHConfigBlock) []string {
+	var rv []string
+	if len(b.HostPattern) > 0 {
+		rv = append(rv, "Host "+strings.Join(b.HostPattern, " "))
+	} else {
+		rv = append(rv, "Match "+b.Match)
+	}
+	if len(b.User) > 0 {
+		rv = append(rv, "    User "+b.User)
+	}
+	if b.UseCertificate {
+		rv = append(rv, "    IdentityFile $CERTNAME", "    IdentitiesOnly yes")
+	}
+	for _, o := range b.Option {
+		rv = append(rv, "    "+o.Keyword+" "+o.Value)
+	}
+	return rv
+}
+
+// Renders blocks into ssh config lines, skipping (with a warning) any that are invalid.
+func RenderSSHConfigBlocks(blocks []*pb.SSHConfigBlock) []string {
+	var rv []string
+	for _, b := range blocks {
+		err := ValidateSSHConfigBlock(b)
+		if err != nil {
+			logWarning("Skipping invalid ssh config block (%s): %s", b.String(), err)
+			continue
+		}
+		rv = append(rv, RenderSSHConfigBlock(b)...)
+	}
+	return rv
+}
+
+// Checks variables to be sent to clients for substitution.
+func ValidateConfigVariables(vars map[string]string) error {
+	for k, v := range vars {
+		if !configVariableName.MatchString(k) || strings.ContainsAny(v, "\r\n") {
+			return ErrBadConfigVariable
+		}
+	}
+	return nil
+}
+
+// Replaces $NAME or ${NAME} with the value of each variable in vars. References to
+// variables not in vars are left as is, as ssh may understand them (e.g. ${HOME}).
+func ExpandConfigVariables(line string, vars map[string]string) string {
+	return configVariableRef.ReplaceAllStringFunc(line, func(ref string) string {
+		v, ok := vars[strings.Trim(ref, "${}")]
+		if !ok {
+			return ref
+		}
+		return v
+	})
+}
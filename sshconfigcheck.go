@@ -0,0 +1,129 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var ErrBadSSHConfig = errors.New("ssh config from server was rejected by ssh.")
+
+// Host used when asking ssh to parse a config file.
+const sshConfigCheckHost = "geecert-config-check.invalid"
+
+// ssh -G still runs Match exec commands, e.g. those in a Match block with no host criteria,
+// so before checking a config they are replaced by a criterion that matches no host.
+var (
+	sshConfigMatchLine = regexp.MustCompile(`(?i)^[ \t]*match[ \t=]`)
+	sshConfigMatchExec = regexp.MustCompile(`(?i)(^|[ \t=])!?exec([ \t]+|[ \t]*=[ \t]*)("[^"]*"|[^ \t\r]+)`)
+)
+
+const sshConfigNoMatch = "host geecert-no-match.invalid"
+
+// Returned when ssh rejects the config we would write, so that it is left unchanged.
+type SSHConfigError struct {
+	Line   string // the offending line, as it would have been written
+	Output string // what ssh said
+}
+
+func (e *SSHConfigError) Error() string {
+	return fmt.Sprintf("%s Line: %q: %s", ErrBadSSHConfig, e.Line, e.Output)
+}
+
+func (e *SSHConfigError) Is(target error) bool {
+	return target == ErrBadSSHConfig
+}
+
+// As per ReplaceSectionOfFile, but first checks that ssh accepts the resulting config file,
+// so that a bad line from the server doesn't stop ssh working. If ssh isn't installed, the
+// config is written unchecked.
+func ReplaceSectionOfSSHConfig(ctx context.Context, name string, path string, lines []string, perm os.FileMode, messageIfChanged string) error {
+	return replaceSectionOfFile(ctx, name, path, lines, perm, messageIfChanged, checkSSHConfig)
+}
+
+var sshConfigErrorLine = regexp.MustCompile(`line (\d+):`)
+
+// Runs ssh -G against contents written alongside path. first and last are the line numbers
+// (from 1) of our section. Errors on other lines were already there, so are only warned about.
+func checkSSHConfig(ctx context.Context, path string, contents []byte, first, last int) error {
+	sshPath, err := exec.LookPath("ssh")
+	if err != nil {
+		logVerbose("Not checking ssh config as ssh is not found: %s", err)
+		return nil
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmpforcheck")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(withoutMatchExec(contents))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	out, err := exec.CommandContext(ctx, sshPath, "-G", "-F", f.Name(), sshConfigCheckHost).CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*exec.ExitError); !ok {
+		logVerbose("Not checking ssh config as ssh could not be run: %s", err)
+		return nil
+	}
+
+	output := strings.TrimSpace(strings.Replace(string(out), f.Name(), path, -1))
+	m := sshConfigErrorLine.FindStringSubmatch(output)
+	if m == nil {
+		return &SSHConfigError{Output: output}
+	}
+	n, _ := strconv.Atoi(m[1])
+	if n < first || n > last {
+		logWarning("ssh reports a problem in %s outside of the section we manage: %s", path, output)
+		return nil
+	}
+	lines, _ := splitLines(contents)
+	return &SSHConfigError{
+		Line:   lines[n-1],
+		Output: strings.SplitN(output, "\n", 2)[0],
+	}
+}
+
+// Returns contents with the commands of any Match exec criteria removed, as above, keeping
+// the line numbers the same.
+func withoutMatchExec(contents []byte) []byte {
+	lines := bytes.Split(contents, []byte("\n"))
+	for i, line := range lines {
+		if sshConfigMatchLine.Match(line) {
+			lines[i] = sshConfigMatchExec.ReplaceAll(line, []byte("${1}"+sshConfigNoMatch))
+		}
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
@@ -0,0 +1,192 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// The OpenSSH signature format, as used by ssh-keygen -Y and git, see PROTOCOL.sshsig.
+const (
+	sshsigMagic   = "SSHSIG"
+	sshsigVersion = 1
+	sshsigBegin   = "-----BEGIN SSH SIGNATURE-----"
+	sshsigEnd     = "-----END SSH SIGNATURE-----"
+)
+
+var (
+	ErrBadSSHSIG          = errors.New("SSH signature is not in a format we understand.")
+	ErrSSHSIGNamespace    = errors.New("SSH signature is for another namespace.")
+	ErrSSHSIGNotCertified = errors.New("SSH signature is not by a certificate.")
+)
+
+type sshsigBlob struct {
+	Version       uint32
+	PublicKey     []byte
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Signature     []byte
+}
+
+type sshsigSignedData struct {
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Digest        []byte
+}
+
+// Returns the data an SSHSIG signature is over, given the digest of the message.
+func sshsigToSign(namespace string, hashName string, digest []byte) []byte {
+	return append([]byte(sshsigMagic), ssh.Marshal(&sshsigSignedData{
+		Namespace:     namespace,
+		HashAlgorithm: hashName,
+		Digest:        digest,
+	})...)
+}
+
+// Signs the contents of r with signer, returning an armored SSH signature that ssh-keygen -Y verify
+// (and git) accept. pub, such as a certificate, is included for verifiers to check.
+// hashName is EnvelopeHashSHA256 or EnvelopeHashSHA512 (the default).
+func SignSSHSIG(signer ssh.Signer, pub ssh.PublicKey, namespace string, r io.Reader, hashName string) ([]byte, error) {
+	if len(hashName) == 0 {
+		hashName = EnvelopeHashSHA512
+	}
+	h, err := envelopeHash(hashName)
+	if err != nil {
+		return nil, err
+	}
+	digest, err := envelopeDigest(h, r, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	toSign := sshsigToSign(namespace, hashName, digest)
+	var sig *ssh.Signature
+	if as, ok := signer.(ssh.AlgorithmSigner); ok && underlyingKeyType(pub) == ssh.KeyAlgoRSA {
+		sig, err = as.SignWithAlgorithm(rand.Reader, toSign, ssh.KeyAlgoRSASHA512)
+	} else {
+		sig, err = signer.Sign(rand.Reader, toSign)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	blob := append([]byte(sshsigMagic), ssh.Marshal(&sshsigBlob{
+		Version:       sshsigVersion,
+		PublicKey:     pub.Marshal(),
+		Namespace:     namespace,
+		HashAlgorithm: hashName,
+		Signature:     ssh.Marshal(sig),
+	})...)
+	return armorSSHSIG(blob), nil
+}
+
+// Type of the key, or of the key in a certificate.
+func underlyingKeyType(pub ssh.PublicKey) string {
+	if cert, ok := pub.(*ssh.Certificate); ok {
+		return cert.Key.Type()
+	}
+	return pub.Type()
+}
+
+// Wraps blob as ssh-keygen does, in base64 lines of 70 characters.
+func armorSSHSIG(blob []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(sshsigBegin + "\n")
+	b64 := base64.StdEncoding.EncodeToString(blob)
+	for len(b64) > 70 {
+		buf.WriteString(b64[:70] + "\n")
+		b64 = b64[70:]
+	}
+	buf.WriteString(b64 + "\n")
+	buf.WriteString(sshsigEnd + "\n")
+	return buf.Bytes()
+}
+
+// Reports whether data looks like an armored SSH signature.
+func isSSHSIG(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte(sshsigBegin))
+}
+
+// Checks an armored SSH signature, as made by SignSSHSIG or ssh-keygen -Y sign, over the contents of
+// r in namespace. The signature must be by a certificate that is valid at now and signed by one of
+// caKeys, which is returned.
+func VerifySSHSIG(armored []byte, r io.Reader, namespace string, caKeys []ssh.PublicKey, now time.Time) (*ssh.Certificate, error) {
+	text := strings.TrimSpace(string(armored))
+	if !strings.HasPrefix(text, sshsigBegin) || !strings.HasSuffix(text, sshsigEnd) {
+		return nil, ErrBadSSHSIG
+	}
+	text = strings.Join(strings.Fields(strings.TrimSuffix(strings.TrimPrefix(text, sshsigBegin), sshsigEnd)), "")
+	data, err := base64.StdEncoding.DecodeString(text)
+	if err != nil || !bytes.HasPrefix(data, []byte(sshsigMagic)) {
+		return nil, ErrBadSSHSIG
+	}
+	var blob sshsigBlob
+	err = ssh.Unmarshal(data[len(sshsigMagic):], &blob)
+	if err != nil || blob.Version != sshsigVersion {
+		return nil, ErrBadSSHSIG
+	}
+	if blob.Namespace != namespace {
+		return nil, ErrSSHSIGNamespace
+	}
+	h, err := envelopeHash(blob.HashAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+	digest, err := envelopeDigest(h, r, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	pk, err := ssh.ParsePublicKey(blob.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	cert, ok := pk.(*ssh.Certificate)
+	if !ok || cert.CertType != ssh.UserCert {
+		return nil, ErrSSHSIGNotCertified
+	}
+	var sig ssh.Signature
+	err = ssh.Unmarshal(blob.Signature, &sig)
+	if err != nil {
+		return nil, ErrBadSSHSIG
+	}
+	if sig.Format == ssh.KeyAlgoRSA {
+		return nil, ErrEnvelopeWeakSignature
+	}
+	err = cert.Key.Verify(sshsigToSign(blob.Namespace, blob.HashAlgorithm, digest), &sig)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkSigningCert(cert, caKeys, now)
+	if err != nil {
+		return nil, err
+	}
+	return cert, nil
+}
@@ -0,0 +1,84 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestSSHSIG(t *testing.T) {
+	ca := testCA(t)
+	caKeys := []ssh.PublicKey{ca.PublicKey()}
+	rk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, ek, _ := ed25519.GenerateKey(rand.Reader)
+	rsaSigner, rsaCert := testCertSigner(t, ca, rk, []string{"alice"}, time.Now().Add(time.Hour))
+	edSigner, edCert := testCertSigner(t, ca, ek, []string{"alice"}, time.Now().Add(time.Hour))
+	expiredSigner, expiredCert := testCertSigner(t, ca, ek, []string{"alice"}, time.Now().Add(-time.Minute))
+	plainSigner, _ := ssh.NewSignerFromKey(ek)
+
+	for _, c := range []struct {
+		name      string
+		signer    ssh.Signer
+		pub       ssh.PublicKey
+		hash      string
+		namespace string // verified with, signed with "git"
+		payload   string // verified with, signed with "payload"
+		caKeys    []ssh.PublicKey
+		want      error
+	}{
+		{"RSA", rsaSigner, rsaCert, "", "git", "payload", caKeys, nil},
+		{"RSA SHA-256", rsaSigner, rsaCert, EnvelopeHashSHA256, "git", "payload", caKeys, nil},
+		{"Ed25519", edSigner, edCert, "", "git", "payload", caKeys, nil},
+		{"wrong namespace", edSigner, edCert, "", "file", "payload", caKeys, ErrSSHSIGNamespace},
+		{"other payload", edSigner, edCert, "", "git", "other", caKeys, errAny},
+		{"untrusted CA", edSigner, edCert, "", "git", "payload", []ssh.PublicKey{testCA(t).PublicKey()}, ErrUntrustedCA},
+		{"expired certificate", expiredSigner, expiredCert, "", "git", "payload", caKeys, errAny},
+		{"SHA-1", sha1Signer{rsaSigner}, rsaCert, "", "git", "payload", caKeys, ErrEnvelopeWeakSignature},
+		{"not certified", plainSigner, plainSigner.PublicKey(), "", "git", "payload", caKeys, ErrSSHSIGNotCertified},
+	} {
+		sig, err := SignSSHSIG(c.signer, c.pub, "git", strings.NewReader("payload"), c.hash)
+		if err != nil {
+			t.Fatal(c.name, err)
+		}
+		if !isSSHSIG(sig) {
+			t.Errorf("%s: not armored: %s", c.name, sig)
+		}
+		cert, err := VerifySSHSIG(sig, strings.NewReader(c.payload), c.namespace, c.caKeys, time.Now())
+		checkTestError(t, c.name, err, c.want)
+		if err == nil && cert.KeyId != "alice" {
+			t.Errorf("%s: got %v", c.name, cert)
+		}
+	}
+
+	for _, bad := range []string{"", "-----BEGIN SSH SIGNATURE-----\nnope\n-----END SSH SIGNATURE-----"} {
+		if _, err := VerifySSHSIG([]byte(bad), strings.NewReader("payload"), "git", caKeys, time.Now()); err != ErrBadSSHSIG {
+			t.Errorf("%q: %v", bad, err)
+		}
+	}
+}
@@ -9,8 +9,43 @@ It is generated from these files:
 	sso.proto
 
 It has these top-level messages:
+	ErrorDetail
 	SSHCertsRequest
+	AccessRequest
 	SSHCertsResponse
+	ClientDirectives
+	RealmCertificate
+	BastionPolicy
+	SSHConfigBlock
+	HostCertificateAuthority
+	LookupCertRequest
+	IssuedCertRecord
+	LookupCertResponse
+	ListApprovalsRequest
+	ApprovalRecord
+	ListApprovalsResponse
+	DecideApprovalRequest
+	DecideApprovalResponse
+	WatchRequest
+	WatchUpdate
+	BreakGlassRequest
+	TelemetryReport
+	TelemetryResponse
+	TelemetrySummaryRequest
+	TelemetryCount
+	TelemetrySummaryResponse
+	PickupCodeRequest
+	PickupCodeResponse
+	EnrollDeviceRequest
+	EnrollDeviceResponse
+	RevokeDeviceRequest
+	RevokeDeviceResponse
+	BatchCertsRequest
+	BatchCertRequest
+	BatchCertsResponse
+	BatchCert
+	ExplainPolicyRequest
+	ExplainPolicyResponse
 	ServerConfig
 */
 package sso
@@ -20,7 +55,7 @@ import fmt "fmt"
 import math "math"
 
 import (
-	context "golang.org/x/net/context"
+	context "context"
 	grpc "google.golang.org/grpc"
 )
 
@@ -35,39 +70,199 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
+type ErrorReason int32
+
+const (
+	ErrorReason_UNKNOWN_ERROR     ErrorReason = 0
+	ErrorReason_TOKEN_EXPIRED     ErrorReason = 1
+	ErrorReason_DOMAIN_MISMATCH   ErrorReason = 2
+	ErrorReason_POLICY_VIOLATION  ErrorReason = 3
+	ErrorReason_TOKEN_INVALID     ErrorReason = 4
+	ErrorReason_CLIENT_TOO_OLD    ErrorReason = 5
+	ErrorReason_MESSAGE_TOO_LARGE ErrorReason = 6
+)
+
+var ErrorReason_name = map[int32]string{
+	0: "UNKNOWN_ERROR",
+	1: "TOKEN_EXPIRED",
+	2: "DOMAIN_MISMATCH",
+	3: "POLICY_VIOLATION",
+	4: "TOKEN_INVALID",
+	5: "CLIENT_TOO_OLD",
+	6: "MESSAGE_TOO_LARGE",
+}
+var ErrorReason_value = map[string]int32{
+	"UNKNOWN_ERROR":     0,
+	"TOKEN_EXPIRED":     1,
+	"DOMAIN_MISMATCH":   2,
+	"POLICY_VIOLATION":  3,
+	"TOKEN_INVALID":     4,
+	"CLIENT_TOO_OLD":    5,
+	"MESSAGE_TOO_LARGE": 6,
+}
+
+func (x ErrorReason) String() string {
+	return proto.EnumName(ErrorReason_name, int32(x))
+}
+func (ErrorReason) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+
+type CredentialType int32
+
+const (
+	CredentialType_ID_TOKEN   CredentialType = 0
+	CredentialType_KERBEROS   CredentialType = 1
+	CredentialType_KUBERNETES CredentialType = 2
+)
+
+var CredentialType_name = map[int32]string{
+	0: "ID_TOKEN",
+	1: "KERBEROS",
+	2: "KUBERNETES",
+}
+var CredentialType_value = map[string]int32{
+	"ID_TOKEN":   0,
+	"KERBEROS":   1,
+	"KUBERNETES": 2,
+}
+
+func (x CredentialType) String() string {
+	return proto.EnumName(CredentialType_name, int32(x))
+}
+func (CredentialType) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
 type ResponseCode int32
 
 const (
-	ResponseCode_OK               ResponseCode = 0
-	ResponseCode_INVALID_ID_TOKEN ResponseCode = 1
-	ResponseCode_NO_CERTS_ALLOWED ResponseCode = 2
+	ResponseCode_OK                   ResponseCode = 0
+	ResponseCode_INVALID_ID_TOKEN     ResponseCode = 1
+	ResponseCode_NO_CERTS_ALLOWED     ResponseCode = 2
+	ResponseCode_RATE_LIMITED         ResponseCode = 3
+	ResponseCode_NOT_AUTHORIZED       ResponseCode = 4
+	ResponseCode_REAUTH_REQUIRED      ResponseCode = 5
+	ResponseCode_APPROVAL_PENDING     ResponseCode = 6
+	ResponseCode_REASON_REQUIRED      ResponseCode = 7
+	ResponseCode_UPGRADE_REQUIRED     ResponseCode = 8
+	ResponseCode_TOO_MANY_CERTS       ResponseCode = 9
+	ResponseCode_DEVICE_NOT_ENROLLED  ResponseCode = 10
+	ResponseCode_DEVICE_NOT_COMPLIANT ResponseCode = 11
+	ResponseCode_TICKET_REJECTED      ResponseCode = 12
+	ResponseCode_NETWORK_NOT_ALLOWED  ResponseCode = 13
 )
 
 var ResponseCode_name = map[int32]string{
-	0: "OK",
-	1: "INVALID_ID_TOKEN",
-	2: "NO_CERTS_ALLOWED",
+	0:  "OK",
+	1:  "INVALID_ID_TOKEN",
+	2:  "NO_CERTS_ALLOWED",
+	3:  "RATE_LIMITED",
+	4:  "NOT_AUTHORIZED",
+	5:  "REAUTH_REQUIRED",
+	6:  "APPROVAL_PENDING",
+	7:  "REASON_REQUIRED",
+	8:  "UPGRADE_REQUIRED",
+	9:  "TOO_MANY_CERTS",
+	10: "DEVICE_NOT_ENROLLED",
+	11: "DEVICE_NOT_COMPLIANT",
+	12: "TICKET_REJECTED",
+	13: "NETWORK_NOT_ALLOWED",
 }
 var ResponseCode_value = map[string]int32{
-	"OK":               0,
-	"INVALID_ID_TOKEN": 1,
-	"NO_CERTS_ALLOWED": 2,
+	"OK":                   0,
+	"INVALID_ID_TOKEN":     1,
+	"NO_CERTS_ALLOWED":     2,
+	"RATE_LIMITED":         3,
+	"NOT_AUTHORIZED":       4,
+	"REAUTH_REQUIRED":      5,
+	"APPROVAL_PENDING":     6,
+	"REASON_REQUIRED":      7,
+	"UPGRADE_REQUIRED":     8,
+	"TOO_MANY_CERTS":       9,
+	"DEVICE_NOT_ENROLLED":  10,
+	"DEVICE_NOT_COMPLIANT": 11,
+	"TICKET_REJECTED":      12,
+	"NETWORK_NOT_ALLOWED":  13,
 }
 
 func (x ResponseCode) String() string {
 	return proto.EnumName(ResponseCode_name, int32(x))
 }
-func (ResponseCode) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+func (ResponseCode) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+type ErrorDetail struct {
+	Reason            ErrorReason  `protobuf:"varint,1,opt,name=reason,enum=ErrorReason" json:"reason,omitempty"`
+	Status            ResponseCode `protobuf:"varint,2,opt,name=status,enum=ResponseCode" json:"status,omitempty"`
+	Remediation       string       `protobuf:"bytes,3,opt,name=remediation" json:"remediation,omitempty"`
+	MaxAuthAgeSeconds int32        `protobuf:"varint,4,opt,name=max_auth_age_seconds,json=maxAuthAgeSeconds" json:"max_auth_age_seconds,omitempty"`
+	ApprovalId        string       `protobuf:"bytes,5,opt,name=approval_id,json=approvalId" json:"approval_id,omitempty"`
+	UpgradeUrl        string       `protobuf:"bytes,6,opt,name=upgrade_url,json=upgradeUrl" json:"upgrade_url,omitempty"`
+}
+
+func (m *ErrorDetail) Reset()                    { *m = ErrorDetail{} }
+func (m *ErrorDetail) String() string            { return proto.CompactTextString(m) }
+func (*ErrorDetail) ProtoMessage()               {}
+func (*ErrorDetail) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+
+func (m *ErrorDetail) GetReason() ErrorReason {
+	if m != nil {
+		return m.Reason
+	}
+	return ErrorReason_UNKNOWN_ERROR
+}
+
+func (m *ErrorDetail) GetStatus() ResponseCode {
+	if m != nil {
+		return m.Status
+	}
+	return ResponseCode_OK
+}
+
+func (m *ErrorDetail) GetRemediation() string {
+	if m != nil {
+		return m.Remediation
+	}
+	return ""
+}
+
+func (m *ErrorDetail) GetMaxAuthAgeSeconds() int32 {
+	if m != nil {
+		return m.MaxAuthAgeSeconds
+	}
+	return 0
+}
+
+func (m *ErrorDetail) GetApprovalId() string {
+	if m != nil {
+		return m.ApprovalId
+	}
+	return ""
+}
+
+func (m *ErrorDetail) GetUpgradeUrl() string {
+	if m != nil {
+		return m.UpgradeUrl
+	}
+	return ""
+}
 
 type SSHCertsRequest struct {
-	IdToken   string `protobuf:"bytes,1,opt,name=id_token,json=idToken" json:"id_token,omitempty"`
-	PublicKey string `protobuf:"bytes,2,opt,name=public_key,json=publicKey" json:"public_key,omitempty"`
+	IdToken             string         `protobuf:"bytes,1,opt,name=id_token,json=idToken" json:"id_token,omitempty"`
+	CredentialType      CredentialType `protobuf:"varint,6,opt,name=credential_type,json=credentialType,enum=CredentialType" json:"credential_type,omitempty"`
+	PublicKey           string         `protobuf:"bytes,2,opt,name=public_key,json=publicKey" json:"public_key,omitempty"`
+	ApprovalId          string         `protobuf:"bytes,3,opt,name=approval_id,json=approvalId" json:"approval_id,omitempty"`
+	Reason              string         `protobuf:"bytes,4,opt,name=reason" json:"reason,omitempty"`
+	SourceAddress       string         `protobuf:"bytes,5,opt,name=source_address,json=sourceAddress" json:"source_address,omitempty"`
+	ReplacesFingerprint string         `protobuf:"bytes,7,opt,name=replaces_fingerprint,json=replacesFingerprint" json:"replaces_fingerprint,omitempty"`
+	DeviceId            string         `protobuf:"bytes,8,opt,name=device_id,json=deviceId" json:"device_id,omitempty"`
+	DeviceSignature     string         `protobuf:"bytes,9,opt,name=device_signature,json=deviceSignature" json:"device_signature,omitempty"`
+	ConfigHash          string         `protobuf:"bytes,10,opt,name=config_hash,json=configHash" json:"config_hash,omitempty"`
+	OnBehalfOf          string         `protobuf:"bytes,11,opt,name=on_behalf_of,json=onBehalfOf" json:"on_behalf_of,omitempty"`
+	AccessRequest       *AccessRequest `protobuf:"bytes,12,opt,name=access_request,json=accessRequest" json:"access_request,omitempty"`
+	RequestId           string         `protobuf:"bytes,13,opt,name=request_id,json=requestId" json:"request_id,omitempty"`
 }
 
 func (m *SSHCertsRequest) Reset()                    { *m = SSHCertsRequest{} }
 func (m *SSHCertsRequest) String() string            { return proto.CompactTextString(m) }
 func (*SSHCertsRequest) ProtoMessage()               {}
-func (*SSHCertsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+func (*SSHCertsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
 
 func (m *SSHCertsRequest) GetIdToken() string {
 	if m != nil {
@@ -76,6 +271,13 @@ func (m *SSHCertsRequest) GetIdToken() string {
 	return ""
 }
 
+func (m *SSHCertsRequest) GetCredentialType() CredentialType {
+	if m != nil {
+		return m.CredentialType
+	}
+	return CredentialType_ID_TOKEN
+}
+
 func (m *SSHCertsRequest) GetPublicKey() string {
 	if m != nil {
 		return m.PublicKey
@@ -83,17 +285,138 @@ func (m *SSHCertsRequest) GetPublicKey() string {
 	return ""
 }
 
+func (m *SSHCertsRequest) GetApprovalId() string {
+	if m != nil {
+		return m.ApprovalId
+	}
+	return ""
+}
+
+func (m *SSHCertsRequest) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *SSHCertsRequest) GetSourceAddress() string {
+	if m != nil {
+		return m.SourceAddress
+	}
+	return ""
+}
+
+func (m *SSHCertsRequest) GetReplacesFingerprint() string {
+	if m != nil {
+		return m.ReplacesFingerprint
+	}
+	return ""
+}
+
+func (m *SSHCertsRequest) GetDeviceId() string {
+	if m != nil {
+		return m.DeviceId
+	}
+	return ""
+}
+
+func (m *SSHCertsRequest) GetDeviceSignature() string {
+	if m != nil {
+		return m.DeviceSignature
+	}
+	return ""
+}
+
+func (m *SSHCertsRequest) GetConfigHash() string {
+	if m != nil {
+		return m.ConfigHash
+	}
+	return ""
+}
+
+func (m *SSHCertsRequest) GetOnBehalfOf() string {
+	if m != nil {
+		return m.OnBehalfOf
+	}
+	return ""
+}
+
+func (m *SSHCertsRequest) GetAccessRequest() *AccessRequest {
+	if m != nil {
+		return m.AccessRequest
+	}
+	return nil
+}
+
+func (m *SSHCertsRequest) GetRequestId() string {
+	if m != nil {
+		return m.RequestId
+	}
+	return ""
+}
+
+type AccessRequest struct {
+	Role            string `protobuf:"bytes,1,opt,name=role" json:"role,omitempty"`
+	DurationSeconds int32  `protobuf:"varint,2,opt,name=duration_seconds,json=durationSeconds" json:"duration_seconds,omitempty"`
+	Ticket          string `protobuf:"bytes,3,opt,name=ticket" json:"ticket,omitempty"`
+	Justification   string `protobuf:"bytes,4,opt,name=justification" json:"justification,omitempty"`
+}
+
+func (m *AccessRequest) Reset()                    { *m = AccessRequest{} }
+func (m *AccessRequest) String() string            { return proto.CompactTextString(m) }
+func (*AccessRequest) ProtoMessage()               {}
+func (*AccessRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+func (m *AccessRequest) GetRole() string {
+	if m != nil {
+		return m.Role
+	}
+	return ""
+}
+
+func (m *AccessRequest) GetDurationSeconds() int32 {
+	if m != nil {
+		return m.DurationSeconds
+	}
+	return 0
+}
+
+func (m *AccessRequest) GetTicket() string {
+	if m != nil {
+		return m.Ticket
+	}
+	return ""
+}
+
+func (m *AccessRequest) GetJustification() string {
+	if m != nil {
+		return m.Justification
+	}
+	return ""
+}
+
 type SSHCertsResponse struct {
-	Status                 ResponseCode `protobuf:"varint,1,opt,name=status,enum=ResponseCode" json:"status,omitempty"`
-	Certificate            string       `protobuf:"bytes,2,opt,name=certificate" json:"certificate,omitempty"`
-	CertificateAuthorities []string     `protobuf:"bytes,3,rep,name=certificate_authorities,json=certificateAuthorities" json:"certificate_authorities,omitempty"`
-	Config                 []string     `protobuf:"bytes,4,rep,name=config" json:"config,omitempty"`
+	Status                     ResponseCode                `protobuf:"varint,1,opt,name=status,enum=ResponseCode" json:"status,omitempty"`
+	Certificate                string                      `protobuf:"bytes,2,opt,name=certificate" json:"certificate,omitempty"`
+	CertificateAuthorities     []string                    `protobuf:"bytes,3,rep,name=certificate_authorities,json=certificateAuthorities" json:"certificate_authorities,omitempty"`
+	Config                     []string                    `protobuf:"bytes,4,rep,name=config" json:"config,omitempty"`
+	ConfigBlocks               []*SSHConfigBlock           `protobuf:"bytes,5,rep,name=config_blocks,json=configBlocks" json:"config_blocks,omitempty"`
+	ConfigVariables            map[string]string           `protobuf:"bytes,6,rep,name=config_variables,json=configVariables" json:"config_variables,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	BastionPolicies            []*BastionPolicy            `protobuf:"bytes,7,rep,name=bastion_policies,json=bastionPolicies" json:"bastion_policies,omitempty"`
+	MaxAuthAgeSeconds          int32                       `protobuf:"varint,8,opt,name=max_auth_age_seconds,json=maxAuthAgeSeconds" json:"max_auth_age_seconds,omitempty"`
+	ApprovalId                 string                      `protobuf:"bytes,9,opt,name=approval_id,json=approvalId" json:"approval_id,omitempty"`
+	RealmCertificates          []*RealmCertificate         `protobuf:"bytes,10,rep,name=realm_certificates,json=realmCertificates" json:"realm_certificates,omitempty"`
+	Directives                 *ClientDirectives           `protobuf:"bytes,11,opt,name=directives" json:"directives,omitempty"`
+	ConfigHash                 string                      `protobuf:"bytes,12,opt,name=config_hash,json=configHash" json:"config_hash,omitempty"`
+	ConfigUnchanged            bool                        `protobuf:"varint,13,opt,name=config_unchanged,json=configUnchanged" json:"config_unchanged,omitempty"`
+	HostCertificateAuthorities []*HostCertificateAuthority `protobuf:"bytes,14,rep,name=host_certificate_authorities,json=hostCertificateAuthorities" json:"host_certificate_authorities,omitempty"`
+	AllowedSigners             []string                    `protobuf:"bytes,15,rep,name=allowed_signers,json=allowedSigners" json:"allowed_signers,omitempty"`
 }
 
 func (m *SSHCertsResponse) Reset()                    { *m = SSHCertsResponse{} }
 func (m *SSHCertsResponse) String() string            { return proto.CompactTextString(m) }
 func (*SSHCertsResponse) ProtoMessage()               {}
-func (*SSHCertsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+func (*SSHCertsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
 
 func (m *SSHCertsResponse) GetStatus() ResponseCode {
 	if m != nil {
@@ -123,288 +446,4355 @@ func (m *SSHCertsResponse) GetConfig() []string {
 	return nil
 }
 
-type ServerConfig struct {
-	CaKeyPath                      string                              `protobuf:"bytes,1,opt,name=ca_key_path,json=caKeyPath" json:"ca_key_path,omitempty"`
-	GenerateCertDurationSeconds    int32                               `protobuf:"varint,2,opt,name=generate_cert_duration_seconds,json=generateCertDurationSeconds" json:"generate_cert_duration_seconds,omitempty"`
-	ClientConfigScope              string                              `protobuf:"bytes,3,opt,name=client_config_scope,json=clientConfigScope" json:"client_config_scope,omitempty"`
-	AllowedUsers                   map[string]*ServerConfig_UserConfig `protobuf:"bytes,4,rep,name=allowed_users,json=allowedUsers" json:"allowed_users,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	ListenPort                     int32                               `protobuf:"varint,5,opt,name=listen_port,json=listenPort" json:"listen_port,omitempty"`
-	AllowedDomainForIdToken        string                              `protobuf:"bytes,6,opt,name=allowed_domain_for_id_token,json=allowedDomainForIdToken" json:"allowed_domain_for_id_token,omitempty"`
-	AllowedClientIdForIdToken      string                              `protobuf:"bytes,7,opt,name=allowed_client_id_for_id_token,json=allowedClientIdForIdToken" json:"allowed_client_id_for_id_token,omitempty"`
-	ServerCertPath                 string                              `protobuf:"bytes,8,opt,name=server_cert_path,json=serverCertPath" json:"server_cert_path,omitempty"`
-	ServerKeyPath                  string                              `protobuf:"bytes,9,opt,name=server_key_path,json=serverKeyPath" json:"server_key_path,omitempty"`
-	AdditionalSshConfigurationLine []string                            `protobuf:"bytes,10,rep,name=additional_ssh_configuration_line,json=additionalSshConfigurationLine" json:"additional_ssh_configuration_line,omitempty"`
-	CaComment                      string                              `protobuf:"bytes,11,opt,name=ca_comment,json=caComment" json:"ca_comment,omitempty"`
-	HttpListenPort                 int32                               `protobuf:"varint,12,opt,name=http_listen_port,json=httpListenPort" json:"http_listen_port,omitempty"`
-	AllowedHosts                   []string                            `protobuf:"bytes,13,rep,name=allowed_hosts,json=allowedHosts" json:"allowed_hosts,omitempty"`
-	CaddyFilePath                  string                              `protobuf:"bytes,14,opt,name=caddy_file_path,json=caddyFilePath" json:"caddy_file_path,omitempty"`
+func (m *SSHCertsResponse) GetConfigBlocks() []*SSHConfigBlock {
+	if m != nil {
+		return m.ConfigBlocks
+	}
+	return nil
 }
 
-func (m *ServerConfig) Reset()                    { *m = ServerConfig{} }
-func (m *ServerConfig) String() string            { return proto.CompactTextString(m) }
-func (*ServerConfig) ProtoMessage()               {}
-func (*ServerConfig) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+func (m *SSHCertsResponse) GetConfigVariables() map[string]string {
+	if m != nil {
+		return m.ConfigVariables
+	}
+	return nil
+}
 
-func (m *ServerConfig) GetCaKeyPath() string {
+func (m *SSHCertsResponse) GetBastionPolicies() []*BastionPolicy {
 	if m != nil {
-		return m.CaKeyPath
+		return m.BastionPolicies
 	}
-	return ""
+	return nil
 }
 
-func (m *ServerConfig) GetGenerateCertDurationSeconds() int32 {
+func (m *SSHCertsResponse) GetMaxAuthAgeSeconds() int32 {
 	if m != nil {
-		return m.GenerateCertDurationSeconds
+		return m.MaxAuthAgeSeconds
 	}
 	return 0
 }
 
-func (m *ServerConfig) GetClientConfigScope() string {
+func (m *SSHCertsResponse) GetApprovalId() string {
 	if m != nil {
-		return m.ClientConfigScope
+		return m.ApprovalId
 	}
 	return ""
 }
 
-func (m *ServerConfig) GetAllowedUsers() map[string]*ServerConfig_UserConfig {
+func (m *SSHCertsResponse) GetRealmCertificates() []*RealmCertificate {
 	if m != nil {
-		return m.AllowedUsers
+		return m.RealmCertificates
 	}
 	return nil
 }
 
-func (m *ServerConfig) GetListenPort() int32 {
+func (m *SSHCertsResponse) GetDirectives() *ClientDirectives {
 	if m != nil {
-		return m.ListenPort
+		return m.Directives
 	}
-	return 0
+	return nil
 }
 
-func (m *ServerConfig) GetAllowedDomainForIdToken() string {
+func (m *SSHCertsResponse) GetConfigHash() string {
 	if m != nil {
-		return m.AllowedDomainForIdToken
+		return m.ConfigHash
 	}
 	return ""
 }
 
-func (m *ServerConfig) GetAllowedClientIdForIdToken() string {
+func (m *SSHCertsResponse) GetConfigUnchanged() bool {
 	if m != nil {
-		return m.AllowedClientIdForIdToken
+		return m.ConfigUnchanged
 	}
-	return ""
+	return false
 }
 
-func (m *ServerConfig) GetServerCertPath() string {
+func (m *SSHCertsResponse) GetHostCertificateAuthorities() []*HostCertificateAuthority {
 	if m != nil {
-		return m.ServerCertPath
+		return m.HostCertificateAuthorities
 	}
-	return ""
+	return nil
 }
 
-func (m *ServerConfig) GetServerKeyPath() string {
+func (m *SSHCertsResponse) GetAllowedSigners() []string {
 	if m != nil {
-		return m.ServerKeyPath
+		return m.AllowedSigners
 	}
-	return ""
+	return nil
 }
 
-func (m *ServerConfig) GetAdditionalSshConfigurationLine() []string {
+type ClientDirectives struct {
+	RenewBeforeSeconds  int32  `protobuf:"varint,1,opt,name=renew_before_seconds,json=renewBeforeSeconds" json:"renew_before_seconds,omitempty"`
+	MinClientVersion    string `protobuf:"bytes,2,opt,name=min_client_version,json=minClientVersion" json:"min_client_version,omitempty"`
+	AgentOnly           bool   `protobuf:"varint,3,opt,name=agent_only,json=agentOnly" json:"agent_only,omitempty"`
+	NotifyBeforeSeconds int32  `protobuf:"varint,4,opt,name=notify_before_seconds,json=notifyBeforeSeconds" json:"notify_before_seconds,omitempty"`
+}
+
+func (m *ClientDirectives) Reset()                    { *m = ClientDirectives{} }
+func (m *ClientDirectives) String() string            { return proto.CompactTextString(m) }
+func (*ClientDirectives) ProtoMessage()               {}
+func (*ClientDirectives) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *ClientDirectives) GetRenewBeforeSeconds() int32 {
 	if m != nil {
-		return m.AdditionalSshConfigurationLine
+		return m.RenewBeforeSeconds
 	}
-	return nil
+	return 0
 }
 
-func (m *ServerConfig) GetCaComment() string {
+func (m *ClientDirectives) GetMinClientVersion() string {
 	if m != nil {
-		return m.CaComment
+		return m.MinClientVersion
 	}
 	return ""
 }
 
-func (m *ServerConfig) GetHttpListenPort() int32 {
+func (m *ClientDirectives) GetAgentOnly() bool {
 	if m != nil {
-		return m.HttpListenPort
+		return m.AgentOnly
 	}
-	return 0
+	return false
 }
 
-func (m *ServerConfig) GetAllowedHosts() []string {
+func (m *ClientDirectives) GetNotifyBeforeSeconds() int32 {
 	if m != nil {
-		return m.AllowedHosts
+		return m.NotifyBeforeSeconds
 	}
-	return nil
+	return 0
 }
 
-func (m *ServerConfig) GetCaddyFilePath() string {
+type RealmCertificate struct {
+	Name                       string                      `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Certificate                string                      `protobuf:"bytes,2,opt,name=certificate" json:"certificate,omitempty"`
+	CertificateAuthorities     []string                    `protobuf:"bytes,3,rep,name=certificate_authorities,json=certificateAuthorities" json:"certificate_authorities,omitempty"`
+	ConfigBlocks               []*SSHConfigBlock           `protobuf:"bytes,4,rep,name=config_blocks,json=configBlocks" json:"config_blocks,omitempty"`
+	HostCertificateAuthorities []*HostCertificateAuthority `protobuf:"bytes,5,rep,name=host_certificate_authorities,json=hostCertificateAuthorities" json:"host_certificate_authorities,omitempty"`
+}
+
+func (m *RealmCertificate) Reset()                    { *m = RealmCertificate{} }
+func (m *RealmCertificate) String() string            { return proto.CompactTextString(m) }
+func (*RealmCertificate) ProtoMessage()               {}
+func (*RealmCertificate) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+
+func (m *RealmCertificate) GetName() string {
 	if m != nil {
-		return m.CaddyFilePath
+		return m.Name
 	}
 	return ""
 }
 
-type ServerConfig_UserConfig struct {
-	Username        string            `protobuf:"bytes,1,opt,name=username" json:"username,omitempty"`
-	ExtraPrincipals []string          `protobuf:"bytes,2,rep,name=extra_principals,json=extraPrincipals" json:"extra_principals,omitempty"`
-	CertPermissions map[string]string `protobuf:"bytes,3,rep,name=cert_permissions,json=certPermissions" json:"cert_permissions,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-}
-
-func (m *ServerConfig_UserConfig) Reset()                    { *m = ServerConfig_UserConfig{} }
-func (m *ServerConfig_UserConfig) String() string            { return proto.CompactTextString(m) }
-func (*ServerConfig_UserConfig) ProtoMessage()               {}
-func (*ServerConfig_UserConfig) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2, 0} }
-
-func (m *ServerConfig_UserConfig) GetUsername() string {
+func (m *RealmCertificate) GetCertificate() string {
 	if m != nil {
-		return m.Username
+		return m.Certificate
 	}
 	return ""
 }
 
-func (m *ServerConfig_UserConfig) GetExtraPrincipals() []string {
+func (m *RealmCertificate) GetCertificateAuthorities() []string {
 	if m != nil {
-		return m.ExtraPrincipals
+		return m.CertificateAuthorities
 	}
 	return nil
 }
 
-func (m *ServerConfig_UserConfig) GetCertPermissions() map[string]string {
+func (m *RealmCertificate) GetConfigBlocks() []*SSHConfigBlock {
 	if m != nil {
-		return m.CertPermissions
+		return m.ConfigBlocks
 	}
 	return nil
 }
 
-func init() {
-	proto.RegisterType((*SSHCertsRequest)(nil), "SSHCertsRequest")
-	proto.RegisterType((*SSHCertsResponse)(nil), "SSHCertsResponse")
-	proto.RegisterType((*ServerConfig)(nil), "ServerConfig")
-	proto.RegisterType((*ServerConfig_UserConfig)(nil), "ServerConfig.UserConfig")
-	proto.RegisterEnum("ResponseCode", ResponseCode_name, ResponseCode_value)
+func (m *RealmCertificate) GetHostCertificateAuthorities() []*HostCertificateAuthority {
+	if m != nil {
+		return m.HostCertificateAuthorities
+	}
+	return nil
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
-
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
+type BastionPolicy struct {
+	HostPattern []string `protobuf:"bytes,1,rep,name=host_pattern,json=hostPattern" json:"host_pattern,omitempty"`
+	JumpHost    []string `protobuf:"bytes,2,rep,name=jump_host,json=jumpHost" json:"jump_host,omitempty"`
+}
 
-// Client API for GeeCertServer service
+func (m *BastionPolicy) Reset()                    { *m = BastionPolicy{} }
+func (m *BastionPolicy) String() string            { return proto.CompactTextString(m) }
+func (*BastionPolicy) ProtoMessage()               {}
+func (*BastionPolicy) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
 
-type GeeCertServerClient interface {
-	GetSSHCerts(ctx context.Context, in *SSHCertsRequest, opts ...grpc.CallOption) (*SSHCertsResponse, error)
+func (m *BastionPolicy) GetHostPattern() []string {
+	if m != nil {
+		return m.HostPattern
+	}
+	return nil
 }
 
-type geeCertServerClient struct {
-	cc *grpc.ClientConn
+func (m *BastionPolicy) GetJumpHost() []string {
+	if m != nil {
+		return m.JumpHost
+	}
+	return nil
 }
 
-func NewGeeCertServerClient(cc *grpc.ClientConn) GeeCertServerClient {
-	return &geeCertServerClient{cc}
+type SSHConfigBlock struct {
+	HostPattern    []string                 `protobuf:"bytes,1,rep,name=host_pattern,json=hostPattern" json:"host_pattern,omitempty"`
+	Match          string                   `protobuf:"bytes,2,opt,name=match" json:"match,omitempty"`
+	User           string                   `protobuf:"bytes,3,opt,name=user" json:"user,omitempty"`
+	UseCertificate bool                     `protobuf:"varint,4,opt,name=use_certificate,json=useCertificate" json:"use_certificate,omitempty"`
+	Option         []*SSHConfigBlock_Option `protobuf:"bytes,5,rep,name=option" json:"option,omitempty"`
 }
 
-func (c *geeCertServerClient) GetSSHCerts(ctx context.Context, in *SSHCertsRequest, opts ...grpc.CallOption) (*SSHCertsResponse, error) {
-	out := new(SSHCertsResponse)
-	err := grpc.Invoke(ctx, "/GeeCertServer/GetSSHCerts", in, out, c.cc, opts...)
-	if err != nil {
-		return nil, err
+func (m *SSHConfigBlock) Reset()                    { *m = SSHConfigBlock{} }
+func (m *SSHConfigBlock) String() string            { return proto.CompactTextString(m) }
+func (*SSHConfigBlock) ProtoMessage()               {}
+func (*SSHConfigBlock) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+func (m *SSHConfigBlock) GetHostPattern() []string {
+	if m != nil {
+		return m.HostPattern
 	}
-	return out, nil
+	return nil
 }
 
-// Server API for GeeCertServer service
+func (m *SSHConfigBlock) GetMatch() string {
+	if m != nil {
+		return m.Match
+	}
+	return ""
+}
 
-type GeeCertServerServer interface {
-	GetSSHCerts(context.Context, *SSHCertsRequest) (*SSHCertsResponse, error)
+func (m *SSHConfigBlock) GetUser() string {
+	if m != nil {
+		return m.User
+	}
+	return ""
 }
 
-func RegisterGeeCertServerServer(s *grpc.Server, srv GeeCertServerServer) {
-	s.RegisterService(&_GeeCertServer_serviceDesc, srv)
+func (m *SSHConfigBlock) GetUseCertificate() bool {
+	if m != nil {
+		return m.UseCertificate
+	}
+	return false
 }
 
-func _GeeCertServer_GetSSHCerts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+func (m *SSHConfigBlock) GetOption() []*SSHConfigBlock_Option {
+	if m != nil {
+		return m.Option
+	}
+	return nil
+}
+
+type SSHConfigBlock_Option struct {
+	Keyword string `protobuf:"bytes,1,opt,name=keyword" json:"keyword,omitempty"`
+	Value   string `protobuf:"bytes,2,opt,name=value" json:"value,omitempty"`
+}
+
+func (m *SSHConfigBlock_Option) Reset()                    { *m = SSHConfigBlock_Option{} }
+func (m *SSHConfigBlock_Option) String() string            { return proto.CompactTextString(m) }
+func (*SSHConfigBlock_Option) ProtoMessage()               {}
+func (*SSHConfigBlock_Option) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7, 0} }
+
+func (m *SSHConfigBlock_Option) GetKeyword() string {
+	if m != nil {
+		return m.Keyword
+	}
+	return ""
+}
+
+func (m *SSHConfigBlock_Option) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+type HostCertificateAuthority struct {
+	HostPattern []string `protobuf:"bytes,1,rep,name=host_pattern,json=hostPattern" json:"host_pattern,omitempty"`
+	PublicKey   string   `protobuf:"bytes,2,opt,name=public_key,json=publicKey" json:"public_key,omitempty"`
+	Comment     string   `protobuf:"bytes,3,opt,name=comment" json:"comment,omitempty"`
+}
+
+func (m *HostCertificateAuthority) Reset()                    { *m = HostCertificateAuthority{} }
+func (m *HostCertificateAuthority) String() string            { return proto.CompactTextString(m) }
+func (*HostCertificateAuthority) ProtoMessage()               {}
+func (*HostCertificateAuthority) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+
+func (m *HostCertificateAuthority) GetHostPattern() []string {
+	if m != nil {
+		return m.HostPattern
+	}
+	return nil
+}
+
+func (m *HostCertificateAuthority) GetPublicKey() string {
+	if m != nil {
+		return m.PublicKey
+	}
+	return ""
+}
+
+func (m *HostCertificateAuthority) GetComment() string {
+	if m != nil {
+		return m.Comment
+	}
+	return ""
+}
+
+type LookupCertRequest struct {
+	IdToken        string         `protobuf:"bytes,1,opt,name=id_token,json=idToken" json:"id_token,omitempty"`
+	CredentialType CredentialType `protobuf:"varint,4,opt,name=credential_type,json=credentialType,enum=CredentialType" json:"credential_type,omitempty"`
+	Serial         uint64         `protobuf:"varint,2,opt,name=serial" json:"serial,omitempty"`
+	Fingerprint    string         `protobuf:"bytes,3,opt,name=fingerprint" json:"fingerprint,omitempty"`
+}
+
+func (m *LookupCertRequest) Reset()                    { *m = LookupCertRequest{} }
+func (m *LookupCertRequest) String() string            { return proto.CompactTextString(m) }
+func (*LookupCertRequest) ProtoMessage()               {}
+func (*LookupCertRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+
+func (m *LookupCertRequest) GetIdToken() string {
+	if m != nil {
+		return m.IdToken
+	}
+	return ""
+}
+
+func (m *LookupCertRequest) GetCredentialType() CredentialType {
+	if m != nil {
+		return m.CredentialType
+	}
+	return CredentialType_ID_TOKEN
+}
+
+func (m *LookupCertRequest) GetSerial() uint64 {
+	if m != nil {
+		return m.Serial
+	}
+	return 0
+}
+
+func (m *LookupCertRequest) GetFingerprint() string {
+	if m != nil {
+		return m.Fingerprint
+	}
+	return ""
+}
+
+type IssuedCertRecord struct {
+	Serial      uint64   `protobuf:"varint,1,opt,name=serial" json:"serial,omitempty"`
+	Email       string   `protobuf:"bytes,2,opt,name=email" json:"email,omitempty"`
+	KeyId       string   `protobuf:"bytes,3,opt,name=key_id,json=keyId" json:"key_id,omitempty"`
+	Principals  []string `protobuf:"bytes,4,rep,name=principals" json:"principals,omitempty"`
+	Fingerprint string   `protobuf:"bytes,5,opt,name=fingerprint" json:"fingerprint,omitempty"`
+	ValidAfter  int64    `protobuf:"varint,6,opt,name=valid_after,json=validAfter" json:"valid_after,omitempty"`
+	ValidBefore int64    `protobuf:"varint,7,opt,name=valid_before,json=validBefore" json:"valid_before,omitempty"`
+	DeviceId    string   `protobuf:"bytes,8,opt,name=device_id,json=deviceId" json:"device_id,omitempty"`
+	OnBehalfOf  string   `protobuf:"bytes,9,opt,name=on_behalf_of,json=onBehalfOf" json:"on_behalf_of,omitempty"`
+}
+
+func (m *IssuedCertRecord) Reset()                    { *m = IssuedCertRecord{} }
+func (m *IssuedCertRecord) String() string            { return proto.CompactTextString(m) }
+func (*IssuedCertRecord) ProtoMessage()               {}
+func (*IssuedCertRecord) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+
+func (m *IssuedCertRecord) GetSerial() uint64 {
+	if m != nil {
+		return m.Serial
+	}
+	return 0
+}
+
+func (m *IssuedCertRecord) GetEmail() string {
+	if m != nil {
+		return m.Email
+	}
+	return ""
+}
+
+func (m *IssuedCertRecord) GetKeyId() string {
+	if m != nil {
+		return m.KeyId
+	}
+	return ""
+}
+
+func (m *IssuedCertRecord) GetPrincipals() []string {
+	if m != nil {
+		return m.Principals
+	}
+	return nil
+}
+
+func (m *IssuedCertRecord) GetFingerprint() string {
+	if m != nil {
+		return m.Fingerprint
+	}
+	return ""
+}
+
+func (m *IssuedCertRecord) GetValidAfter() int64 {
+	if m != nil {
+		return m.ValidAfter
+	}
+	return 0
+}
+
+func (m *IssuedCertRecord) GetValidBefore() int64 {
+	if m != nil {
+		return m.ValidBefore
+	}
+	return 0
+}
+
+func (m *IssuedCertRecord) GetDeviceId() string {
+	if m != nil {
+		return m.DeviceId
+	}
+	return ""
+}
+
+func (m *IssuedCertRecord) GetOnBehalfOf() string {
+	if m != nil {
+		return m.OnBehalfOf
+	}
+	return ""
+}
+
+type LookupCertResponse struct {
+	Status ResponseCode        `protobuf:"varint,1,opt,name=status,enum=ResponseCode" json:"status,omitempty"`
+	Certs  []*IssuedCertRecord `protobuf:"bytes,2,rep,name=certs" json:"certs,omitempty"`
+}
+
+func (m *LookupCertResponse) Reset()                    { *m = LookupCertResponse{} }
+func (m *LookupCertResponse) String() string            { return proto.CompactTextString(m) }
+func (*LookupCertResponse) ProtoMessage()               {}
+func (*LookupCertResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+
+func (m *LookupCertResponse) GetStatus() ResponseCode {
+	if m != nil {
+		return m.Status
+	}
+	return ResponseCode_OK
+}
+
+func (m *LookupCertResponse) GetCerts() []*IssuedCertRecord {
+	if m != nil {
+		return m.Certs
+	}
+	return nil
+}
+
+type ListApprovalsRequest struct {
+	IdToken        string         `protobuf:"bytes,1,opt,name=id_token,json=idToken" json:"id_token,omitempty"`
+	CredentialType CredentialType `protobuf:"varint,2,opt,name=credential_type,json=credentialType,enum=CredentialType" json:"credential_type,omitempty"`
+}
+
+func (m *ListApprovalsRequest) Reset()                    { *m = ListApprovalsRequest{} }
+func (m *ListApprovalsRequest) String() string            { return proto.CompactTextString(m) }
+func (*ListApprovalsRequest) ProtoMessage()               {}
+func (*ListApprovalsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+
+func (m *ListApprovalsRequest) GetIdToken() string {
+	if m != nil {
+		return m.IdToken
+	}
+	return ""
+}
+
+func (m *ListApprovalsRequest) GetCredentialType() CredentialType {
+	if m != nil {
+		return m.CredentialType
+	}
+	return CredentialType_ID_TOKEN
+}
+
+type ApprovalRecord struct {
+	Id          string   `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	Email       string   `protobuf:"bytes,2,opt,name=email" json:"email,omitempty"`
+	Principals  []string `protobuf:"bytes,3,rep,name=principals" json:"principals,omitempty"`
+	Fingerprint string   `protobuf:"bytes,4,opt,name=fingerprint" json:"fingerprint,omitempty"`
+	RequestedAt int64    `protobuf:"varint,5,opt,name=requested_at,json=requestedAt" json:"requested_at,omitempty"`
+	OnBehalfOf  string   `protobuf:"bytes,6,opt,name=on_behalf_of,json=onBehalfOf" json:"on_behalf_of,omitempty"`
+}
+
+func (m *ApprovalRecord) Reset()                    { *m = ApprovalRecord{} }
+func (m *ApprovalRecord) String() string            { return proto.CompactTextString(m) }
+func (*ApprovalRecord) ProtoMessage()               {}
+func (*ApprovalRecord) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+
+func (m *ApprovalRecord) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *ApprovalRecord) GetEmail() string {
+	if m != nil {
+		return m.Email
+	}
+	return ""
+}
+
+func (m *ApprovalRecord) GetPrincipals() []string {
+	if m != nil {
+		return m.Principals
+	}
+	return nil
+}
+
+func (m *ApprovalRecord) GetFingerprint() string {
+	if m != nil {
+		return m.Fingerprint
+	}
+	return ""
+}
+
+func (m *ApprovalRecord) GetRequestedAt() int64 {
+	if m != nil {
+		return m.RequestedAt
+	}
+	return 0
+}
+
+func (m *ApprovalRecord) GetOnBehalfOf() string {
+	if m != nil {
+		return m.OnBehalfOf
+	}
+	return ""
+}
+
+type ListApprovalsResponse struct {
+	Status    ResponseCode      `protobuf:"varint,1,opt,name=status,enum=ResponseCode" json:"status,omitempty"`
+	Approvals []*ApprovalRecord `protobuf:"bytes,2,rep,name=approvals" json:"approvals,omitempty"`
+}
+
+func (m *ListApprovalsResponse) Reset()                    { *m = ListApprovalsResponse{} }
+func (m *ListApprovalsResponse) String() string            { return proto.CompactTextString(m) }
+func (*ListApprovalsResponse) ProtoMessage()               {}
+func (*ListApprovalsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+
+func (m *ListApprovalsResponse) GetStatus() ResponseCode {
+	if m != nil {
+		return m.Status
+	}
+	return ResponseCode_OK
+}
+
+func (m *ListApprovalsResponse) GetApprovals() []*ApprovalRecord {
+	if m != nil {
+		return m.Approvals
+	}
+	return nil
+}
+
+type DecideApprovalRequest struct {
+	IdToken        string         `protobuf:"bytes,1,opt,name=id_token,json=idToken" json:"id_token,omitempty"`
+	CredentialType CredentialType `protobuf:"varint,4,opt,name=credential_type,json=credentialType,enum=CredentialType" json:"credential_type,omitempty"`
+	ApprovalId     string         `protobuf:"bytes,2,opt,name=approval_id,json=approvalId" json:"approval_id,omitempty"`
+	Approve        bool           `protobuf:"varint,3,opt,name=approve" json:"approve,omitempty"`
+}
+
+func (m *DecideApprovalRequest) Reset()                    { *m = DecideApprovalRequest{} }
+func (m *DecideApprovalRequest) String() string            { return proto.CompactTextString(m) }
+func (*DecideApprovalRequest) ProtoMessage()               {}
+func (*DecideApprovalRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+
+func (m *DecideApprovalRequest) GetIdToken() string {
+	if m != nil {
+		return m.IdToken
+	}
+	return ""
+}
+
+func (m *DecideApprovalRequest) GetCredentialType() CredentialType {
+	if m != nil {
+		return m.CredentialType
+	}
+	return CredentialType_ID_TOKEN
+}
+
+func (m *DecideApprovalRequest) GetApprovalId() string {
+	if m != nil {
+		return m.ApprovalId
+	}
+	return ""
+}
+
+func (m *DecideApprovalRequest) GetApprove() bool {
+	if m != nil {
+		return m.Approve
+	}
+	return false
+}
+
+type DecideApprovalResponse struct {
+	Status ResponseCode `protobuf:"varint,1,opt,name=status,enum=ResponseCode" json:"status,omitempty"`
+}
+
+func (m *DecideApprovalResponse) Reset()                    { *m = DecideApprovalResponse{} }
+func (m *DecideApprovalResponse) String() string            { return proto.CompactTextString(m) }
+func (*DecideApprovalResponse) ProtoMessage()               {}
+func (*DecideApprovalResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+
+func (m *DecideApprovalResponse) GetStatus() ResponseCode {
+	if m != nil {
+		return m.Status
+	}
+	return ResponseCode_OK
+}
+
+type WatchRequest struct {
+	IdToken        string         `protobuf:"bytes,1,opt,name=id_token,json=idToken" json:"id_token,omitempty"`
+	CredentialType CredentialType `protobuf:"varint,2,opt,name=credential_type,json=credentialType,enum=CredentialType" json:"credential_type,omitempty"`
+}
+
+func (m *WatchRequest) Reset()                    { *m = WatchRequest{} }
+func (m *WatchRequest) String() string            { return proto.CompactTextString(m) }
+func (*WatchRequest) ProtoMessage()               {}
+func (*WatchRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+
+func (m *WatchRequest) GetIdToken() string {
+	if m != nil {
+		return m.IdToken
+	}
+	return ""
+}
+
+func (m *WatchRequest) GetCredentialType() CredentialType {
+	if m != nil {
+		return m.CredentialType
+	}
+	return CredentialType_ID_TOKEN
+}
+
+type WatchUpdate struct {
+	Status                     ResponseCode                `protobuf:"varint,1,opt,name=status,enum=ResponseCode" json:"status,omitempty"`
+	CertificateAuthorities     []string                    `protobuf:"bytes,2,rep,name=certificate_authorities,json=certificateAuthorities" json:"certificate_authorities,omitempty"`
+	ConfigBlocks               []*SSHConfigBlock           `protobuf:"bytes,3,rep,name=config_blocks,json=configBlocks" json:"config_blocks,omitempty"`
+	ConfigVariables            map[string]string           `protobuf:"bytes,4,rep,name=config_variables,json=configVariables" json:"config_variables,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	BastionPolicies            []*BastionPolicy            `protobuf:"bytes,5,rep,name=bastion_policies,json=bastionPolicies" json:"bastion_policies,omitempty"`
+	RevokedFingerprint         []string                    `protobuf:"bytes,6,rep,name=revoked_fingerprint,json=revokedFingerprint" json:"revoked_fingerprint,omitempty"`
+	Directives                 *ClientDirectives           `protobuf:"bytes,7,opt,name=directives" json:"directives,omitempty"`
+	ReplacedFingerprint        []string                    `protobuf:"bytes,8,rep,name=replaced_fingerprint,json=replacedFingerprint" json:"replaced_fingerprint,omitempty"`
+	HostCertificateAuthorities []*HostCertificateAuthority `protobuf:"bytes,9,rep,name=host_certificate_authorities,json=hostCertificateAuthorities" json:"host_certificate_authorities,omitempty"`
+	Realms                     []*RealmCertificate         `protobuf:"bytes,10,rep,name=realms" json:"realms,omitempty"`
+}
+
+func (m *WatchUpdate) Reset()                    { *m = WatchUpdate{} }
+func (m *WatchUpdate) String() string            { return proto.CompactTextString(m) }
+func (*WatchUpdate) ProtoMessage()               {}
+func (*WatchUpdate) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+
+func (m *WatchUpdate) GetStatus() ResponseCode {
+	if m != nil {
+		return m.Status
+	}
+	return ResponseCode_OK
+}
+
+func (m *WatchUpdate) GetCertificateAuthorities() []string {
+	if m != nil {
+		return m.CertificateAuthorities
+	}
+	return nil
+}
+
+func (m *WatchUpdate) GetConfigBlocks() []*SSHConfigBlock {
+	if m != nil {
+		return m.ConfigBlocks
+	}
+	return nil
+}
+
+func (m *WatchUpdate) GetConfigVariables() map[string]string {
+	if m != nil {
+		return m.ConfigVariables
+	}
+	return nil
+}
+
+func (m *WatchUpdate) GetBastionPolicies() []*BastionPolicy {
+	if m != nil {
+		return m.BastionPolicies
+	}
+	return nil
+}
+
+func (m *WatchUpdate) GetRevokedFingerprint() []string {
+	if m != nil {
+		return m.RevokedFingerprint
+	}
+	return nil
+}
+
+func (m *WatchUpdate) GetDirectives() *ClientDirectives {
+	if m != nil {
+		return m.Directives
+	}
+	return nil
+}
+
+func (m *WatchUpdate) GetReplacedFingerprint() []string {
+	if m != nil {
+		return m.ReplacedFingerprint
+	}
+	return nil
+}
+
+func (m *WatchUpdate) GetHostCertificateAuthorities() []*HostCertificateAuthority {
+	if m != nil {
+		return m.HostCertificateAuthorities
+	}
+	return nil
+}
+
+func (m *WatchUpdate) GetRealms() []*RealmCertificate {
+	if m != nil {
+		return m.Realms
+	}
+	return nil
+}
+
+type BreakGlassRequest struct {
+	PublicKey     string `protobuf:"bytes,1,opt,name=public_key,json=publicKey" json:"public_key,omitempty"`
+	CredentialKey string `protobuf:"bytes,2,opt,name=credential_key,json=credentialKey" json:"credential_key,omitempty"`
+	Justification string `protobuf:"bytes,3,opt,name=justification" json:"justification,omitempty"`
+	Timestamp     int64  `protobuf:"varint,4,opt,name=timestamp" json:"timestamp,omitempty"`
+	Signature     []byte `protobuf:"bytes,5,opt,name=signature" json:"signature,omitempty"`
+}
+
+func (m *BreakGlassRequest) Reset()                    { *m = BreakGlassRequest{} }
+func (m *BreakGlassRequest) String() string            { return proto.CompactTextString(m) }
+func (*BreakGlassRequest) ProtoMessage()               {}
+func (*BreakGlassRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+
+func (m *BreakGlassRequest) GetPublicKey() string {
+	if m != nil {
+		return m.PublicKey
+	}
+	return ""
+}
+
+func (m *BreakGlassRequest) GetCredentialKey() string {
+	if m != nil {
+		return m.CredentialKey
+	}
+	return ""
+}
+
+func (m *BreakGlassRequest) GetJustification() string {
+	if m != nil {
+		return m.Justification
+	}
+	return ""
+}
+
+func (m *BreakGlassRequest) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+func (m *BreakGlassRequest) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+type TelemetryReport struct {
+	Os      string `protobuf:"bytes,1,opt,name=os" json:"os,omitempty"`
+	Arch    string `protobuf:"bytes,2,opt,name=arch" json:"arch,omitempty"`
+	Command string `protobuf:"bytes,3,opt,name=command" json:"command,omitempty"`
+	Outcome string `protobuf:"bytes,4,opt,name=outcome" json:"outcome,omitempty"`
+}
+
+func (m *TelemetryReport) Reset()                    { *m = TelemetryReport{} }
+func (m *TelemetryReport) String() string            { return proto.CompactTextString(m) }
+func (*TelemetryReport) ProtoMessage()               {}
+func (*TelemetryReport) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
+
+func (m *TelemetryReport) GetOs() string {
+	if m != nil {
+		return m.Os
+	}
+	return ""
+}
+
+func (m *TelemetryReport) GetArch() string {
+	if m != nil {
+		return m.Arch
+	}
+	return ""
+}
+
+func (m *TelemetryReport) GetCommand() string {
+	if m != nil {
+		return m.Command
+	}
+	return ""
+}
+
+func (m *TelemetryReport) GetOutcome() string {
+	if m != nil {
+		return m.Outcome
+	}
+	return ""
+}
+
+type TelemetryResponse struct {
+	Status ResponseCode `protobuf:"varint,1,opt,name=status,enum=ResponseCode" json:"status,omitempty"`
+}
+
+func (m *TelemetryResponse) Reset()                    { *m = TelemetryResponse{} }
+func (m *TelemetryResponse) String() string            { return proto.CompactTextString(m) }
+func (*TelemetryResponse) ProtoMessage()               {}
+func (*TelemetryResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
+
+func (m *TelemetryResponse) GetStatus() ResponseCode {
+	if m != nil {
+		return m.Status
+	}
+	return ResponseCode_OK
+}
+
+type TelemetrySummaryRequest struct {
+	IdToken        string         `protobuf:"bytes,1,opt,name=id_token,json=idToken" json:"id_token,omitempty"`
+	CredentialType CredentialType `protobuf:"varint,2,opt,name=credential_type,json=credentialType,enum=CredentialType" json:"credential_type,omitempty"`
+}
+
+func (m *TelemetrySummaryRequest) Reset()                    { *m = TelemetrySummaryRequest{} }
+func (m *TelemetrySummaryRequest) String() string            { return proto.CompactTextString(m) }
+func (*TelemetrySummaryRequest) ProtoMessage()               {}
+func (*TelemetrySummaryRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
+
+func (m *TelemetrySummaryRequest) GetIdToken() string {
+	if m != nil {
+		return m.IdToken
+	}
+	return ""
+}
+
+func (m *TelemetrySummaryRequest) GetCredentialType() CredentialType {
+	if m != nil {
+		return m.CredentialType
+	}
+	return CredentialType_ID_TOKEN
+}
+
+type TelemetryCount struct {
+	ClientName    string `protobuf:"bytes,1,opt,name=client_name,json=clientName" json:"client_name,omitempty"`
+	ClientVersion string `protobuf:"bytes,2,opt,name=client_version,json=clientVersion" json:"client_version,omitempty"`
+	Os            string `protobuf:"bytes,3,opt,name=os" json:"os,omitempty"`
+	Arch          string `protobuf:"bytes,4,opt,name=arch" json:"arch,omitempty"`
+	Command       string `protobuf:"bytes,5,opt,name=command" json:"command,omitempty"`
+	Outcome       string `protobuf:"bytes,6,opt,name=outcome" json:"outcome,omitempty"`
+	Count         int64  `protobuf:"varint,7,opt,name=count" json:"count,omitempty"`
+	LastSeen      int64  `protobuf:"varint,8,opt,name=last_seen,json=lastSeen" json:"last_seen,omitempty"`
+}
+
+func (m *TelemetryCount) Reset()                    { *m = TelemetryCount{} }
+func (m *TelemetryCount) String() string            { return proto.CompactTextString(m) }
+func (*TelemetryCount) ProtoMessage()               {}
+func (*TelemetryCount) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{23} }
+
+func (m *TelemetryCount) GetClientName() string {
+	if m != nil {
+		return m.ClientName
+	}
+	return ""
+}
+
+func (m *TelemetryCount) GetClientVersion() string {
+	if m != nil {
+		return m.ClientVersion
+	}
+	return ""
+}
+
+func (m *TelemetryCount) GetOs() string {
+	if m != nil {
+		return m.Os
+	}
+	return ""
+}
+
+func (m *TelemetryCount) GetArch() string {
+	if m != nil {
+		return m.Arch
+	}
+	return ""
+}
+
+func (m *TelemetryCount) GetCommand() string {
+	if m != nil {
+		return m.Command
+	}
+	return ""
+}
+
+func (m *TelemetryCount) GetOutcome() string {
+	if m != nil {
+		return m.Outcome
+	}
+	return ""
+}
+
+func (m *TelemetryCount) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *TelemetryCount) GetLastSeen() int64 {
+	if m != nil {
+		return m.LastSeen
+	}
+	return 0
+}
+
+type TelemetrySummaryResponse struct {
+	Status ResponseCode      `protobuf:"varint,1,opt,name=status,enum=ResponseCode" json:"status,omitempty"`
+	Counts []*TelemetryCount `protobuf:"bytes,2,rep,name=counts" json:"counts,omitempty"`
+	Since  int64             `protobuf:"varint,3,opt,name=since" json:"since,omitempty"`
+}
+
+func (m *TelemetrySummaryResponse) Reset()                    { *m = TelemetrySummaryResponse{} }
+func (m *TelemetrySummaryResponse) String() string            { return proto.CompactTextString(m) }
+func (*TelemetrySummaryResponse) ProtoMessage()               {}
+func (*TelemetrySummaryResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{24} }
+
+func (m *TelemetrySummaryResponse) GetStatus() ResponseCode {
+	if m != nil {
+		return m.Status
+	}
+	return ResponseCode_OK
+}
+
+func (m *TelemetrySummaryResponse) GetCounts() []*TelemetryCount {
+	if m != nil {
+		return m.Counts
+	}
+	return nil
+}
+
+func (m *TelemetrySummaryResponse) GetSince() int64 {
+	if m != nil {
+		return m.Since
+	}
+	return 0
+}
+
+type PickupCodeRequest struct {
+	Code string `protobuf:"bytes,1,opt,name=code" json:"code,omitempty"`
+}
+
+func (m *PickupCodeRequest) Reset()                    { *m = PickupCodeRequest{} }
+func (m *PickupCodeRequest) String() string            { return proto.CompactTextString(m) }
+func (*PickupCodeRequest) ProtoMessage()               {}
+func (*PickupCodeRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{25} }
+
+func (m *PickupCodeRequest) GetCode() string {
+	if m != nil {
+		return m.Code
+	}
+	return ""
+}
+
+type PickupCodeResponse struct {
+	Status  ResponseCode `protobuf:"varint,1,opt,name=status,enum=ResponseCode" json:"status,omitempty"`
+	IdToken string       `protobuf:"bytes,2,opt,name=id_token,json=idToken" json:"id_token,omitempty"`
+}
+
+func (m *PickupCodeResponse) Reset()                    { *m = PickupCodeResponse{} }
+func (m *PickupCodeResponse) String() string            { return proto.CompactTextString(m) }
+func (*PickupCodeResponse) ProtoMessage()               {}
+func (*PickupCodeResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{26} }
+
+func (m *PickupCodeResponse) GetStatus() ResponseCode {
+	if m != nil {
+		return m.Status
+	}
+	return ResponseCode_OK
+}
+
+func (m *PickupCodeResponse) GetIdToken() string {
+	if m != nil {
+		return m.IdToken
+	}
+	return ""
+}
+
+type EnrollDeviceRequest struct {
+	IdToken        string         `protobuf:"bytes,1,opt,name=id_token,json=idToken" json:"id_token,omitempty"`
+	CredentialType CredentialType `protobuf:"varint,2,opt,name=credential_type,json=credentialType,enum=CredentialType" json:"credential_type,omitempty"`
+	DeviceKey      string         `protobuf:"bytes,3,opt,name=device_key,json=deviceKey" json:"device_key,omitempty"`
+	Hostname       string         `protobuf:"bytes,4,opt,name=hostname" json:"hostname,omitempty"`
+	Platform       string         `protobuf:"bytes,5,opt,name=platform" json:"platform,omitempty"`
+	HardwareId     string         `protobuf:"bytes,6,opt,name=hardware_id,json=hardwareId" json:"hardware_id,omitempty"`
+	SerialNumber   string         `protobuf:"bytes,7,opt,name=serial_number,json=serialNumber" json:"serial_number,omitempty"`
+}
+
+func (m *EnrollDeviceRequest) Reset()                    { *m = EnrollDeviceRequest{} }
+func (m *EnrollDeviceRequest) String() string            { return proto.CompactTextString(m) }
+func (*EnrollDeviceRequest) ProtoMessage()               {}
+func (*EnrollDeviceRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{27} }
+
+func (m *EnrollDeviceRequest) GetIdToken() string {
+	if m != nil {
+		return m.IdToken
+	}
+	return ""
+}
+
+func (m *EnrollDeviceRequest) GetCredentialType() CredentialType {
+	if m != nil {
+		return m.CredentialType
+	}
+	return CredentialType_ID_TOKEN
+}
+
+func (m *EnrollDeviceRequest) GetDeviceKey() string {
+	if m != nil {
+		return m.DeviceKey
+	}
+	return ""
+}
+
+func (m *EnrollDeviceRequest) GetHostname() string {
+	if m != nil {
+		return m.Hostname
+	}
+	return ""
+}
+
+func (m *EnrollDeviceRequest) GetPlatform() string {
+	if m != nil {
+		return m.Platform
+	}
+	return ""
+}
+
+func (m *EnrollDeviceRequest) GetHardwareId() string {
+	if m != nil {
+		return m.HardwareId
+	}
+	return ""
+}
+
+func (m *EnrollDeviceRequest) GetSerialNumber() string {
+	if m != nil {
+		return m.SerialNumber
+	}
+	return ""
+}
+
+type EnrollDeviceResponse struct {
+	Status   ResponseCode `protobuf:"varint,1,opt,name=status,enum=ResponseCode" json:"status,omitempty"`
+	DeviceId string       `protobuf:"bytes,2,opt,name=device_id,json=deviceId" json:"device_id,omitempty"`
+}
+
+func (m *EnrollDeviceResponse) Reset()                    { *m = EnrollDeviceResponse{} }
+func (m *EnrollDeviceResponse) String() string            { return proto.CompactTextString(m) }
+func (*EnrollDeviceResponse) ProtoMessage()               {}
+func (*EnrollDeviceResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{28} }
+
+func (m *EnrollDeviceResponse) GetStatus() ResponseCode {
+	if m != nil {
+		return m.Status
+	}
+	return ResponseCode_OK
+}
+
+func (m *EnrollDeviceResponse) GetDeviceId() string {
+	if m != nil {
+		return m.DeviceId
+	}
+	return ""
+}
+
+type RevokeDeviceRequest struct {
+	IdToken        string         `protobuf:"bytes,1,opt,name=id_token,json=idToken" json:"id_token,omitempty"`
+	CredentialType CredentialType `protobuf:"varint,2,opt,name=credential_type,json=credentialType,enum=CredentialType" json:"credential_type,omitempty"`
+	DeviceId       string         `protobuf:"bytes,3,opt,name=device_id,json=deviceId" json:"device_id,omitempty"`
+	Reason         string         `protobuf:"bytes,4,opt,name=reason" json:"reason,omitempty"`
+}
+
+func (m *RevokeDeviceRequest) Reset()                    { *m = RevokeDeviceRequest{} }
+func (m *RevokeDeviceRequest) String() string            { return proto.CompactTextString(m) }
+func (*RevokeDeviceRequest) ProtoMessage()               {}
+func (*RevokeDeviceRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{29} }
+
+func (m *RevokeDeviceRequest) GetIdToken() string {
+	if m != nil {
+		return m.IdToken
+	}
+	return ""
+}
+
+func (m *RevokeDeviceRequest) GetCredentialType() CredentialType {
+	if m != nil {
+		return m.CredentialType
+	}
+	return CredentialType_ID_TOKEN
+}
+
+func (m *RevokeDeviceRequest) GetDeviceId() string {
+	if m != nil {
+		return m.DeviceId
+	}
+	return ""
+}
+
+func (m *RevokeDeviceRequest) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+type RevokeDeviceResponse struct {
+	Status             ResponseCode `protobuf:"varint,1,opt,name=status,enum=ResponseCode" json:"status,omitempty"`
+	RevokedFingerprint []string     `protobuf:"bytes,2,rep,name=revoked_fingerprint,json=revokedFingerprint" json:"revoked_fingerprint,omitempty"`
+}
+
+func (m *RevokeDeviceResponse) Reset()                    { *m = RevokeDeviceResponse{} }
+func (m *RevokeDeviceResponse) String() string            { return proto.CompactTextString(m) }
+func (*RevokeDeviceResponse) ProtoMessage()               {}
+func (*RevokeDeviceResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{30} }
+
+func (m *RevokeDeviceResponse) GetStatus() ResponseCode {
+	if m != nil {
+		return m.Status
+	}
+	return ResponseCode_OK
+}
+
+func (m *RevokeDeviceResponse) GetRevokedFingerprint() []string {
+	if m != nil {
+		return m.RevokedFingerprint
+	}
+	return nil
+}
+
+type BatchCertsRequest struct {
+	IdToken        string              `protobuf:"bytes,1,opt,name=id_token,json=idToken" json:"id_token,omitempty"`
+	CredentialType CredentialType      `protobuf:"varint,2,opt,name=credential_type,json=credentialType,enum=CredentialType" json:"credential_type,omitempty"`
+	Certs          []*BatchCertRequest `protobuf:"bytes,3,rep,name=certs" json:"certs,omitempty"`
+	Reason         string              `protobuf:"bytes,4,opt,name=reason" json:"reason,omitempty"`
+}
+
+func (m *BatchCertsRequest) Reset()                    { *m = BatchCertsRequest{} }
+func (m *BatchCertsRequest) String() string            { return proto.CompactTextString(m) }
+func (*BatchCertsRequest) ProtoMessage()               {}
+func (*BatchCertsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{31} }
+
+func (m *BatchCertsRequest) GetIdToken() string {
+	if m != nil {
+		return m.IdToken
+	}
+	return ""
+}
+
+func (m *BatchCertsRequest) GetCredentialType() CredentialType {
+	if m != nil {
+		return m.CredentialType
+	}
+	return CredentialType_ID_TOKEN
+}
+
+func (m *BatchCertsRequest) GetCerts() []*BatchCertRequest {
+	if m != nil {
+		return m.Certs
+	}
+	return nil
+}
+
+func (m *BatchCertsRequest) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+type BatchCertRequest struct {
+	Label      string   `protobuf:"bytes,1,opt,name=label" json:"label,omitempty"`
+	PublicKey  string   `protobuf:"bytes,2,opt,name=public_key,json=publicKey" json:"public_key,omitempty"`
+	Principals []string `protobuf:"bytes,3,rep,name=principals" json:"principals,omitempty"`
+}
+
+func (m *BatchCertRequest) Reset()                    { *m = BatchCertRequest{} }
+func (m *BatchCertRequest) String() string            { return proto.CompactTextString(m) }
+func (*BatchCertRequest) ProtoMessage()               {}
+func (*BatchCertRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{32} }
+
+func (m *BatchCertRequest) GetLabel() string {
+	if m != nil {
+		return m.Label
+	}
+	return ""
+}
+
+func (m *BatchCertRequest) GetPublicKey() string {
+	if m != nil {
+		return m.PublicKey
+	}
+	return ""
+}
+
+func (m *BatchCertRequest) GetPrincipals() []string {
+	if m != nil {
+		return m.Principals
+	}
+	return nil
+}
+
+type BatchCertsResponse struct {
+	Status                     ResponseCode                `protobuf:"varint,1,opt,name=status,enum=ResponseCode" json:"status,omitempty"`
+	Certs                      []*BatchCert                `protobuf:"bytes,2,rep,name=certs" json:"certs,omitempty"`
+	CertificateAuthorities     []string                    `protobuf:"bytes,3,rep,name=certificate_authorities,json=certificateAuthorities" json:"certificate_authorities,omitempty"`
+	HostCertificateAuthorities []*HostCertificateAuthority `protobuf:"bytes,4,rep,name=host_certificate_authorities,json=hostCertificateAuthorities" json:"host_certificate_authorities,omitempty"`
+}
+
+func (m *BatchCertsResponse) Reset()                    { *m = BatchCertsResponse{} }
+func (m *BatchCertsResponse) String() string            { return proto.CompactTextString(m) }
+func (*BatchCertsResponse) ProtoMessage()               {}
+func (*BatchCertsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{33} }
+
+func (m *BatchCertsResponse) GetStatus() ResponseCode {
+	if m != nil {
+		return m.Status
+	}
+	return ResponseCode_OK
+}
+
+func (m *BatchCertsResponse) GetCerts() []*BatchCert {
+	if m != nil {
+		return m.Certs
+	}
+	return nil
+}
+
+func (m *BatchCertsResponse) GetCertificateAuthorities() []string {
+	if m != nil {
+		return m.CertificateAuthorities
+	}
+	return nil
+}
+
+func (m *BatchCertsResponse) GetHostCertificateAuthorities() []*HostCertificateAuthority {
+	if m != nil {
+		return m.HostCertificateAuthorities
+	}
+	return nil
+}
+
+type BatchCert struct {
+	Label       string `protobuf:"bytes,1,opt,name=label" json:"label,omitempty"`
+	Certificate string `protobuf:"bytes,2,opt,name=certificate" json:"certificate,omitempty"`
+	Serial      uint64 `protobuf:"varint,3,opt,name=serial" json:"serial,omitempty"`
+	Fingerprint string `protobuf:"bytes,4,opt,name=fingerprint" json:"fingerprint,omitempty"`
+}
+
+func (m *BatchCert) Reset()                    { *m = BatchCert{} }
+func (m *BatchCert) String() string            { return proto.CompactTextString(m) }
+func (*BatchCert) ProtoMessage()               {}
+func (*BatchCert) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{34} }
+
+func (m *BatchCert) GetLabel() string {
+	if m != nil {
+		return m.Label
+	}
+	return ""
+}
+
+func (m *BatchCert) GetCertificate() string {
+	if m != nil {
+		return m.Certificate
+	}
+	return ""
+}
+
+func (m *BatchCert) GetSerial() uint64 {
+	if m != nil {
+		return m.Serial
+	}
+	return 0
+}
+
+func (m *BatchCert) GetFingerprint() string {
+	if m != nil {
+		return m.Fingerprint
+	}
+	return ""
+}
+
+type ExplainPolicyRequest struct {
+	IdToken        string         `protobuf:"bytes,1,opt,name=id_token,json=idToken" json:"id_token,omitempty"`
+	CredentialType CredentialType `protobuf:"varint,2,opt,name=credential_type,json=credentialType,enum=CredentialType" json:"credential_type,omitempty"`
+	OnBehalfOf     string         `protobuf:"bytes,3,opt,name=on_behalf_of,json=onBehalfOf" json:"on_behalf_of,omitempty"`
+	Role           string         `protobuf:"bytes,4,opt,name=role" json:"role,omitempty"`
+}
+
+func (m *ExplainPolicyRequest) Reset()                    { *m = ExplainPolicyRequest{} }
+func (m *ExplainPolicyRequest) String() string            { return proto.CompactTextString(m) }
+func (*ExplainPolicyRequest) ProtoMessage()               {}
+func (*ExplainPolicyRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{35} }
+
+func (m *ExplainPolicyRequest) GetIdToken() string {
+	if m != nil {
+		return m.IdToken
+	}
+	return ""
+}
+
+func (m *ExplainPolicyRequest) GetCredentialType() CredentialType {
+	if m != nil {
+		return m.CredentialType
+	}
+	return CredentialType_ID_TOKEN
+}
+
+func (m *ExplainPolicyRequest) GetOnBehalfOf() string {
+	if m != nil {
+		return m.OnBehalfOf
+	}
+	return ""
+}
+
+func (m *ExplainPolicyRequest) GetRole() string {
+	if m != nil {
+		return m.Role
+	}
+	return ""
+}
+
+type ExplainPolicyResponse struct {
+	Status                ResponseCode      `protobuf:"varint,1,opt,name=status,enum=ResponseCode" json:"status,omitempty"`
+	Outcome               ResponseCode      `protobuf:"varint,2,opt,name=outcome,enum=ResponseCode" json:"outcome,omitempty"`
+	Email                 string            `protobuf:"bytes,3,opt,name=email" json:"email,omitempty"`
+	Groups                []string          `protobuf:"bytes,4,rep,name=groups" json:"groups,omitempty"`
+	Principals            []string          `protobuf:"bytes,5,rep,name=principals" json:"principals,omitempty"`
+	ValiditySeconds       int64             `protobuf:"varint,6,opt,name=validity_seconds,json=validitySeconds" json:"validity_seconds,omitempty"`
+	CriticalOptions       map[string]string `protobuf:"bytes,7,rep,name=critical_options,json=criticalOptions" json:"critical_options,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Extensions            map[string]string `protobuf:"bytes,8,rep,name=extensions" json:"extensions,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Rules                 []string          `protobuf:"bytes,9,rep,name=rules" json:"rules,omitempty"`
+	ValidAfterSkewSeconds int64             `protobuf:"varint,10,opt,name=valid_after_skew_seconds,json=validAfterSkewSeconds" json:"valid_after_skew_seconds,omitempty"`
+}
+
+func (m *ExplainPolicyResponse) Reset()                    { *m = ExplainPolicyResponse{} }
+func (m *ExplainPolicyResponse) String() string            { return proto.CompactTextString(m) }
+func (*ExplainPolicyResponse) ProtoMessage()               {}
+func (*ExplainPolicyResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{36} }
+
+func (m *ExplainPolicyResponse) GetStatus() ResponseCode {
+	if m != nil {
+		return m.Status
+	}
+	return ResponseCode_OK
+}
+
+func (m *ExplainPolicyResponse) GetOutcome() ResponseCode {
+	if m != nil {
+		return m.Outcome
+	}
+	return ResponseCode_OK
+}
+
+func (m *ExplainPolicyResponse) GetEmail() string {
+	if m != nil {
+		return m.Email
+	}
+	return ""
+}
+
+func (m *ExplainPolicyResponse) GetGroups() []string {
+	if m != nil {
+		return m.Groups
+	}
+	return nil
+}
+
+func (m *ExplainPolicyResponse) GetPrincipals() []string {
+	if m != nil {
+		return m.Principals
+	}
+	return nil
+}
+
+func (m *ExplainPolicyResponse) GetValiditySeconds() int64 {
+	if m != nil {
+		return m.ValiditySeconds
+	}
+	return 0
+}
+
+func (m *ExplainPolicyResponse) GetCriticalOptions() map[string]string {
+	if m != nil {
+		return m.CriticalOptions
+	}
+	return nil
+}
+
+func (m *ExplainPolicyResponse) GetExtensions() map[string]string {
+	if m != nil {
+		return m.Extensions
+	}
+	return nil
+}
+
+func (m *ExplainPolicyResponse) GetRules() []string {
+	if m != nil {
+		return m.Rules
+	}
+	return nil
+}
+
+func (m *ExplainPolicyResponse) GetValidAfterSkewSeconds() int64 {
+	if m != nil {
+		return m.ValidAfterSkewSeconds
+	}
+	return 0
+}
+
+type ServerConfig struct {
+	CaKeyPath                      string                                     `protobuf:"bytes,1,opt,name=ca_key_path,json=caKeyPath" json:"ca_key_path,omitempty"`
+	GenerateCertDurationSeconds    int32                                      `protobuf:"varint,2,opt,name=generate_cert_duration_seconds,json=generateCertDurationSeconds" json:"generate_cert_duration_seconds,omitempty"`
+	ClientConfigScope              string                                     `protobuf:"bytes,3,opt,name=client_config_scope,json=clientConfigScope" json:"client_config_scope,omitempty"`
+	AllowedUsers                   map[string]*ServerConfig_UserConfig        `protobuf:"bytes,4,rep,name=allowed_users,json=allowedUsers" json:"allowed_users,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	ListenPort                     int32                                      `protobuf:"varint,5,opt,name=listen_port,json=listenPort" json:"listen_port,omitempty"`
+	AllowedDomainForIdToken        string                                     `protobuf:"bytes,6,opt,name=allowed_domain_for_id_token,json=allowedDomainForIdToken" json:"allowed_domain_for_id_token,omitempty"`
+	AllowedClientIdForIdToken      string                                     `protobuf:"bytes,7,opt,name=allowed_client_id_for_id_token,json=allowedClientIdForIdToken" json:"allowed_client_id_for_id_token,omitempty"`
+	AdditionalClientIdForIdToken   []string                                   `protobuf:"bytes,48,rep,name=additional_client_id_for_id_token,json=additionalClientIdForIdToken" json:"additional_client_id_for_id_token,omitempty"`
+	ServerCertPath                 string                                     `protobuf:"bytes,8,opt,name=server_cert_path,json=serverCertPath" json:"server_cert_path,omitempty"`
+	ServerKeyPath                  string                                     `protobuf:"bytes,9,opt,name=server_key_path,json=serverKeyPath" json:"server_key_path,omitempty"`
+	AdditionalSshConfigurationLine []string                                   `protobuf:"bytes,10,rep,name=additional_ssh_configuration_line,json=additionalSshConfigurationLine" json:"additional_ssh_configuration_line,omitempty"`
+	CaComment                      string                                     `protobuf:"bytes,11,opt,name=ca_comment,json=caComment" json:"ca_comment,omitempty"`
+	HttpListenPort                 int32                                      `protobuf:"varint,12,opt,name=http_listen_port,json=httpListenPort" json:"http_listen_port,omitempty"`
+	AllowedHosts                   []string                                   `protobuf:"bytes,13,rep,name=allowed_hosts,json=allowedHosts" json:"allowed_hosts,omitempty"`
+	CaddyFilePath                  string                                     `protobuf:"bytes,14,opt,name=caddy_file_path,json=caddyFilePath" json:"caddy_file_path,omitempty"`
+	StoreDriver                    string                                     `protobuf:"bytes,15,opt,name=store_driver,json=storeDriver" json:"store_driver,omitempty"`
+	StoreDsn                       string                                     `protobuf:"bytes,16,opt,name=store_dsn,json=storeDsn" json:"store_dsn,omitempty"`
+	MaxCertsPerUserPerDay          int32                                      `protobuf:"varint,17,opt,name=max_certs_per_user_per_day,json=maxCertsPerUserPerDay" json:"max_certs_per_user_per_day,omitempty"`
+	AdminUsers                     []string                                   `protobuf:"bytes,18,rep,name=admin_users,json=adminUsers" json:"admin_users,omitempty"`
+	DnsRecordName                  string                                     `protobuf:"bytes,19,opt,name=dns_record_name,json=dnsRecordName" json:"dns_record_name,omitempty"`
+	DnsZone                        string                                     `protobuf:"bytes,20,opt,name=dns_zone,json=dnsZone" json:"dns_zone,omitempty"`
+	DnsUpdateServer                string                                     `protobuf:"bytes,21,opt,name=dns_update_server,json=dnsUpdateServer" json:"dns_update_server,omitempty"`
+	DnsTsigKeyName                 string                                     `protobuf:"bytes,22,opt,name=dns_tsig_key_name,json=dnsTsigKeyName" json:"dns_tsig_key_name,omitempty"`
+	DnsTsigSecret                  string                                     `protobuf:"bytes,23,opt,name=dns_tsig_secret,json=dnsTsigSecret" json:"dns_tsig_secret,omitempty"`
+	SshConfigBlock                 []*SSHConfigBlock                          `protobuf:"bytes,24,rep,name=ssh_config_block,json=sshConfigBlock" json:"ssh_config_block,omitempty"`
+	ConfigVariables                map[string]string                          `protobuf:"bytes,25,rep,name=config_variables,json=configVariables" json:"config_variables,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	BastionPolicy                  []*BastionPolicy                           `protobuf:"bytes,26,rep,name=bastion_policy,json=bastionPolicy" json:"bastion_policy,omitempty"`
+	MaxAuthAgeSeconds              int32                                      `protobuf:"varint,27,opt,name=max_auth_age_seconds,json=maxAuthAgeSeconds" json:"max_auth_age_seconds,omitempty"`
+	PrivilegedPrincipal            []string                                   `protobuf:"bytes,28,rep,name=privileged_principal,json=privilegedPrincipal" json:"privileged_principal,omitempty"`
+	Approvers                      []string                                   `protobuf:"bytes,29,rep,name=approvers" json:"approvers,omitempty"`
+	ApprovalWebhookUrl             string                                     `protobuf:"bytes,30,opt,name=approval_webhook_url,json=approvalWebhookUrl" json:"approval_webhook_url,omitempty"`
+	ApprovalTimeoutSeconds         int32                                      `protobuf:"varint,31,opt,name=approval_timeout_seconds,json=approvalTimeoutSeconds" json:"approval_timeout_seconds,omitempty"`
+	BreakGlassUser                 []*ServerConfig_BreakGlassUser             `protobuf:"bytes,32,rep,name=break_glass_user,json=breakGlassUser" json:"break_glass_user,omitempty"`
+	BreakGlassLogPath              string                                     `protobuf:"bytes,33,opt,name=break_glass_log_path,json=breakGlassLogPath" json:"break_glass_log_path,omitempty"`
+	BreakGlassCertDurationSeconds  int32                                      `protobuf:"varint,34,opt,name=break_glass_cert_duration_seconds,json=breakGlassCertDurationSeconds" json:"break_glass_cert_duration_seconds,omitempty"`
+	CertExtensions                 map[string]string                          `protobuf:"bytes,35,rep,name=cert_extensions,json=certExtensions" json:"cert_extensions,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	ReasonExtension                string                                     `protobuf:"bytes,36,opt,name=reason_extension,json=reasonExtension" json:"reason_extension,omitempty"`
+	RequireReason                  bool                                       `protobuf:"varint,37,opt,name=require_reason,json=requireReason" json:"require_reason,omitempty"`
+	SourceAddress                  string                                     `protobuf:"bytes,38,opt,name=source_address,json=sourceAddress" json:"source_address,omitempty"`
+	Realm                          []*ServerConfig_Realm                      `protobuf:"bytes,39,rep,name=realm" json:"realm,omitempty"`
+	WatchPollIntervalSeconds       int32                                      `protobuf:"varint,40,opt,name=watch_poll_interval_seconds,json=watchPollIntervalSeconds" json:"watch_poll_interval_seconds,omitempty"`
+	AdditionalHostCaKey            []string                                   `protobuf:"bytes,41,rep,name=additional_host_ca_key,json=additionalHostCaKey" json:"additional_host_ca_key,omitempty"`
+	MinClientVersion               string                                     `protobuf:"bytes,42,opt,name=min_client_version,json=minClientVersion" json:"min_client_version,omitempty"`
+	MinClientVersionByName         map[string]string                          `protobuf:"bytes,43,rep,name=min_client_version_by_name,json=minClientVersionByName" json:"min_client_version_by_name,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	UpgradeUrl                     string                                     `protobuf:"bytes,44,opt,name=upgrade_url,json=upgradeUrl" json:"upgrade_url,omitempty"`
+	AcceptTelemetry                bool                                       `protobuf:"varint,45,opt,name=accept_telemetry,json=acceptTelemetry" json:"accept_telemetry,omitempty"`
+	IdentityClaim                  string                                     `protobuf:"bytes,46,opt,name=identity_claim,json=identityClaim" json:"identity_claim,omitempty"`
+	GroupsClaim                    string                                     `protobuf:"bytes,47,opt,name=groups_claim,json=groupsClaim" json:"groups_claim,omitempty"`
+	SamlBridge                     *ServerConfig_SAMLBridge                   `protobuf:"bytes,49,opt,name=saml_bridge,json=samlBridge" json:"saml_bridge,omitempty"`
+	Kerberos                       *ServerConfig_Kerberos                     `protobuf:"bytes,50,opt,name=kerberos" json:"kerberos,omitempty"`
+	ConfigVersion                  uint32                                     `protobuf:"varint,51,opt,name=config_version,json=configVersion" json:"config_version,omitempty"`
+	Bootstrap                      *ServerConfig_Bootstrap                    `protobuf:"bytes,52,opt,name=bootstrap" json:"bootstrap,omitempty"`
+	Kubernetes                     []*ServerConfig_Kubernetes                 `protobuf:"bytes,53,rep,name=kubernetes" json:"kubernetes,omitempty"`
+	ClientDirectives               *ClientDirectives                          `protobuf:"bytes,54,opt,name=client_directives,json=clientDirectives" json:"client_directives,omitempty"`
+	AgentForwarding                *ServerConfig_AgentForwarding              `protobuf:"bytes,55,opt,name=agent_forwarding,json=agentForwarding" json:"agent_forwarding,omitempty"`
+	CaSignatureAlgorithm           string                                     `protobuf:"bytes,56,opt,name=ca_signature_algorithm,json=caSignatureAlgorithm" json:"ca_signature_algorithm,omitempty"`
+	ParallelCaKeyPath              []string                                   `protobuf:"bytes,57,rep,name=parallel_ca_key_path,json=parallelCaKeyPath" json:"parallel_ca_key_path,omitempty"`
+	KeyIdTemplate                  string                                     `protobuf:"bytes,58,opt,name=key_id_template,json=keyIdTemplate" json:"key_id_template,omitempty"`
+	ValidAfterSkewSeconds          uint32                                     `protobuf:"varint,59,opt,name=valid_after_skew_seconds,json=validAfterSkewSeconds" json:"valid_after_skew_seconds,omitempty"`
+	ClockCheck                     *ServerConfig_ClockCheck                   `protobuf:"bytes,60,opt,name=clock_check,json=clockCheck" json:"clock_check,omitempty"`
+	MaxUnexpiredCertsPerUser       uint32                                     `protobuf:"varint,61,opt,name=max_unexpired_certs_per_user,json=maxUnexpiredCertsPerUser" json:"max_unexpired_certs_per_user,omitempty"`
+	RevokeOldestUnexpiredCert      bool                                       `protobuf:"varint,62,opt,name=revoke_oldest_unexpired_cert,json=revokeOldestUnexpiredCert" json:"revoke_oldest_unexpired_cert,omitempty"`
+	RequireDevice                  bool                                       `protobuf:"varint,63,opt,name=require_device,json=requireDevice" json:"require_device,omitempty"`
+	DeviceExtension                string                                     `protobuf:"bytes,64,opt,name=device_extension,json=deviceExtension" json:"device_extension,omitempty"`
+	Mdm                            *ServerConfig_MDM                          `protobuf:"bytes,65,opt,name=mdm" json:"mdm,omitempty"`
+	Slack                          *ServerConfig_Slack                        `protobuf:"bytes,66,opt,name=slack" json:"slack,omitempty"`
+	StatusPage                     bool                                       `protobuf:"varint,67,opt,name=status_page,json=statusPage" json:"status_page,omitempty"`
+	AutomationAccount              map[string]*ServerConfig_AutomationAccount `protobuf:"bytes,68,rep,name=automation_account,json=automationAccount" json:"automation_account,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Impersonators                  []string                                   `protobuf:"bytes,69,rep,name=impersonators" json:"impersonators,omitempty"`
+	OnBehalfOfExtension            string                                     `protobuf:"bytes,70,opt,name=on_behalf_of_extension,json=onBehalfOfExtension" json:"on_behalf_of_extension,omitempty"`
+	JitRole                        map[string]*ServerConfig_JITRole           `protobuf:"bytes,71,rep,name=jit_role,json=jitRole" json:"jit_role,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	TicketWebhook                  *ServerConfig_TicketWebhook                `protobuf:"bytes,72,opt,name=ticket_webhook,json=ticketWebhook" json:"ticket_webhook,omitempty"`
+	NetworkPolicy                  *ServerConfig_NetworkPolicy                `protobuf:"bytes,73,opt,name=network_policy,json=networkPolicy" json:"network_policy,omitempty"`
+	GeoipPath                      string                                     `protobuf:"bytes,74,opt,name=geoip_path,json=geoipPath" json:"geoip_path,omitempty"`
+	NetworkPolicyExempt            []string                                   `protobuf:"bytes,75,rep,name=network_policy_exempt,json=networkPolicyExempt" json:"network_policy_exempt,omitempty"`
+	WorkingHours                   []*ServerConfig_WorkingHours               `protobuf:"bytes,76,rep,name=working_hours,json=workingHours" json:"working_hours,omitempty"`
+	Listener                       []*ServerConfig_Listener                   `protobuf:"bytes,77,rep,name=listener" json:"listener,omitempty"`
+	TrustedProxy                   []string                                   `protobuf:"bytes,78,rep,name=trusted_proxy,json=trustedProxy" json:"trusted_proxy,omitempty"`
+	ProxyProtocol                  bool                                       `protobuf:"varint,79,opt,name=proxy_protocol,json=proxyProtocol" json:"proxy_protocol,omitempty"`
+	GrpcReflection                 bool                                       `protobuf:"varint,80,opt,name=grpc_reflection,json=grpcReflection" json:"grpc_reflection,omitempty"`
+	MaxMessageBytes                uint32                                     `protobuf:"varint,81,opt,name=max_message_bytes,json=maxMessageBytes" json:"max_message_bytes,omitempty"`
+	CompressResponses              bool                                       `protobuf:"varint,82,opt,name=compress_responses,json=compressResponses" json:"compress_responses,omitempty"`
+	CaHostPattern                  []string                                   `protobuf:"bytes,83,rep,name=ca_host_pattern,json=caHostPattern" json:"ca_host_pattern,omitempty"`
+	AdditionalHostCa               []*HostCertificateAuthority                `protobuf:"bytes,84,rep,name=additional_host_ca,json=additionalHostCa" json:"additional_host_ca,omitempty"`
+}
+
+func (m *ServerConfig) Reset()                    { *m = ServerConfig{} }
+func (m *ServerConfig) String() string            { return proto.CompactTextString(m) }
+func (*ServerConfig) ProtoMessage()               {}
+func (*ServerConfig) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{37} }
+
+func (m *ServerConfig) GetCaKeyPath() string {
+	if m != nil {
+		return m.CaKeyPath
+	}
+	return ""
+}
+
+func (m *ServerConfig) GetGenerateCertDurationSeconds() int32 {
+	if m != nil {
+		return m.GenerateCertDurationSeconds
+	}
+	return 0
+}
+
+func (m *ServerConfig) GetClientConfigScope() string {
+	if m != nil {
+		return m.ClientConfigScope
+	}
+	return ""
+}
+
+func (m *ServerConfig) GetAllowedUsers() map[string]*ServerConfig_UserConfig {
+	if m != nil {
+		return m.AllowedUsers
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetListenPort() int32 {
+	if m != nil {
+		return m.ListenPort
+	}
+	return 0
+}
+
+func (m *ServerConfig) GetAllowedDomainForIdToken() string {
+	if m != nil {
+		return m.AllowedDomainForIdToken
+	}
+	return ""
+}
+
+func (m *ServerConfig) GetAllowedClientIdForIdToken() string {
+	if m != nil {
+		return m.AllowedClientIdForIdToken
+	}
+	return ""
+}
+
+func (m *ServerConfig) GetAdditionalClientIdForIdToken() []string {
+	if m != nil {
+		return m.AdditionalClientIdForIdToken
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetServerCertPath() string {
+	if m != nil {
+		return m.ServerCertPath
+	}
+	return ""
+}
+
+func (m *ServerConfig) GetServerKeyPath() string {
+	if m != nil {
+		return m.ServerKeyPath
+	}
+	return ""
+}
+
+func (m *ServerConfig) GetAdditionalSshConfigurationLine() []string {
+	if m != nil {
+		return m.AdditionalSshConfigurationLine
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetCaComment() string {
+	if m != nil {
+		return m.CaComment
+	}
+	return ""
+}
+
+func (m *ServerConfig) GetHttpListenPort() int32 {
+	if m != nil {
+		return m.HttpListenPort
+	}
+	return 0
+}
+
+func (m *ServerConfig) GetAllowedHosts() []string {
+	if m != nil {
+		return m.AllowedHosts
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetCaddyFilePath() string {
+	if m != nil {
+		return m.CaddyFilePath
+	}
+	return ""
+}
+
+func (m *ServerConfig) GetStoreDriver() string {
+	if m != nil {
+		return m.StoreDriver
+	}
+	return ""
+}
+
+func (m *ServerConfig) GetStoreDsn() string {
+	if m != nil {
+		return m.StoreDsn
+	}
+	return ""
+}
+
+func (m *ServerConfig) GetMaxCertsPerUserPerDay() int32 {
+	if m != nil {
+		return m.MaxCertsPerUserPerDay
+	}
+	return 0
+}
+
+func (m *ServerConfig) GetAdminUsers() []string {
+	if m != nil {
+		return m.AdminUsers
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetDnsRecordName() string {
+	if m != nil {
+		return m.DnsRecordName
+	}
+	return ""
+}
+
+func (m *ServerConfig) GetDnsZone() string {
+	if m != nil {
+		return m.DnsZone
+	}
+	return ""
+}
+
+func (m *ServerConfig) GetDnsUpdateServer() string {
+	if m != nil {
+		return m.DnsUpdateServer
+	}
+	return ""
+}
+
+func (m *ServerConfig) GetDnsTsigKeyName() string {
+	if m != nil {
+		return m.DnsTsigKeyName
+	}
+	return ""
+}
+
+func (m *ServerConfig) GetDnsTsigSecret() string {
+	if m != nil {
+		return m.DnsTsigSecret
+	}
+	return ""
+}
+
+func (m *ServerConfig) GetSshConfigBlock() []*SSHConfigBlock {
+	if m != nil {
+		return m.SshConfigBlock
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetConfigVariables() map[string]string {
+	if m != nil {
+		return m.ConfigVariables
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetBastionPolicy() []*BastionPolicy {
+	if m != nil {
+		return m.BastionPolicy
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetMaxAuthAgeSeconds() int32 {
+	if m != nil {
+		return m.MaxAuthAgeSeconds
+	}
+	return 0
+}
+
+func (m *ServerConfig) GetPrivilegedPrincipal() []string {
+	if m != nil {
+		return m.PrivilegedPrincipal
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetApprovers() []string {
+	if m != nil {
+		return m.Approvers
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetApprovalWebhookUrl() string {
+	if m != nil {
+		return m.ApprovalWebhookUrl
+	}
+	return ""
+}
+
+func (m *ServerConfig) GetApprovalTimeoutSeconds() int32 {
+	if m != nil {
+		return m.ApprovalTimeoutSeconds
+	}
+	return 0
+}
+
+func (m *ServerConfig) GetBreakGlassUser() []*ServerConfig_BreakGlassUser {
+	if m != nil {
+		return m.BreakGlassUser
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetBreakGlassLogPath() string {
+	if m != nil {
+		return m.BreakGlassLogPath
+	}
+	return ""
+}
+
+func (m *ServerConfig) GetBreakGlassCertDurationSeconds() int32 {
+	if m != nil {
+		return m.BreakGlassCertDurationSeconds
+	}
+	return 0
+}
+
+func (m *ServerConfig) GetCertExtensions() map[string]string {
+	if m != nil {
+		return m.CertExtensions
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetReasonExtension() string {
+	if m != nil {
+		return m.ReasonExtension
+	}
+	return ""
+}
+
+func (m *ServerConfig) GetRequireReason() bool {
+	if m != nil {
+		return m.RequireReason
+	}
+	return false
+}
+
+func (m *ServerConfig) GetSourceAddress() string {
+	if m != nil {
+		return m.SourceAddress
+	}
+	return ""
+}
+
+func (m *ServerConfig) GetRealm() []*ServerConfig_Realm {
+	if m != nil {
+		return m.Realm
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetWatchPollIntervalSeconds() int32 {
+	if m != nil {
+		return m.WatchPollIntervalSeconds
+	}
+	return 0
+}
+
+func (m *ServerConfig) GetAdditionalHostCaKey() []string {
+	if m != nil {
+		return m.AdditionalHostCaKey
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetMinClientVersion() string {
+	if m != nil {
+		return m.MinClientVersion
+	}
+	return ""
+}
+
+func (m *ServerConfig) GetMinClientVersionByName() map[string]string {
+	if m != nil {
+		return m.MinClientVersionByName
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetUpgradeUrl() string {
+	if m != nil {
+		return m.UpgradeUrl
+	}
+	return ""
+}
+
+func (m *ServerConfig) GetAcceptTelemetry() bool {
+	if m != nil {
+		return m.AcceptTelemetry
+	}
+	return false
+}
+
+func (m *ServerConfig) GetIdentityClaim() string {
+	if m != nil {
+		return m.IdentityClaim
+	}
+	return ""
+}
+
+func (m *ServerConfig) GetGroupsClaim() string {
+	if m != nil {
+		return m.GroupsClaim
+	}
+	return ""
+}
+
+func (m *ServerConfig) GetSamlBridge() *ServerConfig_SAMLBridge {
+	if m != nil {
+		return m.SamlBridge
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetKerberos() *ServerConfig_Kerberos {
+	if m != nil {
+		return m.Kerberos
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetConfigVersion() uint32 {
+	if m != nil {
+		return m.ConfigVersion
+	}
+	return 0
+}
+
+func (m *ServerConfig) GetBootstrap() *ServerConfig_Bootstrap {
+	if m != nil {
+		return m.Bootstrap
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetKubernetes() []*ServerConfig_Kubernetes {
+	if m != nil {
+		return m.Kubernetes
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetClientDirectives() *ClientDirectives {
+	if m != nil {
+		return m.ClientDirectives
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetAgentForwarding() *ServerConfig_AgentForwarding {
+	if m != nil {
+		return m.AgentForwarding
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetCaSignatureAlgorithm() string {
+	if m != nil {
+		return m.CaSignatureAlgorithm
+	}
+	return ""
+}
+
+func (m *ServerConfig) GetParallelCaKeyPath() []string {
+	if m != nil {
+		return m.ParallelCaKeyPath
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetKeyIdTemplate() string {
+	if m != nil {
+		return m.KeyIdTemplate
+	}
+	return ""
+}
+
+func (m *ServerConfig) GetValidAfterSkewSeconds() uint32 {
+	if m != nil {
+		return m.ValidAfterSkewSeconds
+	}
+	return 0
+}
+
+func (m *ServerConfig) GetClockCheck() *ServerConfig_ClockCheck {
+	if m != nil {
+		return m.ClockCheck
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetMaxUnexpiredCertsPerUser() uint32 {
+	if m != nil {
+		return m.MaxUnexpiredCertsPerUser
+	}
+	return 0
+}
+
+func (m *ServerConfig) GetRevokeOldestUnexpiredCert() bool {
+	if m != nil {
+		return m.RevokeOldestUnexpiredCert
+	}
+	return false
+}
+
+func (m *ServerConfig) GetRequireDevice() bool {
+	if m != nil {
+		return m.RequireDevice
+	}
+	return false
+}
+
+func (m *ServerConfig) GetDeviceExtension() string {
+	if m != nil {
+		return m.DeviceExtension
+	}
+	return ""
+}
+
+func (m *ServerConfig) GetMdm() *ServerConfig_MDM {
+	if m != nil {
+		return m.Mdm
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetSlack() *ServerConfig_Slack {
+	if m != nil {
+		return m.Slack
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetStatusPage() bool {
+	if m != nil {
+		return m.StatusPage
+	}
+	return false
+}
+
+func (m *ServerConfig) GetAutomationAccount() map[string]*ServerConfig_AutomationAccount {
+	if m != nil {
+		return m.AutomationAccount
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetImpersonators() []string {
+	if m != nil {
+		return m.Impersonators
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetOnBehalfOfExtension() string {
+	if m != nil {
+		return m.OnBehalfOfExtension
+	}
+	return ""
+}
+
+func (m *ServerConfig) GetJitRole() map[string]*ServerConfig_JITRole {
+	if m != nil {
+		return m.JitRole
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetTicketWebhook() *ServerConfig_TicketWebhook {
+	if m != nil {
+		return m.TicketWebhook
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetNetworkPolicy() *ServerConfig_NetworkPolicy {
+	if m != nil {
+		return m.NetworkPolicy
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetGeoipPath() string {
+	if m != nil {
+		return m.GeoipPath
+	}
+	return ""
+}
+
+func (m *ServerConfig) GetNetworkPolicyExempt() []string {
+	if m != nil {
+		return m.NetworkPolicyExempt
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetWorkingHours() []*ServerConfig_WorkingHours {
+	if m != nil {
+		return m.WorkingHours
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetListener() []*ServerConfig_Listener {
+	if m != nil {
+		return m.Listener
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetTrustedProxy() []string {
+	if m != nil {
+		return m.TrustedProxy
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetProxyProtocol() bool {
+	if m != nil {
+		return m.ProxyProtocol
+	}
+	return false
+}
+
+func (m *ServerConfig) GetGrpcReflection() bool {
+	if m != nil {
+		return m.GrpcReflection
+	}
+	return false
+}
+
+func (m *ServerConfig) GetMaxMessageBytes() uint32 {
+	if m != nil {
+		return m.MaxMessageBytes
+	}
+	return 0
+}
+
+func (m *ServerConfig) GetCompressResponses() bool {
+	if m != nil {
+		return m.CompressResponses
+	}
+	return false
+}
+
+func (m *ServerConfig) GetCaHostPattern() []string {
+	if m != nil {
+		return m.CaHostPattern
+	}
+	return nil
+}
+
+func (m *ServerConfig) GetAdditionalHostCa() []*HostCertificateAuthority {
+	if m != nil {
+		return m.AdditionalHostCa
+	}
+	return nil
+}
+
+type ServerConfig_BreakGlassUser struct {
+	Email           string   `protobuf:"bytes,1,opt,name=email" json:"email,omitempty"`
+	CredentialKey   string   `protobuf:"bytes,2,opt,name=credential_key,json=credentialKey" json:"credential_key,omitempty"`
+	Username        string   `protobuf:"bytes,3,opt,name=username" json:"username,omitempty"`
+	ExtraPrincipals []string `protobuf:"bytes,4,rep,name=extra_principals,json=extraPrincipals" json:"extra_principals,omitempty"`
+}
+
+func (m *ServerConfig_BreakGlassUser) Reset()         { *m = ServerConfig_BreakGlassUser{} }
+func (m *ServerConfig_BreakGlassUser) String() string { return proto.CompactTextString(m) }
+func (*ServerConfig_BreakGlassUser) ProtoMessage()    {}
+func (*ServerConfig_BreakGlassUser) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{37, 0}
+}
+
+func (m *ServerConfig_BreakGlassUser) GetEmail() string {
+	if m != nil {
+		return m.Email
+	}
+	return ""
+}
+
+func (m *ServerConfig_BreakGlassUser) GetCredentialKey() string {
+	if m != nil {
+		return m.CredentialKey
+	}
+	return ""
+}
+
+func (m *ServerConfig_BreakGlassUser) GetUsername() string {
+	if m != nil {
+		return m.Username
+	}
+	return ""
+}
+
+func (m *ServerConfig_BreakGlassUser) GetExtraPrincipals() []string {
+	if m != nil {
+		return m.ExtraPrincipals
+	}
+	return nil
+}
+
+type ServerConfig_Realm struct {
+	Name                           string            `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	CaKeyPath                      string            `protobuf:"bytes,2,opt,name=ca_key_path,json=caKeyPath" json:"ca_key_path,omitempty"`
+	ClientConfigScope              string            `protobuf:"bytes,3,opt,name=client_config_scope,json=clientConfigScope" json:"client_config_scope,omitempty"`
+	CaComment                      string            `protobuf:"bytes,4,opt,name=ca_comment,json=caComment" json:"ca_comment,omitempty"`
+	AdditionalSshConfigurationLine []string          `protobuf:"bytes,5,rep,name=additional_ssh_configuration_line,json=additionalSshConfigurationLine" json:"additional_ssh_configuration_line,omitempty"`
+	SshConfigBlock                 []*SSHConfigBlock `protobuf:"bytes,6,rep,name=ssh_config_block,json=sshConfigBlock" json:"ssh_config_block,omitempty"`
+	CaHostPattern                  []string          `protobuf:"bytes,7,rep,name=ca_host_pattern,json=caHostPattern" json:"ca_host_pattern,omitempty"`
+}
+
+func (m *ServerConfig_Realm) Reset()                    { *m = ServerConfig_Realm{} }
+func (m *ServerConfig_Realm) String() string            { return proto.CompactTextString(m) }
+func (*ServerConfig_Realm) ProtoMessage()               {}
+func (*ServerConfig_Realm) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{37, 1} }
+
+func (m *ServerConfig_Realm) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *ServerConfig_Realm) GetCaKeyPath() string {
+	if m != nil {
+		return m.CaKeyPath
+	}
+	return ""
+}
+
+func (m *ServerConfig_Realm) GetClientConfigScope() string {
+	if m != nil {
+		return m.ClientConfigScope
+	}
+	return ""
+}
+
+func (m *ServerConfig_Realm) GetCaComment() string {
+	if m != nil {
+		return m.CaComment
+	}
+	return ""
+}
+
+func (m *ServerConfig_Realm) GetAdditionalSshConfigurationLine() []string {
+	if m != nil {
+		return m.AdditionalSshConfigurationLine
+	}
+	return nil
+}
+
+func (m *ServerConfig_Realm) GetSshConfigBlock() []*SSHConfigBlock {
+	if m != nil {
+		return m.SshConfigBlock
+	}
+	return nil
+}
+
+func (m *ServerConfig_Realm) GetCaHostPattern() []string {
+	if m != nil {
+		return m.CaHostPattern
+	}
+	return nil
+}
+
+type ServerConfig_UserConfig struct {
+	Username          string                      `protobuf:"bytes,1,opt,name=username" json:"username,omitempty"`
+	ExtraPrincipals   []string                    `protobuf:"bytes,2,rep,name=extra_principals,json=extraPrincipals" json:"extra_principals,omitempty"`
+	CertPermissions   map[string]string           `protobuf:"bytes,3,rep,name=cert_permissions,json=certPermissions" json:"cert_permissions,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	ConfigVariables   map[string]string           `protobuf:"bytes,4,rep,name=config_variables,json=configVariables" json:"config_variables,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	MaxAuthAgeSeconds int32                       `protobuf:"varint,5,opt,name=max_auth_age_seconds,json=maxAuthAgeSeconds" json:"max_auth_age_seconds,omitempty"`
+	CertExtensions    map[string]string           `protobuf:"bytes,6,rep,name=cert_extensions,json=certExtensions" json:"cert_extensions,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	SourceAddress     string                      `protobuf:"bytes,7,opt,name=source_address,json=sourceAddress" json:"source_address,omitempty"`
+	Realm             []string                    `protobuf:"bytes,8,rep,name=realm" json:"realm,omitempty"`
+	NetworkPolicy     *ServerConfig_NetworkPolicy `protobuf:"bytes,9,opt,name=network_policy,json=networkPolicy" json:"network_policy,omitempty"`
+}
+
+func (m *ServerConfig_UserConfig) Reset()                    { *m = ServerConfig_UserConfig{} }
+func (m *ServerConfig_UserConfig) String() string            { return proto.CompactTextString(m) }
+func (*ServerConfig_UserConfig) ProtoMessage()               {}
+func (*ServerConfig_UserConfig) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{37, 2} }
+
+func (m *ServerConfig_UserConfig) GetUsername() string {
+	if m != nil {
+		return m.Username
+	}
+	return ""
+}
+
+func (m *ServerConfig_UserConfig) GetExtraPrincipals() []string {
+	if m != nil {
+		return m.ExtraPrincipals
+	}
+	return nil
+}
+
+func (m *ServerConfig_UserConfig) GetCertPermissions() map[string]string {
+	if m != nil {
+		return m.CertPermissions
+	}
+	return nil
+}
+
+func (m *ServerConfig_UserConfig) GetConfigVariables() map[string]string {
+	if m != nil {
+		return m.ConfigVariables
+	}
+	return nil
+}
+
+func (m *ServerConfig_UserConfig) GetMaxAuthAgeSeconds() int32 {
+	if m != nil {
+		return m.MaxAuthAgeSeconds
+	}
+	return 0
+}
+
+func (m *ServerConfig_UserConfig) GetCertExtensions() map[string]string {
+	if m != nil {
+		return m.CertExtensions
+	}
+	return nil
+}
+
+func (m *ServerConfig_UserConfig) GetSourceAddress() string {
+	if m != nil {
+		return m.SourceAddress
+	}
+	return ""
+}
+
+func (m *ServerConfig_UserConfig) GetRealm() []string {
+	if m != nil {
+		return m.Realm
+	}
+	return nil
+}
+
+func (m *ServerConfig_UserConfig) GetNetworkPolicy() *ServerConfig_NetworkPolicy {
+	if m != nil {
+		return m.NetworkPolicy
+	}
+	return nil
+}
+
+type ServerConfig_NetworkPolicy struct {
+	AllowedCidr    []string `protobuf:"bytes,1,rep,name=allowed_cidr,json=allowedCidr" json:"allowed_cidr,omitempty"`
+	AllowedCountry []string `protobuf:"bytes,2,rep,name=allowed_country,json=allowedCountry" json:"allowed_country,omitempty"`
+	DeniedCountry  []string `protobuf:"bytes,3,rep,name=denied_country,json=deniedCountry" json:"denied_country,omitempty"`
+}
+
+func (m *ServerConfig_NetworkPolicy) Reset()                    { *m = ServerConfig_NetworkPolicy{} }
+func (m *ServerConfig_NetworkPolicy) String() string            { return proto.CompactTextString(m) }
+func (*ServerConfig_NetworkPolicy) ProtoMessage()               {}
+func (*ServerConfig_NetworkPolicy) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{37, 3} }
+
+func (m *ServerConfig_NetworkPolicy) GetAllowedCidr() []string {
+	if m != nil {
+		return m.AllowedCidr
+	}
+	return nil
+}
+
+func (m *ServerConfig_NetworkPolicy) GetAllowedCountry() []string {
+	if m != nil {
+		return m.AllowedCountry
+	}
+	return nil
+}
+
+func (m *ServerConfig_NetworkPolicy) GetDeniedCountry() []string {
+	if m != nil {
+		return m.DeniedCountry
+	}
+	return nil
+}
+
+type ServerConfig_SAMLBridge struct {
+	RootUrl              string `protobuf:"bytes,1,opt,name=root_url,json=rootUrl" json:"root_url,omitempty"`
+	IdpMetadataPath      string `protobuf:"bytes,2,opt,name=idp_metadata_path,json=idpMetadataPath" json:"idp_metadata_path,omitempty"`
+	KeyPath              string `protobuf:"bytes,3,opt,name=key_path,json=keyPath" json:"key_path,omitempty"`
+	CertPath             string `protobuf:"bytes,4,opt,name=cert_path,json=certPath" json:"cert_path,omitempty"`
+	EmailAttribute       string `protobuf:"bytes,5,opt,name=email_attribute,json=emailAttribute" json:"email_attribute,omitempty"`
+	GroupsAttribute      string `protobuf:"bytes,6,opt,name=groups_attribute,json=groupsAttribute" json:"groups_attribute,omitempty"`
+	TokenLifetimeSeconds int32  `protobuf:"varint,7,opt,name=token_lifetime_seconds,json=tokenLifetimeSeconds" json:"token_lifetime_seconds,omitempty"`
+}
+
+func (m *ServerConfig_SAMLBridge) Reset()                    { *m = ServerConfig_SAMLBridge{} }
+func (m *ServerConfig_SAMLBridge) String() string            { return proto.CompactTextString(m) }
+func (*ServerConfig_SAMLBridge) ProtoMessage()               {}
+func (*ServerConfig_SAMLBridge) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{37, 4} }
+
+func (m *ServerConfig_SAMLBridge) GetRootUrl() string {
+	if m != nil {
+		return m.RootUrl
+	}
+	return ""
+}
+
+func (m *ServerConfig_SAMLBridge) GetIdpMetadataPath() string {
+	if m != nil {
+		return m.IdpMetadataPath
+	}
+	return ""
+}
+
+func (m *ServerConfig_SAMLBridge) GetKeyPath() string {
+	if m != nil {
+		return m.KeyPath
+	}
+	return ""
+}
+
+func (m *ServerConfig_SAMLBridge) GetCertPath() string {
+	if m != nil {
+		return m.CertPath
+	}
+	return ""
+}
+
+func (m *ServerConfig_SAMLBridge) GetEmailAttribute() string {
+	if m != nil {
+		return m.EmailAttribute
+	}
+	return ""
+}
+
+func (m *ServerConfig_SAMLBridge) GetGroupsAttribute() string {
+	if m != nil {
+		return m.GroupsAttribute
+	}
+	return ""
+}
+
+func (m *ServerConfig_SAMLBridge) GetTokenLifetimeSeconds() int32 {
+	if m != nil {
+		return m.TokenLifetimeSeconds
+	}
+	return 0
+}
+
+type ServerConfig_Kerberos struct {
+	KeytabPath       string `protobuf:"bytes,1,opt,name=keytab_path,json=keytabPath" json:"keytab_path,omitempty"`
+	ServicePrincipal string `protobuf:"bytes,2,opt,name=service_principal,json=servicePrincipal" json:"service_principal,omitempty"`
+	Realm            string `protobuf:"bytes,3,opt,name=realm" json:"realm,omitempty"`
+	IdentityDomain   string `protobuf:"bytes,4,opt,name=identity_domain,json=identityDomain" json:"identity_domain,omitempty"`
+}
+
+func (m *ServerConfig_Kerberos) Reset()                    { *m = ServerConfig_Kerberos{} }
+func (m *ServerConfig_Kerberos) String() string            { return proto.CompactTextString(m) }
+func (*ServerConfig_Kerberos) ProtoMessage()               {}
+func (*ServerConfig_Kerberos) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{37, 5} }
+
+func (m *ServerConfig_Kerberos) GetKeytabPath() string {
+	if m != nil {
+		return m.KeytabPath
+	}
+	return ""
+}
+
+func (m *ServerConfig_Kerberos) GetServicePrincipal() string {
+	if m != nil {
+		return m.ServicePrincipal
+	}
+	return ""
+}
+
+func (m *ServerConfig_Kerberos) GetRealm() string {
+	if m != nil {
+		return m.Realm
+	}
+	return ""
+}
+
+func (m *ServerConfig_Kerberos) GetIdentityDomain() string {
+	if m != nil {
+		return m.IdentityDomain
+	}
+	return ""
+}
+
+type ServerConfig_Kubernetes struct {
+	Issuer      string `protobuf:"bytes,1,opt,name=issuer" json:"issuer,omitempty"`
+	Audience    string `protobuf:"bytes,2,opt,name=audience" json:"audience,omitempty"`
+	JwksPath    string `protobuf:"bytes,3,opt,name=jwks_path,json=jwksPath" json:"jwks_path,omitempty"`
+	JwksUri     string `protobuf:"bytes,4,opt,name=jwks_uri,json=jwksUri" json:"jwks_uri,omitempty"`
+	ClusterName string `protobuf:"bytes,5,opt,name=cluster_name,json=clusterName" json:"cluster_name,omitempty"`
+}
+
+func (m *ServerConfig_Kubernetes) Reset()                    { *m = ServerConfig_Kubernetes{} }
+func (m *ServerConfig_Kubernetes) String() string            { return proto.CompactTextString(m) }
+func (*ServerConfig_Kubernetes) ProtoMessage()               {}
+func (*ServerConfig_Kubernetes) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{37, 6} }
+
+func (m *ServerConfig_Kubernetes) GetIssuer() string {
+	if m != nil {
+		return m.Issuer
+	}
+	return ""
+}
+
+func (m *ServerConfig_Kubernetes) GetAudience() string {
+	if m != nil {
+		return m.Audience
+	}
+	return ""
+}
+
+func (m *ServerConfig_Kubernetes) GetJwksPath() string {
+	if m != nil {
+		return m.JwksPath
+	}
+	return ""
+}
+
+func (m *ServerConfig_Kubernetes) GetJwksUri() string {
+	if m != nil {
+		return m.JwksUri
+	}
+	return ""
+}
+
+func (m *ServerConfig_Kubernetes) GetClusterName() string {
+	if m != nil {
+		return m.ClusterName
+	}
+	return ""
+}
+
+type ServerConfig_AgentForwarding struct {
+	ForwardAgent     bool     `protobuf:"varint,1,opt,name=forward_agent,json=forwardAgent" json:"forward_agent,omitempty"`
+	ForwardAgentHost []string `protobuf:"bytes,2,rep,name=forward_agent_host,json=forwardAgentHost" json:"forward_agent_host,omitempty"`
+	AddKeysToAgent   string   `protobuf:"bytes,3,opt,name=add_keys_to_agent,json=addKeysToAgent" json:"add_keys_to_agent,omitempty"`
+}
+
+func (m *ServerConfig_AgentForwarding) Reset()         { *m = ServerConfig_AgentForwarding{} }
+func (m *ServerConfig_AgentForwarding) String() string { return proto.CompactTextString(m) }
+func (*ServerConfig_AgentForwarding) ProtoMessage()    {}
+func (*ServerConfig_AgentForwarding) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{37, 7}
+}
+
+func (m *ServerConfig_AgentForwarding) GetForwardAgent() bool {
+	if m != nil {
+		return m.ForwardAgent
+	}
+	return false
+}
+
+func (m *ServerConfig_AgentForwarding) GetForwardAgentHost() []string {
+	if m != nil {
+		return m.ForwardAgentHost
+	}
+	return nil
+}
+
+func (m *ServerConfig_AgentForwarding) GetAddKeysToAgent() string {
+	if m != nil {
+		return m.AddKeysToAgent
+	}
+	return ""
+}
+
+type ServerConfig_MDM struct {
+	Provider         string `protobuf:"bytes,1,opt,name=provider" json:"provider,omitempty"`
+	Url              string `protobuf:"bytes,2,opt,name=url" json:"url,omitempty"`
+	TenantId         string `protobuf:"bytes,3,opt,name=tenant_id,json=tenantId" json:"tenant_id,omitempty"`
+	ClientId         string `protobuf:"bytes,4,opt,name=client_id,json=clientId" json:"client_id,omitempty"`
+	ClientSecretPath string `protobuf:"bytes,5,opt,name=client_secret_path,json=clientSecretPath" json:"client_secret_path,omitempty"`
+	TokenUrl         string `protobuf:"bytes,6,opt,name=token_url,json=tokenUrl" json:"token_url,omitempty"`
+	CacheSeconds     uint32 `protobuf:"varint,7,opt,name=cache_seconds,json=cacheSeconds" json:"cache_seconds,omitempty"`
+}
+
+func (m *ServerConfig_MDM) Reset()                    { *m = ServerConfig_MDM{} }
+func (m *ServerConfig_MDM) String() string            { return proto.CompactTextString(m) }
+func (*ServerConfig_MDM) ProtoMessage()               {}
+func (*ServerConfig_MDM) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{37, 8} }
+
+func (m *ServerConfig_MDM) GetProvider() string {
+	if m != nil {
+		return m.Provider
+	}
+	return ""
+}
+
+func (m *ServerConfig_MDM) GetUrl() string {
+	if m != nil {
+		return m.Url
+	}
+	return ""
+}
+
+func (m *ServerConfig_MDM) GetTenantId() string {
+	if m != nil {
+		return m.TenantId
+	}
+	return ""
+}
+
+func (m *ServerConfig_MDM) GetClientId() string {
+	if m != nil {
+		return m.ClientId
+	}
+	return ""
+}
+
+func (m *ServerConfig_MDM) GetClientSecretPath() string {
+	if m != nil {
+		return m.ClientSecretPath
+	}
+	return ""
+}
+
+func (m *ServerConfig_MDM) GetTokenUrl() string {
+	if m != nil {
+		return m.TokenUrl
+	}
+	return ""
+}
+
+func (m *ServerConfig_MDM) GetCacheSeconds() uint32 {
+	if m != nil {
+		return m.CacheSeconds
+	}
+	return 0
+}
+
+type ServerConfig_Slack struct {
+	BotTokenPath      string `protobuf:"bytes,1,opt,name=bot_token_path,json=botTokenPath" json:"bot_token_path,omitempty"`
+	SigningSecretPath string `protobuf:"bytes,2,opt,name=signing_secret_path,json=signingSecretPath" json:"signing_secret_path,omitempty"`
+	NotifyIssued      bool   `protobuf:"varint,3,opt,name=notify_issued,json=notifyIssued" json:"notify_issued,omitempty"`
+	ApprovalChannel   string `protobuf:"bytes,4,opt,name=approval_channel,json=approvalChannel" json:"approval_channel,omitempty"`
+	ApiUrl            string `protobuf:"bytes,5,opt,name=api_url,json=apiUrl" json:"api_url,omitempty"`
+}
+
+func (m *ServerConfig_Slack) Reset()                    { *m = ServerConfig_Slack{} }
+func (m *ServerConfig_Slack) String() string            { return proto.CompactTextString(m) }
+func (*ServerConfig_Slack) ProtoMessage()               {}
+func (*ServerConfig_Slack) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{37, 9} }
+
+func (m *ServerConfig_Slack) GetBotTokenPath() string {
+	if m != nil {
+		return m.BotTokenPath
+	}
+	return ""
+}
+
+func (m *ServerConfig_Slack) GetSigningSecretPath() string {
+	if m != nil {
+		return m.SigningSecretPath
+	}
+	return ""
+}
+
+func (m *ServerConfig_Slack) GetNotifyIssued() bool {
+	if m != nil {
+		return m.NotifyIssued
+	}
+	return false
+}
+
+func (m *ServerConfig_Slack) GetApprovalChannel() string {
+	if m != nil {
+		return m.ApprovalChannel
+	}
+	return ""
+}
+
+func (m *ServerConfig_Slack) GetApiUrl() string {
+	if m != nil {
+		return m.ApiUrl
+	}
+	return ""
+}
+
+type ServerConfig_AutomationAccount struct {
+	AllowedPrincipal    []string          `protobuf:"bytes,1,rep,name=allowed_principal,json=allowedPrincipal" json:"allowed_principal,omitempty"`
+	MaxBatchSize        uint32            `protobuf:"varint,2,opt,name=max_batch_size,json=maxBatchSize" json:"max_batch_size,omitempty"`
+	MaxCertsPerHour     uint32            `protobuf:"varint,3,opt,name=max_certs_per_hour,json=maxCertsPerHour" json:"max_certs_per_hour,omitempty"`
+	CertDurationSeconds uint32            `protobuf:"varint,4,opt,name=cert_duration_seconds,json=certDurationSeconds" json:"cert_duration_seconds,omitempty"`
+	CertPermissions     map[string]string `protobuf:"bytes,5,rep,name=cert_permissions,json=certPermissions" json:"cert_permissions,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+}
+
+func (m *ServerConfig_AutomationAccount) Reset()         { *m = ServerConfig_AutomationAccount{} }
+func (m *ServerConfig_AutomationAccount) String() string { return proto.CompactTextString(m) }
+func (*ServerConfig_AutomationAccount) ProtoMessage()    {}
+func (*ServerConfig_AutomationAccount) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{37, 10}
+}
+
+func (m *ServerConfig_AutomationAccount) GetAllowedPrincipal() []string {
+	if m != nil {
+		return m.AllowedPrincipal
+	}
+	return nil
+}
+
+func (m *ServerConfig_AutomationAccount) GetMaxBatchSize() uint32 {
+	if m != nil {
+		return m.MaxBatchSize
+	}
+	return 0
+}
+
+func (m *ServerConfig_AutomationAccount) GetMaxCertsPerHour() uint32 {
+	if m != nil {
+		return m.MaxCertsPerHour
+	}
+	return 0
+}
+
+func (m *ServerConfig_AutomationAccount) GetCertDurationSeconds() uint32 {
+	if m != nil {
+		return m.CertDurationSeconds
+	}
+	return 0
+}
+
+func (m *ServerConfig_AutomationAccount) GetCertPermissions() map[string]string {
+	if m != nil {
+		return m.CertPermissions
+	}
+	return nil
+}
+
+type ServerConfig_JITRole struct {
+	Principals         []string `protobuf:"bytes,1,rep,name=principals" json:"principals,omitempty"`
+	Allowed            []string `protobuf:"bytes,2,rep,name=allowed" json:"allowed,omitempty"`
+	MaxDurationSeconds uint32   `protobuf:"varint,3,opt,name=max_duration_seconds,json=maxDurationSeconds" json:"max_duration_seconds,omitempty"`
+	TicketPattern      string   `protobuf:"bytes,4,opt,name=ticket_pattern,json=ticketPattern" json:"ticket_pattern,omitempty"`
+	RequireApproval    bool     `protobuf:"varint,5,opt,name=require_approval,json=requireApproval" json:"require_approval,omitempty"`
+}
+
+func (m *ServerConfig_JITRole) Reset()                    { *m = ServerConfig_JITRole{} }
+func (m *ServerConfig_JITRole) String() string            { return proto.CompactTextString(m) }
+func (*ServerConfig_JITRole) ProtoMessage()               {}
+func (*ServerConfig_JITRole) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{37, 11} }
+
+func (m *ServerConfig_JITRole) GetPrincipals() []string {
+	if m != nil {
+		return m.Principals
+	}
+	return nil
+}
+
+func (m *ServerConfig_JITRole) GetAllowed() []string {
+	if m != nil {
+		return m.Allowed
+	}
+	return nil
+}
+
+func (m *ServerConfig_JITRole) GetMaxDurationSeconds() uint32 {
+	if m != nil {
+		return m.MaxDurationSeconds
+	}
+	return 0
+}
+
+func (m *ServerConfig_JITRole) GetTicketPattern() string {
+	if m != nil {
+		return m.TicketPattern
+	}
+	return ""
+}
+
+func (m *ServerConfig_JITRole) GetRequireApproval() bool {
+	if m != nil {
+		return m.RequireApproval
+	}
+	return false
+}
+
+type ServerConfig_Listener struct {
+	Network        string `protobuf:"bytes,1,opt,name=network" json:"network,omitempty"`
+	Address        string `protobuf:"bytes,2,opt,name=address" json:"address,omitempty"`
+	Plaintext      bool   `protobuf:"varint,3,opt,name=plaintext" json:"plaintext,omitempty"`
+	ProxyProtocol  bool   `protobuf:"varint,4,opt,name=proxy_protocol,json=proxyProtocol" json:"proxy_protocol,omitempty"`
+	TrustForwarded bool   `protobuf:"varint,5,opt,name=trust_forwarded,json=trustForwarded" json:"trust_forwarded,omitempty"`
+}
+
+func (m *ServerConfig_Listener) Reset()                    { *m = ServerConfig_Listener{} }
+func (m *ServerConfig_Listener) String() string            { return proto.CompactTextString(m) }
+func (*ServerConfig_Listener) ProtoMessage()               {}
+func (*ServerConfig_Listener) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{37, 12} }
+
+func (m *ServerConfig_Listener) GetNetwork() string {
+	if m != nil {
+		return m.Network
+	}
+	return ""
+}
+
+func (m *ServerConfig_Listener) GetAddress() string {
+	if m != nil {
+		return m.Address
+	}
+	return ""
+}
+
+func (m *ServerConfig_Listener) GetPlaintext() bool {
+	if m != nil {
+		return m.Plaintext
+	}
+	return false
+}
+
+func (m *ServerConfig_Listener) GetProxyProtocol() bool {
+	if m != nil {
+		return m.ProxyProtocol
+	}
+	return false
+}
+
+func (m *ServerConfig_Listener) GetTrustForwarded() bool {
+	if m != nil {
+		return m.TrustForwarded
+	}
+	return false
+}
+
+type ServerConfig_WorkingHours struct {
+	AppliesTo  []string `protobuf:"bytes,1,rep,name=applies_to,json=appliesTo" json:"applies_to,omitempty"`
+	Principals []string `protobuf:"bytes,2,rep,name=principals" json:"principals,omitempty"`
+	Window     []string `protobuf:"bytes,3,rep,name=window" json:"window,omitempty"`
+	TimeZone   string   `protobuf:"bytes,4,opt,name=time_zone,json=timeZone" json:"time_zone,omitempty"`
+}
+
+func (m *ServerConfig_WorkingHours) Reset()                    { *m = ServerConfig_WorkingHours{} }
+func (m *ServerConfig_WorkingHours) String() string            { return proto.CompactTextString(m) }
+func (*ServerConfig_WorkingHours) ProtoMessage()               {}
+func (*ServerConfig_WorkingHours) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{37, 13} }
+
+func (m *ServerConfig_WorkingHours) GetAppliesTo() []string {
+	if m != nil {
+		return m.AppliesTo
+	}
+	return nil
+}
+
+func (m *ServerConfig_WorkingHours) GetPrincipals() []string {
+	if m != nil {
+		return m.Principals
+	}
+	return nil
+}
+
+func (m *ServerConfig_WorkingHours) GetWindow() []string {
+	if m != nil {
+		return m.Window
+	}
+	return nil
+}
+
+func (m *ServerConfig_WorkingHours) GetTimeZone() string {
+	if m != nil {
+		return m.TimeZone
+	}
+	return ""
+}
+
+type ServerConfig_TicketWebhook struct {
+	Url            string `protobuf:"bytes,1,opt,name=url" json:"url,omitempty"`
+	AuthTokenPath  string `protobuf:"bytes,2,opt,name=auth_token_path,json=authTokenPath" json:"auth_token_path,omitempty"`
+	TimeoutSeconds uint32 `protobuf:"varint,3,opt,name=timeout_seconds,json=timeoutSeconds" json:"timeout_seconds,omitempty"`
+}
+
+func (m *ServerConfig_TicketWebhook) Reset()         { *m = ServerConfig_TicketWebhook{} }
+func (m *ServerConfig_TicketWebhook) String() string { return proto.CompactTextString(m) }
+func (*ServerConfig_TicketWebhook) ProtoMessage()    {}
+func (*ServerConfig_TicketWebhook) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{37, 14}
+}
+
+func (m *ServerConfig_TicketWebhook) GetUrl() string {
+	if m != nil {
+		return m.Url
+	}
+	return ""
+}
+
+func (m *ServerConfig_TicketWebhook) GetAuthTokenPath() string {
+	if m != nil {
+		return m.AuthTokenPath
+	}
+	return ""
+}
+
+func (m *ServerConfig_TicketWebhook) GetTimeoutSeconds() uint32 {
+	if m != nil {
+		return m.TimeoutSeconds
+	}
+	return 0
+}
+
+type ServerConfig_ClockCheck struct {
+	NtpServer        string `protobuf:"bytes,1,opt,name=ntp_server,json=ntpServer" json:"ntp_server,omitempty"`
+	MaxOffsetSeconds uint32 `protobuf:"varint,2,opt,name=max_offset_seconds,json=maxOffsetSeconds" json:"max_offset_seconds,omitempty"`
+	IntervalSeconds  uint32 `protobuf:"varint,3,opt,name=interval_seconds,json=intervalSeconds" json:"interval_seconds,omitempty"`
+}
+
+func (m *ServerConfig_ClockCheck) Reset()                    { *m = ServerConfig_ClockCheck{} }
+func (m *ServerConfig_ClockCheck) String() string            { return proto.CompactTextString(m) }
+func (*ServerConfig_ClockCheck) ProtoMessage()               {}
+func (*ServerConfig_ClockCheck) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{37, 15} }
+
+func (m *ServerConfig_ClockCheck) GetNtpServer() string {
+	if m != nil {
+		return m.NtpServer
+	}
+	return ""
+}
+
+func (m *ServerConfig_ClockCheck) GetMaxOffsetSeconds() uint32 {
+	if m != nil {
+		return m.MaxOffsetSeconds
+	}
+	return 0
+}
+
+func (m *ServerConfig_ClockCheck) GetIntervalSeconds() uint32 {
+	if m != nil {
+		return m.IntervalSeconds
+	}
+	return 0
+}
+
+type ServerConfig_Bootstrap struct {
+	GrpcServer             string `protobuf:"bytes,1,opt,name=grpc_server,json=grpcServer" json:"grpc_server,omitempty"`
+	ClientNotSoSecret      string `protobuf:"bytes,2,opt,name=client_not_so_secret,json=clientNotSoSecret" json:"client_not_so_secret,omitempty"`
+	GrpcPemCertificatePath string `protobuf:"bytes,3,opt,name=grpc_pem_certificate_path,json=grpcPemCertificatePath" json:"grpc_pem_certificate_path,omitempty"`
+	CredentialFileName     string `protobuf:"bytes,4,opt,name=credential_file_name,json=credentialFileName" json:"credential_file_name,omitempty"`
+	ShortlivedKeyName      string `protobuf:"bytes,5,opt,name=shortlived_key_name,json=shortlivedKeyName" json:"shortlived_key_name,omitempty"`
+	SectionIdentifier      string `protobuf:"bytes,6,opt,name=section_identifier,json=sectionIdentifier" json:"section_identifier,omitempty"`
+	KrlPath                string `protobuf:"bytes,7,opt,name=krl_path,json=krlPath" json:"krl_path,omitempty"`
+}
+
+func (m *ServerConfig_Bootstrap) Reset()                    { *m = ServerConfig_Bootstrap{} }
+func (m *ServerConfig_Bootstrap) String() string            { return proto.CompactTextString(m) }
+func (*ServerConfig_Bootstrap) ProtoMessage()               {}
+func (*ServerConfig_Bootstrap) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{37, 16} }
+
+func (m *ServerConfig_Bootstrap) GetGrpcServer() string {
+	if m != nil {
+		return m.GrpcServer
+	}
+	return ""
+}
+
+func (m *ServerConfig_Bootstrap) GetClientNotSoSecret() string {
+	if m != nil {
+		return m.ClientNotSoSecret
+	}
+	return ""
+}
+
+func (m *ServerConfig_Bootstrap) GetGrpcPemCertificatePath() string {
+	if m != nil {
+		return m.GrpcPemCertificatePath
+	}
+	return ""
+}
+
+func (m *ServerConfig_Bootstrap) GetCredentialFileName() string {
+	if m != nil {
+		return m.CredentialFileName
+	}
+	return ""
+}
+
+func (m *ServerConfig_Bootstrap) GetShortlivedKeyName() string {
+	if m != nil {
+		return m.ShortlivedKeyName
+	}
+	return ""
+}
+
+func (m *ServerConfig_Bootstrap) GetSectionIdentifier() string {
+	if m != nil {
+		return m.SectionIdentifier
+	}
+	return ""
+}
+
+func (m *ServerConfig_Bootstrap) GetKrlPath() string {
+	if m != nil {
+		return m.KrlPath
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterType((*ErrorDetail)(nil), "ErrorDetail")
+	proto.RegisterType((*SSHCertsRequest)(nil), "SSHCertsRequest")
+	proto.RegisterType((*AccessRequest)(nil), "AccessRequest")
+	proto.RegisterType((*SSHCertsResponse)(nil), "SSHCertsResponse")
+	proto.RegisterType((*ClientDirectives)(nil), "ClientDirectives")
+	proto.RegisterType((*RealmCertificate)(nil), "RealmCertificate")
+	proto.RegisterType((*BastionPolicy)(nil), "BastionPolicy")
+	proto.RegisterType((*SSHConfigBlock)(nil), "SSHConfigBlock")
+	proto.RegisterType((*SSHConfigBlock_Option)(nil), "SSHConfigBlock.Option")
+	proto.RegisterType((*HostCertificateAuthority)(nil), "HostCertificateAuthority")
+	proto.RegisterType((*LookupCertRequest)(nil), "LookupCertRequest")
+	proto.RegisterType((*IssuedCertRecord)(nil), "IssuedCertRecord")
+	proto.RegisterType((*LookupCertResponse)(nil), "LookupCertResponse")
+	proto.RegisterType((*ListApprovalsRequest)(nil), "ListApprovalsRequest")
+	proto.RegisterType((*ApprovalRecord)(nil), "ApprovalRecord")
+	proto.RegisterType((*ListApprovalsResponse)(nil), "ListApprovalsResponse")
+	proto.RegisterType((*DecideApprovalRequest)(nil), "DecideApprovalRequest")
+	proto.RegisterType((*DecideApprovalResponse)(nil), "DecideApprovalResponse")
+	proto.RegisterType((*WatchRequest)(nil), "WatchRequest")
+	proto.RegisterType((*WatchUpdate)(nil), "WatchUpdate")
+	proto.RegisterType((*BreakGlassRequest)(nil), "BreakGlassRequest")
+	proto.RegisterType((*TelemetryReport)(nil), "TelemetryReport")
+	proto.RegisterType((*TelemetryResponse)(nil), "TelemetryResponse")
+	proto.RegisterType((*TelemetrySummaryRequest)(nil), "TelemetrySummaryRequest")
+	proto.RegisterType((*TelemetryCount)(nil), "TelemetryCount")
+	proto.RegisterType((*TelemetrySummaryResponse)(nil), "TelemetrySummaryResponse")
+	proto.RegisterType((*PickupCodeRequest)(nil), "PickupCodeRequest")
+	proto.RegisterType((*PickupCodeResponse)(nil), "PickupCodeResponse")
+	proto.RegisterType((*EnrollDeviceRequest)(nil), "EnrollDeviceRequest")
+	proto.RegisterType((*EnrollDeviceResponse)(nil), "EnrollDeviceResponse")
+	proto.RegisterType((*RevokeDeviceRequest)(nil), "RevokeDeviceRequest")
+	proto.RegisterType((*RevokeDeviceResponse)(nil), "RevokeDeviceResponse")
+	proto.RegisterType((*BatchCertsRequest)(nil), "BatchCertsRequest")
+	proto.RegisterType((*BatchCertRequest)(nil), "BatchCertRequest")
+	proto.RegisterType((*BatchCertsResponse)(nil), "BatchCertsResponse")
+	proto.RegisterType((*BatchCert)(nil), "BatchCert")
+	proto.RegisterType((*ExplainPolicyRequest)(nil), "ExplainPolicyRequest")
+	proto.RegisterType((*ExplainPolicyResponse)(nil), "ExplainPolicyResponse")
+	proto.RegisterType((*ServerConfig)(nil), "ServerConfig")
+	proto.RegisterType((*ServerConfig_BreakGlassUser)(nil), "ServerConfig.BreakGlassUser")
+	proto.RegisterType((*ServerConfig_Realm)(nil), "ServerConfig.Realm")
+	proto.RegisterType((*ServerConfig_UserConfig)(nil), "ServerConfig.UserConfig")
+	proto.RegisterType((*ServerConfig_NetworkPolicy)(nil), "ServerConfig.NetworkPolicy")
+	proto.RegisterType((*ServerConfig_SAMLBridge)(nil), "ServerConfig.SAMLBridge")
+	proto.RegisterType((*ServerConfig_Kerberos)(nil), "ServerConfig.Kerberos")
+	proto.RegisterType((*ServerConfig_Kubernetes)(nil), "ServerConfig.Kubernetes")
+	proto.RegisterType((*ServerConfig_AgentForwarding)(nil), "ServerConfig.AgentForwarding")
+	proto.RegisterType((*ServerConfig_MDM)(nil), "ServerConfig.MDM")
+	proto.RegisterType((*ServerConfig_Slack)(nil), "ServerConfig.Slack")
+	proto.RegisterType((*ServerConfig_AutomationAccount)(nil), "ServerConfig.AutomationAccount")
+	proto.RegisterType((*ServerConfig_JITRole)(nil), "ServerConfig.JITRole")
+	proto.RegisterType((*ServerConfig_Listener)(nil), "ServerConfig.Listener")
+	proto.RegisterType((*ServerConfig_WorkingHours)(nil), "ServerConfig.WorkingHours")
+	proto.RegisterType((*ServerConfig_TicketWebhook)(nil), "ServerConfig.TicketWebhook")
+	proto.RegisterType((*ServerConfig_ClockCheck)(nil), "ServerConfig.ClockCheck")
+	proto.RegisterType((*ServerConfig_Bootstrap)(nil), "ServerConfig.Bootstrap")
+	proto.RegisterEnum("ErrorReason", ErrorReason_name, ErrorReason_value)
+	proto.RegisterEnum("CredentialType", CredentialType_name, CredentialType_value)
+	proto.RegisterEnum("ResponseCode", ResponseCode_name, ResponseCode_value)
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// Client API for GeeCertServer service
+
+type GeeCertServerClient interface {
+	GetSSHCerts(ctx context.Context, in *SSHCertsRequest, opts ...grpc.CallOption) (*SSHCertsResponse, error)
+	LookupCert(ctx context.Context, in *LookupCertRequest, opts ...grpc.CallOption) (*LookupCertResponse, error)
+	ListApprovals(ctx context.Context, in *ListApprovalsRequest, opts ...grpc.CallOption) (*ListApprovalsResponse, error)
+	DecideApproval(ctx context.Context, in *DecideApprovalRequest, opts ...grpc.CallOption) (*DecideApprovalResponse, error)
+	BreakGlassCerts(ctx context.Context, in *BreakGlassRequest, opts ...grpc.CallOption) (*SSHCertsResponse, error)
+	WatchUpdates(ctx context.Context, opts ...grpc.CallOption) (GeeCertServer_WatchUpdatesClient, error)
+	ReportTelemetry(ctx context.Context, in *TelemetryReport, opts ...grpc.CallOption) (*TelemetryResponse, error)
+	TelemetrySummary(ctx context.Context, in *TelemetrySummaryRequest, opts ...grpc.CallOption) (*TelemetrySummaryResponse, error)
+	RedeemPickupCode(ctx context.Context, in *PickupCodeRequest, opts ...grpc.CallOption) (*PickupCodeResponse, error)
+	EnrollDevice(ctx context.Context, in *EnrollDeviceRequest, opts ...grpc.CallOption) (*EnrollDeviceResponse, error)
+	RevokeDevice(ctx context.Context, in *RevokeDeviceRequest, opts ...grpc.CallOption) (*RevokeDeviceResponse, error)
+	IssueBatch(ctx context.Context, in *BatchCertsRequest, opts ...grpc.CallOption) (*BatchCertsResponse, error)
+	ExplainPolicy(ctx context.Context, in *ExplainPolicyRequest, opts ...grpc.CallOption) (*ExplainPolicyResponse, error)
+}
+
+type geeCertServerClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewGeeCertServerClient(cc *grpc.ClientConn) GeeCertServerClient {
+	return &geeCertServerClient{cc}
+}
+
+func (c *geeCertServerClient) GetSSHCerts(ctx context.Context, in *SSHCertsRequest, opts ...grpc.CallOption) (*SSHCertsResponse, error) {
+	out := new(SSHCertsResponse)
+	err := grpc.Invoke(ctx, "/GeeCertServer/GetSSHCerts", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geeCertServerClient) LookupCert(ctx context.Context, in *LookupCertRequest, opts ...grpc.CallOption) (*LookupCertResponse, error) {
+	out := new(LookupCertResponse)
+	err := grpc.Invoke(ctx, "/GeeCertServer/LookupCert", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geeCertServerClient) ListApprovals(ctx context.Context, in *ListApprovalsRequest, opts ...grpc.CallOption) (*ListApprovalsResponse, error) {
+	out := new(ListApprovalsResponse)
+	err := grpc.Invoke(ctx, "/GeeCertServer/ListApprovals", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geeCertServerClient) DecideApproval(ctx context.Context, in *DecideApprovalRequest, opts ...grpc.CallOption) (*DecideApprovalResponse, error) {
+	out := new(DecideApprovalResponse)
+	err := grpc.Invoke(ctx, "/GeeCertServer/DecideApproval", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geeCertServerClient) BreakGlassCerts(ctx context.Context, in *BreakGlassRequest, opts ...grpc.CallOption) (*SSHCertsResponse, error) {
+	out := new(SSHCertsResponse)
+	err := grpc.Invoke(ctx, "/GeeCertServer/BreakGlassCerts", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geeCertServerClient) WatchUpdates(ctx context.Context, opts ...grpc.CallOption) (GeeCertServer_WatchUpdatesClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_GeeCertServer_serviceDesc.Streams[0], c.cc, "/GeeCertServer/WatchUpdates", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &geeCertServerWatchUpdatesClient{stream}
+	return x, nil
+}
+
+type GeeCertServer_WatchUpdatesClient interface {
+	Send(*WatchRequest) error
+	Recv() (*WatchUpdate, error)
+	grpc.ClientStream
+}
+
+type geeCertServerWatchUpdatesClient struct {
+	grpc.ClientStream
+}
+
+func (x *geeCertServerWatchUpdatesClient) Send(m *WatchRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *geeCertServerWatchUpdatesClient) Recv() (*WatchUpdate, error) {
+	m := new(WatchUpdate)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *geeCertServerClient) ReportTelemetry(ctx context.Context, in *TelemetryReport, opts ...grpc.CallOption) (*TelemetryResponse, error) {
+	out := new(TelemetryResponse)
+	err := grpc.Invoke(ctx, "/GeeCertServer/ReportTelemetry", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geeCertServerClient) TelemetrySummary(ctx context.Context, in *TelemetrySummaryRequest, opts ...grpc.CallOption) (*TelemetrySummaryResponse, error) {
+	out := new(TelemetrySummaryResponse)
+	err := grpc.Invoke(ctx, "/GeeCertServer/TelemetrySummary", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geeCertServerClient) RedeemPickupCode(ctx context.Context, in *PickupCodeRequest, opts ...grpc.CallOption) (*PickupCodeResponse, error) {
+	out := new(PickupCodeResponse)
+	err := grpc.Invoke(ctx, "/GeeCertServer/RedeemPickupCode", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geeCertServerClient) EnrollDevice(ctx context.Context, in *EnrollDeviceRequest, opts ...grpc.CallOption) (*EnrollDeviceResponse, error) {
+	out := new(EnrollDeviceResponse)
+	err := grpc.Invoke(ctx, "/GeeCertServer/EnrollDevice", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geeCertServerClient) RevokeDevice(ctx context.Context, in *RevokeDeviceRequest, opts ...grpc.CallOption) (*RevokeDeviceResponse, error) {
+	out := new(RevokeDeviceResponse)
+	err := grpc.Invoke(ctx, "/GeeCertServer/RevokeDevice", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geeCertServerClient) IssueBatch(ctx context.Context, in *BatchCertsRequest, opts ...grpc.CallOption) (*BatchCertsResponse, error) {
+	out := new(BatchCertsResponse)
+	err := grpc.Invoke(ctx, "/GeeCertServer/IssueBatch", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geeCertServerClient) ExplainPolicy(ctx context.Context, in *ExplainPolicyRequest, opts ...grpc.CallOption) (*ExplainPolicyResponse, error) {
+	out := new(ExplainPolicyResponse)
+	err := grpc.Invoke(ctx, "/GeeCertServer/ExplainPolicy", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for GeeCertServer service
+
+type GeeCertServerServer interface {
+	GetSSHCerts(context.Context, *SSHCertsRequest) (*SSHCertsResponse, error)
+	LookupCert(context.Context, *LookupCertRequest) (*LookupCertResponse, error)
+	ListApprovals(context.Context, *ListApprovalsRequest) (*ListApprovalsResponse, error)
+	DecideApproval(context.Context, *DecideApprovalRequest) (*DecideApprovalResponse, error)
+	BreakGlassCerts(context.Context, *BreakGlassRequest) (*SSHCertsResponse, error)
+	WatchUpdates(GeeCertServer_WatchUpdatesServer) error
+	ReportTelemetry(context.Context, *TelemetryReport) (*TelemetryResponse, error)
+	TelemetrySummary(context.Context, *TelemetrySummaryRequest) (*TelemetrySummaryResponse, error)
+	RedeemPickupCode(context.Context, *PickupCodeRequest) (*PickupCodeResponse, error)
+	EnrollDevice(context.Context, *EnrollDeviceRequest) (*EnrollDeviceResponse, error)
+	RevokeDevice(context.Context, *RevokeDeviceRequest) (*RevokeDeviceResponse, error)
+	IssueBatch(context.Context, *BatchCertsRequest) (*BatchCertsResponse, error)
+	ExplainPolicy(context.Context, *ExplainPolicyRequest) (*ExplainPolicyResponse, error)
+}
+
+func RegisterGeeCertServerServer(s *grpc.Server, srv GeeCertServerServer) {
+	s.RegisterService(&_GeeCertServer_serviceDesc, srv)
+}
+
+func _GeeCertServer_GetSSHCerts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SSHCertsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeeCertServerServer).GetSSHCerts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/GeeCertServer/GetSSHCerts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeeCertServerServer).GetSSHCerts(ctx, req.(*SSHCertsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GeeCertServer_LookupCert_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LookupCertRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeeCertServerServer).LookupCert(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/GeeCertServer/LookupCert",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeeCertServerServer).LookupCert(ctx, req.(*LookupCertRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GeeCertServer_ListApprovals_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListApprovalsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeeCertServerServer).ListApprovals(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/GeeCertServer/ListApprovals",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeeCertServerServer).ListApprovals(ctx, req.(*ListApprovalsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GeeCertServer_DecideApproval_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DecideApprovalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeeCertServerServer).DecideApproval(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/GeeCertServer/DecideApproval",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeeCertServerServer).DecideApproval(ctx, req.(*DecideApprovalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GeeCertServer_BreakGlassCerts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BreakGlassRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeeCertServerServer).BreakGlassCerts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/GeeCertServer/BreakGlassCerts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeeCertServerServer).BreakGlassCerts(ctx, req.(*BreakGlassRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GeeCertServer_WatchUpdates_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(GeeCertServerServer).WatchUpdates(&geeCertServerWatchUpdatesServer{stream})
+}
+
+type GeeCertServer_WatchUpdatesServer interface {
+	Send(*WatchUpdate) error
+	Recv() (*WatchRequest, error)
+	grpc.ServerStream
+}
+
+type geeCertServerWatchUpdatesServer struct {
+	grpc.ServerStream
+}
+
+func (x *geeCertServerWatchUpdatesServer) Send(m *WatchUpdate) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *geeCertServerWatchUpdatesServer) Recv() (*WatchRequest, error) {
+	m := new(WatchRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _GeeCertServer_ReportTelemetry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TelemetryReport)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeeCertServerServer).ReportTelemetry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/GeeCertServer/ReportTelemetry",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeeCertServerServer).ReportTelemetry(ctx, req.(*TelemetryReport))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GeeCertServer_TelemetrySummary_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TelemetrySummaryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeeCertServerServer).TelemetrySummary(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/GeeCertServer/TelemetrySummary",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeeCertServerServer).TelemetrySummary(ctx, req.(*TelemetrySummaryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GeeCertServer_RedeemPickupCode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PickupCodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeeCertServerServer).RedeemPickupCode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/GeeCertServer/RedeemPickupCode",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeeCertServerServer).RedeemPickupCode(ctx, req.(*PickupCodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GeeCertServer_EnrollDevice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EnrollDeviceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeeCertServerServer).EnrollDevice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/GeeCertServer/EnrollDevice",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeeCertServerServer).EnrollDevice(ctx, req.(*EnrollDeviceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GeeCertServer_RevokeDevice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeDeviceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeeCertServerServer).RevokeDevice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/GeeCertServer/RevokeDevice",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeeCertServerServer).RevokeDevice(ctx, req.(*RevokeDeviceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GeeCertServer_IssueBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchCertsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeeCertServerServer).IssueBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/GeeCertServer/IssueBatch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeeCertServerServer).IssueBatch(ctx, req.(*BatchCertsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GeeCertServer_ExplainPolicy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExplainPolicyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeeCertServerServer).ExplainPolicy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/GeeCertServer/ExplainPolicy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeeCertServerServer).ExplainPolicy(ctx, req.(*ExplainPolicyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _GeeCertServer_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "GeeCertServer",
+	HandlerType: (*GeeCertServerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetSSHCerts",
+			Handler:    _GeeCertServer_GetSSHCerts_Handler,
+		},
+		{
+			MethodName: "LookupCert",
+			Handler:    _GeeCertServer_LookupCert_Handler,
+		},
+		{
+			MethodName: "ListApprovals",
+			Handler:    _GeeCertServer_ListApprovals_Handler,
+		},
+		{
+			MethodName: "DecideApproval",
+			Handler:    _GeeCertServer_DecideApproval_Handler,
+		},
+		{
+			MethodName: "BreakGlassCerts",
+			Handler:    _GeeCertServer_BreakGlassCerts_Handler,
+		},
+		{
+			MethodName: "ReportTelemetry",
+			Handler:    _GeeCertServer_ReportTelemetry_Handler,
+		},
+		{
+			MethodName: "TelemetrySummary",
+			Handler:    _GeeCertServer_TelemetrySummary_Handler,
+		},
+		{
+			MethodName: "RedeemPickupCode",
+			Handler:    _GeeCertServer_RedeemPickupCode_Handler,
+		},
+		{
+			MethodName: "EnrollDevice",
+			Handler:    _GeeCertServer_EnrollDevice_Handler,
+		},
+		{
+			MethodName: "RevokeDevice",
+			Handler:    _GeeCertServer_RevokeDevice_Handler,
+		},
+		{
+			MethodName: "IssueBatch",
+			Handler:    _GeeCertServer_IssueBatch_Handler,
+		},
+		{
+			MethodName: "ExplainPolicy",
+			Handler:    _GeeCertServer_ExplainPolicy_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchUpdates",
+			Handler:       _GeeCertServer_WatchUpdates_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "sso.proto",
+}
+
+// Client API for GeeCertServerV2 service
+
+type GeeCertServerV2Client interface {
+	GetSSHCerts(ctx context.Context, in *SSHCertsRequest, opts ...grpc.CallOption) (*SSHCertsResponse, error)
+	LookupCert(ctx context.Context, in *LookupCertRequest, opts ...grpc.CallOption) (*LookupCertResponse, error)
+	ListApprovals(ctx context.Context, in *ListApprovalsRequest, opts ...grpc.CallOption) (*ListApprovalsResponse, error)
+	DecideApproval(ctx context.Context, in *DecideApprovalRequest, opts ...grpc.CallOption) (*DecideApprovalResponse, error)
+	BreakGlassCerts(ctx context.Context, in *BreakGlassRequest, opts ...grpc.CallOption) (*SSHCertsResponse, error)
+	WatchUpdates(ctx context.Context, opts ...grpc.CallOption) (GeeCertServerV2_WatchUpdatesClient, error)
+	ReportTelemetry(ctx context.Context, in *TelemetryReport, opts ...grpc.CallOption) (*TelemetryResponse, error)
+	TelemetrySummary(ctx context.Context, in *TelemetrySummaryRequest, opts ...grpc.CallOption) (*TelemetrySummaryResponse, error)
+	RedeemPickupCode(ctx context.Context, in *PickupCodeRequest, opts ...grpc.CallOption) (*PickupCodeResponse, error)
+	EnrollDevice(ctx context.Context, in *EnrollDeviceRequest, opts ...grpc.CallOption) (*EnrollDeviceResponse, error)
+	RevokeDevice(ctx context.Context, in *RevokeDeviceRequest, opts ...grpc.CallOption) (*RevokeDeviceResponse, error)
+	IssueBatch(ctx context.Context, in *BatchCertsRequest, opts ...grpc.CallOption) (*BatchCertsResponse, error)
+	ExplainPolicy(ctx context.Context, in *ExplainPolicyRequest, opts ...grpc.CallOption) (*ExplainPolicyResponse, error)
+}
+
+type geeCertServerV2Client struct {
+	cc *grpc.ClientConn
+}
+
+func NewGeeCertServerV2Client(cc *grpc.ClientConn) GeeCertServerV2Client {
+	return &geeCertServerV2Client{cc}
+}
+
+func (c *geeCertServerV2Client) GetSSHCerts(ctx context.Context, in *SSHCertsRequest, opts ...grpc.CallOption) (*SSHCertsResponse, error) {
+	out := new(SSHCertsResponse)
+	err := grpc.Invoke(ctx, "/GeeCertServerV2/GetSSHCerts", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geeCertServerV2Client) LookupCert(ctx context.Context, in *LookupCertRequest, opts ...grpc.CallOption) (*LookupCertResponse, error) {
+	out := new(LookupCertResponse)
+	err := grpc.Invoke(ctx, "/GeeCertServerV2/LookupCert", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geeCertServerV2Client) ListApprovals(ctx context.Context, in *ListApprovalsRequest, opts ...grpc.CallOption) (*ListApprovalsResponse, error) {
+	out := new(ListApprovalsResponse)
+	err := grpc.Invoke(ctx, "/GeeCertServerV2/ListApprovals", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geeCertServerV2Client) DecideApproval(ctx context.Context, in *DecideApprovalRequest, opts ...grpc.CallOption) (*DecideApprovalResponse, error) {
+	out := new(DecideApprovalResponse)
+	err := grpc.Invoke(ctx, "/GeeCertServerV2/DecideApproval", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geeCertServerV2Client) BreakGlassCerts(ctx context.Context, in *BreakGlassRequest, opts ...grpc.CallOption) (*SSHCertsResponse, error) {
+	out := new(SSHCertsResponse)
+	err := grpc.Invoke(ctx, "/GeeCertServerV2/BreakGlassCerts", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geeCertServerV2Client) WatchUpdates(ctx context.Context, opts ...grpc.CallOption) (GeeCertServerV2_WatchUpdatesClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_GeeCertServerV2_serviceDesc.Streams[0], c.cc, "/GeeCertServerV2/WatchUpdates", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &geeCertServerV2WatchUpdatesClient{stream}
+	return x, nil
+}
+
+type GeeCertServerV2_WatchUpdatesClient interface {
+	Send(*WatchRequest) error
+	Recv() (*WatchUpdate, error)
+	grpc.ClientStream
+}
+
+type geeCertServerV2WatchUpdatesClient struct {
+	grpc.ClientStream
+}
+
+func (x *geeCertServerV2WatchUpdatesClient) Send(m *WatchRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *geeCertServerV2WatchUpdatesClient) Recv() (*WatchUpdate, error) {
+	m := new(WatchUpdate)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *geeCertServerV2Client) ReportTelemetry(ctx context.Context, in *TelemetryReport, opts ...grpc.CallOption) (*TelemetryResponse, error) {
+	out := new(TelemetryResponse)
+	err := grpc.Invoke(ctx, "/GeeCertServerV2/ReportTelemetry", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geeCertServerV2Client) TelemetrySummary(ctx context.Context, in *TelemetrySummaryRequest, opts ...grpc.CallOption) (*TelemetrySummaryResponse, error) {
+	out := new(TelemetrySummaryResponse)
+	err := grpc.Invoke(ctx, "/GeeCertServerV2/TelemetrySummary", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geeCertServerV2Client) RedeemPickupCode(ctx context.Context, in *PickupCodeRequest, opts ...grpc.CallOption) (*PickupCodeResponse, error) {
+	out := new(PickupCodeResponse)
+	err := grpc.Invoke(ctx, "/GeeCertServerV2/RedeemPickupCode", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geeCertServerV2Client) EnrollDevice(ctx context.Context, in *EnrollDeviceRequest, opts ...grpc.CallOption) (*EnrollDeviceResponse, error) {
+	out := new(EnrollDeviceResponse)
+	err := grpc.Invoke(ctx, "/GeeCertServerV2/EnrollDevice", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geeCertServerV2Client) RevokeDevice(ctx context.Context, in *RevokeDeviceRequest, opts ...grpc.CallOption) (*RevokeDeviceResponse, error) {
+	out := new(RevokeDeviceResponse)
+	err := grpc.Invoke(ctx, "/GeeCertServerV2/RevokeDevice", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geeCertServerV2Client) IssueBatch(ctx context.Context, in *BatchCertsRequest, opts ...grpc.CallOption) (*BatchCertsResponse, error) {
+	out := new(BatchCertsResponse)
+	err := grpc.Invoke(ctx, "/GeeCertServerV2/IssueBatch", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geeCertServerV2Client) ExplainPolicy(ctx context.Context, in *ExplainPolicyRequest, opts ...grpc.CallOption) (*ExplainPolicyResponse, error) {
+	out := new(ExplainPolicyResponse)
+	err := grpc.Invoke(ctx, "/GeeCertServerV2/ExplainPolicy", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for GeeCertServerV2 service
+
+type GeeCertServerV2Server interface {
+	GetSSHCerts(context.Context, *SSHCertsRequest) (*SSHCertsResponse, error)
+	LookupCert(context.Context, *LookupCertRequest) (*LookupCertResponse, error)
+	ListApprovals(context.Context, *ListApprovalsRequest) (*ListApprovalsResponse, error)
+	DecideApproval(context.Context, *DecideApprovalRequest) (*DecideApprovalResponse, error)
+	BreakGlassCerts(context.Context, *BreakGlassRequest) (*SSHCertsResponse, error)
+	WatchUpdates(GeeCertServerV2_WatchUpdatesServer) error
+	ReportTelemetry(context.Context, *TelemetryReport) (*TelemetryResponse, error)
+	TelemetrySummary(context.Context, *TelemetrySummaryRequest) (*TelemetrySummaryResponse, error)
+	RedeemPickupCode(context.Context, *PickupCodeRequest) (*PickupCodeResponse, error)
+	EnrollDevice(context.Context, *EnrollDeviceRequest) (*EnrollDeviceResponse, error)
+	RevokeDevice(context.Context, *RevokeDeviceRequest) (*RevokeDeviceResponse, error)
+	IssueBatch(context.Context, *BatchCertsRequest) (*BatchCertsResponse, error)
+	ExplainPolicy(context.Context, *ExplainPolicyRequest) (*ExplainPolicyResponse, error)
+}
+
+func RegisterGeeCertServerV2Server(s *grpc.Server, srv GeeCertServerV2Server) {
+	s.RegisterService(&_GeeCertServerV2_serviceDesc, srv)
+}
+
+func _GeeCertServerV2_GetSSHCerts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(SSHCertsRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(GeeCertServerServer).GetSSHCerts(ctx, in)
+		return srv.(GeeCertServerV2Server).GetSSHCerts(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/GeeCertServer/GetSSHCerts",
+		FullMethod: "/GeeCertServerV2/GetSSHCerts",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(GeeCertServerServer).GetSSHCerts(ctx, req.(*SSHCertsRequest))
+		return srv.(GeeCertServerV2Server).GetSSHCerts(ctx, req.(*SSHCertsRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-var _GeeCertServer_serviceDesc = grpc.ServiceDesc{
-	ServiceName: "GeeCertServer",
-	HandlerType: (*GeeCertServerServer)(nil),
+func _GeeCertServerV2_LookupCert_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LookupCertRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeeCertServerV2Server).LookupCert(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/GeeCertServerV2/LookupCert",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeeCertServerV2Server).LookupCert(ctx, req.(*LookupCertRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GeeCertServerV2_ListApprovals_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListApprovalsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeeCertServerV2Server).ListApprovals(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/GeeCertServerV2/ListApprovals",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeeCertServerV2Server).ListApprovals(ctx, req.(*ListApprovalsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GeeCertServerV2_DecideApproval_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DecideApprovalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeeCertServerV2Server).DecideApproval(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/GeeCertServerV2/DecideApproval",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeeCertServerV2Server).DecideApproval(ctx, req.(*DecideApprovalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GeeCertServerV2_BreakGlassCerts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BreakGlassRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeeCertServerV2Server).BreakGlassCerts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/GeeCertServerV2/BreakGlassCerts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeeCertServerV2Server).BreakGlassCerts(ctx, req.(*BreakGlassRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GeeCertServerV2_WatchUpdates_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(GeeCertServerV2Server).WatchUpdates(&geeCertServerV2WatchUpdatesServer{stream})
+}
+
+type GeeCertServerV2_WatchUpdatesServer interface {
+	Send(*WatchUpdate) error
+	Recv() (*WatchRequest, error)
+	grpc.ServerStream
+}
+
+type geeCertServerV2WatchUpdatesServer struct {
+	grpc.ServerStream
+}
+
+func (x *geeCertServerV2WatchUpdatesServer) Send(m *WatchUpdate) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *geeCertServerV2WatchUpdatesServer) Recv() (*WatchRequest, error) {
+	m := new(WatchRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _GeeCertServerV2_ReportTelemetry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TelemetryReport)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeeCertServerV2Server).ReportTelemetry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/GeeCertServerV2/ReportTelemetry",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeeCertServerV2Server).ReportTelemetry(ctx, req.(*TelemetryReport))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GeeCertServerV2_TelemetrySummary_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TelemetrySummaryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeeCertServerV2Server).TelemetrySummary(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/GeeCertServerV2/TelemetrySummary",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeeCertServerV2Server).TelemetrySummary(ctx, req.(*TelemetrySummaryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GeeCertServerV2_RedeemPickupCode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PickupCodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeeCertServerV2Server).RedeemPickupCode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/GeeCertServerV2/RedeemPickupCode",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeeCertServerV2Server).RedeemPickupCode(ctx, req.(*PickupCodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GeeCertServerV2_EnrollDevice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EnrollDeviceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeeCertServerV2Server).EnrollDevice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/GeeCertServerV2/EnrollDevice",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeeCertServerV2Server).EnrollDevice(ctx, req.(*EnrollDeviceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GeeCertServerV2_RevokeDevice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeDeviceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeeCertServerV2Server).RevokeDevice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/GeeCertServerV2/RevokeDevice",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeeCertServerV2Server).RevokeDevice(ctx, req.(*RevokeDeviceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GeeCertServerV2_IssueBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchCertsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeeCertServerV2Server).IssueBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/GeeCertServerV2/IssueBatch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeeCertServerV2Server).IssueBatch(ctx, req.(*BatchCertsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GeeCertServerV2_ExplainPolicy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExplainPolicyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeeCertServerV2Server).ExplainPolicy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/GeeCertServerV2/ExplainPolicy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeeCertServerV2Server).ExplainPolicy(ctx, req.(*ExplainPolicyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _GeeCertServerV2_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "GeeCertServerV2",
+	HandlerType: (*GeeCertServerV2Server)(nil),
 	Methods: []grpc.MethodDesc{
 		{
 			MethodName: "GetSSHCerts",
-			Handler:    _GeeCertServer_GetSSHCerts_Handler,
+			Handler:    _GeeCertServerV2_GetSSHCerts_Handler,
+		},
+		{
+			MethodName: "LookupCert",
+			Handler:    _GeeCertServerV2_LookupCert_Handler,
+		},
+		{
+			MethodName: "ListApprovals",
+			Handler:    _GeeCertServerV2_ListApprovals_Handler,
+		},
+		{
+			MethodName: "DecideApproval",
+			Handler:    _GeeCertServerV2_DecideApproval_Handler,
+		},
+		{
+			MethodName: "BreakGlassCerts",
+			Handler:    _GeeCertServerV2_BreakGlassCerts_Handler,
+		},
+		{
+			MethodName: "ReportTelemetry",
+			Handler:    _GeeCertServerV2_ReportTelemetry_Handler,
+		},
+		{
+			MethodName: "TelemetrySummary",
+			Handler:    _GeeCertServerV2_TelemetrySummary_Handler,
+		},
+		{
+			MethodName: "RedeemPickupCode",
+			Handler:    _GeeCertServerV2_RedeemPickupCode_Handler,
+		},
+		{
+			MethodName: "EnrollDevice",
+			Handler:    _GeeCertServerV2_EnrollDevice_Handler,
+		},
+		{
+			MethodName: "RevokeDevice",
+			Handler:    _GeeCertServerV2_RevokeDevice_Handler,
+		},
+		{
+			MethodName: "IssueBatch",
+			Handler:    _GeeCertServerV2_IssueBatch_Handler,
+		},
+		{
+			MethodName: "ExplainPolicy",
+			Handler:    _GeeCertServerV2_ExplainPolicy_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchUpdates",
+			Handler:       _GeeCertServerV2_WatchUpdates_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "sso.proto",
 }
 
 func init() { proto.RegisterFile("sso.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 758 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x74, 0x54, 0xdd, 0x6e, 0x1a, 0x47,
-	0x14, 0x0e, 0x76, 0x4d, 0xcc, 0x59, 0x63, 0xd6, 0x53, 0x2b, 0xd9, 0x10, 0x95, 0xb8, 0x54, 0xad,
-	0xdc, 0x4a, 0xdd, 0x0b, 0x5a, 0xa9, 0x55, 0xd5, 0x1b, 0xb2, 0x90, 0x04, 0x81, 0x8c, 0x05, 0xee,
-	0xdf, 0xd5, 0x68, 0xb2, 0x7b, 0x08, 0xa3, 0x2c, 0x33, 0xdb, 0x99, 0x21, 0x2d, 0x0f, 0xd1, 0x37,
-	0xe9, 0xcb, 0xf5, 0x0d, 0xaa, 0x99, 0xd9, 0x85, 0x75, 0x9a, 0xde, 0x31, 0xdf, 0xf9, 0x38, 0x7f,
-	0xdf, 0xb7, 0x07, 0x5a, 0x5a, 0xcb, 0xb8, 0x50, 0xd2, 0xc8, 0xfe, 0x14, 0x3a, 0xcb, 0xe5, 0xab,
-	0x04, 0x95, 0xd1, 0x0b, 0xfc, 0x7d, 0x8b, 0xda, 0x90, 0x27, 0x70, 0xca, 0x33, 0x6a, 0xe4, 0x5b,
-	0x14, 0x51, 0xe3, 0xaa, 0x71, 0xdd, 0x5a, 0x3c, 0xe4, 0xd9, 0x9d, 0x7d, 0x92, 0x4f, 0x00, 0x8a,
-	0xed, 0xeb, 0x9c, 0xa7, 0xf4, 0x2d, 0xee, 0xa2, 0x23, 0x17, 0x6c, 0x79, 0x64, 0x8a, 0xbb, 0xfe,
-	0xdf, 0x0d, 0x08, 0x0f, 0xd9, 0x74, 0x21, 0x85, 0x46, 0xf2, 0x39, 0x34, 0xb5, 0x61, 0x66, 0xab,
-	0x5d, 0xb2, 0xf3, 0x41, 0x3b, 0xae, 0x42, 0x89, 0xcc, 0x70, 0x51, 0x06, 0xc9, 0x15, 0x04, 0x29,
-	0x2a, 0xc3, 0x57, 0x3c, 0x65, 0x06, 0xcb, 0xdc, 0x75, 0x88, 0x7c, 0x07, 0x8f, 0x6b, 0x4f, 0xca,
-	0xb6, 0x66, 0x2d, 0x15, 0x37, 0x1c, 0x75, 0x74, 0x7c, 0x75, 0x7c, 0xdd, 0x5a, 0x3c, 0xaa, 0x85,
-	0x87, 0x87, 0x28, 0x79, 0x04, 0xcd, 0x54, 0x8a, 0x15, 0x7f, 0x13, 0x7d, 0xe4, 0x78, 0xe5, 0xab,
-	0xff, 0xd7, 0x29, 0x9c, 0x2d, 0x51, 0xbd, 0x43, 0x95, 0x38, 0x80, 0xf4, 0x20, 0x48, 0x99, 0x1d,
-	0x8d, 0x16, 0xcc, 0xac, 0xcb, 0xe1, 0x5b, 0x29, 0x9b, 0xe2, 0xee, 0x96, 0x99, 0x35, 0x49, 0xa0,
-	0xf7, 0x06, 0x05, 0x2a, 0x5b, 0xde, 0xd6, 0xa2, 0xd9, 0x56, 0x31, 0xc3, 0xa5, 0xa0, 0x1a, 0x53,
-	0x29, 0x32, 0xed, 0xda, 0x3e, 0x59, 0x3c, 0xad, 0x58, 0x76, 0x13, 0xa3, 0x92, 0xb3, 0xf4, 0x14,
-	0x12, 0xc3, 0xc7, 0x69, 0xce, 0x51, 0x18, 0xea, 0xdb, 0xa0, 0x3a, 0x95, 0x05, 0x46, 0xc7, 0xae,
-	0xd8, 0x85, 0x0f, 0xf9, 0x7e, 0x96, 0x36, 0x40, 0x46, 0xd0, 0x66, 0x79, 0x2e, 0xff, 0xc0, 0x8c,
-	0x6e, 0x35, 0x2a, 0xed, 0x86, 0x08, 0x06, 0xcf, 0xe2, 0x7a, 0xeb, 0xf1, 0xd0, 0x53, 0x7e, 0xb2,
-	0x8c, 0xb1, 0x30, 0x6a, 0xb7, 0x38, 0x63, 0x35, 0x88, 0x3c, 0x83, 0x20, 0xe7, 0xda, 0xa0, 0xa0,
-	0x85, 0x54, 0x26, 0x3a, 0x71, 0x7d, 0x82, 0x87, 0x6e, 0xa5, 0x32, 0xe4, 0x47, 0x78, 0x5a, 0x95,
-	0xc9, 0xe4, 0x86, 0x71, 0x41, 0x57, 0x52, 0xd1, 0xbd, 0x11, 0x9a, 0xae, 0xbd, 0xc7, 0x25, 0x65,
-	0xe4, 0x18, 0x2f, 0xa4, 0x9a, 0x94, 0xc6, 0x18, 0x42, 0xaf, 0xfa, 0x77, 0x39, 0x1c, 0xcf, 0xee,
-	0x27, 0x78, 0xe8, 0x12, 0x3c, 0x29, 0x59, 0x89, 0x23, 0x4d, 0xb2, 0x5a, 0x8a, 0x6b, 0x08, 0xb5,
-	0x9b, 0xc8, 0xaf, 0xd6, 0x29, 0x70, 0xea, 0xfe, 0x74, 0xee, 0x71, 0xbb, 0x4c, 0x27, 0xc3, 0x17,
-	0xd0, 0x29, 0x99, 0x7b, 0xa9, 0x5a, 0x8e, 0xd8, 0xf6, 0x70, 0x25, 0xd7, 0x04, 0x3e, 0x65, 0x59,
-	0xc6, 0xed, 0xf2, 0x59, 0x4e, 0xb5, 0x5e, 0x97, 0x1b, 0xaf, 0x44, 0xcb, 0xb9, 0xc0, 0x08, 0x9c,
-	0x25, 0x7a, 0x07, 0xe2, 0x52, 0xaf, 0x93, 0x3a, 0x6d, 0xc6, 0x05, 0x5a, 0xe3, 0xa7, 0x8c, 0xa6,
-	0x72, 0xb3, 0x41, 0x61, 0xa2, 0xa0, 0x32, 0x46, 0xe2, 0x01, 0xdb, 0xfb, 0xda, 0x98, 0x82, 0xd6,
-	0x57, 0x7c, 0xe6, 0x56, 0x7c, 0x6e, 0xf1, 0xd9, 0x61, 0xcd, 0x9f, 0x1d, 0xd4, 0x5c, 0x4b, 0x6d,
-	0x74, 0xd4, 0x76, 0xf5, 0x2b, 0xb1, 0x5e, 0x59, 0xcc, 0x0e, 0x98, 0xb2, 0x2c, 0xdb, 0xd1, 0x15,
-	0xcf, 0xd1, 0x0f, 0x78, 0xee, 0x07, 0x74, 0xf0, 0x0b, 0x9e, 0xa3, 0x1d, 0xb0, 0xfb, 0x4f, 0x03,
-	0xc0, 0xca, 0x5b, 0xda, 0xb7, 0x0b, 0xa7, 0xd6, 0x21, 0x82, 0x6d, 0xb0, 0xf4, 0xee, 0xfe, 0x4d,
-	0xbe, 0x84, 0x10, 0xff, 0x34, 0x8a, 0xd1, 0x42, 0x71, 0x91, 0xf2, 0x82, 0xe5, 0xd6, 0xac, 0xb6,
-	0x74, 0xc7, 0xe1, 0xb7, 0x7b, 0x98, 0xfc, 0x0a, 0xa1, 0x57, 0x00, 0xd5, 0x86, 0x6b, 0xcd, 0xa5,
-	0xf0, 0x1f, 0x58, 0x30, 0xf8, 0xfa, 0xbe, 0xe7, 0x0e, 0xa5, 0x63, 0xa7, 0xcd, 0x81, 0xef, 0x1d,
-	0xd8, 0x49, 0xef, 0xa3, 0xdd, 0xe7, 0x70, 0xf9, 0x21, 0x22, 0x09, 0xe1, 0xd8, 0xde, 0x13, 0xdf,
-	0xb3, 0xfd, 0x49, 0x2e, 0xe1, 0xe4, 0x1d, 0xcb, 0xb7, 0xd5, 0x1d, 0xf0, 0x8f, 0x1f, 0x8e, 0xbe,
-	0x6f, 0x74, 0x7f, 0x83, 0x8b, 0xff, 0x78, 0xfd, 0x03, 0x09, 0xe2, 0x7a, 0x82, 0x60, 0x10, 0xfd,
-	0x5f, 0xe7, 0xb5, 0xd4, 0x5f, 0x3d, 0x87, 0xb3, 0xfa, 0x69, 0x22, 0x4d, 0x38, 0x9a, 0x4f, 0xc3,
-	0x07, 0xe4, 0x12, 0xc2, 0xc9, 0xcd, 0xcf, 0xc3, 0xd9, 0x64, 0x44, 0x27, 0x23, 0x7a, 0x37, 0x9f,
-	0x8e, 0x6f, 0xc2, 0x86, 0x45, 0x6f, 0xe6, 0x34, 0x19, 0x2f, 0xee, 0x96, 0x74, 0x38, 0x9b, 0xcd,
-	0x7f, 0x19, 0x8f, 0xc2, 0xa3, 0xc1, 0x18, 0xda, 0x2f, 0xd1, 0x7d, 0xf7, 0xbe, 0x20, 0xf9, 0x16,
-	0x82, 0x97, 0x68, 0xaa, 0xab, 0x48, 0xc2, 0xf8, 0xbd, 0x73, 0xdb, 0xbd, 0x88, 0xdf, 0x3f, 0x99,
-	0xfd, 0x07, 0xaf, 0x9b, 0xee, 0x3a, 0x7f, 0xf3, 0x6f, 0x00, 0x00, 0x00, 0xff, 0xff, 0x62, 0x8b,
-	0xff, 0xb7, 0xaa, 0x05, 0x00, 0x00,
+	// 5848 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xec, 0x7c, 0x49, 0x6c, 0x23, 0x49,
+	0x76, 0x68, 0x91, 0xd4, 0x42, 0x3d, 0x71, 0x53, 0x68, 0xa9, 0x2c, 0x56, 0x75, 0x77, 0x15, 0x7b,
+	0xab, 0xea, 0x85, 0xd3, 0x5d, 0xd3, 0xfd, 0x7b, 0xf9, 0xd3, 0xd3, 0x43, 0x51, 0xac, 0x2a, 0xb6,
+	0x16, 0xaa, 0x53, 0x54, 0xf5, 0xcc, 0xf8, 0x90, 0x48, 0x65, 0x86, 0xa8, 0x6c, 0x25, 0x33, 0xe9,
+	0xc8, 0xa4, 0x24, 0x0e, 0x60, 0xc0, 0x30, 0x0c, 0xf8, 0x32, 0xc0, 0x5c, 0xc6, 0xf0, 0xc1, 0x03,
+	0x18, 0xf6, 0xc5, 0x30, 0xe0, 0x93, 0xed, 0x83, 0x01, 0xc3, 0x37, 0x2f, 0x27, 0x5f, 0x7c, 0x35,
+	0x7c, 0xf2, 0xd5, 0x80, 0x0d, 0xf8, 0xe4, 0x9b, 0xf1, 0x62, 0xc9, 0x85, 0x4c, 0x95, 0xa4, 0xe9,
+	0x69, 0xc3, 0x87, 0xb9, 0x31, 0xde, 0x7b, 0x19, 0x19, 0xf1, 0xb6, 0x78, 0xef, 0xc5, 0x4b, 0xc2,
+	0x52, 0x10, 0xf8, 0xcd, 0x11, 0xf3, 0x43, 0xbf, 0xf1, 0x1f, 0x39, 0x58, 0xee, 0x30, 0xe6, 0xb3,
+	0x2d, 0x1a, 0x9a, 0x8e, 0x4b, 0x5e, 0x83, 0x05, 0x46, 0xcd, 0xc0, 0xf7, 0xb4, 0xdc, 0xfd, 0xdc,
+	0xc3, 0xca, 0xe3, 0x52, 0x93, 0x63, 0x75, 0x0e, 0xd3, 0x25, 0x8e, 0xbc, 0x0e, 0x0b, 0x41, 0x68,
+	0x86, 0xe3, 0x40, 0xcb, 0x73, 0xaa, 0x72, 0x53, 0xa7, 0xc1, 0xc8, 0xf7, 0x02, 0xda, 0xf6, 0x6d,
+	0xaa, 0x4b, 0x24, 0xb9, 0x0f, 0xcb, 0x8c, 0x0e, 0xa9, 0xed, 0x98, 0xa1, 0xe3, 0x7b, 0x5a, 0xe1,
+	0x7e, 0xee, 0xe1, 0x92, 0x9e, 0x04, 0x91, 0xef, 0xc0, 0xda, 0xd0, 0xbc, 0x30, 0xcc, 0x71, 0x78,
+	0x62, 0x98, 0x03, 0x6a, 0x04, 0xd4, 0xf2, 0x3d, 0x3b, 0xd0, 0xe6, 0xee, 0xe7, 0x1e, 0xce, 0xeb,
+	0x2b, 0x43, 0xf3, 0xa2, 0x35, 0x0e, 0x4f, 0x5a, 0x03, 0x7a, 0x20, 0x10, 0xe4, 0x15, 0x58, 0x36,
+	0x47, 0x23, 0xe6, 0x9f, 0x99, 0xae, 0xe1, 0xd8, 0xda, 0x3c, 0x9f, 0x12, 0x14, 0xa8, 0x6b, 0x23,
+	0xc1, 0x78, 0x34, 0x60, 0xa6, 0x4d, 0x8d, 0x31, 0x73, 0xb5, 0x05, 0x41, 0x20, 0x41, 0x87, 0xcc,
+	0x6d, 0xfc, 0x77, 0x01, 0xaa, 0x07, 0x07, 0xcf, 0xda, 0x94, 0x85, 0x81, 0x4e, 0x7f, 0x73, 0x4c,
+	0x83, 0x90, 0xdc, 0x81, 0xa2, 0x63, 0x1b, 0xa1, 0x7f, 0x4a, 0xc5, 0xbe, 0x97, 0xf4, 0x45, 0xc7,
+	0xee, 0xe3, 0x90, 0x7c, 0x0c, 0x55, 0x8b, 0x51, 0x9b, 0x7a, 0xa1, 0x63, 0xba, 0x46, 0x38, 0x19,
+	0x51, 0x3e, 0x67, 0xe5, 0x71, 0xb5, 0xd9, 0x8e, 0xe0, 0xfd, 0xc9, 0x88, 0xea, 0x15, 0x2b, 0x35,
+	0x26, 0x2f, 0x01, 0x8c, 0xc6, 0x47, 0xae, 0x63, 0x19, 0xa7, 0x74, 0xc2, 0x19, 0xb5, 0xa4, 0x2f,
+	0x09, 0xc8, 0x36, 0x9d, 0x4c, 0xef, 0xa4, 0x30, 0xb3, 0x93, 0x8d, 0x48, 0x14, 0x73, 0x1c, 0x17,
+	0x33, 0xbf, 0x12, 0xf8, 0x63, 0x66, 0x51, 0xc3, 0xb4, 0x6d, 0x46, 0x83, 0x40, 0x72, 0xa1, 0x2c,
+	0xa0, 0x2d, 0x01, 0x24, 0xef, 0xc3, 0x1a, 0xa3, 0x23, 0xd7, 0xb4, 0x68, 0x60, 0x1c, 0x3b, 0xde,
+	0x80, 0xb2, 0x11, 0x73, 0xbc, 0x50, 0x5b, 0xe4, 0xc4, 0xab, 0x0a, 0xf7, 0x24, 0x46, 0x91, 0xbb,
+	0xb0, 0x64, 0xd3, 0x33, 0xc7, 0xa2, 0xb8, 0xa0, 0x22, 0xa7, 0x2b, 0x0a, 0x40, 0xd7, 0x26, 0x8f,
+	0xa0, 0x26, 0x91, 0x81, 0x33, 0xf0, 0xcc, 0x70, 0xcc, 0xa8, 0xb6, 0xc4, 0x69, 0xaa, 0x02, 0x7e,
+	0xa0, 0xc0, 0xb8, 0x35, 0xcb, 0xf7, 0x8e, 0x9d, 0x81, 0x71, 0x62, 0x06, 0x27, 0x1a, 0x88, 0xad,
+	0x09, 0xd0, 0x33, 0x33, 0x38, 0x21, 0xf7, 0xa1, 0xe4, 0x7b, 0xc6, 0x11, 0x3d, 0x31, 0xdd, 0x63,
+	0xc3, 0x3f, 0xd6, 0x96, 0x05, 0x85, 0xef, 0x6d, 0x72, 0x50, 0xef, 0x98, 0x7c, 0x08, 0x15, 0xd3,
+	0xb2, 0x68, 0x10, 0x18, 0x4c, 0xc8, 0x48, 0x2b, 0xdd, 0xcf, 0x3d, 0x5c, 0x7e, 0x5c, 0x69, 0xb6,
+	0x38, 0x58, 0x4a, 0x4e, 0x2f, 0x9b, 0xc9, 0x21, 0xf2, 0x5c, 0xd2, 0xe3, 0x16, 0xca, 0x82, 0xe7,
+	0x12, 0xd2, 0xb5, 0x1b, 0x3f, 0xcd, 0x41, 0x39, 0xf5, 0x3c, 0x21, 0x30, 0xc7, 0x7c, 0x97, 0x4a,
+	0xa9, 0xf3, 0xdf, 0x7c, 0xa7, 0x63, 0xc6, 0x15, 0x34, 0x52, 0xc8, 0x3c, 0x57, 0xc8, 0xaa, 0x82,
+	0x2b, 0x75, 0xdc, 0x80, 0x85, 0xd0, 0xb1, 0x4e, 0x69, 0x28, 0xe5, 0x27, 0x47, 0xe4, 0x35, 0x28,
+	0x7f, 0x3d, 0x0e, 0x42, 0xe7, 0xd8, 0xb1, 0x84, 0xee, 0x0b, 0x11, 0xa6, 0x81, 0x8d, 0x7f, 0x5a,
+	0x80, 0x5a, 0xac, 0x8a, 0xc2, 0x80, 0x12, 0xb6, 0x95, 0xbb, 0xc2, 0xb6, 0x2c, 0xca, 0xe4, 0x64,
+	0x54, 0xaa, 0x57, 0x12, 0x44, 0x3e, 0x82, 0xdb, 0x89, 0x21, 0xb7, 0x31, 0x9f, 0x39, 0xa1, 0x43,
+	0x03, 0xad, 0x70, 0xbf, 0xf0, 0x70, 0x49, 0xdf, 0x48, 0xa0, 0x5b, 0x31, 0x16, 0x37, 0x25, 0x64,
+	0xa5, 0xcd, 0x71, 0x3a, 0x39, 0x22, 0x1f, 0x40, 0x59, 0x8a, 0xf5, 0xc8, 0xf5, 0xad, 0x53, 0xd4,
+	0xbb, 0xc2, 0xc3, 0xe5, 0xc7, 0xd5, 0x26, 0xee, 0x81, 0x23, 0x36, 0x11, 0xae, 0x97, 0xac, 0x78,
+	0x10, 0x90, 0x2f, 0xa1, 0x26, 0x9f, 0x3a, 0x33, 0x99, 0x63, 0x1e, 0xb9, 0x34, 0xd0, 0x16, 0xf8,
+	0x83, 0x6f, 0x34, 0xa7, 0x37, 0xdf, 0x14, 0xd3, 0x3c, 0x57, 0x84, 0x1d, 0x2f, 0x64, 0x13, 0xbd,
+	0x6a, 0xa5, 0xa1, 0xe4, 0x13, 0xa8, 0x1d, 0x99, 0x01, 0x97, 0xcf, 0xc8, 0x77, 0x1d, 0x0b, 0xb7,
+	0xb4, 0xc8, 0xa7, 0xac, 0x34, 0x37, 0x05, 0x62, 0x1f, 0xe1, 0x13, 0xbd, 0x7a, 0x94, 0x18, 0xe2,
+	0xde, 0x2e, 0x73, 0x38, 0xc5, 0x6b, 0x3a, 0x9c, 0xa5, 0x19, 0x33, 0xfd, 0x01, 0x10, 0x46, 0x4d,
+	0x77, 0x68, 0x24, 0xb8, 0x19, 0x68, 0xc0, 0x97, 0xb3, 0xd2, 0xd4, 0x11, 0xd5, 0x8e, 0x31, 0xfa,
+	0x0a, 0x9b, 0x82, 0xa0, 0xa5, 0x82, 0xed, 0x30, 0x6a, 0x85, 0xce, 0x19, 0x0d, 0xb8, 0x2d, 0xe0,
+	0x93, 0x6d, 0xd7, 0xa1, 0x5e, 0xb8, 0x15, 0x21, 0xf4, 0x04, 0xd1, 0xb4, 0x85, 0x95, 0x66, 0x2c,
+	0xec, 0x51, 0xc4, 0xf5, 0xb1, 0x67, 0x9d, 0x98, 0xde, 0x80, 0x0a, 0x73, 0x28, 0x2a, 0x6e, 0x1e,
+	0x2a, 0x30, 0xf9, 0x0d, 0xb8, 0x77, 0xe2, 0x07, 0xa1, 0x71, 0x99, 0xb2, 0x54, 0xf8, 0x56, 0xee,
+	0x34, 0x9f, 0xf9, 0x41, 0xd8, 0x9e, 0xd5, 0x98, 0x89, 0x5e, 0x3f, 0xc9, 0xc6, 0x20, 0xbf, 0xdf,
+	0x84, 0xaa, 0xe9, 0xba, 0xfe, 0x39, 0xb5, 0xb9, 0xdb, 0xa0, 0x2c, 0xd0, 0xaa, 0x5c, 0xa9, 0x2a,
+	0x12, 0x7c, 0x20, 0xa0, 0xf5, 0x4d, 0x58, 0xcb, 0x12, 0x3e, 0xa9, 0x41, 0x01, 0xdd, 0xa7, 0xb0,
+	0x4f, 0xfc, 0x49, 0xd6, 0x60, 0xfe, 0xcc, 0x74, 0xc7, 0x4a, 0xe7, 0xc5, 0xe0, 0xd3, 0xfc, 0xc7,
+	0xb9, 0xc6, 0xdf, 0xe7, 0xa0, 0x36, 0xcd, 0x36, 0xf2, 0x1e, 0xfa, 0x41, 0x8f, 0x9e, 0x1b, 0x47,
+	0xf4, 0xd8, 0x67, 0xb1, 0xc4, 0x73, 0x5c, 0xe2, 0x84, 0xe3, 0x36, 0x39, 0x4a, 0x89, 0xfc, 0x1d,
+	0x20, 0x43, 0xc7, 0x33, 0x2c, 0x3e, 0x93, 0x71, 0x46, 0x59, 0x80, 0x16, 0x2c, 0xde, 0x56, 0x1b,
+	0x3a, 0x9e, 0x78, 0xc5, 0x73, 0x01, 0x47, 0x97, 0x63, 0x0e, 0x90, 0xd0, 0xf7, 0xdc, 0x09, 0x77,
+	0x03, 0x45, 0x7d, 0x89, 0x43, 0x7a, 0x9e, 0x3b, 0x21, 0x8f, 0x61, 0xdd, 0xf3, 0x43, 0xe7, 0x78,
+	0x32, 0xfd, 0x7e, 0x71, 0xc4, 0xad, 0x0a, 0x64, 0x6a, 0x01, 0x8d, 0x9f, 0xe7, 0xa1, 0x36, 0xad,
+	0x38, 0xe8, 0xa9, 0x3c, 0x73, 0x18, 0x79, 0x2a, 0xfc, 0xfd, 0x6d, 0x3a, 0x81, 0x19, 0x63, 0x9f,
+	0xbb, 0x8e, 0xb1, 0x5f, 0xa5, 0x4b, 0xf3, 0xdf, 0x40, 0x97, 0x1a, 0x3d, 0x28, 0xa7, 0xac, 0x9b,
+	0x3c, 0x80, 0x12, 0x7f, 0xdb, 0xc8, 0x0c, 0x43, 0xca, 0xf0, 0xe8, 0xc6, 0x1d, 0x2d, 0x23, 0x6c,
+	0x5f, 0x80, 0xf0, 0x48, 0xfb, 0x7a, 0x3c, 0x1c, 0x19, 0x08, 0xd3, 0xf2, 0x1c, 0x5f, 0x44, 0x00,
+	0x2e, 0xa0, 0xf1, 0x9f, 0x39, 0xa8, 0xa4, 0xb7, 0x73, 0x9d, 0x29, 0xd7, 0x60, 0x7e, 0x68, 0x86,
+	0xd6, 0x89, 0xd2, 0x3f, 0x3e, 0x40, 0xf1, 0x8c, 0x03, 0xca, 0xe4, 0x39, 0xc0, 0x7f, 0xa3, 0xf2,
+	0x8f, 0x03, 0x9a, 0x64, 0x06, 0x97, 0x7a, 0x51, 0xaf, 0x8c, 0x03, 0x9a, 0x94, 0x6d, 0x13, 0x16,
+	0xfc, 0x11, 0x3f, 0x27, 0x04, 0x83, 0x36, 0xa6, 0xb8, 0xdc, 0xec, 0x71, 0xac, 0x2e, 0xa9, 0xea,
+	0x1f, 0xc3, 0x82, 0x80, 0x10, 0x0d, 0x16, 0x4f, 0xe9, 0xe4, 0xdc, 0x67, 0xb6, 0x0a, 0x5c, 0xe4,
+	0x30, 0xdb, 0x4c, 0x1a, 0x67, 0xa0, 0x5d, 0xc6, 0xfb, 0xeb, 0xec, 0xfd, 0x8a, 0x98, 0x46, 0x83,
+	0x45, 0xcb, 0x1f, 0x0e, 0xa9, 0xa7, 0xce, 0x43, 0x35, 0x6c, 0xfc, 0x49, 0x0e, 0x56, 0x76, 0x7c,
+	0xff, 0x74, 0x3c, 0xc2, 0x57, 0xff, 0x72, 0x71, 0xd7, 0xdc, 0xf5, 0xe2, 0xae, 0x0d, 0x58, 0x08,
+	0x28, 0x73, 0x4c, 0x97, 0xaf, 0x6f, 0x4e, 0x97, 0x23, 0x34, 0x96, 0x64, 0x1c, 0x24, 0xa3, 0xd1,
+	0x04, 0xa8, 0xf1, 0x8b, 0x3c, 0xd4, 0xba, 0x41, 0x30, 0xa6, 0xb6, 0x58, 0xa4, 0x85, 0x7c, 0x8c,
+	0xa7, 0xcb, 0xa5, 0xa6, 0x5b, 0x83, 0x79, 0x3a, 0x34, 0x1d, 0x57, 0xf1, 0x97, 0x0f, 0xc8, 0x3a,
+	0x2c, 0x9c, 0xd2, 0x49, 0x1c, 0xd0, 0xcd, 0x9f, 0xd2, 0x49, 0xd7, 0x26, 0x2f, 0x03, 0xe0, 0x2b,
+	0x2c, 0x67, 0x64, 0xba, 0x81, 0x3c, 0x56, 0x13, 0x90, 0xe9, 0xb5, 0xcd, 0xcf, 0xac, 0x0d, 0x3d,
+	0xfe, 0x99, 0xe9, 0x3a, 0xb6, 0x61, 0x1e, 0x87, 0x94, 0xf1, 0x18, 0xb4, 0xa0, 0x03, 0x07, 0xb5,
+	0x10, 0x82, 0xd2, 0x13, 0x04, 0xc2, 0xcf, 0xf0, 0x38, 0xaf, 0xa0, 0x8b, 0x87, 0x84, 0x7b, 0x79,
+	0x71, 0x7c, 0x37, 0x1d, 0x93, 0x2d, 0x4d, 0xc7, 0x64, 0x0d, 0x1b, 0x48, 0x52, 0x84, 0x37, 0x8b,
+	0x57, 0xde, 0x84, 0x79, 0xb4, 0x83, 0x80, 0x1b, 0x21, 0x9e, 0x6f, 0xd3, 0x8c, 0xd6, 0x05, 0xbe,
+	0x71, 0x0a, 0x6b, 0x3b, 0x4e, 0x10, 0xb6, 0xe4, 0x09, 0xfb, 0x4b, 0xc6, 0xe8, 0xf9, 0x6b, 0xe9,
+	0x4a, 0xe3, 0x6f, 0x73, 0x50, 0x51, 0x6f, 0x92, 0xf2, 0xae, 0x40, 0xde, 0x51, 0xc6, 0x94, 0x77,
+	0xec, 0x4b, 0xe4, 0x9c, 0x16, 0x68, 0xe1, 0x2a, 0x81, 0xce, 0xcd, 0x0a, 0xf4, 0x01, 0x94, 0x64,
+	0x60, 0x4a, 0x6d, 0xc3, 0x14, 0x32, 0x2f, 0xe8, 0xcb, 0x11, 0xac, 0x15, 0xce, 0x88, 0x64, 0x61,
+	0x46, 0x24, 0x43, 0x58, 0x9f, 0x62, 0xd6, 0xcd, 0xa4, 0xf2, 0x2e, 0x2c, 0xa9, 0x50, 0x46, 0x49,
+	0xa6, 0xda, 0x4c, 0x33, 0x44, 0x8f, 0x29, 0x1a, 0x7f, 0x9a, 0x83, 0xf5, 0x2d, 0x6a, 0x39, 0x36,
+	0x8d, 0x69, 0xbe, 0x45, 0x4b, 0x9e, 0x8a, 0xbd, 0xf2, 0x33, 0xb1, 0x97, 0x06, 0x8b, 0x62, 0x44,
+	0xe5, 0xc1, 0xab, 0x86, 0x8d, 0xcf, 0x61, 0x63, 0x7a, 0xa1, 0x37, 0xe2, 0x4c, 0xc3, 0x82, 0xd2,
+	0x57, 0xe8, 0xd8, 0xbf, 0x55, 0xf5, 0xfb, 0xd9, 0x3c, 0x2c, 0xf3, 0xb7, 0x1c, 0x8e, 0x6c, 0x33,
+	0xbc, 0xee, 0xda, 0x5e, 0x74, 0xa8, 0xe7, 0x6f, 0x76, 0xa8, 0x17, 0xae, 0x73, 0xa8, 0xef, 0x64,
+	0x44, 0xf0, 0x22, 0x1a, 0x78, 0xd0, 0x4c, 0xac, 0xfe, 0x1b, 0x04, 0xef, 0xf3, 0xd7, 0x0d, 0xde,
+	0x57, 0x19, 0x3d, 0xf3, 0x4f, 0xa9, 0x9d, 0xca, 0x68, 0x17, 0xf8, 0x9e, 0x89, 0x44, 0x25, 0x13,
+	0xda, 0x74, 0x64, 0xbd, 0x78, 0x9d, 0xc8, 0x3a, 0x4e, 0x9b, 0xd3, 0x2f, 0x29, 0xde, 0x2f, 0x24,
+	0xd2, 0xe6, 0xd4, 0x5b, 0xae, 0x0a, 0x7a, 0x96, 0xbe, 0x49, 0x00, 0xfd, 0x88, 0x57, 0x01, 0xdc,
+	0xe1, 0x0b, 0x52, 0x0a, 0x49, 0xf0, 0x2b, 0x09, 0xa1, 0xff, 0x26, 0x07, 0x2b, 0x9b, 0x8c, 0x9a,
+	0xa7, 0x4f, 0x5d, 0x33, 0x95, 0x56, 0x27, 0x8e, 0xfd, 0xdc, 0xf4, 0xb1, 0xff, 0x3a, 0x24, 0x14,
+	0x3b, 0x11, 0x19, 0x94, 0x63, 0x28, 0x92, 0xcd, 0x24, 0xc5, 0x85, 0x8c, 0xa4, 0x98, 0xdc, 0x83,
+	0xa5, 0xd0, 0x19, 0xd2, 0x20, 0x34, 0x87, 0x23, 0xee, 0x28, 0x0a, 0x7a, 0x0c, 0x40, 0x6c, 0x5c,
+	0x7e, 0x40, 0x97, 0x59, 0xd2, 0x63, 0x40, 0xc3, 0x81, 0x6a, 0x9f, 0xba, 0x74, 0x48, 0x51, 0xf3,
+	0xe8, 0xc8, 0x67, 0x21, 0xba, 0x73, 0x3f, 0x50, 0xee, 0xdc, 0x0f, 0x30, 0x4e, 0x33, 0x59, 0x14,
+	0xbc, 0xf1, 0xdf, 0x2a, 0x6c, 0x31, 0x3d, 0x3b, 0x19, 0xb6, 0x98, 0x1e, 0x77, 0x30, 0xfe, 0x38,
+	0xb4, 0xfc, 0x21, 0x95, 0x2e, 0x5c, 0x0d, 0x1b, 0x9f, 0xc2, 0x4a, 0xe2, 0x55, 0x37, 0xf3, 0x2d,
+	0x1e, 0xdc, 0x8e, 0x9e, 0x3d, 0x18, 0x0f, 0x87, 0x26, 0x9b, 0x28, 0x4e, 0x7f, 0x2b, 0x6e, 0xe6,
+	0xdf, 0x72, 0x50, 0x89, 0x5e, 0xd8, 0xf6, 0xc7, 0x22, 0x9c, 0x90, 0xf9, 0x4d, 0x22, 0xa9, 0x00,
+	0x01, 0xda, 0xc3, 0xd4, 0x02, 0x65, 0x9a, 0x95, 0x00, 0x95, 0xad, 0x54, 0xf6, 0x23, 0xd8, 0x5b,
+	0x98, 0x61, 0xef, 0x5c, 0x36, 0x7b, 0xe7, 0x2f, 0x65, 0xef, 0x42, 0x8a, 0xbd, 0xa8, 0xa1, 0x16,
+	0x2e, 0x54, 0x86, 0x31, 0x62, 0x80, 0x01, 0x8c, 0x6b, 0x06, 0xa1, 0x11, 0x50, 0xea, 0xf1, 0x00,
+	0xa6, 0xa0, 0x17, 0x11, 0x70, 0x40, 0xa9, 0xd7, 0xf8, 0xed, 0x1c, 0x68, 0xb3, 0x6c, 0xbd, 0x69,
+	0x94, 0xb2, 0xc0, 0xdf, 0x14, 0x1f, 0x86, 0x69, 0xbe, 0xe9, 0x12, 0x8d, 0xeb, 0x0b, 0x1c, 0xcf,
+	0x12, 0xe7, 0x4e, 0x41, 0x17, 0x83, 0xc6, 0x9b, 0xb0, 0xb2, 0xef, 0x58, 0x18, 0x21, 0xe1, 0xa4,
+	0x71, 0x89, 0xc9, 0xf2, 0xed, 0x28, 0x71, 0xc3, 0xdf, 0x8d, 0xe7, 0x40, 0x92, 0x84, 0x37, 0x5b,
+	0x64, 0x52, 0x47, 0xf2, 0x29, 0x1d, 0x69, 0xfc, 0x5e, 0x1e, 0x56, 0x3b, 0x1e, 0xf3, 0x5d, 0x77,
+	0x8b, 0xc7, 0x75, 0xdf, 0xa6, 0x5a, 0xa1, 0x57, 0x90, 0xe1, 0x24, 0x9a, 0xbc, 0xd0, 0x01, 0x19,
+	0x60, 0xa2, 0xb9, 0xd7, 0xa1, 0x88, 0x7e, 0x8d, 0xeb, 0x97, 0x50, 0x87, 0x68, 0x8c, 0xb8, 0x91,
+	0x6b, 0x86, 0xc7, 0x3e, 0x1b, 0x4a, 0x9d, 0x88, 0xc6, 0xa8, 0x9a, 0x27, 0x26, 0xb3, 0xcf, 0x4d,
+	0xc6, 0xe3, 0x54, 0x19, 0xf4, 0x28, 0x50, 0xd7, 0x26, 0xaf, 0x42, 0x59, 0xc4, 0xe0, 0x86, 0x37,
+	0x1e, 0x1e, 0x51, 0x26, 0x4b, 0x9a, 0x25, 0x01, 0xdc, 0xe3, 0xb0, 0xc6, 0x8f, 0x61, 0x2d, 0xcd,
+	0x88, 0x9b, 0xf1, 0x38, 0x15, 0x2a, 0xe7, 0xd3, 0xa1, 0x72, 0xe3, 0x8f, 0x72, 0xb0, 0xaa, 0xf3,
+	0xd3, 0xe6, 0x7f, 0x81, 0xcb, 0xa9, 0x95, 0x14, 0xa6, 0x82, 0xf6, 0x4b, 0x6a, 0xc4, 0x0d, 0x0f,
+	0xd6, 0xd2, 0x0b, 0xbc, 0xd9, 0xee, 0x2f, 0x39, 0x68, 0xf3, 0x97, 0x1d, 0xb4, 0x8d, 0x3f, 0xc3,
+	0x63, 0x03, 0x43, 0x81, 0x6f, 0x50, 0x56, 0xbf, 0x26, 0x3f, 0xa2, 0x44, 0xa2, 0x20, 0xcf, 0xc3,
+	0xe8, 0xbd, 0xf2, 0xb5, 0x32, 0x91, 0xb8, 0x94, 0x37, 0x03, 0xa8, 0x4d, 0x3f, 0x82, 0xe6, 0xec,
+	0x9a, 0x47, 0xd4, 0x95, 0xcb, 0x14, 0x83, 0xab, 0xb2, 0xdd, 0x2b, 0x72, 0x80, 0xc6, 0x7f, 0xe5,
+	0x80, 0x24, 0x99, 0x72, 0xd3, 0x02, 0x6f, 0x2a, 0x61, 0x82, 0xc4, 0x3e, 0xe5, 0x06, 0x7f, 0xe9,
+	0xda, 0xce, 0x55, 0x01, 0xcb, 0xdc, 0x37, 0xa9, 0xd2, 0xfc, 0x16, 0x2c, 0x45, 0x2b, 0xbd, 0x84,
+	0xaf, 0x57, 0x97, 0xad, 0xe2, 0xa4, 0xbb, 0xf0, 0xa2, 0x1c, 0x7e, 0x36, 0xad, 0x6a, 0xfc, 0x71,
+	0x0e, 0xd6, 0x3a, 0x17, 0x23, 0xd7, 0x74, 0x54, 0x18, 0xf9, 0x6d, 0x2a, 0xe3, 0x74, 0x86, 0x56,
+	0x98, 0xb9, 0xc8, 0x50, 0x17, 0x0c, 0x73, 0xf1, 0x05, 0x43, 0xe3, 0x9f, 0xe7, 0x60, 0x7d, 0x6a,
+	0x8d, 0x37, 0x3d, 0xa6, 0xa2, 0x73, 0x33, 0xf3, 0x02, 0x2e, 0x79, 0x8c, 0x8a, 0xe4, 0xb5, 0x90,
+	0x4c, 0x5e, 0x37, 0x60, 0x61, 0xc0, 0xfc, 0xf1, 0x48, 0x55, 0x22, 0xe4, 0x68, 0x4a, 0xa1, 0xe7,
+	0x67, 0x92, 0xda, 0x47, 0x50, 0xe3, 0xe5, 0x04, 0x27, 0x9c, 0x44, 0x65, 0x4c, 0x51, 0x88, 0xa8,
+	0x2a, 0xb8, 0xaa, 0xa1, 0x3e, 0x87, 0x9a, 0x85, 0x0a, 0x61, 0x99, 0xae, 0x21, 0x8a, 0x56, 0xaa,
+	0x44, 0xff, 0x76, 0x33, 0x73, 0xeb, 0xcd, 0xb6, 0x24, 0x17, 0x85, 0xad, 0x28, 0x7b, 0x48, 0x43,
+	0xc9, 0x13, 0x00, 0x7a, 0x11, 0x52, 0x2f, 0xe0, 0x33, 0x16, 0xe5, 0x3d, 0x42, 0xf6, 0x8c, 0x9d,
+	0x88, 0x50, 0x4c, 0x96, 0x78, 0x12, 0x19, 0xc3, 0xc6, 0xae, 0x0c, 0xce, 0x97, 0x74, 0x31, 0x20,
+	0x1f, 0x81, 0x96, 0x28, 0xb2, 0x18, 0xc1, 0x29, 0x3d, 0x8f, 0x36, 0x0a, 0x7c, 0xa3, 0xeb, 0x71,
+	0xc5, 0xe5, 0xe0, 0x94, 0x9e, 0xcb, 0xed, 0xf2, 0xd0, 0x3b, 0x63, 0xfd, 0x37, 0x09, 0xbd, 0xeb,
+	0x9f, 0x41, 0x75, 0x6a, 0xc5, 0x37, 0x8a, 0xdc, 0x7f, 0xba, 0x05, 0xa5, 0x03, 0xca, 0xce, 0x28,
+	0x13, 0x49, 0x00, 0x79, 0x19, 0x96, 0x2d, 0x13, 0x3d, 0x17, 0x16, 0xf4, 0x4e, 0x54, 0xd4, 0x6e,
+	0x99, 0xdb, 0x74, 0xb2, 0x6f, 0x86, 0x27, 0xa4, 0x0d, 0x2f, 0x0f, 0xa8, 0x47, 0x19, 0x5a, 0x3f,
+	0xda, 0x9e, 0x71, 0xc9, 0xa5, 0xd7, 0x5d, 0x45, 0x85, 0x16, 0xbd, 0x35, 0x75, 0x01, 0xd6, 0x84,
+	0x55, 0x19, 0x26, 0xca, 0x14, 0x31, 0xb0, 0xfc, 0x11, 0x95, 0xea, 0xb6, 0x22, 0x50, 0x62, 0x3d,
+	0x07, 0x88, 0x20, 0x5b, 0x50, 0x56, 0xf7, 0x01, 0x58, 0x22, 0x55, 0xbe, 0xe6, 0x95, 0x66, 0x72,
+	0xe9, 0xcd, 0x96, 0x20, 0x39, 0x44, 0x0a, 0x21, 0xbb, 0x92, 0x99, 0x00, 0x61, 0x88, 0xe0, 0x3a,
+	0x41, 0x48, 0x31, 0x85, 0x64, 0xa2, 0x74, 0x32, 0xaf, 0x83, 0x00, 0xed, 0xfb, 0x2c, 0x24, 0xdf,
+	0x83, 0xbb, 0xea, 0x35, 0xb6, 0x3f, 0x34, 0x1d, 0xcf, 0x38, 0xf6, 0x99, 0x11, 0xd9, 0xbf, 0x88,
+	0x29, 0x6e, 0x4b, 0x92, 0x2d, 0x4e, 0xf1, 0xc4, 0x67, 0x5d, 0xe9, 0x0f, 0x5a, 0xf0, 0xb2, 0x7a,
+	0x5a, 0x6e, 0xce, 0xb1, 0xd3, 0x13, 0x88, 0x88, 0xe3, 0x8e, 0xa4, 0x12, 0x09, 0x65, 0xd7, 0x4e,
+	0x4c, 0xf1, 0x14, 0x1e, 0x98, 0xb6, 0xed, 0x20, 0xab, 0x4c, 0xf7, 0xb2, 0x59, 0xde, 0xe3, 0xba,
+	0x77, 0x2f, 0x26, 0xcc, 0x98, 0xe8, 0x21, 0xd4, 0x02, 0xce, 0x1a, 0x21, 0x23, 0x2e, 0x4a, 0x51,
+	0xba, 0xab, 0x08, 0x38, 0x4a, 0x85, 0xcb, 0xf3, 0x0d, 0xa8, 0x4a, 0xca, 0x48, 0xe6, 0x4b, 0xf2,
+	0x62, 0x98, 0x83, 0x95, 0xdc, 0xbb, 0xa9, 0xa5, 0x05, 0xc1, 0x89, 0x14, 0x9d, 0x92, 0xbe, 0xeb,
+	0x78, 0x94, 0x27, 0x9b, 0x4b, 0xfa, 0xcb, 0x31, 0xe1, 0x41, 0x70, 0xd2, 0x4e, 0x92, 0xed, 0x38,
+	0x1e, 0x8f, 0x00, 0x2d, 0xd3, 0x50, 0x25, 0xdf, 0x65, 0xa5, 0x61, 0x6d, 0x01, 0xc0, 0xb5, 0x9f,
+	0x84, 0xe1, 0xc8, 0x48, 0xca, 0xaa, 0xc4, 0x65, 0x55, 0x41, 0xf8, 0x4e, 0x2c, 0xaf, 0x57, 0x63,
+	0xb5, 0xc0, 0xa3, 0x25, 0xd0, 0xca, 0xfc, 0xfd, 0x4a, 0xea, 0x78, 0x10, 0x05, 0xb8, 0x41, 0xcb,
+	0xb4, 0xed, 0x89, 0x71, 0xec, 0xb8, 0x54, 0x6c, 0xb0, 0x22, 0x73, 0x12, 0x04, 0x3f, 0x71, 0x5c,
+	0xca, 0x37, 0xf8, 0x00, 0x4a, 0x41, 0xe8, 0x33, 0x6a, 0xd8, 0xcc, 0x39, 0xa3, 0x4c, 0xab, 0x8a,
+	0x53, 0x82, 0xc3, 0xb6, 0x38, 0x08, 0x83, 0x2a, 0x49, 0x12, 0x78, 0x5a, 0x4d, 0x04, 0x55, 0x02,
+	0x1f, 0x78, 0xe4, 0x13, 0xa8, 0xe3, 0x1d, 0x21, 0x3f, 0x64, 0x8d, 0x11, 0x65, 0x5c, 0x53, 0xf9,
+	0x0f, 0xdb, 0x9c, 0x68, 0x2b, 0x7c, 0x03, 0xeb, 0x43, 0xf3, 0x82, 0x1f, 0xeb, 0xfb, 0x94, 0xa1,
+	0x4e, 0xee, 0x53, 0xb6, 0x65, 0x8a, 0x4b, 0x7d, 0x1b, 0x2f, 0x8f, 0x84, 0x72, 0x13, 0xe1, 0x42,
+	0x39, 0x48, 0x68, 0xee, 0x1b, 0x50, 0xb5, 0x3d, 0xbc, 0xd4, 0xc6, 0xd2, 0x9a, 0xc8, 0xbd, 0x56,
+	0xc5, 0x1e, 0x6c, 0x2f, 0x10, 0x05, 0x37, 0x9e, 0x7e, 0xdd, 0x81, 0x22, 0xd2, 0xfd, 0xc4, 0xf7,
+	0xa8, 0xb6, 0x26, 0x4e, 0x2b, 0xdb, 0x0b, 0x7e, 0xec, 0x7b, 0x94, 0xbc, 0x05, 0x2b, 0x88, 0x1a,
+	0xf3, 0xa2, 0x8b, 0x21, 0x64, 0xab, 0xad, 0xcb, 0x9b, 0x78, 0x2f, 0x10, 0xc5, 0x18, 0x61, 0x4e,
+	0xe4, 0x91, 0xa0, 0x0d, 0x03, 0x67, 0xc0, 0xb5, 0x82, 0xbf, 0x70, 0x43, 0xa8, 0x8f, 0xed, 0x05,
+	0xfd, 0xc0, 0x19, 0x6c, 0xd3, 0x09, 0x7f, 0xa3, 0x5c, 0x19, 0x27, 0x0d, 0xa8, 0xc5, 0x68, 0xa8,
+	0xdd, 0x8e, 0x56, 0x86, 0x84, 0x07, 0x1c, 0x88, 0xf5, 0x9b, 0x58, 0x67, 0x44, 0x1d, 0x49, 0xd3,
+	0xb2, 0xcb, 0x48, 0x95, 0x20, 0x38, 0x49, 0x8c, 0xc9, 0x6e, 0x46, 0x21, 0xe9, 0x0e, 0x7f, 0xb4,
+	0x91, 0xb6, 0xff, 0xeb, 0x55, 0x92, 0x3e, 0x84, 0x4a, 0xaa, 0x92, 0x34, 0xd1, 0xea, 0x99, 0x75,
+	0xa4, 0x72, 0xb2, 0x8e, 0x34, 0xb9, 0xf4, 0x0a, 0xf8, 0xee, 0x65, 0x57, 0xc0, 0xef, 0xc3, 0xda,
+	0x88, 0x39, 0x67, 0x8e, 0x4b, 0x07, 0xd4, 0x36, 0xa2, 0xf3, 0x50, 0xbb, 0x27, 0x4a, 0x42, 0x31,
+	0x6e, 0x5f, 0xa1, 0xb0, 0x4c, 0x21, 0x2b, 0x91, 0x2c, 0xd0, 0x5e, 0xe2, 0x74, 0x31, 0x00, 0xaf,
+	0x24, 0xa3, 0xba, 0xe6, 0x39, 0x3d, 0x3a, 0xf1, 0xfd, 0x53, 0xde, 0xac, 0xf2, 0x32, 0xe7, 0x37,
+	0x51, 0xb8, 0xaf, 0x04, 0xea, 0x90, 0xb9, 0xe4, 0x63, 0xd0, 0xa2, 0x27, 0x42, 0x67, 0x48, 0xfd,
+	0x71, 0x18, 0xad, 0xfb, 0x15, 0xbe, 0xee, 0x0d, 0x85, 0xef, 0x0b, 0xb4, 0x5a, 0xfc, 0x13, 0xa8,
+	0x1d, 0x61, 0x3d, 0xc7, 0x18, 0x60, 0x41, 0x87, 0xeb, 0xa5, 0x76, 0x9f, 0xb3, 0xe9, 0x5e, 0x9a,
+	0xe7, 0x71, 0xd5, 0x07, 0x35, 0x55, 0xaf, 0x1c, 0xa5, 0xc6, 0xc8, 0xb5, 0xe4, 0x3c, 0xae, 0x3f,
+	0x10, 0x16, 0xf8, 0x40, 0x78, 0xfa, 0x98, 0x7a, 0xc7, 0x1f, 0x70, 0x2b, 0x7c, 0x06, 0x0f, 0x92,
+	0x0f, 0x64, 0x9f, 0x30, 0x0d, 0xbe, 0xf6, 0x97, 0xe2, 0xa7, 0xb3, 0xce, 0x98, 0x2f, 0xa0, 0xca,
+	0x9f, 0x4e, 0x1c, 0xfc, 0xaf, 0xca, 0xf2, 0x63, 0x5a, 0x6b, 0x28, 0x0b, 0xa7, 0xcf, 0xfc, 0x8a,
+	0x95, 0x02, 0x62, 0x08, 0x23, 0xd2, 0x80, 0x78, 0x36, 0xed, 0x35, 0x61, 0x3b, 0x02, 0x1e, 0xd1,
+	0x62, 0x05, 0x04, 0x8b, 0xf1, 0x0e, 0xa3, 0x86, 0x40, 0x69, 0xaf, 0xf3, 0x1a, 0x73, 0x59, 0x42,
+	0xf5, 0xcb, 0xda, 0x71, 0xde, 0xc8, 0x6a, 0xc7, 0x79, 0x04, 0xf3, 0xbc, 0x4c, 0xa7, 0xbd, 0xc9,
+	0x97, 0xbe, 0x9a, 0x5e, 0x3a, 0xaf, 0xe9, 0xe9, 0x82, 0x82, 0x7c, 0x06, 0x77, 0xcf, 0x31, 0x82,
+	0x46, 0xad, 0x76, 0x0d, 0xc7, 0x0b, 0x29, 0x43, 0xb9, 0x2b, 0x9e, 0x3d, 0xe4, 0x3c, 0xd3, 0x38,
+	0xc9, 0xbe, 0xef, 0xba, 0x5d, 0x49, 0xa0, 0xd8, 0xf5, 0x5d, 0xd8, 0x48, 0xf8, 0x77, 0x11, 0xe8,
+	0xf3, 0x38, 0x40, 0x7b, 0x24, 0x14, 0x36, 0xc6, 0xf2, 0x00, 0x1f, 0x03, 0x82, 0x4b, 0xee, 0xbc,
+	0xdf, 0xba, 0xe4, 0xce, 0x9b, 0x42, 0x7d, 0x96, 0xda, 0x38, 0x92, 0xfe, 0xe5, 0x6d, 0xbe, 0xc3,
+	0x47, 0xe9, 0x1d, 0xee, 0x4e, 0xcd, 0xb1, 0xc9, 0xbd, 0x8e, 0x10, 0xd2, 0xc6, 0x30, 0x13, 0x39,
+	0xdd, 0xcb, 0xf5, 0xce, 0x74, 0x2f, 0x17, 0x4a, 0xd3, 0xb4, 0x2c, 0x3a, 0x0a, 0x8d, 0x50, 0x95,
+	0x69, 0xb4, 0x77, 0x45, 0x97, 0x83, 0x80, 0x47, 0xd5, 0x1b, 0x14, 0x93, 0xc3, 0x23, 0xf7, 0x70,
+	0x62, 0x58, 0xae, 0xe9, 0x0c, 0xb5, 0xa6, 0x10, 0x93, 0x82, 0xb6, 0x11, 0x88, 0x67, 0x87, 0x08,
+	0x86, 0x25, 0xd1, 0x77, 0xc4, 0xd9, 0x21, 0x60, 0x82, 0xe4, 0x13, 0x58, 0x0e, 0xcc, 0xa1, 0x6b,
+	0x1c, 0x31, 0xc7, 0x1e, 0x50, 0xed, 0x7d, 0x5e, 0x55, 0xd6, 0xd2, 0xbb, 0x3d, 0x68, 0xed, 0xee,
+	0x6c, 0x72, 0xbc, 0x0e, 0x48, 0x2c, 0x7e, 0x93, 0xc7, 0x50, 0x3c, 0xa5, 0xec, 0x88, 0x32, 0x3f,
+	0xd0, 0x1e, 0xf3, 0xe7, 0x36, 0xd2, 0xcf, 0x6d, 0x4b, 0xac, 0x1e, 0xd1, 0xe1, 0xc2, 0x95, 0xd3,
+	0x94, 0x52, 0xf9, 0xee, 0xfd, 0xdc, 0xc3, 0xb2, 0x2e, 0x2b, 0xf9, 0x4a, 0x24, 0x1f, 0xc2, 0xd2,
+	0x91, 0xef, 0x87, 0x41, 0xc8, 0xcc, 0x91, 0xf6, 0x01, 0x9f, 0xfb, 0xf6, 0x94, 0x81, 0x2b, 0xb4,
+	0x1e, 0x53, 0x92, 0x8f, 0x01, 0x4e, 0xc7, 0x47, 0x94, 0x79, 0x34, 0xa4, 0x81, 0xf6, 0xe1, 0xfd,
+	0xc2, 0xec, 0x5e, 0xb6, 0x23, 0xbc, 0x9e, 0xa0, 0x25, 0xdf, 0x07, 0x19, 0xde, 0x19, 0x89, 0x12,
+	0xfb, 0xff, 0xbb, 0xac, 0xc4, 0x5e, 0xb3, 0xa6, 0x20, 0xe4, 0x19, 0xd4, 0x44, 0xdf, 0xc4, 0xb1,
+	0xcf, 0xce, 0x4d, 0x66, 0x3b, 0xde, 0x40, 0xfb, 0x88, 0x3f, 0xfe, 0xd2, 0x54, 0x30, 0x88, 0x54,
+	0x4f, 0x22, 0x22, 0xbd, 0x6a, 0xa6, 0x01, 0xe4, 0x03, 0xd8, 0xb0, 0xcc, 0xb8, 0x2b, 0xcd, 0x30,
+	0xdd, 0x81, 0xcf, 0x9c, 0xf0, 0x64, 0xa8, 0x7d, 0xcc, 0xa5, 0xb7, 0x66, 0x99, 0x51, 0x6f, 0x5a,
+	0x4b, 0xe1, 0xd0, 0xa1, 0x8d, 0x4c, 0x66, 0xba, 0x2e, 0x75, 0x8d, 0x64, 0x9c, 0xfc, 0x09, 0x37,
+	0x92, 0x15, 0x85, 0x6b, 0x47, 0xf1, 0xf2, 0x1b, 0x50, 0x15, 0x57, 0xbb, 0x46, 0x48, 0x87, 0x58,
+	0xad, 0xa2, 0xda, 0xa7, 0x42, 0x85, 0xf8, 0x1d, 0x6f, 0x5f, 0x02, 0x5f, 0x98, 0x44, 0xfc, 0x7f,
+	0x2e, 0xba, 0xec, 0x24, 0x02, 0x15, 0xcb, 0xc2, 0x73, 0xd2, 0xb0, 0x4e, 0xa8, 0x75, 0xaa, 0x7d,
+	0x2f, 0x4b, 0xb1, 0xda, 0x48, 0xd0, 0x46, 0x3c, 0x56, 0x6b, 0xd5, 0x6f, 0xf2, 0x7d, 0xb8, 0x87,
+	0x67, 0xda, 0xd8, 0xa3, 0x17, 0x23, 0x87, 0x61, 0xdc, 0x9a, 0x0a, 0x5e, 0xb4, 0xcf, 0xf8, 0x7b,
+	0xb5, 0xa1, 0x79, 0x71, 0xa8, 0x48, 0x92, 0xd1, 0x0b, 0xf9, 0x1c, 0xee, 0x89, 0xaa, 0x8e, 0xe1,
+	0xbb, 0x36, 0x0d, 0xc2, 0xa9, 0x99, 0xb4, 0xef, 0x73, 0xa3, 0xba, 0x23, 0x68, 0x7a, 0x9c, 0x24,
+	0x35, 0x51, 0xd2, 0x59, 0x8a, 0xe2, 0x94, 0xf6, 0x79, 0xca, 0x59, 0x8a, 0x3a, 0x54, 0xa2, 0x89,
+	0x30, 0x76, 0xbf, 0x3f, 0x48, 0x36, 0x11, 0xc6, 0xee, 0xf7, 0x55, 0x28, 0x0c, 0xed, 0xa1, 0xd6,
+	0x92, 0x1a, 0x95, 0x76, 0x26, 0x5b, 0xbb, 0x3a, 0x62, 0xd1, 0xab, 0x06, 0xae, 0x69, 0x9d, 0x6a,
+	0x9b, 0xf7, 0x73, 0xb3, 0x5e, 0xf5, 0x00, 0x51, 0xba, 0xa0, 0x40, 0x67, 0x22, 0xb2, 0x67, 0x63,
+	0x64, 0x0e, 0xa8, 0xd6, 0xe6, 0xcb, 0x03, 0x01, 0xda, 0x37, 0x07, 0x94, 0x1c, 0x00, 0x31, 0xc7,
+	0xa1, 0x3f, 0x14, 0x27, 0x94, 0x69, 0x89, 0xfa, 0xf3, 0x16, 0x37, 0x89, 0xd7, 0xa6, 0x54, 0x32,
+	0xa2, 0x6b, 0x09, 0x32, 0xe1, 0xc7, 0x56, 0xcc, 0x69, 0x38, 0xde, 0x79, 0x38, 0xc3, 0x11, 0x65,
+	0x81, 0xef, 0x99, 0xa1, 0xcf, 0x02, 0xad, 0xc3, 0xd5, 0x2b, 0x0d, 0x44, 0x97, 0x9d, 0x2c, 0x23,
+	0x24, 0x98, 0xf3, 0x44, 0x74, 0x6b, 0xc6, 0x05, 0x85, 0x98, 0x41, 0x1f, 0x42, 0xf1, 0x6b, 0x27,
+	0x34, 0x78, 0x75, 0xe1, 0x29, 0x5f, 0x65, 0x3d, 0xbd, 0xca, 0x2f, 0x9c, 0x50, 0xf7, 0x5d, 0xe9,
+	0x63, 0x17, 0xbf, 0x16, 0x23, 0xb2, 0x09, 0x15, 0xd1, 0xa4, 0xa8, 0x42, 0x0f, 0xed, 0x19, 0xe7,
+	0xdd, 0xdd, 0xf4, 0xc3, 0x7d, 0x4e, 0x23, 0x43, 0x10, 0xbd, 0x1c, 0x26, 0x87, 0x38, 0x87, 0x47,
+	0xc3, 0x73, 0x9f, 0x9d, 0xaa, 0xc8, 0xab, 0x9b, 0x35, 0xc7, 0x9e, 0xa0, 0x51, 0x61, 0x98, 0x97,
+	0x1c, 0x62, 0xee, 0x30, 0xa0, 0xbe, 0x33, 0x12, 0x56, 0xf7, 0x85, 0xc8, 0x1d, 0x38, 0x84, 0x5b,
+	0x1b, 0xf6, 0x4d, 0xa5, 0x5e, 0x61, 0xd0, 0x0b, 0x3a, 0x1c, 0x85, 0xda, 0xb6, 0x38, 0xc4, 0x52,
+	0x93, 0x75, 0x38, 0x8a, 0x7c, 0x0e, 0x65, 0x84, 0x39, 0xde, 0xc0, 0x38, 0xf1, 0xc7, 0x2c, 0xd0,
+	0x76, 0xb2, 0xd8, 0xf2, 0x95, 0x20, 0x79, 0x86, 0x14, 0x7a, 0xe9, 0x3c, 0x31, 0x42, 0xff, 0x2c,
+	0x72, 0x15, 0xca, 0xb4, 0x5d, 0xd5, 0x89, 0x93, 0x7c, 0x76, 0x47, 0x62, 0xf5, 0x88, 0x0e, 0x53,
+	0x97, 0x90, 0x8d, 0xf9, 0x2d, 0xfe, 0x88, 0xf9, 0x17, 0x13, 0x6d, 0x4f, 0xa4, 0x2e, 0x12, 0xb8,
+	0x8f, 0x30, 0x34, 0x0f, 0x8e, 0x34, 0x78, 0xd7, 0xb5, 0xe5, 0xbb, 0x5a, 0x4f, 0x98, 0x07, 0x87,
+	0xee, 0x4b, 0x20, 0x36, 0x0c, 0x0d, 0xd8, 0xc8, 0x32, 0x18, 0x3d, 0x76, 0xd1, 0x4f, 0xfa, 0x9e,
+	0xb6, 0xcf, 0xe9, 0x2a, 0x08, 0xd6, 0x23, 0x28, 0xe6, 0x00, 0x68, 0xef, 0x43, 0x1a, 0x04, 0x18,
+	0xc2, 0x1e, 0x4d, 0xd0, 0x7b, 0x7f, 0xc9, 0x8d, 0xbc, 0x3a, 0x34, 0x2f, 0x76, 0x05, 0x7c, 0x13,
+	0xc1, 0xe4, 0x5d, 0x20, 0x96, 0x3f, 0x1c, 0x31, 0xd1, 0x4c, 0x2b, 0x6a, 0x23, 0x81, 0xa6, 0xf3,
+	0x79, 0x57, 0x14, 0x46, 0x15, 0x4d, 0x64, 0x96, 0x65, 0xa4, 0x1a, 0x81, 0x0e, 0x84, 0xce, 0x5a,
+	0xe6, 0xb3, 0x44, 0x2b, 0xd0, 0x53, 0x20, 0xb3, 0x61, 0x86, 0xd6, 0xbf, 0xaa, 0x74, 0x58, 0x9b,
+	0x8e, 0x3e, 0xea, 0x3f, 0xcf, 0x41, 0x25, 0x1d, 0x7c, 0xc6, 0x65, 0xab, 0x5c, 0xb2, 0x6c, 0x75,
+	0xcd, 0x6b, 0xc6, 0x3a, 0x14, 0xd1, 0xe7, 0xf1, 0x50, 0x44, 0xd6, 0xcb, 0xd5, 0x18, 0xfd, 0x0f,
+	0xbd, 0x08, 0x99, 0x69, 0xcc, 0x74, 0xe3, 0x54, 0x39, 0x3c, 0x8a, 0xe0, 0x83, 0xfa, 0x5f, 0xe7,
+	0x61, 0x9e, 0x87, 0x65, 0x99, 0x9d, 0x77, 0x53, 0xc5, 0x95, 0xfc, 0x74, 0x71, 0xe5, 0xa6, 0x75,
+	0x91, 0x74, 0x26, 0x3d, 0x37, 0x9d, 0x49, 0x5f, 0x2b, 0x67, 0x9f, 0xbf, 0x56, 0xce, 0x9e, 0x95,
+	0xbf, 0x2d, 0x5c, 0x2f, 0x7f, 0xcb, 0x50, 0x8d, 0xc5, 0x0c, 0xd5, 0xa8, 0xff, 0xcb, 0x3c, 0x00,
+	0xca, 0x51, 0x3c, 0x9b, 0x12, 0x48, 0xee, 0x1a, 0x02, 0xc9, 0x67, 0x0a, 0x84, 0xfc, 0x10, 0x6a,
+	0xa2, 0x04, 0x42, 0xd9, 0xd0, 0x09, 0x44, 0x1e, 0x20, 0xee, 0x00, 0xde, 0x4d, 0x1b, 0xe9, 0x61,
+	0x90, 0x4a, 0x09, 0xf6, 0x63, 0x7a, 0x95, 0x48, 0xa6, 0xa1, 0x7c, 0xe6, 0xec, 0x06, 0x87, 0x17,
+	0xcc, 0x7c, 0xad, 0x14, 0xf5, 0xb2, 0x5c, 0x73, 0xfe, 0xb2, 0x5c, 0xf3, 0x70, 0x36, 0xd7, 0x11,
+	0xc2, 0x79, 0xe7, 0x85, 0x7b, 0xbc, 0x2a, 0xed, 0x99, 0x4d, 0x52, 0x16, 0xb3, 0x92, 0x94, 0x35,
+	0x95, 0xa4, 0x14, 0x65, 0x55, 0x14, 0x07, 0x19, 0xde, 0x7e, 0xe9, 0xa6, 0xde, 0x9e, 0x17, 0x48,
+	0x33, 0x64, 0x71, 0xa3, 0x02, 0xe9, 0xaf, 0xa0, 0xbf, 0xa1, 0xde, 0x82, 0xd5, 0x0c, 0x7e, 0xdd,
+	0x68, 0x8a, 0xdf, 0xc9, 0x41, 0x39, 0xb5, 0x57, 0x4c, 0x1a, 0xa2, 0x7a, 0xa1, 0x63, 0x33, 0xd5,
+	0x38, 0x29, 0x61, 0x6d, 0xc7, 0x66, 0xc9, 0x3e, 0x68, 0x1e, 0x18, 0xb0, 0x89, 0x96, 0x4f, 0xf5,
+	0x41, 0xb7, 0x05, 0x14, 0x25, 0x65, 0x53, 0xcf, 0x49, 0xd0, 0x89, 0xbb, 0x9c, 0xb2, 0x80, 0x4a,
+	0xb2, 0xfa, 0xef, 0xe7, 0x01, 0xe2, 0x24, 0x03, 0xcb, 0x45, 0xcc, 0xf7, 0x43, 0x9e, 0x26, 0xc9,
+	0xcb, 0x0d, 0x1c, 0x63, 0x8e, 0xf4, 0x16, 0xac, 0x38, 0xf6, 0xc8, 0x18, 0xd2, 0xd0, 0xb4, 0xcd,
+	0xd0, 0x4c, 0xfa, 0xab, 0xaa, 0x63, 0x8f, 0x76, 0x25, 0x9c, 0x7b, 0xad, 0x3b, 0x50, 0x8c, 0x5c,
+	0x5a, 0x21, 0x6a, 0x27, 0xe5, 0xa8, 0xbb, 0xb0, 0x14, 0x17, 0x20, 0xe5, 0x75, 0xae, 0xa5, 0x4a,
+	0x8f, 0x6f, 0x42, 0x95, 0xbb, 0x68, 0xc3, 0x0c, 0x43, 0xe6, 0x1c, 0x8d, 0x43, 0x2a, 0x6f, 0x75,
+	0x2b, 0x1c, 0xdc, 0x52, 0x50, 0x34, 0x77, 0x99, 0x5e, 0xc5, 0x94, 0xa2, 0x18, 0x5b, 0x15, 0xf0,
+	0x98, 0xf4, 0x03, 0xd8, 0xe0, 0x55, 0x52, 0xc3, 0x75, 0x8e, 0x69, 0xe8, 0x0c, 0x63, 0xe3, 0x59,
+	0xe4, 0xc6, 0xb3, 0xc6, 0xb1, 0x3b, 0x12, 0xa9, 0x0a, 0xf1, 0x7f, 0x90, 0x83, 0xa2, 0x4a, 0xa2,
+	0x30, 0xe4, 0x3b, 0xa5, 0x93, 0xd0, 0x3c, 0x4a, 0x56, 0xc0, 0x41, 0x80, 0xf8, 0xba, 0xdf, 0x86,
+	0x15, 0xac, 0x9f, 0x61, 0x3c, 0x1a, 0x97, 0x75, 0x64, 0xa3, 0xb7, 0x44, 0xc4, 0x35, 0x9d, 0xc8,
+	0x38, 0xe4, 0x5d, 0x0a, 0x1f, 0xe0, 0xd6, 0xa3, 0xbc, 0x52, 0x94, 0x9a, 0x25, 0x77, 0xa2, 0x74,
+	0x53, 0x94, 0x97, 0xeb, 0x7f, 0x98, 0x03, 0x88, 0x53, 0x29, 0xbc, 0x83, 0x71, 0xb0, 0x03, 0x92,
+	0xc9, 0x65, 0xc9, 0x11, 0x3a, 0x4b, 0x73, 0x6c, 0x3b, 0x14, 0x7b, 0x0b, 0xe4, 0xbd, 0xb3, 0x1a,
+	0xf3, 0x66, 0xe6, 0xf3, 0xd3, 0x20, 0x29, 0x9f, 0x22, 0x02, 0x94, 0xec, 0x38, 0x72, 0xcc, 0x1c,
+	0xd5, 0xab, 0x82, 0xe3, 0x43, 0xe6, 0xa0, 0x7e, 0x5a, 0x2e, 0x46, 0x23, 0x4c, 0x24, 0xe8, 0xb2,
+	0xbd, 0x54, 0xc2, 0x30, 0xd5, 0xae, 0xff, 0x2c, 0x07, 0xd5, 0xa9, 0x44, 0x0b, 0x23, 0x1b, 0x99,
+	0x9b, 0x19, 0x3c, 0xe5, 0xe2, 0x2b, 0x2d, 0xea, 0x25, 0x09, 0xe4, 0xe4, 0x58, 0x38, 0x48, 0x11,
+	0x25, 0x3b, 0xad, 0x6b, 0x49, 0x4a, 0x3c, 0x20, 0xb0, 0x1e, 0x69, 0xda, 0x36, 0x9e, 0x9b, 0x81,
+	0x11, 0xfa, 0x72, 0x5a, 0xb1, 0x93, 0x8a, 0x69, 0xdb, 0xdb, 0x74, 0x12, 0xf4, 0x7d, 0x4e, 0x5e,
+	0xff, 0xd7, 0x1c, 0x14, 0x76, 0xb7, 0x76, 0x79, 0xab, 0x00, 0xf3, 0xcf, 0x1c, 0x3b, 0x62, 0x55,
+	0x34, 0x46, 0xb3, 0x45, 0x8d, 0x17, 0x7c, 0xc2, 0x9f, 0xc8, 0xa2, 0x90, 0x7a, 0x26, 0x2f, 0xb6,
+	0x2b, 0x16, 0x09, 0x40, 0xd7, 0x46, 0x64, 0x54, 0x89, 0x8f, 0x74, 0x58, 0x96, 0xdc, 0x71, 0x23,
+	0x12, 0x29, 0xaa, 0x9f, 0x82, 0xcb, 0x82, 0x55, 0x32, 0x7b, 0x15, 0x15, 0x50, 0x65, 0x0e, 0x42,
+	0x3b, 0xe3, 0x8f, 0xcc, 0x8a, 0x1c, 0x80, 0x26, 0xf7, 0x2a, 0x94, 0x2d, 0xd3, 0x3a, 0x49, 0x6b,
+	0x6c, 0x59, 0x2f, 0x71, 0xa0, 0xd2, 0xd4, 0x7f, 0xc8, 0xc1, 0x3c, 0x4f, 0x50, 0xc8, 0x6b, 0x50,
+	0x39, 0xf2, 0x43, 0x71, 0x27, 0x90, 0xd4, 0xd4, 0xd2, 0x91, 0x1f, 0xf2, 0x4b, 0x00, 0x15, 0x51,
+	0x60, 0x8a, 0x8b, 0xc1, 0x6d, 0x72, 0x81, 0x62, 0xef, 0x2b, 0x12, 0x95, 0x58, 0xe1, 0xab, 0x50,
+	0x96, 0x1f, 0x1e, 0x70, 0xcd, 0xb2, 0x65, 0x87, 0x64, 0x49, 0x00, 0x45, 0xf7, 0x2d, 0x2f, 0xa0,
+	0xa8, 0xba, 0x22, 0x7e, 0x0f, 0xe2, 0x51, 0x57, 0x32, 0xa6, 0xaa, 0xe0, 0x6d, 0x01, 0x26, 0xb7,
+	0xb1, 0xd7, 0xd2, 0xe1, 0xfb, 0x15, 0x4c, 0x59, 0x30, 0x47, 0xce, 0x21, 0x73, 0xeb, 0xff, 0x9e,
+	0x87, 0x95, 0x99, 0x84, 0x08, 0x4d, 0x4b, 0x39, 0xbc, 0xd8, 0xb4, 0x84, 0x63, 0xac, 0x49, 0x44,
+	0x6c, 0x5a, 0xaf, 0x41, 0x05, 0x8f, 0xc9, 0x23, 0x5e, 0xf5, 0x0a, 0x9c, 0x9f, 0x08, 0xd5, 0x2f,
+	0xeb, 0xa5, 0xa1, 0x79, 0xc1, 0x2f, 0x93, 0x0f, 0x9c, 0x9f, 0x50, 0xf2, 0x36, 0x90, 0x74, 0x5d,
+	0x1e, 0x83, 0x7c, 0xad, 0x10, 0x45, 0xbd, 0x2a, 0xa3, 0xc5, 0x58, 0x1e, 0xf3, 0x87, 0xec, 0x92,
+	0xe3, 0x1c, 0xa7, 0x5f, 0xb5, 0x32, 0x0a, 0x8d, 0x46, 0x46, 0x84, 0x21, 0x5a, 0x13, 0x3f, 0xb8,
+	0x22, 0xff, 0xbb, 0x5e, 0xa0, 0xf1, 0x2b, 0x39, 0x05, 0xff, 0x31, 0x07, 0x8b, 0x5f, 0x74, 0xfb,
+	0x3c, 0x97, 0x4b, 0x5f, 0xd8, 0xe6, 0x66, 0x2e, 0x6c, 0xb1, 0x3f, 0x56, 0xf0, 0x5a, 0x5a, 0xa4,
+	0x1a, 0x62, 0x09, 0x1a, 0x79, 0x39, 0xc3, 0x1d, 0xc1, 0x4d, 0xe4, 0xf3, 0x34, 0x73, 0x5e, 0x8f,
+	0xf2, 0x46, 0x15, 0xfb, 0xc9, 0x6f, 0xda, 0x04, 0x54, 0xa5, 0x05, 0xbc, 0xc0, 0x2a, 0x0a, 0x01,
+	0x4a, 0x83, 0xb8, 0xbe, 0x14, 0xf5, 0xaa, 0x84, 0xab, 0x8e, 0xdc, 0xfa, 0x9f, 0xe7, 0xa0, 0xa8,
+	0x12, 0x2a, 0x5c, 0xaa, 0x8c, 0x18, 0xd4, 0x01, 0x26, 0x87, 0x7c, 0x13, 0x32, 0x68, 0x91, 0xdd,
+	0x4e, 0x72, 0x88, 0x55, 0x76, 0x7e, 0xef, 0x1b, 0xd2, 0x8b, 0x50, 0x7d, 0x79, 0x13, 0x01, 0x32,
+	0x72, 0xae, 0xb9, 0x4b, 0x72, 0x2e, 0x9e, 0xaa, 0xa9, 0x3a, 0x14, 0xb5, 0xe5, 0x7a, 0x2b, 0x1c,
+	0xfc, 0x44, 0x41, 0xf1, 0xdc, 0x2f, 0x25, 0x73, 0x47, 0xfe, 0xe5, 0xcf, 0x68, 0xe4, 0x3a, 0x14,
+	0x7d, 0x99, 0x96, 0x8b, 0xaa, 0xfc, 0x08, 0xe9, 0xfb, 0x53, 0xc2, 0xc9, 0xcf, 0x08, 0x67, 0x03,
+	0x16, 0xce, 0x1d, 0xcf, 0xf6, 0xcf, 0xe5, 0x09, 0x2f, 0x47, 0xdc, 0xb5, 0xe0, 0x71, 0xc7, 0xef,
+	0x7e, 0xa4, 0x97, 0x42, 0x00, 0x5e, 0xfe, 0xd4, 0x19, 0x94, 0x53, 0x99, 0xb9, 0x72, 0x81, 0xb9,
+	0xd8, 0x05, 0xbe, 0x01, 0x55, 0x1e, 0x6f, 0x26, 0xfc, 0x89, 0xcc, 0x93, 0x10, 0x1c, 0x3b, 0x14,
+	0xdc, 0xf8, 0xd4, 0x55, 0x82, 0x90, 0x7e, 0x25, 0x4c, 0x5d, 0x21, 0xd4, 0x7f, 0x37, 0x07, 0x10,
+	0xd7, 0x9d, 0x70, 0xdb, 0x5e, 0x38, 0x52, 0x17, 0x4f, 0xe2, 0xc5, 0x4b, 0x5e, 0x38, 0x92, 0x57,
+	0x4e, 0xef, 0x08, 0x2b, 0xf5, 0x8f, 0x8f, 0x03, 0x1a, 0xa6, 0xae, 0x92, 0xcb, 0x7a, 0x6d, 0x68,
+	0x5e, 0xf4, 0x38, 0x42, 0x69, 0xd5, 0x23, 0xa8, 0xcd, 0x14, 0xb8, 0xa5, 0x45, 0x3b, 0xe9, 0xba,
+	0x76, 0xfd, 0xef, 0xf2, 0xb0, 0x14, 0xd5, 0x30, 0xf1, 0x6c, 0xe7, 0xa9, 0x72, 0x6a, 0x19, 0x80,
+	0x20, 0xb9, 0x8e, 0xef, 0xc0, 0x9a, 0xea, 0x70, 0xf4, 0x43, 0x23, 0xf0, 0xd5, 0xa5, 0x56, 0x3e,
+	0x99, 0x82, 0xed, 0xf9, 0xe1, 0x81, 0x1f, 0x5d, 0x6c, 0xdd, 0xe1, 0x33, 0x8e, 0x68, 0xea, 0x5b,
+	0xbe, 0xe4, 0x69, 0xbb, 0x81, 0x04, 0xfb, 0x34, 0xd9, 0x7d, 0xcb, 0x59, 0xf9, 0x1e, 0xac, 0x25,
+	0x32, 0x53, 0x7e, 0x3d, 0x99, 0x68, 0x7b, 0x23, 0x31, 0x0e, 0xef, 0x28, 0x79, 0x69, 0x1b, 0xbd,
+	0xf9, 0x89, 0xcf, 0x42, 0xd7, 0x39, 0xa3, 0x76, 0x7c, 0x35, 0x37, 0x2f, 0xbd, 0x79, 0x84, 0x52,
+	0xb7, 0x73, 0xef, 0x02, 0x09, 0x44, 0xee, 0x6f, 0x88, 0xb8, 0xe2, 0xd8, 0x91, 0x5f, 0x81, 0x20,
+	0xb9, 0xc0, 0x74, 0x23, 0x04, 0x0f, 0xe4, 0x98, 0x2b, 0x96, 0xbe, 0x28, 0x03, 0x39, 0xe6, 0xe2,
+	0x5a, 0xeb, 0x3f, 0x82, 0x95, 0x99, 0xeb, 0xf5, 0x0c, 0x07, 0xd4, 0x4c, 0x3a, 0xa0, 0x99, 0x32,
+	0x64, 0x9c, 0x7e, 0xfc, 0x1f, 0x0c, 0xd0, 0xbb, 0x70, 0xf7, 0x05, 0xb7, 0x0d, 0x37, 0x9a, 0x8a,
+	0xc2, 0x46, 0x76, 0xad, 0x2f, 0x63, 0x96, 0x0f, 0xd3, 0x1c, 0x7b, 0xe5, 0x8a, 0x23, 0x23, 0xf9,
+	0x9a, 0x2f, 0xa1, 0x94, 0x2c, 0xd6, 0x65, 0x4c, 0xfe, 0x76, 0x7a, 0xf2, 0xf5, 0xa9, 0x4a, 0x9f,
+	0x38, 0x0f, 0x12, 0x53, 0xbe, 0xf5, 0x0b, 0xf5, 0x61, 0xbf, 0xbc, 0xa6, 0x5a, 0x81, 0xf2, 0xe1,
+	0xde, 0xf6, 0x5e, 0xef, 0xab, 0x3d, 0xa3, 0xa3, 0xeb, 0x3d, 0xbd, 0x76, 0x0b, 0x41, 0xfd, 0xde,
+	0x76, 0x67, 0xcf, 0xe8, 0xfc, 0x70, 0xbf, 0xab, 0x77, 0xb6, 0x6a, 0x39, 0xb2, 0x0a, 0xd5, 0xad,
+	0xde, 0x6e, 0xab, 0xbb, 0x67, 0xec, 0x76, 0x0f, 0x76, 0x5b, 0xfd, 0xf6, 0xb3, 0x5a, 0x9e, 0xac,
+	0x41, 0x6d, 0xbf, 0xb7, 0xd3, 0x6d, 0xff, 0xc8, 0x78, 0xde, 0xed, 0xed, 0xb4, 0xfa, 0xdd, 0xde,
+	0x5e, 0xad, 0x10, 0x3f, 0xdd, 0xdd, 0x7b, 0xde, 0xda, 0xe9, 0x6e, 0xd5, 0xe6, 0x08, 0x81, 0x4a,
+	0x7b, 0xa7, 0xdb, 0xd9, 0xeb, 0x1b, 0xfd, 0x5e, 0xcf, 0xe8, 0xed, 0x6c, 0xd5, 0xe6, 0xc9, 0x3a,
+	0xac, 0xec, 0x76, 0x0e, 0x0e, 0x5a, 0x4f, 0x3b, 0x1c, 0xb8, 0xd3, 0xd2, 0x9f, 0x76, 0x6a, 0x0b,
+	0x6f, 0x7d, 0x0f, 0x2a, 0xe9, 0xd6, 0x2a, 0x52, 0x82, 0x62, 0x77, 0xcb, 0xe0, 0x53, 0xd6, 0x6e,
+	0xe1, 0x68, 0xbb, 0xa3, 0x6f, 0x76, 0xf4, 0xde, 0x41, 0x2d, 0x47, 0x2a, 0x00, 0xdb, 0x87, 0x9b,
+	0x1d, 0x7d, 0xaf, 0xd3, 0xef, 0x1c, 0xd4, 0xf2, 0x6f, 0xfd, 0x65, 0x1e, 0x4a, 0xc9, 0x86, 0x27,
+	0xb2, 0x00, 0xf9, 0xde, 0x76, 0xed, 0x16, 0x2e, 0x55, 0x2e, 0xc7, 0x88, 0x26, 0xcb, 0x21, 0x74,
+	0xaf, 0x67, 0xb4, 0x3b, 0x7a, 0xff, 0xc0, 0x68, 0xed, 0xec, 0xf4, 0xbe, 0xea, 0x6c, 0xd5, 0xf2,
+	0xa4, 0x06, 0x25, 0xbd, 0xd5, 0xef, 0x18, 0x3b, 0xdd, 0xdd, 0x6e, 0xbf, 0xb3, 0x55, 0x2b, 0xe0,
+	0xfa, 0xf7, 0x7a, 0x7d, 0xa3, 0x75, 0xd8, 0x7f, 0xd6, 0xd3, 0xbb, 0x3f, 0xee, 0xe0, 0x9e, 0x56,
+	0xa1, 0xaa, 0x77, 0x10, 0x62, 0xe8, 0x9d, 0x2f, 0x0f, 0x39, 0x9b, 0xe6, 0x71, 0xc2, 0xd6, 0xfe,
+	0xbe, 0xde, 0x7b, 0xde, 0xda, 0x31, 0xf6, 0x3b, 0x7b, 0x5b, 0xdd, 0xbd, 0xa7, 0xb5, 0x05, 0x49,
+	0x7a, 0xd0, 0xdb, 0x8b, 0x49, 0x17, 0x91, 0xf4, 0x70, 0xff, 0xa9, 0xde, 0xda, 0xea, 0xc4, 0xd0,
+	0x22, 0xbe, 0x09, 0xb9, 0xb1, 0xdb, 0xda, 0xfb, 0x91, 0x58, 0x57, 0x6d, 0x89, 0xdc, 0x86, 0xd5,
+	0xad, 0xce, 0xf3, 0x6e, 0xbb, 0x63, 0xe0, 0x22, 0x3a, 0x7b, 0x7a, 0x6f, 0x67, 0xa7, 0xb3, 0x55,
+	0x03, 0xa2, 0xc1, 0x5a, 0x02, 0xd1, 0xee, 0xed, 0xee, 0xef, 0x74, 0x5b, 0x7b, 0xfd, 0xda, 0x32,
+	0xbe, 0xb1, 0xdf, 0x6d, 0x6f, 0x77, 0xfa, 0x86, 0xde, 0xf9, 0xa2, 0xd3, 0xc6, 0x5d, 0x94, 0x70,
+	0x9e, 0xbd, 0x4e, 0xff, 0xab, 0x9e, 0xbe, 0xcd, 0xe9, 0xd5, 0x86, 0xcb, 0x8f, 0xff, 0x62, 0x01,
+	0xca, 0x4f, 0x29, 0x6f, 0xe3, 0x91, 0x3e, 0xf2, 0x03, 0x58, 0x7e, 0x4a, 0x43, 0xf5, 0x15, 0x36,
+	0xa9, 0x35, 0xa7, 0xfe, 0x18, 0xa1, 0xbe, 0x32, 0xf3, 0x89, 0x76, 0xe3, 0x16, 0xf9, 0x08, 0x20,
+	0xfe, 0x0e, 0x8c, 0x90, 0xe6, 0xcc, 0x77, 0x7d, 0xf5, 0xd5, 0xe6, 0xec, 0x87, 0x62, 0x8d, 0x5b,
+	0xe4, 0x07, 0x50, 0x4e, 0x7d, 0xad, 0x44, 0xd6, 0x9b, 0x59, 0x9f, 0x7a, 0xd5, 0x37, 0x9a, 0x99,
+	0x1f, 0x35, 0x35, 0x6e, 0x91, 0x36, 0x54, 0xd2, 0x9f, 0xf5, 0x90, 0x8d, 0x66, 0xe6, 0x07, 0x49,
+	0xf5, 0xdb, 0xcd, 0xec, 0xef, 0x7f, 0x1a, 0xb7, 0xc8, 0xa7, 0x50, 0xdd, 0x4c, 0x5d, 0x38, 0x07,
+	0x84, 0x34, 0x67, 0x3e, 0x7a, 0xc8, 0xde, 0xfb, 0xfb, 0xf2, 0xb3, 0x20, 0xd1, 0x65, 0x11, 0x90,
+	0x72, 0x33, 0xf9, 0x95, 0x50, 0xbd, 0x94, 0xfc, 0x20, 0xa6, 0x71, 0xeb, 0x61, 0xee, 0xbd, 0x1c,
+	0xf9, 0x04, 0xaa, 0xe2, 0x5b, 0x84, 0xf8, 0x32, 0xb2, 0xd6, 0x9c, 0xfa, 0x4c, 0xa1, 0x4e, 0x9a,
+	0x33, 0x5f, 0x13, 0x34, 0x6e, 0x91, 0x2e, 0xd4, 0xa6, 0x3b, 0xda, 0x89, 0xd6, 0xbc, 0xe4, 0xdb,
+	0x81, 0xfa, 0x9d, 0xe6, 0x65, 0xed, 0xef, 0x8d, 0x5b, 0xe4, 0x33, 0xfc, 0xa4, 0xd8, 0xa6, 0x74,
+	0x18, 0xf7, 0x9d, 0x13, 0xd2, 0x9c, 0xe9, 0x56, 0xaf, 0xaf, 0x36, 0x67, 0x1b, 0xd3, 0xf9, 0xe3,
+	0xa5, 0x64, 0x3b, 0x35, 0x59, 0x6b, 0x66, 0xb4, 0x99, 0xd7, 0xd7, 0x9b, 0x59, 0x3d, 0xd7, 0xe2,
+	0xf1, 0x64, 0x3f, 0x32, 0x59, 0x6b, 0x66, 0xf4, 0x4f, 0xd7, 0xd7, 0x9b, 0x59, 0x4d, 0xcb, 0x42,
+	0xe3, 0x78, 0xc2, 0xb2, 0x29, 0x3e, 0xb5, 0x6d, 0xce, 0xb4, 0x1a, 0xd7, 0x57, 0x9b, 0xb3, 0x9d,
+	0xb6, 0x42, 0xe3, 0x52, 0xbd, 0x81, 0x64, 0xbd, 0x99, 0xd5, 0x1c, 0x5a, 0xdf, 0xc8, 0x6e, 0x21,
+	0x6c, 0xdc, 0x7a, 0xfc, 0x57, 0x0b, 0x50, 0x4d, 0x19, 0xcd, 0xf3, 0xc7, 0xbf, 0x36, 0x9b, 0x5f,
+	0x9b, 0xcd, 0xaf, 0xcd, 0xe6, 0x85, 0x66, 0x73, 0xb4, 0xc0, 0x93, 0xae, 0xef, 0xfe, 0xcf, 0x00,
+	0x40, 0x3d, 0xc4, 0x6f, 0x6b, 0x48, 0x00, 0x00,
 }
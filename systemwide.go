@@ -0,0 +1,119 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Where SystemWide installs the CAs and ssh config, which ssh reads for every user.
+const (
+	SystemKnownHostsPath = "/etc/ssh/ssh_known_hosts"
+	SystemSSHConfigPath  = "/etc/ssh/ssh_config.d/geecert.conf"
+)
+
+// The system ssh config, which must include SystemSSHConfigPath for ssh to read it.
+const systemSSHConfig = "/etc/ssh/ssh_config"
+
+var (
+	ErrSystemWideNeedsRoot    = errors.New("Installing for all users needs root, please run with sudo.")
+	ErrSystemWideNotSupported = errors.New("Installing for all users is not supported on Windows.")
+)
+
+// Returns an error if config.SystemWide is set but we can't write the system files.
+func checkSystemWide(config *ClientAppConfiguration) error {
+	if !config.SystemWide {
+		return nil
+	}
+	if runtime.GOOS == "windows" {
+		return ErrSystemWideNotSupported
+	}
+	if os.Geteuid() != 0 {
+		return ErrSystemWideNeedsRoot
+	}
+	return nil
+}
+
+// Returns true if the system ssh config includes path, e.g. with Include
+// /etc/ssh/ssh_config.d/*.conf as most distributions have, so that ssh reads it.
+func systemSSHConfigIncludes(path string) bool {
+	contents, err := os.ReadFile(systemSSHConfig)
+	return err == nil && sshConfigIncludes(contents, filepath.Dir(systemSSHConfig), path)
+}
+
+func includeRemediation(path string) string {
+	return fmt.Sprintf("Add this line at the top of %s: Include %s", systemSSHConfig, filepath.Join(filepath.Dir(path), "*.conf"))
+}
+
+func warnIfNotIncluded(path string) {
+	if !systemSSHConfigIncludes(path) {
+		logWarning("%s doesn't include %s, so ssh won't use it. %s", systemSSHConfig, path, includeRemediation(path))
+	}
+}
+
+// Returns true if an Include line in contents matches path. Relative patterns are relative
+// to dir, as for the system config.
+func sshConfigIncludes(contents []byte, dir, path string) bool {
+	lines, _ := splitLines(contents)
+	for _, line := range lines {
+		opt := ParseSSHConfigOption(line)
+		if !strings.EqualFold(opt.Keyword, "Include") {
+			continue
+		}
+		for _, pattern := range strings.Fields(opt.Value) {
+			if !filepath.IsAbs(pattern) {
+				pattern = filepath.Join(dir, pattern)
+			}
+			if ok, _ := filepath.Match(pattern, path); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Keywords in config from the server that are set per user, so are left out of the
+// system-wide config.
+var perUserSSHConfigKeywords = map[string]bool{
+	"user":            true,
+	"identityfile":    true,
+	"identitiesonly":  true,
+	"certificatefile": true,
+}
+
+// Returns the config lines that are the same for every user, leaving out per-user settings
+// and any line that refers to a variable such as $EMAIL or $HOME, which would be the values
+// for the user the client runs as.
+func systemWideConfigLines(lines []string) []string {
+	var rv []string
+	for _, line := range lines {
+		opt := ParseSSHConfigOption(line)
+		if perUserSSHConfigKeywords[strings.ToLower(opt.Keyword)] || configVariableRef.MatchString(line) {
+			logVerbose("Leaving per-user line out of the system-wide ssh config: %s", strings.TrimSpace(line))
+			continue
+		}
+		rv = append(rv, line)
+	}
+	return rv
+}
@@ -0,0 +1,136 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"time"
+
+	pb "github.com/continusec/geecert/sso"
+)
+
+const telemetryTimeout = 5 * time.Second
+
+// Commands that report telemetry when enabled. Admin and diagnostic commands don't.
+var telemetryCommands = map[string]bool{
+	"fetch":                  true,
+	renewIfNeededCommandName: true,
+	"break-glass":            true,
+	"update":                 true,
+}
+
+// Returns the name of the class of outcome of a command, as per ExitCode.
+func telemetryOutcome(err error) string {
+	switch ExitCode(err) {
+	case ExitOK:
+		return "ok"
+	case ExitUsage:
+		return "usage"
+	case ExitUserDenied:
+		return "user_denied"
+	case ExitPolicyFailed:
+		return "policy_failed"
+	case ExitServerError:
+		return "server_error"
+	case ExitTokenExpired:
+		return "token_expired"
+	case ExitPartialInstall:
+		return "partial_install"
+	}
+	return "error"
+}
+
+// If telemetry is enabled, tells the server the outcome of a command, along with our
+// platform and version. Nothing identifying the user or machine is sent. Failures are ignored.
+func sendTelemetry(ctx context.Context, config *ClientAppConfiguration, command string, cmdErr error) {
+	if !config.Telemetry || !telemetryCommands[command] {
+		return
+	}
+
+	conn, err := DialServer(ctx, config)
+	if err != nil {
+		logVerbose("Unable to send telemetry: %s", err)
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, telemetryTimeout)
+	defer cancel()
+
+	_, err = NewClient(conn).ReportTelemetry(ctx, &pb.TelemetryReport{
+		Os:      platform(),
+		Arch:    runtime.GOARCH,
+		Command: command,
+		Outcome: telemetryOutcome(cmdErr),
+	})
+	if err != nil {
+		logVerbose("Unable to send telemetry: %s", err)
+	}
+}
+
+// telemetry
+// Prints counts of outcomes reported by clients. Requires that our user is an admin on the server.
+func telemetryCommand(ctx context.Context, config *ClientAppConfiguration, args []string) error {
+	if len(args) != 0 {
+		return ErrUsage
+	}
+
+	summary, err := TelemetrySummary(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Reports since %s:\n\n", time.Unix(summary.Since, 0).Format(time.RFC3339))
+	if len(summary.Counts) == 0 {
+		fmt.Println("No telemetry received.")
+		return nil
+	}
+
+	fmt.Printf("%8s  %-20s  %-10s  %-8s  %-7s  %-16s  %-15s  %s\n", "COUNT", "CLIENT", "VERSION", "OS", "ARCH", "COMMAND", "OUTCOME", "LAST SEEN")
+	for _, c := range summary.Counts {
+		fmt.Printf("%8d  %-20s  %-10s  %-8s  %-7s  %-16s  %-15s  %s\n", c.Count, c.ClientName, c.ClientVersion, c.Os, c.Arch, c.Command, c.Outcome, time.Unix(c.LastSeen, 0).Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Fetch counts of telemetry reports received by the server. Requires that our user is an admin on the server.
+func TelemetrySummary(ctx context.Context, config *ClientAppConfiguration) (*pb.TelemetrySummaryResponse, error) {
+	idToken, _, err := GetValidIDToken(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := DialServer(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	resp, err := NewClient(conn).TelemetrySummary(ctx, &pb.TelemetrySummaryRequest{IdToken: idToken, CredentialType: credentialType(config)})
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Status != pb.ResponseCode_OK {
+		return nil, responseCodeError(resp.Status)
+	}
+	return resp, nil
+}
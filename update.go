@@ -0,0 +1,298 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const updateCheckInterval = 24 * time.Hour // how often to check for updates if AutoCheckForUpdates is set
+
+var (
+	ErrNoUpdateURL           = errors.New("No update manifest URL configured.")
+	ErrBadUpdateSignature    = errors.New("Update manifest signature is not valid.")
+	ErrNoUpdateForPlatform   = errors.New("Update manifest has no binary for this platform.")
+	ErrUpdateChecksumInvalid = errors.New("Downloaded binary does not match checksum in update manifest.")
+)
+
+// Describes the latest release of the client, as published at UpdateManifestURL.
+type UpdateManifest struct {
+	Version  string                   `json:"version"`
+	Binaries map[string]*UpdateBinary `json:"binaries"` // keyed by GOOS-GOARCH, e.g. linux-amd64
+}
+
+type UpdateBinary struct {
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"` // hex encoded
+}
+
+// The manifest as served, with a signature over the manifest bytes.
+type signedUpdateManifest struct {
+	Manifest  []byte `json:"manifest"`
+	Signature []byte `json:"signature"` // ssh wire format signature
+}
+
+func updateSignedData(manifest []byte) []byte {
+	return append([]byte("geecert-update-v1\n"), manifest...)
+}
+
+// Signs a JSON encoded UpdateManifest, returning the document to publish at UpdateManifestURL.
+func SignUpdateManifest(signer ssh.Signer, manifest []byte) ([]byte, error) {
+	um := &UpdateManifest{}
+	err := json.Unmarshal(manifest, um)
+	if err != nil {
+		return nil, err
+	}
+	var sig *ssh.Signature
+	if as, ok := signer.(ssh.AlgorithmSigner); ok && signer.PublicKey().Type() == ssh.KeyAlgoRSA {
+		sig, err = as.SignWithAlgorithm(rand.Reader, updateSignedData(manifest), ssh.KeyAlgoRSASHA512)
+	} else {
+		sig, err = signer.Sign(rand.Reader, updateSignedData(manifest))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(&signedUpdateManifest{
+		Manifest:  manifest,
+		Signature: ssh.Marshal(sig),
+	}, "", "  ")
+}
+
+// Verifies a document produced by SignUpdateManifest against the public key (authorized_keys format).
+func VerifyUpdateManifest(publicKey string, signed []byte) (*UpdateManifest, error) {
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(publicKey))
+	if err != nil {
+		return nil, err
+	}
+
+	sm := &signedUpdateManifest{}
+	err = json.Unmarshal(signed, sm)
+	if err != nil {
+		return nil, err
+	}
+
+	sig := &ssh.Signature{}
+	err = ssh.Unmarshal(sm.Signature, sig)
+	if err != nil {
+		return nil, ErrBadUpdateSignature
+	}
+	// SHA-1 signatures are refused, so RSA keys must sign with rsa-sha2-256 or rsa-sha2-512
+	if pub.Type() == ssh.KeyAlgoRSA && sig.Format != ssh.KeyAlgoRSASHA256 && sig.Format != ssh.KeyAlgoRSASHA512 {
+		return nil, ErrBadUpdateSignature
+	}
+	err = pub.Verify(updateSignedData(sm.Manifest), sig)
+	if err != nil {
+		return nil, ErrBadUpdateSignature
+	}
+
+	um := &UpdateManifest{}
+	err = json.Unmarshal(sm.Manifest, um)
+	if err != nil {
+		return nil, err
+	}
+	return um, nil
+}
+
+// Fetches and verifies the update manifest from UpdateManifestURL.
+func FetchUpdateManifest(ctx context.Context, config *ClientAppConfiguration) (*UpdateManifest, error) {
+	ctx = withDialer(ctx, config)
+	if len(config.UpdateManifestURL) == 0 || len(config.UpdatePublicKey) == 0 {
+		return nil, ErrNoUpdateURL
+	}
+
+	resp, err := httpGet(ctx, config.UpdateManifestURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("Unexpected status fetching update manifest: %s", resp.Status))
+	}
+
+	signed, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	return VerifyUpdateManifest(config.UpdatePublicKey, signed)
+}
+
+// update [-check]
+// Replaces the running binary with the latest release, or with -check, only reports if there is one.
+func updateCommand(ctx context.Context, config *ClientAppConfiguration, args []string) error {
+	checkOnly := false
+	switch {
+	case len(args) == 1 && args[0] == "-check":
+		checkOnly = true
+	case len(args) != 0:
+		return ErrUsage
+	}
+
+	um, err := FetchUpdateManifest(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	if CompareVersions(um.Version, clientVersion(config)) <= 0 {
+		fmt.Printf("Already up to date (%s).\n", clientVersion(config))
+		return nil
+	}
+
+	if checkOnly {
+		fmt.Printf("Version %s is available, currently running %s.\n", um.Version, clientVersion(config))
+		return nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	exe, err = filepath.EvalSymlinks(exe)
+	if err != nil {
+		return err
+	}
+
+	logInfo("Updating %s from %s to %s.", exe, clientVersion(config), um.Version)
+	err = InstallUpdate(ctx, um, exe)
+	if err != nil {
+		return err
+	}
+	logInfo("Update installed.")
+	return nil
+}
+
+// Downloads the binary for this platform from the manifest, checks it against the manifest's
+// checksum and atomically replaces the file at path with it.
+func InstallUpdate(ctx context.Context, um *UpdateManifest, path string) error {
+	bin, ok := um.Binaries[runtime.GOOS+"-"+runtime.GOARCH]
+	if !ok {
+		return ErrNoUpdateForPlatform
+	}
+	want, err := hex.DecodeString(bin.SHA256)
+	if err != nil || len(want) != sha256.Size {
+		return ErrUpdateChecksumInvalid
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	// Download alongside the binary so that the rename below stays within a filesystem
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".update-")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // no-op once renamed
+
+	err = downloadBinary(ctx, bin.URL, want, tmp)
+	if err == nil {
+		err = tmp.Chmod(fi.Mode())
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	if runtime.GOOS == "windows" {
+		// A running executable can't be replaced on Windows, but it can be renamed
+		oldPath := path + ".old"
+		os.Remove(oldPath)
+		err = os.Rename(path, oldPath)
+		if err != nil {
+			return err
+		}
+		err = os.Rename(tmpPath, path)
+		if err != nil {
+			os.Rename(oldPath, path)
+			return err
+		}
+		return nil
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+func downloadBinary(ctx context.Context, url string, want []byte, w io.Writer) error {
+	resp, err := httpGet(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(fmt.Sprintf("Unexpected status downloading update: %s", resp.Status))
+	}
+
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(w, h), resp.Body)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(h.Sum(nil), want) {
+		return ErrUpdateChecksumInvalid
+	}
+	return nil
+}
+
+// If AutoCheckForUpdates is set and we haven't checked recently, tells the user if a newer
+// version is available. Errors are logged rather than returned, as they shouldn't stop the client.
+func autoCheckForUpdates(ctx context.Context, config *ClientAppConfiguration) {
+	if !config.AutoCheckForUpdates || len(config.UpdateManifestURL) == 0 {
+		return
+	}
+
+	path, err := credentialsPath(config)
+	if err != nil {
+		return
+	}
+	stampPath := path + "-update-check"
+	fi, err := os.Stat(stampPath)
+	if err == nil && time.Since(fi.ModTime()) < updateCheckInterval {
+		return
+	}
+	os.WriteFile(stampPath, nil, 0600)
+	os.Chtimes(stampPath, time.Now(), time.Now())
+
+	um, err := FetchUpdateManifest(ctx, config)
+	if err != nil {
+		logWarning("Unable to check for updates: %s", err)
+		return
+	}
+	if CompareVersions(um.Version, clientVersion(config)) > 0 {
+		logInfo("Version %s is available, run with the update command to install it.", um.Version)
+	}
+}
@@ -0,0 +1,93 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// Version of this library, sent to the server as the client version unless overridden by
+// ClientAppConfiguration.ClientVersion.
+const Version = "0.2.0"
+
+// Metadata sent with each request to the server.
+const (
+	ClientNameMetadata    = "geecert-client-name"
+	ClientVersionMetadata = "geecert-client-version"
+)
+
+// Compares dotted version numbers such as "1.2.10", returning -1, 0 or 1 if a is older, the
+// same as or newer than b. Missing parts count as 0, and any suffix on a part (e.g. "3-beta") is ignored.
+func CompareVersions(a, b string) int {
+	as := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		an, bn := versionPart(as, i), versionPart(bs, i)
+		if an < bn {
+			return -1
+		}
+		if an > bn {
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionPart(parts []string, i int) int {
+	if i >= len(parts) {
+		return 0
+	}
+	p := parts[i]
+	end := 0
+	for end < len(p) && p[end] >= '0' && p[end] <= '9' {
+		end++
+	}
+	n, _ := strconv.Atoi(p[:end])
+	return n
+}
+
+func clientVersion(config *ClientAppConfiguration) string {
+	if len(config.ClientVersion) > 0 {
+		return config.ClientVersion
+	}
+	return Version
+}
+
+// Adds our name and version to the metadata sent with a request.
+func withClientVersion(ctx context.Context, config *ClientAppConfiguration) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, ClientNameMetadata, config.ClientName, ClientVersionMetadata, clientVersion(config))
+}
+
+// version
+func versionCommand(ctx context.Context, config *ClientAppConfiguration, args []string) error {
+	if len(args) != 0 {
+		return ErrUsage
+	}
+	name := config.ClientName
+	if len(name) == 0 {
+		name = "geecert"
+	}
+	fmt.Printf("%s %s\n", name, clientVersion(config))
+	return nil
+}
@@ -0,0 +1,101 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+// VS Code settings are JSON with comments, so rather than parse the whole file
+// we just look for the one setting that we care about.
+var vscodeConfigFileSetting = regexp.MustCompile(`"remote\.SSH\.configFile"\s*:\s*("(?:[^"\\]|\\.)*")`)
+
+// Returns the path to the VS Code user settings file.
+func vscodeSettingsPath() (string, error) {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("APPDATA"), "Code", "User", "settings.json"), nil
+	}
+
+	hd, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	if runtime.GOOS == "darwin" {
+		return filepath.Join(hd, "Library", "Application Support", "Code", "User", "settings.json"), nil
+	}
+	return filepath.Join(hd, ".config", "Code", "User", "settings.json"), nil
+}
+
+// Returns the ssh config file that VS Code Remote SSH has been configured to use
+// instead of the default, or "" if none.
+func VSCodeSSHConfigFile() (string, error) {
+	path, err := vscodeSettingsPath()
+	if err != nil {
+		return "", err
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	m := vscodeConfigFileSetting.FindSubmatch(contents)
+	if m == nil {
+		return "", nil
+	}
+
+	var configFile string
+	err = json.Unmarshal(m[1], &configFile)
+	if err != nil {
+		return "", err
+	}
+
+	return homedir.Expand(configFile)
+}
+
+// If VS Code Remote SSH uses an ssh config file other than defaultConfigPath,
+// add our section to that file too.
+func UpdateVSCodeSSHConfig(ctx context.Context, config *ClientAppConfiguration, defaultConfigPath string, lines []string) error {
+	configFile, err := VSCodeSSHConfigFile()
+	if err != nil {
+		return err
+	}
+
+	if config.FollowSymlinks {
+		configFile, err = followSymlinks(configFile)
+		if err != nil {
+			return err
+		}
+	}
+	if configFile == "" || filepath.Clean(configFile) == filepath.Clean(defaultConfigPath) {
+		return nil
+	}
+
+	return ReplaceSectionOfSSHConfig(ctx, config.SectionIdentifier, configFile, lines, 0644, "Updating VS Code Remote SSH config file to use certificates.")
+}
@@ -0,0 +1,276 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	pb "github.com/continusec/geecert/sso"
+)
+
+const (
+	watchCheckInterval = time.Minute      // how often to check the certificate and ID token
+	watchRetryMax      = 10 * time.Minute // longest wait before reconnecting after an error
+)
+
+// watch
+// Runs until killed, keeping the certificate fresh and applying updates pushed by the server.
+func watchCommand(ctx context.Context, config *ClientAppConfiguration, args []string) error {
+	if len(args) != 0 {
+		return ErrUsage
+	}
+
+	wake := Wakeups(ctx)
+	retry := time.Second
+	for {
+		start := time.Now()
+		err := Watch(ctx, config)
+		var se *ServerError
+		if errors.As(err, &se) && se.Status == pb.ResponseCode_NO_CERTS_ALLOWED {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if time.Since(start) > watchRetryMax {
+			retry = time.Second
+		}
+		notifyIfExpiring(config)
+		logInfo("Lost connection to server (%s), reconnecting in %s.", err, retry)
+		// Don't hold up startup while the server can't be reached
+		sdNotify(fmt.Sprintf("READY=1\nSTATUS=Lost connection to server (%s), reconnecting.", err))
+		select {
+		case <-time.After(retry):
+		case slept := <-wake:
+			// The network is often back by now, so try again rather than wait out the backoff
+			logInfo("Woke after sleeping for %s, reconnecting.", slept.Round(time.Second))
+			retry = time.Second
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		retry *= 2
+		if retry > watchRetryMax {
+			retry = watchRetryMax
+		}
+	}
+}
+
+// Holds a session with the server, renewing the certificate before it expires, and applying
+// any updates to CAs and config that the server sends. Returns when the session ends.
+func Watch(ctx context.Context, config *ClientAppConfiguration) error {
+	err := EnsureFreshCert(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	idToken, _, err := GetValidIDToken(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	conn, err := DialServer(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := NewClient(conn).WatchUpdates(ctx)
+	if err != nil {
+		return err
+	}
+	err = stream.Send(&pb.WatchRequest{IdToken: idToken, CredentialType: credentialType(config)})
+	if err != nil {
+		return err
+	}
+	sdNotify("READY=1\nSTATUS=Connected to server.")
+
+	updates := make(chan *pb.WatchUpdate)
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			u, err := stream.Recv()
+			if err != nil {
+				errs <- err
+				return
+			}
+			select {
+			case updates <- u:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	check := func() error {
+		notifyIfExpiring(config)
+		err := EnsureFreshCert(ctx, config)
+		if err != nil {
+			return err
+		}
+
+		// Send a fresh ID token before ours expires, refreshing it if needed
+		newIDToken, _, err := GetValidIDToken(ctx, config)
+		if err != nil {
+			return err
+		}
+		if newIDToken != idToken {
+			idToken = newIDToken
+			return stream.Send(&pb.WatchRequest{IdToken: idToken, CredentialType: credentialType(config)})
+		}
+		return nil
+	}
+
+	wake := Wakeups(ctx)
+	ticker := time.NewTicker(watchCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case u := <-updates:
+			if u.Status != pb.ResponseCode_OK {
+				return responseCodeError(u.Status)
+			}
+			err = applyWatchUpdate(ctx, config, u)
+			if err != nil {
+				return err
+			}
+		case err = <-errs:
+			return err
+		case <-ticker.C:
+			err = check()
+			if err != nil {
+				return err
+			}
+		case slept := <-wake:
+			// Don't wait for the ticker, which doesn't count time asleep
+			logInfo("Woke after sleeping for %s, checking the certificate.", slept.Round(time.Second))
+			err = check()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Updates known_hosts, the ssh config file (including realms') and bastion policies as sent by
+// the server, and fetches a new certificate if ours is revoked or signed by a CA that is no
+// longer trusted, or we may now use a realm we have no certificate for.
+// If ours was revoked to make room for another key, it is removed instead.
+func applyWatchUpdate(ctx context.Context, config *ClientAppConfiguration, u *pb.WatchUpdate) error {
+	err := checkSystemWide(config)
+	if err != nil {
+		return err
+	}
+	paths, err := DefaultInstallPaths(config)
+	if err != nil {
+		return err
+	}
+
+	cas := knownHostsLines(u.HostCertificateAuthorities, u.CertificateAuthorities)
+	err = ReplaceSectionOfFile(ctx, config.SectionIdentifier, paths.KnownHosts, cas, 0644, "Updating known_hosts certificate authorities.")
+	if err != nil {
+		return err
+	}
+
+	if len(u.ConfigBlocks) > 0 {
+		cnf, err := sshConfigLines(config, RenderSSHConfigBlocks(u.ConfigBlocks), u.ConfigVariables, paths, filepath.Join("~", ".ssh"))
+		if err != nil {
+			return err
+		}
+		err = ReplaceSectionOfSSHConfig(ctx, config.SectionIdentifier, paths.SSHConfig, cnf, 0644, "Updating ssh config file to use certificates.")
+		if err != nil {
+			return err
+		}
+	}
+
+	// Realms removed by the server are left until the next certificate is fetched, as older
+	// servers don't send realms at all
+	newRealm := false
+	for _, rc := range u.Realms {
+		if _, err := os.Stat(paths.forRealm(rc.Name).Cert); err != nil {
+			newRealm = true
+			continue
+		}
+		err = installRealmConfig(ctx, config, rc, u.ConfigVariables, paths, filepath.Join("~", ".ssh"))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = saveBastionPolicies(ctx, paths, u.BastionPolicies)
+	if err != nil {
+		return err
+	}
+
+	err = applyDirectives(ctx, config, paths, u.Directives)
+	if err != nil {
+		return err
+	}
+
+	cert, err := LoadInstalledCert(config)
+	if err != nil {
+		return err
+	}
+	fingerprint := ssh.FingerprintSHA256(cert.Key)
+	for _, fp := range u.ReplacedFingerprint {
+		if fp == fingerprint {
+			logInfo("Our certificate has been replaced by one for another key, removing it.")
+			return os.Remove(paths.Cert)
+		}
+	}
+	for _, fp := range u.RevokedFingerprint {
+		if fp == fingerprint {
+			logInfo("Our key has been revoked, fetching a new certificate.")
+			return ProcessClient(ctx, config)
+		}
+	}
+	if newRealm {
+		logInfo("We may now use another realm, fetching a new certificate.")
+		return ProcessClient(ctx, config)
+	}
+	if !caTrusted(cas, cert.SignatureKey) {
+		logInfo("Our certificate is signed by a CA that is no longer in use, fetching a new certificate.")
+		return ProcessClient(ctx, config)
+	}
+
+	return nil
+}
+
+// Returns true if ca is in the @cert-authority lines.
+func caTrusted(lines []string, ca ssh.PublicKey) bool {
+	want := base64.StdEncoding.EncodeToString(ca.Marshal())
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) >= 4 && fields[0] == "@cert-authority" && fields[3] == want {
+			return true
+		}
+	}
+	return false
+}
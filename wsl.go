@@ -0,0 +1,86 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package geecert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Named pipe of the Windows OpenSSH agent, the default for WSLAgentPipe.
+const DefaultWSLAgentPipe = "//./pipe/openssh-ssh-agent"
+
+var ErrNoNPipeRelay = errors.New("npiperelay.exe not found in PATH.")
+
+// Returns true if running under the Windows Subsystem for Linux.
+func isWSL() bool {
+	if runtime.GOOS != "linux" {
+		return false
+	}
+	if len(os.Getenv("WSL_DISTRO_NAME")) > 0 {
+		return true
+	}
+	b, err := os.ReadFile("/proc/sys/kernel/osrelease")
+	return err == nil && strings.Contains(strings.ToLower(string(b)), "microsoft")
+}
+
+// Connection to a Windows agent's named pipe via npiperelay.exe's stdin and stdout.
+type npipeRelayConn struct {
+	io.WriteCloser
+	io.Reader
+	cmd *exec.Cmd
+}
+
+func (c *npipeRelayConn) Close() error {
+	c.WriteCloser.Close()
+	return c.cmd.Wait()
+}
+
+// Under WSL with no SSH_AUTH_SOCK, connect to the Windows agent with npiperelay.exe,
+// as used by the common socat bridges, but without needing the bridge to be running.
+func dialWSLAgent(ctx context.Context, config *ClientAppConfiguration) (io.ReadWriteCloser, error) {
+	relay, err := exec.LookPath("npiperelay.exe")
+	if err != nil {
+		return nil, ErrNoNPipeRelay
+	}
+	pipe := config.WSLAgentPipe
+	if len(pipe) == 0 {
+		pipe = DefaultWSLAgentPipe
+	}
+	cmd := exec.CommandContext(ctx, relay, "-ei", "-s", pipe)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	err = cmd.Start()
+	if err != nil {
+		return nil, fmt.Errorf("%w (%w)", ErrAgentUnavailable, err)
+	}
+	return &npipeRelayConn{WriteCloser: stdin, Reader: stdout, cmd: cmd}, nil
+}